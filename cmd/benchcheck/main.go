@@ -0,0 +1,137 @@
+// Command benchcheck compares two `go test -bench` outputs and fails when
+// any benchmark regressed beyond the allowed threshold.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// result holds the averaged metrics of a benchmark across runs
+type result struct {
+	nsPerOp     float64
+	allocsPerOp float64
+	runs        int
+}
+
+func main() {
+	baselinePath := flag.String("baseline", "build/bench-baseline.txt", "Path to baseline benchmark output")
+	currentPath := flag.String("current", "build/bench-current.txt", "Path to current benchmark output")
+	threshold := flag.Float64("threshold", 20, "Maximum allowed regression in percent")
+	flag.Parse()
+
+	baseline, err := parseFile(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read baseline: %v\n", err)
+		os.Exit(2)
+	}
+	current, err := parseFile(*currentPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read current results: %v\n", err)
+		os.Exit(2)
+	}
+
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	regressed := false
+	fmt.Printf("%-60s %14s %14s %8s %10s\n", "benchmark", "old ns/op", "new ns/op", "delta", "allocs")
+	for _, name := range names {
+		cur := current[name]
+		base, ok := baseline[name]
+		if !ok {
+			fmt.Printf("%-60s %14s %14.0f %8s %10s\n", name, "-", cur.nsPerOp, "new", "-")
+			continue
+		}
+
+		timeDelta := percentChange(base.nsPerOp, cur.nsPerOp)
+		allocDelta := percentChange(base.allocsPerOp, cur.allocsPerOp)
+		status := ""
+		if timeDelta > *threshold || allocDelta > *threshold {
+			status = "  REGRESSION"
+			regressed = true
+		}
+		fmt.Printf("%-60s %14.0f %14.0f %+7.1f%% %+9.1f%%%s\n",
+			name, base.nsPerOp, cur.nsPerOp, timeDelta, allocDelta, status)
+	}
+
+	if regressed {
+		fmt.Fprintf(os.Stderr, "benchmarks regressed by more than %.0f%%\n", *threshold)
+		os.Exit(1)
+	}
+}
+
+// parseFile reads benchmark lines from a `go test -bench` output file,
+// averaging repeated runs of the same benchmark (e.g. from -count)
+func parseFile(path string) (map[string]*result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	results := make(map[string]*result)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		name := trimProcs(fields[0])
+		var ns, allocs float64
+		var haveNs bool
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				ns, haveNs = value, true
+			case "allocs/op":
+				allocs = value
+			}
+		}
+		if !haveNs {
+			continue
+		}
+
+		r, ok := results[name]
+		if !ok {
+			r = &result{}
+			results[name] = r
+		}
+		r.nsPerOp = (r.nsPerOp*float64(r.runs) + ns) / float64(r.runs+1)
+		r.allocsPerOp = (r.allocsPerOp*float64(r.runs) + allocs) / float64(r.runs+1)
+		r.runs++
+	}
+
+	return results, scanner.Err()
+}
+
+// trimProcs removes the -GOMAXPROCS suffix so results compare across machines
+func trimProcs(name string) string {
+	if i := strings.LastIndex(name, "-"); i > 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+// percentChange returns the relative change from old to new in percent
+func percentChange(old, new float64) float64 {
+	if old == 0 {
+		return 0
+	}
+	return (new - old) / old * 100
+}
@@ -2,22 +2,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/aalobaidi/ggRMCP/pkg/composite"
 	appconfig "github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/descriptors"
+	"github.com/aalobaidi/ggRMCP/pkg/events"
 	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/history"
+	"github.com/aalobaidi/ggRMCP/pkg/inventory"
+	"github.com/aalobaidi/ggRMCP/pkg/selftest"
 	"github.com/aalobaidi/ggRMCP/pkg/server"
 	"github.com/aalobaidi/ggRMCP/pkg/session"
 	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"github.com/aalobaidi/ggRMCP/pkg/transform"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/aalobaidi/ggRMCP/pkg/upstream"
+	"github.com/aalobaidi/ggRMCP/pkg/version"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds application configuration
@@ -28,6 +42,47 @@ type Config struct {
 	LogLevel       string
 	Development    bool
 	DescriptorPath string
+	ConfigPath     string
+
+	// Hex SHA-256 digest the descriptor file must have
+	DescriptorSHA256 string
+
+	// Fail startup on any schema or discovery inconsistency
+	Strict bool
+
+	// Run the self-test and exit instead of serving ("grmcp check")
+	Check bool
+
+	// Print the self-test report as JSON
+	JSON bool
+
+	// Write a report of the backend instead of serving ("grmcp export")
+	Export bool
+
+	// Report written by export
+	Format string
+
+	// Compare two API versions instead of serving ("grmcp diff")
+	Diff bool
+
+	// Descriptor set files compared by diff: old and new, or only the new
+	// one when comparing against the backend's reflection
+	DiffFiles []string
+
+	// Compare the descriptor set with the backend's reflection
+	AgainstReflection bool
+
+	// Print the effective configuration with secrets redacted and exit
+	PrintConfig bool
+
+	// Print the build information and exit
+	Version bool
+
+	// Config subcommand to run instead of serving ("grmcp config schema")
+	ConfigCommand string
+
+	// Flags explicitly set on the command line
+	setFlags map[string]bool
 }
 
 // parseFlags parses command line flags
@@ -40,12 +95,309 @@ func parseFlags() *Config {
 	flag.StringVar(&config.LogLevel, "log-level", "info", "Log level (debug, info, warn, error)")
 	flag.BoolVar(&config.Development, "dev", false, "Enable development mode")
 	flag.StringVar(&config.DescriptorPath, "descriptor", "", "Path to protobuf descriptor file (optional)")
+	flag.StringVar(&config.DescriptorSHA256, "descriptor-sha256", "", "SHA-256 digest the descriptor file must have (optional)")
+	flag.StringVar(&config.ConfigPath, "config", "", "Path to YAML/JSON configuration file (optional)")
+	flag.BoolVar(&config.Strict, "strict", false, "Fail startup on any schema or discovery inconsistency (for CI and staging)")
+	flag.BoolVar(&config.JSON, "json", false, "Print the check or diff report as JSON")
+	flag.BoolVar(&config.AgainstReflection, "against-reflection", false, "Compare the descriptor set with the backend's reflection (with the diff command)")
+	flag.StringVar(&config.Format, "format", "inventory", "Report written by the export command (inventory)")
+	flag.BoolVar(&config.PrintConfig, "print-config", false, "Print the effective configuration with secrets redacted and exit")
+	flag.BoolVar(&config.Version, "version", false, "Print the version, commit and build date and exit")
+
+	// "grmcp check [flags]" runs the self-test instead of serving,
+	// "grmcp export [flags]" writes a report of the backend's tools,
+	// "grmcp diff OLD NEW [flags]" compares two descriptor sets and
+	// "grmcp config <command> [flags]" runs a config subcommand
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "check" {
+		config.Check = true
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "export" {
+		config.Export = true
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "diff" {
+		config.Diff = true
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "config" {
+		config.ConfigCommand = "help"
+		args = args[1:]
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			config.ConfigCommand = args[0]
+			args = args[1:]
+		}
+	}
+	_ = flag.CommandLine.Parse(args)
 
-	flag.Parse()
+	// diff takes file arguments, before or between flags
+	for config.Diff && flag.NArg() > 0 {
+		config.DiffFiles = append(config.DiffFiles, flag.Arg(0))
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
+	config.setFlags = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		config.setFlags[f.Name] = true
+	})
 
 	return config
 }
 
+// applyFlagOverrides applies command line flags on top of the application config.
+// Without a config file all flags apply; with one, only explicitly set flags do.
+func applyFlagOverrides(config *Config, appConfig *appconfig.Config) {
+	override := func(name string) bool {
+		return config.ConfigPath == "" || config.setFlags[name]
+	}
+
+	if override("grpc-host") {
+		appConfig.GRPC.Host = config.GRPCHost
+	}
+	if override("grpc-port") {
+		appConfig.GRPC.Port = config.GRPCPort
+	}
+	if config.DescriptorPath != "" {
+		appConfig.GRPC.DescriptorSet.Enabled = true
+		appConfig.GRPC.DescriptorSet.Path = config.DescriptorPath
+	}
+	if config.DescriptorSHA256 != "" {
+		appConfig.GRPC.DescriptorSet.Verify.SHA256 = config.DescriptorSHA256
+	}
+	if config.Strict {
+		appConfig.GRPC.DescriptorSet.CrossCheck = true
+	}
+}
+
+// strictReport lists the discovery issues and tool build failures that fail
+// startup in strict mode
+func strictReport(discoverer grpc.ServiceDiscoverer, toolBuilder *tools.MCPToolBuilder) []string {
+	var report []string
+	for _, issue := range discoverer.DiscoveryIssues() {
+		report = append(report, issue.String())
+	}
+
+	for _, failure := range toolBuilder.SchemaFailures() {
+		report = append(report, fmt.Sprintf("schema_failure: %s: %s", failure.Method, failure.Error))
+	}
+	return report
+}
+
+// runSelfTest runs the self-test, prints its report and reports whether it passed
+func runSelfTest(ctx context.Context, config *Config, options selftest.Options) bool {
+	report := selftest.Run(ctx, options)
+
+	var err error
+	if config.JSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print self-test report: %v\n", err)
+	}
+	return report.Passed()
+}
+
+// check runs the self-test against the configured backend and returns the
+// process exit code: 0 when it passes, 1 when a check fails
+func check(config *Config, appConfig *appconfig.Config, logger *zap.Logger) int {
+	serviceDiscoverer, err := grpc.NewServiceDiscovererWithConfig(appConfig.GRPC, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create service discoverer: %v\n", err)
+		return 1
+	}
+	defer func() { _ = serviceDiscoverer.Close() }()
+
+	toolBuilder, err := tools.NewMCPToolBuilderWithConfig(logger, appConfig.Tools)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create tool builder: %v\n", err)
+		return 1
+	}
+
+	options := selftest.Options{
+		Target:      fmt.Sprintf("%s:%d", appConfig.GRPC.Host, appConfig.GRPC.Port),
+		Discoverer:  serviceDiscoverer,
+		ToolBuilder: toolBuilder,
+		Config:      appConfig.SelfTest,
+	}
+	if len(appConfig.MCP.Upstreams) > 0 {
+		options.Upstreams = upstream.NewAggregator(appConfig.MCP.Upstreams, logger)
+		defer func() { _ = options.Upstreams.Close() }()
+	}
+
+	if !runSelfTest(context.Background(), config, options) {
+		return 1
+	}
+	return 0
+}
+
+// export writes a report of the tools the gateway exposes for the configured
+// backend and returns the process exit code
+func export(config *Config, appConfig *appconfig.Config, logger *zap.Logger) int {
+	if config.Format != "inventory" {
+		fmt.Fprintf(os.Stderr, "Unknown export format %q (supported: inventory)\n", config.Format)
+		return 2
+	}
+
+	serviceDiscoverer, err := grpc.NewServiceDiscovererWithConfig(appConfig.GRPC, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create service discoverer: %v\n", err)
+		return 1
+	}
+	defer func() { _ = serviceDiscoverer.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := serviceDiscoverer.Connect(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to gRPC server: %v\n", err)
+		return 1
+	}
+	if err := serviceDiscoverer.DiscoverServices(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to discover services: %v\n", err)
+		return 1
+	}
+
+	toolBuilder, err := tools.NewMCPToolBuilderWithConfig(logger, appConfig.Tools)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create tool builder: %v\n", err)
+		return 1
+	}
+	methods := serviceDiscoverer.GetMethods()
+	builtTools, err := toolBuilder.BuildTools(methods)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build tools: %v\n", err)
+		return 1
+	}
+
+	options := inventory.Options{
+		Target:    fmt.Sprintf("%s:%d", appConfig.GRPC.Host, appConfig.GRPC.Port),
+		Methods:   methods,
+		Tools:     builtTools,
+		Composite: appConfig.Tools.Composite,
+	}
+	if len(appConfig.MCP.Upstreams) > 0 {
+		aggregator := upstream.NewAggregator(appConfig.MCP.Upstreams, logger)
+		defer func() { _ = aggregator.Close() }()
+		options.Upstream = aggregator.Tools(ctx)
+	}
+
+	report, err := inventory.Build(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build inventory: %v\n", err)
+		return 1
+	}
+	if err := report.WriteJSON(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write inventory: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// diff compares two descriptor sets, or a descriptor set with the backend's
+// reflection, and returns the process exit code: 0 without breaking changes,
+// 1 with breaking changes and 2 when the versions cannot be compared
+func diff(config *Config, appConfig *appconfig.Config, logger *zap.Logger) int {
+	wantFiles := 2
+	if config.AgainstReflection {
+		wantFiles = 1
+	}
+	if len(config.DiffFiles) != wantFiles {
+		fmt.Fprintln(os.Stderr, "Usage: grmcp diff OLD.binpb NEW.binpb, or grmcp diff NEW.binpb --against-reflection")
+		return 2
+	}
+
+	loader := descriptors.NewLoader(logger)
+	newName := config.DiffFiles[len(config.DiffFiles)-1]
+	newMethods, err := loader.LoadMethods(newName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load %s: %v\n", newName, err)
+		return 2
+	}
+
+	var oldName string
+	var oldMethods []types.MethodInfo
+	if config.AgainstReflection {
+		// The backend serves the old version; the descriptor set is the one about to ship
+		oldName = fmt.Sprintf("reflection at %s:%d", appConfig.GRPC.Host, appConfig.GRPC.Port)
+		oldMethods, err = reflectedMethods(appConfig, logger)
+	} else {
+		oldName = config.DiffFiles[0]
+		oldMethods, err = loader.LoadMethods(oldName)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load %s: %v\n", oldName, err)
+		return 2
+	}
+
+	report := descriptors.Compare(oldName, oldMethods, newName, newMethods)
+	if config.JSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print diff: %v\n", err)
+		return 2
+	}
+	if len(report.Breaking()) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// reflectedMethods discovers the backend's methods through reflection only
+func reflectedMethods(appConfig *appconfig.Config, logger *zap.Logger) ([]types.MethodInfo, error) {
+	grpcConfig := appConfig.GRPC
+	grpcConfig.DescriptorSet = appconfig.DescriptorSetConfig{}
+	serviceDiscoverer, err := grpc.NewServiceDiscovererWithConfig(grpcConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = serviceDiscoverer.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := serviceDiscoverer.Connect(ctx); err != nil {
+		return nil, err
+	}
+	if err := serviceDiscoverer.DiscoverServices(ctx); err != nil {
+		return nil, err
+	}
+	return serviceDiscoverer.GetMethods(), nil
+}
+
+// runConfigCommand runs a config subcommand and returns the process exit code
+func runConfigCommand(config *Config) int {
+	switch config.ConfigCommand {
+	case "schema":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(appconfig.Schema()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print config schema: %v\n", err)
+			return 1
+		}
+		return 0
+	case "validate":
+		if config.ConfigPath == "" {
+			fmt.Fprintln(os.Stderr, "grmcp config validate needs --config")
+			return 2
+		}
+		if _, err := appconfig.Load(config.ConfigPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("%s is valid\n", config.ConfigPath)
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  grmcp config schema                   print the JSON Schema of the config file")
+		fmt.Fprintln(os.Stderr, "  grmcp config validate --config=FILE   check a config file and exit non-zero if it is invalid")
+		return 2
+	}
+}
+
 // setupLogger creates a configured logger
 func setupLogger(config *Config) (*zap.Logger, error) {
 	var zapConfig zap.Config
@@ -84,9 +436,51 @@ func setupRouter(handler *server.Handler) *mux.Router {
 	// Health check endpoint
 	router.HandleFunc("/health", handler.HealthHandler).Methods("GET")
 
+	// Build information
+	router.HandleFunc(server.VersionPath, handler.VersionHandler).Methods("GET")
+
 	// Metrics endpoint
 	router.HandleFunc("/metrics", handler.MetricsHandler).Methods("GET")
 
+	// Quota usage endpoint
+	router.HandleFunc("/usage", handler.UsageHandler).Methods("GET")
+
+	// Per-tool usage statistics
+	router.HandleFunc(server.ToolStatsPath, handler.ToolStatsHandler).Methods("GET")
+
+	// Upload endpoint for bytes field arguments
+	router.HandleFunc(server.UploadPath, handler.UploadHandler).Methods("POST")
+
+	// Full tool definitions linked from compact tools/list entries
+	router.HandleFunc(server.SchemasPath+"/{tool}", handler.SchemasHandler).Methods("GET")
+
+	// Discovery documents for clients and registries
+	router.HandleFunc(server.WellKnownMCPPath, handler.WellKnownHandler).Methods("GET")
+	router.HandleFunc(server.ProtectedResourcePath, handler.ProtectedResourceHandler).Methods("GET")
+
+	// Session migration endpoints
+	router.HandleFunc(server.SessionsExportPath, handler.SessionsExportHandler).Methods("GET")
+	router.HandleFunc(server.SessionsImportPath, handler.SessionsImportHandler).Methods("POST")
+
+	// Session admin endpoints (require the session admin token)
+	router.HandleFunc(server.SessionsAdminPath, handler.SessionsAdminHandler).Methods("GET")
+	router.HandleFunc(server.SessionsAdminPath+"/{id}", handler.SessionTerminateHandler).Methods("DELETE")
+
+	// Call history endpoint (requires the history admin token)
+	router.HandleFunc(server.HistoryPath, handler.HistoryHandler).Methods("GET")
+
+	// Approval endpoints (require the approval admin token)
+	router.HandleFunc(server.ApprovalsPath, handler.ApprovalsHandler).Methods("GET")
+	router.HandleFunc(server.ApprovalsPath+"/{id}", handler.ApprovalDecisionHandler).Methods("POST")
+
+	// Tool admin endpoints (require the tool admin token)
+	router.HandleFunc(server.ToolsAdminPath, handler.ToolsAdminHandler).Methods("GET")
+	router.HandleFunc(server.ToolsAdminPath+"/{name}", handler.ToolChangeHandler).Methods("POST")
+	router.HandleFunc(server.MaintenancePath, handler.MaintenanceHandler).Methods("GET", "POST")
+
+	// Connection diagnostics endpoint (requires the channelz admin token)
+	router.HandleFunc(server.DiagnosticsPath, handler.DiagnosticsHandler).Methods("GET")
+
 	return router
 }
 
@@ -114,6 +508,13 @@ func gracefulShutdown(server *http.Server, logger *zap.Logger) {
 func main() {
 	// Parse command line flags
 	config := parseFlags()
+	if config.Version {
+		fmt.Println(version.Get())
+		os.Exit(0)
+	}
+	if config.ConfigCommand != "" {
+		os.Exit(runConfigCommand(config))
+	}
 
 	// Setup logger
 	logger, err := setupLogger(config)
@@ -127,27 +528,56 @@ func main() {
 		}
 	}()
 
+	build := version.Get()
 	logger.Info("Starting GrMCP Gateway",
+		zap.String("version", build.Version),
+		zap.String("commit", build.Commit),
+		zap.String("build_date", build.Date),
 		zap.String("grpc_host", config.GRPCHost),
 		zap.Int("grpc_port", config.GRPCPort),
 		zap.Int("http_port", config.HTTPPort),
 		zap.String("log_level", config.LogLevel),
 		zap.Bool("development", config.Development))
 
-	// Create service discoverer with FileDescriptorSet support
-	descriptorConfig := appconfig.DescriptorSetConfig{
-		Enabled:              config.DescriptorPath != "",
-		Path:                 config.DescriptorPath,
-		PreferOverReflection: false, // Use reflection as primary, descriptor as enhancement
-		IncludeSourceInfo:    true,
+	// Load application config (defaults unless a config file is given)
+	appConfig := appconfig.Default()
+	if config.ConfigPath != "" {
+		appConfig, err = appconfig.Load(config.ConfigPath)
+		if err != nil {
+			logger.Fatal("Failed to load config file", zap.Error(err))
+		}
+	}
+
+	applyFlagOverrides(config, appConfig)
+	logger.Debug("Effective configuration", zap.Any("config", appConfig.Redacted()))
+
+	if config.PrintConfig {
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(appConfig.Redacted()); err != nil {
+			logger.Fatal("Failed to print config", zap.Error(err))
+		}
+		return
+	}
+
+	if config.Check {
+		_ = logger.Sync()
+		os.Exit(check(config, appConfig, logger))
+	}
+
+	if config.Export {
+		_ = logger.Sync()
+		os.Exit(export(config, appConfig, logger))
+	}
+
+	if config.Diff {
+		_ = logger.Sync()
+		os.Exit(diff(config, appConfig, logger))
 	}
 
-	serviceDiscoverer, err := grpc.NewServiceDiscoverer(
-		config.GRPCHost,
-		config.GRPCPort,
-		logger,
-		descriptorConfig,
-	)
+	// Create service discoverer with FileDescriptorSet support
+	// (reflection is primary, the descriptor set is an enhancement)
+	serviceDiscoverer, err := grpc.NewServiceDiscovererWithConfig(appConfig.GRPC, logger)
 	if err != nil {
 		logger.Fatal("Failed to create service discoverer", zap.Error(err))
 	}
@@ -165,6 +595,27 @@ func main() {
 		}
 	}()
 
+	// Serve the gateway's own channelz for gRPC debugging tools
+	if address := appConfig.GRPC.Channelz.ListenAddress; address != "" {
+		stopChannelz, err := grpc.ServeChannelz(address, logger)
+		if err != nil {
+			logger.Fatal("Failed to serve channelz", zap.Error(err))
+		}
+		defer stopChannelz()
+	}
+
+	// Publish invocation and discovery change events, starting with the initial discovery
+	eventSink, err := events.NewSink(appConfig.MCP.Events, logger)
+	if err != nil {
+		logger.Fatal("Failed to create event sink", zap.Error(err))
+	}
+	defer func() {
+		if err := eventSink.Close(); err != nil {
+			logger.Warn("Failed to close event sink", zap.Error(err))
+		}
+	}()
+	serviceDiscoverer.OnDiscoveryChange(eventSink.PublishDiscoveryChange)
+
 	// Discover services (will use FileDescriptorSet if available, fallback to reflection)
 	if err := serviceDiscoverer.DiscoverServices(ctx); err != nil {
 		logger.Fatal("Failed to discover services", zap.Error(err))
@@ -176,36 +627,154 @@ func main() {
 		zap.Any("serviceCount", stats["serviceCount"]),
 		zap.Int("methodCount", serviceDiscoverer.GetMethodCount()))
 
-	// Create session manager
-	sessionManager := session.NewManager(logger)
+	// Create session manager; stateless sessions live in signed session IDs
+	var sessionManager session.Manager
+	var memorySessions *session.MemoryManager
+	if appConfig.Session.Stateless.Enabled {
+		sessionManager = session.NewTokenManager(appConfig.Session.Stateless, logger)
+	} else {
+		memorySessions = session.NewManager(logger)
+		sessionManager = memorySessions
+	}
 	defer func() {
 		if err := sessionManager.Close(); err != nil {
 			logger.Warn("Failed to close session manager", zap.Error(err))
 		}
 	}()
 
+	// Restore sessions saved by the previous run
+	if stateFile := appConfig.Session.Migration.StateFile; stateFile != "" && memorySessions != nil {
+		if _, err := memorySessions.LoadState(stateFile); err != nil {
+			logger.Warn("Failed to restore session state", zap.String("path", stateFile), zap.Error(err))
+		}
+	}
+
 	// Create tool builder
-	toolBuilder := tools.NewMCPToolBuilder(logger)
+	toolBuilder, err := tools.NewMCPToolBuilderWithConfig(logger, appConfig.Tools)
+	if err != nil {
+		logger.Fatal("Failed to create tool builder", zap.Error(err))
+	}
+
+	// Schema failures are recorded for each discovery, whether or not tools are listed
+	if _, err := toolBuilder.BuildDiscoveredTools(serviceDiscoverer.GetMethods()); err != nil {
+		logger.Fatal("Failed to build tools", zap.Error(err))
+	}
+	serviceDiscoverer.OnDiscovery(func(methods []types.MethodInfo) {
+		if _, err := toolBuilder.BuildDiscoveredTools(methods); err != nil {
+			logger.Warn("Failed to build discovered tools", zap.Error(err))
+		}
+	})
+
+	// In strict mode, any inconsistency that would otherwise be a warning fails startup
+	if config.Strict {
+		if report := strictReport(serviceDiscoverer, toolBuilder); len(report) > 0 {
+			fmt.Fprintf(os.Stderr, "Strict mode: %d startup issue(s):\n  %s\n", len(report), strings.Join(report, "\n  "))
+			logger.Fatal("Strict mode startup checks failed", zap.Strings("issues", report))
+		}
+		logger.Info("Strict mode startup checks passed")
+	}
 
-	// Create HTTP handler with default header forwarding config
-	defaultConfig := appconfig.Default()
-	handler := server.NewHandler(logger, serviceDiscoverer, sessionManager, toolBuilder, defaultConfig.GRPC.HeaderForwarding)
+	// Fault injection must never reach a production deployment
+	if appConfig.Tools.Chaos.Enabled {
+		if !config.Development {
+			logger.Fatal("Chaos mode requires development mode (--dev)")
+		}
+		logger.Warn("Chaos mode enabled: tool calls will be delayed, failed and truncated at random",
+			zap.Float64("latencyPercent", appConfig.Tools.Chaos.LatencyPercent),
+			zap.Float64("errorPercent", appConfig.Tools.Chaos.ErrorPercent),
+			zap.Float64("truncatePercent", appConfig.Tools.Chaos.TruncatePercent))
+	}
+
+	// Create HTTP handler with application config
+	handler := server.NewHandlerWithConfig(logger, serviceDiscoverer, sessionManager, toolBuilder, appConfig)
+
+	// Re-export the tools of downstream MCP servers
+	var aggregator *upstream.Aggregator
+	if len(appConfig.MCP.Upstreams) > 0 {
+		aggregator = upstream.NewAggregator(appConfig.MCP.Upstreams, logger)
+		defer func() {
+			if err := aggregator.Close(); err != nil {
+				logger.Warn("Failed to close upstream MCP servers", zap.Error(err))
+			}
+		}()
+		handler.SetUpstreams(aggregator)
+	}
+
+	// Notify webhooks after each tool call
+	if len(appConfig.MCP.Webhooks) > 0 {
+		dispatcher := webhook.NewDispatcher(appConfig.MCP.Webhooks, logger)
+		defer func() {
+			if err := dispatcher.Close(); err != nil {
+				logger.Warn("Failed to close webhooks", zap.Error(err))
+			}
+		}()
+		handler.SetWebhooks(dispatcher)
+	}
+	handler.SetEvents(eventSink)
+
+	// Record tool calls for the admin API and the history tool
+	recorder, err := history.NewRecorder(appConfig.MCP.History, logger)
+	if err != nil {
+		logger.Fatal("Failed to open the call history", zap.Error(err))
+	}
+	defer func() {
+		if err := recorder.Close(); err != nil {
+			logger.Warn("Failed to close the call history", zap.Error(err))
+		}
+	}()
+	handler.SetHistory(recorder)
+
+	// Verify the gateway end to end before accepting traffic
+	if appConfig.SelfTest.OnStartup {
+		passed := runSelfTest(context.Background(), config, selftest.Options{
+			Target:      fmt.Sprintf("%s:%d", appConfig.GRPC.Host, appConfig.GRPC.Port),
+			Discoverer:  serviceDiscoverer,
+			ToolBuilder: toolBuilder,
+			Upstreams:   aggregator,
+			Config:      appConfig.SelfTest,
+			Discovered:  true,
+		})
+		if !passed {
+			logger.Fatal("Startup self-test failed")
+		}
+		logger.Info("Startup self-test passed")
+	}
+
+	// Attach tool scripts
+	for _, scriptConfig := range appConfig.Tools.Scripts {
+		hook, err := transform.NewScriptHook(scriptConfig)
+		if err != nil {
+			logger.Fatal("Failed to load tool script", zap.String("path", scriptConfig.Path), zap.Error(err))
+		}
+		handler.AddTransformHook(hook)
+	}
+
+	// Expose tools chaining several RPCs
+	for _, compositeConfig := range appConfig.Tools.Composite {
+		tool, err := composite.New(compositeConfig)
+		if err != nil {
+			logger.Fatal("Failed to load composite tool", zap.String("name", compositeConfig.Name), zap.Error(err))
+		}
+		handler.AddCompositeTool(tool)
+	}
 
 	// Setup router
 	router := setupRouter(handler)
 
 	// Apply middleware
-	middlewares := server.DefaultMiddleware(logger)
+	middlewares := handler.Middleware()
 	finalHandler := server.ChainMiddleware(middlewares...)(router)
 
 	// Create HTTP server
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.HTTPPort),
 		Handler:      finalHandler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  appConfig.Server.ReadTimeout,
+		WriteTimeout: appConfig.Server.WriteTimeout,
+		IdleTimeout:  appConfig.Server.IdleTimeout,
 	}
+	httpServer.RegisterOnShutdown(handler.CancelApprovals)
+	httpServer.RegisterOnShutdown(handler.CloseNotifications)
 
 	// Start server in a goroutine
 	go func() {
@@ -217,4 +786,11 @@ func main() {
 
 	// Wait for shutdown signal
 	gracefulShutdown(httpServer, logger)
+
+	// Save sessions for the next run
+	if stateFile := appConfig.Session.Migration.StateFile; stateFile != "" && memorySessions != nil {
+		if err := memorySessions.SaveState(stateFile); err != nil {
+			logger.Warn("Failed to save session state", zap.String("path", stateFile), zap.Error(err))
+		}
+	}
 }
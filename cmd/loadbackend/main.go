@@ -0,0 +1,50 @@
+// Command loadbackend serves synthetic gRPC services for load tests: every
+// method echoes its request, and the server supports reflection so the
+// gateway discovers them like any other backend.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aalobaidi/ggRMCP/pkg/benchutil"
+)
+
+func main() {
+	port := flag.Int("port", 50061, "Port to serve gRPC on")
+	services := flag.Int("services", 5, "Number of services")
+	methods := flag.Int("methods", 10, "Number of methods per service")
+	extraFields := flag.Int("extra-fields", 0, "Additional string fields per request and response message")
+	latency := flag.Duration("latency", 0, "Simulated processing time of every call")
+	flag.Parse()
+
+	files, err := benchutil.Files(*services, *methods, *extraFields)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to listen: %v\n", err)
+		os.Exit(2)
+	}
+
+	server := benchutil.NewServer(files, *latency)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		server.GracefulStop()
+	}()
+
+	fmt.Printf("Serving %d services with %d methods each on %s\n", *services, *methods, listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		fmt.Fprintf(os.Stderr, "server failed: %v\n", err)
+		os.Exit(1)
+	}
+}
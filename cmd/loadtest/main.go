@@ -0,0 +1,120 @@
+// Command loadtest runs a traffic profile against a running gateway, reports
+// throughput and latency, and fails when the run regressed against a
+// baseline report or too many requests failed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aalobaidi/ggRMCP/pkg/loadtest"
+)
+
+// headerFlags collects repeated -header "Name: value" flags
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("header %q is not Name: value", value)
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+	return nil
+}
+
+func main() {
+	url := flag.String("url", "http://localhost:50052/", "MCP endpoint of the gateway")
+	profilePath := flag.String("profile", "loadtest/profiles/smoke.yaml", "Path to the traffic profile")
+	jsonOutput := flag.Bool("json", false, "Print the report as JSON")
+	outputPath := flag.String("output", "", "Also write the JSON report to this file")
+	baselinePath := flag.String("baseline", "", "JSON report to compare against (optional)")
+	threshold := flag.Float64("threshold", 20, "Maximum allowed throughput drop or p95/p99 latency increase in percent")
+	maxErrorRate := flag.Float64("max-error-rate", 1, "Maximum allowed percentage of failed requests")
+	headers := headerFlags{}
+	flag.Var(headers, "header", "Header added to every request, as \"Name: value\" (repeatable)")
+	flag.Parse()
+
+	profile, err := loadtest.LoadProfile(*profilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Running profile %s against %s for %s...\n", profile.Name, *url, profile.Duration())
+	report, err := loadtest.Run(ctx, loadtest.Options{URL: *url, Profile: profile, Headers: headers, Progress: os.Stderr})
+	if report == nil {
+		fmt.Fprintf(os.Stderr, "load test failed: %v\n", err)
+		os.Exit(2)
+	}
+	interrupted := err != nil
+
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		os.Exit(2)
+	}
+	if *outputPath != "" {
+		if err := writeReport(report, *outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if interrupted {
+		fmt.Fprintln(os.Stderr, "load test interrupted")
+		os.Exit(2)
+	}
+
+	failed := false
+	if rate := report.Total.ErrorRate(); rate > *maxErrorRate {
+		fmt.Fprintf(os.Stderr, "%.2f%% of requests failed, more than %.2f%%\n", rate, *maxErrorRate)
+		failed = true
+	}
+	if *baselinePath != "" {
+		baseline, err := loadtest.ReadReport(*baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(2)
+		}
+		regressions := loadtest.Compare(baseline, report, *threshold)
+		for _, regression := range regressions {
+			fmt.Fprintf(os.Stderr, "REGRESSION %s\n", regression)
+		}
+		if len(regressions) > 0 {
+			fmt.Fprintf(os.Stderr, "load test regressed by more than %.0f%% against %s (%s)\n",
+				*threshold, *baselinePath, baseline.ServerVersion)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// writeReport writes the JSON report to a file
+func writeReport(report *loadtest.Report, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
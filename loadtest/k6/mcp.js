@@ -0,0 +1,94 @@
+// k6 version of the steady profile, for teams that already run k6:
+//
+//   k6 run -e URL=http://localhost:50062/ loadtest/k6/mcp.js
+//
+// Each virtual user initializes its own session, then sends a weighted mix of
+// tools/list, ping and tools/call requests. Tool calls pick a random tool of
+// the synthetic backend started by "make loadtest" (or set TOOL_PREFIX).
+import http from 'k6/http';
+import { check, fail } from 'k6';
+
+const url = __ENV.URL || 'http://localhost:50062/';
+const toolPrefix = __ENV.TOOL_PREFIX || '';
+
+export const options = {
+  stages: [
+    { duration: '10s', target: 8 },
+    { duration: '60s', target: 32 },
+    { duration: '10s', target: 0 },
+  ],
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    'http_req_duration{method:tools/call}': ['p(95)<250', 'p(99)<500'],
+  },
+};
+
+const mix = [
+  { method: 'tools/list', weight: 5 },
+  { method: 'ping', weight: 5 },
+  { method: 'tools/call', weight: 90 },
+];
+const totalWeight = mix.reduce((sum, request) => sum + request.weight, 0);
+
+const callArguments = {
+  query: 'find everything',
+  pageSize: 50,
+  filter: { name: 'root', id: '1', status: 'STATUS_ACTIVE' },
+};
+
+let nextID = 0;
+
+function rpc(method, params, session) {
+  const headers = { 'Content-Type': 'application/json', Accept: 'application/json' };
+  if (session) {
+    headers['Mcp-Session-Id'] = session;
+  }
+  const body = JSON.stringify({ jsonrpc: '2.0', id: ++nextID, method, params });
+  const res = http.post(url, body, { headers, tags: { method } });
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+    'no JSON-RPC error': (r) => r.status === 200 && !r.json('error'),
+  });
+  return res;
+}
+
+function initialize() {
+  const res = rpc('initialize', {
+    protocolVersion: '2024-11-05',
+    capabilities: {},
+    clientInfo: { name: 'ggrmcp-k6', version: '1.0.0' },
+  });
+  return res.headers['Mcp-Session-Id'];
+}
+
+// setup lists the tools once; every virtual user gets the names
+export function setup() {
+  const session = initialize();
+  const res = rpc('tools/list', {}, session);
+  const tools = (res.json('result.tools') || [])
+    .map((tool) => tool.name)
+    .filter((name) => name.startsWith(toolPrefix));
+  if (tools.length === 0) {
+    fail(`no tools start with "${toolPrefix}"`);
+  }
+  return { tools };
+}
+
+let session;
+
+export default function (data) {
+  if (!session) {
+    session = initialize();
+    return;
+  }
+
+  let n = Math.random() * totalWeight;
+  const request = mix.find((r) => (n -= r.weight) < 0) || mix[mix.length - 1];
+  if (request.method === 'tools/call') {
+    const name = data.tools[Math.floor(Math.random() * data.tools.length)];
+    const res = rpc('tools/call', { name, arguments: callArguments }, session);
+    check(res, { 'tool succeeded': (r) => r.status === 200 && !r.json('result.isError') });
+  } else {
+    rpc(request.method, {}, session);
+  }
+}
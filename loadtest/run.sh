@@ -0,0 +1,47 @@
+#!/usr/bin/env bash
+# Starts the synthetic backend and the gateway, runs a traffic profile against
+# them and stops both. Used by "make loadtest"; settings come from the
+# environment (see the Makefile's LOADTEST_* variables).
+set -euo pipefail
+
+BUILD_DIR=${BUILD_DIR:-build}
+PROFILE=${LOADTEST_PROFILE:-steady}
+SERVICES=${LOADTEST_SERVICES:-5}
+METHODS=${LOADTEST_METHODS:-10}
+LATENCY=${LOADTEST_LATENCY:-0s}
+BACKEND_PORT=${LOADTEST_BACKEND_PORT:-50061}
+HTTP_PORT=${LOADTEST_HTTP_PORT:-50062}
+REPORT=${LOADTEST_REPORT:-$BUILD_DIR/loadtest-$PROFILE.json}
+
+cleanup() {
+	[ -n "${gateway_pid:-}" ] && kill "$gateway_pid" 2>/dev/null || true
+	[ -n "${backend_pid:-}" ] && kill "$backend_pid" 2>/dev/null || true
+	wait 2>/dev/null || true
+}
+trap cleanup EXIT
+
+"$BUILD_DIR/loadbackend" --port="$BACKEND_PORT" --services="$SERVICES" --methods="$METHODS" \
+	--latency="$LATENCY" >"$BUILD_DIR/loadbackend.log" 2>&1 &
+backend_pid=$!
+
+"$BUILD_DIR/grmcp" --grpc-host=localhost --grpc-port="$BACKEND_PORT" --http-port="$HTTP_PORT" \
+	--config=loadtest/grmcp.yaml --log-level=warn >"$BUILD_DIR/loadtest-gateway.log" 2>&1 &
+gateway_pid=$!
+
+# Wait for the gateway to discover the backend's tools
+for _ in $(seq 1 50); do
+	if curl -fs "http://localhost:$HTTP_PORT/health" >/dev/null 2>&1; then
+		break
+	fi
+	if ! kill -0 "$gateway_pid" 2>/dev/null; then
+		echo "gateway exited, see $BUILD_DIR/loadtest-gateway.log" >&2
+		exit 2
+	fi
+	sleep 0.2
+done
+
+args=(--url="http://localhost:$HTTP_PORT/" --profile="loadtest/profiles/$PROFILE.yaml" --output="$REPORT")
+if [ -n "${LOADTEST_BASELINE:-}" ]; then
+	args+=(--baseline="$LOADTEST_BASELINE" --threshold="${LOADTEST_THRESHOLD:-20}")
+fi
+"$BUILD_DIR/loadtest" "${args[@]}"
@@ -0,0 +1,289 @@
+// Package approval parks tool calls until a human approves or rejects them,
+// keeping an audit trail of the decisions.
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// Statuses of an approval request
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusExpired   = "expired"   // nobody decided before the timeout
+	StatusCancelled = "cancelled" // the caller went away before a decision
+)
+
+// Errors returned when deciding
+var (
+	ErrNotFound   = errors.New("approval request not found")
+	ErrTooMany    = errors.New("too many calls are waiting for approval")
+	ErrClosedGate = errors.New("approval gate is closed")
+)
+
+// Request is a call waiting for approval, or a decided one in the audit trail
+type Request struct {
+	ID          string     `json:"id"`
+	Tool        string     `json:"tool"`
+	SessionID   string     `json:"sessionId"`
+	RequestID   string     `json:"requestId,omitempty"`
+	Arguments   string     `json:"arguments,omitempty"`
+	Channel     string     `json:"channel"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	Approver    string     `json:"approver,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+// Stats counts the requests of the gate
+type Stats struct {
+	Pending   int   `json:"pending"`
+	Approved  int64 `json:"approved"`
+	Rejected  int64 `json:"rejected"`
+	Expired   int64 `json:"expired"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// pending is a parked call
+type pending struct {
+	request Request
+	decided chan struct{}
+}
+
+// Gate parks the calls of the configured tools until they are decided
+type Gate struct {
+	config config.ApprovalConfig
+	notify func(Request)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*pending
+	audit   []Request
+	stats   Stats
+	closed  bool
+}
+
+// NewGate returns a gate for the configured tools, or nil when no tool
+// needs approval. notify is called when a call is parked and when it is
+// decided.
+func NewGate(cfg config.ApprovalConfig, notify func(Request)) *Gate {
+	if len(cfg.Tools) == 0 {
+		return nil
+	}
+	if notify == nil {
+		notify = func(Request) {}
+	}
+	return &Gate{
+		config:  cfg,
+		notify:  notify,
+		now:     time.Now,
+		pending: make(map[string]*pending),
+	}
+}
+
+// Requires reports whether calls of the tool need approval
+func (g *Gate) Requires(toolName string) bool {
+	if g == nil {
+		return false
+	}
+	return slices.Contains(g.config.Tools, "*") || slices.Contains(g.config.Tools, toolName)
+}
+
+// Submit parks a call and returns its request. The request expires after the
+// configured timeout, or at its ExpiresAt if that comes first, e.g. the
+// deadline of the parked call.
+func (g *Gate) Submit(request Request) (Request, error) {
+	id, err := newID()
+	if err != nil {
+		return Request{}, err
+	}
+	now := g.now()
+	request.ID = id
+	request.Status = StatusPending
+	request.RequestedAt = now
+	if expiresAt := now.Add(g.config.Timeout); request.ExpiresAt.IsZero() || expiresAt.Before(request.ExpiresAt) {
+		request.ExpiresAt = expiresAt
+	}
+	request.DecidedAt = nil
+
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return Request{}, ErrClosedGate
+	}
+	if len(g.pending) >= g.config.MaxPending {
+		g.mu.Unlock()
+		return Request{}, ErrTooMany
+	}
+	g.pending[id] = &pending{request: request, decided: make(chan struct{})}
+	g.mu.Unlock()
+
+	g.notify(request)
+	return request, nil
+}
+
+// Wait blocks until the request is decided, it expires or ctx is done, and
+// returns the request with its final status
+func (g *Gate) Wait(ctx context.Context, id string) (Request, error) {
+	g.mu.Lock()
+	p, ok := g.pending[id]
+	if !ok {
+		// The request may have been decided before the caller started waiting
+		defer g.mu.Unlock()
+		for i := len(g.audit) - 1; i >= 0; i-- {
+			if g.audit[i].ID == id {
+				return g.audit[i], nil
+			}
+		}
+		return Request{}, ErrNotFound
+	}
+	g.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(p.request.ExpiresAt))
+	defer timer.Stop()
+	select {
+	case <-p.decided:
+	case <-timer.C:
+		g.finish(id, StatusExpired, "", "")
+	case <-ctx.Done():
+		// A caller out of time expired like the request; one gone away cancelled it
+		status := StatusCancelled
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status = StatusExpired
+		}
+		g.finish(id, status, "", "")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return p.request, nil
+}
+
+// Decide approves or rejects a pending request
+func (g *Gate) Decide(id string, approve bool, approver, reason string) (Request, error) {
+	status := StatusRejected
+	if approve {
+		status = StatusApproved
+	}
+	return g.finish(id, status, approver, reason)
+}
+
+// finish records the outcome of a pending request, moving it to the audit trail
+func (g *Gate) finish(id, status, approver, reason string) (Request, error) {
+	g.mu.Lock()
+	p, ok := g.pending[id]
+	if !ok {
+		g.mu.Unlock()
+		return Request{}, ErrNotFound
+	}
+	delete(g.pending, id)
+
+	decidedAt := g.now()
+	p.request.Status = status
+	p.request.DecidedAt = &decidedAt
+	p.request.Approver = approver
+	p.request.Reason = reason
+	request := p.request
+
+	switch status {
+	case StatusApproved:
+		g.stats.Approved++
+	case StatusRejected:
+		g.stats.Rejected++
+	case StatusExpired:
+		g.stats.Expired++
+	case StatusCancelled:
+		g.stats.Cancelled++
+	}
+	g.audit = append(g.audit, request)
+	if len(g.audit) > g.config.MaxAudit {
+		g.audit = slices.Delete(g.audit, 0, len(g.audit)-g.config.MaxAudit)
+	}
+	close(p.decided)
+	g.mu.Unlock()
+
+	g.notify(request)
+	return request, nil
+}
+
+// Pending returns the requests waiting for a decision, oldest first
+func (g *Gate) Pending() []Request {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	requests := make([]Request, 0, len(g.pending))
+	for _, p := range g.pending {
+		requests = append(requests, p.request)
+	}
+	slices.SortFunc(requests, func(a, b Request) int {
+		return a.RequestedAt.Compare(b.RequestedAt)
+	})
+	return requests
+}
+
+// Audit returns the decided requests, most recent first
+func (g *Gate) Audit() []Request {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	requests := slices.Clone(g.audit)
+	slices.Reverse(requests)
+	return requests
+}
+
+// Stats returns the gate's counters; nil for a nil gate
+func (g *Gate) Stats() *Stats {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stats := g.stats
+	stats.Pending = len(g.pending)
+	return &stats
+}
+
+// Close cancels the pending requests; further calls are rejected
+func (g *Gate) Close() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.closed = true
+	ids := make([]string, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+	g.mu.Unlock()
+
+	for _, id := range ids {
+		_, _ = g.finish(id, StatusCancelled, "", "gateway shutting down")
+	}
+}
+
+// newID returns a random request ID
+func newID() (string, error) {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
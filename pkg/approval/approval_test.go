@@ -0,0 +1,170 @@
+package approval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGate(t *testing.T, timeout time.Duration, maxPending int) (*Gate, *[]Request) {
+	var notified []Request
+	gate := NewGate(config.ApprovalConfig{
+		Tools:      []string{"bank_transfer"},
+		Timeout:    timeout,
+		MaxPending: maxPending,
+		MaxAudit:   2,
+	}, func(request Request) { notified = append(notified, request) })
+	require.NotNil(t, gate)
+	return gate, &notified
+}
+
+func TestNewGate_NoTools(t *testing.T) {
+	var gate *Gate = NewGate(config.ApprovalConfig{}, nil)
+	assert.Nil(t, gate)
+	assert.False(t, gate.Requires("bank_transfer"))
+	assert.Nil(t, gate.Pending())
+	assert.Nil(t, gate.Stats())
+	gate.Close()
+}
+
+func TestGate_Requires(t *testing.T) {
+	gate, _ := newTestGate(t, time.Minute, 10)
+	assert.True(t, gate.Requires("bank_transfer"))
+	assert.False(t, gate.Requires("bank_balance"))
+
+	all := NewGate(config.ApprovalConfig{Tools: []string{"*"}}, nil)
+	assert.True(t, all.Requires("bank_balance"))
+}
+
+func TestGate_Decide(t *testing.T) {
+	gate, notified := newTestGate(t, time.Minute, 10)
+
+	request, err := gate.Submit(Request{Tool: "bank_transfer", SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, request.Status)
+	require.Len(t, gate.Pending(), 1)
+
+	done := make(chan Request)
+	go func() {
+		decided, err := gate.Wait(context.Background(), request.ID)
+		assert.NoError(t, err)
+		done <- decided
+	}()
+
+	decided, err := gate.Decide(request.ID, true, "alice", "looks fine")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, decided.Status)
+
+	// Waiting returns the decision whether it came before or during the wait
+	waited := <-done
+	assert.Equal(t, StatusApproved, waited.Status)
+	assert.Equal(t, "alice", waited.Approver)
+	assert.Equal(t, "looks fine", waited.Reason)
+	assert.NotNil(t, waited.DecidedAt)
+
+	_, err = gate.Decide(request.ID, false, "bob", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.Len(t, *notified, 2)
+	assert.Equal(t, StatusPending, (*notified)[0].Status)
+	assert.Equal(t, StatusApproved, (*notified)[1].Status)
+	assert.Empty(t, gate.Pending())
+}
+
+func TestGate_Expire(t *testing.T) {
+	gate, _ := newTestGate(t, 20*time.Millisecond, 10)
+
+	request, err := gate.Submit(Request{Tool: "bank_transfer"})
+	require.NoError(t, err)
+	decided, err := gate.Wait(context.Background(), request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, decided.Status)
+	assert.Equal(t, int64(1), gate.Stats().Expired)
+}
+
+func TestGate_ExpireAtCallerDeadline(t *testing.T) {
+	gate, _ := newTestGate(t, time.Minute, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	request, err := gate.Submit(Request{Tool: "bank_transfer", ExpiresAt: deadline})
+	require.NoError(t, err)
+	assert.Equal(t, deadline, request.ExpiresAt)
+
+	decided, err := gate.Wait(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, decided.Status)
+
+	// A later deadline does not extend the configured timeout
+	request, err = gate.Submit(Request{Tool: "bank_transfer", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), request.ExpiresAt, time.Second)
+}
+
+func TestGate_Cancel(t *testing.T) {
+	gate, _ := newTestGate(t, time.Minute, 10)
+
+	request, err := gate.Submit(Request{Tool: "bank_transfer"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	decided, err := gate.Wait(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, decided.Status)
+
+	_, err = gate.Wait(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGate_Close(t *testing.T) {
+	gate, _ := newTestGate(t, time.Minute, 10)
+
+	request, err := gate.Submit(Request{Tool: "bank_transfer"})
+	require.NoError(t, err)
+	gate.Close()
+
+	decided, err := gate.Wait(context.Background(), request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, decided.Status)
+
+	_, err = gate.Submit(Request{Tool: "bank_transfer"})
+	assert.ErrorIs(t, err, ErrClosedGate)
+}
+
+func TestGate_TooMany(t *testing.T) {
+	gate, _ := newTestGate(t, time.Minute, 1)
+
+	_, err := gate.Submit(Request{Tool: "bank_transfer"})
+	require.NoError(t, err)
+	_, err = gate.Submit(Request{Tool: "bank_transfer"})
+	assert.ErrorIs(t, err, ErrTooMany)
+}
+
+func TestGate_Audit(t *testing.T) {
+	gate, _ := newTestGate(t, time.Minute, 10)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		request, err := gate.Submit(Request{Tool: "bank_transfer"})
+		require.NoError(t, err)
+		_, err = gate.Decide(request.ID, i%2 == 0, "alice", "")
+		require.NoError(t, err)
+		ids = append(ids, request.ID)
+	}
+
+	// Only the most recent decisions are kept, newest first
+	audit := gate.Audit()
+	require.Len(t, audit, 2)
+	assert.Equal(t, ids[2], audit[0].ID)
+	assert.Equal(t, ids[1], audit[1].ID)
+
+	stats := gate.Stats()
+	assert.Equal(t, int64(2), stats.Approved)
+	assert.Equal(t, int64(1), stats.Rejected)
+	assert.Equal(t, 0, stats.Pending)
+}
@@ -0,0 +1,394 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// jwksMinRefetch limits how often a token with an unknown key ID makes the
+// JWKS be fetched again, so forged key IDs cannot flood the issuer
+const jwksMinRefetch = 30 * time.Second
+
+// maxJWKSBytes bounds the size of a fetched JWKS
+const maxJWKSBytes = 1 << 20
+
+// Claims are the claims of a verified token
+type Claims map[string]interface{}
+
+// Subject returns the sub claim
+func (c Claims) Subject() string {
+	subject, _ := c["sub"].(string)
+	return subject
+}
+
+// Value returns a claim; nested claims are named by dotted paths
+func (c Claims) Value(name string) (interface{}, bool) {
+	var value interface{} = map[string]interface{}(c)
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Strings returns the values of a claim holding an array of strings or a
+// space-separated string (like scope). Nested claims are named by dotted paths.
+func (c Claims) Strings(name string) []string {
+	value, _ := c.Value(name)
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// time returns a NumericDate claim
+func (c Claims) time(name string) (time.Time, bool) {
+	number, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// claimsKey is the context key of the verified claims of a request
+type claimsKey struct{}
+
+// WithClaims returns a context carrying the verified claims of the request
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the verified claims of the request, if any
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+// tokenHeader is the JOSE header of a token
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jsonWebKey is a key of a JWKS
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// Verifier verifies JWT bearer tokens
+type Verifier struct {
+	config config.JWTConfig
+	client *http.Client
+
+	// now returns the current time (replaceable for tests)
+	now func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // key ID -> key
+	fetchedAt time.Time
+}
+
+// NewVerifier creates a verifier of the configured tokens
+func NewVerifier(jwtConfig config.JWTConfig) *Verifier {
+	return &Verifier{
+		config: jwtConfig,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Verify checks a token's signature and its exp, nbf, iss and aud claims
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	if err := v.verifySignature(ctx, header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as written, so large numeric IDs compare exactly
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed claims")
+	}
+	var claims Claims
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature checks the signature with the key the algorithm calls for
+func (v *Verifier) verifySignature(ctx context.Context, header tokenHeader, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch header.Algorithm {
+	case "HS256":
+		if v.config.Secret == "" {
+			return errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, []byte(v.config.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case "RS256":
+		key, err := v.publicKey(ctx, header.KeyID, "RSA")
+		if err != nil {
+			return err
+		}
+		if err := rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case "ES256":
+		key, err := v.publicKey(ctx, header.KeyID, "EC")
+		if err != nil {
+			return err
+		}
+		if len(signature) != 64 {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key.(*ecdsa.PublicKey), digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", header.Algorithm)
+}
+
+// checkClaims checks the token's validity period, issuer and audience
+func (v *Verifier) checkClaims(claims Claims) error {
+	now := v.now()
+
+	exp, ok := claims.time("exp")
+	if !ok {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(exp.Add(v.config.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(v.config.Leeway).Before(nbf) {
+		return errors.New("token not valid yet")
+	}
+
+	if v.config.Issuer != "" {
+		if issuer, _ := claims["iss"].(string); issuer != v.config.Issuer {
+			return fmt.Errorf("unexpected issuer %q", issuer)
+		}
+	}
+	if v.config.Audience != "" && !slices.Contains(claims.Strings("aud"), v.config.Audience) {
+		return errors.New("token is not issued for this audience")
+	}
+	return nil
+}
+
+// publicKey returns the JWKS key of a token, fetching the JWKS when its keys
+// are stale or the key ID is unknown
+func (v *Verifier) publicKey(ctx context.Context, keyID, keyType string) (crypto.PublicKey, error) {
+	if v.config.JWKSURL == "" {
+		return nil, errors.New("asymmetric tokens are not accepted")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	sinceFetch := v.now().Sub(v.fetchedAt)
+	key, found := v.findKey(keyID, keyType)
+	if v.keys == nil || sinceFetch > v.config.JWKSRefresh || (!found && sinceFetch > jwksMinRefetch) {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			if found {
+				// Keep using known keys while the issuer is unreachable
+				return key, nil
+			}
+			return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
+		v.keys = keys
+		v.fetchedAt = v.now()
+		key, found = v.findKey(keyID, keyType)
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return key, nil
+}
+
+// findKey returns the key of an ID, or the only key of the type when the
+// token names none
+func (v *Verifier) findKey(keyID, keyType string) (crypto.PublicKey, bool) {
+	matches := func(key crypto.PublicKey) bool {
+		switch key.(type) {
+		case *rsa.PublicKey:
+			return keyType == "RSA"
+		case *ecdsa.PublicKey:
+			return keyType == "EC"
+		}
+		return false
+	}
+
+	if keyID != "" {
+		key, ok := v.keys[keyID]
+		return key, ok && matches(key)
+	}
+	var candidate crypto.PublicKey
+	for _, key := range v.keys {
+		if matches(key) {
+			if candidate != nil {
+				return nil, false
+			}
+			candidate = key
+		}
+	}
+	return candidate, candidate != nil
+}
+
+// fetchKeys fetches the JWKS and parses its RSA and P-256 signing keys
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.config.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Keys of other types and curves are skipped
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+// publicKey parses an RSA or P-256 key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 coordinates")
+		}
+		// Reject points off the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a token
+func decodeSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
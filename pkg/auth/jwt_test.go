@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// signToken builds a token signed by sign over its header and claims
+func signToken(t *testing.T, header, claims map[string]interface{}, sign func(signed string) []byte) string {
+	encode := func(value interface{}) string {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(signed))
+}
+
+func hs256(signed string) []byte {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "alice",
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"ggrmcp", "other"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"support"},
+	}
+}
+
+func TestVerifier_HS256(t *testing.T) {
+	verifier := NewVerifier(config.JWTConfig{
+		Secret:   testSecret,
+		Issuer:   "https://issuer.example.com",
+		Audience: "ggrmcp",
+		Leeway:   time.Minute,
+	})
+	header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+	claims, err := verifier.Verify(context.Background(), signToken(t, header, validClaims(), hs256))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject())
+	assert.Equal(t, []string{"support"}, claims.Strings("roles"))
+
+	reject := func(name string, header, claims map[string]interface{}, sign func(string) []byte, message string) {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), signToken(t, header, claims, sign))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), message)
+		})
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	reject("Tampered", header, validClaims(), func(signed string) []byte { return hs256(signed + "x") }, "invalid signature")
+	reject("None", map[string]interface{}{"alg": "none"}, validClaims(), func(string) []byte { return nil }, "unsupported algorithm")
+	reject("Expired", header, withClaim("exp", time.Now().Add(-2*time.Minute).Unix()), hs256, "expired")
+	reject("No_expiry", header, withClaim("exp", nil), hs256, "no exp")
+	reject("Not_yet_valid", header, withClaim("nbf", time.Now().Add(time.Hour).Unix()), hs256, "not valid yet")
+	reject("Issuer", header, withClaim("iss", "https://evil.example.com"), hs256, "unexpected issuer")
+	reject("Audience", header, withClaim("aud", "other"), hs256, "audience")
+	reject("Asymmetric_without_JWKS", map[string]interface{}{"alg": "RS256"}, validClaims(), hs256, "not accepted")
+
+	// Expiry within the leeway is tolerated
+	_, err = verifier.Verify(context.Background(),
+		signToken(t, header, withClaim("exp", time.Now().Add(-30*time.Second).Unix()), hs256))
+	assert.NoError(t, err)
+}
+
+func TestVerifier_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encodeInt := func(value *big.Int, size int) string {
+		return base64.RawURLEncoding.EncodeToString(value.FillBytes(make([]byte, size)))
+	}
+	var fetches atomic.Int64
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]interface{}{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encodeInt(rsaKey.N, 256), "e": "AQAB"},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encodeInt(ecKey.X, 32), "y": encodeInt(ecKey.Y, 32)},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": "AAAA"},
+		}})
+	}))
+	defer jwks.Close()
+
+	verifier := NewVerifier(config.JWTConfig{JWKSURL: jwks.URL, JWKSRefresh: time.Hour})
+	rs256 := func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signature
+	}
+	es256 := func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		require.NoError(t, err)
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	_, err = verifier.Verify(context.Background(), signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, validClaims(), rs256))
+	require.NoError(t, err)
+	_, err = verifier.Verify(context.Background(), signToken(t, map[string]interface{}{"alg": "ES256", "kid": "ec-1"}, validClaims(), es256))
+	require.NoError(t, err)
+
+	// A token without a key ID uses the only key of its type
+	_, err = verifier.Verify(context.Background(), signToken(t, map[string]interface{}{"alg": "ES256"}, validClaims(), es256))
+	require.NoError(t, err)
+
+	// Keys are reused, and an unknown key ID does not refetch at once
+	_, err = verifier.Verify(context.Background(), signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-2"}, validClaims(), rs256))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown key")
+	assert.Equal(t, int64(1), fetches.Load())
+
+	// A key of the wrong type is refused
+	_, err = verifier.Verify(context.Background(), signToken(t, map[string]interface{}{"alg": "RS256", "kid": "ec-1"}, validClaims(), rs256))
+	assert.Error(t, err)
+
+	// HS256 tokens need a secret
+	_, err = verifier.Verify(context.Background(), signToken(t, map[string]interface{}{"alg": "HS256"}, validClaims(), hs256))
+	assert.Error(t, err)
+}
+
+func TestClaims_Strings(t *testing.T) {
+	var claims Claims
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"scope": "orders:read  orders:write",
+		"groups": ["support", 7, "ops"],
+		"realm_access": {"roles": ["admin"]}
+	}`), &claims))
+
+	assert.Equal(t, []string{"orders:read", "orders:write"}, claims.Strings("scope"))
+	assert.Equal(t, []string{"support", "ops"}, claims.Strings("groups"))
+	assert.Equal(t, []string{"admin"}, claims.Strings("realm_access.roles"))
+	assert.Nil(t, claims.Strings("realm_access.groups"))
+	assert.Nil(t, claims.Strings("scope.nested"))
+	assert.Nil(t, Claims(nil).Strings("roles"))
+
+	ctx := WithClaims(context.Background(), claims)
+	fromContext, ok := ClaimsFromContext(ctx)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(fromContext.Strings("scope")[0], "orders"))
+}
@@ -0,0 +1,273 @@
+// Package benchutil provides synthetic protobuf services and a gRPC backend
+// serving them, so benchmarks and load tests run without generated code or a
+// real service.
+package benchutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Files builds a registry with the given number of services, each with methodsPerService
+// unary methods whose request and response messages have extraFields additional string fields
+// on top of nested, repeated, map, enum and recursive fields
+func Files(services, methodsPerService, extraFields int) (*protoregistry.Files, error) {
+	fdSet := &descriptorpb.FileDescriptorSet{}
+	for i := 0; i < services; i++ {
+		fdSet.File = append(fdSet.File, fileDescriptor(i, methodsPerService, extraFields))
+	}
+
+	files, err := protodesc.NewFiles(fdSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build synthetic descriptors: %w", err)
+	}
+	return files, nil
+}
+
+// Methods returns method information for every service method in the registry
+func Methods(files *protoregistry.Files) []types.MethodInfo {
+	var methods []types.MethodInfo
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		for i := 0; i < file.Services().Len(); i++ {
+			service := file.Services().Get(i)
+			for j := 0; j < service.Methods().Len(); j++ {
+				method := service.Methods().Get(j)
+				info := types.MethodInfo{
+					Name:             string(method.Name()),
+					FullName:         string(method.FullName()),
+					ServiceName:      string(service.FullName()),
+					InputType:        string(method.Input().FullName()),
+					OutputType:       string(method.Output().FullName()),
+					InputDescriptor:  method.Input(),
+					OutputDescriptor: method.Output(),
+				}
+				info.ToolName = info.GenerateToolName()
+				methods = append(methods, info)
+			}
+		}
+		return true
+	})
+	return methods
+}
+
+// SampleRequestJSON returns a representative request payload for the synthetic messages
+func SampleRequestJSON(items int) string {
+	var b strings.Builder
+	b.WriteString(`{"query":"find everything","pageSize":50,"filter":{"name":"root","id":"1","status":"STATUS_ACTIVE"},"items":[`)
+	for i := 0; i < items; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"name":"item-%d","id":"%d","tags":["a","b","c"],"labels":{"env":"prod","team":"core"},"status":"STATUS_ACTIVE","child":{"name":"child-%d","id":"%d"}}`, i, i, i, i)
+	}
+	b.WriteString("]}")
+	return b.String()
+}
+
+// NewServer creates a gRPC server for the registry's services. Every method
+// waits for latency, then echoes its request as the response, and the server
+// supports reflection.
+func NewServer(files *protoregistry.Files, latency time.Duration) *grpc.Server {
+	server := grpc.NewServer(grpc.UnknownServiceHandler(echoHandler(files, latency)))
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServer(reflection.ServerOptions{
+		Services:           serviceList{files: files},
+		DescriptorResolver: files,
+	}))
+	return server
+}
+
+// StartBackend serves the registry's services over an in-memory bufconn listener.
+// Every method echoes its request as the response, and the server supports reflection.
+// The returned function stops the server and closes the client connection.
+func StartBackend(files *protoregistry.Files) (*grpc.ClientConn, func(), error) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(files, 0)
+
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		server.Stop()
+		return nil, nil, fmt.Errorf("failed to dial bufconn backend: %w", err)
+	}
+
+	return conn, func() {
+		_ = conn.Close()
+		server.Stop()
+	}, nil
+}
+
+// serviceList advertises the registry's services to the reflection server
+type serviceList struct {
+	files *protoregistry.Files
+}
+
+func (s serviceList) GetServiceInfo() map[string]grpc.ServiceInfo {
+	services := make(map[string]grpc.ServiceInfo)
+	s.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		for i := 0; i < file.Services().Len(); i++ {
+			services[string(file.Services().Get(i).FullName())] = grpc.ServiceInfo{}
+		}
+		return true
+	})
+	return services
+}
+
+// echoHandler decodes each request with its method descriptor and echoes it as
+// the response after the given latency
+func echoHandler(files *protoregistry.Files, latency time.Duration) grpc.StreamHandler {
+	return func(_ interface{}, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(fullMethod, "/"), "/", "."))
+
+		desc, err := files.FindDescriptorByName(name)
+		if err != nil {
+			return fmt.Errorf("unknown method %s: %w", fullMethod, err)
+		}
+		method := desc.(protoreflect.MethodDescriptor)
+
+		request := dynamicpb.NewMessage(method.Input())
+		if err := stream.RecvMsg(request); err != nil {
+			return err
+		}
+
+		if latency > 0 {
+			timer := time.NewTimer(latency)
+			select {
+			case <-timer.C:
+			case <-stream.Context().Done():
+				timer.Stop()
+				return stream.Context().Err()
+			}
+		}
+
+		// Requests and responses share field numbers, so the wire bytes round-trip
+		data, err := proto.Marshal(request)
+		if err != nil {
+			return err
+		}
+		response := dynamicpb.NewMessage(method.Output())
+		if err := proto.Unmarshal(data, response); err != nil {
+			return err
+		}
+
+		return stream.SendMsg(response)
+	}
+}
+
+// fileDescriptor builds one synthetic file with a service and its messages
+func fileDescriptor(index, methods, extraFields int) *descriptorpb.FileDescriptorProto {
+	pkg := fmt.Sprintf("bench.svc%d", index)
+	ref := func(name string) *string { return proto.String("." + pkg + "." + name) }
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName *string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName(name)),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+			TypeName: typeName,
+		}
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	item := &descriptorpb.DescriptorProto{
+		Name: proto.String("Item"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, nil),
+			field("id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, nil),
+			field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated, nil),
+			field("labels", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ref("Item.LabelsEntry")),
+			field("status", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional, ref("Status")),
+			field("child", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ref("Item")),
+		},
+		NestedType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("LabelsEntry"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, nil),
+				field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, nil),
+			},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}},
+	}
+
+	payloadFields := func() []*descriptorpb.FieldDescriptorProto {
+		fields := []*descriptorpb.FieldDescriptorProto{
+			field("query", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, nil),
+			field("page_size", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, nil),
+			field("filter", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ref("Item")),
+			field("items", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ref("Item")),
+		}
+		for i := 0; i < extraFields; i++ {
+			fields = append(fields, field(fmt.Sprintf("extra_%d", i), int32(100+i), descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, nil))
+		}
+		return fields
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String(fmt.Sprintf("bench/svc%d.proto", index)),
+		Package: proto.String(pkg),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_ACTIVE"), Number: proto.Int32(1)},
+				{Name: proto.String("STATUS_ARCHIVED"), Number: proto.Int32(2)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{item},
+	}
+
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String("BenchService")}
+	for m := 0; m < methods; m++ {
+		request := fmt.Sprintf("Method%dRequest", m)
+		response := fmt.Sprintf("Method%dResponse", m)
+		file.MessageType = append(file.MessageType,
+			&descriptorpb.DescriptorProto{Name: proto.String(request), Field: payloadFields()},
+			&descriptorpb.DescriptorProto{Name: proto.String(response), Field: payloadFields()},
+		)
+		service.Method = append(service.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(fmt.Sprintf("Method%d", m)),
+			InputType:  ref(request),
+			OutputType: ref(response),
+		})
+	}
+	file.Service = []*descriptorpb.ServiceDescriptorProto{service}
+
+	return file
+}
+
+// jsonName converts a snake_case field name to its lowerCamelCase JSON name
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
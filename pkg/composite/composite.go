@@ -0,0 +1,308 @@
+// Package composite runs tools that chain several RPCs. Each step's
+// arguments are built from the tool's input and earlier results with jq
+// expressions, and steps may be skipped by a condition. When a step fails,
+// the compensations of the steps that committed before it run in reverse
+// order, saga style.
+package composite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/jq"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// Invoker calls a tool with JSON arguments and returns its JSON response
+type Invoker func(ctx context.Context, toolName, argumentsJSON string) (string, error)
+
+// Outcomes of a composite call
+const (
+	StatusCompleted  = "completed"   // every step succeeded or was skipped
+	StatusPartial    = "partial"     // something failed and committed steps remain
+	StatusRolledBack = "rolled_back" // a step failed and every committed step was compensated
+	StatusFailed     = "failed"      // a step failed before any step succeeded
+)
+
+// Outcomes of a step
+const (
+	StepSucceeded          = "succeeded"           // committed
+	StepFailed             = "failed"              // its call or arguments failed
+	StepSkipped            = "skipped"             // its condition was false
+	StepNotRun             = "not_run"             // an earlier step failed
+	StepCompensated        = "compensated"         // committed, then rolled back
+	StepCompensationFailed = "compensation_failed" // committed, and the rollback failed
+)
+
+// StepResult reports what one step did
+type StepResult struct {
+	Name   string      `json:"name"`
+	Tool   string      `json:"tool"`
+	Status string      `json:"status"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+
+	// Compensation reports the rollback of a committed step
+	Compensation *CompensationResult `json:"compensation,omitempty"`
+}
+
+// CompensationResult reports the call that rolled back a step
+type CompensationResult struct {
+	Tool   string      `json:"tool"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Result reports the outcome of a composite call
+type Result struct {
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Output interface{}  `json:"output,omitempty"`
+	Steps  []StepResult `json:"steps"`
+}
+
+// step is a compiled step
+type step struct {
+	name       string
+	tool       string
+	arguments  template
+	when       *jq.Program
+	compensate *compensation
+}
+
+// compensation is a compiled compensation
+type compensation struct {
+	tool      string
+	arguments template
+}
+
+// Tool is a compiled composite tool
+type Tool struct {
+	definition mcp.Tool
+	steps      []step
+	output     *jq.Program
+}
+
+// New compiles a composite tool's expressions
+func New(cfg config.CompositeToolConfig) (*Tool, error) {
+	schema := cfg.InputSchema
+	if len(schema) == 0 {
+		schema = map[string]interface{}{"type": "object"}
+	}
+	description := cfg.Description
+	if description == "" {
+		description = fmt.Sprintf("Calls %d tools in sequence", len(cfg.Steps))
+	}
+	t := &Tool{
+		definition: mcp.Tool{
+			Name:        cfg.Name,
+			Description: description,
+			InputSchema: schema,
+		},
+	}
+
+	for _, s := range cfg.Steps {
+		compiled := step{name: s.Name, tool: s.Tool}
+		arguments := s.Arguments
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		var err error
+		if compiled.arguments, err = compileTemplate(arguments); err != nil {
+			return nil, fmt.Errorf("composite tool %s: step %s: arguments: %w", cfg.Name, s.Name, err)
+		}
+		if s.When != "" {
+			if compiled.when, err = jq.Compile(s.When); err != nil {
+				return nil, fmt.Errorf("composite tool %s: step %s: when: %w", cfg.Name, s.Name, err)
+			}
+		}
+		if s.Compensate != nil {
+			arguments := s.Compensate.Arguments
+			if arguments == nil {
+				arguments = map[string]interface{}{}
+			}
+			compiled.compensate = &compensation{tool: s.Compensate.Tool}
+			if compiled.compensate.arguments, err = compileTemplate(arguments); err != nil {
+				return nil, fmt.Errorf("composite tool %s: step %s: compensation arguments: %w", cfg.Name, s.Name, err)
+			}
+		}
+		t.steps = append(t.steps, compiled)
+	}
+
+	if cfg.Output != "" {
+		var err error
+		if t.output, err = jq.Compile(cfg.Output); err != nil {
+			return nil, fmt.Errorf("composite tool %s: output: %w", cfg.Name, err)
+		}
+	}
+	return t, nil
+}
+
+// Definition returns the tool as listed to clients
+func (t *Tool) Definition() mcp.Tool {
+	return t.definition
+}
+
+// Run calls the steps in order, stopping at the first failure and then
+// compensating the steps that committed
+func (t *Tool) Run(ctx context.Context, input map[string]interface{}, invoke Invoker) *Result {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	results := make(map[string]interface{})
+	scope := map[string]interface{}{"input": normalize(input), "steps": results}
+
+	result := &Result{Status: StatusCompleted, Steps: make([]StepResult, 0, len(t.steps))}
+	succeeded := 0
+	var failure map[string]interface{}
+	for _, s := range t.steps {
+		report := StepResult{Name: s.name, Tool: s.tool}
+		if result.Status != StatusCompleted {
+			report.Status = StepNotRun
+			result.Steps = append(result.Steps, report)
+			continue
+		}
+
+		response, run, err := t.runStep(ctx, s, scope, invoke)
+		switch {
+		case err != nil:
+			report.Status = StepFailed
+			report.Error = err.Error()
+			result.Error = fmt.Sprintf("step %s failed: %v", s.name, err)
+			result.Status = StatusFailed
+			failure = map[string]interface{}{"step": s.name, "error": err.Error()}
+			if succeeded > 0 {
+				result.Status = StatusPartial
+			}
+		case !run:
+			report.Status = StepSkipped
+		default:
+			report.Status = StepSucceeded
+			report.Result = response
+			results[s.name] = response
+			succeeded++
+		}
+		result.Steps = append(result.Steps, report)
+	}
+
+	if failure != nil && succeeded > 0 {
+		scope["failure"] = failure
+		t.compensate(ctx, result, scope, invoke)
+	}
+
+	if result.Status == StatusCompleted && t.output != nil {
+		output, err := evaluate(t.output, scope)
+		if err != nil {
+			result.Status = StatusPartial
+			result.Error = fmt.Sprintf("output: %v", err)
+		} else {
+			result.Output = output
+		}
+	}
+	return result
+}
+
+// compensate rolls back the committed steps in reverse order. Every
+// compensation is attempted even when an earlier one fails; the call is
+// rolled back only when each committed step was compensated.
+func (t *Tool) compensate(ctx context.Context, result *Result, scope interface{}, invoke Invoker) {
+	// Roll back even when the failure was the call being cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	rolledBack := true
+	for i := len(result.Steps) - 1; i >= 0; i-- {
+		report := &result.Steps[i]
+		if report.Status != StepSucceeded {
+			continue
+		}
+		s := t.steps[i]
+		if s.compensate == nil {
+			// The step stays committed
+			rolledBack = false
+			continue
+		}
+
+		report.Compensation = &CompensationResult{Tool: s.compensate.tool}
+		response, err := t.invoke(ctx, s.compensate.tool, s.compensate.arguments, scope, invoke)
+		if err != nil {
+			report.Status = StepCompensationFailed
+			report.Compensation.Error = err.Error()
+			rolledBack = false
+			continue
+		}
+		report.Status = StepCompensated
+		report.Compensation.Result = response
+	}
+	if rolledBack {
+		result.Status = StatusRolledBack
+	}
+}
+
+// runStep evaluates a step's condition and arguments and invokes its tool,
+// reporting whether it ran
+func (t *Tool) runStep(ctx context.Context, s step, scope interface{}, invoke Invoker) (interface{}, bool, error) {
+	if s.when != nil {
+		condition, err := evaluate(s.when, scope)
+		if err != nil {
+			return nil, false, fmt.Errorf("when: %w", err)
+		}
+		if condition == nil || condition == false {
+			return nil, false, nil
+		}
+	}
+
+	response, err := t.invoke(ctx, s.tool, s.arguments, scope, invoke)
+	return response, true, err
+}
+
+// invoke renders the arguments of a call and returns its decoded response
+func (t *Tool) invoke(ctx context.Context, toolName string, arguments template, scope interface{}, invoke Invoker) (interface{}, error) {
+	rendered, err := arguments.render(scope)
+	if err != nil {
+		return nil, fmt.Errorf("arguments: %w", err)
+	}
+	encoded, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("arguments: %w", err)
+	}
+
+	response, err := invoke(ctx, toolName, string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decode(response)
+	if err != nil {
+		return nil, fmt.Errorf("response is not JSON")
+	}
+	return decoded, nil
+}
+
+// decode parses a JSON response, keeping numbers exact
+func decode(text string) (interface{}, error) {
+	if text == "" {
+		return map[string]interface{}{}, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// normalize re-decodes the input so expressions see plain JSON values
+func normalize(input map[string]interface{}) interface{} {
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return input
+	}
+	decoded, err := decode(string(encoded))
+	if err != nil {
+		return input
+	}
+	return decoded
+}
@@ -0,0 +1,237 @@
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend answers tool calls from canned responses and records the arguments
+type fakeBackend struct {
+	responses map[string]string
+	failures  map[string]error
+	calls     []string
+}
+
+func (f *fakeBackend) invoke(_ context.Context, toolName, argumentsJSON string) (string, error) {
+	f.calls = append(f.calls, toolName+" "+argumentsJSON)
+	if err := f.failures[toolName]; err != nil {
+		return "", err
+	}
+	return f.responses[toolName], nil
+}
+
+func inviteTool(t *testing.T) *Tool {
+	tool, err := New(config.CompositeToolConfig{
+		Name: "create_user_and_send_invite",
+		Steps: []config.CompositeStepConfig{
+			{
+				Name:      "user",
+				Tool:      "users_create",
+				Arguments: map[string]interface{}{"email": "{{ .input.email }}", "roles": []interface{}{"member", "{{ .input.role }}"}},
+			},
+			{
+				Name: "invite",
+				Tool: "mail_send",
+				Arguments: map[string]interface{}{
+					"user_id": "{{ .steps.user.id }}",
+					"subject": "Welcome {{ .input.email }} (#{{ .steps.user.number }})",
+				},
+				When: ".input.notify != false",
+			},
+		},
+		Output: "{user: .steps.user.id, invited: (.steps.invite != null)}",
+	})
+	require.NoError(t, err)
+	return tool
+}
+
+func TestTool_Run(t *testing.T) {
+	t.Run("Completed", func(t *testing.T) {
+		backend := &fakeBackend{responses: map[string]string{
+			"users_create": `{"id":"u-1","number":42}`,
+			"mail_send":    `{"messageId":"m-1"}`,
+		}}
+		result := inviteTool(t).Run(context.Background(), map[string]interface{}{"email": "ada@example.com", "role": "admin"}, backend.invoke)
+
+		assert.Equal(t, StatusCompleted, result.Status)
+		assert.Equal(t, []string{
+			`users_create {"email":"ada@example.com","roles":["member","admin"]}`,
+			`mail_send {"subject":"Welcome ada@example.com (#42)","user_id":"u-1"}`,
+		}, backend.calls)
+		assert.Equal(t, map[string]interface{}{"user": "u-1", "invited": true}, result.Output)
+		require.Len(t, result.Steps, 2)
+		assert.Equal(t, StepSucceeded, result.Steps[1].Status)
+	})
+
+	t.Run("Condition_skips_a_step", func(t *testing.T) {
+		backend := &fakeBackend{responses: map[string]string{"users_create": `{"id":"u-1"}`}}
+		result := inviteTool(t).Run(context.Background(), map[string]interface{}{"email": "ada@example.com", "notify": false}, backend.invoke)
+
+		assert.Equal(t, StatusCompleted, result.Status)
+		assert.Len(t, backend.calls, 1)
+		assert.Equal(t, StepSkipped, result.Steps[1].Status)
+		assert.Equal(t, map[string]interface{}{"user": "u-1", "invited": false}, result.Output)
+	})
+
+	t.Run("Partial_failure", func(t *testing.T) {
+		backend := &fakeBackend{
+			responses: map[string]string{"users_create": `{"id":"u-1"}`},
+			failures:  map[string]error{"mail_send": errors.New("mail server down")},
+		}
+		result := inviteTool(t).Run(context.Background(), map[string]interface{}{"email": "ada@example.com"}, backend.invoke)
+
+		assert.Equal(t, StatusPartial, result.Status)
+		assert.Equal(t, "step invite failed: mail server down", result.Error)
+		assert.Nil(t, result.Output)
+		assert.Equal(t, StepSucceeded, result.Steps[0].Status)
+		assert.Equal(t, StepFailed, result.Steps[1].Status)
+		assert.Equal(t, "mail server down", result.Steps[1].Error)
+	})
+
+	t.Run("First_step_fails", func(t *testing.T) {
+		backend := &fakeBackend{failures: map[string]error{"users_create": errors.New("duplicate email")}}
+		result := inviteTool(t).Run(context.Background(), nil, backend.invoke)
+
+		assert.Equal(t, StatusFailed, result.Status)
+		assert.Equal(t, StepNotRun, result.Steps[1].Status)
+		assert.Len(t, backend.calls, 1)
+
+		// The report is plain JSON
+		encoded, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.Contains(t, string(encoded), `"status":"not_run"`)
+	})
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(config.CompositeToolConfig{Name: "bad", Steps: []config.CompositeStepConfig{
+		{Name: "a", Tool: "x", Arguments: map[string]interface{}{"id": "{{ .input.id"}},
+	}})
+	assert.ErrorContains(t, err, "unterminated")
+
+	_, err = New(config.CompositeToolConfig{Name: "bad", Steps: []config.CompositeStepConfig{
+		{Name: "a", Tool: "x", When: ".input.id =="},
+	}})
+	assert.ErrorContains(t, err, "when")
+
+	_, err = New(config.CompositeToolConfig{Name: "bad", Output: "{", Steps: []config.CompositeStepConfig{{Name: "a", Tool: "x"}}})
+	assert.ErrorContains(t, err, "output")
+}
+
+func TestDefinition(t *testing.T) {
+	definition := inviteTool(t).Definition()
+	assert.Equal(t, "create_user_and_send_invite", definition.Name)
+	assert.Equal(t, "Calls 2 tools in sequence", definition.Description)
+	assert.Equal(t, map[string]interface{}{"type": "object"}, definition.InputSchema)
+}
+
+func orderTool(t *testing.T, compensateCharge bool) *Tool {
+	steps := []config.CompositeStepConfig{
+		{
+			Name:      "reservation",
+			Tool:      "stock_reserve",
+			Arguments: map[string]interface{}{"sku": "{{ .input.sku }}"},
+			Compensate: &config.CompensationConfig{
+				Tool:      "stock_release",
+				Arguments: map[string]interface{}{"reservation_id": "{{ .steps.reservation.id }}", "reason": "{{ .failure.step }}: {{ .failure.error }}"},
+			},
+		},
+		{Name: "charge", Tool: "payments_charge", Arguments: map[string]interface{}{"amount": "{{ .input.amount }}"}},
+		{Name: "shipment", Tool: "shipping_create"},
+	}
+	if compensateCharge {
+		steps[1].Compensate = &config.CompensationConfig{
+			Tool:      "payments_refund",
+			Arguments: map[string]interface{}{"charge_id": "{{ .steps.charge.id }}"},
+		}
+	}
+	tool, err := New(config.CompositeToolConfig{Name: "place_order", Steps: steps})
+	require.NoError(t, err)
+	return tool
+}
+
+func TestTool_Compensation(t *testing.T) {
+	responses := map[string]string{
+		"stock_reserve":   `{"id":"r-1"}`,
+		"payments_charge": `{"id":"c-1"}`,
+		"stock_release":   `{}`,
+		"payments_refund": `{"refunded":true}`,
+	}
+	input := map[string]interface{}{"sku": "book", "amount": 12}
+
+	t.Run("Rolls_back_committed_steps_in_reverse", func(t *testing.T) {
+		backend := &fakeBackend{responses: responses, failures: map[string]error{"shipping_create": errors.New("no carrier")}}
+		result := orderTool(t, true).Run(context.Background(), input, backend.invoke)
+
+		assert.Equal(t, StatusRolledBack, result.Status)
+		assert.Equal(t, []string{
+			`stock_reserve {"sku":"book"}`,
+			`payments_charge {"amount":12}`,
+			`shipping_create {}`,
+			`payments_refund {"charge_id":"c-1"}`,
+			`stock_release {"reason":"shipment: no carrier","reservation_id":"r-1"}`,
+		}, backend.calls)
+		assert.Equal(t, StepCompensated, result.Steps[0].Status)
+		assert.Equal(t, StepCompensated, result.Steps[1].Status)
+		assert.Equal(t, map[string]interface{}{"refunded": true}, result.Steps[1].Compensation.Result)
+		assert.Equal(t, StepFailed, result.Steps[2].Status)
+		assert.Nil(t, result.Steps[2].Compensation)
+	})
+
+	t.Run("Steps_without_compensation_stay_committed", func(t *testing.T) {
+		backend := &fakeBackend{responses: responses, failures: map[string]error{"shipping_create": errors.New("no carrier")}}
+		result := orderTool(t, false).Run(context.Background(), input, backend.invoke)
+
+		assert.Equal(t, StatusPartial, result.Status)
+		assert.Equal(t, StepCompensated, result.Steps[0].Status)
+		assert.Equal(t, StepSucceeded, result.Steps[1].Status)
+	})
+
+	t.Run("Failed_compensation", func(t *testing.T) {
+		backend := &fakeBackend{responses: responses, failures: map[string]error{
+			"shipping_create": errors.New("no carrier"),
+			"payments_refund": errors.New("refund rejected"),
+		}}
+		result := orderTool(t, true).Run(context.Background(), input, backend.invoke)
+
+		assert.Equal(t, StatusPartial, result.Status)
+		assert.Equal(t, StepCompensationFailed, result.Steps[1].Status)
+		assert.Equal(t, "refund rejected", result.Steps[1].Compensation.Error)
+		// Later compensations still run
+		assert.Equal(t, StepCompensated, result.Steps[0].Status)
+	})
+
+	t.Run("Nothing_to_roll_back", func(t *testing.T) {
+		backend := &fakeBackend{responses: responses, failures: map[string]error{"stock_reserve": errors.New("out of stock")}}
+		result := orderTool(t, true).Run(context.Background(), input, backend.invoke)
+
+		assert.Equal(t, StatusFailed, result.Status)
+		assert.Len(t, backend.calls, 1)
+	})
+
+	t.Run("Cancelled_calls_are_still_compensated", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var compensationCtx context.Context
+		invoke := func(callCtx context.Context, toolName, argumentsJSON string) (string, error) {
+			switch toolName {
+			case "payments_charge":
+				cancel()
+				return "", callCtx.Err()
+			case "stock_release":
+				compensationCtx = callCtx
+			}
+			return responses[toolName], nil
+		}
+		result := orderTool(t, true).Run(ctx, input, invoke)
+
+		assert.Equal(t, StatusRolledBack, result.Status)
+		require.NotNil(t, compensationCtx)
+		assert.NoError(t, compensationCtx.Err())
+	})
+}
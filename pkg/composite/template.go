@@ -0,0 +1,169 @@
+package composite
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/jq"
+)
+
+// template is an argument value whose strings may embed jq expressions
+type template interface {
+	render(scope interface{}) (interface{}, error)
+}
+
+// compileTemplate compiles the {{ }} expressions in a configured value
+func compileTemplate(value interface{}) (template, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := make(objectTemplate, len(v))
+		for key, item := range v {
+			compiled, err := compileTemplate(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			object[key] = compiled
+		}
+		return object, nil
+	case []interface{}:
+		array := make(arrayTemplate, len(v))
+		for i, item := range v {
+			compiled, err := compileTemplate(item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			array[i] = compiled
+		}
+		return array, nil
+	case string:
+		return compileString(v)
+	}
+	return constant{value}, nil
+}
+
+// constant is a value without expressions
+type constant struct {
+	value interface{}
+}
+
+func (c constant) render(interface{}) (interface{}, error) {
+	return c.value, nil
+}
+
+// objectTemplate renders each member
+type objectTemplate map[string]template
+
+func (o objectTemplate) render(scope interface{}) (interface{}, error) {
+	rendered := make(map[string]interface{}, len(o))
+	for key, value := range o {
+		item, err := value.render(scope)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		rendered[key] = item
+	}
+	return rendered, nil
+}
+
+// arrayTemplate renders each element
+type arrayTemplate []template
+
+func (a arrayTemplate) render(scope interface{}) (interface{}, error) {
+	rendered := make([]interface{}, len(a))
+	for i, value := range a {
+		item, err := value.render(scope)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		rendered[i] = item
+	}
+	return rendered, nil
+}
+
+// expression is a string that is a single {{ }} expression; it renders to
+// the expression's value with its type
+type expression struct {
+	program *jq.Program
+}
+
+func (e expression) render(scope interface{}) (interface{}, error) {
+	return evaluate(e.program, scope)
+}
+
+// interpolation is a string with embedded expressions; it renders to a
+// string, with non-string values written as JSON
+type interpolation struct {
+	literals    []string // literal text before each expression, and after the last
+	expressions []*jq.Program
+}
+
+func (in interpolation) render(scope interface{}) (interface{}, error) {
+	var b strings.Builder
+	for i, program := range in.expressions {
+		b.WriteString(in.literals[i])
+		value, err := evaluate(program, scope)
+		if err != nil {
+			return nil, err
+		}
+		if text, ok := value.(string); ok {
+			b.WriteString(text)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(encoded)
+	}
+	b.WriteString(in.literals[len(in.literals)-1])
+	return b.String(), nil
+}
+
+// compileString splits a string into literal text and {{ }} expressions
+func compileString(text string) (template, error) {
+	var parsed interpolation
+	rest := text
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated {{ in %q", text)
+		}
+		program, err := jq.Compile(rest[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		parsed.literals = append(parsed.literals, rest[:start])
+		parsed.expressions = append(parsed.expressions, program)
+		rest = rest[start+end+2:]
+	}
+	parsed.literals = append(parsed.literals, rest)
+
+	switch {
+	case len(parsed.expressions) == 0:
+		return constant{text}, nil
+	case len(parsed.expressions) == 1 && parsed.literals[0] == "" && parsed.literals[1] == "":
+		return expression{program: parsed.expressions[0]}, nil
+	}
+	return parsed, nil
+}
+
+// evaluate returns the single output of a program, null when it has none
+// and an array when it has several
+func evaluate(program *jq.Program, scope interface{}) (interface{}, error) {
+	outputs, err := program.Run(scope)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", program, err)
+	}
+	switch len(outputs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return outputs[0], nil
+	}
+	return outputs, nil
+}
@@ -1,7 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -24,6 +30,9 @@ type Config struct {
 
 	// Logging configuration
 	Logging LoggingConfig `json:"logging" yaml:"logging"`
+
+	// Self-test run by "grmcp check" and optionally at startup
+	SelfTest SelfTestConfig `json:"self_test" yaml:"self_test"`
 }
 
 // ServerConfig contains HTTP server settings
@@ -31,14 +40,79 @@ type ServerConfig struct {
 	// HTTP server port
 	Port int `json:"port" yaml:"port"`
 
-	// Request timeout
+	// Deadline of each request, applied by the timeout middleware
 	Timeout time.Duration `json:"timeout" yaml:"timeout"`
 
+	// HTTP connection timeouts
+	ReadTimeout  time.Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout time.Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout  time.Duration `json:"idle_timeout" yaml:"idle_timeout"`
+
 	// Maximum request size
 	MaxRequestSize int64 `json:"max_request_size" yaml:"max_request_size"`
 
+	// Response streaming configuration
+	Streaming StreamingConfig `json:"streaming" yaml:"streaming"`
+
 	// Security headers configuration
 	Security SecurityConfig `json:"security" yaml:"security"`
+
+	// HTTP middleware chain
+	Middleware MiddlewareConfig `json:"middleware" yaml:"middleware"`
+}
+
+// MiddlewareNames lists the available HTTP middleware in their default order
+var MiddlewareNames = []string{
+	"recovery",
+	"logging",
+	"security",
+	"cors",
+	"rate_limit",
+	"content_type",
+	"request_size",
+	"timeout",
+	"metrics",
+	"validate_jsonrpc",
+}
+
+// MiddlewareConfig contains the order of the HTTP middleware chain and the
+// panic recovery settings
+type MiddlewareConfig struct {
+	// Middleware names, outermost first; middleware left out are disabled
+	// (empty for the default order)
+	Order []string `json:"order" yaml:"order"`
+
+	// Panic recovery, for both the HTTP chain and JSON-RPC requests
+	Recovery RecoveryConfig `json:"recovery" yaml:"recovery"`
+}
+
+// RecoveryConfig contains the behavior of the panic recovery handler
+type RecoveryConfig struct {
+	// Log the stack trace of recovered panics
+	LogStack bool `json:"log_stack" yaml:"log_stack"`
+
+	// Error message returned to the client
+	ErrorMessage string `json:"error_message" yaml:"error_message"`
+
+	// Append the panic value to the error message (development only)
+	IncludeDetails bool `json:"include_details" yaml:"include_details"`
+}
+
+// StreamingConfig contains settings for chunked responses to slow clients
+type StreamingConfig struct {
+	// Stream large responses as server-sent events to clients that accept them
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Responses larger than this are written in chunks of this size
+	ChunkSize int `json:"chunk_size" yaml:"chunk_size"`
+
+	// Write deadline for each chunk; replaces the overall write timeout
+	// so slow clients that keep reading are not cut off
+	ChunkTimeout time.Duration `json:"chunk_timeout" yaml:"chunk_timeout"`
+
+	// Interval of server-initiated pings while a tool call is in flight
+	// (0 disables); a failed ping cancels the call
+	PingInterval time.Duration `json:"ping_interval" yaml:"ping_interval"`
 }
 
 // SecurityConfig contains security-related settings
@@ -46,11 +120,207 @@ type SecurityConfig struct {
 	// Enable security headers
 	EnableHeaders bool `json:"enable_headers" yaml:"enable_headers"`
 
+	// Security header values and headers removed from responses
+	Headers SecurityHeadersConfig `json:"headers" yaml:"headers"`
+
 	// CORS settings
 	CORS CORSConfig `json:"cors" yaml:"cors"`
 
 	// Rate limiting
 	RateLimit RateLimitConfig `json:"rate_limit" yaml:"rate_limit"`
+
+	// Policy engine authorization
+	Policy PolicyConfig `json:"policy" yaml:"policy"`
+
+	// Usage quotas per API key
+	Quota QuotaConfig `json:"quota" yaml:"quota"`
+
+	// Replay protection for signed requests
+	Replay ReplayConfig `json:"replay" yaml:"replay"`
+
+	// JWT bearer token authentication of MCP requests
+	JWT JWTConfig `json:"jwt" yaml:"jwt"`
+
+	// Tool permissions of the roles in the callers' tokens
+	RBAC RBACConfig `json:"rbac" yaml:"rbac"`
+
+	// Constraints tying tool arguments to the callers' token claims
+	ArgumentConstraints []ArgumentConstraintConfig `json:"argument_constraints" yaml:"argument_constraints"`
+}
+
+// SecurityHeadersConfig contains the headers the security middleware adds to
+// responses and the ones it removes. Empty values omit the header.
+type SecurityHeadersConfig struct {
+	FrameOptions            string `json:"frame_options" yaml:"frame_options"`
+	XSSProtection           string `json:"xss_protection" yaml:"xss_protection"`
+	StrictTransportSecurity string `json:"strict_transport_security" yaml:"strict_transport_security"`
+	ReferrerPolicy          string `json:"referrer_policy" yaml:"referrer_policy"`
+	ContentSecurityPolicy   string `json:"content_security_policy" yaml:"content_security_policy"`
+
+	// Cache-Control for responses that do not set their own
+	CacheControl string `json:"cache_control" yaml:"cache_control"`
+
+	// Additional headers set on every response
+	Custom map[string]string `json:"custom" yaml:"custom"`
+
+	// Response headers removed before sending, such as ones identifying
+	// the backend or the software serving it
+	Strip []string `json:"strip" yaml:"strip"`
+}
+
+// QuotaConfig contains usage accounting and quota settings
+type QuotaConfig struct {
+	// Enable quota accounting and enforcement
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Header carrying the caller's API key; callers without a key listed
+	// in Keys share one anonymous quota
+	KeyHeader string `json:"key_header" yaml:"key_header"`
+
+	// Default limits, applied to the shared anonymous quota
+	Daily   QuotaLimitConfig `json:"daily" yaml:"daily"`
+	Monthly QuotaLimitConfig `json:"monthly" yaml:"monthly"`
+
+	// Limits of the API keys that are accounted on their own, keyed by API key
+	Keys map[string]QuotaPlanConfig `json:"keys" yaml:"keys" secret:"keys"`
+
+	// Cost weights of the tools, charged against the cost limits; tools
+	// without an entry cost nothing
+	Costs []ToolCostConfig `json:"costs" yaml:"costs"`
+
+	// Maximum cost a single session may spend (0 = unlimited); the sessions
+	// of a listed API key or authenticated caller share one budget
+	SessionCost float64 `json:"session_cost" yaml:"session_cost"`
+}
+
+// ToolCostConfig assigns a cost weight to a tool
+type ToolCostConfig struct {
+	// Tool name, or "*" for every tool without its own entry
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Cost charged for each call
+	Cost float64 `json:"cost" yaml:"cost"`
+}
+
+// QuotaPlanConfig contains the daily and monthly limits for a key
+type QuotaPlanConfig struct {
+	Daily   QuotaLimitConfig `json:"daily" yaml:"daily"`
+	Monthly QuotaLimitConfig `json:"monthly" yaml:"monthly"`
+}
+
+// QuotaLimitConfig limits calls, upstream bytes and tool cost within a period (0 = unlimited)
+type QuotaLimitConfig struct {
+	Calls int64   `json:"calls" yaml:"calls"`
+	Bytes int64   `json:"bytes" yaml:"bytes"`
+	Cost  float64 `json:"cost" yaml:"cost"`
+}
+
+// ReplayConfig contains replay protection settings. Protected requests carry
+// a timestamp, a nonce and an HMAC-SHA256 signature of both and the body.
+type ReplayConfig struct {
+	// Require signed, fresh and unique requests for the protected methods
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Key shared with the callers that sign requests
+	Secret string `json:"secret" yaml:"secret" secret:"true"`
+
+	// Maximum age of a request, and maximum clock skew into the future
+	Window time.Duration `json:"window" yaml:"window"`
+
+	// JSON-RPC methods that must be signed
+	Methods []string `json:"methods" yaml:"methods"`
+
+	// Headers carrying the Unix timestamp in seconds, the nonce and the
+	// hex-encoded signature
+	TimestampHeader string `json:"timestamp_header" yaml:"timestamp_header"`
+	NonceHeader     string `json:"nonce_header" yaml:"nonce_header"`
+	SignatureHeader string `json:"signature_header" yaml:"signature_header"`
+
+	// Maximum number of nonces remembered within the window; requests are
+	// rejected while the store is full
+	MaxNonces int `json:"max_nonces" yaml:"max_nonces"`
+}
+
+// JWTConfig contains bearer token authentication settings. Tokens are
+// signed with HS256 under Secret, or with RS256 or ES256 under a key of the
+// JWKS.
+type JWTConfig struct {
+	// Require a valid JWT bearer token on MCP requests
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Key of HS256 tokens (at least 32 bytes)
+	Secret string `json:"secret" yaml:"secret" secret:"true"`
+
+	// URL of the JWKS holding the keys of RS256 and ES256 tokens
+	JWKSURL string `json:"jwks_url" yaml:"jwks_url"`
+
+	// How long fetched keys are used before the JWKS is fetched again
+	JWKSRefresh time.Duration `json:"jwks_refresh" yaml:"jwks_refresh"`
+
+	// Required iss claim (not checked when empty)
+	Issuer string `json:"issuer" yaml:"issuer"`
+
+	// Required aud claim (not checked when empty)
+	Audience string `json:"audience" yaml:"audience"`
+
+	// Clock skew allowed when checking exp and nbf
+	Leeway time.Duration `json:"leeway" yaml:"leeway"`
+}
+
+// RBACConfig maps the roles or groups in callers' tokens to the tools they
+// may list and call. A caller may use the tools of all of its roles.
+type RBACConfig struct {
+	// Enforce roles in tools/list and tools/call (requires JWT authentication)
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Claims holding the caller's roles, as arrays or space-separated
+	// strings; nested claims are named by dotted paths (e.g. "realm_access.roles")
+	Claims []string `json:"claims" yaml:"claims"`
+
+	// The roles granting tools
+	Roles []RoleConfig `json:"roles" yaml:"roles"`
+}
+
+// RoleConfig lists the tools a role grants
+type RoleConfig struct {
+	// Role or group as it appears in the claims
+	Name string `json:"name" yaml:"name"`
+
+	// Tools of the role, as glob patterns of tool names (e.g. "shop_*")
+	Tools []string `json:"tools" yaml:"tools"`
+}
+
+// ArgumentConstraintConfig requires an argument of the matching tools to
+// equal a claim of the caller's token, or one of its values when the claim
+// is an array (e.g. tenant_id must equal the token's tenant_id)
+type ArgumentConstraintConfig struct {
+	// Tools constrained, as a glob pattern of tool names ("*" for all)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Argument constrained, dotted for nested fields (e.g. "order.tenant_id");
+	// fields match under their proto and JSON names
+	Argument string `json:"argument" yaml:"argument"`
+
+	// Claim the argument must match, dotted for nested claims
+	Claim string `json:"claim" yaml:"claim"`
+
+	// Allow calls without the argument; they are refused by default
+	AllowMissing bool `json:"allow_missing" yaml:"allow_missing"`
+}
+
+// PolicyConfig contains policy engine (OPA) settings
+type PolicyConfig struct {
+	// Enable policy evaluation before each tool call
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// OPA decision URL (e.g. http://localhost:8181/v1/data/ggrmcp/authz)
+	URL string `json:"url" yaml:"url"`
+
+	// Policy evaluation timeout
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Allow calls when the policy engine is unreachable (not recommended)
+	FailOpen bool `json:"fail_open" yaml:"fail_open"`
 }
 
 // CORSConfig contains CORS settings
@@ -90,11 +360,176 @@ type GRPCConfig struct {
 	// Message size limits
 	MaxMessageSize int `json:"max_message_size" yaml:"max_message_size"`
 
+	// Log the start and end of each upstream tool call with its call ID
+	LogCalls bool `json:"log_calls" yaml:"log_calls"`
+
+	// Forward proxy for upstream connections
+	Proxy ProxyConfig `json:"proxy" yaml:"proxy"`
+
+	// :authority sent on upstream calls instead of host:port, for proxies
+	// such as Envoy that route on it
+	Authority string `json:"authority" yaml:"authority"`
+
+	// User agent sent on upstream calls, ahead of the gRPC library's own
+	UserAgent string `json:"user_agent" yaml:"user_agent"`
+
+	// Transport security for upstream connections
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+
+	// Google ID token credentials for Cloud Run / IAP-protected backends
+	GoogleIDToken GoogleIDTokenConfig `json:"google_id_token" yaml:"google_id_token"`
+
 	// Header forwarding configuration
 	HeaderForwarding HeaderForwardingConfig `json:"header_forwarding" yaml:"header_forwarding"`
 
 	// FileDescriptorSet configuration
 	DescriptorSet DescriptorSetConfig `json:"descriptor_set" yaml:"descriptor_set"`
+
+	// Service discovery scoping
+	Discovery DiscoveryConfig `json:"discovery" yaml:"discovery"`
+
+	// Per-method invocation limits, keyed by method name
+	// (e.g. "hello.HelloService.SayHello", "HelloService.SayHello" or a tool name)
+	MethodLimits map[string]MethodLimitConfig `json:"method_limits" yaml:"method_limits"`
+
+	// Shadow traffic configuration
+	Shadow ShadowConfig `json:"shadow" yaml:"shadow"`
+
+	// Canary routing configuration
+	Canary CanaryConfig `json:"canary" yaml:"canary"`
+
+	// Connection diagnostics from gRPC channelz
+	Channelz ChannelzConfig `json:"channelz" yaml:"channelz"`
+}
+
+// ChannelzConfig contains settings for connection diagnostics from gRPC channelz
+type ChannelzConfig struct {
+	// Bearer token for the diagnostics endpoint (disabled when empty)
+	AdminToken string `json:"admin_token" yaml:"admin_token" secret:"true"`
+
+	// Also query the backend's channelz service, when it exposes one
+	QueryBackend bool `json:"query_backend" yaml:"query_backend"`
+
+	// Address serving the gateway's own channelz service to gRPC tools such as
+	// grpcdebug, e.g. "localhost:50055" (not served when empty)
+	ListenAddress string `json:"listen_address" yaml:"listen_address"`
+}
+
+// CanaryConfig contains settings for weighted routing to a canary backend
+type CanaryConfig struct {
+	// Enable canary routing
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Canary backend host
+	Host string `json:"host" yaml:"host"`
+
+	// Canary backend port
+	Port int `json:"port" yaml:"port"`
+
+	// Percentage of tool calls routed to the canary (0-100)
+	Percent float64 `json:"percent" yaml:"percent"`
+
+	// Per-service percentage overrides, keyed by service name (e.g. "hello.HelloService")
+	Services map[string]float64 `json:"services" yaml:"services"`
+
+	// Transport security for the canary backend (defaults to the primary's)
+	TLS *TLSConfig `json:"tls" yaml:"tls"`
+
+	// :authority and user agent for the canary backend (default to the primary's)
+	Authority string `json:"authority" yaml:"authority"`
+	UserAgent string `json:"user_agent" yaml:"user_agent"`
+}
+
+// ShadowConfig contains settings for mirroring tool calls to a second backend
+type ShadowConfig struct {
+	// Enable shadow traffic
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Shadow backend host
+	Host string `json:"host" yaml:"host"`
+
+	// Shadow backend port
+	Port int `json:"port" yaml:"port"`
+
+	// Timeout for mirrored calls
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Tool name patterns whose calls are mirrored (e.g. "shop_orders_get*");
+	// none when empty, since mirrored calls repeat any side effects
+	Tools []string `json:"tools" yaml:"tools"`
+
+	// Mirrored calls in flight at once; further calls are dropped and counted
+	MaxInFlight int `json:"max_in_flight" yaml:"max_in_flight"`
+
+	// Transport security for the shadow backend (defaults to the primary's)
+	TLS *TLSConfig `json:"tls" yaml:"tls"`
+
+	// :authority and user agent for the shadow backend (default to the primary's)
+	Authority string `json:"authority" yaml:"authority"`
+	UserAgent string `json:"user_agent" yaml:"user_agent"`
+}
+
+// TLSConfig contains transport security settings for a backend
+type TLSConfig struct {
+	// Use TLS for the connection (plaintext when disabled)
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// PEM bundle of CAs trusted to verify the backend (system roots when empty)
+	CAFile string `json:"ca_file" yaml:"ca_file"`
+
+	// Client certificate and key for mutual TLS
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+
+	// Server name to verify instead of the target host
+	ServerName string `json:"server_name" yaml:"server_name"`
+
+	// SPIFFE workload identity; replaces the static files when enabled
+	SPIFFE SPIFFEConfig `json:"spiffe" yaml:"spiffe"`
+}
+
+// GoogleIDTokenConfig contains settings for attaching Google-signed ID tokens to upstream calls
+type GoogleIDTokenConfig struct {
+	// Attach an ID token to every upstream call
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Token audience, usually the backend URL (e.g. https://api-abc123-uc.a.run.app)
+	Audience string `json:"audience" yaml:"audience"`
+
+	// Service account key file; the metadata server is used when empty
+	CredentialsFile string `json:"credentials_file" yaml:"credentials_file"`
+
+	// Metadata key carrying the token (e.g. "x-serverless-authorization"
+	// to leave the forwarded authorization header untouched)
+	Header string `json:"header" yaml:"header"`
+}
+
+// SPIFFEConfig contains settings for sourcing mTLS credentials from a SPIFFE Workload API
+type SPIFFEConfig struct {
+	// Fetch X.509 SVIDs and trust bundles from the Workload API
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Workload API socket (e.g. unix:///run/spire/sockets/agent.sock);
+	// defaults to the SPIFFE_ENDPOINT_SOCKET environment variable
+	SocketPath string `json:"socket_path" yaml:"socket_path"`
+
+	// SPIFFE IDs the backend may present (e.g. spiffe://example.org/backend)
+	AuthorizedIDs []string `json:"authorized_ids" yaml:"authorized_ids"`
+
+	// Trust domain the backend must belong to when no IDs are listed
+	TrustDomain string `json:"trust_domain" yaml:"trust_domain"`
+}
+
+// MethodLimitConfig contains invocation limits for a single method
+type MethodLimitConfig struct {
+	// Maximum number of in-flight calls (0 means unlimited)
+	MaxConcurrent int `json:"max_concurrent" yaml:"max_concurrent"`
+
+	// Sustained calls per second (0 means unlimited)
+	QPS float64 `json:"qps" yaml:"qps"`
+
+	// Burst size for QPS limiting (defaults to 1)
+	Burst int `json:"burst" yaml:"burst"`
 }
 
 // KeepAliveConfig contains keep-alive settings
@@ -104,6 +539,17 @@ type KeepAliveConfig struct {
 	PermitWithoutStream bool          `json:"permit_without_stream" yaml:"permit_without_stream"`
 }
 
+// ProxyConfig contains forward proxy settings for upstream connections.
+// Without a URL, the standard HTTPS_PROXY, ALL_PROXY and NO_PROXY environment variables apply.
+type ProxyConfig struct {
+	// Proxy URL: http://, https://, socks5:// or socks5h:// (e.g. http://proxy.internal:3128)
+	URL string `json:"url" yaml:"url"`
+
+	// Proxy credentials (override any user info in the URL)
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password" secret:"true"`
+}
+
 // ReconnectConfig contains reconnection settings
 type ReconnectConfig struct {
 	Interval    time.Duration `json:"interval" yaml:"interval"`
@@ -126,6 +572,87 @@ type HeaderForwardingConfig struct {
 
 	// Case sensitive header matching
 	CaseSensitive bool `json:"case_sensitive" yaml:"case_sensitive"`
+
+	// Headers clients may set for a single call with the "_headers" tool
+	// argument; blocked headers are still refused
+	CallHeaders []string `json:"call_headers" yaml:"call_headers"`
+
+	// MCP client identity sent to the backend as x-mcp-* metadata
+	Identity IdentityForwardingConfig `json:"identity" yaml:"identity"`
+
+	// Headers sent as binary metadata: their base64 values (standard or
+	// URL-safe) are decoded and sent under the name with a "-bin" suffix
+	BinaryHeaders []string `json:"binary_headers" yaml:"binary_headers"`
+
+	// Handling of header values gRPC does not allow in text metadata:
+	// "drop" the header or "encode" the value with percent-encoding
+	InvalidValues string `json:"invalid_values" yaml:"invalid_values"`
+
+	// Cap on the size of the metadata sent with a call, counted as in HTTP/2
+	// (name, value and 32 bytes per entry); the largest entries are dropped
+	// until the rest fits (0 for no cap)
+	MaxMetadataBytes int `json:"max_metadata_bytes" yaml:"max_metadata_bytes"`
+}
+
+// IdentityForwardingConfig sends the identity of the MCP client behind each
+// call as x-mcp-client-name, x-mcp-client-version, x-mcp-session-id and
+// x-mcp-principal metadata. Other x-mcp-* headers are not forwarded, so
+// clients cannot set them.
+type IdentityForwardingConfig struct {
+	// Enable identity metadata
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Header in which an authenticating proxy in front of the gateway passes
+	// the caller's principal (empty to send no principal)
+	PrincipalHeader string `json:"principal_header" yaml:"principal_header"`
+}
+
+// DiscoveryConfig limits which services are discovered and exposed as tools.
+// Patterns are full service names or prefixes (e.g. "com.mycorp.api" or "com.mycorp.api.*").
+type DiscoveryConfig struct {
+	// Only discover services matching one of these patterns (all when empty)
+	IncludeServices []string `json:"include_services" yaml:"include_services"`
+
+	// Skip services matching any of these patterns, in addition to the built-in
+	// gRPC services (reflection, health, channelz, testing)
+	ExcludeServices []string `json:"exclude_services" yaml:"exclude_services"`
+
+	// Built-in gRPC services to expose anyway (e.g. "grpc.health.v1.Health")
+	ExposeInternal []string `json:"expose_internal" yaml:"expose_internal"`
+
+	// Exposure of services served in several package versions (e.g. shop.v1 and shop.v2)
+	Versions VersionsConfig `json:"versions" yaml:"versions"`
+
+	// Backend build metadata surfaced as a resource and in /health
+	ServerInfo ServerInfoConfig `json:"server_info" yaml:"server_info"`
+}
+
+// ServerInfoConfig controls discovery of the backend's version and build metadata.
+// It is read from a version RPC taking an empty request and from the custom
+// file options of the files declaring the backend's services.
+type ServerInfoConfig struct {
+	// Read server info after each discovery
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Full method name or tool name of the version RPC; when empty, a unary method
+	// named GetServerInfo, ServerInfo, GetBuildInfo, BuildInfo, GetVersion or Version
+	// with an empty request is used
+	Method string `json:"method" yaml:"method"`
+}
+
+// VersionsConfig controls how methods that exist in several package versions are exposed.
+// Versions are package components such as "v1", "v2beta1" or "v1alpha".
+type VersionsConfig struct {
+	// "all" (default) exposes every version with a version label;
+	// "collapse" exposes one unversioned tool per method bound to the preferred version
+	Mode string `json:"mode" yaml:"mode"`
+
+	// Preferred version by unversioned service name (e.g. "shop.OrderService": "v1");
+	// the newest stable version is preferred otherwise
+	Preferred map[string]string `json:"preferred" yaml:"preferred"`
+
+	// In collapse mode, retry the other versions when the preferred one returns Unimplemented
+	Fallback bool `json:"fallback" yaml:"fallback"`
 }
 
 // DescriptorSetConfig contains FileDescriptorSet settings
@@ -141,81 +668,1184 @@ type DescriptorSetConfig struct {
 
 	// Include source location info for comment extraction
 	IncludeSourceInfo bool `json:"include_source_info" yaml:"include_source_info"`
+
+	// Compare the descriptor set with the backend's reflection and report
+	// methods that are missing from either or whose types differ
+	CrossCheck bool `json:"cross_check" yaml:"cross_check"`
+
+	// Integrity checks the file must pass before it is loaded. A file that
+	// fails them stops discovery instead of falling back to reflection.
+	Verify DescriptorVerifyConfig `json:"verify" yaml:"verify"`
+}
+
+// DescriptorVerifyConfig pins a descriptor set file by digest, signature or both
+type DescriptorVerifyConfig struct {
+	// Hex SHA-256 digest of the file, optionally prefixed with "sha256:"
+	SHA256 string `json:"sha256" yaml:"sha256"`
+
+	// File holding the signature of the descriptor set, as written by
+	// "cosign sign-blob --key cosign.key --output-signature"
+	Signature string `json:"signature" yaml:"signature"`
+
+	// PEM public key verifying the signature (ECDSA, RSA or Ed25519, e.g. cosign.pub)
+	PublicKey string `json:"public_key" yaml:"public_key"`
 }
 
+// sha256Digest matches a hex SHA-256 digest
+var sha256Digest = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
 // MCPConfig contains MCP protocol settings
 type MCPConfig struct {
+	// Server name, version and instructions advertised to clients in initialize
+	Server MCPServerConfig `json:"server" yaml:"server"`
+
 	// Validation limits
 	Validation ValidationConfig `json:"validation" yaml:"validation"`
 
 	// Protocol version
 	ProtocolVersion string `json:"protocol_version" yaml:"protocol_version"`
-}
 
-// ValidationConfig contains validation limits
-type ValidationConfig struct {
-	MaxFieldLength    int   `json:"max_field_length" yaml:"max_field_length"`
-	MaxToolNameLength int   `json:"max_tool_name_length" yaml:"max_tool_name_length"`
-	MaxRequestSize    int64 `json:"max_request_size" yaml:"max_request_size"`
-	MaxResponseSize   int64 `json:"max_response_size" yaml:"max_response_size"`
-}
+	// Error catalog for backend error details
+	ErrorCatalog ErrorCatalogConfig `json:"error_catalog" yaml:"error_catalog"`
 
-// SessionConfig contains session management settings
-type SessionConfig struct {
-	// Session expiration time
-	Expiration time.Duration `json:"expiration" yaml:"expiration"`
+	// Batch tool call extension
+	Batch BatchConfig `json:"batch" yaml:"batch"`
 
-	// Cleanup interval
-	CleanupInterval time.Duration `json:"cleanup_interval" yaml:"cleanup_interval"`
+	// Temporary resources holding payloads too large to inline
+	Resources ResourcesConfig `json:"resources" yaml:"resources"`
 
-	// Maximum number of concurrent sessions
-	MaxSessions int `json:"max_sessions" yaml:"max_sessions"`
+	// Large responses kept per session and read page by page with ggrmcp_get_cached
+	ResponseCache ResponseCacheConfig `json:"response_cache" yaml:"response_cache"`
 
-	// Session rate limiting
-	RateLimit SessionRateLimitConfig `json:"rate_limit" yaml:"rate_limit"`
+	// Argument completion for interactive clients
+	Completion CompletionConfig `json:"completion" yaml:"completion"`
+
+	// Client roots consumption
+	Roots RootsConfig `json:"roots" yaml:"roots"`
+
+	// Sampling requests sent to the client for sampled tools
+	Sampling SamplingConfig `json:"sampling" yaml:"sampling"`
+
+	// Downstream MCP servers whose tools are re-exported alongside the gRPC tools
+	Upstreams []UpstreamConfig `json:"upstreams" yaml:"upstreams"`
+
+	// Endpoints notified after each tool call
+	Webhooks []WebhookConfig `json:"webhooks" yaml:"webhooks"`
+
+	// Invocation and discovery change events published to Kafka or NATS
+	Events EventsConfig `json:"events" yaml:"events"`
+
+	// Persistent history of tool calls
+	History HistoryConfig `json:"history" yaml:"history"`
+
+	// Human approval of calls to sensitive tools
+	Approval ApprovalConfig `json:"approval" yaml:"approval"`
+
+	// Admin API disabling and re-enabling tools at runtime
+	ToolAdmin ToolAdminConfig `json:"tool_admin" yaml:"tool_admin"`
+
+	// Gateway-wide maintenance mode rejecting tool calls
+	Maintenance MaintenanceConfig `json:"maintenance" yaml:"maintenance"`
+
+	// In-memory per-tool call counts, error rates and latencies
+	ToolStats ToolStatsConfig `json:"tool_stats" yaml:"tool_stats"`
+
+	// Label calls with the client name and origin in /metrics and call events
+	UsageLabels UsageLabelsConfig `json:"usage_labels" yaml:"usage_labels"`
+
+	// Named toolsets clients select when they initialize
+	Profiles ProfilesConfig `json:"profiles" yaml:"profiles"`
+
+	// Discovery documents under /.well-known for clients and registries
+	WellKnown WellKnownConfig `json:"well_known" yaml:"well_known"`
+
+	// Handling of the _meta block clients send with tool calls
+	Meta MetaConfig `json:"meta" yaml:"meta"`
 }
 
-// SessionRateLimitConfig contains session-specific rate limiting
-type SessionRateLimitConfig struct {
-	RequestsPerMinute int           `json:"requests_per_minute" yaml:"requests_per_minute"`
-	BurstSize         int           `json:"burst_size" yaml:"burst_size"`
-	WindowSize        time.Duration `json:"window_size" yaml:"window_size"`
+// metadataNamePattern matches the names gRPC allows for text metadata
+var metadataNamePattern = regexp.MustCompile(`^[0-9a-z_.-]+$`)
+
+// MetaConfig controls the _meta block of tool call params, which clients
+// use to correlate calls with their results
+type MetaConfig struct {
+	// Copy the entries of a call's _meta block into its result's _meta;
+	// entries the gateway sets itself, such as elapsedMs, are kept
+	Echo bool `json:"echo" yaml:"echo"`
+
+	// _meta entries sent to the backend as gRPC metadata, mapping each
+	// _meta key to a metadata name (e.g. traceId: x-trace-id)
+	Forward map[string]string `json:"forward" yaml:"forward"`
 }
 
-// ToolsConfig contains tool building settings
-type ToolsConfig struct {
-	// Schema cache settings
-	Cache CacheConfig `json:"cache" yaml:"cache"`
+// MCPServerConfig contains the server information returned by initialize
+type MCPServerConfig struct {
+	// Server name shown to clients
+	Name string `json:"name" yaml:"name"`
 
-	// Schema generation limits
-	MaxDepth      int `json:"max_depth" yaml:"max_depth"`
-	MaxFields     int `json:"max_fields" yaml:"max_fields"`
-	MaxEnumValues int `json:"max_enum_values" yaml:"max_enum_values"`
+	// Human-readable display name (optional)
+	Title string `json:"title" yaml:"title"`
+
+	// Advertised version; the gateway's build version when empty
+	Version string `json:"version" yaml:"version"`
+
+	// Guidance for the model on the backend's domain and how to use its
+	// tools, sent to clients as the initialize instructions (optional)
+	Instructions string `json:"instructions" yaml:"instructions"`
 }
 
-// CacheConfig contains caching settings
-type CacheConfig struct {
-	Enabled    bool          `json:"enabled" yaml:"enabled"`
-	TTL        time.Duration `json:"ttl" yaml:"ttl"`
-	MaxEntries int           `json:"max_entries" yaml:"max_entries"`
+// WellKnownConfig contains settings for the /.well-known discovery documents
+type WellKnownConfig struct {
+	// Serve /.well-known/mcp.json describing the transport, protocol versions and capabilities
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Public URL of the MCP endpoint (e.g. https://mcp.example.com/);
+	// derived from each request when empty
+	PublicURL string `json:"public_url" yaml:"public_url"`
+
+	// Description of the gateway shown to clients and registries
+	Description string `json:"description" yaml:"description"`
+
+	// OAuth authorization servers issuing tokens for the gateway; when set,
+	// /.well-known/oauth-protected-resource is served
+	AuthorizationServers []string `json:"authorization_servers" yaml:"authorization_servers"`
+
+	// OAuth scopes clients may request for the gateway
+	Scopes []string `json:"scopes" yaml:"scopes"`
 }
 
-// LoggingConfig contains logging settings
-type LoggingConfig struct {
-	Level       string `json:"level" yaml:"level"`
-	Format      string `json:"format" yaml:"format"`
-	Development bool   `json:"development" yaml:"development"`
+// upstreamPrefixPattern matches prefixes that keep re-exported tool names valid
+var upstreamPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// UpstreamConfig describes a downstream MCP server reached over HTTP or stdio
+type UpstreamConfig struct {
+	// Name of the server in logs and health output
+	Name string `json:"name" yaml:"name"`
+
+	// Prefix of the re-exported tool names ("<prefix>_<tool>"); defaults to the name
+	Prefix string `json:"prefix" yaml:"prefix"`
+
+	// Streamable HTTP endpoint of the server (e.g. http://localhost:9000/mcp)
+	URL string `json:"url" yaml:"url"`
+
+	// Headers sent with every HTTP request (e.g. Authorization)
+	Headers map[string]string `json:"headers" yaml:"headers" secret:"true"`
+
+	// Command started to serve MCP over stdin/stdout, instead of a URL
+	Command string `json:"command" yaml:"command"`
+
+	// Arguments of the command
+	Args []string `json:"args" yaml:"args"`
+
+	// Environment variables ("KEY=value") added to the command's environment
+	Env []string `json:"env" yaml:"env" secret:"true"`
+
+	// Timeout of each request to the server (30s when unset)
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// How long the server's tool list is cached (5m when unset); a
+	// tools/list_changed notification from the server refreshes it sooner
+	ToolsTTL time.Duration `json:"tools_ttl" yaml:"tools_ttl"`
 }
 
-// Default returns a configuration with sensible defaults
-func Default() *Config {
-	return &Config{
+// WebhookConfig configures an endpoint receiving tool call events in batches
+type WebhookConfig struct {
+	// Name of the endpoint in logs and metrics
+	Name string `json:"name" yaml:"name"`
+
+	// URL the events are posted to
+	URL string `json:"url" yaml:"url"`
+
+	// Headers sent with every request (e.g. Authorization)
+	Headers map[string]string `json:"headers" yaml:"headers" secret:"true"`
+
+	// Key of the HMAC-SHA256 signature sent in X-Ggrmcp-Signature (unsigned when empty)
+	Secret string `json:"secret" yaml:"secret" secret:"true"`
+
+	// Events sent per request (100 when unset)
+	BatchSize int `json:"batch_size" yaml:"batch_size"`
+
+	// Longest time an event waits for its batch to fill (5s when unset)
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval"`
+
+	// Events waiting to be sent; further events are dropped (10000 when unset)
+	QueueSize int `json:"queue_size" yaml:"queue_size"`
+
+	// Retries of a failed delivery, with exponential backoff starting at RetryBackoff
+	// (3 retries and 1s when unset)
+	MaxRetries   int           `json:"max_retries" yaml:"max_retries"`
+	RetryBackoff time.Duration `json:"retry_backoff" yaml:"retry_backoff"`
+
+	// Timeout of each request (10s when unset)
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Arguments are cut to this many bytes (1024 when unset, -1 omits them)
+	MaxArgumentBytes int `json:"max_argument_bytes" yaml:"max_argument_bytes"`
+}
+
+// EventDrivers lists the supported event streaming systems
+var EventDrivers = []string{"kafka", "nats"}
+
+// EventsConfig configures the event stream
+type EventsConfig struct {
+	// Publish events
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Event streaming system: "kafka" or "nats"
+	Driver string `json:"driver" yaml:"driver"`
+
+	// Topics (Kafka) or subjects (NATS) the events are published to
+	Topics EventTopicsConfig `json:"topics" yaml:"topics"`
+
+	// Kafka connection
+	Kafka KafkaConfig `json:"kafka" yaml:"kafka"`
+
+	// NATS connection
+	NATS NATSConfig `json:"nats" yaml:"nats"`
+
+	// Events published per request
+	BatchSize int `json:"batch_size" yaml:"batch_size"`
+
+	// Longest time an event waits for its batch to fill
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval"`
+
+	// Events waiting to be published; further events are dropped
+	QueueSize int `json:"queue_size" yaml:"queue_size"`
+
+	// Retries of a failed publish, with exponential backoff starting at RetryBackoff
+	MaxRetries   int           `json:"max_retries" yaml:"max_retries"`
+	RetryBackoff time.Duration `json:"retry_backoff" yaml:"retry_backoff"`
+
+	// Timeout of each publish
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Invocation arguments are cut to this many bytes (-1 omits them)
+	MaxArgumentBytes int `json:"max_argument_bytes" yaml:"max_argument_bytes"`
+}
+
+// EventTopicsConfig names the topic of each kind of event; an empty topic
+// disables the kind
+type EventTopicsConfig struct {
+	// Completed tool calls
+	Invocation string `json:"invocation" yaml:"invocation"`
+
+	// Tools added or removed by a discovery
+	Discovery string `json:"discovery" yaml:"discovery"`
+}
+
+// KafkaConfig contains the settings to reach Kafka through its REST Proxy
+type KafkaConfig struct {
+	// Base URL of the Kafka REST Proxy (e.g. http://localhost:8082)
+	RESTURL string `json:"rest_url" yaml:"rest_url"`
+
+	// Headers sent with every request (e.g. Authorization)
+	Headers map[string]string `json:"headers" yaml:"headers" secret:"true"`
+}
+
+// NATSConfig contains the settings to reach a NATS server
+type NATSConfig struct {
+	// Server URL (e.g. nats://localhost:4222, or tls://host:4222 for TLS)
+	URL string `json:"url" yaml:"url"`
+
+	// TLS settings; setting any of them turns TLS on, as do tls:// URLs
+	// and servers that require TLS (verified against the system roots)
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+
+	// Token authentication
+	Token string `json:"token" yaml:"token" secret:"true"`
+
+	// User and password authentication
+	User     string `json:"user" yaml:"user"`
+	Password string `json:"password" yaml:"password" secret:"true"`
+}
+
+// HistoryDrivers lists the supported call history stores
+var HistoryDrivers = []string{"memory", "sqlite", "postgres"}
+
+// HistoryConfig configures the persistent history of tool calls
+type HistoryConfig struct {
+	// Record tool calls
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Store: "memory" (lost on restart), "sqlite" or "postgres"
+	Driver string `json:"driver" yaml:"driver"`
+
+	// Data source name: a file path for SQLite, a connection URL for Postgres
+	DSN string `json:"dsn" yaml:"dsn" secret:"true"`
+
+	// Calls older than this are deleted
+	Retention time.Duration `json:"retention" yaml:"retention"`
+
+	// Calls kept by the memory store; the oldest are dropped first
+	MaxEntries int `json:"max_entries" yaml:"max_entries"`
+
+	// Argument and result fields whose values are replaced with "[REDACTED]"
+	// (case-insensitive, at any depth)
+	RedactFields []string `json:"redact_fields" yaml:"redact_fields"`
+
+	// Arguments and results are cut to this many bytes after redaction (-1 omits them)
+	MaxArgumentBytes int `json:"max_argument_bytes" yaml:"max_argument_bytes"`
+	MaxResultBytes   int `json:"max_result_bytes" yaml:"max_result_bytes"`
+
+	// Calls waiting to be written; further calls are dropped
+	QueueSize int `json:"queue_size" yaml:"queue_size"`
+
+	// Expose the ggrmcp_history tool listing the session's own calls
+	Tool bool `json:"tool" yaml:"tool"`
+
+	// Bearer token of the /admin/history endpoint (disabled when empty)
+	AdminToken string `json:"admin_token" yaml:"admin_token" secret:"true"`
+}
+
+// ApprovalChannels lists how approvers can be asked
+var ApprovalChannels = []string{"admin", "elicitation"}
+
+// ApprovalConfig configures the human approval gate
+type ApprovalConfig struct {
+	// Tools whose calls wait for an approver ("*" for all tools)
+	Tools []string `json:"tools" yaml:"tools"`
+
+	// How approvers are asked: "admin" (the admin API, announced to webhooks)
+	// or "elicitation" (the client's user through elicitation/create, falling
+	// back to the admin API for clients that cannot elicit)
+	Channel string `json:"channel" yaml:"channel"`
+
+	// How long a call waits for a decision before it is rejected; must be below
+	// server.timeout and server.write_timeout, which bound the waiting request
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Calls waiting at once; further calls needing approval are rejected
+	MaxPending int `json:"max_pending" yaml:"max_pending"`
+
+	// Decisions kept in memory for the audit trail; the oldest are dropped first
+	MaxAudit int `json:"max_audit" yaml:"max_audit"`
+
+	// Bearer token of the /admin/approvals endpoint (disabled when empty)
+	AdminToken string `json:"admin_token" yaml:"admin_token" secret:"true"`
+}
+
+// ToolAdminConfig configures the admin API that disables tools at runtime,
+// e.g. as a kill switch during incidents
+type ToolAdminConfig struct {
+	// Bearer token of the /admin/tools endpoints (disabled when empty)
+	AdminToken string `json:"admin_token" yaml:"admin_token" secret:"true"`
+
+	// The disabled tools are saved here on every change and restored at
+	// startup (empty to keep them in memory only)
+	StateFile string `json:"state_file" yaml:"state_file"`
+}
+
+// MaintenanceConfig contains the maintenance mode the gateway starts in. It
+// can be switched at runtime through the tool admin API.
+type MaintenanceConfig struct {
+	// Reject tool calls with a retry-later error and flag tools/list
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Notice shown to clients, e.g. the reason and expected end
+	Message string `json:"message" yaml:"message"`
+
+	// How long clients are told to wait before retrying
+	RetryAfter time.Duration `json:"retry_after" yaml:"retry_after"`
+}
+
+// ToolStatsConfig configures the per-tool usage statistics served at /stats/tools
+type ToolStatsConfig struct {
+	// Count calls and sample latencies per tool
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Recent calls per tool that error rates and latency percentiles cover
+	Window int `json:"window" yaml:"window"`
+
+	// Expose the ggrmcp_stats tool reporting the statistics to clients
+	Tool bool `json:"tool" yaml:"tool"`
+}
+
+// UsageLabelsConfig configures the labeling of calls with the agent product
+// (clientInfo.name from initialize) and origin driving them
+type UsageLabelsConfig struct {
+	// Count calls per client name and origin, and add both to call events
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Request header naming the session's origin, e.g. Origin or a header
+	// set by a fronting proxy
+	OriginHeader string `json:"origin_header" yaml:"origin_header"`
+
+	// Distinct values counted per label; later ones are counted as "other"
+	MaxValues int `json:"max_values" yaml:"max_values"`
+}
+
+// ProfilesConfig configures toolset profiles. A session selects a profile
+// with the "profile" initialize param or the profile header; tools/list and
+// tools/call then only cover the profile's tools.
+type ProfilesConfig struct {
+	// Request header selecting a profile when initialize names none
+	Header string `json:"header" yaml:"header"`
+
+	// Profile of sessions selecting none (empty leaves them all tools)
+	Default string `json:"default" yaml:"default"`
+
+	// The profiles clients can select
+	Definitions []ProfileConfig `json:"definitions" yaml:"definitions"`
+}
+
+// ProfileConfig describes a toolset profile
+type ProfileConfig struct {
+	// Name clients select the profile by (e.g. "read-only")
+	Name string `json:"name" yaml:"name"`
+
+	// Tools of the profile, as glob patterns of tool names (e.g. "shop_*_get")
+	Tools []string `json:"tools" yaml:"tools"`
+
+	// Headers a session must send to select the profile (e.g. Authorization)
+	RequiredHeaders []string `json:"required_headers" yaml:"required_headers"`
+
+	// Headers forwarded to the backend, narrowing those header forwarding
+	// allows (empty forwards them all)
+	ForwardHeaders []string `json:"forward_headers" yaml:"forward_headers"`
+}
+
+// SamplingConfig contains settings for sampling/createMessage requests
+type SamplingConfig struct {
+	// How long a tool call waits for the client's completion (may include human review)
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Default maximum tokens requested when a sampled tool sets none
+	MaxTokens int `json:"max_tokens" yaml:"max_tokens"`
+}
+
+// RootsConfig contains settings for fetching the client's roots
+type RootsConfig struct {
+	// How long a tool call waits for the client to answer roots/list
+	FetchTimeout time.Duration `json:"fetch_timeout" yaml:"fetch_timeout"`
+}
+
+// CompletionConfig contains settings for the completion/complete method
+type CompletionConfig struct {
+	// Enable the completions capability
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Known values by full field name (e.g. "pkg.Order.currency")
+	Values map[string][]string `json:"values" yaml:"values"`
+}
+
+// ResourcesConfig contains settings for gateway-held MCP resources
+type ResourcesConfig struct {
+	// How long a stored resource remains readable
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+
+	// Maximum number of stored resources across all sessions
+	MaxEntries int `json:"max_entries" yaml:"max_entries"`
+
+	// Maximum total bytes held across all resources
+	MaxTotalBytes int64 `json:"max_total_bytes" yaml:"max_total_bytes"`
+
+	// Maximum size of a single resource
+	MaxResourceBytes int64 `json:"max_resource_bytes" yaml:"max_resource_bytes"`
+}
+
+// ResponseCacheConfig contains settings for the per-session response cache.
+// Cached responses are held in the resource store, under its TTL and limits.
+type ResponseCacheConfig struct {
+	// Cache large responses and expose the ggrmcp_get_cached tool
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Responses larger than this are cached and returned as their first page
+	MinBytes int `json:"min_bytes" yaml:"min_bytes"`
+
+	// Size of the first page and default page size of ggrmcp_get_cached
+	PageBytes int `json:"page_bytes" yaml:"page_bytes"`
+
+	// Largest page ggrmcp_get_cached returns
+	MaxPageBytes int `json:"max_page_bytes" yaml:"max_page_bytes"`
+}
+
+// BatchConfig contains settings for the tools/call_batch extension
+type BatchConfig struct {
+	// Enable the tools/call_batch method
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Maximum number of calls in a single batch
+	MaxItems int `json:"max_items" yaml:"max_items"`
+
+	// Maximum number of calls executed concurrently
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+}
+
+// ErrorCatalogConfig maps backend google.rpc.ErrorInfo details to curated messages
+type ErrorCatalogConfig struct {
+	// Enable error catalog lookups
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Catalog entries, matched in order
+	Entries []ErrorCatalogEntry `json:"entries" yaml:"entries"`
+}
+
+// ErrorCatalogEntry describes a single error catalog mapping
+type ErrorCatalogEntry struct {
+	// ErrorInfo reason to match (required)
+	Reason string `json:"reason" yaml:"reason"`
+
+	// ErrorInfo domain to match (empty matches any domain)
+	Domain string `json:"domain" yaml:"domain"`
+
+	// Human-readable message shown to the client
+	Message string `json:"message" yaml:"message"`
+
+	// Suggested next action for the client
+	Remediation string `json:"remediation" yaml:"remediation"`
+}
+
+// ValidationConfig contains validation limits
+type ValidationConfig struct {
+	MaxFieldLength    int   `json:"max_field_length" yaml:"max_field_length"`
+	MaxToolNameLength int   `json:"max_tool_name_length" yaml:"max_tool_name_length"`
+	MaxRequestSize    int64 `json:"max_request_size" yaml:"max_request_size"`
+	MaxResponseSize   int64 `json:"max_response_size" yaml:"max_response_size"`
+}
+
+// SessionConfig contains session management settings
+type SessionConfig struct {
+	// Session expiration time
+	Expiration time.Duration `json:"expiration" yaml:"expiration"`
+
+	// Cleanup interval
+	CleanupInterval time.Duration `json:"cleanup_interval" yaml:"cleanup_interval"`
+
+	// Maximum number of concurrent sessions
+	MaxSessions int `json:"max_sessions" yaml:"max_sessions"`
+
+	// Session rate limiting
+	RateLimit SessionRateLimitConfig `json:"rate_limit" yaml:"rate_limit"`
+
+	// Replica affinity for L7 load balancers in front of several gateways
+	Affinity AffinityConfig `json:"affinity" yaml:"affinity"`
+
+	// Export and import of session state for planned maintenance
+	Migration MigrationConfig `json:"migration" yaml:"migration"`
+
+	// Sessions carried in signed session IDs instead of the gateway's memory
+	Stateless StatelessSessionConfig `json:"stateless" yaml:"stateless"`
+
+	// Bearer token of the /admin/sessions listing and termination endpoints
+	// (disabled when empty)
+	AdminToken string `json:"admin_token" yaml:"admin_token" secret:"true"`
+}
+
+// StatelessSessionConfig contains the signing of session tokens. A token
+// carries the session's creation time and the listed headers, so any
+// replica sharing the secret can serve the session.
+type StatelessSessionConfig struct {
+	// Issue signed session tokens instead of keeping sessions in memory
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// HMAC key signing new tokens (at least 32 bytes)
+	Secret string `json:"secret" yaml:"secret" secret:"true"`
+
+	// Keys of tokens still accepted during a secret rotation
+	PreviousSecrets []string `json:"previous_secrets" yaml:"previous_secrets" secret:"true"`
+
+	// Headers of the initializing request kept in the token and used for
+	// the whole session; tokens are signed, not encrypted
+	Headers []string `json:"headers" yaml:"headers"`
+
+	// Lifetime of a token; clients with an older one get a new session
+	MaxAge time.Duration `json:"max_age" yaml:"max_age"`
+}
+
+// MigrationConfig contains the admin endpoints and state file used to move
+// sessions between replicas or across a restart
+type MigrationConfig struct {
+	// Serve the export and import admin endpoints
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Bearer token required by the admin endpoints; exports carry the
+	// forwarded headers of every session
+	Token string `json:"token" yaml:"token" secret:"true"`
+
+	// Sessions are written here on shutdown and restored at startup
+	// (empty to disable)
+	StateFile string `json:"state_file" yaml:"state_file"`
+
+	// Maximum size of an import request body
+	MaxImportSize int64 `json:"max_import_size" yaml:"max_import_size"`
+}
+
+// AffinityConfig contains the header and cookie that pin a session to the
+// replica holding it in memory
+type AffinityConfig struct {
+	// Emit and accept the affinity header and cookie
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Identifier of this replica (the hostname if empty)
+	ReplicaID string `json:"replica_id" yaml:"replica_id"`
+
+	// Header carrying the replica ID (empty to disable)
+	Header string `json:"header" yaml:"header"`
+
+	// Cookie carrying the replica ID (empty to disable)
+	Cookie string `json:"cookie" yaml:"cookie"`
+
+	// Only send the cookie over HTTPS
+	CookieSecure bool `json:"cookie_secure" yaml:"cookie_secure"`
+}
+
+// SessionRateLimitConfig contains session-specific rate limiting
+type SessionRateLimitConfig struct {
+	RequestsPerMinute int           `json:"requests_per_minute" yaml:"requests_per_minute"`
+	BurstSize         int           `json:"burst_size" yaml:"burst_size"`
+	WindowSize        time.Duration `json:"window_size" yaml:"window_size"`
+}
+
+// ToolsConfig contains tool building settings
+type ToolsConfig struct {
+	// Schema cache settings
+	Cache CacheConfig `json:"cache" yaml:"cache"`
+
+	// Schema generation limits; messages nested deeper than MaxDepth are
+	// defined once under $defs and referenced instead of inlined
+	MaxDepth      int `json:"max_depth" yaml:"max_depth"`
+	MaxFields     int `json:"max_fields" yaml:"max_fields"`
+	MaxEnumValues int `json:"max_enum_values" yaml:"max_enum_values"`
+
+	// List tools whose schema cannot be generated with a permissive object
+	// schema and a warning instead of leaving them out
+	DegradedSchemas bool `json:"degraded_schemas" yaml:"degraded_schemas"`
+
+	// Schema of 64-bit integer fields, which protojson emits as strings:
+	// "integer", "string" (decimal string with a pattern) or "both" (oneOf)
+	Int64Encoding string `json:"int64_encoding" yaml:"int64_encoding"`
+
+	// Handling of number arguments for double and float fields: "round"
+	// takes the nearest value the field holds, "reject" refuses numbers
+	// with more precision than the field holds
+	DoublePrecision string `json:"double_precision" yaml:"double_precision"`
+
+	// Request/response JSON transformations, applied in order
+	Transforms []TransformConfig `json:"transforms" yaml:"transforms"`
+
+	// Starlark scripts attached to tools, applied after transformations
+	Scripts []ScriptConfig `json:"scripts" yaml:"scripts"`
+
+	// Response size budgets, first matching entry wins
+	ResponseLimits []ResponseLimitConfig `json:"response_limits" yaml:"response_limits"`
+
+	// Array, object and string size guards on call arguments, first
+	// matching entry wins
+	ArgumentLimits []ArgumentLimitConfig `json:"argument_limits" yaml:"argument_limits"`
+
+	// Latency thresholds above which calls are logged and counted as slow,
+	// first matching entry wins
+	SlowCalls []SlowCallConfig `json:"slow_calls" yaml:"slow_calls"`
+
+	// Large bytes fields returned as resources instead of inline base64
+	BinaryFields BinaryFieldsConfig `json:"binary_fields" yaml:"binary_fields"`
+
+	// Resource references and data URIs accepted for bytes field arguments
+	BinaryInputs BinaryInputsConfig `json:"binary_inputs" yaml:"binary_inputs"`
+
+	// Encodings accepted for bytes field arguments
+	BytesEncoding BytesEncodingConfig `json:"bytes_encoding" yaml:"bytes_encoding"`
+
+	// Rewrite integer and bool map keys in arguments to the form protojson
+	// requires (e.g. "+7" or "7.0" to "7", "True" to "true") and reject
+	// keys of the wrong type before invocation
+	NormalizeMapKeys bool `json:"normalize_map_keys" yaml:"normalize_map_keys"`
+
+	// Accept a "_dryRun" argument that returns the gRPC request a call would
+	// send instead of invoking the method
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+
+	// Accept a "_select" argument holding a JSONPath ($...) or jq expression
+	// that projects the response before it is returned
+	Select bool `json:"select" yaml:"select"`
+
+	// Describe failed calls in each tool's outputSchema as a oneOf of the
+	// response and an error envelope (code, message, details), which failed
+	// calls then return instead of a plain error text
+	ErrorEnvelope bool `json:"error_envelope" yaml:"error_envelope"`
+
+	// Automatic pagination of list methods with page_token and next_page_token fields
+	Pagination PaginationConfig `json:"pagination" yaml:"pagination"`
+
+	// Handling of methods returning google.longrunning.Operation
+	LongRunning LongRunningConfig `json:"long_running" yaml:"long_running"`
+
+	// Response bytes fields returned as image or audio content
+	MediaFields []MediaFieldConfig `json:"media_fields" yaml:"media_fields"`
+
+	// Default argument values and named presets per tool, merged under the
+	// arguments the client supplies
+	ArgumentDefaults []ArgumentDefaultsConfig `json:"argument_defaults" yaml:"argument_defaults"`
+
+	// Tools composed of several RPCs that the gateway calls in sequence
+	Composite []CompositeToolConfig `json:"composite" yaml:"composite"`
+
+	// Path-like arguments kept inside the client's declared roots
+	PathArguments []PathArgumentConfig `json:"path_arguments" yaml:"path_arguments"`
+
+	// Methods whose responses ask the client's LLM for a completion
+	Sampled []SampledToolConfig `json:"sampled" yaml:"sampled"`
+
+	// Handling of methods marked with option deprecated = true
+	Deprecation DeprecationConfig `json:"deprecation" yaml:"deprecation"`
+
+	// Limits on tool descriptions built from proto comments
+	Descriptions DescriptionsConfig `json:"descriptions" yaml:"descriptions"`
+
+	// Compact tools/list entries whose schemas are fetched on demand
+	LazySchemas LazySchemasConfig `json:"lazy_schemas" yaml:"lazy_schemas"`
+
+	// Promotion of single-field request wrappers to the tool's arguments
+	Flatten FlattenConfig `json:"flatten" yaml:"flatten"`
+
+	// Formats of string fields, advertised in schemas and optionally validated
+	Formats FormatsConfig `json:"formats" yaml:"formats"`
+
+	// Fault injection for resilience testing (development only)
+	Chaos ChaosConfig `json:"chaos" yaml:"chaos"`
+}
+
+// StringFormats lists the JSON Schema formats supported on string fields
+var StringFormats = []string{
+	"date",
+	"date-time",
+	"time",
+	"uuid",
+	"email",
+	"uri",
+	"ipv4",
+	"ipv6",
+	"hostname",
+}
+
+// FormatsConfig assigns JSON Schema formats to string fields
+type FormatsConfig struct {
+	// Reject tool calls whose string arguments do not match their field's
+	// format before invoking the backend
+	Validate bool `json:"validate" yaml:"validate"`
+
+	// Formats by fully qualified field name (e.g. "shop.Customer.email"),
+	// in addition to formats declared with the google.api.field_info option
+	Fields map[string]string `json:"fields" yaml:"fields"`
+}
+
+// DescriptionsConfig contains the cleanup and limits applied to tool
+// descriptions built from proto comments
+type DescriptionsConfig struct {
+	// Maximum length of a tool description in bytes (0 for unlimited); longer
+	// descriptions keep their first paragraph and link the full text as a resource
+	MaxLength int `json:"max_length" yaml:"max_length"`
+
+	// Reduce markdown in comments to plain text
+	StripMarkdown bool `json:"strip_markdown" yaml:"strip_markdown"`
+
+	// Comment lines starting with any of these markers are removed
+	// (case-insensitive, e.g. "TODO:", "@internal")
+	DropLines []string `json:"drop_lines" yaml:"drop_lines"`
+
+	// Regular expression replacements applied to comments, in order
+	Scrub []ScrubRuleConfig `json:"scrub" yaml:"scrub"`
+}
+
+// LazySchemasConfig controls compact tools/list entries, for backends whose
+// full tool list exceeds client limits
+type LazySchemasConfig struct {
+	// List tools by name and the first line of their description, with
+	// schemas replaced by a $ref to the schemas endpoint; full definitions
+	// are returned by the ggrmcp_describe_tool tool and /schemas/{tool}
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Only compact lists of more tools than this (0 to always compact)
+	MinTools int `json:"min_tools" yaml:"min_tools"`
+}
+
+// FlattenConfig controls flattening of request messages that only wrap
+// another message, such as CreateOrderRequest { Order order = 1; }
+type FlattenConfig struct {
+	// Let tools whose request has a single message field take that
+	// message's fields as their arguments; the arguments are wrapped back
+	// into the request before invocation
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Tools flattened, as path.Match patterns (all eligible tools if empty)
+	Tools []string `json:"tools" yaml:"tools"`
+}
+
+// ScrubRuleConfig replaces matches of a regular expression in comments
+type ScrubRuleConfig struct {
+	// Regular expression (RE2 syntax)
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// Replacement, which may refer to groups as $1
+	Replacement string `json:"replacement" yaml:"replacement"`
+}
+
+// PaginationConfig controls automatic pagination of list methods
+type PaginationConfig struct {
+	// Accept a "_pages" argument on methods whose request has a page_token
+	// field and whose response has a next_page_token field; the gateway then
+	// fetches up to that many pages and concatenates their items
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Most pages a single call may fetch
+	MaxPages int `json:"max_pages" yaml:"max_pages"`
+}
+
+// LongRunningConfig controls tools whose method returns google.longrunning.Operation
+type LongRunningConfig struct {
+	// "" (default) returns operations as the backend sends them; "wait" polls
+	// Operations.GetOperation until the operation is done and returns its
+	// response; "handle" returns the pending operation at once. Both modes add
+	// the ggrmcp_poll_operation tool to check on pending operations.
+	Mode string `json:"mode" yaml:"mode"`
+
+	// How long "wait" polls before returning the pending operation instead
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Delay between two polls
+	PollInterval time.Duration `json:"poll_interval" yaml:"poll_interval"`
+}
+
+// DeprecationConfig controls how deprecated methods are listed
+type DeprecationConfig struct {
+	// Leave deprecated tools out of tools/list and refuse calls to them
+	Hide bool `json:"hide" yaml:"hide"`
+
+	// Replacement hints by deprecated tool name (e.g. the tool to use instead)
+	Replacements map[string]string `json:"replacements" yaml:"replacements"`
+}
+
+// SampledToolConfig flags a method whose response carries a prompt for the
+// client's LLM; the completion is passed to a follow-up method
+type SampledToolConfig struct {
+	// Tool name whose responses may request sampling
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Response field holding the prompt; sampling only happens when it is set
+	PromptField string `json:"prompt_field" yaml:"prompt_field"`
+
+	// System prompt sent with the sampling request
+	SystemPrompt string `json:"system_prompt" yaml:"system_prompt"`
+
+	// Maximum tokens to sample (mcp.sampling.max_tokens if zero)
+	MaxTokens int `json:"max_tokens" yaml:"max_tokens"`
+
+	// Tool invoked with the completion
+	FollowUpTool string `json:"follow_up_tool" yaml:"follow_up_tool"`
+
+	// Follow-up argument receiving the completion text
+	CompletionField string `json:"completion_field" yaml:"completion_field"`
+
+	// Response fields copied to the follow-up arguments under the same name
+	PassFields []string `json:"pass_fields" yaml:"pass_fields"`
+}
+
+// ArgumentDefaultsConfig provides argument values for a tool. Every matching
+// entry applies, in order; later entries override earlier ones.
+type ArgumentDefaultsConfig struct {
+	// Tool name the values apply to ("*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Values of arguments the client leaves out; nested objects are merged
+	Defaults map[string]interface{} `json:"defaults" yaml:"defaults"`
+
+	// Named argument sets a client selects with the "_preset" argument,
+	// applied over the defaults and under the client's arguments
+	Presets map[string]map[string]interface{} `json:"presets" yaml:"presets"`
+}
+
+// CompositeToolConfig defines a tool that chains several RPCs
+type CompositeToolConfig struct {
+	// Name of the tool
+	Name string `json:"name" yaml:"name"`
+
+	// Description shown to clients
+	Description string `json:"description" yaml:"description"`
+
+	// JSON Schema of the tool's arguments (any object when empty)
+	InputSchema map[string]interface{} `json:"input_schema" yaml:"input_schema"`
+
+	// RPCs called in order; the chain stops at the first failure
+	Steps []CompositeStepConfig `json:"steps" yaml:"steps"`
+
+	// jq expression over .input and .steps building the tool's output
+	// (every step's result when empty)
+	Output string `json:"output" yaml:"output"`
+}
+
+// CompositeStepConfig is one RPC of a composite tool
+type CompositeStepConfig struct {
+	// Name by which later steps refer to the step's result (.steps.<name>)
+	Name string `json:"name" yaml:"name"`
+
+	// Tool the step invokes
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Arguments of the call; strings may embed jq expressions in {{ }} over
+	// .input (the composite tool's arguments) and .steps (earlier results)
+	Arguments map[string]interface{} `json:"arguments" yaml:"arguments"`
+
+	// jq expression; the step runs only when it is true (always when empty)
+	When string `json:"when" yaml:"when"`
+
+	// RPC undoing the step, called when a later step fails
+	Compensate *CompensationConfig `json:"compensate" yaml:"compensate"`
+}
+
+// CompensationConfig is the RPC that rolls back a composite step
+type CompensationConfig struct {
+	// Tool the compensation invokes
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Arguments of the call, templated like step arguments; .failure holds
+	// the failed step's name and error
+	Arguments map[string]interface{} `json:"arguments" yaml:"arguments"`
+}
+
+// compositeNamePattern matches step names usable in jq paths
+var compositeNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PathArgumentConfig names request arguments that hold filesystem paths
+type PathArgumentConfig struct {
+	// Tool name the fields belong to ("*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Dot-separated argument paths holding a path or a list of paths
+	Fields []string `json:"fields" yaml:"fields"`
+
+	// Resolve relative paths against the first root instead of rejecting them
+	Prefix bool `json:"prefix" yaml:"prefix"`
+}
+
+// MediaFieldConfig maps a response bytes field to an MCP image or audio content block
+type MediaFieldConfig struct {
+	// Field name, fully qualified or ending in Message.field (e.g. "Render.image_png")
+	Field string `json:"field" yaml:"field"`
+
+	// Content type: "image" or "audio"
+	Type string `json:"type" yaml:"type"`
+
+	// MIME type of the data (detected from the content if empty)
+	MimeType string `json:"mime_type" yaml:"mime_type"`
+}
+
+// BinaryInputsConfig contains settings for resolving bytes field arguments
+type BinaryInputsConfig struct {
+	// Resolve resource URIs and data URIs passed for bytes fields
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Maximum total decoded bytes resolved into a single call
+	MaxBytes int64 `json:"max_bytes" yaml:"max_bytes"`
+}
+
+// BytesEncodingConfig controls the encodings accepted for bytes field arguments
+type BytesEncodingConfig struct {
+	// Check bytes arguments before invocation, accepting standard and URL-safe
+	// base64 with or without padding, and pass them on as standard base64
+	Normalize bool `json:"normalize" yaml:"normalize"`
+
+	// Also accept hex values prefixed with "0x" (implies normalize)
+	AcceptHex bool `json:"accept_hex" yaml:"accept_hex"`
+}
+
+// BinaryFieldsConfig contains settings for returning bytes fields as resources
+type BinaryFieldsConfig struct {
+	// Store large bytes fields as resources
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Decoded size above which a bytes field is stored
+	ThresholdBytes int `json:"threshold_bytes" yaml:"threshold_bytes"`
+
+	// MIME types by full field name (e.g. "pkg.Document.content"); others are sniffed
+	MimeTypes map[string]string `json:"mime_types" yaml:"mime_types"`
+}
+
+// ResponseLimitConfig caps the size of a tool's response returned to the model
+type ResponseLimitConfig struct {
+	// Tool name the limit applies to ("*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Maximum response size in bytes (0 means unlimited)
+	MaxBytes int `json:"max_bytes" yaml:"max_bytes"`
+
+	// Maximum response size in estimated tokens (0 means unlimited)
+	MaxTokens int `json:"max_response_tokens" yaml:"max_response_tokens"`
+
+	// Store the full response as a resource and link it from the result
+	StoreFull bool `json:"store_full" yaml:"store_full"`
+}
+
+// ArgumentLimitConfig bounds the arguments of calls to a tool at any depth,
+// whatever the tool's schema
+type ArgumentLimitConfig struct {
+	// Tool name the limits apply to ("*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Maximum number of items in an array (0 means unlimited)
+	MaxArrayLength int `json:"max_array_length" yaml:"max_array_length"`
+
+	// Maximum number of entries in an object, such as a map field (0 means unlimited)
+	MaxMapEntries int `json:"max_map_entries" yaml:"max_map_entries"`
+
+	// Maximum length of a string in bytes (0 means unlimited)
+	MaxStringLength int `json:"max_string_length" yaml:"max_string_length"`
+}
+
+// SlowCallConfig sets the latency above which calls to a tool are slow
+type SlowCallConfig struct {
+	// Tool name pattern the threshold applies to (e.g. "shop_reportservice_*", "*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Calls taking longer are logged with a warning and counted in /metrics
+	Threshold time.Duration `json:"threshold" yaml:"threshold"`
+
+	// Also warn the client by setting slowThresholdMs in the result's _meta
+	Warn bool `json:"warn" yaml:"warn"`
+}
+
+// ScriptConfig attaches a sandboxed Starlark script to a tool
+type ScriptConfig struct {
+	// Tool name the script applies to ("*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Path to the Starlark script file
+	Path string `json:"path" yaml:"path"`
+
+	// Maximum Starlark execution steps per invocation (CPU limit)
+	MaxSteps uint64 `json:"max_steps" yaml:"max_steps"`
+
+	// Maximum wall-clock time per invocation
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// Maximum JSON size of the payload passed to the script and of the dict
+	// it returns (1MB when unset)
+	MaxPayloadBytes int `json:"max_payload_bytes" yaml:"max_payload_bytes"`
+}
+
+// TransformConfig describes a built-in JSON transformation for tool calls
+type TransformConfig struct {
+	// Tool name the transformation applies to ("*" for all tools)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Payload to transform: "request" (arguments) or "response"
+	Stage string `json:"stage" yaml:"stage"`
+
+	// Fields to rename (dot-separated path -> new path)
+	Rename map[string]string `json:"rename" yaml:"rename"`
+
+	// Fields to remove
+	Strip []string `json:"strip" yaml:"strip"`
+
+	// Numeric fields to multiply by a factor (e.g. unit conversion)
+	Scale map[string]float64 `json:"scale" yaml:"scale"`
+}
+
+// CacheConfig contains caching settings
+type CacheConfig struct {
+	Enabled    bool          `json:"enabled" yaml:"enabled"`
+	TTL        time.Duration `json:"ttl" yaml:"ttl"`
+	MaxEntries int           `json:"max_entries" yaml:"max_entries"`
+}
+
+// LoggingConfig contains logging settings
+type LoggingConfig struct {
+	Level       string `json:"level" yaml:"level"`
+	Format      string `json:"format" yaml:"format"`
+	Development bool   `json:"development" yaml:"development"`
+}
+
+// ChaosErrorCodes lists the gRPC status codes chaos mode can inject
+var ChaosErrorCodes = []string{
+	"CANCELLED",
+	"UNKNOWN",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// ChaosConfig contains fault injection settings. Each fault is drawn
+// independently for every tool call to the gRPC backend; the gateway only
+// accepts it in development mode (--dev).
+type ChaosConfig struct {
+	// Enable fault injection
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Tools affected (empty means all tools)
+	Tools []string `json:"tools" yaml:"tools"`
+
+	// Percentage of calls delayed, the delay lasting Latency plus up to LatencyJitter
+	LatencyPercent float64       `json:"latency_percent" yaml:"latency_percent"`
+	Latency        time.Duration `json:"latency" yaml:"latency"`
+	LatencyJitter  time.Duration `json:"latency_jitter" yaml:"latency_jitter"`
+
+	// Percentage of calls failed with one of ErrorCodes, picked at random,
+	// without reaching the backend
+	ErrorPercent float64  `json:"error_percent" yaml:"error_percent"`
+	ErrorCodes   []string `json:"error_codes" yaml:"error_codes"`
+
+	// Percentage of successful responses cut off at a random byte
+	TruncatePercent float64 `json:"truncate_percent" yaml:"truncate_percent"`
+
+	// Seed of the random faults, for reproducible runs (0 seeds from the clock)
+	Seed int64 `json:"seed" yaml:"seed"`
+}
+
+// SelfTestConfig contains the settings of the startup self-test
+type SelfTestConfig struct {
+	// Run the self-test at startup and exit if it fails
+	OnStartup bool `json:"on_startup" yaml:"on_startup"`
+
+	// Tool invoked to verify calls end to end, such as a ping or no-op method
+	// (the invocation check is skipped when empty)
+	Tool string `json:"tool" yaml:"tool"`
+
+	// JSON arguments of the tool call
+	Arguments string `json:"arguments" yaml:"arguments"`
+
+	// Time allowed for the whole self-test
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// Default returns a configuration with sensible defaults
+func Default() *Config {
+	return &Config{
 		Server: ServerConfig{
 			Port:           50053,
 			Timeout:        30 * time.Second,
+			ReadTimeout:    15 * time.Second,
+			WriteTimeout:   15 * time.Second,
+			IdleTimeout:    60 * time.Second,
 			MaxRequestSize: 4 * 1024 * 1024, // 4MB
+			Streaming: StreamingConfig{
+				Enabled:      false, // Disabled by default
+				ChunkSize:    64 * 1024,
+				ChunkTimeout: 15 * time.Second,
+			},
 			Security: SecurityConfig{
 				EnableHeaders: true,
+				Headers: SecurityHeadersConfig{
+					FrameOptions:            "DENY",
+					XSSProtection:           "1; mode=block",
+					StrictTransportSecurity: "max-age=31536000; includeSubDomains",
+					ReferrerPolicy:          "strict-origin-when-cross-origin",
+					ContentSecurityPolicy: "default-src 'self'; " +
+						"script-src 'self' 'unsafe-inline'; " +
+						"style-src 'self' 'unsafe-inline'; " +
+						"img-src 'self' data: https:; " +
+						"connect-src 'self'",
+					CacheControl: "no-store",
+					Strip:        []string{"Server", "X-Powered-By", "Via"},
+				},
 				CORS: CORSConfig{
 					AllowedOrigins: []string{"*"},
 					AllowedMethods: []string{"GET", "POST", "OPTIONS"},
@@ -226,6 +1856,37 @@ func Default() *Config {
 					BurstSize:         100,
 					WindowSize:        time.Minute,
 				},
+				Policy: PolicyConfig{
+					Enabled:  false, // Disabled by default
+					Timeout:  2 * time.Second,
+					FailOpen: false,
+				},
+				Quota: QuotaConfig{
+					Enabled:   false, // Disabled by default
+					KeyHeader: "X-API-Key",
+				},
+				Replay: ReplayConfig{
+					Enabled:         false, // Disabled by default
+					Window:          5 * time.Minute,
+					Methods:         []string{"tools/call", "tools/call_batch"},
+					TimestampHeader: "X-Request-Timestamp",
+					NonceHeader:     "X-Request-Nonce",
+					SignatureHeader: "X-Request-Signature",
+					MaxNonces:       100000,
+				},
+				JWT: JWTConfig{
+					JWKSRefresh: 10 * time.Minute,
+					Leeway:      time.Minute,
+				},
+				RBAC: RBACConfig{
+					Claims: []string{"roles", "groups"},
+				},
+			},
+			Middleware: MiddlewareConfig{
+				Recovery: RecoveryConfig{
+					LogStack:     true,
+					ErrorMessage: "Internal Server Error",
+				},
 			},
 		},
 		GRPC: GRPCConfig{
@@ -243,6 +1904,11 @@ func Default() *Config {
 				MaxAttempts: 5,
 			},
 			MaxMessageSize: 4 * 1024 * 1024, // 4MB
+			LogCalls:       true,
+			GoogleIDToken: GoogleIDTokenConfig{
+				Enabled: false, // Disabled by default
+				Header:  "authorization",
+			},
 			HeaderForwarding: HeaderForwardingConfig{
 				Enabled: true,
 				AllowedHeaders: []string{
@@ -264,8 +1930,10 @@ func Default() *Config {
 					"upgrade",
 					"mcp-session-id",
 				},
-				ForwardAll:    false,
-				CaseSensitive: false,
+				ForwardAll:       false,
+				CaseSensitive:    false,
+				InvalidValues:    "drop",
+				MaxMetadataBytes: 8 * 1024, // 8KB, the smallest common server limit
 			},
 			DescriptorSet: DescriptorSetConfig{
 				Enabled:              false, // Disabled by default
@@ -273,8 +1941,25 @@ func Default() *Config {
 				PreferOverReflection: false,
 				IncludeSourceInfo:    true,
 			},
+			Shadow: ShadowConfig{
+				Enabled:     false, // Disabled by default
+				Timeout:     30 * time.Second,
+				MaxInFlight: 64,
+			},
+			Canary: CanaryConfig{
+				Enabled: false, // Disabled by default
+				Percent: 0,
+			},
+			Discovery: DiscoveryConfig{
+				ServerInfo: ServerInfoConfig{
+					Enabled: true,
+				},
+			},
 		},
 		MCP: MCPConfig{
+			Server: MCPServerConfig{
+				Name: "ggRMCP",
+			},
 			ProtocolVersion: "2024-11-05",
 			Validation: ValidationConfig{
 				MaxFieldLength:    1024,
@@ -282,6 +1967,90 @@ func Default() *Config {
 				MaxRequestSize:    4 * 1024 * 1024,  // 4MB
 				MaxResponseSize:   16 * 1024 * 1024, // 16MB
 			},
+			ErrorCatalog: ErrorCatalogConfig{
+				Enabled: false, // Disabled by default
+				Entries: []ErrorCatalogEntry{},
+			},
+			Batch: BatchConfig{
+				Enabled:     false, // Opt-in extension
+				MaxItems:    20,
+				Concurrency: 4,
+			},
+			Roots: RootsConfig{
+				FetchTimeout: 5 * time.Second,
+			},
+			Sampling: SamplingConfig{
+				Timeout:   2 * time.Minute,
+				MaxTokens: 1024,
+			},
+			Resources: ResourcesConfig{
+				TTL:              15 * time.Minute,
+				MaxEntries:       1000,
+				MaxTotalBytes:    256 * 1024 * 1024, // 256MB
+				MaxResourceBytes: 16 * 1024 * 1024,  // 16MB
+			},
+			ResponseCache: ResponseCacheConfig{
+				MinBytes:     16 * 1024, // 16KB
+				PageBytes:    4 * 1024,  // 4KB
+				MaxPageBytes: 64 * 1024, // 64KB
+			},
+			WellKnown: WellKnownConfig{
+				Enabled: true,
+			},
+			Meta: MetaConfig{
+				Echo: true,
+			},
+			Events: EventsConfig{
+				Driver: "nats",
+				Topics: EventTopicsConfig{
+					Invocation: "ggrmcp.invocations",
+					Discovery:  "ggrmcp.discovery",
+				},
+				NATS: NATSConfig{
+					URL: "nats://localhost:4222",
+				},
+				BatchSize:        100,
+				FlushInterval:    time.Second,
+				QueueSize:        10000,
+				MaxRetries:       3,
+				RetryBackoff:     time.Second,
+				Timeout:          10 * time.Second,
+				MaxArgumentBytes: 1024,
+			},
+			History: HistoryConfig{
+				Driver:     "memory",
+				Retention:  7 * 24 * time.Hour,
+				MaxEntries: 10000,
+				RedactFields: []string{
+					"password", "secret", "token", "access_token", "refresh_token",
+					"api_key", "apiKey", "authorization", "credential", "credentials",
+				},
+				MaxArgumentBytes: 4096,
+				MaxResultBytes:   4096,
+				QueueSize:        1000,
+				Tool:             true,
+			},
+			Approval: ApprovalConfig{
+				Channel:    "admin",
+				Timeout:    10 * time.Second,
+				MaxPending: 100,
+				MaxAudit:   1000,
+			},
+			Maintenance: MaintenanceConfig{
+				Enabled:    false,
+				Message:    "The gateway is under maintenance.",
+				RetryAfter: 5 * time.Minute,
+			},
+			ToolStats: ToolStatsConfig{
+				Window: 1000,
+			},
+			UsageLabels: UsageLabelsConfig{
+				OriginHeader: "Origin",
+				MaxValues:    100,
+			},
+			Profiles: ProfilesConfig{
+				Header: "Mcp-Profile",
+			},
 		},
 		Session: SessionConfig{
 			Expiration:      30 * time.Minute,
@@ -292,6 +2061,19 @@ func Default() *Config {
 				BurstSize:         20,
 				WindowSize:        time.Minute,
 			},
+			Affinity: AffinityConfig{
+				Enabled: false,
+				Header:  "X-Gateway-Replica",
+				Cookie:  "ggrmcp_replica",
+			},
+			Migration: MigrationConfig{
+				Enabled:       false,
+				MaxImportSize: 64 * 1024 * 1024, // 64MB
+			},
+			Stateless: StatelessSessionConfig{
+				Enabled: false,
+				MaxAge:  24 * time.Hour,
+			},
 		},
 		Tools: ToolsConfig{
 			Cache: CacheConfig{
@@ -299,51 +2081,832 @@ func Default() *Config {
 				TTL:        1 * time.Hour,
 				MaxEntries: 1000,
 			},
-			MaxDepth:      10,
-			MaxFields:     100,
-			MaxEnumValues: 50,
+			MaxDepth:        10,
+			MaxFields:       100,
+			MaxEnumValues:   50,
+			Int64Encoding:   "integer",
+			DoublePrecision: "round",
+			BinaryFields: BinaryFieldsConfig{
+				Enabled:        false, // Disabled by default
+				ThresholdBytes: 64 * 1024,
+			},
+			BinaryInputs: BinaryInputsConfig{
+				Enabled:  false,            // Disabled by default
+				MaxBytes: 16 * 1024 * 1024, // 16MB
+			},
+			Pagination: PaginationConfig{
+				Enabled:  false, // Disabled by default
+				MaxPages: 10,
+			},
+			LongRunning: LongRunningConfig{
+				Mode:         "", // Disabled by default
+				Timeout:      2 * time.Minute,
+				PollInterval: time.Second,
+			},
+			Chaos: ChaosConfig{
+				Enabled:    false, // Development only
+				ErrorCodes: []string{"UNAVAILABLE", "DEADLINE_EXCEEDED", "RESOURCE_EXHAUSTED", "INTERNAL"},
+			},
 		},
 		Logging: LoggingConfig{
 			Level:       "info",
 			Format:      "json",
 			Development: false,
 		},
+		SelfTest: SelfTestConfig{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Development returns a configuration suitable for development
+func Development() *Config {
+	config := Default()
+
+	// Override development-specific settings
+	config.Logging.Level = "debug"
+	config.Logging.Development = true
+	config.Server.Security.CORS.AllowedOrigins = []string{"http://localhost:3000", "http://127.0.0.1:3000"}
+	config.Session.RateLimit.RequestsPerMinute = 1000 // Higher limit for development
+
+	return config
+}
+
+// Validate validates the configuration
+func (c *Config) Validate() error {
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		return fmt.Errorf("invalid server port: %d", c.Server.Port)
+	}
+
+	if c.GRPC.Port <= 0 || c.GRPC.Port > 65535 {
+		return fmt.Errorf("invalid gRPC port: %d", c.GRPC.Port)
+	}
+
+	if c.Server.Timeout <= 0 {
+		return fmt.Errorf("server timeout must be positive")
+	}
+
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		return fmt.Errorf("server read, write and idle timeouts must not be negative")
+	}
+
+	if err := c.Server.Middleware.validate(); err != nil {
+		return err
+	}
+
+	if c.Server.Streaming.Enabled {
+		if c.Server.Streaming.ChunkSize <= 0 {
+			return fmt.Errorf("streaming chunk size must be positive")
+		}
+		if c.Server.Streaming.ChunkTimeout <= 0 {
+			return fmt.Errorf("streaming chunk timeout must be positive")
+		}
+		if c.Server.Streaming.PingInterval < 0 {
+			return fmt.Errorf("streaming ping interval must not be negative")
+		}
+	}
+
+	switch c.GRPC.Discovery.Versions.Mode {
+	case "", "all", "collapse":
+	default:
+		return fmt.Errorf("discovery versions mode must be \"all\" or \"collapse\"")
+	}
+
+	if c.GRPC.ConnectTimeout <= 0 {
+		return fmt.Errorf("gRPC connect timeout must be positive")
+	}
+
+	switch c.GRPC.HeaderForwarding.InvalidValues {
+	case "drop", "encode":
+	default:
+		return fmt.Errorf("header forwarding invalid values must be \"drop\" or \"encode\"")
+	}
+	if c.GRPC.HeaderForwarding.MaxMetadataBytes < 0 {
+		return fmt.Errorf("header forwarding max metadata bytes must not be negative")
+	}
+
+	if c.Session.MaxSessions <= 0 {
+		return fmt.Errorf("max sessions must be positive")
+	}
+
+	if c.Session.Affinity.Enabled && c.Session.Affinity.Header == "" && c.Session.Affinity.Cookie == "" {
+		return fmt.Errorf("session affinity needs a header or a cookie")
+	}
+
+	if c.Session.Migration.Enabled {
+		if c.Session.Migration.Token == "" {
+			return fmt.Errorf("session migration endpoints need a token")
+		}
+		if c.Session.Migration.MaxImportSize <= 0 {
+			return fmt.Errorf("session migration max import size must be positive")
+		}
+	}
+
+	if c.Session.Stateless.Enabled {
+		if len(c.Session.Stateless.Secret) < 32 {
+			return fmt.Errorf("stateless session secret must be at least 32 bytes")
+		}
+		if c.Session.Stateless.MaxAge <= 0 {
+			return fmt.Errorf("stateless session max age must be positive")
+		}
+		if c.Session.Migration.Enabled || c.Session.Migration.StateFile != "" {
+			return fmt.Errorf("stateless sessions cannot be migrated")
+		}
+	}
+
+	// Validate policy configuration
+	if c.Server.Security.Policy.Enabled {
+		if c.Server.Security.Policy.URL == "" {
+			return fmt.Errorf("policy URL must be specified when enabled")
+		}
+		if c.Server.Security.Policy.Timeout <= 0 {
+			return fmt.Errorf("policy timeout must be positive")
+		}
+	}
+
+	// Validate quota configuration
+	if c.Server.Security.Quota.Enabled {
+		if c.Server.Security.Quota.KeyHeader == "" {
+			return fmt.Errorf("quota key header must be specified when enabled")
+		}
+		plans := []QuotaPlanConfig{{
+			Daily:   c.Server.Security.Quota.Daily,
+			Monthly: c.Server.Security.Quota.Monthly,
+		}}
+		for _, plan := range c.Server.Security.Quota.Keys {
+			plans = append(plans, plan)
+		}
+		for _, plan := range plans {
+			if plan.Daily.Calls < 0 || plan.Daily.Bytes < 0 || plan.Monthly.Calls < 0 || plan.Monthly.Bytes < 0 ||
+				plan.Daily.Cost < 0 || plan.Monthly.Cost < 0 {
+				return fmt.Errorf("quota limits must not be negative")
+			}
+		}
+		if c.Server.Security.Quota.SessionCost < 0 {
+			return fmt.Errorf("quota session cost must not be negative")
+		}
+		costTools := make(map[string]bool)
+		for _, cost := range c.Server.Security.Quota.Costs {
+			if cost.Tool == "" {
+				return fmt.Errorf("quota cost tool must be specified")
+			}
+			if cost.Cost < 0 {
+				return fmt.Errorf("quota cost of tool %s must not be negative", cost.Tool)
+			}
+			if costTools[cost.Tool] {
+				return fmt.Errorf("duplicate quota cost for tool %s", cost.Tool)
+			}
+			costTools[cost.Tool] = true
+		}
 	}
-}
 
-// Development returns a configuration suitable for development
-func Development() *Config {
-	config := Default()
+	// Validate replay protection configuration
+	if replay := c.Server.Security.Replay; replay.Enabled {
+		if replay.Secret == "" {
+			return fmt.Errorf("replay protection secret must be specified when enabled")
+		}
+		if replay.Window <= 0 {
+			return fmt.Errorf("replay protection window must be positive")
+		}
+		if len(replay.Methods) == 0 {
+			return fmt.Errorf("replay protection methods must be specified when enabled")
+		}
+		if replay.TimestampHeader == "" || replay.NonceHeader == "" || replay.SignatureHeader == "" {
+			return fmt.Errorf("replay protection headers must be specified when enabled")
+		}
+		if replay.MaxNonces <= 0 {
+			return fmt.Errorf("replay protection max nonces must be positive")
+		}
+	}
 
-	// Override development-specific settings
-	config.Logging.Level = "debug"
-	config.Logging.Development = true
-	config.Server.Security.CORS.AllowedOrigins = []string{"http://localhost:3000", "http://127.0.0.1:3000"}
-	config.Session.RateLimit.RequestsPerMinute = 1000 // Higher limit for development
+	// Validate JWT authentication and the roles granting tools
+	if jwt := c.Server.Security.JWT; jwt.Enabled {
+		if jwt.Secret == "" && jwt.JWKSURL == "" {
+			return fmt.Errorf("JWT secret or JWKS URL must be specified when enabled")
+		}
+		if jwt.Secret != "" && len(jwt.Secret) < 32 {
+			return fmt.Errorf("JWT secret must be at least 32 bytes")
+		}
+		if jwt.JWKSURL != "" {
+			parsed, err := url.Parse(jwt.JWKSURL)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("invalid JWKS URL %q: must be an http(s) URL", jwt.JWKSURL)
+			}
+			if jwt.JWKSRefresh <= 0 {
+				return fmt.Errorf("JWKS refresh must be positive")
+			}
+		}
+		if jwt.Leeway < 0 {
+			return fmt.Errorf("JWT leeway must not be negative")
+		}
+	}
+	for i, constraint := range c.Server.Security.ArgumentConstraints {
+		if !c.Server.Security.JWT.Enabled {
+			return fmt.Errorf("argument constraints require JWT authentication")
+		}
+		if constraint.Tool == "" || constraint.Argument == "" || constraint.Claim == "" {
+			return fmt.Errorf("argument constraint %d: tool, argument and claim must be specified", i)
+		}
+		if _, err := path.Match(constraint.Tool, ""); err != nil {
+			return fmt.Errorf("argument constraint %d: invalid tool pattern %q: %w", i, constraint.Tool, err)
+		}
+	}
+	if rbac := c.Server.Security.RBAC; rbac.Enabled {
+		if !c.Server.Security.JWT.Enabled {
+			return fmt.Errorf("RBAC requires JWT authentication")
+		}
+		if len(rbac.Claims) == 0 {
+			return fmt.Errorf("RBAC claims must be specified when enabled")
+		}
+		for _, role := range rbac.Roles {
+			if role.Name == "" {
+				return fmt.Errorf("RBAC role name must be specified")
+			}
+			for _, pattern := range role.Tools {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid tool pattern %q in role %q: %w", pattern, role.Name, err)
+				}
+			}
+		}
+	}
 
-	return config
-}
+	for key, name := range c.MCP.Meta.Forward {
+		if key == "" {
+			return fmt.Errorf("meta forward keys must not be empty")
+		}
+		if !metadataNamePattern.MatchString(name) || strings.HasPrefix(name, "grpc-") || strings.HasSuffix(name, "-bin") {
+			return fmt.Errorf("meta forward of %s: invalid metadata name: %q", key, name)
+		}
+	}
 
-// Validate validates the configuration
-func (c *Config) Validate() error {
-	if c.Server.Port <= 0 || c.Server.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", c.Server.Port)
+	// Validate error catalog configuration
+	for i, entry := range c.MCP.ErrorCatalog.Entries {
+		if entry.Reason == "" {
+			return fmt.Errorf("error catalog entry %d: reason must be specified", i)
+		}
+		if entry.Message == "" {
+			return fmt.Errorf("error catalog entry %d: message must be specified", i)
+		}
 	}
 
-	if c.GRPC.Port <= 0 || c.GRPC.Port > 65535 {
-		return fmt.Errorf("invalid gRPC port: %d", c.GRPC.Port)
+	// Validate proxy configuration
+	if c.GRPC.Proxy.URL != "" {
+		proxyURL, err := url.Parse(c.GRPC.Proxy.URL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported proxy scheme: %q", proxyURL.Scheme)
+		}
 	}
 
-	if c.Server.Timeout <= 0 {
-		return fmt.Errorf("server timeout must be positive")
+	// Validate transport security configuration
+	tlsConfigs := map[string]*TLSConfig{"gRPC": &c.GRPC.TLS}
+	if c.GRPC.Shadow.TLS != nil {
+		tlsConfigs["shadow"] = c.GRPC.Shadow.TLS
+	}
+	if c.GRPC.Canary.TLS != nil {
+		tlsConfigs["canary"] = c.GRPC.Canary.TLS
+	}
+	for name, tlsConfig := range tlsConfigs {
+		if err := tlsConfig.validate(); err != nil {
+			return fmt.Errorf("%s TLS: %w", name, err)
+		}
 	}
 
-	if c.GRPC.ConnectTimeout <= 0 {
-		return fmt.Errorf("gRPC connect timeout must be positive")
+	// Validate Google ID token configuration
+	if c.GRPC.GoogleIDToken.Enabled {
+		if c.GRPC.GoogleIDToken.Audience == "" {
+			return fmt.Errorf("google ID token audience must be specified when enabled")
+		}
+		if !c.GRPC.TLS.Enabled {
+			return fmt.Errorf("google ID tokens require gRPC TLS to be enabled")
+		}
 	}
 
-	if c.Session.MaxSessions <= 0 {
-		return fmt.Errorf("max sessions must be positive")
+	// Validate shadow configuration
+	if c.GRPC.Shadow.Enabled {
+		if c.GRPC.Shadow.Host == "" {
+			return fmt.Errorf("shadow host must be specified when enabled")
+		}
+		if c.GRPC.Shadow.Port <= 0 || c.GRPC.Shadow.Port > 65535 {
+			return fmt.Errorf("invalid shadow port: %d", c.GRPC.Shadow.Port)
+		}
+		if c.GRPC.Shadow.Timeout <= 0 {
+			return fmt.Errorf("shadow timeout must be positive")
+		}
+		if c.GRPC.Shadow.MaxInFlight <= 0 {
+			return fmt.Errorf("shadow max in flight must be positive")
+		}
+		for _, tool := range c.GRPC.Shadow.Tools {
+			if _, err := path.Match(tool, ""); err != nil {
+				return fmt.Errorf("invalid shadow tool pattern %q: %w", tool, err)
+			}
+		}
+	}
+
+	// Validate canary configuration
+	if c.GRPC.Canary.Enabled {
+		if c.GRPC.Canary.Host == "" {
+			return fmt.Errorf("canary host must be specified when enabled")
+		}
+		if c.GRPC.Canary.Port <= 0 || c.GRPC.Canary.Port > 65535 {
+			return fmt.Errorf("invalid canary port: %d", c.GRPC.Canary.Port)
+		}
+		if c.GRPC.Canary.Percent < 0 || c.GRPC.Canary.Percent > 100 {
+			return fmt.Errorf("canary percent must be between 0 and 100")
+		}
+		for service, percent := range c.GRPC.Canary.Services {
+			if percent < 0 || percent > 100 {
+				return fmt.Errorf("canary percent for %s must be between 0 and 100", service)
+			}
+		}
+	}
+
+	// Backend channelz stats are only reported by the diagnostics endpoint
+	if c.GRPC.Channelz.QueryBackend && c.GRPC.Channelz.AdminToken == "" {
+		return fmt.Errorf("channelz query_backend requires an admin token")
+	}
+
+	// Validate per-method limits
+	for name, limit := range c.GRPC.MethodLimits {
+		if limit.MaxConcurrent < 0 || limit.QPS < 0 || limit.Burst < 0 {
+			return fmt.Errorf("method limit for %s must not be negative", name)
+		}
+	}
+
+	// Validate transformations
+	for i, transform := range c.Tools.Transforms {
+		if transform.Tool == "" {
+			return fmt.Errorf("transform %d: tool must be specified", i)
+		}
+		if transform.Stage != "request" && transform.Stage != "response" {
+			return fmt.Errorf("transform %d: stage must be \"request\" or \"response\"", i)
+		}
+	}
+
+	// Validate scripts
+	for i, script := range c.Tools.Scripts {
+		if script.Tool == "" {
+			return fmt.Errorf("script %d: tool must be specified", i)
+		}
+		if script.Path == "" {
+			return fmt.Errorf("script %d: path must be specified", i)
+		}
+		if script.MaxPayloadBytes < 0 {
+			return fmt.Errorf("script %d: max payload bytes must not be negative", i)
+		}
+	}
+
+	// Validate response limits
+	for i, limit := range c.Tools.ResponseLimits {
+		if limit.Tool == "" {
+			return fmt.Errorf("response limit %d: tool must be specified", i)
+		}
+		if limit.MaxBytes < 0 || limit.MaxTokens < 0 {
+			return fmt.Errorf("response limit %d: limits must not be negative", i)
+		}
+	}
+
+	// Validate argument limits
+	for i, limit := range c.Tools.ArgumentLimits {
+		if limit.Tool == "" {
+			return fmt.Errorf("argument limit %d: tool must be specified", i)
+		}
+		if limit.MaxArrayLength < 0 || limit.MaxMapEntries < 0 || limit.MaxStringLength < 0 {
+			return fmt.Errorf("argument limit %d: limits must not be negative", i)
+		}
+	}
+
+	// Validate slow call thresholds
+	for i, slow := range c.Tools.SlowCalls {
+		if slow.Tool == "" {
+			return fmt.Errorf("slow call %d: tool must be specified", i)
+		}
+		if _, err := path.Match(slow.Tool, ""); err != nil {
+			return fmt.Errorf("slow call %d: invalid tool pattern %q: %w", i, slow.Tool, err)
+		}
+		if slow.Threshold <= 0 {
+			return fmt.Errorf("slow call %d: threshold must be positive", i)
+		}
+	}
+
+	if c.Tools.BinaryFields.Enabled && c.Tools.BinaryFields.ThresholdBytes <= 0 {
+		return fmt.Errorf("binary field threshold must be positive")
+	}
+	if c.Tools.BinaryInputs.Enabled && c.Tools.BinaryInputs.MaxBytes <= 0 {
+		return fmt.Errorf("binary input max bytes must be positive")
+	}
+
+	if c.Tools.MaxDepth <= 0 {
+		return fmt.Errorf("tools max depth must be positive")
+	}
+
+	switch c.Tools.Int64Encoding {
+	case "integer", "string", "both":
+	default:
+		return fmt.Errorf("int64 encoding must be \"integer\", \"string\" or \"both\"")
+	}
+
+	switch c.Tools.DoublePrecision {
+	case "round", "reject":
+	default:
+		return fmt.Errorf("double precision must be \"round\" or \"reject\"")
+	}
+
+	if c.Tools.Descriptions.MaxLength < 0 {
+		return fmt.Errorf("description max length must not be negative")
+	}
+	if c.Tools.LazySchemas.MinTools < 0 {
+		return fmt.Errorf("lazy schemas min tools must not be negative")
+	}
+	for _, pattern := range c.Tools.Flatten.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid flatten tool pattern %q: %w", pattern, err)
+		}
+	}
+	for i, rule := range c.Tools.Descriptions.Scrub {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("description scrub rule %d: invalid pattern: %w", i, err)
+		}
+	}
+
+	if c.Tools.Pagination.Enabled && c.Tools.Pagination.MaxPages < 1 {
+		return fmt.Errorf("pagination max pages must be positive")
+	}
+
+	switch c.Tools.LongRunning.Mode {
+	case "":
+	case "wait", "handle":
+		if c.Tools.LongRunning.PollInterval <= 0 {
+			return fmt.Errorf("long-running operation poll interval must be positive")
+		}
+		if c.Tools.LongRunning.Mode == "wait" && c.Tools.LongRunning.Timeout <= 0 {
+			return fmt.Errorf("long-running operation timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("long-running operation mode must be \"wait\" or \"handle\"")
+	}
+
+	for field, format := range c.Tools.Formats.Fields {
+		if !slices.Contains(StringFormats, format) {
+			return fmt.Errorf("format of field %s: unknown format: %s", field, format)
+		}
+	}
+
+	// Validate argument defaults
+	for i, defaults := range c.Tools.ArgumentDefaults {
+		if defaults.Tool == "" {
+			return fmt.Errorf("argument defaults %d: tool must be specified", i)
+		}
+		if len(defaults.Defaults) == 0 && len(defaults.Presets) == 0 {
+			return fmt.Errorf("argument defaults %d: defaults or presets must be specified", i)
+		}
+		for name := range defaults.Presets {
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("argument defaults %d: preset names must not be empty", i)
+			}
+		}
+	}
+
+	// Validate composite tools
+	compositeNames := make(map[string]bool)
+	for i, composite := range c.Tools.Composite {
+		if composite.Name == "" {
+			return fmt.Errorf("composite tool %d: name must be specified", i)
+		}
+		if compositeNames[composite.Name] {
+			return fmt.Errorf("composite tool %s: duplicate name", composite.Name)
+		}
+		compositeNames[composite.Name] = true
+		if len(composite.Steps) == 0 {
+			return fmt.Errorf("composite tool %s: steps must be specified", composite.Name)
+		}
+		stepNames := make(map[string]bool)
+		for j, step := range composite.Steps {
+			if !compositeNamePattern.MatchString(step.Name) {
+				return fmt.Errorf("composite tool %s: step %d: name must be letters, digits and underscores", composite.Name, j)
+			}
+			if stepNames[step.Name] {
+				return fmt.Errorf("composite tool %s: duplicate step %s", composite.Name, step.Name)
+			}
+			stepNames[step.Name] = true
+			if step.Tool == "" {
+				return fmt.Errorf("composite tool %s: step %s: tool must be specified", composite.Name, step.Name)
+			}
+			if step.Compensate != nil && step.Compensate.Tool == "" {
+				return fmt.Errorf("composite tool %s: step %s: compensation tool must be specified", composite.Name, step.Name)
+			}
+		}
+	}
+
+	// Validate path arguments
+	for i, paths := range c.Tools.PathArguments {
+		if paths.Tool == "" {
+			return fmt.Errorf("path argument %d: tool must be specified", i)
+		}
+		if len(paths.Fields) == 0 {
+			return fmt.Errorf("path argument %d: fields must be specified", i)
+		}
+	}
+	if c.MCP.Roots.FetchTimeout <= 0 {
+		return fmt.Errorf("roots fetch timeout must be positive")
+	}
+
+	// Validate sampled tools
+	for i, sampled := range c.Tools.Sampled {
+		if sampled.Tool == "" || sampled.FollowUpTool == "" {
+			return fmt.Errorf("sampled tool %d: tool and follow_up_tool must be specified", i)
+		}
+		if sampled.PromptField == "" || sampled.CompletionField == "" {
+			return fmt.Errorf("sampled tool %d: prompt_field and completion_field must be specified", i)
+		}
+		if sampled.MaxTokens < 0 {
+			return fmt.Errorf("sampled tool %d: max tokens cannot be negative", i)
+		}
+	}
+	if c.MCP.Sampling.Timeout <= 0 {
+		return fmt.Errorf("sampling timeout must be positive")
+	}
+	if c.MCP.Sampling.MaxTokens <= 0 {
+		return fmt.Errorf("sampling max tokens must be positive")
+	}
+
+	// Validate fault injection
+	chaos := c.Tools.Chaos
+	for name, percent := range map[string]float64{
+		"latency":  chaos.LatencyPercent,
+		"error":    chaos.ErrorPercent,
+		"truncate": chaos.TruncatePercent,
+	} {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("chaos %s percent must be between 0 and 100", name)
+		}
+	}
+	if chaos.Latency < 0 || chaos.LatencyJitter < 0 {
+		return fmt.Errorf("chaos latency cannot be negative")
+	}
+	if chaos.ErrorPercent > 0 && len(chaos.ErrorCodes) == 0 {
+		return fmt.Errorf("chaos error codes must be specified when error percent is set")
+	}
+	for _, code := range chaos.ErrorCodes {
+		if !slices.Contains(ChaosErrorCodes, code) {
+			return fmt.Errorf("invalid chaos error code %q: must be one of %v", code, ChaosErrorCodes)
+		}
+	}
+
+	// Validate self-test settings
+	if c.SelfTest.Timeout <= 0 {
+		return fmt.Errorf("self-test timeout must be positive")
+	}
+	if c.SelfTest.Arguments != "" {
+		var arguments map[string]interface{}
+		if err := json.Unmarshal([]byte(c.SelfTest.Arguments), &arguments); err != nil {
+			return fmt.Errorf("self-test arguments must be a JSON object: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(c.MCP.Server.Name) == "" {
+		return fmt.Errorf("MCP server name cannot be empty")
+	}
+
+	// Validate discovery document URLs
+	if c.MCP.WellKnown.PublicURL != "" {
+		if err := validateHTTPURL(c.MCP.WellKnown.PublicURL); err != nil {
+			return fmt.Errorf("invalid public URL: %w", err)
+		}
+	}
+	for _, server := range c.MCP.WellKnown.AuthorizationServers {
+		if err := validateHTTPURL(server); err != nil {
+			return fmt.Errorf("invalid authorization server: %w", err)
+		}
+	}
+
+	// Validate upstream MCP servers
+	prefixes := make(map[string]bool)
+	for i, upstream := range c.MCP.Upstreams {
+		if upstream.Name == "" {
+			return fmt.Errorf("upstream %d: name must be specified", i)
+		}
+		if (upstream.URL == "") == (upstream.Command == "") {
+			return fmt.Errorf("upstream %s: exactly one of url and command must be specified", upstream.Name)
+		}
+		prefix := upstream.Prefix
+		if prefix == "" {
+			prefix = upstream.Name
+		}
+		if !upstreamPrefixPattern.MatchString(prefix) {
+			return fmt.Errorf("upstream %s: prefix may only contain letters, digits and underscores: %s", upstream.Name, prefix)
+		}
+		if prefixes[prefix] {
+			return fmt.Errorf("upstream %s: duplicate prefix: %s", upstream.Name, prefix)
+		}
+		// Tools are routed by "<prefix>_", so a prefix must not start another one
+		for other := range prefixes {
+			if strings.HasPrefix(prefix+"_", other+"_") || strings.HasPrefix(other+"_", prefix+"_") {
+				return fmt.Errorf("upstream %s: prefix %s overlaps prefix %s", upstream.Name, prefix, other)
+			}
+		}
+		prefixes[prefix] = true
+		if upstream.Timeout < 0 {
+			return fmt.Errorf("upstream %s: timeout cannot be negative", upstream.Name)
+		}
+		if upstream.ToolsTTL < 0 {
+			return fmt.Errorf("upstream %s: tools TTL cannot be negative", upstream.Name)
+		}
+	}
+
+	// Validate webhooks
+	for i, webhook := range c.MCP.Webhooks {
+		if webhook.Name == "" {
+			return fmt.Errorf("webhook %d: name must be specified", i)
+		}
+		if err := validateHTTPURL(webhook.URL); err != nil {
+			return fmt.Errorf("webhook %s: invalid URL: %w", webhook.Name, err)
+		}
+		if webhook.BatchSize < 0 || webhook.QueueSize < 0 || webhook.MaxRetries < 0 {
+			return fmt.Errorf("webhook %s: batch size, queue size and max retries cannot be negative", webhook.Name)
+		}
+		if webhook.FlushInterval < 0 || webhook.RetryBackoff < 0 || webhook.Timeout < 0 {
+			return fmt.Errorf("webhook %s: durations cannot be negative", webhook.Name)
+		}
+		if webhook.MaxArgumentBytes < -1 {
+			return fmt.Errorf("webhook %s: max argument bytes must be -1 or more", webhook.Name)
+		}
+	}
+
+	// Validate the event stream
+	if events := c.MCP.Events; events.Enabled {
+		switch events.Driver {
+		case "kafka":
+			if err := validateHTTPURL(events.Kafka.RESTURL); err != nil {
+				return fmt.Errorf("invalid Kafka REST proxy URL: %w", err)
+			}
+		case "nats":
+			parsed, err := url.Parse(events.NATS.URL)
+			if err != nil || (parsed.Scheme != "nats" && parsed.Scheme != "tls") || parsed.Host == "" {
+				return fmt.Errorf("invalid NATS URL %q: must look like nats://host:port or tls://host:port", events.NATS.URL)
+			}
+			if events.NATS.TLS.SPIFFE.Enabled {
+				return fmt.Errorf("NATS TLS does not support SPIFFE")
+			}
+			natsTLS := events.NATS.TLS
+			natsTLS.Enabled = true
+			if err := natsTLS.validate(); err != nil {
+				return fmt.Errorf("invalid NATS TLS config: %w", err)
+			}
+			if strings.ContainsAny(events.Topics.Invocation+events.Topics.Discovery, " \t\r\n") {
+				return fmt.Errorf("NATS subjects cannot contain whitespace")
+			}
+		default:
+			return fmt.Errorf("invalid event driver %q: must be one of %v", events.Driver, EventDrivers)
+		}
+		if events.Topics.Invocation == "" && events.Topics.Discovery == "" {
+			return fmt.Errorf("events are enabled but no topic is set")
+		}
+		if events.BatchSize <= 0 || events.QueueSize <= 0 {
+			return fmt.Errorf("event batch size and queue size must be positive")
+		}
+		if events.FlushInterval <= 0 || events.Timeout <= 0 {
+			return fmt.Errorf("event flush interval and timeout must be positive")
+		}
+		if events.MaxRetries < 0 || events.RetryBackoff < 0 {
+			return fmt.Errorf("event max retries and retry backoff cannot be negative")
+		}
+		if events.MaxArgumentBytes < -1 {
+			return fmt.Errorf("event max argument bytes must be -1 or more")
+		}
+	}
+
+	// Validate the call history
+	if history := c.MCP.History; history.Enabled {
+		if !slices.Contains(HistoryDrivers, history.Driver) {
+			return fmt.Errorf("invalid history driver %q: must be one of %v", history.Driver, HistoryDrivers)
+		}
+		if history.Driver != "memory" && history.DSN == "" {
+			return fmt.Errorf("history DSN must be specified for the %s driver", history.Driver)
+		}
+		if history.Retention <= 0 {
+			return fmt.Errorf("history retention must be positive")
+		}
+		if history.Driver == "memory" && history.MaxEntries <= 0 {
+			return fmt.Errorf("history max entries must be positive")
+		}
+		if history.MaxArgumentBytes < -1 || history.MaxResultBytes < -1 {
+			return fmt.Errorf("history max argument and result bytes must be -1 or more")
+		}
+		if history.QueueSize <= 0 {
+			return fmt.Errorf("history queue size must be positive")
+		}
+	}
+
+	// Validate the approval gate
+	if c.MCP.ToolAdmin.StateFile != "" && c.MCP.ToolAdmin.AdminToken == "" {
+		return fmt.Errorf("tool admin state file requires an admin token")
+	}
+	if c.MCP.Maintenance.RetryAfter < 0 {
+		return fmt.Errorf("maintenance retry after must not be negative")
+	}
+	if c.MCP.ToolStats.Enabled && c.MCP.ToolStats.Window <= 0 {
+		return fmt.Errorf("tool stats window must be positive")
+	}
+
+	if c.MCP.UsageLabels.Enabled && c.MCP.UsageLabels.MaxValues <= 0 {
+		return fmt.Errorf("usage labels max values must be positive")
+	}
+
+	// Validate the toolset profiles
+	if profiles := c.MCP.Profiles; len(profiles.Definitions) > 0 || profiles.Default != "" {
+		if profiles.Header == "" {
+			return fmt.Errorf("profile header must be specified")
+		}
+		names := make(map[string]bool)
+		for _, profile := range profiles.Definitions {
+			if profile.Name == "" {
+				return fmt.Errorf("profile name must be specified")
+			}
+			if names[profile.Name] {
+				return fmt.Errorf("duplicate profile %q", profile.Name)
+			}
+			names[profile.Name] = true
+			if len(profile.Tools) == 0 {
+				return fmt.Errorf("profile %q must list at least one tool", profile.Name)
+			}
+			for _, pattern := range profile.Tools {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid tool pattern %q in profile %q: %w", pattern, profile.Name, err)
+				}
+			}
+		}
+		if profiles.Default != "" && !names[profiles.Default] {
+			return fmt.Errorf("default profile %q is not defined", profiles.Default)
+		}
+	}
+
+	if approval := c.MCP.Approval; len(approval.Tools) > 0 {
+		if !slices.Contains(ApprovalChannels, approval.Channel) {
+			return fmt.Errorf("invalid approval channel %q: must be one of %v", approval.Channel, ApprovalChannels)
+		}
+		if approval.Channel == "admin" && approval.AdminToken == "" {
+			return fmt.Errorf("approval admin token must be specified for the admin channel")
+		}
+		if approval.Timeout <= 0 {
+			return fmt.Errorf("approval timeout must be positive")
+		}
+		// A parked call holds its request open, so it must fit in the request's deadlines
+		order := c.Server.Middleware.Order
+		if (len(order) == 0 || slices.Contains(order, "timeout")) && approval.Timeout >= c.Server.Timeout {
+			return fmt.Errorf("approval timeout %s must be below the server timeout %s", approval.Timeout, c.Server.Timeout)
+		}
+		if c.Server.WriteTimeout > 0 && approval.Timeout >= c.Server.WriteTimeout {
+			return fmt.Errorf("approval timeout %s must be below the server write timeout %s", approval.Timeout, c.Server.WriteTimeout)
+		}
+		if approval.MaxPending <= 0 || approval.MaxAudit <= 0 {
+			return fmt.Errorf("approval max pending and max audit must be positive")
+		}
+	}
+
+	// Validate media field mappings
+	for i, media := range c.Tools.MediaFields {
+		if media.Field == "" {
+			return fmt.Errorf("media field %d: field must be specified", i)
+		}
+		if media.Type != "image" && media.Type != "audio" {
+			return fmt.Errorf("media field %d: type must be \"image\" or \"audio\"", i)
+		}
+	}
+
+	// Validate resource store limits
+	if c.MCP.Resources.TTL <= 0 {
+		return fmt.Errorf("resource TTL must be positive")
+	}
+	if c.MCP.Resources.MaxEntries <= 0 || c.MCP.Resources.MaxTotalBytes <= 0 || c.MCP.Resources.MaxResourceBytes <= 0 {
+		return fmt.Errorf("resource store limits must be positive")
+	}
+
+	// Validate the response cache
+	if cache := c.MCP.ResponseCache; cache.Enabled {
+		if cache.MinBytes <= 0 || cache.PageBytes <= 0 || cache.MaxPageBytes <= 0 {
+			return fmt.Errorf("response cache sizes must be positive")
+		}
+		if cache.PageBytes > cache.MaxPageBytes || cache.PageBytes > cache.MinBytes {
+			return fmt.Errorf("response cache page bytes cannot exceed max page bytes or min bytes")
+		}
+		if int64(cache.MinBytes) > c.MCP.Resources.MaxResourceBytes {
+			return fmt.Errorf("response cache min bytes cannot exceed the resource size limit")
+		}
+	}
+
+	// Validate batch configuration
+	if c.MCP.Batch.Enabled {
+		if c.MCP.Batch.MaxItems <= 0 {
+			return fmt.Errorf("batch max items must be positive")
+		}
+		if c.MCP.Batch.Concurrency <= 0 {
+			return fmt.Errorf("batch concurrency must be positive")
+		}
 	}
 
 	// Validate descriptor set configuration
@@ -351,7 +2914,66 @@ func (c *Config) Validate() error {
 		if c.GRPC.DescriptorSet.Path == "" {
 			return fmt.Errorf("descriptor set path must be specified when enabled")
 		}
+		verify := c.GRPC.DescriptorSet.Verify
+		if verify.SHA256 != "" && !sha256Digest.MatchString(strings.TrimPrefix(strings.ToLower(verify.SHA256), "sha256:")) {
+			return fmt.Errorf("descriptor set sha256 must be 64 hex characters")
+		}
+		if (verify.Signature == "") != (verify.PublicKey == "") {
+			return fmt.Errorf("descriptor set signature and public key must be set together")
+		}
+	}
+
+	return nil
+}
+
+// validate checks the TLS settings for consistency
+func (t *TLSConfig) validate() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	if t.SPIFFE.Enabled {
+		if len(t.SPIFFE.AuthorizedIDs) == 0 && t.SPIFFE.TrustDomain == "" {
+			return fmt.Errorf("SPIFFE requires authorized IDs or a trust domain")
+		}
+		return nil
 	}
 
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("cert file and key file must be specified together")
+	}
+
+	return nil
+}
+
+// validate checks the middleware names and recovery settings
+func (m *MiddlewareConfig) validate() error {
+	seen := make(map[string]bool, len(m.Order))
+	for _, name := range m.Order {
+		if !slices.Contains(MiddlewareNames, name) {
+			return fmt.Errorf("unknown middleware: %s", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("middleware listed twice: %s", name)
+		}
+		seen[name] = true
+	}
+
+	if m.Recovery.ErrorMessage == "" {
+		return fmt.Errorf("recovery error message must not be empty")
+	}
+
+	return nil
+}
+
+// validateHTTPURL checks that a URL is absolute with an http or https scheme
+func validateHTTPURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s is not an absolute http or https URL", raw)
+	}
 	return nil
 }
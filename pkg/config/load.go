@@ -0,0 +1,215 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretsTimeout bounds the resolution of all secret references of a config
+const secretsTimeout = 30 * time.Second
+
+// Load reads a YAML (or JSON) configuration file on top of the defaults,
+// resolving env and file secret references
+func Load(path string) (*Config, error) {
+	return LoadWithSecrets(path, NewSecretResolver())
+}
+
+// LoadWithSecrets reads a configuration file and resolves its secret
+// references with the resolver's providers before validating it
+func LoadWithSecrets(path string, secrets *SecretResolver) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	config := Default()
+	if err := decode(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	if err := secrets.Resolve(ctx, config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// decode parses a config file onto a config. Unknown keys and values of the
+// wrong type are all reported, each with its line and column.
+func decode(data []byte, config *Config) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		// Empty file: keep the defaults
+		return nil
+	}
+
+	var errs []error
+	checkNode(&root, reflect.TypeOf(config).Elem(), "", &errs)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return root.Decode(config)
+}
+
+// checkNode checks a YAML node against the config type it decodes into
+func checkNode(node *yaml.Node, t reflect.Type, path string, errs *[]error) {
+	fail := func(node *yaml.Node, format string, args ...interface{}) {
+		location := path
+		if location == "" {
+			location = "config"
+		}
+		*errs = append(*errs, fmt.Errorf("line %d, column %d: %s: %s",
+			node.Line, node.Column, location, fmt.Sprintf(format, args...)))
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			checkNode(content, t, path, errs)
+		}
+		return
+	case yaml.AliasNode:
+		checkNode(node.Alias, t, path, errs)
+		return
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		// Free-form values accept anything
+	case reflect.Pointer:
+		checkNode(node, t.Elem(), path, errs)
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			fail(node, "expected a mapping, got %s", nodeKind(node))
+			return
+		}
+		fields := make(map[string]reflect.StructField)
+		var names []string
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.IsExported() {
+				fields[yamlName(field)] = field
+				names = append(names, yamlName(field))
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				checkNode(value, t, path, errs)
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				if suggestion := closestKey(key.Value, names); suggestion != "" {
+					fail(key, "unknown key %q (did you mean %q?)", key.Value, suggestion)
+				} else {
+					fail(key, "unknown key %q", key.Value)
+				}
+				continue
+			}
+			checkNode(value, field.Type, joinPath(path, key.Value), errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			fail(node, "expected a mapping, got %s", nodeKind(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			fail(node, "expected a list, got %s", nodeKind(node))
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			fail(node, "expected %s, got %s", typeKind(t), nodeKind(node))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			fail(node, "expected %s, got %q", typeKind(t), node.Value)
+		}
+	}
+}
+
+// nodeKind names the kind of a YAML node in errors
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
+
+// typeKind names the values a scalar config type accepts in errors
+func typeKind(t reflect.Type) string {
+	if t == durationType {
+		return "a duration (e.g. 30s)"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a string"
+}
+
+// closestKey returns the known key a misspelled key most likely meant, if any
+func closestKey(key string, known []string) string {
+	// Allow about one typo per three characters
+	best, bestDistance := "", len(key)/3+2
+	for _, candidate := range known {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two keys
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
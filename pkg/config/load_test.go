@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Errors(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(content string) (*Config, error) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+		return Load(configFile)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		config, err := load(`
+server:
+  port: 8080
+  timeout: 45s
+grpc:
+  canary:
+    tls: &tls
+      enabled: true
+      ca_file: /etc/ca.pem
+  tls:
+    <<: *tls
+    server_name: backend.internal
+tools:
+  argument_defaults:
+    - tool: shop_orders_list
+      defaults: {page_size: 20, filter: {status: open}}
+`)
+		require.NoError(t, err)
+		assert.Equal(t, 8080, config.Server.Port)
+		assert.Equal(t, 45*time.Second, config.Server.Timeout)
+		assert.Equal(t, "/etc/ca.pem", config.GRPC.TLS.CAFile)
+		assert.Equal(t, "backend.internal", config.GRPC.TLS.ServerName)
+		assert.Equal(t, 20, config.Tools.ArgumentDefaults[0].Defaults["page_size"])
+
+		config, err = load("")
+		require.NoError(t, err)
+		assert.Equal(t, Default(), config)
+	})
+
+	t.Run("All_reported", func(t *testing.T) {
+		_, err := load(`server:
+  port: abc
+  securty:
+    enabled: true
+  timeout: 5 minutes
+grpc:
+  host: [a, b]
+`)
+		require.Error(t, err)
+		lines := strings.Split(err.Error(), "\n")
+		require.Len(t, lines, 4)
+		assert.Contains(t, lines[0], `line 2, column 9: server.port: expected an integer, got "abc"`)
+		assert.Equal(t, `line 3, column 3: server: unknown key "securty" (did you mean "security"?)`, lines[1])
+		assert.Equal(t, `line 5, column 12: server.timeout: expected a duration (e.g. 30s), got "5 minutes"`, lines[2])
+		assert.Equal(t, `line 7, column 9: grpc.host: expected a string, got a list`, lines[3])
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		_, err := load(`{"mcp": {"profiles": {"definitions": [{"name": "p", "tols": ["a*"]}]}}}`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `mcp.profiles.definitions[0]: unknown key "tols" (did you mean "tools"?)`)
+
+		_, err = load("session:\n  quota_like_nothing: 1\n")
+		require.Error(t, err)
+		assert.True(t, strings.HasSuffix(err.Error(), `session: unknown key "quota_like_nothing"`))
+	})
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	_ "embed"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaURI is the JSON Schema dialect of Schema
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the durations time.ParseDuration accepts, e.g. 30s or 1h30m
+const durationPattern = `^-?(0|([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$`
+
+// configSource is parsed for the doc comments that describe the schema's fields
+//
+//go:embed config.go
+var configSource string
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema returns a JSON Schema of the config file, for editors and CI checks.
+// Descriptions are the field comments of config.go and defaults are those of Default.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}), reflect.ValueOf(*Default()), configDocs())
+	schema["$schema"] = SchemaURI
+	schema["title"] = "ggRMCP configuration"
+	return schema
+}
+
+// typeSchema describes a config type; def is its default value
+func typeSchema(t reflect.Type, def reflect.Value, docs map[string]string) map[string]interface{} {
+	if t == durationType {
+		schema := map[string]interface{}{"type": "string", "pattern": durationPattern}
+		if def.IsValid() && !def.IsZero() {
+			schema["default"] = def.Interface().(time.Duration).String()
+		}
+		return schema
+	}
+
+	schema := map[string]interface{}{}
+	switch t.Kind() {
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		schema["minimum"] = 0
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), reflect.Value{}, docs)
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), reflect.Value{}, docs)
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), reflect.Value{}, docs)
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			var fieldDefault reflect.Value
+			if def.IsValid() {
+				fieldDefault = def.Field(i)
+			}
+			property := typeSchema(field.Type, fieldDefault, docs)
+			if doc := docs[t.Name()+"."+field.Name]; doc != "" {
+				property["description"] = doc
+			} else if doc := docs[field.Type.Name()]; doc != "" {
+				property["description"] = doc
+			}
+			properties[yamlName(field)] = property
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+		return schema
+	default:
+		// Free-form values (e.g. argument defaults) accept anything
+		return schema
+	}
+
+	if def.IsValid() && !def.IsZero() {
+		switch t.Kind() {
+		case reflect.Slice, reflect.Map:
+			if def.Len() > 0 && isScalarKind(t.Elem().Kind()) {
+				schema["default"] = def.Interface()
+			}
+		default:
+			schema["default"] = def.Interface()
+		}
+	}
+	return schema
+}
+
+// isScalarKind reports whether values of a kind are JSON scalars
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// configDocs maps "Type.Field" and "Type" to the doc comments of config.go
+func configDocs() map[string]string {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+
+	docs := make(map[string]string)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil {
+				doc = genDecl.Doc
+			}
+			docs[typeSpec.Name.Name] = commentText(doc)
+
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range structType.Fields.List {
+				text := commentText(field.Doc)
+				if text == "" {
+					text = commentText(field.Comment)
+				}
+				for _, name := range field.Names {
+					docs[typeSpec.Name.Name+"."+name.Name] = text
+				}
+			}
+		}
+	}
+	return docs
+}
+
+// commentText joins the lines of a comment into one
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
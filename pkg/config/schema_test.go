@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	assert.Equal(t, SchemaURI, schema["$schema"])
+
+	// The schema is plain JSON
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	property := func(path ...string) map[string]interface{} {
+		current := decoded
+		for _, name := range path {
+			current = current["properties"].(map[string]interface{})[name].(map[string]interface{})
+		}
+		return current
+	}
+
+	server := property("server")
+	assert.Equal(t, "object", server["type"])
+	assert.Equal(t, false, server["additionalProperties"])
+	assert.Equal(t, "Server configuration", server["description"])
+
+	port := property("server", "port")
+	assert.Equal(t, "integer", port["type"])
+	assert.EqualValues(t, 50053, port["default"])
+
+	leeway := property("server", "security", "jwt", "leeway")
+	assert.Equal(t, "string", leeway["type"])
+	assert.Equal(t, "1m0s", leeway["default"])
+	assert.Equal(t, "Clock skew allowed when checking exp and nbf", leeway["description"])
+	pattern := regexp.MustCompile(leeway["pattern"].(string))
+	for _, duration := range []string{"0", "30s", "1h30m", "1.5s", "250ms"} {
+		assert.True(t, pattern.MatchString(duration), duration)
+	}
+	for _, duration := range []string{"", "30", "5 minutes"} {
+		assert.False(t, pattern.MatchString(duration), duration)
+	}
+
+	definitions := property("mcp", "profiles", "definitions")
+	assert.Equal(t, "array", definitions["type"])
+	items := definitions["items"].(map[string]interface{})
+	assert.Contains(t, items["properties"], "tools")
+
+	// Free-form values accept anything
+	argumentDefaults := property("tools", "argument_defaults")["items"].(map[string]interface{})
+	defaults := argumentDefaults["properties"].(map[string]interface{})["defaults"].(map[string]interface{})
+	assert.Equal(t, "object", defaults["type"])
+	assert.Equal(t, map[string]interface{}{}, defaults["additionalProperties"])
+}
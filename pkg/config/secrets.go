@@ -0,0 +1,217 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces secrets in redacted configs
+const RedactedValue = "[REDACTED]"
+
+// secretReference matches a config value naming a secret instead of holding
+// it, e.g. ${env:JWT_SECRET} or ${file:/run/secrets/jwt}
+var secretReference = regexp.MustCompile(`^\$\{([a-z][a-z0-9_-]*):([^}]+)\}$`)
+
+// SecretProvider resolves the secret references of one scheme, so secrets can
+// be kept in Vault, a KMS or another store instead of the config file
+type SecretProvider interface {
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider
+type SecretProviderFunc func(ctx context.Context, reference string) (string, error)
+
+// Resolve calls the function
+func (f SecretProviderFunc) Resolve(ctx context.Context, reference string) (string, error) {
+	return f(ctx, reference)
+}
+
+// SecretResolver replaces the secret references in a config with their values
+type SecretResolver struct {
+	providers map[string]SecretProvider
+}
+
+// NewSecretResolver creates a resolver of the env and file schemes
+func NewSecretResolver() *SecretResolver {
+	r := &SecretResolver{providers: make(map[string]SecretProvider)}
+	r.Register("env", SecretProviderFunc(resolveEnvSecret))
+	r.Register("file", SecretProviderFunc(resolveFileSecret))
+	return r
+}
+
+// Register adds the provider of a scheme, replacing any previous one
+func (r *SecretResolver) Register(scheme string, provider SecretProvider) {
+	r.providers[scheme] = provider
+}
+
+// Resolve replaces every value of the config that is a secret reference.
+// Map keys and free-form values (argument defaults, presets) are left as they are.
+func (r *SecretResolver) Resolve(ctx context.Context, config *Config) error {
+	return r.resolveValue(ctx, reflect.ValueOf(config).Elem(), "")
+}
+
+// resolveValue walks a config value, naming fields by their YAML keys in errors
+func (r *SecretResolver) resolveValue(ctx context.Context, value reflect.Value, path string) error {
+	switch value.Kind() {
+	case reflect.String:
+		resolved, err := r.resolveString(ctx, value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		value.SetString(resolved)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if err := r.resolveValue(ctx, value.Field(i), joinPath(path, yamlName(field))); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if !value.IsNil() {
+			return r.resolveValue(ctx, value.Elem(), path)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			if err := r.resolveValue(ctx, value.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			// Map values are not addressable: resolve a copy and store it back
+			entry := reflect.New(value.Type().Elem()).Elem()
+			entry.Set(value.MapIndex(key))
+			if err := r.resolveValue(ctx, entry, joinPath(path, fmt.Sprint(key.Interface()))); err != nil {
+				return err
+			}
+			value.SetMapIndex(key, entry)
+		}
+	}
+	return nil
+}
+
+// resolveString returns the secret a value refers to, or the value itself
+func (r *SecretResolver) resolveString(ctx context.Context, value string) (string, error) {
+	match := secretReference.FindStringSubmatch(value)
+	if match == nil {
+		return value, nil
+	}
+	provider, ok := r.providers[match[1]]
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %q", match[1])
+	}
+	secret, err := provider.Resolve(ctx, match[2])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret: %w", match[1], err)
+	}
+	return secret, nil
+}
+
+// resolveEnvSecret reads a secret from an environment variable
+func resolveEnvSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveFileSecret reads a secret from a file, such as a mounted Kubernetes
+// or Docker secret, without its trailing newline
+func resolveFileSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Redacted returns a copy of the config with the secret fields replaced by
+// RedactedValue, for logging and export
+func (c *Config) Redacted() *Config {
+	redacted := redactedCopy(reflect.ValueOf(c).Elem(), "")
+	config := redacted.Interface().(Config)
+	return &config
+}
+
+// redactedCopy deep-copies a value, replacing secrets. mode is the secret tag
+// in effect: "true" redacts strings, "keys" redacts the keys of a map.
+func redactedCopy(value reflect.Value, mode string) reflect.Value {
+	copied := reflect.New(value.Type()).Elem()
+
+	switch value.Kind() {
+	case reflect.String:
+		if mode == "true" && value.String() != "" {
+			copied.SetString(RedactedValue)
+		} else {
+			copied.SetString(value.String())
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldMode := mode
+			if tag := field.Tag.Get("secret"); tag != "" {
+				fieldMode = tag
+			}
+			copied.Field(i).Set(redactedCopy(value.Field(i), fieldMode))
+		}
+	case reflect.Pointer:
+		if !value.IsNil() {
+			copied.Set(redactedCopy(value.Elem(), mode).Addr())
+		}
+	case reflect.Slice:
+		if !value.IsNil() {
+			copied.Set(reflect.MakeSlice(value.Type(), value.Len(), value.Len()))
+			for i := 0; i < value.Len(); i++ {
+				copied.Index(i).Set(redactedCopy(value.Index(i), mode))
+			}
+		}
+	case reflect.Map:
+		if value.IsNil() {
+			break
+		}
+		copied.Set(reflect.MakeMapWithSize(value.Type(), value.Len()))
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for i, key := range keys {
+			entryMode, copiedKey := mode, key
+			if mode == "keys" {
+				// Keys are the secrets (e.g. API keys): number them instead
+				entryMode = ""
+				copiedKey = reflect.ValueOf(fmt.Sprintf("%s %d", RedactedValue, i+1)).Convert(key.Type())
+			}
+			copied.SetMapIndex(copiedKey, redactedCopy(value.MapIndex(key), entryMode))
+		}
+	default:
+		// Numbers, booleans and free-form values are shared, never modified
+		copied.Set(value)
+	}
+	return copied
+}
+
+// yamlName returns the YAML key of a struct field
+func yamlName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+// joinPath appends a key to a dotted config path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWithSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "jwt-secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("0123456789abcdef0123456789abcdef\n"), 0o600))
+	t.Setenv("GGRMCP_TEST_HOOK_SECRET", "hook-secret")
+
+	configFile := filepath.Join(dir, "config.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+	}
+	write(`
+server:
+  security:
+    jwt:
+      enabled: true
+      secret: ${file:` + secretFile + `}
+mcp:
+  webhooks:
+    - name: siem
+      url: https://siem.example.com/hook
+      secret: ${env:GGRMCP_TEST_HOOK_SECRET}
+      headers:
+        Authorization: ${vault:kv/siem#token}
+session:
+  admin_token: ${env:GGRMCP_TEST_HOOK_SECRET}-literal}
+`)
+
+	secrets := NewSecretResolver()
+	secrets.Register("vault", SecretProviderFunc(func(ctx context.Context, reference string) (string, error) {
+		if reference != "kv/siem#token" {
+			return "", errors.New("no such secret")
+		}
+		return "Bearer from-vault", nil
+	}))
+
+	config, err := LoadWithSecrets(configFile, secrets)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", config.Server.Security.JWT.Secret)
+	assert.Equal(t, "hook-secret", config.MCP.Webhooks[0].Secret)
+	assert.Equal(t, "Bearer from-vault", config.MCP.Webhooks[0].Headers["Authorization"])
+
+	// Only whole values are references
+	assert.Equal(t, "${env:GGRMCP_TEST_HOOK_SECRET}-literal}", config.Session.AdminToken)
+
+	t.Run("Unresolvable", func(t *testing.T) {
+		_, err := Load(configFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `mcp.webhooks[0].headers.Authorization: unknown secret provider "vault"`)
+
+		write("session:\n  admin_token: ${env:GGRMCP_TEST_UNSET}\n")
+		_, err = Load(configFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "session.admin_token: failed to resolve env secret: environment variable GGRMCP_TEST_UNSET is not set")
+	})
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	config := Default()
+	config.Server.Security.JWT.Secret = "jwt-secret"
+	config.Server.Security.Quota.Keys = map[string]QuotaPlanConfig{
+		"partner-key": {Daily: QuotaLimitConfig{Calls: 10}},
+	}
+	config.Session.Stateless.PreviousSecrets = []string{"old-secret"}
+	config.MCP.Webhooks = []WebhookConfig{{
+		Name:    "siem",
+		URL:     "https://siem.example.com/hook",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}}
+	config.GRPC.Proxy.Password = "proxy-password"
+
+	redacted := config.Redacted()
+	assert.Equal(t, RedactedValue, redacted.Server.Security.JWT.Secret)
+	assert.Equal(t, map[string]QuotaPlanConfig{RedactedValue + " 1": {Daily: QuotaLimitConfig{Calls: 10}}}, redacted.Server.Security.Quota.Keys)
+	assert.Equal(t, []string{RedactedValue}, redacted.Session.Stateless.PreviousSecrets)
+	assert.Equal(t, map[string]string{"Authorization": RedactedValue}, redacted.MCP.Webhooks[0].Headers)
+	assert.Equal(t, "https://siem.example.com/hook", redacted.MCP.Webhooks[0].URL)
+	assert.Equal(t, RedactedValue, redacted.GRPC.Proxy.Password)
+
+	// Empty secrets stay empty, showing they are unset
+	assert.Empty(t, redacted.Server.Security.Replay.Secret)
+
+	// The original is untouched
+	assert.Equal(t, "jwt-secret", config.Server.Security.JWT.Secret)
+	assert.Equal(t, "Bearer token", config.MCP.Webhooks[0].Headers["Authorization"])
+	assert.Equal(t, []string{"old-secret"}, config.Session.Stateless.PreviousSecrets)
+	assert.Contains(t, config.Server.Security.Quota.Keys, "partner-key")
+}
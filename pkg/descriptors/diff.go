@@ -0,0 +1,347 @@
+package descriptors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Kinds of changes
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Elements a change applies to
+const (
+	ElementService   = "service"
+	ElementMethod    = "method"
+	ElementField     = "field"
+	ElementEnumValue = "enum_value"
+)
+
+// Change is one difference between two versions of an API
+type Change struct {
+	Kind    string `json:"kind"`
+	Element string `json:"element"`
+
+	// Full name of the element, e.g. shop.v1.CancelRequest.reason
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+
+	// Whether clients of the tools built from the old version can break
+	Breaking bool `json:"breaking"`
+}
+
+// Diff lists the changes from an old to a new version of an API
+type Diff struct {
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Changes []Change `json:"changes"`
+}
+
+// Breaking returns the breaking changes
+func (d *Diff) Breaking() []Change {
+	var breaking []Change
+	for _, change := range d.Changes {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+// WriteText writes the changes, one per line, breaking ones first flagged
+func (d *Diff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API changes from %s to %s\n", d.Old, d.New)
+	for _, change := range d.Changes {
+		flag := ""
+		if change.Breaking {
+			flag = "BREAKING"
+		}
+		fmt.Fprintf(&b, "  %-8s  %s %s: %s", flag, change.Element, change.Kind, change.Name)
+		if change.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", change.Detail)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "RESULT: %d change(s), %d breaking\n", len(d.Changes), len(d.Breaking()))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the diff as indented JSON
+func (d *Diff) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// Message directions: request fields are tool arguments, response fields tool results
+const (
+	directionInput  = "input"
+	directionOutput = "output"
+)
+
+// Compare reports the services, methods, fields and enum values that differ
+// between two sets of methods, sorted by name
+func Compare(oldName string, oldMethods []types.MethodInfo, newName string, newMethods []types.MethodInfo) *Diff {
+	c := &comparison{changes: make(map[Change]bool), visited: make(map[string]bool)}
+
+	oldServices, newServices := servicesOf(oldMethods), servicesOf(newMethods)
+	for service := range oldServices {
+		if !newServices[service] {
+			c.add(Change{Kind: ChangeRemoved, Element: ElementService, Name: service, Breaking: true})
+		}
+	}
+	for service := range newServices {
+		if !oldServices[service] {
+			c.add(Change{Kind: ChangeAdded, Element: ElementService, Name: service})
+		}
+	}
+
+	oldByName, newByName := methodsByName(oldMethods), methodsByName(newMethods)
+	for name, oldMethod := range oldByName {
+		newMethod, ok := newByName[name]
+		switch {
+		case !ok && newServices[serviceOf(oldMethod)]:
+			c.add(Change{Kind: ChangeRemoved, Element: ElementMethod, Name: name, Breaking: true})
+		case ok:
+			c.compareMethods(oldMethod, newMethod)
+		}
+	}
+	for name, newMethod := range newByName {
+		if _, ok := oldByName[name]; !ok && oldServices[serviceOf(newMethod)] {
+			c.add(Change{Kind: ChangeAdded, Element: ElementMethod, Name: name})
+		}
+	}
+
+	diff := &Diff{Old: oldName, New: newName, Changes: make([]Change, 0, len(c.changes))}
+	for change, breaking := range c.changes {
+		change.Breaking = breaking
+		diff.Changes = append(diff.Changes, change)
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		a, b := diff.Changes[i], diff.Changes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Kind+a.Detail < b.Kind+b.Detail
+	})
+	return diff
+}
+
+// comparison collects changes; a message reached both as input and as
+// output reports each change once, breaking if it breaks either way
+type comparison struct {
+	changes map[Change]bool
+	visited map[string]bool
+}
+
+func (c *comparison) add(change Change) {
+	breaking := change.Breaking
+	change.Breaking = false
+	c.changes[change] = c.changes[change] || breaking
+}
+
+// compareMethods compares a method present in both versions
+func (c *comparison) compareMethods(oldMethod, newMethod types.MethodInfo) {
+	name := oldMethod.FullName
+	changed := func(detail string, breaking bool) {
+		c.add(Change{Kind: ChangeChanged, Element: ElementMethod, Name: name, Detail: detail, Breaking: breaking})
+	}
+
+	oldInput, newInput := strings.TrimPrefix(oldMethod.InputType, "."), strings.TrimPrefix(newMethod.InputType, ".")
+	if oldInput != newInput {
+		changed(fmt.Sprintf("input type %s -> %s", oldInput, newInput), true)
+	} else {
+		c.compareMessages(oldMethod.InputDescriptor, newMethod.InputDescriptor, directionInput)
+	}
+
+	oldOutput, newOutput := strings.TrimPrefix(oldMethod.OutputType, "."), strings.TrimPrefix(newMethod.OutputType, ".")
+	if oldOutput != newOutput {
+		changed(fmt.Sprintf("output type %s -> %s", oldOutput, newOutput), true)
+	} else {
+		c.compareMessages(oldMethod.OutputDescriptor, newMethod.OutputDescriptor, directionOutput)
+	}
+
+	if oldMethod.IsClientStreaming != newMethod.IsClientStreaming || oldMethod.IsServerStreaming != newMethod.IsServerStreaming {
+		changed(fmt.Sprintf("streaming %s -> %s", streamingMode(oldMethod), streamingMode(newMethod)), true)
+	}
+	if !oldMethod.Deprecated && newMethod.Deprecated {
+		changed("deprecated", false)
+	}
+}
+
+// compareMessages compares the fields of a message present in both versions
+func (c *comparison) compareMessages(oldMessage, newMessage protoreflect.MessageDescriptor, direction string) {
+	if oldMessage == nil || newMessage == nil {
+		return
+	}
+	key := direction + ":" + string(oldMessage.FullName())
+	if c.visited[key] {
+		return
+	}
+	c.visited[key] = true
+
+	oldFields, newFields := oldMessage.Fields(), newMessage.Fields()
+	for i := 0; i < oldFields.Len(); i++ {
+		oldField := oldFields.Get(i)
+		name := string(oldField.FullName())
+		newField := newFields.ByName(oldField.Name())
+		if newField == nil {
+			c.add(Change{Kind: ChangeRemoved, Element: ElementField, Name: name, Breaking: true})
+			continue
+		}
+		c.compareFields(oldField, newField, direction)
+	}
+	for i := 0; i < newFields.Len(); i++ {
+		newField := newFields.Get(i)
+		if oldFields.ByName(newField.Name()) != nil {
+			continue
+		}
+		// A new required argument breaks calls written for the old tool
+		required := isRequired(newField)
+		detail := "optional"
+		if required {
+			detail = "required"
+		}
+		c.add(Change{
+			Kind:     ChangeAdded,
+			Element:  ElementField,
+			Name:     string(newField.FullName()),
+			Detail:   detail,
+			Breaking: required && direction == directionInput,
+		})
+	}
+}
+
+// compareFields compares a field present in both versions, then the
+// messages or enums it refers to
+func (c *comparison) compareFields(oldField, newField protoreflect.FieldDescriptor, direction string) {
+	name := string(oldField.FullName())
+	changed := func(detail string, breaking bool) {
+		c.add(Change{Kind: ChangeChanged, Element: ElementField, Name: name, Detail: detail, Breaking: breaking})
+	}
+
+	oldType, newType := fieldType(oldField), fieldType(newField)
+	if oldType != newType {
+		changed(fmt.Sprintf("type %s -> %s", oldType, newType), true)
+		return
+	}
+	if oldField.Number() != newField.Number() {
+		// Field names are the tool's surface, but the gateway and the
+		// backend must agree on numbers
+		changed(fmt.Sprintf("number %d -> %d", oldField.Number(), newField.Number()), true)
+	}
+	if oldRequired, newRequired := isRequired(oldField), isRequired(newField); oldRequired != newRequired {
+		if newRequired {
+			changed("now required", direction == directionInput)
+		} else {
+			changed("no longer required", direction == directionOutput)
+		}
+	}
+
+	if oldField.IsMap() {
+		oldField, newField = oldField.MapValue(), newField.MapValue()
+	}
+	switch oldField.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		c.compareMessages(oldField.Message(), newField.Message(), direction)
+	case protoreflect.EnumKind:
+		c.compareEnums(oldField.Enum(), newField.Enum(), direction)
+	}
+}
+
+// compareEnums compares the values of an enum present in both versions
+func (c *comparison) compareEnums(oldEnum, newEnum protoreflect.EnumDescriptor, direction string) {
+	key := direction + ":" + string(oldEnum.FullName())
+	if c.visited[key] {
+		return
+	}
+	c.visited[key] = true
+
+	enumName := string(oldEnum.FullName())
+	for i := 0; i < oldEnum.Values().Len(); i++ {
+		value := oldEnum.Values().Get(i)
+		if newEnum.Values().ByName(value.Name()) == nil {
+			c.add(Change{Kind: ChangeRemoved, Element: ElementEnumValue, Name: enumName + "." + string(value.Name()), Breaking: true})
+		}
+	}
+	for i := 0; i < newEnum.Values().Len(); i++ {
+		value := newEnum.Values().Get(i)
+		if oldEnum.Values().ByName(value.Name()) == nil {
+			c.add(Change{Kind: ChangeAdded, Element: ElementEnumValue, Name: enumName + "." + string(value.Name())})
+		}
+	}
+}
+
+// fieldType describes a field's type, including its cardinality
+func fieldType(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", fieldType(field.MapKey()), fieldType(field.MapValue()))
+	}
+	var name string
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		name = string(field.Message().FullName())
+	case protoreflect.EnumKind:
+		name = string(field.Enum().FullName())
+	default:
+		name = field.Kind().String()
+	}
+	if field.IsList() {
+		return "repeated " + name
+	}
+	return name
+}
+
+// isRequired reports whether a field's tool schema lists it as required,
+// as the tool builder decides it
+func isRequired(field protoreflect.FieldDescriptor) bool {
+	return field.Cardinality() == protoreflect.Required || !field.HasPresence()
+}
+
+// streamingMode names the streaming mode of a method
+func streamingMode(method types.MethodInfo) string {
+	switch {
+	case method.IsClientStreaming && method.IsServerStreaming:
+		return "bidirectional"
+	case method.IsClientStreaming:
+		return "client"
+	case method.IsServerStreaming:
+		return "server"
+	}
+	return "unary"
+}
+
+// servicesOf returns the full names of the services of some methods
+func servicesOf(methods []types.MethodInfo) map[string]bool {
+	services := make(map[string]bool)
+	for _, method := range methods {
+		services[serviceOf(method)] = true
+	}
+	return services
+}
+
+// serviceOf returns the full name of a method's service; ServiceName may be
+// shortened to the last package component
+func serviceOf(method types.MethodInfo) string {
+	return strings.TrimSuffix(method.FullName, "."+method.Name)
+}
+
+// methodsByName indexes methods by their full name
+func methodsByName(methods []types.MethodInfo) map[string]types.MethodInfo {
+	byName := make(map[string]types.MethodInfo, len(methods))
+	for _, method := range methods {
+		byName[method.FullName] = method
+	}
+	return byName
+}
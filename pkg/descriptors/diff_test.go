@@ -0,0 +1,154 @@
+package descriptors
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// shopDescriptorSet writes a descriptor set of a shop.v1 API, changed by edit
+func shopDescriptorSet(t *testing.T, name string, edit func(file *descriptorpb.FileDescriptorProto)) string {
+	t.Helper()
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		field := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     fieldType.Enum(),
+			JsonName: proto.String(name),
+		}
+		if typeName != "" {
+			field.TypeName = proto.String(typeName)
+		}
+		return field
+	}
+	method := func(name, input, output string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{Name: proto.String(name), InputType: proto.String(input), OutputType: proto.String(output)}
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop/v1/orders.proto"),
+		Package: proto.String("shop.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetOrderRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+			{Name: proto.String("CancelRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("reason", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+			{Name: proto.String("Order"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("status", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".shop.v1.Status"),
+				field("total", 3, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+			}},
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_OPEN"), Number: proto.Int32(1)},
+				{Name: proto.String("STATUS_HELD"), Number: proto.Int32(2)},
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{Name: proto.String("Orders"), Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetOrder", ".shop.v1.GetOrderRequest", ".shop.v1.Order"),
+				method("CancelOrder", ".shop.v1.CancelRequest", ".shop.v1.Order"),
+				method("RetireOrder", ".shop.v1.GetOrderRequest", ".shop.v1.Order"),
+			}},
+			{Name: proto.String("Legacy"), Method: []*descriptorpb.MethodDescriptorProto{
+				method("Ping", ".shop.v1.GetOrderRequest", ".shop.v1.Order"),
+			}},
+		},
+	}
+	if edit != nil {
+		edit(file)
+	}
+
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestCompare(t *testing.T) {
+	oldPath := shopDescriptorSet(t, "old.binpb", nil)
+	newPath := shopDescriptorSet(t, "new.binpb", func(file *descriptorpb.FileDescriptorProto) {
+		getOrder, cancel, order := file.MessageType[0], file.MessageType[1], file.MessageType[2]
+
+		// A new required (proto3 implicit presence) argument, and an optional one
+		getOrder.Field = append(getOrder.Field,
+			&descriptorpb.FieldDescriptorProto{Name: proto.String("tenant"), Number: proto.Int32(2), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+		)
+		// reason removed
+		cancel.Field = cancel.Field[:1]
+		// total changes type, a result field is added
+		order.Field[2].Type = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+		order.Field = append(order.Field,
+			&descriptorpb.FieldDescriptorProto{Name: proto.String("note"), Number: proto.Int32(4), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Proto3Optional: proto.Bool(true), OneofIndex: proto.Int32(0)},
+		)
+		order.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("_note")}}
+		// STATUS_HELD removed, STATUS_SHIPPED added
+		file.EnumType[0].Value[2].Name = proto.String("STATUS_SHIPPED")
+
+		// RetireOrder removed, ListOrders added, Legacy removed, Carts added
+		orders := file.Service[0]
+		orders.Method[2] = &descriptorpb.MethodDescriptorProto{Name: proto.String("ListOrders"), InputType: proto.String(".shop.v1.GetOrderRequest"), OutputType: proto.String(".shop.v1.Order"), ServerStreaming: proto.Bool(true)}
+		orders.Method[1].Options = &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)}
+		file.Service[1].Name = proto.String("Carts")
+	})
+
+	loader := NewLoader(zap.NewNop())
+	oldMethods, err := loader.LoadMethods(oldPath)
+	require.NoError(t, err)
+	newMethods, err := loader.LoadMethods(newPath)
+	require.NoError(t, err)
+
+	diff := Compare("old.binpb", oldMethods, "new.binpb", newMethods)
+	var lines []string
+	for _, change := range diff.Changes {
+		line := change.Element + " " + change.Kind + " " + change.Name
+		if change.Detail != "" {
+			line += " (" + change.Detail + ")"
+		}
+		if change.Breaking {
+			line = "BREAKING " + line
+		}
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{
+		"BREAKING field removed shop.v1.CancelRequest.reason",
+		"service added shop.v1.Carts",
+		"BREAKING field added shop.v1.GetOrderRequest.tenant (required)",
+		"BREAKING service removed shop.v1.Legacy",
+		"field added shop.v1.Order.note (optional)",
+		"BREAKING field changed shop.v1.Order.total (type int64 -> double)",
+		"method changed shop.v1.Orders.CancelOrder (deprecated)",
+		"method added shop.v1.Orders.ListOrders",
+		"BREAKING method removed shop.v1.Orders.RetireOrder",
+		"BREAKING enum_value removed shop.v1.Status.STATUS_HELD",
+		"enum_value added shop.v1.Status.STATUS_SHIPPED",
+	}, lines)
+	assert.Len(t, diff.Breaking(), 6)
+
+	var text bytes.Buffer
+	require.NoError(t, diff.WriteText(&text))
+	assert.Contains(t, text.String(), "  BREAKING  method removed: shop.v1.Orders.RetireOrder\n")
+	assert.True(t, strings.HasSuffix(text.String(), "RESULT: 11 change(s), 6 breaking\n"))
+
+	// Identical versions have no changes
+	same := Compare("old.binpb", oldMethods, "old.binpb", oldMethods)
+	assert.Empty(t, same.Changes)
+	assert.Empty(t, same.Breaking())
+}
@@ -0,0 +1,119 @@
+package descriptors
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// loadEditionsCorpus loads the editions 2023 descriptors of tests/testdata/editions
+func loadEditionsCorpus(t *testing.T) []types.MethodInfo {
+	data, err := os.ReadFile("../../tests/testdata/editions/editions.txtpb")
+	require.NoError(t, err)
+	var fdSet descriptorpb.FileDescriptorSet
+	require.NoError(t, prototext.Unmarshal(data, &fdSet))
+
+	loader := NewLoader(zap.NewNop())
+	files, err := loader.BuildRegistry(&fdSet)
+	require.NoError(t, err)
+	methods, err := loader.ExtractMethodInfo(files)
+	require.NoError(t, err)
+	require.Len(t, methods, 2)
+	return methods
+}
+
+func TestEditions_Loader(t *testing.T) {
+	methods := loadEditionsCorpus(t)
+	assert.Equal(t, "inventory.InventoryService", methods[0].ServiceName)
+	assert.Equal(t, "GetItem", methods[0].Name)
+	assert.Equal(t, "PutItem", methods[1].Name)
+
+	item := methods[1].InputDescriptor
+	fields := item.Fields()
+	assert.Equal(t, protoreflect.Required, fields.ByName("sku").Cardinality())
+	assert.True(t, fields.ByName("name").HasPresence(), "editions fields have explicit presence by default")
+	assert.False(t, fields.ByName("quantity").HasPresence())
+	assert.Equal(t, protoreflect.GroupKind, fields.ByName("dimensions").Kind())
+	assert.False(t, fields.ByName("bins").IsPacked())
+	assert.True(t, fields.ByName("status").Enum().IsClosed())
+
+	// File-level features apply to the fields of the imported file
+	note := fields.ByName("note").Message().Fields()
+	assert.False(t, note.ByName("text").HasPresence())
+	assert.True(t, note.ByName("author").HasPresence())
+}
+
+func TestEditions_Schema(t *testing.T) {
+	methods := loadEditionsCorpus(t)
+	builder := tools.NewMCPToolBuilder(zap.NewNop())
+
+	tool, err := builder.BuildTool(methods[1])
+	require.NoError(t, err)
+
+	schema := tool.InputSchema.(map[string]interface{})
+	assert.ElementsMatch(t, []string{"sku", "quantity", "tags", "bins", "stock_by_site"}, schema["required"])
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"sku", "name", "quantity", "tags", "bins", "dimensions", "status", "note", "stock_by_site", "source"} {
+		assert.Contains(t, properties, name)
+	}
+
+	// DELIMITED messages are described like any other message
+	dimensions := properties["dimensions"].(map[string]interface{})
+	assert.Equal(t, "object", dimensions["type"])
+	assert.Contains(t, dimensions["properties"], "width")
+	assert.NotContains(t, dimensions, "required", "fields of an editions message are optional by default")
+
+	// Closed enums list their values only
+	status := properties["status"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"STATUS_ACTIVE", "STATUS_RETIRED"}, status["enum"])
+
+	note := properties["note"].(map[string]interface{})
+	assert.Equal(t, []string{"text"}, note["required"])
+
+	request, err := builder.BuildTool(methods[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sku"}, request.InputSchema.(map[string]interface{})["required"])
+}
+
+func TestEditions_JSONRoundTrip(t *testing.T) {
+	methods := loadEditionsCorpus(t)
+	item := methods[1].InputDescriptor
+
+	input := `{
+		"sku": "A1",
+		"quantity": 3,
+		"bins": [4, 7],
+		"dimensions": {"width": 1.5, "height": 2},
+		"status": "STATUS_ACTIVE",
+		"note": {"text": "fragile"},
+		"stockBySite": {"ams": 2},
+		"supplier": "acme"
+	}`
+	message := dynamicpb.NewMessage(item)
+	require.NoError(t, protojson.Unmarshal([]byte(input), message))
+
+	wire, err := proto.Marshal(message)
+	require.NoError(t, err)
+	decoded := dynamicpb.NewMessage(item)
+	require.NoError(t, proto.Unmarshal(wire, decoded))
+
+	output, err := protojson.Marshal(decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, input, string(output))
+
+	// LEGACY_REQUIRED fields are enforced
+	err = protojson.Unmarshal([]byte(`{"name": "no sku"}`), dynamicpb.NewMessage(item))
+	assert.ErrorContains(t, err, "required field")
+}
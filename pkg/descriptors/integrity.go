@@ -0,0 +1,96 @@
+package descriptors
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrIntegrity is wrapped by the errors of descriptor sets that fail verification
+var ErrIntegrity = errors.New("descriptor set failed integrity verification")
+
+// Integrity lists the checks a descriptor set file must pass before it is
+// parsed; empty fields are not checked
+type Integrity struct {
+	// Hex SHA-256 digest of the file, optionally prefixed with "sha256:"
+	SHA256 string
+
+	// File holding the signature of the descriptor set, base64-encoded as
+	// written by "cosign sign-blob --output-signature", or raw
+	Signature string
+
+	// PEM public key the signature is verified with (ECDSA, RSA or Ed25519,
+	// e.g. the cosign.pub of "cosign generate-key-pair")
+	PublicKey string
+}
+
+// Verify checks descriptor set data against the configured digest and signature
+func (i Integrity) Verify(data []byte) error {
+	if i.SHA256 != "" {
+		digest := sha256.Sum256(data)
+		expected := strings.TrimPrefix(strings.ToLower(i.SHA256), "sha256:")
+		if actual := hex.EncodeToString(digest[:]); actual != expected {
+			return fmt.Errorf("%w: sha256 is %s, expected %s", ErrIntegrity, actual, expected)
+		}
+	}
+
+	if i.Signature != "" {
+		if err := verifySignature(data, i.Signature, i.PublicKey); err != nil {
+			return fmt.Errorf("%w: %v", ErrIntegrity, err)
+		}
+	}
+	return nil
+}
+
+// verifySignature checks a signature of the SHA-256 digest of data, the
+// scheme cosign uses for blobs signed with a key
+func verifySignature(data []byte, signaturePath, publicKeyPath string) error {
+	encoded, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		signature = encoded
+	}
+
+	keyPEM, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return fmt.Errorf("public key %s is not PEM", publicKeyPath)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	digest := sha256.Sum256(data)
+	var valid bool
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if !valid {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
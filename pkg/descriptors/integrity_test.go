@@ -0,0 +1,109 @@
+package descriptors
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestLoader_LoadVerifiedFromFile(t *testing.T) {
+	dir := t.TempDir()
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto)},
+	})
+	require.NoError(t, err)
+	path := filepath.Join(dir, "service.binpb")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	digest := sha256.Sum256(data)
+	loader := NewLoader(zap.NewNop())
+
+	t.Run("SHA256", func(t *testing.T) {
+		fdSet, err := loader.LoadVerifiedFromFile(path, Integrity{SHA256: "sha256:" + hex.EncodeToString(digest[:])})
+		require.NoError(t, err)
+		assert.Len(t, fdSet.File, 1)
+
+		_, err = loader.LoadVerifiedFromFile(path, Integrity{SHA256: hex.EncodeToString(make([]byte, 32))})
+		require.ErrorIs(t, err, ErrIntegrity)
+		assert.Contains(t, err.Error(), "sha256 is "+hex.EncodeToString(digest[:]))
+	})
+
+	// writeKey writes a PEM public key and a base64 signature of the file
+	writeKey := func(t *testing.T, name string, publicKey interface{}, signature []byte) Integrity {
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		require.NoError(t, err)
+		integrity := Integrity{
+			Signature: filepath.Join(dir, name+".sig"),
+			PublicKey: filepath.Join(dir, name+".pub"),
+		}
+		require.NoError(t, os.WriteFile(integrity.PublicKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+		require.NoError(t, os.WriteFile(integrity.Signature, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0o600))
+		return integrity
+	}
+
+	t.Run("Signatures", func(t *testing.T) {
+		ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		ecdsaSignature, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+		require.NoError(t, err)
+
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+
+		ed25519Public, ed25519Private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		for name, integrity := range map[string]Integrity{
+			"ecdsa":   writeKey(t, "ecdsa", &ecdsaKey.PublicKey, ecdsaSignature),
+			"rsa":     writeKey(t, "rsa", &rsaKey.PublicKey, rsaSignature),
+			"ed25519": writeKey(t, "ed25519", ed25519Public, ed25519.Sign(ed25519Private, data)),
+		} {
+			_, err := loader.LoadVerifiedFromFile(path, integrity)
+			assert.NoError(t, err, name)
+		}
+
+		// A signature by another key is rejected
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		_, err = loader.LoadVerifiedFromFile(path, writeKey(t, "other", &otherKey.PublicKey, ecdsaSignature))
+		require.ErrorIs(t, err, ErrIntegrity)
+		assert.Contains(t, err.Error(), "signature does not match")
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		signature, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+		require.NoError(t, err)
+		integrity := writeKey(t, "tampered", &ecdsaKey.PublicKey, signature)
+
+		tampered := append([]byte{}, data...)
+		tampered[len(tampered)-1] ^= 0xff
+		tamperedPath := filepath.Join(dir, "tampered.binpb")
+		require.NoError(t, os.WriteFile(tamperedPath, tampered, 0o600))
+
+		_, err = loader.LoadVerifiedFromFile(tamperedPath, integrity)
+		require.ErrorIs(t, err, ErrIntegrity)
+	})
+}
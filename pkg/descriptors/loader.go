@@ -31,6 +31,12 @@ func NewLoader(logger *zap.Logger) *Loader {
 
 // LoadFromFile loads a FileDescriptorSet from a binary protobuf file
 func (l *Loader) LoadFromFile(path string) (*descriptorpb.FileDescriptorSet, error) {
+	return l.LoadVerifiedFromFile(path, Integrity{})
+}
+
+// LoadVerifiedFromFile loads a FileDescriptorSet from a binary protobuf file
+// once it passes the integrity checks
+func (l *Loader) LoadVerifiedFromFile(path string, integrity Integrity) (*descriptorpb.FileDescriptorSet, error) {
 	l.logger.Info("Loading FileDescriptorSet", zap.String("path", path))
 
 	// Open the file
@@ -50,6 +56,11 @@ func (l *Loader) LoadFromFile(path string) (*descriptorpb.FileDescriptorSet, err
 		return nil, fmt.Errorf("failed to read descriptor file %s: %w", path, err)
 	}
 
+	// Verify the file before trusting anything in it
+	if err := integrity.Verify(data); err != nil {
+		return nil, fmt.Errorf("descriptor file %s: %w", path, err)
+	}
+
 	// Parse the FileDescriptorSet
 	var fdSet descriptorpb.FileDescriptorSet
 	if err := proto.Unmarshal(data, &fdSet); err != nil {
@@ -63,6 +74,19 @@ func (l *Loader) LoadFromFile(path string) (*descriptorpb.FileDescriptorSet, err
 	return &fdSet, nil
 }
 
+// LoadMethods loads a FileDescriptorSet file and extracts its methods
+func (l *Loader) LoadMethods(path string) ([]types.MethodInfo, error) {
+	fdSet, err := l.LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := l.BuildRegistry(fdSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build file registry from %s: %w", path, err)
+	}
+	return l.ExtractMethodInfo(files)
+}
+
 // BuildRegistry creates a protoregistry.Files from a FileDescriptorSet
 func (l *Loader) BuildRegistry(fdSet *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
 	files := &protoregistry.Files{}
@@ -140,6 +164,10 @@ func (l *Loader) ExtractMethodInfo(files *protoregistry.Files) ([]types.MethodIn
 	// Iterate through all files in the registry
 	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
 		l.logger.Debug("Extracting methods from file", zap.String("file", string(fd.FullName())))
+		if fd.Services().Len() == 0 {
+			return true
+		}
+		fileDescriptor := protodesc.ToFileDescriptorProto(fd)
 
 		// Process each service in the file
 		for i := 0; i < fd.Services().Len(); i++ {
@@ -167,8 +195,12 @@ func (l *Loader) ExtractMethodInfo(files *protoregistry.Files) ([]types.MethodIn
 					OutputDescriptor:   methodDesc.Output(),
 					IsClientStreaming:  methodDesc.IsStreamingClient(),
 					IsServerStreaming:  methodDesc.IsStreamingServer(),
+					Deprecated:         isDeprecated(methodDesc),
+					IdempotencyLevel:   idempotencyLevel(methodDesc),
+					Source:             types.MethodSourceDescriptorSet,
 					// Additional fields from file descriptors
-					Comments: []string{extractComments(methodDesc)},
+					Comments:       []string{extractComments(methodDesc)},
+					FileDescriptor: fileDescriptor,
 				}
 
 				// Generate tool name
@@ -191,6 +223,19 @@ func (l *Loader) ExtractMethodInfo(files *protoregistry.Files) ([]types.MethodIn
 	return methods, nil
 }
 
+// isDeprecated reports whether a method or its service sets option deprecated = true
+func isDeprecated(method protoreflect.MethodDescriptor) bool {
+	methodOptions, _ := method.Options().(*descriptorpb.MethodOptions)
+	serviceOptions, _ := method.Parent().Options().(*descriptorpb.ServiceOptions)
+	return methodOptions.GetDeprecated() || serviceOptions.GetDeprecated()
+}
+
+// idempotencyLevel returns the idempotency_level option of a method
+func idempotencyLevel(method protoreflect.MethodDescriptor) descriptorpb.MethodOptions_IdempotencyLevel {
+	options, _ := method.Options().(*descriptorpb.MethodOptions)
+	return options.GetIdempotencyLevel()
+}
+
 // extractComments extracts leading and trailing comments from a descriptor
 func extractComments(desc protoreflect.Descriptor) string {
 	// Get source location info if available
@@ -0,0 +1,83 @@
+package errcatalog
+
+import (
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// Catalog maps backend ErrorInfo details to curated, user-friendly messages
+type Catalog struct {
+	config config.ErrorCatalogConfig
+}
+
+// NewCatalog creates a new error catalog with the given configuration
+func NewCatalog(config config.ErrorCatalogConfig) *Catalog {
+	return &Catalog{
+		config: config,
+	}
+}
+
+// Lookup finds the catalog entry matching the ErrorInfo details carried by err
+func (c *Catalog) Lookup(err error) (config.ErrorCatalogEntry, bool) {
+	if !c.config.Enabled || err == nil {
+		return config.ErrorCatalogEntry{}, false
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return config.ErrorCatalogEntry{}, false
+	}
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+
+		if entry, found := c.match(info.GetReason(), info.GetDomain()); found {
+			return entry, true
+		}
+	}
+
+	return config.ErrorCatalogEntry{}, false
+}
+
+// Describe returns the curated message for err, or an empty string if nothing matched
+func (c *Catalog) Describe(err error) string {
+	entry, ok := c.Lookup(err)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(entry.Message)
+	if entry.Remediation != "" {
+		sb.WriteString("\nSuggested action: ")
+		sb.WriteString(entry.Remediation)
+	}
+
+	return sb.String()
+}
+
+// match returns the first entry matching the given reason and domain
+func (c *Catalog) match(reason, domain string) (config.ErrorCatalogEntry, bool) {
+	for _, entry := range c.config.Entries {
+		if entry.Reason != reason {
+			continue
+		}
+		if entry.Domain != "" && entry.Domain != domain {
+			continue
+		}
+		return entry, true
+	}
+
+	return config.ErrorCatalogEntry{}, false
+}
+
+// IsEnabled returns whether the error catalog is enabled
+func (c *Catalog) IsEnabled() bool {
+	return c.config.Enabled
+}
@@ -0,0 +1,121 @@
+package errcatalog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newErrorWithInfo(t *testing.T, reason, domain string) error {
+	st, err := status.New(codes.FailedPrecondition, "precondition failed").WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: domain,
+	})
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestCatalog_Lookup(t *testing.T) {
+	catalogConfig := config.ErrorCatalogConfig{
+		Enabled: true,
+		Entries: []config.ErrorCatalogEntry{
+			{
+				Reason:      "QUOTA_EXCEEDED",
+				Domain:      "billing.example.com",
+				Message:     "The account has run out of quota.",
+				Remediation: "Wait for the quota to reset before retrying.",
+			},
+			{
+				Reason:  "ACCOUNT_LOCKED",
+				Message: "The account is locked.",
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		config        config.ErrorCatalogConfig
+		err           error
+		expectedFound bool
+		expectedMsg   string
+	}{
+		{
+			name:          "Reason_and_domain_match",
+			config:        catalogConfig,
+			err:           newErrorWithInfo(t, "QUOTA_EXCEEDED", "billing.example.com"),
+			expectedFound: true,
+			expectedMsg:   "The account has run out of quota.",
+		},
+		{
+			name:          "Domain_mismatch_is_ignored",
+			config:        catalogConfig,
+			err:           newErrorWithInfo(t, "QUOTA_EXCEEDED", "other.example.com"),
+			expectedFound: false,
+		},
+		{
+			name:          "Empty_domain_matches_any",
+			config:        catalogConfig,
+			err:           newErrorWithInfo(t, "ACCOUNT_LOCKED", "users.example.com"),
+			expectedFound: true,
+			expectedMsg:   "The account is locked.",
+		},
+		{
+			name:          "Wrapped_status_error",
+			config:        catalogConfig,
+			err:           fmt.Errorf("gRPC call failed: %w", newErrorWithInfo(t, "ACCOUNT_LOCKED", "")),
+			expectedFound: true,
+			expectedMsg:   "The account is locked.",
+		},
+		{
+			name:          "Non_status_error",
+			config:        catalogConfig,
+			err:           errors.New("plain error"),
+			expectedFound: false,
+		},
+		{
+			name: "Disabled_catalog",
+			config: config.ErrorCatalogConfig{
+				Enabled: false,
+				Entries: catalogConfig.Entries,
+			},
+			err:           newErrorWithInfo(t, "ACCOUNT_LOCKED", ""),
+			expectedFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := NewCatalog(tt.config)
+			entry, found := catalog.Lookup(tt.err)
+			assert.Equal(t, tt.expectedFound, found)
+			if tt.expectedFound {
+				assert.Equal(t, tt.expectedMsg, entry.Message)
+			}
+		})
+	}
+}
+
+func TestCatalog_Describe(t *testing.T) {
+	catalog := NewCatalog(config.ErrorCatalogConfig{
+		Enabled: true,
+		Entries: []config.ErrorCatalogEntry{
+			{
+				Reason:      "QUOTA_EXCEEDED",
+				Message:     "The account has run out of quota.",
+				Remediation: "Wait for the quota to reset before retrying.",
+			},
+		},
+	})
+
+	description := catalog.Describe(newErrorWithInfo(t, "QUOTA_EXCEEDED", ""))
+	assert.Equal(t, "The account has run out of quota.\nSuggested action: Wait for the quota to reset before retrying.", description)
+
+	assert.Empty(t, catalog.Describe(newErrorWithInfo(t, "UNKNOWN", "")))
+}
@@ -0,0 +1,338 @@
+// Package events publishes tool invocation and discovery change events to an
+// event streaming system (Kafka or NATS).
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+// EventTypeDiscoveryChange is the type of the event sent when a discovery
+// adds or removes tools
+const EventTypeDiscoveryChange = "discovery_change"
+
+// Publisher sends messages to a topic of an event streaming system
+type Publisher interface {
+	// Publish sends the messages in order, returning once they are accepted
+	Publish(ctx context.Context, topic string, messages [][]byte) error
+
+	// Close releases the connection
+	Close() error
+}
+
+// NewPublisher creates the publisher of the configured driver
+func NewPublisher(eventsConfig config.EventsConfig) (Publisher, error) {
+	switch eventsConfig.Driver {
+	case "kafka":
+		return newKafkaPublisher(eventsConfig.Kafka, eventsConfig.Timeout), nil
+	case "nats":
+		return newNATSPublisher(eventsConfig.NATS)
+	default:
+		return nil, fmt.Errorf("unsupported event driver %q", eventsConfig.Driver)
+	}
+}
+
+// DiscoveryEvent describes the tools added and removed by a discovery
+type DiscoveryEvent struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Added     []string  `json:"added,omitempty"`
+	Removed   []string  `json:"removed,omitempty"`
+	ToolCount int       `json:"toolCount"`
+}
+
+// Stats counts the events of the sink
+type Stats struct {
+	Driver  string                `json:"driver"`
+	Dropped int64                 `json:"dropped"`
+	Retries int64                 `json:"retries"`
+	Queued  int                   `json:"queued"`
+	Topics  map[string]TopicStats `json:"topics"`
+}
+
+// TopicStats counts the events of one topic
+type TopicStats struct {
+	Published int64 `json:"published"`
+	Failed    int64 `json:"failed"`
+}
+
+// message is an encoded event waiting to be published
+type message struct {
+	topic   string
+	payload []byte
+}
+
+// topicCounters counts the events of one topic
+type topicCounters struct {
+	published atomic.Int64
+	failed    atomic.Int64
+}
+
+// Sink publishes events in batches per topic from a bounded queue
+type Sink struct {
+	config    config.EventsConfig
+	publisher Publisher
+	logger    *zap.Logger
+	queue     chan message
+	topics    map[string]*topicCounters
+
+	dropped atomic.Int64
+	retries atomic.Int64
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewSink connects to the configured event streaming system and starts
+// publishing, or returns nil if events are disabled
+func NewSink(eventsConfig config.EventsConfig, logger *zap.Logger) (*Sink, error) {
+	if !eventsConfig.Enabled {
+		return nil, nil
+	}
+	publisher, err := NewPublisher(eventsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newSink(eventsConfig, publisher, logger), nil
+}
+
+// newSink starts publishing through the given publisher
+func newSink(eventsConfig config.EventsConfig, publisher Publisher, logger *zap.Logger) *Sink {
+	s := &Sink{
+		config:    eventsConfig,
+		publisher: publisher,
+		logger:    logger.With(zap.String("events", eventsConfig.Driver)),
+		queue:     make(chan message, eventsConfig.QueueSize),
+		topics:    make(map[string]*topicCounters),
+		closing:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, topic := range []string{eventsConfig.Topics.Invocation, eventsConfig.Topics.Discovery} {
+		if topic != "" {
+			s.topics[topic] = &topicCounters{}
+		}
+	}
+
+	go func() {
+		defer close(s.done)
+		s.run()
+	}()
+	return s
+}
+
+// PublishInvocation queues the event of a completed tool call
+func (s *Sink) PublishInvocation(event webhook.Event) {
+	if s == nil || s.config.Topics.Invocation == "" {
+		return
+	}
+	s.enqueue(s.config.Topics.Invocation, event.WithArgumentLimit(s.config.MaxArgumentBytes))
+}
+
+// PublishDiscoveryChange queues the event of a discovery that added or removed tools
+func (s *Sink) PublishDiscoveryChange(change grpc.DiscoveryChange) {
+	if s == nil || s.config.Topics.Discovery == "" {
+		return
+	}
+	s.enqueue(s.config.Topics.Discovery, DiscoveryEvent{
+		Type:      EventTypeDiscoveryChange,
+		ID:        newEventID(),
+		Time:      time.Now().UTC(),
+		Added:     change.Added,
+		Removed:   change.Removed,
+		ToolCount: change.ToolCount,
+	})
+}
+
+// enqueue encodes an event and queues it without blocking; events that do not
+// fit in a full queue are dropped
+func (s *Sink) enqueue(topic string, event interface{}) {
+	select {
+	case <-s.closing:
+		return
+	default:
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.topics[topic].failed.Add(1)
+		s.logger.Error("Failed to encode event", zap.String("topic", topic), zap.Error(err))
+		return
+	}
+
+	select {
+	case s.queue <- message{topic: topic, payload: payload}:
+	default:
+		if s.dropped.Add(1) == 1 {
+			s.logger.Warn("Event queue full, dropping events")
+		}
+	}
+}
+
+// Stats returns the event counters
+func (s *Sink) Stats() *Stats {
+	if s == nil {
+		return nil
+	}
+
+	stats := &Stats{
+		Driver:  s.config.Driver,
+		Dropped: s.dropped.Load(),
+		Retries: s.retries.Load(),
+		Queued:  len(s.queue),
+		Topics:  make(map[string]TopicStats, len(s.topics)),
+	}
+	for topic, counters := range s.topics {
+		stats.Topics[topic] = TopicStats{
+			Published: counters.published.Load(),
+			Failed:    counters.failed.Load(),
+		}
+	}
+	return stats
+}
+
+// Close publishes the queued events and disconnects; publishes failing from
+// then on are not retried
+func (s *Sink) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.closeOnce.Do(func() { close(s.closing) })
+	<-s.done
+	return s.publisher.Close()
+}
+
+// run collects events into a batch per topic, publishing a batch when it is
+// full or when the flush interval elapses
+func (s *Sink) run() {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batches := make(map[string][][]byte)
+	add := func(m message) {
+		batches[m.topic] = append(batches[m.topic], m.payload)
+		if len(batches[m.topic]) >= s.config.BatchSize {
+			s.publish(m.topic, batches[m.topic])
+			delete(batches, m.topic)
+		}
+	}
+	flush := func() {
+		for topic, batch := range batches {
+			s.publish(topic, batch)
+			delete(batches, topic)
+		}
+	}
+
+	for {
+		select {
+		case m := <-s.queue:
+			add(m)
+		case <-ticker.C:
+			flush()
+		case <-s.closing:
+			// Drain what is queued, then stop
+			for {
+				select {
+				case m := <-s.queue:
+					add(m)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish sends a batch, retrying with exponential backoff while the failure
+// may be temporary
+func (s *Sink) publish(topic string, batch [][]byte) {
+	counters := s.topics[topic]
+
+	var err error
+	backoff := s.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		err = s.publisher.Publish(ctx, topic, batch)
+		cancel()
+		if err == nil {
+			counters.published.Add(int64(len(batch)))
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= s.config.MaxRetries || s.isClosing() {
+			break
+		}
+
+		s.logger.Debug("Retrying event publish",
+			zap.String("topic", topic),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		if !s.wait(backoff) {
+			break
+		}
+		s.retries.Add(1)
+		backoff *= 2
+	}
+
+	counters.failed.Add(int64(len(batch)))
+	s.logger.Warn("Failed to publish events",
+		zap.String("topic", topic),
+		zap.Int("events", len(batch)),
+		zap.Error(err))
+}
+
+// wait waits before a retry, returning false if the sink closes meanwhile
+func (s *Sink) wait(backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.closing:
+		return false
+	}
+}
+
+// isClosing reports whether the sink is shutting down
+func (s *Sink) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// permanentError is a publish failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+// newEventID returns a random event identifier
+func newEventID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,197 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakePublisher records the batches published to it, failing with the given errors first
+type fakePublisher struct {
+	mu      sync.Mutex
+	errs    []error
+	batches map[string][][][]byte
+	closed  bool
+}
+
+func (f *fakePublisher) Publish(_ context.Context, topic string, messages [][]byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	if f.batches == nil {
+		f.batches = make(map[string][][][]byte)
+	}
+	f.batches[topic] = append(f.batches[topic], messages)
+	return nil
+}
+
+func (f *fakePublisher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakePublisher) published(topic string) [][][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][][]byte(nil), f.batches[topic]...)
+}
+
+func newEventsConfig() config.EventsConfig {
+	eventsConfig := config.Default().MCP.Events
+	eventsConfig.Enabled = true
+	eventsConfig.FlushInterval = time.Hour
+	eventsConfig.RetryBackoff = time.Millisecond
+	return eventsConfig
+}
+
+func TestSink_Batches(t *testing.T) {
+	eventsConfig := newEventsConfig()
+	eventsConfig.BatchSize = 2
+	eventsConfig.MaxArgumentBytes = 4
+	publisher := &fakePublisher{}
+	sink := newSink(eventsConfig, publisher, zap.NewNop())
+
+	sink.PublishInvocation(webhook.Event{Type: webhook.EventTypeToolCall, Tool: "a", Arguments: `{"id":1}`})
+	sink.PublishInvocation(webhook.Event{Type: webhook.EventTypeToolCall, Tool: "b"})
+	sink.PublishDiscoveryChange(grpc.DiscoveryChange{Added: []string{"shop_orders_place"}, ToolCount: 1})
+
+	// A full batch is published right away
+	require.Eventually(t, func() bool { return len(publisher.published("ggrmcp.invocations")) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// The rest is published when closing
+	require.NoError(t, sink.Close())
+	assert.True(t, publisher.closed)
+
+	var invocation webhook.Event
+	require.NoError(t, json.Unmarshal(publisher.published("ggrmcp.invocations")[0][0], &invocation))
+	assert.Equal(t, "a", invocation.Tool)
+	assert.Equal(t, `{"id`, invocation.Arguments)
+	assert.True(t, invocation.ArgumentsTruncated)
+
+	discovery := publisher.published("ggrmcp.discovery")
+	require.Len(t, discovery, 1)
+	var change DiscoveryEvent
+	require.NoError(t, json.Unmarshal(discovery[0][0], &change))
+	assert.Equal(t, EventTypeDiscoveryChange, change.Type)
+	assert.Equal(t, []string{"shop_orders_place"}, change.Added)
+	assert.NotEmpty(t, change.ID)
+
+	assert.Equal(t, &Stats{
+		Driver: "nats",
+		Topics: map[string]TopicStats{
+			"ggrmcp.invocations": {Published: 2},
+			"ggrmcp.discovery":   {Published: 1},
+		},
+	}, sink.Stats())
+}
+
+func TestSink_DisabledTopic(t *testing.T) {
+	eventsConfig := newEventsConfig()
+	eventsConfig.Topics.Discovery = ""
+	publisher := &fakePublisher{}
+	sink := newSink(eventsConfig, publisher, zap.NewNop())
+
+	sink.PublishDiscoveryChange(grpc.DiscoveryChange{Added: []string{"shop_orders_place"}})
+	require.NoError(t, sink.Close())
+	assert.Empty(t, publisher.published(""))
+	assert.NotContains(t, sink.Stats().Topics, "")
+}
+
+func TestSink_Retries(t *testing.T) {
+	t.Run("Temporary_failures", func(t *testing.T) {
+		eventsConfig := newEventsConfig()
+		eventsConfig.BatchSize = 1
+		publisher := &fakePublisher{errs: []error{errors.New("connection refused"), errors.New("connection reset")}}
+		sink := newSink(eventsConfig, publisher, zap.NewNop())
+		defer func() { _ = sink.Close() }()
+
+		sink.PublishInvocation(webhook.Event{Tool: "a"})
+		require.Eventually(t, func() bool { return len(publisher.published("ggrmcp.invocations")) == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(2), sink.Stats().Retries)
+	})
+
+	t.Run("Permanent_failure", func(t *testing.T) {
+		publisher := &fakePublisher{errs: []error{&permanentError{err: errors.New("unknown topic")}}}
+		sink := newSink(newEventsConfig(), publisher, zap.NewNop())
+
+		sink.PublishInvocation(webhook.Event{Tool: "a"})
+		require.NoError(t, sink.Close())
+		stats := sink.Stats()
+		assert.Equal(t, int64(0), stats.Retries)
+		assert.Equal(t, TopicStats{Failed: 1}, stats.Topics["ggrmcp.invocations"])
+	})
+}
+
+func TestSink_Nil(t *testing.T) {
+	sink, err := NewSink(config.Default().MCP.Events, zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, sink)
+
+	sink.PublishInvocation(webhook.Event{Tool: "a"})
+	sink.PublishDiscoveryChange(grpc.DiscoveryChange{})
+	assert.Nil(t, sink.Stats())
+	assert.NoError(t, sink.Close())
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/ggrmcp.invocations", r.URL.Path)
+		assert.Equal(t, kafkaContentType, r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, string(body))
+
+		switch len(requests) {
+		case 1:
+			_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":10},{"partition":0,"offset":11}]}`))
+		case 2:
+			_, _ = w.Write([]byte(`{"offsets":[{"error_code":50002,"error":"leader not available"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	publisher := newKafkaPublisher(config.KafkaConfig{
+		RESTURL: srv.URL + "/",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}, time.Second)
+	defer func() { _ = publisher.Close() }()
+
+	err := publisher.Publish(context.Background(), "ggrmcp.invocations", [][]byte{[]byte(`{"tool":"a"}`), []byte(`{"tool":"b"}`)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"records":[{"value":{"tool":"a"}},{"value":{"tool":"b"}}]}`, requests[0])
+
+	// Record errors are worth retrying
+	err = publisher.Publish(context.Background(), "ggrmcp.invocations", [][]byte{[]byte(`{}`)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "leader not available")
+	var permanent *permanentError
+	assert.False(t, errors.As(err, &permanent))
+
+	// Client errors are not
+	err = publisher.Publish(context.Background(), "ggrmcp.invocations", [][]byte{[]byte(`{}`)})
+	require.Error(t, err)
+	assert.True(t, errors.As(err, &permanent))
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// Content types of the Kafka REST Proxy v2 API
+const (
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept      = "application/vnd.kafka.v2+json"
+)
+
+// kafkaPublisher produces records through the Kafka REST Proxy
+type kafkaPublisher struct {
+	config config.KafkaConfig
+	client *http.Client
+}
+
+func newKafkaPublisher(kafkaConfig config.KafkaConfig, timeout time.Duration) *kafkaPublisher {
+	return &kafkaPublisher{
+		config: kafkaConfig,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// kafkaRecord is a record of a produce request
+type kafkaRecord struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaProduceResponse reports the outcome of each record
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish produces the messages to the topic in a single request
+func (p *kafkaPublisher) Publish(ctx context.Context, topic string, messages [][]byte) error {
+	records := make([]kafkaRecord, len(messages))
+	for i, payload := range messages {
+		records[i] = kafkaRecord{Value: payload}
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return &permanentError{err: err}
+	}
+
+	endpoint := strings.TrimRight(p.config.RESTURL, "/") + "/topics/" + url.PathEscape(topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	for name, value := range p.config.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaAccept)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("kafka REST proxy returned status %d", resp.StatusCode)
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return &permanentError{err: fmt.Errorf("kafka REST proxy returned status %d", resp.StatusCode)}
+	}
+
+	// The proxy answers 200 even when some records were not produced
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("invalid kafka REST proxy response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka rejected a record: %s (error code %d)", offset.Error, *offset.ErrorCode)
+		}
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (p *kafkaPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/nats-io/nats.go"
+)
+
+// natsTimeout bounds connecting and publishing when the context has no deadline
+const natsTimeout = 10 * time.Second
+
+// natsPublisher publishes messages with core NATS. It connects on first use
+// and after a failure; each publish flushes and waits for the server to
+// answer, so a returned nil means the server has processed the messages.
+type natsPublisher struct {
+	url     string
+	options []nats.Option
+
+	mu   sync.Mutex
+	conn *nats.Conn
+}
+
+func newNATSPublisher(natsConfig config.NATSConfig) (*natsPublisher, error) {
+	options := []nats.Option{
+		nats.Name("ggrmcp"),
+		// Failed publishes are retried by the event queue on a new connection
+		nats.NoReconnect(),
+		// Server errors are returned by Publish instead
+		nats.ErrorHandler(func(*nats.Conn, *nats.Subscription, error) {}),
+	}
+	switch {
+	case natsConfig.Token != "":
+		options = append(options, nats.Token(natsConfig.Token))
+	case natsConfig.User != "":
+		options = append(options, nats.UserInfo(natsConfig.User, natsConfig.Password))
+	}
+
+	// tls:// URLs and servers announcing tls_required are handled by the
+	// client; these settings only customize verification
+	tlsConfig := natsConfig.TLS
+	if tlsConfig.Enabled || tlsConfig.ServerName != "" {
+		options = append(options, nats.Secure(&tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: tlsConfig.ServerName,
+		}))
+	}
+	if tlsConfig.CAFile != "" {
+		options = append(options, nats.RootCAs(tlsConfig.CAFile))
+	}
+	if tlsConfig.CertFile != "" {
+		options = append(options, nats.ClientCert(tlsConfig.CertFile, tlsConfig.KeyFile))
+	}
+
+	return &natsPublisher{url: natsConfig.URL, options: options}, nil
+}
+
+// Publish sends the messages to the subject
+func (p *natsPublisher) Publish(ctx context.Context, subject string, messages [][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.dial(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := p.publish(ctx, subject, messages)
+	if err != nil {
+		// Start from a new connection next time
+		p.disconnect()
+	}
+	return err
+}
+
+// publish sends the messages and waits for the server to answer a PING
+func (p *natsPublisher) publish(ctx context.Context, subject string, messages [][]byte) error {
+	previous := p.conn.LastError()
+	for _, payload := range messages {
+		if err := p.conn.Publish(subject, payload); err != nil {
+			return fmt.Errorf("failed to publish to NATS: %w", err)
+		}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, natsTimeout)
+		defer cancel()
+	}
+	flushErr := p.conn.FlushWithContext(ctx)
+
+	// Errors such as permission violations arrive before the PONG and are
+	// recorded as the connection's last error
+	if err := p.conn.LastError(); err != nil && err != previous {
+		return fmt.Errorf("NATS error: %s", strings.TrimPrefix(err.Error(), "nats: "))
+	}
+	if flushErr != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", flushErr)
+	}
+	return nil
+}
+
+// dial connects and authenticates
+func (p *natsPublisher) dial(ctx context.Context) error {
+	timeout := natsTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	conn, err := nats.Connect(p.url, append(p.options, nats.Timeout(timeout))...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	p.conn = conn
+	return nil
+}
+
+// disconnect drops the connection
+func (p *natsPublisher) disconnect() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// Close drops the connection
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disconnect()
+	return nil
+}
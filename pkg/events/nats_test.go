@@ -0,0 +1,192 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// natsServer is a minimal NATS server recording the CONNECT options and the
+// published messages; like a real server it rejects publishes to the subject
+// "forbidden" and keeps the connection open
+type natsServer struct {
+	listener net.Listener
+	tls      *tls.Config // requires TLS when set
+	connects chan string
+	messages chan string
+}
+
+func newNATSServer(t *testing.T, tlsConfig *tls.Config) *natsServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &natsServer{
+		listener: listener,
+		tls:      tlsConfig,
+		connects: make(chan string, 10),
+		messages: make(chan string, 100),
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *natsServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	if s.tls == nil {
+		_, _ = conn.Write([]byte(`INFO {"server_id":"test","max_payload":1048576}` + "\r\n"))
+	} else {
+		_, _ = conn.Write([]byte(`INFO {"server_id":"test","max_payload":1048576,"tls_required":true}` + "\r\n"))
+		conn = tls.Server(conn, s.tls)
+	}
+	reader := bufio.NewReader(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			s.connects <- strings.TrimPrefix(line, "CONNECT ")
+		case line == "PING":
+			_, _ = conn.Write([]byte("PONG\r\n"))
+		case strings.HasPrefix(line, "PUB "):
+			var subject string
+			var size int
+			_, _ = fmt.Sscanf(line, "PUB %s %d", &subject, &size)
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			if subject == "forbidden" {
+				_, _ = conn.Write([]byte("-ERR 'Permissions Violation for Publish to forbidden'\r\n"))
+				continue
+			}
+			s.messages <- subject + " " + string(payload[:size])
+		}
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	server := newNATSServer(t, nil)
+	publisher, err := newNATSPublisher(config.NATSConfig{
+		URL:   "nats://" + server.listener.Addr().String(),
+		Token: "s3cret",
+	})
+	require.NoError(t, err)
+	defer func() { _ = publisher.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, publisher.Publish(ctx, "ggrmcp.invocations", [][]byte{[]byte(`{"tool":"a"}`), []byte(`{"tool":"b"}`)}))
+	assert.Contains(t, <-server.connects, `"auth_token":"s3cret"`)
+	assert.Equal(t, `ggrmcp.invocations {"tool":"a"}`, <-server.messages)
+	assert.Equal(t, `ggrmcp.invocations {"tool":"b"}`, <-server.messages)
+
+	// Server errors are reported and the publisher reconnects afterwards
+	err = publisher.Publish(ctx, "forbidden", [][]byte{[]byte(`{}`)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Permissions Violation")
+
+	require.NoError(t, publisher.Publish(ctx, "ggrmcp.discovery", [][]byte{[]byte(`{}`)}))
+	assert.Equal(t, `ggrmcp.discovery {}`, <-server.messages)
+	assert.Len(t, server.connects, 1)
+}
+
+func TestNATSPublisher_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	publisher, err := newNATSPublisher(config.NATSConfig{URL: "nats://" + address})
+	require.NoError(t, err)
+
+	err = publisher.Publish(context.Background(), "ggrmcp.invocations", [][]byte{[]byte(`{}`)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to NATS")
+}
+
+func TestNATSPublisher_TLS(t *testing.T) {
+	serverTLS, caFile := selfSignedTLS(t)
+	server := newNATSServer(t, serverTLS)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The server requires TLS, so the client upgrades even for nats:// URLs
+	for _, url := range []string{"nats://", "tls://"} {
+		publisher, err := newNATSPublisher(config.NATSConfig{
+			URL: url + server.listener.Addr().String(),
+			TLS: config.TLSConfig{CAFile: caFile, ServerName: "nats.internal"},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, publisher.Publish(ctx, "ggrmcp.invocations", [][]byte{[]byte(`{}`)}), url)
+		assert.Equal(t, `ggrmcp.invocations {}`, <-server.messages)
+		_ = publisher.Close()
+	}
+
+	// Without the CA the server certificate is not trusted
+	publisher, err := newNATSPublisher(config.NATSConfig{URL: "nats://" + server.listener.Addr().String()})
+	require.NoError(t, err)
+	err = publisher.Publish(ctx, "ggrmcp.invocations", [][]byte{[]byte(`{}`)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "certificate")
+}
+
+// selfSignedTLS returns a server TLS config for nats.internal and the file
+// holding its certificate
+func selfSignedTLS(t *testing.T) (*tls.Config, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "nats.internal"},
+		DNSNames:              []string{"nats.internal"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}, caFile
+}
@@ -0,0 +1,221 @@
+package formats
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/jsonwalk"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldInfoNumber is the extension number of the google.api.field_info field option
+const fieldInfoNumber protowire.Number = 291403980
+
+// fieldInfoFormats maps google.api.FieldInfo.Format values to JSON Schema formats
+var fieldInfoFormats = map[uint64]string{
+	1: "uuid", // UUID4
+	2: "ipv4", // IPV4
+	3: "ipv6", // IPV6
+}
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+)
+
+// checker validates a value and describes the expected form when it fails
+type checker struct {
+	valid    func(string) bool
+	expected string
+}
+
+// checkers validate each supported format
+var checkers = map[string]checker{
+	"date": {
+		valid:    parses("2006-01-02"),
+		expected: "a date in YYYY-MM-DD form, e.g. 2024-03-15",
+	},
+	"date-time": {
+		valid:    parses(time.RFC3339),
+		expected: "an RFC 3339 timestamp with a time zone, e.g. 2024-03-15T09:30:00Z",
+	},
+	"time": {
+		valid:    parses("15:04:05Z07:00"),
+		expected: "a time of day with a time zone, e.g. 09:30:00Z or 09:30:00+01:00",
+	},
+	"uuid": {
+		valid:    uuidPattern.MatchString,
+		expected: "a UUID, e.g. 123e4567-e89b-12d3-a456-426614174000",
+	},
+	"email": {
+		valid: func(value string) bool {
+			address, err := mail.ParseAddress(value)
+			return err == nil && address.Address == value
+		},
+		expected: "a bare email address, e.g. name@example.com",
+	},
+	"uri": {
+		valid: func(value string) bool {
+			u, err := url.Parse(value)
+			return err == nil && u.Scheme != ""
+		},
+		expected: "an absolute URI with a scheme, e.g. https://example.com/path",
+	},
+	"ipv4": {
+		valid: func(value string) bool {
+			addr, err := netip.ParseAddr(value)
+			return err == nil && addr.Is4()
+		},
+		expected: "an IPv4 address, e.g. 192.0.2.1",
+	},
+	"ipv6": {
+		valid: func(value string) bool {
+			addr, err := netip.ParseAddr(value)
+			return err == nil && addr.Is6()
+		},
+		expected: "an IPv6 address, e.g. 2001:db8::1",
+	},
+	"hostname": {
+		valid: func(value string) bool {
+			return len(value) <= 253 && hostnamePattern.MatchString(value)
+		},
+		expected: "a host name, e.g. api.example.com",
+	},
+}
+
+// parses returns a check that the value parses with the time layout
+func parses(layout string) func(string) bool {
+	return func(value string) bool {
+		_, err := time.Parse(layout, value)
+		return err == nil
+	}
+}
+
+// Registry resolves the formats of string fields and validates arguments against them
+type Registry struct {
+	config config.FormatsConfig
+}
+
+// NewRegistry creates a new format registry with the given configuration
+func NewRegistry(config config.FormatsConfig) *Registry {
+	return &Registry{
+		config: config,
+	}
+}
+
+// Format returns the JSON Schema format of a string field, or "" if it has none.
+// Configured formats take precedence over the google.api.field_info option.
+func (r *Registry) Format(fd protoreflect.FieldDescriptor) string {
+	if r == nil || fd.Kind() != protoreflect.StringKind {
+		return ""
+	}
+	if format, ok := r.config.Fields[string(fd.FullName())]; ok {
+		return format
+	}
+	return fieldInfoFormat(fd)
+}
+
+// Check validates a value against a format, describing the expected form on failure
+func Check(format, value string) error {
+	c, ok := checkers[format]
+	if !ok || c.valid(value) {
+		return nil
+	}
+	return fmt.Errorf("must be %s", c.expected)
+}
+
+// Validating reports whether call arguments are checked against field formats
+func (r *Registry) Validating() bool {
+	return r != nil && r.config.Validate
+}
+
+// Validate checks the string arguments of a call against their field formats
+// and returns one message per invalid value, or nil if validation is disabled
+func (r *Registry) Validate(desc protoreflect.MessageDescriptor, args map[string]interface{}) []string {
+	if !r.Validating() {
+		return nil
+	}
+
+	var problems []string
+	jsonwalk.Walker{
+		Match: func(fd protoreflect.FieldDescriptor) bool { return r.Format(fd) != "" },
+		Visit: func(fd protoreflect.FieldDescriptor, path string, value interface{}) interface{} {
+			if s, ok := value.(string); ok {
+				if err := Check(r.Format(fd), s); err != nil {
+					problems = append(problems, fmt.Sprintf("field %s %v (got %q)", path, err, s))
+				}
+			}
+			return value
+		},
+	}.Walk(desc, args)
+	return problems
+}
+
+// fieldInfoFormat reads the format from the google.api.field_info option,
+// scanning the raw options so the annotation package need not be linked
+func fieldInfoFormat(fd protoreflect.FieldDescriptor) string {
+	options := fd.Options()
+	if options == nil {
+		return ""
+	}
+	raw, err := proto.Marshal(options)
+	if err != nil || len(raw) == 0 {
+		return ""
+	}
+
+	info, ok := findBytes(raw, fieldInfoNumber)
+	if !ok {
+		return ""
+	}
+	for len(info) > 0 {
+		num, typ, n := protowire.ConsumeTag(info)
+		if n < 0 {
+			return ""
+		}
+		info = info[n:]
+		if num == 1 && typ == protowire.VarintType {
+			value, n := protowire.ConsumeVarint(info)
+			if n < 0 {
+				return ""
+			}
+			return fieldInfoFormats[value]
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, info); n < 0 {
+			return ""
+		}
+		info = info[n:]
+	}
+	return ""
+}
+
+// findBytes returns the last length-delimited value of a field in a wire-format message
+func findBytes(raw []byte, number protowire.Number) ([]byte, bool) {
+	var found []byte
+	ok := false
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return nil, false
+		}
+		raw = raw[n:]
+		if num == number && typ == protowire.BytesType {
+			value, m := protowire.ConsumeBytes(raw)
+			if m < 0 {
+				return nil, false
+			}
+			found, ok = value, true
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, raw); n < 0 {
+			return nil, false
+		}
+		raw = raw[n:]
+	}
+	return found, ok
+}
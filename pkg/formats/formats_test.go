@@ -0,0 +1,173 @@
+package formats
+
+import (
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// customerDescriptor builds a message with a field_info annotated UUID field,
+// plain string fields and nested, repeated and map fields
+func customerDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+
+	// google.api.field_info { format: UUID4 }
+	fieldInfo := protowire.AppendTag(nil, 1, protowire.VarintType)
+	fieldInfo = protowire.AppendVarint(fieldInfo, 1)
+	options := &descriptorpb.FieldOptions{}
+	raw := protowire.AppendTag(nil, fieldInfoNumber, protowire.BytesType)
+	options.ProtoReflect().SetUnknown(protowire.AppendBytes(raw, fieldInfo))
+
+	id := field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	id.Options = options
+
+	emails := field("emails", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	emails.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+	visits := field("visits", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Customer.VisitsEntry")
+	visits.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("customer.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Address"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("website", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+			{
+				Name: proto.String("Customer"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					id,
+					field("birthday", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					emails,
+					field("address", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Address"),
+					visits,
+					field("age", 6, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("VisitsEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+						field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Customer")
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{"date", []string{"2024-03-15"}, []string{"15/03/2024", "2024-02-30", "2024-3-15"}},
+		{"date-time", []string{"2024-03-15T09:30:00Z", "2024-03-15T09:30:00.5+01:00"}, []string{"2024-03-15 09:30", "2024-03-15T09:30:00"}},
+		{"time", []string{"09:30:00Z", "09:30:00.250-05:00"}, []string{"9:30", "09:30:00"}},
+		{"uuid", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567e89b12d3a456426614174000", "not-a-uuid"}},
+		{"email", []string{"name@example.com"}, []string{"name", "Name <name@example.com>"}},
+		{"uri", []string{"https://example.com/path", "urn:isbn:0451450523"}, []string{"/relative/path", "example.com"}},
+		{"ipv4", []string{"192.0.2.1"}, []string{"2001:db8::1", "256.0.0.1"}},
+		{"ipv6", []string{"2001:db8::1"}, []string{"192.0.2.1", "2001:db8::g"}},
+		{"hostname", []string{"api.example.com", "localhost"}, []string{"-bad.example.com", "under_score.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			for _, value := range tt.valid {
+				assert.NoError(t, Check(tt.format, value), value)
+			}
+			for _, value := range tt.invalid {
+				err := Check(tt.format, value)
+				if assert.Error(t, err, value) {
+					assert.Contains(t, err.Error(), "e.g.")
+				}
+			}
+		})
+	}
+
+	t.Run("Every_configurable_format_is_checked", func(t *testing.T) {
+		for _, format := range config.StringFormats {
+			assert.Contains(t, checkers, format)
+		}
+	})
+}
+
+func TestRegistry_Format(t *testing.T) {
+	desc := customerDescriptor(t)
+	registry := NewRegistry(config.FormatsConfig{
+		Fields: map[string]string{"shop.Customer.birthday": "date"},
+	})
+
+	assert.Equal(t, "uuid", registry.Format(desc.Fields().ByName("id")))
+	assert.Equal(t, "date", registry.Format(desc.Fields().ByName("birthday")))
+	assert.Empty(t, registry.Format(desc.Fields().ByName("emails")))
+	assert.Empty(t, registry.Format(desc.Fields().ByName("age")))
+
+	var none *Registry
+	assert.Empty(t, none.Format(desc.Fields().ByName("birthday")))
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	desc := customerDescriptor(t)
+	formatsConfig := config.FormatsConfig{
+		Validate: true,
+		Fields: map[string]string{
+			"shop.Customer.birthday":          "date",
+			"shop.Customer.emails":            "email",
+			"shop.Address.website":            "uri",
+			"shop.Customer.VisitsEntry.value": "date-time",
+		},
+	}
+
+	args := map[string]interface{}{
+		"id":       "123e4567-e89b-12d3-a456-426614174000",
+		"birthday": "03/15/1990",
+		"emails":   []interface{}{"ana@example.com", "ana"},
+		"address":  map[string]interface{}{"website": "example.com"},
+		"visits":   map[string]interface{}{"berlin": "2024-03-15T09:30:00Z", "paris": "yesterday"},
+		"age":      42,
+	}
+
+	t.Run("Reports_every_mismatch", func(t *testing.T) {
+		problems := NewRegistry(formatsConfig).Validate(desc, args)
+		require.Len(t, problems, 4)
+		assert.Equal(t, `field birthday must be a date in YYYY-MM-DD form, e.g. 2024-03-15 (got "03/15/1990")`, problems[0])
+		assert.Contains(t, problems[1], "field emails[1] must be a bare email address")
+		assert.Contains(t, problems[2], "field address.website must be an absolute URI")
+		assert.Contains(t, problems[3], `field visits["paris"] must be an RFC 3339 timestamp`)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		formatsConfig := formatsConfig
+		formatsConfig.Validate = false
+		assert.Nil(t, NewRegistry(formatsConfig).Validate(desc, args))
+	})
+}
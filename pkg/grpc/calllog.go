@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	grpcLib "google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CallInfo identifies a tool call in the HTTP and gRPC logs
+type CallInfo struct {
+	CallID    string
+	RequestID string
+	SessionID string
+}
+
+// callInfoKey is the context key of the call info
+type callInfoKey struct{}
+
+// WithCallInfo returns a context carrying the call info for outgoing calls
+func WithCallInfo(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// CallInfoFromContext returns the call info carried by the context, if any
+func CallInfoFromContext(ctx context.Context) (CallInfo, bool) {
+	info, ok := ctx.Value(callInfoKey{}).(CallInfo)
+	return info, ok
+}
+
+// LogFields returns the fields identifying the call in log entries
+func (i CallInfo) LogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("callId", i.CallID),
+		zap.String("requestId", i.RequestID),
+		zap.String("sessionId", i.SessionID),
+	}
+}
+
+// callLoggingInterceptor logs the start and end of outgoing calls made for a
+// tool call. Calls without call info (health checks, reflection) are not logged.
+func callLoggingInterceptor(logger *zap.Logger) grpcLib.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpcLib.ClientConn, invoker grpcLib.UnaryInvoker, opts ...grpcLib.CallOption) error {
+		info, ok := CallInfoFromContext(ctx)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		fields := append(info.LogFields(),
+			zap.String("grpcMethod", method),
+			zap.String("target", cc.Target()))
+
+		logger.Info("gRPC call started",
+			append(fields[:len(fields):len(fields)], zap.Int("requestBytes", messageSize(req)))...)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		fields = append(fields,
+			zap.String("status", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)))
+		if err != nil {
+			logger.Warn("gRPC call failed", append(fields, zap.Error(err))...)
+			return err
+		}
+
+		logger.Info("gRPC call completed", append(fields, zap.Int("responseBytes", messageSize(reply)))...)
+		return nil
+	}
+}
+
+// messageSize returns the encoded size of a protobuf message, or 0 for other values
+func messageSize(msg interface{}) int {
+	if message, ok := msg.(proto.Message); ok {
+		return proto.Size(message)
+	}
+	return 0
+}
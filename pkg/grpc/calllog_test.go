@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	grpcLib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCallLoggingInterceptor(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	interceptor := callLoggingInterceptor(zap.New(core))
+
+	cc, err := grpcLib.NewClient("passthrough:///backend:50051", grpcLib.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	info := CallInfo{CallID: "call-1", RequestID: "req-1", SessionID: "sess-1"}
+	req := wrapperspb.String("hello")
+
+	t.Run("Completed", func(t *testing.T) {
+		reply := wrapperspb.String("")
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpcLib.ClientConn, opts ...grpcLib.CallOption) error {
+			reply.(*wrapperspb.StringValue).Value = "hello, world"
+			return nil
+		}
+
+		require.NoError(t, interceptor(WithCallInfo(context.Background(), info), "/hello.HelloService/SayHello", req, reply, cc, invoker))
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "gRPC call started", entries[0].Message)
+		assert.Equal(t, "gRPC call completed", entries[1].Message)
+		for _, entry := range entries {
+			fields := entry.ContextMap()
+			assert.Equal(t, "call-1", fields["callId"])
+			assert.Equal(t, "req-1", fields["requestId"])
+			assert.Equal(t, "sess-1", fields["sessionId"])
+			assert.Equal(t, "/hello.HelloService/SayHello", fields["grpcMethod"])
+		}
+		assert.Equal(t, int64(7), entries[0].ContextMap()["requestBytes"])
+		assert.Equal(t, int64(14), entries[1].ContextMap()["responseBytes"])
+		assert.Equal(t, "OK", entries[1].ContextMap()["status"])
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpcLib.ClientConn, opts ...grpcLib.CallOption) error {
+			return status.Error(codes.NotFound, "no such greeting")
+		}
+
+		err := interceptor(WithCallInfo(context.Background(), info), "/hello.HelloService/SayHello", req, wrapperspb.String(""), cc, invoker)
+		require.Error(t, err)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 2)
+		assert.Equal(t, "gRPC call failed", entries[1].Message)
+		assert.Equal(t, "NotFound", entries[1].ContextMap()["status"])
+		assert.Equal(t, "call-1", entries[1].ContextMap()["callId"])
+	})
+
+	t.Run("Without_call_info", func(t *testing.T) {
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpcLib.ClientConn, opts ...grpcLib.CallOption) error {
+			return nil
+		}
+
+		require.NoError(t, interceptor(context.Background(), "/grpc.health.v1.Health/Check", req, wrapperspb.String(""), cc, invoker))
+		assert.Zero(t, logs.Len())
+	})
+}
@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"go.uber.org/zap"
+)
+
+// canaryRouter routes a percentage of tool calls to a canary backend
+type canaryRouter struct {
+	target   *backendTarget
+	percent  float64
+	services map[string]float64
+
+	// random returns a value in [0, 100)
+	random func() float64
+
+	// Per-target counters
+	primaryCalls  atomic.Int64
+	primaryErrors atomic.Int64
+	canaryCalls   atomic.Int64
+	canaryErrors  atomic.Int64
+}
+
+// newCanaryRouter creates a canary router for the configured canary target
+func newCanaryRouter(canaryConfig config.CanaryConfig, baseConfig ConnectionManagerConfig, logger *zap.Logger) *canaryRouter {
+	return &canaryRouter{
+		target:   newBackendTarget("canary", canaryConfig.Host, canaryConfig.Port, baseConfig, logger),
+		percent:  canaryConfig.Percent,
+		services: canaryConfig.Services,
+		random:   func() float64 { return rand.Float64() * 100 },
+	}
+}
+
+// route returns the canary client if this call should go to the canary, or nil for the primary
+func (c *canaryRouter) route(method types.MethodInfo) ReflectionClient {
+	percent := c.percent
+	if servicePercent, exists := c.services[method.ServiceName]; exists {
+		percent = servicePercent
+	}
+
+	if percent <= 0 || c.random() >= percent {
+		return nil
+	}
+
+	return c.target.reflectionClient()
+}
+
+// record updates the per-target counters for a completed call
+func (c *canaryRouter) record(canary bool, err error) {
+	if canary {
+		c.canaryCalls.Add(1)
+		if err != nil {
+			c.canaryErrors.Add(1)
+		}
+		return
+	}
+
+	c.primaryCalls.Add(1)
+	if err != nil {
+		c.primaryErrors.Add(1)
+	}
+}
+
+// stats returns per-target routing counters
+func (c *canaryRouter) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"percent": c.percent,
+		"primary": map[string]interface{}{
+			"calls":  c.primaryCalls.Load(),
+			"errors": c.primaryErrors.Load(),
+		},
+		"canary": map[string]interface{}{
+			"calls":     c.canaryCalls.Load(),
+			"errors":    c.canaryErrors.Load(),
+			"connected": c.target.reflectionClient() != nil,
+		},
+	}
+}
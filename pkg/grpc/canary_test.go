@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestCanaryRouter(percent float64, services map[string]float64, roll float64) (*canaryRouter, *mockReflectionClient) {
+	canaryClient := &mockReflectionClient{}
+	return &canaryRouter{
+		target: &backendTarget{
+			name:   "canary",
+			logger: zap.NewNop(),
+			client: canaryClient,
+		},
+		percent:  percent,
+		services: services,
+		random:   func() float64 { return roll },
+	}, canaryClient
+}
+
+func TestCanaryRouter_Route(t *testing.T) {
+	method := types.MethodInfo{ServiceName: "hello.HelloService"}
+
+	tests := []struct {
+		name         string
+		percent      float64
+		services     map[string]float64
+		roll         float64
+		expectCanary bool
+	}{
+		{"Roll_below_percent", 10, nil, 5, true},
+		{"Roll_above_percent", 10, nil, 50, false},
+		{"Zero_percent", 0, nil, 0, false},
+		{"Full_percent", 100, nil, 99.9, true},
+		{"Service_override_routes", 0, map[string]float64{"hello.HelloService": 50}, 25, true},
+		{"Service_override_excludes", 100, map[string]float64{"hello.HelloService": 0}, 0, false},
+		{"Other_service_override_ignored", 10, map[string]float64{"other.Service": 100}, 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, canaryClient := newTestCanaryRouter(tt.percent, tt.services, tt.roll)
+			client := router.route(method)
+			if tt.expectCanary {
+				assert.Equal(t, canaryClient, client)
+			} else {
+				assert.Nil(t, client)
+			}
+		})
+	}
+}
+
+func TestCanaryRouter_RouteDisconnected(t *testing.T) {
+	router, _ := newTestCanaryRouter(100, nil, 0)
+	router.target.client = nil
+
+	assert.Nil(t, router.route(types.MethodInfo{ServiceName: "hello.HelloService"}))
+}
+
+func TestCanaryRouter_Stats(t *testing.T) {
+	router, _ := newTestCanaryRouter(10, nil, 0)
+
+	router.record(false, nil)
+	router.record(false, errors.New("failed"))
+	router.record(true, nil)
+
+	stats := router.stats()
+	assert.Equal(t, int64(2), stats["primary"].(map[string]interface{})["calls"])
+	assert.Equal(t, int64(1), stats["primary"].(map[string]interface{})["errors"])
+	assert.Equal(t, int64(1), stats["canary"].(map[string]interface{})["calls"])
+	assert.Equal(t, int64(0), stats["canary"].(map[string]interface{})["errors"])
+}
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+// DiscoveryChange describes how a discovery changed the set of tools
+type DiscoveryChange struct {
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	ToolCount int      `json:"toolCount"`
+}
+
+// changeListeners notifies listeners of discoveries and of the changes they make to the tools
+type changeListeners struct {
+	mu        sync.Mutex
+	listeners []func(DiscoveryChange)
+	discovery []func([]types.MethodInfo)
+}
+
+// add registers a listener
+func (c *changeListeners) add(listener func(DiscoveryChange)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// addDiscovery registers a listener called with the methods of every discovery
+func (c *changeListeners) addDiscovery(listener func([]types.MethodInfo)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.discovery = append(c.discovery, listener)
+}
+
+// notify calls the discovery listeners, then the change listeners if the
+// tools differ between the two discoveries
+func (c *changeListeners) notify(methods []types.MethodInfo, previous, current map[string]types.MethodInfo) {
+	c.mu.Lock()
+	listeners := slices.Clone(c.listeners)
+	discovery := slices.Clone(c.discovery)
+	c.mu.Unlock()
+
+	for _, listener := range discovery {
+		listener(methods)
+	}
+
+	change, changed := diffTools(previous, current)
+	if !changed {
+		return
+	}
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+// diffTools lists the tools added and removed between two discoveries
+func diffTools(previous, current map[string]types.MethodInfo) (DiscoveryChange, bool) {
+	change := DiscoveryChange{ToolCount: len(current)}
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			change.Added = append(change.Added, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			change.Removed = append(change.Removed, name)
+		}
+	}
+	sort.Strings(change.Added)
+	sort.Strings(change.Removed)
+	return change, len(change.Added) > 0 || len(change.Removed) > 0
+}
@@ -0,0 +1,331 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	grpcLib "google.golang.org/grpc"
+	channelzgrpc "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	channelzservice "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// channelzMaxResults bounds each channelz listing, so a busy process cannot
+// make a diagnostics report arbitrarily large
+const channelzMaxResults = 100
+
+// ChannelzReport describes the gRPC connections seen by channelz
+type ChannelzReport struct {
+	// Gateway holds the gateway's own channels to the backends
+	Gateway *ChannelzSnapshot `json:"gateway"`
+
+	// Backend holds the backend's view, when queried and exposed by the backend
+	Backend *ChannelzSnapshot `json:"backend,omitempty"`
+
+	// BackendError explains why the backend's view is missing
+	BackendError string `json:"backendError,omitempty"`
+}
+
+// ChannelzSnapshot is the channelz state of one process
+type ChannelzSnapshot struct {
+	Channels []ChannelzChannel `json:"channels"`
+	Servers  []ChannelzServer  `json:"servers"`
+
+	// Truncated is set when a listing had more than channelzMaxResults entries
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ChannelzCalls counts the calls made through a channel or served by a server
+type ChannelzCalls struct {
+	Started         int64      `json:"started"`
+	Succeeded       int64      `json:"succeeded"`
+	Failed          int64      `json:"failed"`
+	LastCallStarted *time.Time `json:"lastCallStarted,omitempty"`
+}
+
+// ChannelzChannel is a client channel and its subchannels
+type ChannelzChannel struct {
+	ID          int64                `json:"id"`
+	Target      string               `json:"target"`
+	State       string               `json:"state"`
+	Calls       ChannelzCalls        `json:"calls"`
+	Subchannels []ChannelzSubchannel `json:"subchannels"`
+}
+
+// ChannelzSubchannel is a connection attempt to one backend address
+type ChannelzSubchannel struct {
+	ID      int64            `json:"id"`
+	Target  string           `json:"target"`
+	State   string           `json:"state"`
+	Calls   ChannelzCalls    `json:"calls"`
+	Sockets []ChannelzSocket `json:"sockets"`
+}
+
+// ChannelzServer is a gRPC server and its connected sockets
+type ChannelzServer struct {
+	ID      int64            `json:"id"`
+	Calls   ChannelzCalls    `json:"calls"`
+	Sockets []ChannelzSocket `json:"sockets"`
+}
+
+// ChannelzSocket holds the stream and message counters of one connection
+type ChannelzSocket struct {
+	ID                  int64      `json:"id"`
+	Local               string     `json:"local,omitempty"`
+	Remote              string     `json:"remote,omitempty"`
+	StreamsStarted      int64      `json:"streamsStarted"`
+	StreamsSucceeded    int64      `json:"streamsSucceeded"`
+	StreamsFailed       int64      `json:"streamsFailed"`
+	MessagesSent        int64      `json:"messagesSent"`
+	MessagesReceived    int64      `json:"messagesReceived"`
+	KeepAlivesSent      int64      `json:"keepAlivesSent"`
+	LastMessageSent     *time.Time `json:"lastMessageSent,omitempty"`
+	LastMessageReceived *time.Time `json:"lastMessageReceived,omitempty"`
+}
+
+// channelzSource is the part of the channelz service used for reports,
+// implemented by the in-process service and by a client of a remote one
+type channelzSource interface {
+	GetTopChannels(context.Context, *channelzgrpc.GetTopChannelsRequest) (*channelzgrpc.GetTopChannelsResponse, error)
+	GetServers(context.Context, *channelzgrpc.GetServersRequest) (*channelzgrpc.GetServersResponse, error)
+	GetServerSockets(context.Context, *channelzgrpc.GetServerSocketsRequest) (*channelzgrpc.GetServerSocketsResponse, error)
+	GetSubchannel(context.Context, *channelzgrpc.GetSubchannelRequest) (*channelzgrpc.GetSubchannelResponse, error)
+	GetSocket(context.Context, *channelzgrpc.GetSocketRequest) (*channelzgrpc.GetSocketResponse, error)
+}
+
+// channelzRegistrar captures the channelz service implementation instead of serving it
+type channelzRegistrar struct {
+	impl channelzgrpc.ChannelzServer
+}
+
+// RegisterService implements grpc.ServiceRegistrar
+func (r *channelzRegistrar) RegisterService(_ *grpcLib.ServiceDesc, impl interface{}) {
+	r.impl, _ = impl.(channelzgrpc.ChannelzServer)
+}
+
+// localChannelz returns the in-process channelz service
+func localChannelz() channelzSource {
+	registrar := &channelzRegistrar{}
+	channelzservice.RegisterChannelzServiceToServer(registrar)
+	return registrar.impl
+}
+
+// remoteChannelz queries the channelz service of another process
+type remoteChannelz struct {
+	client channelzgrpc.ChannelzClient
+}
+
+func (r remoteChannelz) GetTopChannels(ctx context.Context, req *channelzgrpc.GetTopChannelsRequest) (*channelzgrpc.GetTopChannelsResponse, error) {
+	return r.client.GetTopChannels(ctx, req)
+}
+
+func (r remoteChannelz) GetServers(ctx context.Context, req *channelzgrpc.GetServersRequest) (*channelzgrpc.GetServersResponse, error) {
+	return r.client.GetServers(ctx, req)
+}
+
+func (r remoteChannelz) GetServerSockets(ctx context.Context, req *channelzgrpc.GetServerSocketsRequest) (*channelzgrpc.GetServerSocketsResponse, error) {
+	return r.client.GetServerSockets(ctx, req)
+}
+
+func (r remoteChannelz) GetSubchannel(ctx context.Context, req *channelzgrpc.GetSubchannelRequest) (*channelzgrpc.GetSubchannelResponse, error) {
+	return r.client.GetSubchannel(ctx, req)
+}
+
+func (r remoteChannelz) GetSocket(ctx context.Context, req *channelzgrpc.GetSocketRequest) (*channelzgrpc.GetSocketResponse, error) {
+	return r.client.GetSocket(ctx, req)
+}
+
+// Channelz reports the gateway's gRPC connections and, if asked, the
+// backend's view of them. A backend without channelz is not an error: the
+// reason is reported alongside the gateway's view.
+func (d *serviceDiscoverer) Channelz(ctx context.Context, queryBackend bool) (*ChannelzReport, error) {
+	gateway, err := collectChannelz(ctx, localChannelz())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gateway channelz: %w", err)
+	}
+	report := &ChannelzReport{Gateway: gateway}
+	if !queryBackend {
+		return report, nil
+	}
+
+	conn := d.connManager.GetConnection()
+	if conn == nil {
+		report.BackendError = "not connected to gRPC server"
+		return report, nil
+	}
+	backend, err := collectChannelz(ctx, remoteChannelz{client: channelzgrpc.NewChannelzClient(conn)})
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		report.BackendError = "backend does not expose channelz"
+	case err != nil:
+		report.BackendError = err.Error()
+	default:
+		report.Backend = backend
+	}
+	return report, nil
+}
+
+// collectChannelz reads the channels and servers of a process with their sockets
+func collectChannelz(ctx context.Context, source channelzSource) (*ChannelzSnapshot, error) {
+	snapshot := &ChannelzSnapshot{
+		Channels: make([]ChannelzChannel, 0),
+		Servers:  make([]ChannelzServer, 0),
+	}
+
+	channels, err := source.GetTopChannels(ctx, &channelzgrpc.GetTopChannelsRequest{MaxResults: channelzMaxResults})
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Truncated = !channels.GetEnd()
+	for _, channel := range channels.GetChannel() {
+		data := channel.GetData()
+		converted := ChannelzChannel{
+			ID:          channel.GetRef().GetChannelId(),
+			Target:      data.GetTarget(),
+			State:       data.GetState().GetState().String(),
+			Calls:       channelzCalls(data.GetCallsStarted(), data.GetCallsSucceeded(), data.GetCallsFailed(), data.GetLastCallStartedTimestamp()),
+			Subchannels: make([]ChannelzSubchannel, 0, len(channel.GetSubchannelRef())),
+		}
+		for _, ref := range channel.GetSubchannelRef() {
+			response, err := source.GetSubchannel(ctx, &channelzgrpc.GetSubchannelRequest{SubchannelId: ref.GetSubchannelId()})
+			if status.Code(err) == codes.NotFound {
+				continue // closed since the channel was listed
+			}
+			if err != nil {
+				return nil, err
+			}
+			subchannel := response.GetSubchannel()
+			subData := subchannel.GetData()
+			sockets, err := channelzSockets(ctx, source, subchannel.GetSocketRef())
+			if err != nil {
+				return nil, err
+			}
+			converted.Subchannels = append(converted.Subchannels, ChannelzSubchannel{
+				ID:      ref.GetSubchannelId(),
+				Target:  subData.GetTarget(),
+				State:   subData.GetState().GetState().String(),
+				Calls:   channelzCalls(subData.GetCallsStarted(), subData.GetCallsSucceeded(), subData.GetCallsFailed(), subData.GetLastCallStartedTimestamp()),
+				Sockets: sockets,
+			})
+		}
+		snapshot.Channels = append(snapshot.Channels, converted)
+	}
+
+	servers, err := source.GetServers(ctx, &channelzgrpc.GetServersRequest{MaxResults: channelzMaxResults})
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Truncated = snapshot.Truncated || !servers.GetEnd()
+	for _, server := range servers.GetServer() {
+		data := server.GetData()
+		refs, err := source.GetServerSockets(ctx, &channelzgrpc.GetServerSocketsRequest{
+			ServerId:   server.GetRef().GetServerId(),
+			MaxResults: channelzMaxResults,
+		})
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Truncated = snapshot.Truncated || !refs.GetEnd()
+		sockets, err := channelzSockets(ctx, source, refs.GetSocketRef())
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Servers = append(snapshot.Servers, ChannelzServer{
+			ID:      server.GetRef().GetServerId(),
+			Calls:   channelzCalls(data.GetCallsStarted(), data.GetCallsSucceeded(), data.GetCallsFailed(), data.GetLastCallStartedTimestamp()),
+			Sockets: sockets,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// channelzSockets reads the sockets behind the refs, skipping closed ones
+func channelzSockets(ctx context.Context, source channelzSource, refs []*channelzgrpc.SocketRef) ([]ChannelzSocket, error) {
+	sockets := make([]ChannelzSocket, 0, len(refs))
+	for _, ref := range refs {
+		response, err := source.GetSocket(ctx, &channelzgrpc.GetSocketRequest{SocketId: ref.GetSocketId()})
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		socket := response.GetSocket()
+		data := socket.GetData()
+		sockets = append(sockets, ChannelzSocket{
+			ID:                  ref.GetSocketId(),
+			Local:               channelzAddress(socket.GetLocal()),
+			Remote:              channelzAddress(socket.GetRemote()),
+			StreamsStarted:      data.GetStreamsStarted(),
+			StreamsSucceeded:    data.GetStreamsSucceeded(),
+			StreamsFailed:       data.GetStreamsFailed(),
+			MessagesSent:        data.GetMessagesSent(),
+			MessagesReceived:    data.GetMessagesReceived(),
+			KeepAlivesSent:      data.GetKeepAlivesSent(),
+			LastMessageSent:     channelzTime(data.GetLastMessageSentTimestamp()),
+			LastMessageReceived: channelzTime(data.GetLastMessageReceivedTimestamp()),
+		})
+	}
+	return sockets, nil
+}
+
+// channelzCalls converts call counters
+func channelzCalls(started, succeeded, failed int64, lastStarted *timestamppb.Timestamp) ChannelzCalls {
+	return ChannelzCalls{
+		Started:         started,
+		Succeeded:       succeeded,
+		Failed:          failed,
+		LastCallStarted: channelzTime(lastStarted),
+	}
+}
+
+// channelzTime converts a timestamp, treating unset and zero timestamps as absent
+func channelzTime(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil || (timestamp.GetSeconds() == 0 && timestamp.GetNanos() == 0) {
+		return nil
+	}
+	t := timestamp.AsTime()
+	return &t
+}
+
+// channelzAddress formats a socket address
+func channelzAddress(address *channelzgrpc.Address) string {
+	switch {
+	case address.GetTcpipAddress() != nil:
+		tcp := address.GetTcpipAddress()
+		return net.JoinHostPort(net.IP(tcp.GetIpAddress()).String(), strconv.Itoa(int(tcp.GetPort())))
+	case address.GetUdsAddress() != nil:
+		return "unix:" + address.GetUdsAddress().GetFilename()
+	case address.GetOtherAddress() != nil:
+		return address.GetOtherAddress().GetName()
+	default:
+		return ""
+	}
+}
+
+// ServeChannelz serves the gateway's own channelz service on the address, for
+// gRPC debugging tools. The returned function stops the server.
+func ServeChannelz(address string, logger *zap.Logger) (func(), error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for channelz on %s: %w", address, err)
+	}
+
+	server := grpcLib.NewServer()
+	channelzservice.RegisterChannelzServiceToServer(server)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Warn("Channelz server stopped", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Serving channelz", zap.String("address", listener.Addr().String()))
+	return server.Stop, nil
+}
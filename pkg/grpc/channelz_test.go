@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	grpcLib "google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startChannelzBackend starts a backend serving health checks, and channelz
+// if asked, and returns a client connection to it after one call
+func startChannelzBackend(t *testing.T, withChannelz bool) (*grpcLib.ClientConn, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpcLib.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	if withChannelz {
+		channelzservice.RegisterChannelzServiceToServer(server)
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	address := listener.Addr().String()
+	conn, err := grpcLib.NewClient(address, grpcLib.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	return conn, address
+}
+
+func newChannelzDiscoverer(conn *grpcLib.ClientConn) *serviceDiscoverer {
+	connManager := &mockConnectionManager{}
+	connManager.On("GetConnection").Return(conn)
+	return newServiceDiscovererWithConnManager(connManager, zap.NewNop())
+}
+
+func TestServiceDiscoverer_Channelz(t *testing.T) {
+	t.Run("Gateway_and_backend", func(t *testing.T) {
+		conn, address := startChannelzBackend(t, true)
+
+		report, err := newChannelzDiscoverer(conn).Channelz(context.Background(), true)
+		require.NoError(t, err)
+		assert.Empty(t, report.BackendError)
+
+		// The gateway sees its channel to the backend with a connected socket
+		var channel *ChannelzChannel
+		for i := range report.Gateway.Channels {
+			if report.Gateway.Channels[i].Target == address {
+				channel = &report.Gateway.Channels[i]
+			}
+		}
+		require.NotNil(t, channel, "channel to %s", address)
+		assert.Equal(t, "READY", channel.State)
+		assert.GreaterOrEqual(t, channel.Calls.Succeeded, int64(1))
+		require.NotEmpty(t, channel.Subchannels)
+		require.NotEmpty(t, channel.Subchannels[0].Sockets)
+		socket := channel.Subchannels[0].Sockets[0]
+		assert.Equal(t, address, socket.Remote)
+		assert.GreaterOrEqual(t, socket.StreamsStarted, int64(1))
+
+		// The backend sees the gateway's connection on its server
+		require.NotNil(t, report.Backend)
+		var remotes []string
+		for _, server := range report.Backend.Servers {
+			for _, socket := range server.Sockets {
+				remotes = append(remotes, socket.Remote)
+			}
+		}
+		assert.Contains(t, remotes, socket.Local)
+	})
+
+	t.Run("Backend_without_channelz", func(t *testing.T) {
+		conn, _ := startChannelzBackend(t, false)
+
+		report, err := newChannelzDiscoverer(conn).Channelz(context.Background(), true)
+		require.NoError(t, err)
+		assert.NotNil(t, report.Gateway)
+		assert.Nil(t, report.Backend)
+		assert.Equal(t, "backend does not expose channelz", report.BackendError)
+	})
+
+	t.Run("Backend_not_queried", func(t *testing.T) {
+		report, err := newChannelzDiscoverer(nil).Channelz(context.Background(), false)
+		require.NoError(t, err)
+		assert.NotNil(t, report.Gateway)
+		assert.Nil(t, report.Backend)
+		assert.Empty(t, report.BackendError)
+	})
+}
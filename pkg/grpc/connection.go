@@ -3,13 +3,13 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	grpcLib "google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -20,6 +20,9 @@ type connectionManager struct {
 
 	mu   sync.RWMutex
 	conn *grpcLib.ClientConn
+
+	// credsCloser releases credential resources (e.g. a SPIFFE Workload API stream)
+	credsCloser io.Closer
 }
 
 // NewConnectionManager creates a new connection manager
@@ -39,13 +42,13 @@ func (cm *connectionManager) Connect(ctx context.Context) error {
 	if cm.conn != nil {
 		_ = cm.conn.Close()
 	}
+	cm.closeCredentialsLocked()
 
 	target := fmt.Sprintf("%s:%d", cm.config.Host, cm.config.Port)
 	cm.logger.Info("Connecting to gRPC server", zap.String("target", target))
 
 	// Configure connection options
 	opts := []grpcLib.DialOption{
-		grpcLib.WithTransportCredentials(insecure.NewCredentials()),
 		grpcLib.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                cm.config.KeepAlive.Time,
 			Timeout:             cm.config.KeepAlive.Timeout,
@@ -57,12 +60,55 @@ func (cm *connectionManager) Connect(ctx context.Context) error {
 		),
 	}
 
+	// Log tool calls with the IDs the HTTP layer logs them under
+	if cm.config.LogCalls {
+		opts = append(opts, grpcLib.WithChainUnaryInterceptor(callLoggingInterceptor(cm.logger)))
+	}
+
+	// Tunnel through a forward proxy if one is configured
+	proxyURL, err := resolveProxy(cm.config.Proxy, target)
+	if err != nil {
+		return fmt.Errorf("failed to resolve proxy: %w", err)
+	}
+	if proxyURL != nil {
+		cm.logger.Info("Connecting through proxy",
+			zap.String("proxy", proxyURL.Redacted()),
+			zap.String("target", target))
+		opts = append(opts, grpcLib.WithContextDialer(proxyDialer(proxyURL)))
+	}
+
+	// Route through proxies that dispatch on :authority, such as Envoy
+	if cm.config.Authority != "" {
+		opts = append(opts, grpcLib.WithAuthority(cm.config.Authority))
+	}
+	if cm.config.UserAgent != "" {
+		opts = append(opts, grpcLib.WithUserAgent(cm.config.UserAgent))
+	}
+
 	// Create context with timeout
 	connectCtx, cancel := context.WithTimeout(ctx, cm.config.ConnectTimeout)
 	defer cancel()
 
+	creds, credsCloser, err := transportCredentials(connectCtx, cm.config.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to load transport credentials: %w", err)
+	}
+	cm.credsCloser = credsCloser
+	opts = append(opts, grpcLib.WithTransportCredentials(creds))
+
+	// Attach Google ID tokens for Cloud Run / IAP-protected backends
+	if cm.config.GoogleIDToken.Enabled {
+		idTokenCreds, err := newGoogleIDTokenCredentials(cm.config.GoogleIDToken)
+		if err != nil {
+			cm.closeCredentialsLocked()
+			return fmt.Errorf("failed to load Google ID token credentials: %w", err)
+		}
+		opts = append(opts, grpcLib.WithPerRPCCredentials(idTokenCreds))
+	}
+
 	conn, err := grpcLib.DialContext(connectCtx, target, opts...)
 	if err != nil {
+		cm.closeCredentialsLocked()
 		return fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
 
@@ -72,6 +118,7 @@ func (cm *connectionManager) Connect(ctx context.Context) error {
 	if err := cm.healthCheckLocked(ctx); err != nil {
 		_ = cm.conn.Close()
 		cm.conn = nil
+		cm.closeCredentialsLocked()
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
@@ -145,6 +192,7 @@ func (cm *connectionManager) healthCheckLocked(ctx context.Context) error {
 func (cm *connectionManager) Close() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
+	defer cm.closeCredentialsLocked()
 
 	if cm.conn != nil {
 		err := cm.conn.Close()
@@ -158,3 +206,14 @@ func (cm *connectionManager) Close() error {
 
 	return nil
 }
+
+// closeCredentialsLocked releases credential resources (caller must hold lock)
+func (cm *connectionManager) closeCredentialsLocked() {
+	if cm.credsCloser == nil {
+		return
+	}
+	if err := cm.credsCloser.Close(); err != nil {
+		cm.logger.Warn("Failed to close transport credentials", zap.Error(err))
+	}
+	cm.credsCloser = nil
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	grpcLib "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestConnectionManager_AuthorityAndUserAgent(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	received := make(chan metadata.MD, 1)
+	server := grpcLib.NewServer(grpcLib.UnaryInterceptor(
+		func(ctx context.Context, req interface{}, info *grpcLib.UnaryServerInfo, handler grpcLib.UnaryHandler) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			received <- md
+			return handler(ctx, req)
+		}))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	cm := NewConnectionManager(ConnectionManagerConfig{
+		Host:           addr.IP.String(),
+		Port:           addr.Port,
+		ConnectTimeout: 5 * time.Second,
+		MaxMessageSize: 4 * 1024 * 1024,
+		Authority:      "orders.internal",
+		UserAgent:      "ggrmcp-gateway/1.0",
+	}, zap.NewNop())
+	require.NoError(t, cm.Connect(context.Background()))
+	t.Cleanup(func() { _ = cm.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(cm.GetConnection()).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	md := <-received
+	assert.Equal(t, []string{"orders.internal"}, md.Get(":authority"))
+	require.Len(t, md.Get("user-agent"), 1)
+	assert.True(t, strings.HasPrefix(md.Get("user-agent")[0], "ggrmcp-gateway/1.0 grpc-go/"), md.Get("user-agent")[0])
+}
@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spiffe/go-spiffe/v2/spiffegrpc/grpccredentials"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// transportCredentials builds the transport credentials for a connection.
+// The returned closer (possibly nil) releases resources such as a Workload API stream.
+func transportCredentials(ctx context.Context, config TLSConfig) (credentials.TransportCredentials, io.Closer, error) {
+	if !config.Enabled {
+		return insecure.NewCredentials(), nil, nil
+	}
+
+	if config.SPIFFE.Enabled {
+		return spiffeCredentials(ctx, config.SPIFFE)
+	}
+
+	tlsConfig, err := staticTLSConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil, nil
+}
+
+// staticTLSConfig builds a TLS configuration from CA and certificate files
+func staticTLSConfig(config TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.ServerName,
+	}
+
+	if config.CAFile != "" {
+		caPEM, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// spiffeCredentials builds mTLS credentials backed by the SPIFFE Workload API.
+// The X.509 source watches the Workload API, so rotated SVIDs and bundles apply to new handshakes.
+func spiffeCredentials(ctx context.Context, config SPIFFEConfig) (credentials.TransportCredentials, io.Closer, error) {
+	authorizer, err := spiffeAuthorizer(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sourceOptions []workloadapi.X509SourceOption
+	if config.SocketPath != "" {
+		sourceOptions = append(sourceOptions,
+			workloadapi.WithClientOptions(workloadapi.WithAddr(config.SocketPath)))
+	}
+
+	source, err := workloadapi.NewX509Source(ctx, sourceOptions...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch SVID from Workload API: %w", err)
+	}
+
+	return grpccredentials.MTLSClientCredentials(source, source, authorizer), source, nil
+}
+
+// spiffeAuthorizer restricts which backend SPIFFE IDs are accepted
+func spiffeAuthorizer(config SPIFFEConfig) (tlsconfig.Authorizer, error) {
+	if len(config.AuthorizedIDs) > 0 {
+		ids := make([]spiffeid.ID, 0, len(config.AuthorizedIDs))
+		for _, rawID := range config.AuthorizedIDs {
+			id, err := spiffeid.FromString(rawID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid authorized SPIFFE ID %q: %w", rawID, err)
+			}
+			ids = append(ids, id)
+		}
+		return tlsconfig.AuthorizeOneOf(ids...), nil
+	}
+
+	if config.TrustDomain != "" {
+		trustDomain, err := spiffeid.TrustDomainFromString(config.TrustDomain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE trust domain %q: %w", config.TrustDomain, err)
+		}
+		return tlsconfig.AuthorizeMemberOf(trustDomain), nil
+	}
+
+	return nil, fmt.Errorf("SPIFFE requires authorized IDs or a trust domain")
+}
@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert writes a self-signed certificate and key to dir
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "backend.internal"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTransportCredentials(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir)
+
+	t.Run("Disabled_is_plaintext", func(t *testing.T) {
+		creds, closer, err := transportCredentials(context.Background(), TLSConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, closer)
+		assert.Equal(t, "insecure", creds.Info().SecurityProtocol)
+	})
+
+	t.Run("Custom_CA_and_client_certificate", func(t *testing.T) {
+		creds, closer, err := transportCredentials(context.Background(), TLSConfig{
+			Enabled:    true,
+			CAFile:     certFile,
+			CertFile:   certFile,
+			KeyFile:    keyFile,
+			ServerName: "backend.internal",
+		})
+		require.NoError(t, err)
+		assert.Nil(t, closer)
+		assert.Equal(t, "tls", creds.Info().SecurityProtocol)
+
+		tlsConfig, err := staticTLSConfig(TLSConfig{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile})
+		require.NoError(t, err)
+		assert.NotNil(t, tlsConfig.RootCAs)
+		assert.Len(t, tlsConfig.Certificates, 1)
+	})
+
+	t.Run("Invalid_CA_file", func(t *testing.T) {
+		_, _, err := transportCredentials(context.Background(), TLSConfig{Enabled: true, CAFile: keyFile})
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing_CA_file", func(t *testing.T) {
+		_, _, err := transportCredentials(context.Background(), TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")})
+		assert.Error(t, err)
+	})
+}
+
+func TestSPIFFEAuthorizer(t *testing.T) {
+	_, err := spiffeAuthorizer(SPIFFEConfig{AuthorizedIDs: []string{"spiffe://example.org/backend"}})
+	assert.NoError(t, err)
+
+	_, err = spiffeAuthorizer(SPIFFEConfig{TrustDomain: "example.org"})
+	assert.NoError(t, err)
+
+	_, err = spiffeAuthorizer(SPIFFEConfig{AuthorizedIDs: []string{"https://example.org/backend"}})
+	assert.Error(t, err)
+
+	_, err = spiffeAuthorizer(SPIFFEConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"go.uber.org/zap"
+)
+
+// deprecatedCallStats counts calls to one deprecated method
+type deprecatedCallStats struct {
+	Calls      int64      `json:"calls"`
+	LastCalled *time.Time `json:"lastCalled,omitempty"`
+}
+
+// deprecationTracker records calls to deprecated methods, so operators can
+// see which deprecated tools are still in use before removing them
+type deprecationTracker struct {
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	calls map[string]*deprecatedCallStats // keyed by tool name
+}
+
+// newDeprecationTracker creates an empty deprecation tracker
+func newDeprecationTracker(logger *zap.Logger) *deprecationTracker {
+	return &deprecationTracker{
+		logger: logger,
+		calls:  make(map[string]*deprecatedCallStats),
+	}
+}
+
+// record counts a call if the method is deprecated
+func (t *deprecationTracker) record(method types.MethodInfo) {
+	if !method.Deprecated {
+		return
+	}
+
+	t.mu.Lock()
+	stats, seen := t.calls[method.ToolName]
+	if !seen {
+		stats = &deprecatedCallStats{}
+		t.calls[method.ToolName] = stats
+	}
+	now := time.Now()
+	stats.Calls++
+	stats.LastCalled = &now
+	t.mu.Unlock()
+
+	// Warn once per method; later calls only show up in the stats
+	if !seen {
+		t.logger.Warn("Deprecated method called",
+			zap.String("toolName", method.ToolName),
+			zap.String("method", method.FullName))
+	}
+}
+
+// stats reports every deprecated tool with its call counts, including unused ones
+func (t *deprecationTracker) stats(tools map[string]types.MethodInfo) map[string]deprecatedCallStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := make(map[string]deprecatedCallStats)
+	for toolName, method := range tools {
+		if !method.Deprecated {
+			continue
+		}
+		if stats, ok := t.calls[toolName]; ok {
+			report[toolName] = *stats
+		} else {
+			report[toolName] = deprecatedCallStats{}
+		}
+	}
+	return report
+}
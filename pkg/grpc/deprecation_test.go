@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestDeprecationTracker(t *testing.T) {
+	current := types.MethodInfo{ToolName: "shop_orderservice_create"}
+	deprecated := types.MethodInfo{ToolName: "shop_orderservice_place", Deprecated: true}
+	unused := types.MethodInfo{ToolName: "shop_orderservice_cancel", Deprecated: true}
+	tools := map[string]types.MethodInfo{
+		current.ToolName:    current,
+		deprecated.ToolName: deprecated,
+		unused.ToolName:     unused,
+	}
+
+	tracker := newDeprecationTracker(zap.NewNop())
+	tracker.record(current)
+	tracker.record(deprecated)
+	tracker.record(deprecated)
+
+	report := tracker.stats(tools)
+	assert.Len(t, report, 2)
+	assert.Equal(t, int64(2), report[deprecated.ToolName].Calls)
+	assert.NotNil(t, report[deprecated.ToolName].LastCalled)
+	assert.Nil(t, report[unused.ToolName].LastCalled)
+	assert.Equal(t, int64(0), report[unused.ToolName].Calls)
+	assert.NotContains(t, report, current.ToolName)
+}
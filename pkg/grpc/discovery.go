@@ -2,14 +2,18 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"time"
 
 	"github.com/aalobaidi/ggRMCP/pkg/config"
 	"github.com/aalobaidi/ggRMCP/pkg/descriptors"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
 	"github.com/aalobaidi/ggRMCP/pkg/types"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // serviceDiscoverer implements ServiceDiscoverer interface
@@ -20,10 +24,44 @@ type serviceDiscoverer struct {
 	reflectionClient ReflectionClient
 	tools            atomic.Pointer[map[string]types.MethodInfo]
 
+	// Dynamic messages of the methods in tools, replaced along with them
+	messages atomic.Pointer[messagePool]
+
+	// Inconsistencies found by the last discovery
+	issues atomic.Pointer[[]DiscoveryIssue]
+
+	// Backend message types, for Any fields in requests and responses
+	registry *typeRegistry
+
+	// Backend version and build metadata read by the last discovery
+	serverInfo       atomic.Pointer[ServerInfo]
+	serverInfoConfig config.ServerInfoConfig
+
 	// Method extraction components
 	descriptorLoader *descriptors.Loader
 	descriptorConfig config.DescriptorSetConfig
 
+	// Services to discover
+	scope serviceScope
+
+	// Exposure of services served in several package versions
+	versions versionResolver
+
+	// Per-method invocation limits
+	methodLimits *methodLimits
+
+	// Optional shadow traffic mirror
+	shadow *shadowMirror
+
+	// Optional canary router
+	canary *canaryRouter
+
+	// Calls to deprecated methods
+	deprecated *deprecationTracker
+
+	// Listeners notified when discovery changes the tools
+	changes changeListeners
+
 	// Configuration
 	reconnectInterval    time.Duration
 	maxReconnectAttempts int
@@ -31,16 +69,41 @@ type serviceDiscoverer struct {
 
 // NewServiceDiscoverer creates a new service discoverer with descriptor support
 func NewServiceDiscoverer(host string, port int, logger *zap.Logger, descriptorConfig config.DescriptorSetConfig) (ServiceDiscoverer, error) {
+	grpcConfig := config.Default().GRPC
+	grpcConfig.Host = host
+	grpcConfig.Port = port
+	grpcConfig.DescriptorSet = descriptorConfig
+
+	return NewServiceDiscovererWithConfig(grpcConfig, logger)
+}
+
+// NewServiceDiscovererWithConfig creates a new service discoverer from the full gRPC configuration
+func NewServiceDiscovererWithConfig(grpcConfig config.GRPCConfig, logger *zap.Logger) (ServiceDiscoverer, error) {
 	baseConfig := ConnectionManagerConfig{
-		Host:           host,
-		Port:           port,
-		ConnectTimeout: 5 * time.Second,
+		Host:           grpcConfig.Host,
+		Port:           grpcConfig.Port,
+		ConnectTimeout: grpcConfig.ConnectTimeout,
 		KeepAlive: KeepAliveConfig{
-			Time:                10 * time.Second,
-			Timeout:             5 * time.Second,
-			PermitWithoutStream: true,
+			Time:                grpcConfig.KeepAlive.Time,
+			Timeout:             grpcConfig.KeepAlive.Timeout,
+			PermitWithoutStream: grpcConfig.KeepAlive.PermitWithoutStream,
+		},
+		MaxMessageSize: grpcConfig.MaxMessageSize,
+		LogCalls:       grpcConfig.LogCalls,
+		Proxy: ProxyConfig{
+			URL:      grpcConfig.Proxy.URL,
+			Username: grpcConfig.Proxy.Username,
+			Password: grpcConfig.Proxy.Password,
+		},
+		Authority: grpcConfig.Authority,
+		UserAgent: grpcConfig.UserAgent,
+		TLS:       tlsConfigFrom(grpcConfig.TLS),
+		GoogleIDToken: GoogleIDTokenConfig{
+			Enabled:         grpcConfig.GoogleIDToken.Enabled,
+			Audience:        grpcConfig.GoogleIDToken.Audience,
+			CredentialsFile: grpcConfig.GoogleIDToken.CredentialsFile,
+			Header:          grpcConfig.GoogleIDToken.Header,
 		},
-		MaxMessageSize: 4 * 1024 * 1024, // 4MB
 	}
 
 	connManager := NewConnectionManager(baseConfig, logger)
@@ -49,9 +112,44 @@ func NewServiceDiscoverer(host string, port int, logger *zap.Logger, descriptorC
 		logger:               logger.Named("discovery"),
 		connManager:          connManager,
 		descriptorLoader:     descriptors.NewLoader(logger),
-		descriptorConfig:     descriptorConfig,
-		reconnectInterval:    5 * time.Second,
-		maxReconnectAttempts: 5,
+		descriptorConfig:     grpcConfig.DescriptorSet,
+		scope:                newServiceScope(grpcConfig.Discovery),
+		versions:             newVersionResolver(grpcConfig.Discovery.Versions),
+		serverInfoConfig:     grpcConfig.Discovery.ServerInfo,
+		registry:             &typeRegistry{},
+		methodLimits:         newMethodLimits(grpcConfig.MethodLimits),
+		deprecated:           newDeprecationTracker(logger),
+		reconnectInterval:    grpcConfig.Reconnect.Interval,
+		maxReconnectAttempts: grpcConfig.Reconnect.MaxAttempts,
+	}
+
+	if grpcConfig.Shadow.Enabled {
+		shadowConfig := baseConfig
+		if grpcConfig.Shadow.TLS != nil {
+			shadowConfig.TLS = tlsConfigFrom(*grpcConfig.Shadow.TLS)
+		}
+		if grpcConfig.Shadow.Authority != "" {
+			shadowConfig.Authority = grpcConfig.Shadow.Authority
+		}
+		if grpcConfig.Shadow.UserAgent != "" {
+			shadowConfig.UserAgent = grpcConfig.Shadow.UserAgent
+		}
+		d.shadow = newShadowMirror(grpcConfig.Shadow, shadowConfig, logger)
+		d.shadow.target.registry = d.registry
+	}
+	if grpcConfig.Canary.Enabled {
+		canaryConfig := baseConfig
+		if grpcConfig.Canary.TLS != nil {
+			canaryConfig.TLS = tlsConfigFrom(*grpcConfig.Canary.TLS)
+		}
+		if grpcConfig.Canary.Authority != "" {
+			canaryConfig.Authority = grpcConfig.Canary.Authority
+		}
+		if grpcConfig.Canary.UserAgent != "" {
+			canaryConfig.UserAgent = grpcConfig.Canary.UserAgent
+		}
+		d.canary = newCanaryRouter(grpcConfig.Canary, canaryConfig, logger)
+		d.canary.target.registry = d.registry
 	}
 
 	// Initialize with empty tools map
@@ -61,6 +159,23 @@ func NewServiceDiscoverer(host string, port int, logger *zap.Logger, descriptorC
 	return d, nil
 }
 
+// tlsConfigFrom converts application TLS settings to connection settings
+func tlsConfigFrom(tlsConfig config.TLSConfig) TLSConfig {
+	return TLSConfig{
+		Enabled:    tlsConfig.Enabled,
+		CAFile:     tlsConfig.CAFile,
+		CertFile:   tlsConfig.CertFile,
+		KeyFile:    tlsConfig.KeyFile,
+		ServerName: tlsConfig.ServerName,
+		SPIFFE: SPIFFEConfig{
+			Enabled:       tlsConfig.SPIFFE.Enabled,
+			SocketPath:    tlsConfig.SPIFFE.SocketPath,
+			AuthorizedIDs: tlsConfig.SPIFFE.AuthorizedIDs,
+			TrustDomain:   tlsConfig.SPIFFE.TrustDomain,
+		},
+	}
+}
+
 // Connect establishes connection to the gRPC server
 func (d *serviceDiscoverer) Connect(ctx context.Context) error {
 	d.logger.Info("Connecting to gRPC server via connection manager")
@@ -76,13 +191,25 @@ func (d *serviceDiscoverer) Connect(ctx context.Context) error {
 		return fmt.Errorf("connection manager returned nil connection")
 	}
 
-	d.reflectionClient = NewReflectionClient(conn, d.logger)
+	d.reflectionClient = newScopedReflectionClient(conn, d.logger, d.scope, d.registry)
 
 	// Verify connection with health check
 	if err := d.reflectionClient.HealthCheck(ctx); err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
+	// Shadow backend problems never block the primary connection
+	if d.shadow != nil {
+		if err := d.shadow.target.connect(ctx); err != nil {
+			d.logger.Warn("Shadow traffic disabled: failed to connect to shadow backend", zap.Error(err))
+		}
+	}
+	if d.canary != nil {
+		if err := d.canary.target.connect(ctx); err != nil {
+			d.logger.Warn("Canary routing disabled: failed to connect to canary backend", zap.Error(err))
+		}
+	}
+
 	d.logger.Info("Successfully connected to gRPC server")
 	return nil
 }
@@ -96,16 +223,28 @@ func (d *serviceDiscoverer) DiscoverServices(ctx context.Context) error {
 	d.logger.Info("Starting service discovery")
 
 	var methods []types.MethodInfo
+	var issues []DiscoveryIssue
 	var err error
 
 	// Try FileDescriptorSet first if enabled and available
 	if d.descriptorConfig.Enabled && d.descriptorConfig.Path != "" {
 		methods, err = d.discoverFromFileDescriptor()
+		if errors.Is(err, descriptors.ErrIntegrity) {
+			// A tampered descriptor set must stop discovery, not be replaced
+			// by reflection without anyone noticing
+			d.logger.Error("Descriptor set failed integrity verification", zap.Error(err))
+			return err
+		}
 		if err == nil {
 			d.logger.Info("Successfully discovered services from FileDescriptorSet")
+			issues = append(issues, d.crossCheckReflection(ctx, methods)...)
 		} else {
 			d.logger.Warn("Failed to discover from FileDescriptorSet, falling back to reflection",
 				zap.Error(err))
+			issues = append(issues, DiscoveryIssue{
+				Kind:   IssueDescriptorSetFallback,
+				Detail: fmt.Sprintf("descriptor set %s could not be used: %v", d.descriptorConfig.Path, err),
+			})
 			methods = nil
 		}
 	}
@@ -118,22 +257,80 @@ func (d *serviceDiscoverer) DiscoverServices(ctx context.Context) error {
 		}
 	}
 
+	// Label or collapse services served in several package versions
+	methods = d.versions.apply(methods)
+
+	issues = append(issues, findMethodIssues(methods)...)
+	for _, issue := range issues {
+		d.logger.Warn("Service discovery issue",
+			zap.String("kind", issue.Kind),
+			zap.String("method", issue.Method),
+			zap.String("detail", issue.Detail))
+	}
+	d.issues.Store(&issues)
+
 	// Set the discovered tools
 	tools := make(map[string]types.MethodInfo)
 	for _, method := range methods {
 		tools[method.ToolName] = method
 	}
-	d.tools.Store(&tools)
+	var previous map[string]types.MethodInfo
+	if before := d.tools.Swap(&tools); before != nil {
+		previous = *before
+	}
+	d.messages.Store(&messagePool{})
+	d.registry.update(methods)
+	d.changes.notify(methods, previous, tools)
+
+	d.serverInfo.Store(d.discoverServerInfo(ctx, methods))
 
 	return nil
 }
 
+// OnDiscoveryChange registers a listener called after a discovery that adds or removes tools
+func (d *serviceDiscoverer) OnDiscoveryChange(listener func(DiscoveryChange)) {
+	d.changes.add(listener)
+}
+
+// OnDiscovery registers a listener called with the methods of every discovery
+func (d *serviceDiscoverer) OnDiscovery(listener func([]types.MethodInfo)) {
+	d.changes.addDiscovery(listener)
+}
+
+// crossCheckReflection compares the descriptor set with the backend's
+// reflection if configured; a backend without reflection is not an issue
+func (d *serviceDiscoverer) crossCheckReflection(ctx context.Context, fromSet []types.MethodInfo) []DiscoveryIssue {
+	if !d.descriptorConfig.CrossCheck {
+		return nil
+	}
+
+	fromReflection, err := d.reflectionClient.DiscoverMethods(ctx)
+	if err != nil {
+		d.logger.Warn("Skipping descriptor set cross-check: reflection unavailable", zap.Error(err))
+		return nil
+	}
+	return compareDescriptorSources(fromSet, d.scope.filterMethods(fromReflection))
+}
+
+// DiscoveryIssues returns the inconsistencies found by the last discovery
+func (d *serviceDiscoverer) DiscoveryIssues() []DiscoveryIssue {
+	issues := d.issues.Load()
+	if issues == nil {
+		return nil
+	}
+	return *issues
+}
+
 // discoverFromFileDescriptor discovers services from FileDescriptorSet
 func (d *serviceDiscoverer) discoverFromFileDescriptor() ([]types.MethodInfo, error) {
 	d.logger.Info("Discovering services from FileDescriptorSet", zap.String("path", d.descriptorConfig.Path))
 
 	// Load FileDescriptorSet
-	fdSet, err := d.descriptorLoader.LoadFromFile(d.descriptorConfig.Path)
+	fdSet, err := d.descriptorLoader.LoadVerifiedFromFile(d.descriptorConfig.Path, descriptors.Integrity{
+		SHA256:    d.descriptorConfig.Verify.SHA256,
+		Signature: d.descriptorConfig.Verify.Signature,
+		PublicKey: d.descriptorConfig.Verify.PublicKey,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to load descriptor set: %w", err)
 	}
@@ -149,6 +346,7 @@ func (d *serviceDiscoverer) discoverFromFileDescriptor() ([]types.MethodInfo, er
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract method info: %w", err)
 	}
+	methods = d.scope.filterMethods(methods)
 
 	d.logger.Info("FileDescriptorSet discovery completed", zap.Int("methodCount", len(methods)))
 	return methods, nil
@@ -216,7 +414,7 @@ func (d *serviceDiscoverer) Reconnect(ctx context.Context) error {
 			lastErr = fmt.Errorf("connection manager returned nil connection after reconnect")
 			continue
 		}
-		d.reflectionClient = NewReflectionClient(conn, d.logger)
+		d.reflectionClient = newScopedReflectionClient(conn, d.logger, d.scope, d.registry)
 
 		// Rediscover services after reconnection
 		if err := d.DiscoverServices(ctx); err != nil {
@@ -267,9 +465,21 @@ func (d *serviceDiscoverer) Close() error {
 		d.logger.Error("Failed to close connection manager", zap.Error(err))
 	}
 
+	if d.shadow != nil {
+		if err := d.shadow.close(); err != nil {
+			d.logger.Error("Failed to close shadow backend connection", zap.Error(err))
+		}
+	}
+	if d.canary != nil {
+		if err := d.canary.target.close(); err != nil {
+			d.logger.Error("Failed to close canary backend connection", zap.Error(err))
+		}
+	}
+
 	// Reset tools to empty map
 	emptyMap := make(map[string]types.MethodInfo)
 	d.tools.Store(&emptyMap)
+	d.messages.Store(nil)
 
 	d.logger.Info("Service discoverer closed")
 	return nil
@@ -327,13 +537,21 @@ func (d *serviceDiscoverer) GetServiceStats() map[string]interface{} {
 		"methodCount":  len(*tools),
 		"isConnected":  d.isConnected(),
 		"services":     serviceList,
+		"deprecated":   d.deprecated.stats(*tools),
+	}
+
+	if d.shadow != nil {
+		stats["shadow"] = d.shadow.stats()
+	}
+	if d.canary != nil {
+		stats["canary"] = d.canary.stats()
 	}
 
 	return stats
 }
 
-// getMethodByTool returns information about a method by its tool name (private helper)
-func (d *serviceDiscoverer) getMethodByTool(toolName string) (types.MethodInfo, bool) {
+// GetMethodByTool returns information about a method by its tool name
+func (d *serviceDiscoverer) GetMethodByTool(toolName string) (types.MethodInfo, bool) {
 	tools := d.tools.Load()
 	if tools == nil {
 		return types.MethodInfo{}, false
@@ -345,7 +563,7 @@ func (d *serviceDiscoverer) getMethodByTool(toolName string) (types.MethodInfo,
 // InvokeMethodByTool invokes a gRPC method by tool name with optional headers
 func (d *serviceDiscoverer) InvokeMethodByTool(ctx context.Context, headers map[string]string, toolName string, inputJSON string) (string, error) {
 	// Get method info by tool name
-	method, exists := d.getMethodByTool(toolName)
+	method, exists := d.GetMethodByTool(toolName)
 	if !exists {
 		return "", fmt.Errorf("tool %s not found", toolName)
 	}
@@ -359,19 +577,70 @@ func (d *serviceDiscoverer) InvokeMethodByTool(ctx context.Context, headers map[
 		return "", fmt.Errorf("not connected to gRPC server")
 	}
 
-	d.logger.Debug("Invoking gRPC method by tool",
-		zap.String("toolName", toolName),
+	d.deprecated.record(method)
+
+	result, err := d.invokeMethod(ctx, headers, method, inputJSON)
+
+	// Collapsed multi-version tools fall back to other versions the backend may still serve
+	for _, fallback := range method.Fallbacks {
+		if status.Code(err) != codes.Unimplemented {
+			break
+		}
+		logging.FromContext(ctx, d.logger).Info("Method unimplemented, falling back to another version",
+			zap.String("method", method.FullName),
+			zap.String("fallback", fallback.FullName))
+		result, err = d.invokeMethod(ctx, headers, fallback, inputJSON)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to invoke method: %w", err)
+	}
+
+	return result, nil
+}
+
+// invokeMethod invokes one method, applying limits, canary routing and shadowing
+func (d *serviceDiscoverer) invokeMethod(ctx context.Context, headers map[string]string, method types.MethodInfo, inputJSON string) (string, error) {
+	logging.FromContext(ctx, d.logger).Debug("Invoking gRPC method by tool",
 		zap.String("service", method.FullName),
 		zap.Int("headerCount", len(headers)),
 		zap.String("input", inputJSON))
 
-	// Invoke the method through the reflection client
-	result, err := d.reflectionClient.InvokeMethod(ctx, headers, method, inputJSON)
+	// Enforce per-method concurrency and QPS limits
+	release, err := d.methodLimits.acquire(ctx, method)
 	if err != nil {
-		return "", fmt.Errorf("failed to invoke method: %w", err)
+		return "", err
 	}
+	defer release()
 
-	return result, nil
+	// Route to the canary backend when selected, otherwise to the primary
+	client := d.reflectionClient
+	routedToCanary := false
+	if d.canary != nil {
+		if canaryClient := d.canary.route(method); canaryClient != nil {
+			client = canaryClient
+			routedToCanary = true
+		}
+	}
+
+	// Invoke the method through the reflection client
+	result, err := client.InvokeMethod(withMessagePool(ctx, d.messages.Load()), headers, method, inputJSON)
+	if d.canary != nil {
+		d.canary.record(routedToCanary, err)
+	}
+
+	// Mirror the call to the shadow backend (fire-and-forget) if its tool is listed
+	if d.shadow != nil && d.shadow.mirrors(method.ToolName) {
+		d.shadow.mirror(headers, method, inputJSON, result, err)
+	}
+
+	return result, err
+}
+
+// NewServiceDiscovererWithConnManager creates a service discoverer over a caller-provided
+// connection manager (e.g. an in-memory bufconn connection in benchmarks)
+func NewServiceDiscovererWithConnManager(connManager ConnectionManager, logger *zap.Logger) ServiceDiscoverer {
+	return newServiceDiscovererWithConnManager(connManager, logger)
 }
 
 // newServiceDiscovererWithConnManager creates a service discoverer with a custom connection manager (for testing)
@@ -381,6 +650,9 @@ func newServiceDiscovererWithConnManager(connManager ConnectionManager, logger *
 		connManager:          connManager,
 		descriptorLoader:     descriptors.NewLoader(logger),
 		descriptorConfig:     config.DescriptorSetConfig{},
+		methodLimits:         newMethodLimits(nil),
+		deprecated:           newDeprecationTracker(logger),
+		registry:             &typeRegistry{},
 		reconnectInterval:    5 * time.Second,
 		maxReconnectAttempts: 5,
 	}
@@ -324,7 +324,7 @@ func TestMethodInvocationByToolName(t *testing.T) {
 	require.NotNil(t, helloMethod, "Should find SayHello method")
 
 	// Verify the method can be looked up by tool name
-	foundMethod, exists := unifiedDiscoverer.getMethodByTool(helloMethod.ToolName)
+	foundMethod, exists := unifiedDiscoverer.GetMethodByTool(helloMethod.ToolName)
 	require.True(t, exists, "Should find method by tool name")
 	assert.Equal(t, helloMethod.FullName, foundMethod.FullName)
 	assert.Equal(t, helloMethod.ServiceName, foundMethod.ServiceName)
@@ -138,7 +138,7 @@ func TestMethodInfoPropagation_EndToEnd(t *testing.T) {
 
 	// Get a specific method to test description propagation
 	toolName := "hello_helloservice_sayhello" // Generated tool name for hello.HelloService.SayHello
-	method, found := unifiedDiscoverer.getMethodByTool(toolName)
+	method, found := unifiedDiscoverer.GetMethodByTool(toolName)
 	require.True(t, found, "Should find SayHello method by tool name")
 
 	t.Logf("MethodInfo.Description: '%s'", method.Description)
@@ -0,0 +1,284 @@
+package grpc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMetadataHost is the GCE/Cloud Run metadata server
+	defaultMetadataHost = "metadata.google.internal"
+
+	// defaultTokenURI is Google's OAuth 2.0 token endpoint
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+
+	// idTokenRefreshMargin refreshes tokens this long before they expire
+	idTokenRefreshMargin = 5 * time.Minute
+)
+
+// googleIDTokenCredentials attaches Google-signed ID tokens to gRPC calls,
+// fetched from the metadata server or minted with a service account key
+type googleIDTokenCredentials struct {
+	header string
+	fetch  func(ctx context.Context) (string, error)
+
+	// now returns the current time (replaceable for tests)
+	now func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// serviceAccountKey is the subset of a service account key file used to mint ID tokens
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// newGoogleIDTokenCredentials creates ID token credentials for the configured audience
+func newGoogleIDTokenCredentials(config GoogleIDTokenConfig) (*googleIDTokenCredentials, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	creds := &googleIDTokenCredentials{
+		header: config.Header,
+		now:    time.Now,
+	}
+	if creds.header == "" {
+		creds.header = "authorization"
+	}
+
+	if config.CredentialsFile == "" {
+		metadataHost := os.Getenv("GCE_METADATA_HOST")
+		if metadataHost == "" {
+			metadataHost = defaultMetadataHost
+		}
+		creds.fetch = func(ctx context.Context) (string, error) {
+			return fetchMetadataIDToken(ctx, client, metadataHost, config.Audience)
+		}
+		return creds, nil
+	}
+
+	key, signer, err := loadServiceAccountKey(config.CredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	creds.fetch = func(ctx context.Context) (string, error) {
+		return exchangeServiceAccountIDToken(ctx, client, key, signer, config.Audience, creds.now())
+	}
+	return creds, nil
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (c *googleIDTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.idToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{c.header: "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (c *googleIDTokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// idToken returns a cached token, fetching a new one when it is close to expiry
+func (c *googleIDTokenCredentials) idToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Add(idTokenRefreshMargin).Before(c.expiry) {
+		return c.token, nil
+	}
+
+	token, err := c.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Google ID token: %w", err)
+	}
+
+	expiry, err := jwtExpiry(token)
+	if err != nil {
+		return "", fmt.Errorf("invalid Google ID token: %w", err)
+	}
+
+	c.token = token
+	c.expiry = expiry
+	return token, nil
+}
+
+// fetchMetadataIDToken requests an ID token for the instance's service account
+func fetchMetadataIDToken(ctx context.Context, client *http.Client, metadataHost, audience string) (string, error) {
+	endpoint := fmt.Sprintf("http://%s/computeMetadata/v1/instance/service-accounts/default/identity?audience=%s&format=full",
+		metadataHost, url.QueryEscape(audience))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create metadata request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata server request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read metadata response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// loadServiceAccountKey reads a service account key file and parses its private key
+func loadServiceAccountKey(path string) (*serviceAccountKey, *rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var key serviceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if key.Type != "service_account" {
+		return nil, nil, fmt.Errorf("credentials file must be a service account key, got type %q", key.Type)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = defaultTokenURI
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, nil, fmt.Errorf("service account private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	signer, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("service account private key must be RSA")
+	}
+
+	return &key, signer, nil
+}
+
+// exchangeServiceAccountIDToken signs a JWT assertion with the service account key
+// and exchanges it for an ID token at the token endpoint
+func exchangeServiceAccountIDToken(ctx context.Context, client *http.Client, key *serviceAccountKey, signer *rsa.PrivateKey, audience string, now time.Time) (string, error) {
+	assertion, err := signJWT(signer, key.PrivateKeyID, map[string]interface{}{
+		"iss":             key.ClientEmail,
+		"sub":             key.ClientEmail,
+		"aud":             key.TokenURI,
+		"target_audience": audience,
+		"iat":             now.Unix(),
+		"exp":             now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if result.IDToken == "" {
+		return "", fmt.Errorf("token response did not include an ID token")
+	}
+
+	return result.IDToken, nil
+}
+
+// signJWT creates an RS256-signed JWT with the given claims
+func signJWT(signer *rsa.PrivateKey, keyID string, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWT header: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWT claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtExpiry reads the exp claim of a JWT without verifying it
+func jwtExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode JWT payload: %w", err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse JWT claims: %w", err)
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, fmt.Errorf("JWT has no exp claim")
+	}
+
+	return time.Unix(claims.Exp, 0), nil
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsignedJWT builds a JWT with the given expiry for tests that don't verify signatures
+func unsignedJWT(exp time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return header + "." + payload + ".sig"
+}
+
+func TestGoogleIDTokenCredentials_MetadataServer(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		assert.Equal(t, "/computeMetadata/v1/instance/service-accounts/default/identity", r.URL.Path)
+		assert.Equal(t, "https://backend.example.com", r.URL.Query().Get("audience"))
+		_, _ = w.Write([]byte(unsignedJWT(time.Now().Add(time.Hour))))
+	}))
+	defer server.Close()
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	creds, err := newGoogleIDTokenCredentials(GoogleIDTokenConfig{
+		Enabled:  true,
+		Audience: "https://backend.example.com",
+		Header:   "x-serverless-authorization",
+	})
+	require.NoError(t, err)
+	assert.True(t, creds.RequireTransportSecurity())
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(md["x-serverless-authorization"], "Bearer "))
+
+	// Cached until close to expiry
+	_, err = creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), requests.Load())
+
+	creds.now = func() time.Time { return time.Now().Add(56 * time.Minute) }
+	_, err = creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), requests.Load())
+}
+
+func TestGoogleIDTokenCredentials_ServiceAccountKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idToken := unsignedJWT(time.Now().Add(time.Hour))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+
+		// Verify the assertion was signed by the service account key
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.NoError(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, digest[:], signature))
+
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "gateway@project.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, "https://backend.example.com", claims["target_audience"])
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	}))
+	defer server.Close()
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	keyFile, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "gateway@project.iam.gserviceaccount.com",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+		"token_uri":      server.URL,
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(path, keyFile, 0o600))
+
+	creds, err := newGoogleIDTokenCredentials(GoogleIDTokenConfig{
+		Enabled:         true,
+		Audience:        "https://backend.example.com",
+		CredentialsFile: path,
+	})
+	require.NoError(t, err)
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer "+idToken, md["authorization"])
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Unix(1700000000, 0)
+	parsed, err := jwtExpiry(unsignedJWT(exp))
+	require.NoError(t, err)
+	assert.Equal(t, exp, parsed)
+
+	_, err = jwtExpiry("not-a-jwt")
+	assert.Error(t, err)
+}
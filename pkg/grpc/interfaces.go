@@ -40,9 +40,15 @@ type ServiceDiscoverer interface {
 	// GetMethods returns all discovered methods in a flat list
 	GetMethods() []types.MethodInfo
 
+	// GetMethodByTool returns the method behind a tool name
+	GetMethodByTool(toolName string) (types.MethodInfo, bool)
+
 	// InvokeMethodByTool invokes a gRPC method by tool name with optional headers
 	InvokeMethodByTool(ctx context.Context, headers map[string]string, toolName string, inputJSON string) (string, error)
 
+	// GetOperation polls a long-running operation and returns it as JSON
+	GetOperation(ctx context.Context, headers map[string]string, name string) (string, error)
+
 	// HealthCheck performs a health check
 	HealthCheck(ctx context.Context) error
 
@@ -54,6 +60,21 @@ type ServiceDiscoverer interface {
 
 	// GetServiceStats returns statistics about discovered services
 	GetServiceStats() map[string]interface{}
+
+	// DiscoveryIssues returns the inconsistencies found by the last discovery
+	DiscoveryIssues() []DiscoveryIssue
+
+	// ServerInfo returns the backend version and build metadata read by the last discovery, or nil
+	ServerInfo() *ServerInfo
+
+	// Channelz reports the gateway's gRPC connections and, if queryBackend is set, the backend's view of them
+	Channelz(ctx context.Context, queryBackend bool) (*ChannelzReport, error)
+
+	// OnDiscoveryChange registers a listener called after a discovery that adds or removes tools
+	OnDiscoveryChange(listener func(DiscoveryChange))
+
+	// OnDiscovery registers a listener called with the methods of every discovery
+	OnDiscovery(listener func([]types.MethodInfo))
 }
 
 // ReflectionClient handles gRPC reflection API
@@ -73,11 +94,50 @@ type ReflectionClient interface {
 
 // ConnectionManagerConfig contains configuration for connection management
 type ConnectionManagerConfig struct {
-	Host           string          `json:"host"`
-	Port           int             `json:"port"`
-	ConnectTimeout time.Duration   `json:"connect_timeout"`
-	KeepAlive      KeepAliveConfig `json:"keep_alive"`
-	MaxMessageSize int             `json:"max_message_size"`
+	Host           string              `json:"host"`
+	Port           int                 `json:"port"`
+	ConnectTimeout time.Duration       `json:"connect_timeout"`
+	KeepAlive      KeepAliveConfig     `json:"keep_alive"`
+	MaxMessageSize int                 `json:"max_message_size"`
+	LogCalls       bool                `json:"log_calls"`
+	Proxy          ProxyConfig         `json:"proxy"`
+	Authority      string              `json:"authority"`
+	UserAgent      string              `json:"user_agent"`
+	TLS            TLSConfig           `json:"tls"`
+	GoogleIDToken  GoogleIDTokenConfig `json:"google_id_token"`
+}
+
+// GoogleIDTokenConfig contains Google ID token settings for gRPC connections
+type GoogleIDTokenConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Audience        string `json:"audience"`
+	CredentialsFile string `json:"credentials_file"`
+	Header          string `json:"header"`
+}
+
+// TLSConfig contains transport security settings for gRPC connections
+type TLSConfig struct {
+	Enabled    bool         `json:"enabled"`
+	CAFile     string       `json:"ca_file"`
+	CertFile   string       `json:"cert_file"`
+	KeyFile    string       `json:"key_file"`
+	ServerName string       `json:"server_name"`
+	SPIFFE     SPIFFEConfig `json:"spiffe"`
+}
+
+// SPIFFEConfig contains SPIFFE Workload API settings for gRPC connections
+type SPIFFEConfig struct {
+	Enabled       bool     `json:"enabled"`
+	SocketPath    string   `json:"socket_path"`
+	AuthorizedIDs []string `json:"authorized_ids"`
+	TrustDomain   string   `json:"trust_domain"`
+}
+
+// ProxyConfig contains forward proxy settings for gRPC connections
+type ProxyConfig struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"-"`
 }
 
 // KeepAliveConfig contains keep-alive settings for gRPC connections
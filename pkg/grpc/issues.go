@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+// Kinds of discovery issues
+const (
+	IssueDescriptorSetFallback = "descriptor_set_fallback"
+	IssueDuplicateTool         = "duplicate_tool"
+	IssueMissingDescriptor     = "missing_descriptor"
+	IssueDescriptorMismatch    = "descriptor_mismatch"
+)
+
+// DiscoveryIssue describes an inconsistency found during service discovery.
+// Discovery carries on past these; strict mode turns them into startup errors.
+type DiscoveryIssue struct {
+	Kind   string `json:"kind"`
+	Method string `json:"method,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// String formats the issue as a single report line
+func (i DiscoveryIssue) String() string {
+	if i.Method == "" {
+		return fmt.Sprintf("%s: %s", i.Kind, i.Detail)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Kind, i.Method, i.Detail)
+}
+
+// findMethodIssues reports tool names shared by several methods, of which
+// only one is reachable, and methods discovered without descriptors
+func findMethodIssues(methods []types.MethodInfo) []DiscoveryIssue {
+	var issues []DiscoveryIssue
+
+	byTool := make(map[string][]string)
+	for _, method := range methods {
+		byTool[method.ToolName] = append(byTool[method.ToolName], method.FullName)
+
+		var missing []string
+		if method.InputDescriptor == nil {
+			missing = append(missing, "input")
+		}
+		if method.OutputDescriptor == nil {
+			missing = append(missing, "output")
+		}
+		if len(missing) > 0 {
+			issues = append(issues, DiscoveryIssue{
+				Kind:   IssueMissingDescriptor,
+				Method: method.FullName,
+				Detail: fmt.Sprintf("no %s message descriptor", strings.Join(missing, " or ")),
+			})
+		}
+	}
+
+	toolNames := make([]string, 0, len(byTool))
+	for toolName, fullNames := range byTool {
+		if len(fullNames) > 1 {
+			toolNames = append(toolNames, toolName)
+		}
+	}
+	sort.Strings(toolNames)
+	for _, toolName := range toolNames {
+		issues = append(issues, DiscoveryIssue{
+			Kind:   IssueDuplicateTool,
+			Method: strings.Join(byTool[toolName], ", "),
+			Detail: fmt.Sprintf("methods share the tool name %s; only one is exposed", toolName),
+		})
+	}
+
+	return issues
+}
+
+// compareDescriptorSources reports methods the descriptor set and the
+// server's reflection disagree on
+func compareDescriptorSources(fromSet, fromReflection []types.MethodInfo) []DiscoveryIssue {
+	reflected := make(map[string]types.MethodInfo, len(fromReflection))
+	for _, method := range fromReflection {
+		reflected[method.FullName] = method
+	}
+
+	var issues []DiscoveryIssue
+	seen := make(map[string]bool, len(fromSet))
+	for _, method := range fromSet {
+		seen[method.FullName] = true
+
+		served, ok := reflected[method.FullName]
+		if !ok {
+			issues = append(issues, DiscoveryIssue{
+				Kind:   IssueDescriptorMismatch,
+				Method: method.FullName,
+				Detail: "in the descriptor set but not served by the backend",
+			})
+			continue
+		}
+
+		if in, servedIn := messageName(method, true), messageName(served, true); in != servedIn {
+			issues = append(issues, DiscoveryIssue{
+				Kind:   IssueDescriptorMismatch,
+				Method: method.FullName,
+				Detail: fmt.Sprintf("input type is %s in the descriptor set but %s on the backend", in, servedIn),
+			})
+		}
+		if out, servedOut := messageName(method, false), messageName(served, false); out != servedOut {
+			issues = append(issues, DiscoveryIssue{
+				Kind:   IssueDescriptorMismatch,
+				Method: method.FullName,
+				Detail: fmt.Sprintf("output type is %s in the descriptor set but %s on the backend", out, servedOut),
+			})
+		}
+		if method.IsClientStreaming != served.IsClientStreaming || method.IsServerStreaming != served.IsServerStreaming {
+			issues = append(issues, DiscoveryIssue{
+				Kind:   IssueDescriptorMismatch,
+				Method: method.FullName,
+				Detail: "streaming mode differs between the descriptor set and the backend",
+			})
+		}
+	}
+
+	var missing []string
+	for fullName := range reflected {
+		if !seen[fullName] {
+			missing = append(missing, fullName)
+		}
+	}
+	sort.Strings(missing)
+	for _, fullName := range missing {
+		issues = append(issues, DiscoveryIssue{
+			Kind:   IssueDescriptorMismatch,
+			Method: fullName,
+			Detail: "served by the backend but missing from the descriptor set",
+		})
+	}
+
+	return issues
+}
+
+// messageName returns the full name of a method's input or output message
+func messageName(method types.MethodInfo, input bool) string {
+	if input {
+		if method.InputDescriptor != nil {
+			return string(method.InputDescriptor.FullName())
+		}
+		return strings.TrimPrefix(method.InputType, ".")
+	}
+	if method.OutputDescriptor != nil {
+		return string(method.OutputDescriptor.FullName())
+	}
+	return strings.TrimPrefix(method.OutputType, ".")
+}
@@ -0,0 +1,142 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/descriptors"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFindMethodIssues(t *testing.T) {
+	methods := []types.MethodInfo{
+		{FullName: "shop.v1.Orders.Place", ToolName: "shop_orders_place", InputType: ".shop.v1.PlaceRequest"},
+		{FullName: "shop.v2.Orders.Place", ToolName: "shop_orders_place"},
+	}
+
+	issues := findMethodIssues(methods)
+	require.Len(t, issues, 3)
+	assert.Equal(t, DiscoveryIssue{
+		Kind:   IssueMissingDescriptor,
+		Method: "shop.v1.Orders.Place",
+		Detail: "no input or output message descriptor",
+	}, issues[0])
+	assert.Equal(t, IssueDuplicateTool, issues[2].Kind)
+	assert.Equal(t, "shop.v1.Orders.Place, shop.v2.Orders.Place", issues[2].Method)
+	assert.Equal(t, "duplicate_tool: shop.v1.Orders.Place, shop.v2.Orders.Place: methods share the tool name shop_orders_place; only one is exposed", issues[2].String())
+}
+
+func TestCompareDescriptorSources(t *testing.T) {
+	fromSet := []types.MethodInfo{
+		{FullName: "shop.Orders.Place", InputType: ".shop.PlaceRequest", OutputType: ".shop.Order"},
+		{FullName: "shop.Orders.Cancel", InputType: ".shop.CancelRequest", OutputType: ".shop.Order"},
+		{FullName: "shop.Orders.Retired", InputType: ".shop.RetiredRequest", OutputType: ".shop.Order"},
+	}
+	fromReflection := []types.MethodInfo{
+		{FullName: "shop.Orders.Place", InputType: "shop.PlaceRequest", OutputType: "shop.Order"},
+		{FullName: "shop.Orders.Cancel", InputType: "shop.CancelOrderRequest", OutputType: "shop.Order", IsServerStreaming: true},
+		{FullName: "shop.Orders.Track", InputType: "shop.TrackRequest", OutputType: "shop.Tracking"},
+	}
+
+	var details []string
+	for _, issue := range compareDescriptorSources(fromSet, fromReflection) {
+		assert.Equal(t, IssueDescriptorMismatch, issue.Kind)
+		details = append(details, issue.Method+": "+issue.Detail)
+	}
+	assert.Equal(t, []string{
+		"shop.Orders.Cancel: input type is shop.CancelRequest in the descriptor set but shop.CancelOrderRequest on the backend",
+		"shop.Orders.Cancel: streaming mode differs between the descriptor set and the backend",
+		"shop.Orders.Retired: in the descriptor set but not served by the backend",
+		"shop.Orders.Track: served by the backend but missing from the descriptor set",
+	}, details)
+}
+
+func TestServiceDiscoverer_DiscoveryIssues(t *testing.T) {
+	logger := zap.NewNop()
+	discoverer := newServiceDiscovererWithConnManager(&mockConnectionManager{}, logger)
+	discoverer.descriptorConfig = config.DescriptorSetConfig{Enabled: true, Path: "/nonexistent/descriptor.binpb", CrossCheck: true}
+
+	reflectionClient := &mockReflectionClient{}
+	reflectionClient.On("DiscoverMethods", mock.Anything).Return([]types.MethodInfo{
+		{FullName: "shop.Orders.Place", ToolName: "shop_orders_place"},
+	}, nil)
+	discoverer.reflectionClient = reflectionClient
+
+	require.NoError(t, discoverer.DiscoverServices(context.Background()))
+
+	issues := discoverer.DiscoveryIssues()
+	require.Len(t, issues, 2)
+	assert.Equal(t, IssueDescriptorSetFallback, issues[0].Kind)
+	assert.Contains(t, issues[0].Detail, "/nonexistent/descriptor.binpb")
+	assert.Equal(t, IssueMissingDescriptor, issues[1].Kind)
+}
+
+func TestServiceDiscoverer_DescriptorIntegrity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.binpb")
+	require.NoError(t, os.WriteFile(path, []byte("tampered"), 0o600))
+
+	logger := zap.NewNop()
+	discoverer := newServiceDiscovererWithConnManager(&mockConnectionManager{}, logger)
+	discoverer.descriptorConfig = config.DescriptorSetConfig{
+		Enabled: true,
+		Path:    path,
+		Verify:  config.DescriptorVerifyConfig{SHA256: strings.Repeat("0", 64)},
+	}
+	reflectionClient := &mockReflectionClient{}
+	discoverer.reflectionClient = reflectionClient
+
+	// No fallback to reflection: the mock would fail on an unexpected call
+	err := discoverer.DiscoverServices(context.Background())
+	require.ErrorIs(t, err, descriptors.ErrIntegrity)
+	assert.Empty(t, discoverer.GetMethods())
+	reflectionClient.AssertNotCalled(t, "DiscoverMethods", mock.Anything)
+}
+
+func TestDiffTools(t *testing.T) {
+	previous := map[string]types.MethodInfo{"shop_orders_place": {}, "shop_orders_retired": {}}
+	current := map[string]types.MethodInfo{"shop_orders_place": {}, "shop_orders_cancel": {}, "shop_orders_get": {}}
+
+	change, changed := diffTools(previous, current)
+	assert.True(t, changed)
+	assert.Equal(t, DiscoveryChange{
+		Added:     []string{"shop_orders_cancel", "shop_orders_get"},
+		Removed:   []string{"shop_orders_retired"},
+		ToolCount: 3,
+	}, change)
+
+	_, changed = diffTools(current, current)
+	assert.False(t, changed)
+
+	// The first discovery adds every tool
+	change, changed = diffTools(nil, current)
+	assert.True(t, changed)
+	assert.Len(t, change.Added, 3)
+}
+
+func TestChangeListeners(t *testing.T) {
+	var listeners changeListeners
+	var changes []DiscoveryChange
+	listeners.add(func(change DiscoveryChange) { changes = append(changes, change) })
+	discoveries := 0
+	listeners.addDiscovery(func([]types.MethodInfo) { discoveries++ })
+
+	tools := map[string]types.MethodInfo{"shop_orders_place": {}}
+	listeners.notify(nil, nil, tools)
+	listeners.notify(nil, tools, tools)
+	listeners.notify(nil, tools, nil)
+
+	// Every discovery is reported, changed or not
+	assert.Equal(t, 3, discoveries)
+	require.Len(t, changes, 2)
+	assert.Equal(t, []string{"shop_orders_place"}, changes[0].Added)
+	assert.Equal(t, []string{"shop_orders_place"}, changes[1].Removed)
+	assert.Equal(t, 0, changes[1].ToolCount)
+}
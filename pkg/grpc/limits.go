@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"golang.org/x/time/rate"
+)
+
+// methodLimiter enforces the limits configured for a single method key
+type methodLimiter struct {
+	sem     chan struct{}
+	limiter *rate.Limiter
+}
+
+// methodLimits enforces per-method concurrency and QPS limits
+type methodLimits struct {
+	config map[string]config.MethodLimitConfig
+
+	mu       sync.Mutex
+	limiters map[string]*methodLimiter // keyed by config key
+	resolved map[string]string         // method full name -> config key ("" if unlimited)
+}
+
+// newMethodLimits creates per-method limits from configuration
+func newMethodLimits(limits map[string]config.MethodLimitConfig) *methodLimits {
+	return &methodLimits{
+		config:   limits,
+		limiters: make(map[string]*methodLimiter),
+		resolved: make(map[string]string),
+	}
+}
+
+// acquire waits until the method may be invoked and returns a release function
+func (l *methodLimits) acquire(ctx context.Context, method types.MethodInfo) (func(), error) {
+	limiter := l.limiterFor(method)
+	if limiter == nil {
+		return func() {}, nil
+	}
+
+	if limiter.limiter != nil {
+		if err := limiter.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limit exceeded for method %s: %w", method.FullName, err)
+		}
+	}
+
+	if limiter.sem != nil {
+		select {
+		case limiter.sem <- struct{}{}:
+			return func() { <-limiter.sem }, nil
+		case <-ctx.Done():
+			return nil, fmt.Errorf("concurrency limit exceeded for method %s: %w", method.FullName, ctx.Err())
+		}
+	}
+
+	return func() {}, nil
+}
+
+// limiterFor returns the limiter for a method, or nil if the method is unlimited
+func (l *methodLimits) limiterFor(method types.MethodInfo) *methodLimiter {
+	if len(l.config) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key, ok := l.resolved[method.FullName]
+	if !ok {
+		key = l.matchKey(method)
+		l.resolved[method.FullName] = key
+	}
+	if key == "" {
+		return nil
+	}
+
+	if limiter, exists := l.limiters[key]; exists {
+		return limiter
+	}
+
+	limit := l.config[key]
+	limiter := &methodLimiter{}
+	if limit.MaxConcurrent > 0 {
+		limiter.sem = make(chan struct{}, limit.MaxConcurrent)
+	}
+	if limit.QPS > 0 {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter.limiter = rate.NewLimiter(rate.Limit(limit.QPS), burst)
+	}
+	l.limiters[key] = limiter
+
+	return limiter
+}
+
+// matchKey finds the config key matching a method, preferring exact matches
+func (l *methodLimits) matchKey(method types.MethodInfo) string {
+	for _, candidate := range []string{method.FullName, method.ToolName} {
+		if _, exists := l.config[candidate]; exists {
+			return candidate
+		}
+	}
+
+	// Allow keys without the package prefix (e.g. "HelloService.SayHello")
+	best := ""
+	for key := range l.config {
+		if strings.HasSuffix(method.FullName, "."+key) && len(key) > len(best) {
+			best = key
+		}
+	}
+
+	return best
+}
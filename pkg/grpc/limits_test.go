@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodLimits_MatchKey(t *testing.T) {
+	method := types.MethodInfo{
+		FullName: "reports.ReportService.Generate",
+		ToolName: "reports_reportservice_generate",
+	}
+
+	tests := []struct {
+		name        string
+		keys        []string
+		expectedKey string
+	}{
+		{"Full_name", []string{"reports.ReportService.Generate"}, "reports.ReportService.Generate"},
+		{"Tool_name", []string{"reports_reportservice_generate"}, "reports_reportservice_generate"},
+		{"Without_package", []string{"ReportService.Generate"}, "ReportService.Generate"},
+		{"Longest_suffix_wins", []string{"Generate", "ReportService.Generate"}, "ReportService.Generate"},
+		{"Partial_name_does_not_match", []string{"Service.Generate"}, ""},
+		{"No_match", []string{"OtherService.Generate"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limitConfig := make(map[string]config.MethodLimitConfig)
+			for _, key := range tt.keys {
+				limitConfig[key] = config.MethodLimitConfig{MaxConcurrent: 1}
+			}
+
+			limits := newMethodLimits(limitConfig)
+			assert.Equal(t, tt.expectedKey, limits.matchKey(method))
+		})
+	}
+}
+
+func TestMethodLimits_MaxConcurrent(t *testing.T) {
+	limits := newMethodLimits(map[string]config.MethodLimitConfig{
+		"ReportService.Generate": {MaxConcurrent: 1},
+	})
+	method := types.MethodInfo{FullName: "reports.ReportService.Generate"}
+
+	release, err := limits.acquire(context.Background(), method)
+	require.NoError(t, err)
+
+	// A second call must wait for the first to finish
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limits.acquire(ctx, method)
+	assert.Error(t, err)
+
+	release()
+
+	release, err = limits.acquire(context.Background(), method)
+	require.NoError(t, err)
+	release()
+}
+
+func TestMethodLimits_QPS(t *testing.T) {
+	limits := newMethodLimits(map[string]config.MethodLimitConfig{
+		"ReportService.Generate": {QPS: 0.5},
+	})
+	method := types.MethodInfo{FullName: "reports.ReportService.Generate"}
+
+	release, err := limits.acquire(context.Background(), method)
+	require.NoError(t, err)
+	release()
+
+	// The next token is two seconds away, beyond the context deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = limits.acquire(ctx, method)
+	assert.Error(t, err)
+}
+
+func TestMethodLimits_Unlimited(t *testing.T) {
+	limits := newMethodLimits(nil)
+
+	release, err := limits.acquire(context.Background(), types.MethodInfo{FullName: "a.B.C"})
+	require.NoError(t, err)
+	release()
+}
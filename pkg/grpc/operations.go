@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+const (
+	// OperationType is the message returned by methods starting a long-running operation
+	OperationType = "google.longrunning.Operation"
+
+	// operationsService polls long-running operations
+	operationsService = "google.longrunning.Operations"
+
+	// getOperationRequestType is the request of Operations.GetOperation
+	getOperationRequestType = "google.longrunning.GetOperationRequest"
+)
+
+// ReturnsOperation reports whether a method starts a long-running operation
+func ReturnsOperation(method types.MethodInfo) bool {
+	if method.OutputDescriptor != nil {
+		return method.OutputDescriptor.FullName() == OperationType
+	}
+	return method.OutputType == OperationType || method.OutputType == "."+OperationType
+}
+
+// GetOperation polls a long-running operation of the backend with
+// Operations.GetOperation and returns the operation as JSON. The request and
+// operation types come from the backend's descriptors, which include them
+// whenever a discovered method returns an operation.
+func (d *serviceDiscoverer) GetOperation(ctx context.Context, headers map[string]string, name string) (string, error) {
+	if d.reflectionClient == nil {
+		return "", fmt.Errorf("not connected to gRPC server")
+	}
+
+	request, hasRequest := d.registry.message(getOperationRequestType)
+	operation, hasOperation := d.registry.message(OperationType)
+	if !hasRequest || !hasOperation {
+		return "", fmt.Errorf("the backend's descriptors do not declare %s", operationsService)
+	}
+
+	method := types.MethodInfo{
+		Name:             "GetOperation",
+		FullName:         operationsService + ".GetOperation",
+		ServiceName:      operationsService,
+		InputType:        string(request.FullName()),
+		OutputType:       string(operation.FullName()),
+		InputDescriptor:  request,
+		OutputDescriptor: operation,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	input, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal operation request: %w", err)
+	}
+
+	// Operations are polled on the primary backend, bypassing canary routing and shadowing
+	return d.reflectionClient.InvokeMethod(ctx, headers, method, string(input))
+}
@@ -0,0 +1,182 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// exportMethod returns a method starting a long-running export, declared with
+// a minimal google/longrunning/operations.proto the gateway has no Go types for
+func exportMethod(t *testing.T) types.MethodInfo {
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		field := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     fieldType.Enum(),
+			JsonName: proto.String(name),
+		}
+		if typeName != "" {
+			field.TypeName = proto.String(typeName)
+		}
+		return field
+	}
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+
+	operations := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("google/longrunning/operations.proto"),
+		Package:    proto.String("google.longrunning"),
+		Dependency: []string{"google/protobuf/any.proto", "google/rpc/status.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Operation"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, ""),
+					field("metadata", 2, message, ".google.protobuf.Any"),
+					field("done", 3, descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""),
+					field("error", 4, message, ".google.rpc.Status"),
+					field("response", 5, message, ".google.protobuf.Any"),
+				},
+			},
+			{
+				Name:  proto.String("GetOperationRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, str, "")},
+			},
+		},
+	}
+	shop := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("shop/export.proto"),
+		Package:    proto.String("shop"),
+		Dependency: []string{"google/longrunning/operations.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("ExportRequest")},
+			{Name: proto.String("ExportResult"), Field: []*descriptorpb.FieldDescriptorProto{field("url", 1, str, "")}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("ExportService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Export"),
+				InputType:  proto.String(".shop.ExportRequest"),
+				OutputType: proto.String(".google.longrunning.Operation"),
+			}},
+		}},
+	}
+
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto),
+		protodesc.ToFileDescriptorProto(status.File_google_rpc_status_proto),
+		operations,
+		shop,
+	}})
+	require.NoError(t, err)
+	descriptor, err := files.FindDescriptorByName("shop.ExportService.Export")
+	require.NoError(t, err)
+	methodDesc := descriptor.(protoreflect.MethodDescriptor)
+
+	method := types.MethodInfo{
+		Name:             "Export",
+		FullName:         "shop.ExportService.Export",
+		ServiceName:      "shop.ExportService",
+		InputType:        "shop.ExportRequest",
+		OutputType:       OperationType,
+		InputDescriptor:  methodDesc.Input(),
+		OutputDescriptor: methodDesc.Output(),
+	}
+	method.ToolName = method.GenerateToolName()
+	return method
+}
+
+// doneOperation returns a finished operation holding an export result
+func doneOperation(t *testing.T, method types.MethodInfo) proto.Message {
+	resultDesc := method.InputDescriptor.ParentFile().Messages().ByName("ExportResult")
+	result := dynamicpb.NewMessage(resultDesc)
+	result.Set(resultDesc.Fields().ByName("url"), protoreflect.ValueOfString("https://example.com/export.csv"))
+	value, err := proto.Marshal(result)
+	require.NoError(t, err)
+
+	operation := dynamicpb.NewMessage(method.OutputDescriptor)
+	fields := method.OutputDescriptor.Fields()
+	operation.Set(fields.ByName("name"), protoreflect.ValueOfString("operations/1"))
+	operation.Set(fields.ByName("done"), protoreflect.ValueOfBool(true))
+	response := operation.Mutable(fields.ByName("response")).Message()
+	response.Set(response.Descriptor().Fields().ByName("type_url"), protoreflect.ValueOfString("type.googleapis.com/shop.ExportResult"))
+	response.Set(response.Descriptor().Fields().ByName("value"), protoreflect.ValueOfBytes(value))
+	return operation
+}
+
+func TestReturnsOperation(t *testing.T) {
+	method := exportMethod(t)
+	assert.True(t, ReturnsOperation(method))
+	assert.True(t, ReturnsOperation(types.MethodInfo{OutputType: ".google.longrunning.Operation"}))
+	assert.False(t, ReturnsOperation(types.MethodInfo{OutputType: "shop.ExportResult"}))
+}
+
+func TestTypeRegistry_ResolvesDiscoveredAny(t *testing.T) {
+	method := exportMethod(t)
+	operation := doneOperation(t, method)
+
+	// Backend types in Any fields cannot be written with the linked types only
+	_, err := protoregistry.GlobalTypes.FindMessageByName("shop.ExportResult")
+	require.ErrorIs(t, err, protoregistry.NotFound)
+	_, err = marshalJSON(operation, nil)
+	require.Error(t, err)
+
+	registry := &typeRegistry{}
+	registry.update([]types.MethodInfo{method})
+	out, err := marshalJSON(operation, registry)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "operations/1",
+		"done": true,
+		"response": {"@type": "type.googleapis.com/shop.ExportResult", "url": "https://example.com/export.csv"}
+	}`, out)
+
+	// Linked types still resolve
+	messageType, err := registry.FindMessageByURL("type.googleapis.com/google.rpc.Status")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("google.rpc.Status"), messageType.Descriptor().FullName())
+}
+
+func TestServiceDiscoverer_GetOperation(t *testing.T) {
+	method := exportMethod(t)
+	discoverer := newServiceDiscovererWithConnManager(&mockConnectionManager{}, zap.NewNop())
+	client := &mockReflectionClient{}
+	discoverer.reflectionClient = client
+
+	// Without discovered operation types there is nothing to poll with
+	_, err := discoverer.GetOperation(context.Background(), nil, "operations/1")
+	require.Error(t, err)
+
+	client.On("DiscoverMethods", mock.Anything).Return([]types.MethodInfo{method}, nil)
+	require.NoError(t, discoverer.DiscoverServices(context.Background()))
+
+	headers := map[string]string{"authorization": "Bearer token"}
+	isGetOperation := mock.MatchedBy(func(m types.MethodInfo) bool {
+		return m.FullName == "google.longrunning.Operations.GetOperation" &&
+			m.InputDescriptor.FullName() == getOperationRequestType &&
+			m.OutputDescriptor.FullName() == OperationType
+	})
+	client.On("InvokeMethod", mock.Anything, headers, isGetOperation, `{"name":"operations/1"}`).
+		Return(`{"name":"operations/1","done":true}`, nil)
+
+	out, err := discoverer.GetOperation(context.Background(), headers, "operations/1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"operations/1","done":true}`, out)
+}
@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// maxPooledBufferSize caps the encode buffers kept for reuse so a single
+// large response does not pin its memory for the life of the process
+const maxPooledBufferSize = 64 * 1024
+
+// jsonBufferPool holds encode buffers for protojson output
+var jsonBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
+// typeResolver resolves the types of Any fields
+type typeResolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// marshalJSON encodes a message to a JSON string using a pooled buffer;
+// Any fields are resolved with the given resolver (linked types only if nil)
+func marshalJSON(msg proto.Message, resolver typeResolver) (string, error) {
+	bufPtr := jsonBufferPool.Get().(*[]byte)
+	defer func() {
+		if cap(*bufPtr) <= maxPooledBufferSize {
+			jsonBufferPool.Put(bufPtr)
+		}
+	}()
+
+	buf, err := protojson.MarshalOptions{Resolver: resolver}.MarshalAppend((*bufPtr)[:0], msg)
+	if err != nil {
+		return "", err
+	}
+	*bufPtr = buf
+
+	// The string conversion copies, so the buffer is safe to reuse afterwards
+	return string(buf), nil
+}
+
+// messagePool reuses dynamic messages per message descriptor. Each discovery
+// has its own pool, which is dropped with the descriptors it holds when a
+// rediscovery replaces the discoverer's method cache. A nil pool allocates
+// every message.
+//
+// A message may only be returned to the pool once nothing references it any
+// more; InvokeMethod satisfies this because the gRPC codec has finished with
+// both messages by the time Invoke returns and the output is copied to JSON.
+type messagePool struct {
+	pools sync.Map // protoreflect.MessageDescriptor -> *sync.Pool
+}
+
+// messagePoolKey is the context key for the message pool of a call's discovery
+type messagePoolKey struct{}
+
+// withMessagePool returns a context whose calls take messages from the pool
+func withMessagePool(ctx context.Context, pool *messagePool) context.Context {
+	return context.WithValue(ctx, messagePoolKey{}, pool)
+}
+
+// messagePoolFrom returns the message pool of a call, or nil if it has none
+func messagePoolFrom(ctx context.Context) *messagePool {
+	pool, _ := ctx.Value(messagePoolKey{}).(*messagePool)
+	return pool
+}
+
+// get returns an empty message for the descriptor
+func (p *messagePool) get(desc protoreflect.MessageDescriptor) *dynamicpb.Message {
+	if p == nil {
+		return dynamicpb.NewMessage(desc)
+	}
+	return p.poolFor(desc).Get().(*dynamicpb.Message)
+}
+
+// put clears a message and makes it available for reuse
+func (p *messagePool) put(msg *dynamicpb.Message) {
+	if p == nil {
+		return
+	}
+	// Clearing each field keeps the message's internal storage, unlike
+	// Reset which reallocates it
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		msg.Clear(fd)
+		return true
+	})
+	msg.SetUnknown(nil)
+
+	p.poolFor(msg.Descriptor()).Put(msg)
+}
+
+// poolFor returns the pool for a descriptor, creating it on first use
+func (p *messagePool) poolFor(desc protoreflect.MessageDescriptor) *sync.Pool {
+	if pool, ok := p.pools.Load(desc); ok {
+		return pool.(*sync.Pool)
+	}
+	pool, _ := p.pools.LoadOrStore(desc, &sync.Pool{
+		New: func() interface{} {
+			return dynamicpb.NewMessage(desc)
+		},
+	})
+	return pool.(*sync.Pool)
+}
@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMessagePool_ReturnsClearedMessages(t *testing.T) {
+	desc := (&structpb.Value{}).ProtoReflect().Descriptor()
+	pool := &messagePool{}
+
+	msg := pool.get(desc)
+	require.NoError(t, protojson.Unmarshal([]byte(`"hello"`), msg))
+	msg.SetUnknown(protoreflect.RawFields{0x08, 0x01})
+	pool.put(msg)
+
+	// Whether or not the same instance comes back, it must be empty
+	for i := 0; i < 10; i++ {
+		reused := pool.get(desc)
+		assert.Equal(t, desc, reused.Descriptor())
+		assert.Empty(t, reused.GetUnknown())
+		count := 0
+		reused.Range(func(protoreflect.FieldDescriptor, protoreflect.Value) bool {
+			count++
+			return true
+		})
+		assert.Zero(t, count)
+		pool.put(reused)
+	}
+}
+
+func TestMessagePool_SeparatesDescriptors(t *testing.T) {
+	valueDesc := (&structpb.Value{}).ProtoReflect().Descriptor()
+	listDesc := (&structpb.ListValue{}).ProtoReflect().Descriptor()
+	pool := &messagePool{}
+
+	pool.put(pool.get(valueDesc))
+	assert.Equal(t, listDesc, pool.get(listDesc).Descriptor())
+	assert.Equal(t, valueDesc, pool.get(valueDesc).Descriptor())
+}
+
+func TestMessagePool_Nil(t *testing.T) {
+	desc := (&structpb.Value{}).ProtoReflect().Descriptor()
+	var pool *messagePool
+
+	msg := pool.get(desc)
+	assert.Equal(t, desc, msg.Descriptor())
+	pool.put(msg)
+	assert.Nil(t, messagePoolFrom(context.Background()))
+}
+
+func TestServiceDiscoverer_MessagePoolPerDiscovery(t *testing.T) {
+	discoverer := newServiceDiscovererWithConnManager(&mockConnectionManager{}, zap.NewNop())
+	reflectionClient := &mockReflectionClient{}
+	reflectionClient.On("DiscoverMethods", mock.Anything).Return([]types.MethodInfo{
+		{FullName: "shop.Orders.Place", ToolName: "shop_orders_place"},
+	}, nil)
+	var pools []*messagePool
+	reflectionClient.On("InvokeMethod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			pools = append(pools, messagePoolFrom(args.Get(0).(context.Context)))
+		}).Return(`{}`, nil)
+	discoverer.reflectionClient = reflectionClient
+
+	invoke := func() {
+		_, err := discoverer.InvokeMethodByTool(context.Background(), nil, "shop_orders_place", "{}")
+		require.NoError(t, err)
+	}
+	require.NoError(t, discoverer.DiscoverServices(context.Background()))
+	invoke()
+	invoke()
+	require.NoError(t, discoverer.DiscoverServices(context.Background()))
+	invoke()
+
+	// Calls of one discovery share its pool; a rediscovery drops it
+	require.Len(t, pools, 3)
+	assert.NotNil(t, pools[0])
+	assert.Same(t, pools[0], pools[1])
+	assert.NotSame(t, pools[1], pools[2])
+}
+
+func TestMarshalJSON(t *testing.T) {
+	msg := structpb.NewStringValue("hello")
+
+	out, err := marshalJSON(msg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `"hello"`, out)
+
+	// A later call reusing the buffer must not alter an earlier result
+	_, err = marshalJSON(structpb.NewStringValue("overwritten"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `"hello"`, out)
+}
+
+func TestMarshalJSON_DropsOversizedBuffers(t *testing.T) {
+	large := structpb.NewStringValue(strings.Repeat("x", 2*maxPooledBufferSize))
+
+	out, err := marshalJSON(large, nil)
+	require.NoError(t, err)
+	assert.Len(t, out, 2*maxPooledBufferSize+2)
+
+	bufPtr := jsonBufferPool.Get().(*[]byte)
+	assert.LessOrEqual(t, cap(*bufPtr), maxPooledBufferSize)
+	jsonBufferPool.Put(bufPtr)
+}
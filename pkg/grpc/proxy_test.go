@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveProxy(t *testing.T) {
+	t.Run("Explicit_URL_with_credentials", func(t *testing.T) {
+		proxyURL, err := resolveProxy(ProxyConfig{
+			URL:      "socks5://proxy.internal:1080",
+			Username: "gateway",
+			Password: "secret",
+		}, "backend.internal:50051")
+		require.NoError(t, err)
+		require.NotNil(t, proxyURL)
+
+		assert.Equal(t, "socks5", proxyURL.Scheme)
+		assert.Equal(t, "gateway", proxyURL.User.Username())
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("HTTPS_PROXY", "http://env-proxy.internal:3128")
+		t.Setenv("NO_PROXY", "direct.internal")
+
+		proxyURL, err := resolveProxy(ProxyConfig{}, "backend.internal:50051")
+		require.NoError(t, err)
+		require.NotNil(t, proxyURL)
+		assert.Equal(t, "env-proxy.internal:3128", proxyURL.Host)
+
+		proxyURL, err = resolveProxy(ProxyConfig{}, "direct.internal:50051")
+		require.NoError(t, err)
+		assert.Nil(t, proxyURL)
+	})
+
+	t.Run("Unsupported_scheme", func(t *testing.T) {
+		_, err := resolveProxy(ProxyConfig{URL: "ftp://proxy.internal"}, "backend.internal:50051")
+		assert.Error(t, err)
+	})
+}
+
+// startConnectProxy starts a minimal HTTP CONNECT proxy that requires the given authorization
+func startConnectProxy(t *testing.T, expectedAuth string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				if req.Header.Get("Proxy-Authorization") != expectedAuth {
+					_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+					return
+				}
+
+				upstream, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer func() { _ = upstream.Close() }()
+
+				_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+				go func() { _, _ = io.Copy(upstream, conn) }()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return listener
+}
+
+// startEchoServer starts a TCP server that echoes what it receives
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener
+}
+
+func TestDialHTTPConnect(t *testing.T) {
+	// "gateway:secret" in basic auth
+	proxyListener := startConnectProxy(t, "Basic Z2F0ZXdheTpzZWNyZXQ=")
+	echoListener := startEchoServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Authenticated_tunnel", func(t *testing.T) {
+		proxyURL, err := resolveProxy(ProxyConfig{
+			URL:      "http://" + proxyListener.Addr().String(),
+			Username: "gateway",
+			Password: "secret",
+		}, echoListener.Addr().String())
+		require.NoError(t, err)
+
+		conn, err := proxyDialer(proxyURL)(ctx, echoListener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+
+		buf := make([]byte, 4)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(buf))
+	})
+
+	t.Run("Missing_credentials", func(t *testing.T) {
+		proxyURL, err := resolveProxy(ProxyConfig{
+			URL: "http://" + proxyListener.Addr().String(),
+		}, echoListener.Addr().String())
+		require.NoError(t, err)
+
+		_, err = proxyDialer(proxyURL)(ctx, echoListener.Addr().String())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "407")
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	appheaders "github.com/aalobaidi/ggRMCP/pkg/headers"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
 	"github.com/aalobaidi/ggRMCP/pkg/types"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -18,7 +20,6 @@ import (
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
-	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 // reflectionClient implements ReflectionClient interface
@@ -27,6 +28,12 @@ type reflectionClient struct {
 	client grpc_reflection_v1alpha.ServerReflectionClient
 	logger *zap.Logger
 
+	// Services outside the scope are skipped before fetching descriptors
+	scope serviceScope
+
+	// Backend message types resolving Any fields (linked types only if nil)
+	registry *typeRegistry
+
 	// Cache for resolved file descriptors
 	fdCache map[string]*descriptorpb.FileDescriptorProto
 	mu      sync.RWMutex
@@ -34,11 +41,18 @@ type reflectionClient struct {
 
 // NewReflectionClient creates a new reflection client
 func NewReflectionClient(conn *grpc.ClientConn, logger *zap.Logger) ReflectionClient {
+	return newScopedReflectionClient(conn, logger, serviceScope{}, nil)
+}
+
+// newScopedReflectionClient creates a reflection client that only discovers services in scope
+func newScopedReflectionClient(conn *grpc.ClientConn, logger *zap.Logger, scope serviceScope, registry *typeRegistry) *reflectionClient {
 	return &reflectionClient{
-		conn:    conn,
-		client:  grpc_reflection_v1alpha.NewServerReflectionClient(conn),
-		logger:  logger,
-		fdCache: make(map[string]*descriptorpb.FileDescriptorProto),
+		conn:     conn,
+		client:   grpc_reflection_v1alpha.NewServerReflectionClient(conn),
+		logger:   logger,
+		scope:    scope,
+		registry: registry,
+		fdCache:  make(map[string]*descriptorpb.FileDescriptorProto),
 	}
 }
 
@@ -57,8 +71,8 @@ func (r *reflectionClient) DiscoverMethods(ctx context.Context) ([]types.MethodI
 
 	r.logger.Info("Found services", zap.Strings("services", serviceNames))
 
-	// Filter out internal gRPC services
-	filteredServices := r.filterInternalServices(serviceNames)
+	// Filter out internal gRPC services and services outside the discovery scope
+	filteredServices := r.scope.filterServices(r.filterInternalServices(serviceNames))
 	r.logger.Info("Filtered services",
 		zap.Strings("originalServices", serviceNames),
 		zap.Strings("filteredServices", filteredServices))
@@ -264,6 +278,9 @@ func (r *reflectionClient) createMethodInfoWithServiceContext(ctx context.Contex
 		OutputType:        method.GetOutputType(),
 		IsClientStreaming: method.GetClientStreaming(),
 		IsServerStreaming: method.GetServerStreaming(),
+		Deprecated:        method.GetOptions().GetDeprecated() || service.GetOptions().GetDeprecated(),
+		IdempotencyLevel:  method.GetOptions().GetIdempotencyLevel(),
+		Source:            types.MethodSourceReflection,
 		FileDescriptor:    fileDescriptor,
 	}
 
@@ -331,42 +348,56 @@ func (r *reflectionClient) resolveMessageDescriptor(typeName string, fileDescrip
 
 // InvokeMethod invokes a gRPC method dynamically with optional headers
 func (r *reflectionClient) InvokeMethod(ctx context.Context, headers map[string]string, method MethodInfo, inputJSON string) (string, error) {
+	logger := logging.FromContext(ctx, r.logger)
+
 	// Add headers to context metadata if provided
 	if len(headers) > 0 {
 		for key, value := range headers {
+			// Binary metadata travels as base64 in headers; gRPC encodes the raw bytes itself
+			if strings.HasSuffix(key, "-bin") {
+				if decoded, err := appheaders.DecodeBinary(value); err == nil {
+					value = string(decoded)
+				}
+			}
 			ctx = metadata.AppendToOutgoingContext(ctx, key, value)
 		}
-		r.logger.Debug("Forwarding headers to gRPC server",
+		logger.Debug("Forwarding headers to gRPC server",
 			zap.String("method", method.FullName),
 			zap.Int("headerCount", len(headers)))
 	}
 
-	r.logger.Debug("Starting dynamic method invocation",
+	logger.Debug("Starting dynamic method invocation",
 		zap.String("method", method.FullName),
 		zap.String("inputType", string(method.InputDescriptor.FullName())),
 		zap.String("outputType", string(method.OutputDescriptor.FullName())),
 		zap.String("inputJSON", inputJSON))
 
-	// 1. Create dynamic input message
-	inputMsg := dynamicpb.NewMessage(method.InputDescriptor)
+	// 1. Take a dynamic input message from the pool of the method's discovery
+	messages := messagePoolFrom(ctx)
+	inputMsg := messages.get(method.InputDescriptor)
+	defer messages.put(inputMsg)
 
 	// 2. Parse JSON input into the dynamic message
 	if inputJSON != "" && inputJSON != "{}" {
-		if err := protojson.Unmarshal([]byte(inputJSON), inputMsg); err != nil {
+		if err := (protojson.UnmarshalOptions{Resolver: r.registry}).Unmarshal([]byte(inputJSON), inputMsg); err != nil {
 			return "", fmt.Errorf("failed to parse input JSON: %w", err)
 		}
 	}
 
-	r.logger.Debug("Created input message", zap.String("message", inputMsg.String()))
+	// Formatting the message is expensive, so only do it when debug logging is on
+	if ce := logger.Check(zap.DebugLevel, "Created input message"); ce != nil {
+		ce.Write(zap.String("message", inputMsg.String()))
+	}
 
-	// 3. Create dynamic output message
-	outputMsg := dynamicpb.NewMessage(method.OutputDescriptor)
+	// 3. Take a dynamic output message from the pool
+	outputMsg := messages.get(method.OutputDescriptor)
+	defer messages.put(outputMsg)
 
 	// 4. Invoke the gRPC method using generic invoke
 	// Convert method name to gRPC format: /package.Service/Method
-	grpcMethodName := fmt.Sprintf("/%s/%s", method.FullName[:strings.LastIndex(method.FullName, ".")], method.Name)
+	grpcMethodName := "/" + method.FullName[:strings.LastIndex(method.FullName, ".")] + "/" + method.Name
 
-	r.logger.Debug("Invoking gRPC method",
+	logger.Debug("Invoking gRPC method",
 		zap.String("grpcMethodName", grpcMethodName),
 		zap.String("originalFullName", method.FullName))
 
@@ -375,46 +406,65 @@ func (r *reflectionClient) InvokeMethod(ctx context.Context, headers map[string]
 		return "", fmt.Errorf("gRPC call failed: %w", err)
 	}
 
-	r.logger.Debug("Received output message", zap.String("message", outputMsg.String()))
+	if ce := logger.Check(zap.DebugLevel, "Received output message"); ce != nil {
+		ce.Write(zap.String("message", outputMsg.String()))
+	}
 
 	// 5. Convert output to JSON
-	outputJSON, err := protojson.Marshal(outputMsg)
+	outputJSON, err := marshalJSON(outputMsg, r.outputTypes(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal output to JSON: %w", err)
 	}
 
-	r.logger.Debug("Method invocation successful",
+	logger.Debug("Method invocation successful",
 		zap.String("method", method.FullName),
-		zap.String("outputJSON", string(outputJSON)))
+		zap.String("outputJSON", outputJSON))
 
-	return string(outputJSON), nil
+	return outputJSON, nil
 }
 
-// filterInternalServices filters out internal gRPC services
-func (r *reflectionClient) filterInternalServices(services []string) []string {
-	var filtered []string
+// outputTypes resolves the Any fields of a response, fetching the types the
+// discovered files do not declare from the backend
+func (r *reflectionClient) outputTypes(ctx context.Context) typeResolver {
+	if r.registry == nil {
+		return nil
+	}
+	return backendTypes{
+		typeRegistry: r.registry,
+		lookup: func(name protoreflect.FullName) (protoreflect.FileDescriptor, error) {
+			return r.fetchFile(ctx, name)
+		},
+	}
+}
 
-	internalPrefixes := []string{
-		"grpc.reflection.",
-		"grpc.health.",
-		"grpc.channelz.",
-		"grpc.testing.",
+// fetchFile asks the backend for the file declaring a type, resolving its
+// imports with the discovered and linked files
+func (r *reflectionClient) fetchFile(ctx context.Context, name protoreflect.FullName) (protoreflect.FileDescriptor, error) {
+	logger := logging.FromContext(ctx, r.logger)
+	fileDescriptor, err := r.getFileDescriptorBySymbol(ctx, string(name))
+	if err != nil {
+		logger.Debug("Backend does not declare Any type", zap.String("type", string(name)), zap.Error(err))
+		return nil, err
 	}
+	file, err := protodesc.NewFile(fileDescriptor, r.registry)
+	if err != nil {
+		logger.Warn("Failed to build descriptor of Any type",
+			zap.String("type", string(name)),
+			zap.String("file", fileDescriptor.GetName()),
+			zap.Error(err))
+		return nil, err
+	}
+	return file, nil
+}
 
+// filterInternalServices filters out internal gRPC services and excluded services
+func (r *reflectionClient) filterInternalServices(services []string) []string {
+	var filtered []string
 	for _, service := range services {
-		isInternal := false
-		for _, prefix := range internalPrefixes {
-			if strings.HasPrefix(service, prefix) {
-				isInternal = true
-				break
-			}
-		}
-
-		if !isInternal {
+		if !r.scope.excludes(service) {
 			filtered = append(filtered, service)
 		}
 	}
-
 	return filtered
 }
 
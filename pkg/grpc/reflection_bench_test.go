@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/benchutil"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func BenchmarkJSONToDynamicpb(b *testing.B) {
+	files, err := benchutil.Files(1, 1, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	method := benchutil.Methods(files)[0]
+	input := []byte(benchutil.SampleRequestJSON(20))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg := dynamicpb.NewMessage(method.InputDescriptor)
+		if err := protojson.Unmarshal(input, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDynamicpbToJSON(b *testing.B) {
+	files, err := benchutil.Files(1, 1, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	method := benchutil.Methods(files)[0]
+	msg := dynamicpb.NewMessage(method.InputDescriptor)
+	if err := protojson.Unmarshal([]byte(benchutil.SampleRequestJSON(20)), msg); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := protojson.Marshal(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReflectionClient_InvokeMethod(b *testing.B) {
+	files, err := benchutil.Files(1, 1, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	method := benchutil.Methods(files)[0]
+
+	conn, stop, err := benchutil.StartBackend(files)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer stop()
+
+	client := NewReflectionClient(conn, zap.NewNop())
+	input := benchutil.SampleRequestJSON(20)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.InvokeMethod(ctx, nil, method, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReflectionClient_InvokeMethodParallel(b *testing.B) {
+	files, err := benchutil.Files(1, 1, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	method := benchutil.Methods(files)[0]
+
+	conn, stop, err := benchutil.StartBackend(files)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer stop()
+
+	client := NewReflectionClient(conn, zap.NewNop())
+	input := benchutil.SampleRequestJSON(20)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := client.InvokeMethod(ctx, nil, method, input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -3,6 +3,7 @@ package grpc
 import (
 	"testing"
 
+	"github.com/aalobaidi/ggRMCP/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -38,6 +39,27 @@ func TestFilterInternalServices(t *testing.T) {
 	assert.NotContains(t, filtered, "grpc.testing.TestService")
 }
 
+func TestFilterInternalServices_Configured(t *testing.T) {
+	client := &reflectionClient{
+		logger:  zap.NewNop(),
+		fdCache: make(map[string]*descriptorpb.FileDescriptorProto),
+		scope: newServiceScope(config.DiscoveryConfig{
+			ExcludeServices: []string{"com.example.admin.*"},
+			ExposeInternal:  []string{"grpc.health.v1.Health"},
+		}),
+	}
+
+	filtered := client.filterInternalServices([]string{
+		"grpc.reflection.v1alpha.ServerReflection",
+		"grpc.health.v1.Health",
+		"grpc.channelz.v1.Channelz",
+		"com.example.admin.AdminService",
+		"com.example.MyService",
+	})
+
+	assert.Equal(t, []string{"grpc.health.v1.Health", "com.example.MyService"}, filtered)
+}
+
 func TestGetSimpleServiceName(t *testing.T) {
 	tests := []struct {
 		input    string
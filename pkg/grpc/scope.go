@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+// builtinInternalServices are gRPC infrastructure services excluded by default
+var builtinInternalServices = []string{
+	"grpc.reflection.*",
+	"grpc.health.*",
+	"grpc.channelz.*",
+	"grpc.testing.*",
+}
+
+// serviceScope decides which services are discovered
+type serviceScope struct {
+	include []string
+	exclude []string
+	expose  []string
+}
+
+// newServiceScope creates a service scope from discovery configuration
+func newServiceScope(discoveryConfig config.DiscoveryConfig) serviceScope {
+	return serviceScope{
+		include: discoveryConfig.IncludeServices,
+		exclude: discoveryConfig.ExcludeServices,
+		expose:  discoveryConfig.ExposeInternal,
+	}
+}
+
+// excludes reports whether the service matches an exclude pattern. The
+// built-in internal services are excluded unless exposed.
+func (s serviceScope) excludes(service string) bool {
+	if matchAnyServicePattern(s.exclude, service) {
+		return true
+	}
+	return matchAnyServicePattern(builtinInternalServices, service) &&
+		!matchAnyServicePattern(s.expose, service)
+}
+
+// allows reports whether the service is in scope
+func (s serviceScope) allows(service string) bool {
+	if s.excludes(service) {
+		return false
+	}
+
+	if len(s.include) == 0 {
+		return true
+	}
+	for _, pattern := range s.include {
+		if matchServicePattern(pattern, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterServices returns the services in scope
+func (s serviceScope) filterServices(services []string) []string {
+	var filtered []string
+	for _, service := range services {
+		if s.allows(service) {
+			filtered = append(filtered, service)
+		}
+	}
+	return filtered
+}
+
+// filterMethods returns the methods whose service is in scope
+func (s serviceScope) filterMethods(methods []types.MethodInfo) []types.MethodInfo {
+	filtered := make([]types.MethodInfo, 0, len(methods))
+	for _, method := range methods {
+		if s.allows(method.ServiceName) {
+			filtered = append(filtered, method)
+		}
+	}
+	return filtered
+}
+
+// matchAnyServicePattern reports whether any of the patterns matches the service
+func matchAnyServicePattern(patterns []string, service string) bool {
+	for _, pattern := range patterns {
+		if matchServicePattern(pattern, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchServicePattern matches a full service name or a package prefix,
+// with an optional trailing ".*" or "*"
+func matchServicePattern(pattern, service string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(service, prefix)
+	}
+	return service == pattern || strings.HasPrefix(service, pattern+".")
+}
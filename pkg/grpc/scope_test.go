@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceScope_Allows(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.DiscoveryConfig
+		service  string
+		expected bool
+	}{
+		{
+			name:     "Empty_scope_allows_everything",
+			service:  "com.other.Service",
+			expected: true,
+		},
+		{
+			name:     "Wildcard_prefix_matches",
+			config:   config.DiscoveryConfig{IncludeServices: []string{"com.mycorp.api.*"}},
+			service:  "com.mycorp.api.v1.UserService",
+			expected: true,
+		},
+		{
+			name:     "Package_prefix_matches",
+			config:   config.DiscoveryConfig{IncludeServices: []string{"com.mycorp.api"}},
+			service:  "com.mycorp.api.UserService",
+			expected: true,
+		},
+		{
+			name:     "Package_prefix_requires_segment_boundary",
+			config:   config.DiscoveryConfig{IncludeServices: []string{"com.mycorp.api"}},
+			service:  "com.mycorp.apiv2.UserService",
+			expected: false,
+		},
+		{
+			name:     "Exact_service_matches",
+			config:   config.DiscoveryConfig{IncludeServices: []string{"hello.HelloService"}},
+			service:  "hello.HelloService",
+			expected: true,
+		},
+		{
+			name:     "Service_outside_include_is_skipped",
+			config:   config.DiscoveryConfig{IncludeServices: []string{"com.mycorp.api.*"}},
+			service:  "com.other.Service",
+			expected: false,
+		},
+		{
+			name: "Exclude_wins_over_include",
+			config: config.DiscoveryConfig{
+				IncludeServices: []string{"com.mycorp.*"},
+				ExcludeServices: []string{"com.mycorp.admin"},
+			},
+			service:  "com.mycorp.admin.AdminService",
+			expected: false,
+		},
+		{
+			name:     "Builtin_internal_service_is_excluded",
+			service:  "grpc.health.v1.Health",
+			expected: false,
+		},
+		{
+			name:     "Exposed_internal_service_is_allowed",
+			config:   config.DiscoveryConfig{ExposeInternal: []string{"grpc.health.*"}},
+			service:  "grpc.health.v1.Health",
+			expected: true,
+		},
+		{
+			name: "Exposing_does_not_override_exclusions",
+			config: config.DiscoveryConfig{
+				ExcludeServices: []string{"grpc.health.*"},
+				ExposeInternal:  []string{"grpc.health.*"},
+			},
+			service:  "grpc.health.v1.Health",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := newServiceScope(tt.config)
+			assert.Equal(t, tt.expected, scope.allows(tt.service))
+		})
+	}
+}
+
+func TestServiceScope_Filter(t *testing.T) {
+	scope := newServiceScope(config.DiscoveryConfig{IncludeServices: []string{"com.mycorp.api.*"}})
+
+	services := scope.filterServices([]string{"com.mycorp.api.UserService", "com.other.Service"})
+	assert.Equal(t, []string{"com.mycorp.api.UserService"}, services)
+
+	methods := scope.filterMethods([]types.MethodInfo{
+		{ServiceName: "com.mycorp.api.UserService", Name: "GetUser"},
+		{ServiceName: "com.other.Service", Name: "Other"},
+	})
+	assert.Len(t, methods, 1)
+	assert.Equal(t, "GetUser", methods[0].Name)
+
+	assert.NotNil(t, scope.filterMethods(nil), "an empty result must not look like a failed discovery")
+}
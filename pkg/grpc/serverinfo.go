@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// serverInfoMethods are the conventional names of version RPCs, in order of preference
+var serverInfoMethods = []string{"GetServerInfo", "ServerInfo", "GetBuildInfo", "BuildInfo", "GetVersion", "Version"}
+
+// serverInfoTimeout bounds the version RPC made after each discovery
+const serverInfoTimeout = 5 * time.Second
+
+// fileOptionsExtendee is the message custom file options extend
+const fileOptionsExtendee = ".google.protobuf.FileOptions"
+
+// ServerInfo describes the version and build of the backend behind the gateway
+type ServerInfo struct {
+	// Method is the version RPC the info was read from (empty if none was found)
+	Method string `json:"method,omitempty"`
+
+	// Info is the response of the version RPC
+	Info map[string]interface{} `json:"info,omitempty"`
+
+	// FileOptions are the custom options of the files declaring the backend's
+	// services, keyed by file then by option name (or field number when the
+	// option's declaration is not available)
+	FileOptions map[string]map[string]interface{} `json:"fileOptions,omitempty"`
+
+	// FetchedAt is when the info was read
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// ServerInfo returns the backend info read by the last discovery, or nil if the
+// backend exposes neither a version RPC nor custom file options
+func (d *serviceDiscoverer) ServerInfo() *ServerInfo {
+	return d.serverInfo.Load()
+}
+
+// discoverServerInfo reads the backend's version RPC and custom file options.
+// Failures only log: server info is informational and never blocks discovery.
+func (d *serviceDiscoverer) discoverServerInfo(ctx context.Context, methods []types.MethodInfo) *ServerInfo {
+	if !d.serverInfoConfig.Enabled {
+		return nil
+	}
+
+	info := &ServerInfo{FileOptions: customFileOptions(methods)}
+
+	if method, ok := findServerInfoMethod(methods, d.serverInfoConfig.Method); ok {
+		callCtx, cancel := context.WithTimeout(ctx, serverInfoTimeout)
+		defer cancel()
+
+		// Always ask the primary backend, bypassing canary routing and shadowing
+		response, err := d.reflectionClient.InvokeMethod(callCtx, nil, method, "{}")
+		if err != nil {
+			d.logger.Warn("Failed to read backend server info",
+				zap.String("method", method.FullName), zap.Error(err))
+		} else if err := json.Unmarshal([]byte(response), &info.Info); err != nil {
+			d.logger.Warn("Backend server info is not a JSON object",
+				zap.String("method", method.FullName), zap.Error(err))
+		} else {
+			info.Method = method.FullName
+		}
+	} else if d.serverInfoConfig.Method != "" {
+		d.logger.Warn("Configured server info method not found",
+			zap.String("method", d.serverInfoConfig.Method))
+	}
+
+	if info.Method == "" && len(info.FileOptions) == 0 {
+		return nil
+	}
+	info.FetchedAt = time.Now().UTC()
+	return info
+}
+
+// findServerInfoMethod picks the configured version RPC, or the first
+// conventionally named unary method whose request has no fields
+func findServerInfoMethod(methods []types.MethodInfo, configured string) (types.MethodInfo, bool) {
+	candidates := make([]types.MethodInfo, 0)
+	for _, method := range methods {
+		if method.IsClientStreaming || method.IsServerStreaming {
+			continue
+		}
+		if configured != "" {
+			if method.FullName == configured || method.ToolName == configured {
+				return method, true
+			}
+			continue
+		}
+		if method.InputDescriptor == nil || method.InputDescriptor.Fields().Len() > 0 {
+			continue
+		}
+		if serverInfoRank(method.Name) >= 0 {
+			candidates = append(candidates, method)
+		}
+	}
+	if len(candidates) == 0 {
+		return types.MethodInfo{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		ri, rj := serverInfoRank(candidates[i].Name), serverInfoRank(candidates[j].Name)
+		if ri != rj {
+			return ri < rj
+		}
+		return candidates[i].FullName < candidates[j].FullName
+	})
+	return candidates[0], true
+}
+
+// serverInfoRank returns the preference of a conventional version RPC name, or -1
+func serverInfoRank(name string) int {
+	for i, conventional := range serverInfoMethods {
+		if name == conventional {
+			return i
+		}
+	}
+	return -1
+}
+
+// customFileOptions decodes the custom options of the files declaring the
+// methods. Custom options are extensions the gateway has no Go types for, so
+// they are kept as unknown fields and decoded from the wire format; options
+// are named from the extensions declared in the same set of files.
+func customFileOptions(methods []types.MethodInfo) map[string]map[string]interface{} {
+	names := make(map[protowire.Number]string)
+	seen := make(map[string]bool)
+	for _, method := range methods {
+		file := method.FileDescriptor
+		if file == nil || seen[file.GetName()] {
+			continue
+		}
+		seen[file.GetName()] = true
+		for _, extension := range file.GetExtension() {
+			if extension.GetExtendee() != fileOptionsExtendee {
+				continue
+			}
+			name := extension.GetName()
+			if file.GetPackage() != "" {
+				name = file.GetPackage() + "." + name
+			}
+			names[protowire.Number(extension.GetNumber())] = name
+		}
+	}
+
+	options := make(map[string]map[string]interface{})
+	for _, method := range methods {
+		file := method.FileDescriptor
+		if file == nil || options[file.GetName()] != nil || file.GetOptions() == nil {
+			continue
+		}
+		decoded := decodeUnknownOptions(file.GetOptions().ProtoReflect().GetUnknown(), names)
+		if len(decoded) > 0 {
+			options[file.GetName()] = decoded
+		}
+	}
+	return options
+}
+
+// decodeUnknownOptions decodes option fields from the wire format. Without a
+// schema, values are reported as wire values: varints and fixed-width numbers
+// as unsigned integers, bytes as strings (base64 when not valid UTF-8).
+// Repeated options are collected into a list.
+func decodeUnknownOptions(raw []byte, names map[protowire.Number]string) map[string]interface{} {
+	options := make(map[string]interface{})
+	for len(raw) > 0 {
+		number, wireType, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			break
+		}
+		raw = raw[n:]
+
+		var value interface{}
+		switch wireType {
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(raw)
+			value = v
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(raw)
+			value = v
+		case protowire.Fixed64Type:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(raw)
+			value = v
+		case protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(raw)
+			if utf8.Valid(v) {
+				value = string(v)
+			} else {
+				value = base64.StdEncoding.EncodeToString(v)
+			}
+		default:
+			n = protowire.ConsumeFieldValue(number, wireType, raw)
+		}
+		if n < 0 {
+			break
+		}
+		raw = raw[n:]
+		if value == nil {
+			continue
+		}
+
+		key, named := names[number]
+		if !named {
+			key = strconv.Itoa(int(number))
+		}
+		switch existing := options[key].(type) {
+		case nil:
+			options[key] = value
+		case []interface{}:
+			options[key] = append(existing, value)
+		default:
+			options[key] = []interface{}{existing, value}
+		}
+	}
+	return options
+}
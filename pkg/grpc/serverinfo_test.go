@@ -0,0 +1,150 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// shopFile returns a file declaring a build_version option and setting it
+// with two options the file does not declare, one of them repeated
+func shopFile() *descriptorpb.FileDescriptorProto {
+	var raw []byte
+	raw = protowire.AppendTag(raw, 50001, protowire.BytesType)
+	raw = protowire.AppendString(raw, "1.4.2")
+	raw = protowire.AppendTag(raw, 50002, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, 7)
+	raw = protowire.AppendTag(raw, 50003, protowire.BytesType)
+	raw = protowire.AppendString(raw, "eu")
+	raw = protowire.AppendTag(raw, 50003, protowire.BytesType)
+	raw = protowire.AppendString(raw, "us")
+
+	options := &descriptorpb.FileOptions{GoPackage: proto.String("example.com/shop")}
+	options.ProtoReflect().SetUnknown(raw)
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop/orders.proto"),
+		Package: proto.String("shop"),
+		Options: options,
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("build_version"),
+			Number:   proto.Int32(50001),
+			Extendee: proto.String(fileOptionsExtendee),
+		}},
+	}
+}
+
+func serverInfoMethod(service, name string, empty bool) types.MethodInfo {
+	method := versionedMethod(service, name)
+	method.InputDescriptor = (&durationpb.Duration{}).ProtoReflect().Descriptor()
+	if empty {
+		method.InputDescriptor = (&emptypb.Empty{}).ProtoReflect().Descriptor()
+	}
+	return method
+}
+
+func TestFindServerInfoMethod(t *testing.T) {
+	version := serverInfoMethod("shop.AdminService", "Version", true)
+	buildInfo := serverInfoMethod("shop.AdminService", "GetBuildInfo", true)
+	withFields := serverInfoMethod("shop.OrderService", "GetServerInfo", false)
+	streaming := serverInfoMethod("shop.AdminService", "ServerInfo", true)
+	streaming.IsServerStreaming = true
+	place := serverInfoMethod("shop.OrderService", "Place", true)
+
+	methods := []types.MethodInfo{place, version, withFields, streaming, buildInfo}
+
+	method, ok := findServerInfoMethod(methods, "")
+	require.True(t, ok)
+	assert.Equal(t, buildInfo.FullName, method.FullName)
+
+	method, ok = findServerInfoMethod(methods, "shop.OrderService.Place")
+	require.True(t, ok)
+	assert.Equal(t, place.FullName, method.FullName)
+	method, ok = findServerInfoMethod(methods, version.ToolName)
+	require.True(t, ok)
+	assert.Equal(t, version.FullName, method.FullName)
+
+	_, ok = findServerInfoMethod(methods, "shop.AdminService.Missing")
+	assert.False(t, ok)
+	_, ok = findServerInfoMethod([]types.MethodInfo{place, withFields}, "")
+	assert.False(t, ok)
+}
+
+func TestCustomFileOptions(t *testing.T) {
+	first := versionedMethod("shop.OrderService", "Place")
+	first.FileDescriptor = shopFile()
+	second := versionedMethod("shop.OrderService", "Cancel")
+	second.FileDescriptor = shopFile()
+	plain := versionedMethod("billing.InvoiceService", "Get")
+	plain.FileDescriptor = &descriptorpb.FileDescriptorProto{Name: proto.String("billing.proto")}
+
+	options := customFileOptions([]types.MethodInfo{first, second, plain, versionedMethod("x.Service", "Get")})
+	assert.Equal(t, map[string]map[string]interface{}{
+		"shop/orders.proto": {
+			"shop.build_version": "1.4.2",
+			"50002":              uint64(7),
+			"50003":              []interface{}{"eu", "us"},
+		},
+	}, options)
+}
+
+func TestServiceDiscoverer_ServerInfo(t *testing.T) {
+	version := serverInfoMethod("shop.AdminService", "GetVersion", true)
+	version.FileDescriptor = shopFile()
+
+	newDiscoverer := func(serverInfo config.ServerInfoConfig) (*serviceDiscoverer, *mockReflectionClient) {
+		discoverer := newServiceDiscovererWithConnManager(&mockConnectionManager{}, zap.NewNop())
+		discoverer.serverInfoConfig = serverInfo
+		client := &mockReflectionClient{}
+		client.On("DiscoverMethods", mock.Anything).Return([]types.MethodInfo{version}, nil)
+		discoverer.reflectionClient = client
+		return discoverer, client
+	}
+
+	t.Run("Version_RPC_and_file_options", func(t *testing.T) {
+		discoverer, client := newDiscoverer(config.ServerInfoConfig{Enabled: true})
+		client.On("InvokeMethod", mock.Anything, map[string]string(nil), version, "{}").
+			Return(`{"version":"1.4.2","commit":"abc123"}`, nil)
+
+		require.NoError(t, discoverer.DiscoverServices(context.Background()))
+		info := discoverer.ServerInfo()
+		require.NotNil(t, info)
+		assert.Equal(t, version.FullName, info.Method)
+		assert.Equal(t, map[string]interface{}{"version": "1.4.2", "commit": "abc123"}, info.Info)
+		assert.Equal(t, "1.4.2", info.FileOptions["shop/orders.proto"]["shop.build_version"])
+		assert.False(t, info.FetchedAt.IsZero())
+	})
+
+	t.Run("Failing_RPC_keeps_file_options", func(t *testing.T) {
+		discoverer, client := newDiscoverer(config.ServerInfoConfig{Enabled: true})
+		client.On("InvokeMethod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return("", errors.New("unimplemented"))
+
+		require.NoError(t, discoverer.DiscoverServices(context.Background()))
+		info := discoverer.ServerInfo()
+		require.NotNil(t, info)
+		assert.Empty(t, info.Method)
+		assert.Nil(t, info.Info)
+		assert.NotEmpty(t, info.FileOptions)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		discoverer, client := newDiscoverer(config.ServerInfoConfig{})
+
+		require.NoError(t, discoverer.DiscoverServices(context.Background()))
+		assert.Nil(t, discoverer.ServerInfo())
+		client.AssertNotCalled(t, "InvokeMethod", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
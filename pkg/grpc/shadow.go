@@ -0,0 +1,169 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+// shadowMirror mirrors tool calls to a shadow backend and compares the responses.
+// Mirrored calls are fire-and-forget and never affect the primary result.
+type shadowMirror struct {
+	logger  *zap.Logger
+	target  *backendTarget
+	timeout time.Duration
+	tools   []string
+
+	// slots bounds the mirrored calls in flight
+	slots chan struct{}
+
+	// mu orders mirroring against close, so no call starts once close waits
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+
+	// Comparison counters
+	mirrored   atomic.Int64
+	matched    atomic.Int64
+	mismatched atomic.Int64
+	failed     atomic.Int64
+	dropped    atomic.Int64
+}
+
+// newShadowMirror creates a shadow mirror for the configured shadow target
+func newShadowMirror(shadowConfig config.ShadowConfig, baseConfig ConnectionManagerConfig, logger *zap.Logger) *shadowMirror {
+	return &shadowMirror{
+		logger:  logger.Named("shadow"),
+		target:  newBackendTarget("shadow", shadowConfig.Host, shadowConfig.Port, baseConfig, logger),
+		timeout: shadowConfig.Timeout,
+		tools:   shadowConfig.Tools,
+		slots:   make(chan struct{}, shadowConfig.MaxInFlight),
+	}
+}
+
+// mirrors reports whether calls of the tool are mirrored
+func (s *shadowMirror) mirrors(toolName string) bool {
+	for _, pattern := range s.tools {
+		if matched, _ := path.Match(pattern, toolName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// mirror invokes the method on the shadow backend in the background and compares
+// the outcome. Calls are dropped while the in-flight limit is reached, and
+// ignored once the mirror is closed.
+func (s *shadowMirror) mirror(headers map[string]string, method types.MethodInfo, inputJSON string, primaryResult string, primaryErr error) {
+	client := s.target.reflectionClient()
+	if client == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		s.logger.Debug("Shadow call dropped, too many in flight", zap.String("method", method.FullName))
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	s.mirrored.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.slots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		shadowResult, shadowErr := client.InvokeMethod(ctx, headers, method, inputJSON)
+		s.compare(method, primaryResult, primaryErr, shadowResult, shadowErr)
+	}()
+}
+
+// compare records whether the shadow outcome matches the primary outcome
+func (s *shadowMirror) compare(method types.MethodInfo, primaryResult string, primaryErr error, shadowResult string, shadowErr error) {
+	// Shadow failures with a healthy primary are tracked separately from diffs
+	if shadowErr != nil && primaryErr == nil {
+		s.failed.Add(1)
+		s.logger.Warn("Shadow call failed",
+			zap.String("method", method.FullName),
+			zap.Error(shadowErr))
+		return
+	}
+
+	if outcomesMatch(primaryResult, primaryErr, shadowResult, shadowErr) {
+		s.matched.Add(1)
+		return
+	}
+
+	s.mismatched.Add(1)
+	s.logger.Warn("Shadow response differs from primary",
+		zap.String("method", method.FullName),
+		zap.Bool("primaryError", primaryErr != nil),
+		zap.Bool("shadowError", shadowErr != nil),
+		zap.Int("primaryBytes", len(primaryResult)),
+		zap.Int("shadowBytes", len(shadowResult)))
+	s.logger.Debug("Shadow response diff",
+		zap.String("method", method.FullName),
+		zap.String("primary", primaryResult),
+		zap.String("shadow", shadowResult))
+}
+
+// outcomesMatch compares two call outcomes, treating JSON results semantically
+func outcomesMatch(primaryResult string, primaryErr error, shadowResult string, shadowErr error) bool {
+	if primaryErr != nil || shadowErr != nil {
+		if primaryErr == nil || shadowErr == nil {
+			return false
+		}
+		return status.Code(primaryErr) == status.Code(shadowErr)
+	}
+
+	var primaryValue, shadowValue interface{}
+	if err := json.Unmarshal([]byte(primaryResult), &primaryValue); err != nil {
+		return primaryResult == shadowResult
+	}
+	if err := json.Unmarshal([]byte(shadowResult), &shadowValue); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(primaryValue, shadowValue)
+}
+
+// stats returns shadow comparison counters
+func (s *shadowMirror) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"mirrored":   s.mirrored.Load(),
+		"matched":    s.matched.Load(),
+		"mismatched": s.mismatched.Load(),
+		"failed":     s.failed.Load(),
+		"dropped":    s.dropped.Load(),
+	}
+}
+
+// close stops mirroring, waits for in-flight mirrored calls and closes the
+// shadow connection
+func (s *shadowMirror) close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wg.Wait()
+	return s.target.close()
+}
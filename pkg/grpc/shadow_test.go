@@ -0,0 +1,136 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOutcomesMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		primary       string
+		primaryErr    error
+		shadow        string
+		shadowErr     error
+		expectedMatch bool
+	}{
+		{"Identical_json", `{"a":1,"b":"x"}`, nil, `{"a":1,"b":"x"}`, nil, true},
+		{"Reordered_keys", `{"a":1,"b":"x"}`, nil, `{"b":"x","a":1}`, nil, true},
+		{"Different_values", `{"a":1}`, nil, `{"a":2}`, nil, false},
+		{"Same_error_code", "", status.Error(codes.NotFound, "a"), "", status.Error(codes.NotFound, "b"), true},
+		{"Different_error_code", "", status.Error(codes.NotFound, "a"), "", status.Error(codes.Internal, "a"), false},
+		{"Only_shadow_succeeds", "", status.Error(codes.NotFound, "a"), `{}`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedMatch, outcomesMatch(tt.primary, tt.primaryErr, tt.shadow, tt.shadowErr))
+		})
+	}
+}
+
+func TestShadowMirror_Mirror(t *testing.T) {
+	method := types.MethodInfo{FullName: "hello.HelloService.SayHello"}
+
+	mockClient := &mockReflectionClient{}
+	mockClient.On("InvokeMethod", mock.Anything, mock.Anything, method, `{"name":"a"}`).Return(`{"message":"hi a"}`, nil)
+	mockClient.On("InvokeMethod", mock.Anything, mock.Anything, method, `{"name":"b"}`).Return(`{"message":"hello b"}`, nil)
+	mockClient.On("InvokeMethod", mock.Anything, mock.Anything, method, `{"name":"c"}`).Return("", errors.New("unavailable"))
+
+	shadow := newTestShadowMirror(mockClient, 8)
+
+	shadow.mirror(nil, method, `{"name":"a"}`, `{"message":"hi a"}`, nil)
+	shadow.mirror(nil, method, `{"name":"b"}`, `{"message":"hi b"}`, nil)
+	shadow.mirror(nil, method, `{"name":"c"}`, `{"message":"hi c"}`, nil)
+
+	// close waits for all in-flight mirrored calls
+	assert.NoError(t, shadow.close())
+
+	stats := shadow.stats()
+	assert.Equal(t, int64(3), stats["mirrored"])
+	assert.Equal(t, int64(1), stats["matched"])
+	assert.Equal(t, int64(1), stats["mismatched"])
+	assert.Equal(t, int64(1), stats["failed"])
+
+	// Nothing is mirrored once closed
+	shadow.mirror(nil, method, `{"name":"a"}`, `{"message":"hi a"}`, nil)
+	assert.Equal(t, int64(3), shadow.stats()["mirrored"])
+}
+
+func TestShadowMirror_DropsWhenFull(t *testing.T) {
+	method := types.MethodInfo{FullName: "hello.HelloService.SayHello"}
+
+	release := make(chan struct{})
+	mockClient := &mockReflectionClient{}
+	mockClient.On("InvokeMethod", mock.Anything, mock.Anything, method, mock.Anything).
+		Run(func(mock.Arguments) { <-release }).Return(`{}`, nil)
+
+	shadow := newTestShadowMirror(mockClient, 2)
+	for i := 0; i < 5; i++ {
+		shadow.mirror(nil, method, `{}`, `{}`, nil)
+	}
+	close(release)
+	assert.NoError(t, shadow.close())
+
+	stats := shadow.stats()
+	assert.Equal(t, int64(2), stats["mirrored"])
+	assert.Equal(t, int64(3), stats["dropped"])
+	assert.Equal(t, int64(2), stats["matched"])
+}
+
+// newTestShadowMirror creates a shadow mirror calling the client
+func newTestShadowMirror(client *mockReflectionClient, maxInFlight int) *shadowMirror {
+	mockConnMgr := &mockConnectionManager{}
+	mockConnMgr.On("Close").Return(nil)
+
+	return &shadowMirror{
+		logger: zap.NewNop(),
+		target: &backendTarget{
+			name:        "shadow",
+			logger:      zap.NewNop(),
+			connManager: mockConnMgr,
+			client:      client,
+		},
+		timeout: time.Second,
+		tools:   []string{"*"},
+		slots:   make(chan struct{}, maxInFlight),
+	}
+}
+
+func TestServiceDiscoverer_ShadowTools(t *testing.T) {
+	discoverer := newServiceDiscovererWithConnManager(&mockConnectionManager{}, zap.NewNop())
+	primary := &mockReflectionClient{}
+	primary.On("DiscoverMethods", mock.Anything).Return([]types.MethodInfo{
+		{FullName: "shop.Orders.Get", ToolName: "shop_orders_get"},
+		{FullName: "shop.Orders.Delete", ToolName: "shop_orders_delete"},
+	}, nil)
+	primary.On("InvokeMethod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(`{}`, nil)
+	discoverer.reflectionClient = primary
+
+	shadowClient := &mockReflectionClient{}
+	shadowClient.On("InvokeMethod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(`{}`, nil)
+	discoverer.shadow = newTestShadowMirror(shadowClient, 8)
+	discoverer.shadow.tools = []string{"shop_orders_get*"}
+
+	require.NoError(t, discoverer.DiscoverServices(context.Background()))
+	for _, toolName := range []string{"shop_orders_get", "shop_orders_delete"} {
+		_, err := discoverer.InvokeMethodByTool(context.Background(), nil, toolName, "{}")
+		require.NoError(t, err)
+	}
+	require.NoError(t, discoverer.shadow.close())
+
+	// Only the listed tool reaches the shadow backend
+	assert.Equal(t, int64(1), discoverer.shadow.stats()["mirrored"])
+	shadowClient.AssertNumberOfCalls(t, "InvokeMethod", 1)
+	assert.Equal(t, "shop.Orders.Get", shadowClient.Calls[0].Arguments.Get(2).(types.MethodInfo).FullName)
+}
@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// backendTarget is a secondary gRPC backend (shadow or canary) with its own connection
+type backendTarget struct {
+	name        string
+	logger      *zap.Logger
+	connManager ConnectionManager
+
+	// Backend message types shared with the primary's discovery
+	registry *typeRegistry
+
+	mu     sync.RWMutex
+	client ReflectionClient
+}
+
+// newBackendTarget creates a secondary backend target sharing the primary connection settings
+func newBackendTarget(name string, host string, port int, baseConfig ConnectionManagerConfig, logger *zap.Logger) *backendTarget {
+	baseConfig.Host = host
+	baseConfig.Port = port
+
+	targetLogger := logger.Named(name)
+	return &backendTarget{
+		name:        name,
+		logger:      targetLogger,
+		connManager: NewConnectionManager(baseConfig, targetLogger),
+	}
+}
+
+// connect establishes the connection to the backend target
+func (t *backendTarget) connect(ctx context.Context) error {
+	if err := t.connManager.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to %s backend: %w", t.name, err)
+	}
+
+	conn := t.connManager.GetConnection()
+	if conn == nil {
+		return fmt.Errorf("connection manager returned nil %s connection", t.name)
+	}
+
+	t.mu.Lock()
+	t.client = newScopedReflectionClient(conn, t.logger, serviceScope{}, t.registry)
+	t.mu.Unlock()
+
+	t.logger.Info("Connected to backend target", zap.String("target", t.name))
+	return nil
+}
+
+// reflectionClient returns the target's client, or nil if not connected
+func (t *backendTarget) reflectionClient() ReflectionClient {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.client
+}
+
+// close closes the connection to the backend target
+func (t *backendTarget) close() error {
+	t.mu.Lock()
+	t.client = nil
+	t.mu.Unlock()
+
+	return t.connManager.Close()
+}
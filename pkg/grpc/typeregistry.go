@@ -0,0 +1,211 @@
+package grpc
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// The standard error details are linked so the details of a google.rpc.Status always resolve
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// typeRegistry resolves the message types declared in the backend's
+// discovered descriptors, so google.protobuf.Any fields holding backend types
+// (such as the response of a long-running operation) can be written as JSON.
+// Types linked into the gateway are resolved first.
+type typeRegistry struct {
+	messages atomic.Pointer[map[protoreflect.FullName]protoreflect.MessageDescriptor]
+	files    atomic.Pointer[protoregistry.Files]
+
+	// Serializes fetching types from the backend; missing holds the types it
+	// does not declare, so each is asked for once per discovery
+	mu      sync.Mutex
+	missing map[protoreflect.FullName]bool
+}
+
+// update replaces the registry with the messages of the files declaring the
+// methods' request and response types, and of the files they import
+func (r *typeRegistry) update(methods []types.MethodInfo) {
+	messages := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	files := &protoregistry.Files{}
+	seen := make(map[string]bool)
+
+	var addMessages func(descriptors protoreflect.MessageDescriptors)
+	addMessages = func(descriptors protoreflect.MessageDescriptors) {
+		for i := 0; i < descriptors.Len(); i++ {
+			message := descriptors.Get(i)
+			messages[message.FullName()] = message
+			addMessages(message.Messages())
+		}
+	}
+	var addFile func(file protoreflect.FileDescriptor)
+	addFile = func(file protoreflect.FileDescriptor) {
+		if file == nil || seen[file.Path()] {
+			return
+		}
+		seen[file.Path()] = true
+		// A file conflicting with one already registered is still searched for messages
+		_ = files.RegisterFile(file)
+		addMessages(file.Messages())
+		for i := 0; i < file.Imports().Len(); i++ {
+			addFile(file.Imports().Get(i).FileDescriptor)
+		}
+	}
+
+	for _, method := range methods {
+		if method.InputDescriptor != nil {
+			addFile(method.InputDescriptor.ParentFile())
+		}
+		if method.OutputDescriptor != nil {
+			addFile(method.OutputDescriptor.ParentFile())
+		}
+	}
+	r.mu.Lock()
+	r.messages.Store(&messages)
+	r.files.Store(files)
+	r.missing = nil
+	r.mu.Unlock()
+}
+
+// fetch returns a message type missing from the discovered files by asking
+// the backend for the file declaring it; the answer is kept until the next update
+func (r *typeRegistry) fetch(name protoreflect.FullName, lookup func(protoreflect.FullName) (protoreflect.FileDescriptor, error)) (protoreflect.MessageDescriptor, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if descriptor, ok := r.message(name); ok {
+		return descriptor, true
+	}
+	if r.missing[name] {
+		return nil, false
+	}
+
+	file, err := lookup(name)
+	if err != nil {
+		if r.missing == nil {
+			r.missing = make(map[protoreflect.FullName]bool)
+		}
+		r.missing[name] = true
+		return nil, false
+	}
+
+	// Copy on write, as lookups read the messages without the lock
+	messages := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	if current := r.messages.Load(); current != nil {
+		for fullName, descriptor := range *current {
+			messages[fullName] = descriptor
+		}
+	}
+	var addMessages func(descriptors protoreflect.MessageDescriptors)
+	addMessages = func(descriptors protoreflect.MessageDescriptors) {
+		for i := 0; i < descriptors.Len(); i++ {
+			message := descriptors.Get(i)
+			messages[message.FullName()] = message
+			addMessages(message.Messages())
+		}
+	}
+	addMessages(file.Messages())
+	r.messages.Store(&messages)
+
+	descriptor, ok := messages[name]
+	return descriptor, ok
+}
+
+// FindFileByPath implements protodesc.Resolver, so files fetched from the
+// backend can import the discovered files as well as the linked ones
+func (r *typeRegistry) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if files := r.files.Load(); files != nil {
+		if file, err := files.FindFileByPath(path); err == nil {
+			return file, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+// FindDescriptorByName implements protodesc.Resolver
+func (r *typeRegistry) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if files := r.files.Load(); files != nil {
+		if descriptor, err := files.FindDescriptorByName(name); err == nil {
+			return descriptor, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// message returns the discovered descriptor of a message type
+func (r *typeRegistry) message(name protoreflect.FullName) (protoreflect.MessageDescriptor, bool) {
+	if r == nil {
+		return nil, false
+	}
+	messages := r.messages.Load()
+	if messages == nil {
+		return nil, false
+	}
+	descriptor, ok := (*messages)[name]
+	return descriptor, ok
+}
+
+// FindMessageByName implements protoregistry.MessageTypeResolver
+func (r *typeRegistry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if err == nil {
+		return messageType, nil
+	}
+	if descriptor, ok := r.message(name); ok {
+		return dynamicpb.NewMessageType(descriptor), nil
+	}
+	return nil, err
+}
+
+// FindMessageByURL implements protoregistry.MessageTypeResolver
+func (r *typeRegistry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	return r.FindMessageByName(typeURLName(url))
+}
+
+// typeURLName returns the message name of an Any's type URL
+func typeURLName(url string) protoreflect.FullName {
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		url = url[i+1:]
+	}
+	return protoreflect.FullName(url)
+}
+
+// FindExtensionByName implements protoregistry.ExtensionTypeResolver
+func (r *typeRegistry) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+// FindExtensionByNumber implements protoregistry.ExtensionTypeResolver
+func (r *typeRegistry) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// backendTypes resolves the types of Any fields, such as the details of a
+// google.rpc.Status, like the registry, and asks the backend's reflection
+// service for the types its discovered files do not declare
+type backendTypes struct {
+	*typeRegistry
+	lookup func(protoreflect.FullName) (protoreflect.FileDescriptor, error)
+}
+
+// FindMessageByName implements protoregistry.MessageTypeResolver
+func (b backendTypes) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	messageType, err := b.typeRegistry.FindMessageByName(name)
+	if err == nil || b.typeRegistry == nil || b.lookup == nil {
+		return messageType, err
+	}
+	if descriptor, ok := b.fetch(name, b.lookup); ok {
+		return dynamicpb.NewMessageType(descriptor), nil
+	}
+	return nil, err
+}
+
+// FindMessageByURL implements protoregistry.MessageTypeResolver
+func (b backendTypes) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	return b.FindMessageByName(typeURLName(url))
+}
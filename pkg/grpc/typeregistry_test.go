@@ -0,0 +1,204 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	grpcLib "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// reportFiles returns a method whose response embeds google.rpc.Status, and a
+// file declaring a detail type the method's file does not import
+func reportFiles(t *testing.T) (types.MethodInfo, protoreflect.FileDescriptor) {
+	report, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("shop/report.proto"),
+		Package:    proto.String("shop"),
+		Dependency: []string{"google/rpc/status.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("ReportRequest")},
+			{
+				Name: proto.String("Report"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("failures"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+					TypeName: proto.String(".google.rpc.Status"),
+					JsonName: proto.String("failures"),
+				}},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("ReportService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Get"),
+				InputType:  proto.String(".shop.ReportRequest"),
+				OutputType: proto.String(".shop.Report"),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	details, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop/errors.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("StockDetail"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("sku"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				JsonName: proto.String("sku"),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	methodDesc := report.Services().Get(0).Methods().Get(0)
+	method := types.MethodInfo{
+		Name:             "Get",
+		FullName:         "shop.ReportService.Get",
+		ServiceName:      "shop.ReportService",
+		InputType:        "shop.ReportRequest",
+		OutputType:       "shop.Report",
+		InputDescriptor:  methodDesc.Input(),
+		OutputDescriptor: methodDesc.Output(),
+	}
+	method.ToolName = method.GenerateToolName()
+	return method, details
+}
+
+// failedReport returns a report holding a failure with a backend and a standard detail
+func failedReport(t *testing.T, method types.MethodInfo) proto.Message {
+	stock, err := anypb.New(&errdetails.ErrorInfo{Reason: "OUT_OF_STOCK", Domain: "shop.example.com"})
+	require.NoError(t, err)
+	failure, err := proto.Marshal(&rpcstatus.Status{
+		Code:    9,
+		Message: "out of stock",
+		Details: []*anypb.Any{
+			{
+				TypeUrl: "type.googleapis.com/shop.StockDetail",
+				Value:   protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "A1"),
+			},
+			stock,
+		},
+	})
+	require.NoError(t, err)
+
+	report := dynamicpb.NewMessage(method.OutputDescriptor)
+	wire := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), failure)
+	require.NoError(t, proto.Unmarshal(wire, report))
+	return report
+}
+
+const expandedReport = `{"failures": [{
+	"code": 9,
+	"message": "out of stock",
+	"details": [
+		{"@type": "type.googleapis.com/shop.StockDetail", "sku": "A1"},
+		{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "OUT_OF_STOCK", "domain": "shop.example.com"}
+	]
+}]}`
+
+func TestBackendTypes_StatusDetails(t *testing.T) {
+	method, details := reportFiles(t)
+	report := failedReport(t, method)
+
+	registry := &typeRegistry{}
+	registry.update([]types.MethodInfo{method})
+
+	// The detail's file is not imported by the method's file
+	_, err := marshalJSON(report, registry)
+	require.Error(t, err)
+
+	lookups := 0
+	resolver := backendTypes{typeRegistry: registry, lookup: func(name protoreflect.FullName) (protoreflect.FileDescriptor, error) {
+		lookups++
+		if details.Messages().ByName(name.Name()) == nil {
+			return nil, fmt.Errorf("symbol not found: %s", name)
+		}
+		return details, nil
+	}}
+
+	out, err := marshalJSON(report, resolver)
+	require.NoError(t, err)
+	assert.JSONEq(t, expandedReport, out)
+
+	// Fetched and missing types are asked for once per discovery
+	out, err = marshalJSON(report, resolver)
+	require.NoError(t, err)
+	assert.JSONEq(t, expandedReport, out)
+	assert.Equal(t, 1, lookups)
+
+	_, err = resolver.FindMessageByURL("type.googleapis.com/shop.Missing")
+	require.ErrorIs(t, err, protoregistry.NotFound)
+	_, err = resolver.FindMessageByURL("type.googleapis.com/shop.Missing")
+	require.ErrorIs(t, err, protoregistry.NotFound)
+	assert.Equal(t, 2, lookups)
+
+	registry.update([]types.MethodInfo{method})
+	_, err = marshalJSON(report, resolver)
+	require.NoError(t, err)
+	assert.Equal(t, 3, lookups)
+}
+
+func TestReflectionClient_FetchesStatusDetailTypes(t *testing.T) {
+	method, details := reportFiles(t)
+
+	files := &protoregistry.Files{}
+	for _, file := range []protoreflect.FileDescriptor{
+		anypb.File_google_protobuf_any_proto,
+		rpcstatus.File_google_rpc_status_proto,
+		method.OutputDescriptor.ParentFile(),
+		details,
+	} {
+		require.NoError(t, files.RegisterFile(file))
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpcLib.NewServer()
+	grpc_reflection_v1alpha.RegisterServerReflectionServer(server, reflection.NewServer(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files,
+	}))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpcLib.NewClient(listener.Addr().String(), grpcLib.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	registry := &typeRegistry{}
+	registry.update([]types.MethodInfo{method})
+	client := newScopedReflectionClient(conn, zap.NewNop(), serviceScope{}, registry)
+
+	out, err := marshalJSON(failedReport(t, method), client.outputTypes(context.Background()))
+	require.NoError(t, err)
+	assert.JSONEq(t, expandedReport, out)
+
+	// Without a registry only linked types resolve
+	assert.Nil(t, newScopedReflectionClient(conn, zap.NewNop(), serviceScope{}, nil).outputTypes(context.Background()))
+}
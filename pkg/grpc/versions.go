@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+// versionPattern matches package version components such as v1, v2beta1 or v1alpha
+var versionPattern = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d*))?$`)
+
+// packageVersion is a parsed package version component
+type packageVersion struct {
+	label     string
+	major     int
+	stability int // 0 alpha, 1 beta, 2 stable
+	minor     int
+}
+
+// parseVersion parses a package component as a version
+func parseVersion(component string) (packageVersion, bool) {
+	match := versionPattern.FindStringSubmatch(component)
+	if match == nil {
+		return packageVersion{}, false
+	}
+
+	version := packageVersion{label: component, stability: 2}
+	version.major, _ = strconv.Atoi(match[1])
+	switch match[2] {
+	case "alpha":
+		version.stability = 0
+	case "beta":
+		version.stability = 1
+	}
+	if match[3] != "" {
+		version.minor, _ = strconv.Atoi(match[3])
+	}
+	return version, true
+}
+
+// newer reports whether v is a later version than other
+func (v packageVersion) newer(other packageVersion) bool {
+	if v.major != other.major {
+		return v.major > other.major
+	}
+	if v.stability != other.stability {
+		return v.stability > other.stability
+	}
+	return v.minor > other.minor
+}
+
+// splitVersion removes the package version from a dotted service name
+// (e.g. "shop.v2.OrderService" -> "shop.OrderService", v2)
+func splitVersion(serviceName string) (string, packageVersion, bool) {
+	parts := strings.Split(serviceName, ".")
+
+	// The last component is the service itself, so search the package only
+	for i := len(parts) - 2; i >= 0; i-- {
+		if version, ok := parseVersion(parts[i]); ok {
+			unversioned := append(append([]string{}, parts[:i]...), parts[i+1:]...)
+			return strings.Join(unversioned, "."), version, true
+		}
+	}
+	return serviceName, packageVersion{}, false
+}
+
+// versionResolver decides how methods served in several package versions are exposed
+type versionResolver struct {
+	collapse  bool
+	preferred map[string]string
+	fallback  bool
+}
+
+// newVersionResolver creates a version resolver from discovery configuration
+func newVersionResolver(versionsConfig config.VersionsConfig) versionResolver {
+	return versionResolver{
+		collapse:  versionsConfig.Mode == "collapse",
+		preferred: versionsConfig.Preferred,
+		fallback:  versionsConfig.Fallback,
+	}
+}
+
+// apply labels each version of a multi-version service, or collapses the versions
+// of each method into one unversioned tool bound to the preferred version.
+// Services served in a single version are left as they are.
+func (v versionResolver) apply(methods []types.MethodInfo) []types.MethodInfo {
+	versionsByService := make(map[string]map[string]packageVersion)
+	for _, method := range methods {
+		service, version, ok := splitVersion(method.ServiceName)
+		if !ok {
+			continue
+		}
+		if versionsByService[service] == nil {
+			versionsByService[service] = make(map[string]packageVersion)
+		}
+		versionsByService[service][version.label] = version
+	}
+
+	multiVersion := func(method types.MethodInfo) (string, packageVersion, bool) {
+		service, version, ok := splitVersion(method.ServiceName)
+		return service, version, ok && len(versionsByService[service]) > 1
+	}
+
+	if !v.collapse {
+		for i, method := range methods {
+			if _, version, ok := multiVersion(method); ok {
+				methods[i].Version = version.label
+			}
+		}
+		return methods
+	}
+
+	// Group the versions of each method, keeping discovery order
+	var keys []string
+	candidates := make(map[string][]types.MethodInfo)
+	versions := make(map[string]packageVersion)
+	collapsed := make([]types.MethodInfo, 0, len(methods))
+	for _, method := range methods {
+		service, version, ok := multiVersion(method)
+		if !ok {
+			collapsed = append(collapsed, method)
+			continue
+		}
+
+		key := service + "." + method.Name
+		if _, seen := candidates[key]; !seen {
+			keys = append(keys, key)
+		}
+		candidates[key] = append(candidates[key], method)
+		versions[method.FullName] = version
+	}
+
+	for _, key := range keys {
+		methods := candidates[key]
+		service, _, _ := splitVersion(methods[0].ServiceName)
+		preferred := v.preferred[service]
+
+		// Preferred version first, then stable before pre-release, newest first
+		sort.SliceStable(methods, func(i, j int) bool {
+			a, b := versions[methods[i].FullName], versions[methods[j].FullName]
+			if (a.label == preferred) != (b.label == preferred) {
+				return a.label == preferred
+			}
+			if (a.stability == 2) != (b.stability == 2) {
+				return a.stability == 2
+			}
+			return a.newer(b)
+		})
+
+		chosen := methods[0]
+		unversioned := chosen
+		unversioned.ServiceName = service
+		chosen.ToolName = unversioned.GenerateToolName()
+		if v.fallback {
+			chosen.Fallbacks = methods[1:]
+		}
+		collapsed = append(collapsed, chosen)
+	}
+
+	return collapsed
+}
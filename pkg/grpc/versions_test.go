@@ -0,0 +1,145 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func versionedMethod(service, name string) types.MethodInfo {
+	method := types.MethodInfo{
+		Name:        name,
+		FullName:    service + "." + name,
+		ServiceName: service,
+	}
+	method.ToolName = method.GenerateToolName()
+	return method
+}
+
+func TestSplitVersion(t *testing.T) {
+	tests := []struct {
+		service     string
+		unversioned string
+		version     string
+	}{
+		{"shop.v1.OrderService", "shop.OrderService", "v1"},
+		{"com.acme.shop.v2beta1.OrderService", "com.acme.shop.OrderService", "v2beta1"},
+		{"v3.OrderService", "OrderService", "v3"},
+		{"shop.OrderService", "shop.OrderService", ""},
+		{"shop.vnext.OrderService", "shop.vnext.OrderService", ""},
+		{"shop.v1", "shop.v1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			unversioned, version, _ := splitVersion(tt.service)
+			assert.Equal(t, tt.unversioned, unversioned)
+			assert.Equal(t, tt.version, version.label)
+		})
+	}
+}
+
+func TestPackageVersion_Newer(t *testing.T) {
+	parse := func(label string) packageVersion {
+		version, ok := parseVersion(label)
+		require.True(t, ok, label)
+		return version
+	}
+
+	assert.True(t, parse("v2").newer(parse("v1")))
+	assert.True(t, parse("v2").newer(parse("v2beta1")))
+	assert.True(t, parse("v2beta1").newer(parse("v2alpha3")))
+	assert.True(t, parse("v2beta2").newer(parse("v2beta1")))
+	assert.False(t, parse("v1").newer(parse("v1")))
+}
+
+func TestVersionResolver(t *testing.T) {
+	methods := func() []types.MethodInfo {
+		return []types.MethodInfo{
+			versionedMethod("shop.v1.OrderService", "Place"),
+			versionedMethod("shop.v1.OrderService", "Legacy"),
+			versionedMethod("shop.v2.OrderService", "Place"),
+			versionedMethod("shop.v3alpha.OrderService", "Place"),
+			versionedMethod("billing.v1.InvoiceService", "Get"),
+			versionedMethod("hello.HelloService", "SayHello"),
+		}
+	}
+	byTool := func(methods []types.MethodInfo) map[string]types.MethodInfo {
+		tools := make(map[string]types.MethodInfo)
+		for _, method := range methods {
+			tools[method.ToolName] = method
+		}
+		return tools
+	}
+
+	t.Run("All_labels_versions", func(t *testing.T) {
+		tools := byTool(newVersionResolver(config.VersionsConfig{}).apply(methods()))
+
+		require.Len(t, tools, 6)
+		assert.Equal(t, "v1", tools["shop_v1_orderservice_place"].Version)
+		assert.Equal(t, "v2", tools["shop_v2_orderservice_place"].Version)
+		assert.Equal(t, "v3alpha", tools["shop_v3alpha_orderservice_place"].Version)
+		assert.Empty(t, tools["billing_v1_invoiceservice_get"].Version, "single version is not labeled")
+		assert.Empty(t, tools["hello_helloservice_sayhello"].Version)
+	})
+
+	t.Run("Collapse_prefers_newest_stable", func(t *testing.T) {
+		tools := byTool(newVersionResolver(config.VersionsConfig{Mode: "collapse", Fallback: true}).apply(methods()))
+
+		require.Len(t, tools, 4)
+		place := tools["shop_orderservice_place"]
+		assert.Equal(t, "shop.v2.OrderService.Place", place.FullName)
+		require.Len(t, place.Fallbacks, 2)
+		assert.Equal(t, "shop.v1.OrderService.Place", place.Fallbacks[0].FullName)
+		assert.Equal(t, "shop.v3alpha.OrderService.Place", place.Fallbacks[1].FullName)
+
+		assert.Equal(t, "shop.v1.OrderService.Legacy", tools["shop_orderservice_legacy"].FullName)
+		assert.Contains(t, tools, "billing_v1_invoiceservice_get")
+		assert.Contains(t, tools, "hello_helloservice_sayhello")
+	})
+
+	t.Run("Collapse_preferred_version_without_fallback", func(t *testing.T) {
+		resolver := newVersionResolver(config.VersionsConfig{
+			Mode:      "collapse",
+			Preferred: map[string]string{"shop.OrderService": "v1"},
+		})
+		tools := byTool(resolver.apply(methods()))
+
+		place := tools["shop_orderservice_place"]
+		assert.Equal(t, "shop.v1.OrderService.Place", place.FullName)
+		assert.Empty(t, place.Fallbacks)
+	})
+}
+
+func TestServiceDiscoverer_InvokeVersionFallback(t *testing.T) {
+	mockConnMgr := &mockConnectionManager{}
+	discoverer := newServiceDiscovererWithConnManager(mockConnMgr, zap.NewNop())
+	mockReflClient := &mockReflectionClient{}
+	discoverer.reflectionClient = mockReflClient
+
+	v2 := versionedMethod("shop.v2.OrderService", "Place")
+	v1 := versionedMethod("shop.v1.OrderService", "Place")
+	collapsed := v2
+	collapsed.ToolName = "shop_orderservice_place"
+	collapsed.Fallbacks = []types.MethodInfo{v1}
+	tools := map[string]types.MethodInfo{collapsed.ToolName: collapsed}
+	discoverer.tools.Store(&tools)
+
+	mockReflClient.On("InvokeMethod", mock.Anything, mock.Anything, collapsed, `{}`).
+		Return("", status.Error(codes.Unimplemented, "unknown service shop.v2.OrderService"))
+	mockReflClient.On("InvokeMethod", mock.Anything, mock.Anything, v1, `{}`).
+		Return(`{"id":"1"}`, nil)
+
+	result, err := discoverer.InvokeMethodByTool(context.Background(), nil, collapsed.ToolName, `{}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"1"}`, result)
+	mockReflClient.AssertExpectations(t)
+}
@@ -77,6 +77,45 @@ func (f *Filter) FilterHeaders(headers map[string]string) map[string]string {
 	return filtered
 }
 
+// AllowsCallHeader determines if a client may set a header for a single call
+func (f *Filter) AllowsCallHeader(headerName string) bool {
+	if !f.config.Enabled {
+		return false
+	}
+
+	name := headerName
+	if !f.config.CaseSensitive {
+		name = strings.ToLower(headerName)
+	}
+
+	// Blocked headers take precedence here too
+	for _, blocked := range f.config.BlockedHeaders {
+		blockedName := blocked
+		if !f.config.CaseSensitive {
+			blockedName = strings.ToLower(blocked)
+		}
+		if name == blockedName {
+			return false
+		}
+	}
+
+	for _, allowed := range f.config.CallHeaders {
+		allowedName := allowed
+		if !f.config.CaseSensitive {
+			allowedName = strings.ToLower(allowed)
+		}
+		if name == allowedName {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCallHeaders returns the list of headers clients may set per call
+func (f *Filter) GetCallHeaders() []string {
+	return f.config.CallHeaders
+}
+
 // GetAllowedHeaders returns the list of allowed headers
 func (f *Filter) GetAllowedHeaders() []string {
 	return f.config.AllowedHeaders
@@ -223,6 +223,24 @@ func TestHeaderFilter_GetMethods(t *testing.T) {
 	assert.Equal(t, []string{"cookie", "set-cookie"}, filter.GetBlockedHeaders())
 }
 
+func TestHeaderFilter_AllowsCallHeader(t *testing.T) {
+	filter := NewFilter(config.HeaderForwardingConfig{
+		Enabled:        true,
+		AllowedHeaders: []string{"authorization"},
+		BlockedHeaders: []string{"cookie"},
+		CallHeaders:    []string{"X-Tenant-Id", "x-trace-id", "cookie"},
+	})
+
+	assert.True(t, filter.AllowsCallHeader("x-tenant-id"))
+	assert.True(t, filter.AllowsCallHeader("X-Trace-Id"))
+	assert.False(t, filter.AllowsCallHeader("authorization")) // forwarded from the session only
+	assert.False(t, filter.AllowsCallHeader("cookie"))        // blocked takes precedence
+	assert.Equal(t, []string{"X-Tenant-Id", "x-trace-id", "cookie"}, filter.GetCallHeaders())
+
+	disabled := NewFilter(config.HeaderForwardingConfig{CallHeaders: []string{"x-tenant-id"}})
+	assert.False(t, disabled.AllowsCallHeader("x-tenant-id"))
+}
+
 func TestDefaultConfiguration(t *testing.T) {
 	// Test that the default configuration is sensible
 	defaultConfig := config.Default()
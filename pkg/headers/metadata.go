@@ -0,0 +1,206 @@
+package headers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// metadataEntryOverhead is the size HTTP/2 counts for every header on top of
+// its name and value (RFC 7540, section 6.5.2)
+const metadataEntryOverhead = 32
+
+// connectionHeaders are HTTP/1 connection-specific headers, which HTTP/2
+// peers reject as malformed
+var connectionHeaders = map[string]bool{
+	"connection":        true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"host":              true,
+}
+
+// Problem describes a forwarded header that was dropped or rewritten to keep
+// the outgoing metadata valid
+type Problem struct {
+	Header  string // header name as forwarded
+	Reason  string // what was wrong with it
+	Dropped bool   // false when the value was encoded instead
+}
+
+// Sanitize turns forwarded headers into valid gRPC metadata. Headers
+// configured as binary are renamed with a -bin suffix. Names are checked in
+// lowercase, as gRPC sends them, and headers with names gRPC does not allow
+// are dropped. Values of -bin headers must be base64; values of
+// other headers must be printable ASCII and are otherwise dropped or
+// percent-encoded as configured. When the metadata exceeds the configured
+// size, the largest headers are dropped until the rest fits.
+func (f *Filter) Sanitize(headers map[string]string) (map[string]string, []Problem) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	metadata := make(map[string]string, len(headers))
+	var problems []Problem
+	for _, name := range names {
+		key, value := strings.ToLower(name), headers[name]
+		forwardedName := name
+		if f.IsBinary(name) {
+			decoded, err := decodeAnyBase64(value)
+			if err != nil {
+				problems = append(problems, Problem{Header: name, Reason: "binary value is not base64", Dropped: true})
+				continue
+			}
+			if !strings.HasSuffix(key, "-bin") {
+				forwardedName, key = name+"-bin", key+"-bin"
+			}
+			value = base64.StdEncoding.EncodeToString(decoded)
+		}
+		if reason := invalidMetadataKey(key); reason != "" {
+			problems = append(problems, Problem{Header: name, Reason: reason, Dropped: true})
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(key, "-bin"):
+			if _, err := DecodeBinary(value); err != nil {
+				problems = append(problems, Problem{Header: name, Reason: "binary value is not base64", Dropped: true})
+				continue
+			}
+		case !isPrintableASCII(value):
+			if f.config.InvalidValues != "encode" {
+				problems = append(problems, Problem{Header: name, Reason: "value is not printable ASCII", Dropped: true})
+				continue
+			}
+			value = percentEncode(value)
+			problems = append(problems, Problem{Header: name, Reason: "value is not printable ASCII"})
+		}
+		metadata[forwardedName] = value
+	}
+
+	return metadata, append(problems, f.limitMetadata(metadata)...)
+}
+
+// limitMetadata drops the largest entries until the metadata fits the configured size
+func (f *Filter) limitMetadata(metadata map[string]string) []Problem {
+	limit := f.config.MaxMetadataBytes
+	if limit <= 0 {
+		return nil
+	}
+
+	total := 0
+	for name, value := range metadata {
+		total += metadataEntrySize(name, value)
+	}
+
+	var problems []Problem
+	for total > limit {
+		largest, largestSize := "", 0
+		for name, value := range metadata {
+			size := metadataEntrySize(name, value)
+			if size > largestSize || (size == largestSize && name < largest) {
+				largest, largestSize = name, size
+			}
+		}
+		delete(metadata, largest)
+		total -= largestSize
+		problems = append(problems, Problem{
+			Header:  largest,
+			Reason:  fmt.Sprintf("metadata exceeds %d bytes (entry of %d bytes)", limit, largestSize),
+			Dropped: true,
+		})
+	}
+	return problems
+}
+
+// metadataEntrySize returns the size HTTP/2 counts for a header
+func metadataEntrySize(name, value string) int {
+	return len(name) + len(value) + metadataEntryOverhead
+}
+
+// invalidMetadataKey explains why a lowercased header name cannot be sent as
+// gRPC metadata, or returns "" when it can
+func invalidMetadataKey(key string) string {
+	if key == "" {
+		return "name is empty"
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' && c != '.' {
+			return "name has characters outside [0-9a-z-_.]"
+		}
+	}
+	if connectionHeaders[key] {
+		return "connection-specific headers are not allowed in HTTP/2"
+	}
+	return ""
+}
+
+// IsBinary reports whether a header is configured as binary metadata
+func (f *Filter) IsBinary(headerName string) bool {
+	name := headerName
+	if !f.config.CaseSensitive {
+		name = strings.ToLower(headerName)
+	}
+	for _, binary := range f.config.BinaryHeaders {
+		binaryName := binary
+		if !f.config.CaseSensitive {
+			binaryName = strings.ToLower(binary)
+		}
+		if name == binaryName {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeAnyBase64 decodes a value given by a client in standard or URL-safe
+// base64, with or without padding
+func decodeAnyBase64(value string) ([]byte, error) {
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(value, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(value, "=") && len(value)%4 != 0 {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	return encoding.DecodeString(value)
+}
+
+// DecodeBinary decodes the value of a -bin header, which gRPC sends as
+// base64 with or without padding
+func DecodeBinary(value string) ([]byte, error) {
+	if len(value)%4 == 0 {
+		return base64.StdEncoding.DecodeString(value)
+	}
+	return base64.RawStdEncoding.DecodeString(value)
+}
+
+// isPrintableASCII reports whether a value is allowed in text metadata
+func isPrintableASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x20 || value[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// percentEncode escapes the bytes of a value outside printable ASCII, and
+// the percent sign itself, as %XX
+func percentEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
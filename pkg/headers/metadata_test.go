@@ -0,0 +1,141 @@
+package headers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Sanitize(t *testing.T) {
+	tests := []struct {
+		name          string
+		invalidValues string
+		headers       map[string]string
+		expected      map[string]string
+		dropped       []string
+		encoded       []string
+	}{
+		{
+			name:     "Valid_headers_are_kept_as_given",
+			headers:  map[string]string{"X-Trace-Id": "abc", "x-token-bin": "AAEC", "x-raw-bin": "AAECAw"},
+			expected: map[string]string{"X-Trace-Id": "abc", "x-token-bin": "AAEC", "x-raw-bin": "AAECAw"},
+		},
+		{
+			name:     "Invalid_names_are_dropped",
+			headers:  map[string]string{"x trace": "a", "x:trace": "b", "": "c", "Connection": "close", "x-ok": "d"},
+			expected: map[string]string{"x-ok": "d"},
+			dropped:  []string{"", "Connection", "x trace", "x:trace"},
+		},
+		{
+			name:     "Non_base64_binary_values_are_dropped",
+			headers:  map[string]string{"x-token-bin": "not base64!"},
+			expected: map[string]string{},
+			dropped:  []string{"x-token-bin"},
+		},
+		{
+			name:     "Non_ASCII_values_are_dropped",
+			headers:  map[string]string{"x-user": "José", "x-note": "a\nb"},
+			expected: map[string]string{},
+			dropped:  []string{"x-note", "x-user"},
+		},
+		{
+			name:          "Non_ASCII_values_are_encoded",
+			invalidValues: "encode",
+			headers:       map[string]string{"x-user": "José 100%", "x-ok": "plain 100%"},
+			expected:      map[string]string{"x-user": "Jos%C3%A9 100%25", "x-ok": "plain 100%"},
+			encoded:       []string{"x-user"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFilter(config.HeaderForwardingConfig{Enabled: true, InvalidValues: tt.invalidValues})
+			metadata, problems := filter.Sanitize(tt.headers)
+			assert.Equal(t, tt.expected, metadata)
+
+			var dropped, encoded []string
+			for _, problem := range problems {
+				assert.NotEmpty(t, problem.Reason)
+				if problem.Dropped {
+					dropped = append(dropped, problem.Header)
+				} else {
+					encoded = append(encoded, problem.Header)
+				}
+			}
+			assert.Equal(t, tt.dropped, dropped)
+			assert.Equal(t, tt.encoded, encoded)
+		})
+	}
+}
+
+func TestFilter_SanitizeSizeLimit(t *testing.T) {
+	filter := NewFilter(config.HeaderForwardingConfig{Enabled: true, MaxMetadataBytes: 200})
+	headers := map[string]string{
+		"x-small":  "a",
+		"x-medium": strings.Repeat("b", 40),
+		"x-large":  strings.Repeat("c", 100),
+	}
+
+	metadata, problems := filter.Sanitize(headers)
+	assert.Equal(t, map[string]string{"x-small": "a", "x-medium": strings.Repeat("b", 40)}, metadata)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "x-large", problems[0].Header)
+	assert.True(t, problems[0].Dropped)
+	assert.Contains(t, problems[0].Reason, "exceeds 200 bytes")
+
+	// Without a cap everything valid is kept
+	metadata, problems = NewFilter(config.HeaderForwardingConfig{Enabled: true}).Sanitize(headers)
+	assert.Len(t, metadata, 3)
+	assert.Empty(t, problems)
+}
+
+func TestFilter_SanitizeBinaryHeaders(t *testing.T) {
+	filter := NewFilter(config.HeaderForwardingConfig{
+		Enabled:       true,
+		BinaryHeaders: []string{"X-Trace-Context", "x-token-bin"},
+	})
+	assert.True(t, filter.IsBinary("x-trace-context"))
+	assert.False(t, filter.IsBinary("x-trace-id"))
+
+	metadata, problems := filter.Sanitize(map[string]string{
+		"X-Trace-Context": "-_8",    // URL-safe, unpadded
+		"x-token-bin":     "AAECAw", // already suffixed
+		"x-trace-id":      "-_8",    // text header, kept as is
+		"X-Plain":         "AAEC",   // not configured as binary
+	})
+	assert.Empty(t, problems)
+	assert.Equal(t, map[string]string{
+		"X-Trace-Context-bin": "+/8=",
+		"x-token-bin":         "AAECAw==",
+		"x-trace-id":          "-_8",
+		"X-Plain":             "AAEC",
+	}, metadata)
+
+	metadata, problems = filter.Sanitize(map[string]string{"x-trace-context": "not base64!"})
+	assert.Empty(t, metadata)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "x-trace-context", problems[0].Header)
+	assert.True(t, problems[0].Dropped)
+
+	// Case-sensitive matching only renames exact names
+	filter = NewFilter(config.HeaderForwardingConfig{
+		Enabled:       true,
+		CaseSensitive: true,
+		BinaryHeaders: []string{"X-Trace-Context"},
+	})
+	assert.True(t, filter.IsBinary("X-Trace-Context"))
+	assert.False(t, filter.IsBinary("x-trace-context"))
+}
+
+func TestDecodeBinary(t *testing.T) {
+	for _, value := range []string{"AAECAw==", "AAECAw"} {
+		decoded, err := DecodeBinary(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, []byte{0, 1, 2, 3}, decoded, value)
+	}
+	_, err := DecodeBinary("***")
+	assert.Error(t, err)
+}
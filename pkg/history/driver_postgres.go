@@ -0,0 +1,6 @@
+//go:build postgres
+
+package history
+
+// Link the pgx Postgres driver, registered as "pgx"
+import _ "github.com/jackc/pgx/v5/stdlib"
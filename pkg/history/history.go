@@ -0,0 +1,262 @@
+// Package history keeps a persistent, redacted record of tool calls that
+// operators can query and sessions can consult for their own calls.
+package history
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Defaults and bounds of a query
+const (
+	DefaultLimit = 20
+	MaxLimit     = 500
+)
+
+// pruneInterval is how often calls past the retention are deleted
+const pruneInterval = time.Hour
+
+// writeTimeout bounds each write and prune
+const writeTimeout = 10 * time.Second
+
+// Record is a completed tool call
+type Record struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"sessionId"`
+	RequestID string    `json:"requestId,omitempty"`
+	Tool      string    `json:"tool"`
+	Status    string    `json:"status"`
+	Code      string    `json:"code,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+
+	// Redacted JSON arguments and result text, cut to the configured limits
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+}
+
+// Query selects records, most recent first; zero fields match everything
+type Query struct {
+	SessionID string
+	Tool      string
+	Status    string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// Store persists records
+type Store interface {
+	// Append writes the records
+	Append(ctx context.Context, records []Record) error
+
+	// Query returns the matching records, most recent first
+	Query(ctx context.Context, query Query) ([]Record, error)
+
+	// Prune deletes the records older than the given time, returning how many
+	Prune(ctx context.Context, before time.Time) (int64, error)
+
+	// Close releases the store
+	Close() error
+}
+
+// Open opens the configured store
+func Open(historyConfig config.HistoryConfig) (Store, error) {
+	switch historyConfig.Driver {
+	case "memory":
+		return newMemoryStore(historyConfig.MaxEntries), nil
+	case "sqlite", "postgres":
+		return openSQLStore(historyConfig.Driver, historyConfig.DSN)
+	default:
+		return nil, fmt.Errorf("unsupported history driver %q", historyConfig.Driver)
+	}
+}
+
+// Stats counts the records of the recorder
+type Stats struct {
+	Driver   string `json:"driver"`
+	Recorded int64  `json:"recorded"`
+	Failed   int64  `json:"failed"`
+	Dropped  int64  `json:"dropped"`
+	Pruned   int64  `json:"pruned"`
+	Queued   int    `json:"queued"`
+}
+
+// Recorder redacts tool calls and writes them to the store in the background
+type Recorder struct {
+	config   config.HistoryConfig
+	store    Store
+	logger   *zap.Logger
+	redactor *redactor
+	queue    chan Record
+
+	recorded atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+	pruned   atomic.Int64
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewRecorder opens the configured store and starts recording, or returns
+// nil if the history is disabled
+func NewRecorder(historyConfig config.HistoryConfig, logger *zap.Logger) (*Recorder, error) {
+	if !historyConfig.Enabled {
+		return nil, nil
+	}
+	store, err := Open(historyConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newRecorder(historyConfig, store, logger), nil
+}
+
+// newRecorder starts recording to the given store
+func newRecorder(historyConfig config.HistoryConfig, store Store, logger *zap.Logger) *Recorder {
+	r := &Recorder{
+		config:   historyConfig,
+		store:    store,
+		logger:   logger.With(zap.String("history", historyConfig.Driver)),
+		redactor: newRedactor(historyConfig.RedactFields),
+		queue:    make(chan Record, historyConfig.QueueSize),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(r.done)
+		r.run()
+	}()
+	return r
+}
+
+// Record redacts a call and queues it without blocking; calls that do not fit
+// in a full queue are dropped
+func (r *Recorder) Record(record Record) {
+	if r == nil {
+		return
+	}
+	select {
+	case <-r.closing:
+		return
+	default:
+	}
+
+	record.Arguments = cut(r.redactor.redact(record.Arguments), r.config.MaxArgumentBytes)
+	record.Result = cut(r.redactor.redact(record.Result), r.config.MaxResultBytes)
+
+	select {
+	case r.queue <- record:
+	default:
+		if r.dropped.Add(1) == 1 {
+			r.logger.Warn("History queue full, dropping calls")
+		}
+	}
+}
+
+// Query returns the matching calls, most recent first
+func (r *Recorder) Query(ctx context.Context, query Query) ([]Record, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultLimit
+	}
+	query.Limit = min(query.Limit, MaxLimit)
+	return r.store.Query(ctx, query)
+}
+
+// Stats returns the recorder counters
+func (r *Recorder) Stats() *Stats {
+	if r == nil {
+		return nil
+	}
+	return &Stats{
+		Driver:   r.config.Driver,
+		Recorded: r.recorded.Load(),
+		Failed:   r.failed.Load(),
+		Dropped:  r.dropped.Load(),
+		Pruned:   r.pruned.Load(),
+		Queued:   len(r.queue),
+	}
+}
+
+// Close writes the queued calls and closes the store
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.closeOnce.Do(func() { close(r.closing) })
+	<-r.done
+	return r.store.Close()
+}
+
+// run writes queued calls as they arrive and prunes expired ones periodically
+func (r *Recorder) run() {
+	r.prune()
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record := <-r.queue:
+			r.write(r.collect(record))
+		case <-ticker.C:
+			r.prune()
+		case <-r.closing:
+			for {
+				select {
+				case record := <-r.queue:
+					r.write(r.collect(record))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// collect gathers the calls already queued behind the first one, so bursts
+// are written together
+func (r *Recorder) collect(first Record) []Record {
+	records := []Record{first}
+	for len(records) < 100 {
+		select {
+		case record := <-r.queue:
+			records = append(records, record)
+		default:
+			return records
+		}
+	}
+	return records
+}
+
+// write appends calls to the store
+func (r *Recorder) write(records []Record) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := r.store.Append(ctx, records); err != nil {
+		r.failed.Add(int64(len(records)))
+		r.logger.Warn("Failed to record tool calls", zap.Int("calls", len(records)), zap.Error(err))
+		return
+	}
+	r.recorded.Add(int64(len(records)))
+}
+
+// prune deletes the calls past the retention
+func (r *Recorder) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	deleted, err := r.store.Prune(ctx, time.Now().Add(-r.config.Retention))
+	if err != nil {
+		r.logger.Warn("Failed to prune the call history", zap.Error(err))
+		return
+	}
+	r.pruned.Add(deleted)
+}
@@ -0,0 +1,143 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRedactor(t *testing.T) {
+	r := newRedactor([]string{"password", "apiKey"})
+
+	assert.Equal(t,
+		`{"amount":12.50,"card":{"number":"4111","password":"[REDACTED]"},"items":[{"APIKEY":"[REDACTED]"}],"user":"ana"}`,
+		r.redact(`{"user":"ana","amount":12.50,"card":{"number":"4111","password":"hunter2"},"items":[{"APIKEY":"k-1"}]}`))
+
+	// Text that is not a JSON document is kept
+	assert.Equal(t, "password=hunter2", r.redact("password=hunter2"))
+	assert.Equal(t, `{"a":1} {"b":2}`, r.redact(`{"a":1} {"b":2}`))
+	assert.Equal(t, `{"html":"<b>&</b>"}`, r.redact(`{"html":"<b>&</b>"}`))
+}
+
+func TestCut(t *testing.T) {
+	assert.Equal(t, `{"a":"é"}`, cut(`{"a":"é"}`, 100))
+	assert.Equal(t, `{"a":"`, cut(`{"a":"é"}`, 7))
+	assert.Empty(t, cut(`{}`, -1))
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1_700_000_000, 0)
+	store := newMemoryStore(3)
+
+	require.NoError(t, store.Append(ctx, []Record{
+		{ID: "1", Time: start, SessionID: "s1", Tool: "shop_orders_get", Status: "success"},
+		{ID: "2", Time: start.Add(time.Minute), SessionID: "s2", Tool: "shop_orders_get", Status: "success"},
+		{ID: "3", Time: start.Add(2 * time.Minute), SessionID: "s1", Tool: "shop_orders_cancel", Status: "error"},
+		{ID: "4", Time: start.Add(3 * time.Minute), SessionID: "s1", Tool: "shop_orders_get", Status: "success"},
+	}))
+
+	ids := func(records []Record) []string {
+		var ids []string
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		return ids
+	}
+
+	// The oldest records are dropped beyond the limit
+	all, err := store.Query(ctx, Query{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2"}, ids(all))
+
+	session, err := store.Query(ctx, Query{SessionID: "s1", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3"}, ids(session))
+
+	filtered, err := store.Query(ctx, Query{Tool: "shop_orders_get", Status: "success", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4"}, ids(filtered))
+
+	window, err := store.Query(ctx, Query{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute), Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "2"}, ids(window))
+
+	deleted, err := store.Prune(ctx, start.Add(150*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	all, err = store.Query(ctx, Query{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4"}, ids(all))
+}
+
+func TestRecorder(t *testing.T) {
+	historyConfig := config.Default().MCP.History
+	historyConfig.Enabled = true
+	historyConfig.MaxResultBytes = 10
+	recorder, err := NewRecorder(historyConfig, zap.NewNop())
+	require.NoError(t, err)
+
+	recorder.Record(Record{ID: "1", Time: time.Now().Add(-8 * 24 * time.Hour), SessionID: "s1", Tool: "old"})
+	recorder.Record(Record{
+		ID:        "2",
+		Time:      time.Now(),
+		SessionID: "s1",
+		Tool:      "auth_login",
+		Status:    "success",
+		Arguments: `{"user":"ana","password":"hunter2"}`,
+		Result:    `{"token":"t-1","expiresIn":3600}`,
+	})
+
+	require.Eventually(t, func() bool { return recorder.Stats().Recorded == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	records, err := recorder.Query(context.Background(), Query{SessionID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, `{"password":"[REDACTED]","user":"ana"}`, records[0].Arguments)
+	assert.Equal(t, `{"expiresI`, records[0].Result)
+
+	// Calls past the retention are pruned
+	recorder.prune()
+	assert.Equal(t, int64(1), recorder.Stats().Pruned)
+
+	require.NoError(t, recorder.Close())
+	recorder.Record(Record{ID: "3"})
+	assert.Equal(t, 0, recorder.Stats().Queued)
+}
+
+func TestRecorder_Disabled(t *testing.T) {
+	recorder, err := NewRecorder(config.Default().MCP.History, zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, recorder)
+
+	recorder.Record(Record{ID: "1"})
+	assert.Nil(t, recorder.Stats())
+	assert.NoError(t, recorder.Close())
+}
+
+func TestOpen_SQLDriverNotBuiltIn(t *testing.T) {
+	_, err := Open(config.HistoryConfig{Driver: "postgres", DSN: "postgres://localhost/ggrmcp"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build with -tags postgres")
+}
+
+func TestBuildQuery(t *testing.T) {
+	since := time.UnixMilli(1_700_000_000_000)
+	query := Query{SessionID: "s1", Status: "error", Since: since, Limit: 20}
+
+	statement, args := buildQuery("postgres", query)
+	assert.Equal(t, "SELECT "+historyColumns+" FROM ggrmcp_history WHERE session_id = $1 AND status = $2 AND time_ms >= $3 ORDER BY time_ms DESC, id DESC LIMIT 20", statement)
+	assert.Equal(t, []interface{}{"s1", "error", int64(1_700_000_000_000)}, args)
+
+	statement, _ = buildQuery("sqlite", query)
+	assert.Contains(t, statement, "WHERE session_id = ? AND status = ? AND time_ms >= ?")
+
+	statement, args = buildQuery("sqlite", Query{Limit: 5})
+	assert.Equal(t, "SELECT "+historyColumns+" FROM ggrmcp_history ORDER BY time_ms DESC, id DESC LIMIT 5", statement)
+	assert.Empty(t, args)
+}
@@ -0,0 +1,82 @@
+package history
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps the most recent records in memory
+type memoryStore struct {
+	mu         sync.Mutex
+	records    []Record // oldest first
+	maxEntries int
+}
+
+func newMemoryStore(maxEntries int) *memoryStore {
+	return &memoryStore{maxEntries: maxEntries}
+}
+
+// Append adds the records, dropping the oldest beyond the limit
+func (m *memoryStore) Append(_ context.Context, records []Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, records...)
+	if excess := len(m.records) - m.maxEntries; excess > 0 {
+		m.records = append([]Record(nil), m.records[excess:]...)
+	}
+	return nil
+}
+
+// Query returns the matching records, most recent first
+func (m *memoryStore) Query(_ context.Context, query Query) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var records []Record
+	for i := len(m.records) - 1; i >= 0 && len(records) < query.Limit; i-- {
+		if record := m.records[i]; matches(record, query) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// matches reports whether a record is selected by the query
+func matches(record Record, query Query) bool {
+	switch {
+	case query.SessionID != "" && record.SessionID != query.SessionID:
+		return false
+	case query.Tool != "" && record.Tool != query.Tool:
+		return false
+	case query.Status != "" && record.Status != query.Status:
+		return false
+	case !query.Since.IsZero() && record.Time.Before(query.Since):
+		return false
+	case !query.Until.IsZero() && !record.Time.Before(query.Until):
+		return false
+	}
+	return true
+}
+
+// Prune deletes the records older than the given time
+func (m *memoryStore) Prune(_ context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.records[:0]
+	for _, record := range m.records {
+		if !record.Time.Before(before) {
+			kept = append(kept, record)
+		}
+	}
+	deleted := int64(len(m.records) - len(kept))
+	m.records = kept
+	return deleted, nil
+}
+
+// Close does nothing
+func (m *memoryStore) Close() error {
+	return nil
+}
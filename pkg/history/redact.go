@@ -0,0 +1,82 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// redactedValue replaces the values of redacted fields
+const redactedValue = "[REDACTED]"
+
+// redactor replaces the values of sensitive fields in JSON documents
+type redactor struct {
+	fields map[string]bool // lower-case field names
+}
+
+func newRedactor(fields []string) *redactor {
+	r := &redactor{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	return r
+}
+
+// redact returns the JSON text with sensitive fields replaced at any depth.
+// Text that is not JSON is returned as it is.
+func (r *redactor) redact(text string) string {
+	if text == "" || len(r.fields) == 0 {
+		return text
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil || decoder.More() {
+		return text
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.walk(document)); err != nil {
+		return text
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// walk redacts a decoded JSON value
+func (r *redactor) walk(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = r.walk(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.walk(item)
+		}
+	}
+	return value
+}
+
+// cut shortens text to limit bytes without splitting a character; a negative
+// limit drops it
+func cut(text string, limit int) string {
+	if limit < 0 {
+		return ""
+	}
+	if len(text) <= limit {
+		return text
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end]
+}
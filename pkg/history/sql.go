@@ -0,0 +1,189 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlDriver is the database/sql driver backing a history driver, linked into
+// the binary by building with its tag
+type sqlDriver struct {
+	name string // registered database/sql driver name
+	tag  string // build tag importing the driver
+}
+
+// sqlDrivers maps the SQL history drivers to their database/sql drivers
+var sqlDrivers = map[string]sqlDriver{
+	"sqlite":   {name: "sqlite", tag: "sqlite"},
+	"postgres": {name: "pgx", tag: "postgres"},
+}
+
+// historySchema creates the history table; times are Unix milliseconds so the
+// same schema works with SQLite and Postgres
+var historySchema = []string{
+	`CREATE TABLE IF NOT EXISTS ggrmcp_history (
+		id TEXT PRIMARY KEY,
+		time_ms BIGINT NOT NULL,
+		session_id TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		tool TEXT NOT NULL,
+		status TEXT NOT NULL,
+		code TEXT NOT NULL,
+		latency_ms BIGINT NOT NULL,
+		arguments TEXT NOT NULL,
+		result TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS ggrmcp_history_session ON ggrmcp_history (session_id, time_ms)`,
+	`CREATE INDEX IF NOT EXISTS ggrmcp_history_time ON ggrmcp_history (time_ms)`,
+}
+
+// historyColumns lists the columns in the order records are written and read
+const historyColumns = "id, time_ms, session_id, request_id, tool, status, code, latency_ms, arguments, result"
+
+// sqlStore keeps records in a SQLite or Postgres table
+type sqlStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// openSQLStore connects to the database and creates the table if needed
+func openSQLStore(driver, dsn string) (*sqlStore, error) {
+	backing := sqlDrivers[driver]
+	if !slices.Contains(sql.Drivers(), backing.name) {
+		return nil, fmt.Errorf("the %s history driver is not built in; build with -tags %s", driver, backing.tag)
+	}
+
+	db, err := sql.Open(backing.name, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the history database: %w", err)
+	}
+	if driver == "sqlite" {
+		// SQLite allows a single writer
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	for _, statement := range historySchema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create the history table: %w", err)
+		}
+	}
+
+	return &sqlStore{db: db, dialect: driver}, nil
+}
+
+// Append inserts the records in one transaction
+func (s *sqlStore) Append(ctx context.Context, records []Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	insert, err := tx.PrepareContext(ctx, "INSERT INTO ggrmcp_history ("+historyColumns+") VALUES ("+placeholders(s.dialect, 1, 10)+")")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insert.Close() }()
+
+	for _, record := range records {
+		if _, err := insert.ExecContext(ctx,
+			record.ID, record.Time.UnixMilli(), record.SessionID, record.RequestID, record.Tool,
+			record.Status, record.Code, record.LatencyMs, record.Arguments, record.Result); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Query returns the matching records, most recent first
+func (s *sqlStore) Query(ctx context.Context, query Query) ([]Record, error) {
+	statement, args := buildQuery(s.dialect, query)
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []Record
+	for rows.Next() {
+		var record Record
+		var timeMs int64
+		if err := rows.Scan(&record.ID, &timeMs, &record.SessionID, &record.RequestID, &record.Tool,
+			&record.Status, &record.Code, &record.LatencyMs, &record.Arguments, &record.Result); err != nil {
+			return nil, err
+		}
+		record.Time = time.UnixMilli(timeMs).UTC()
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// buildQuery returns the SELECT statement of a query and its arguments
+func buildQuery(dialect string, query Query) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" "+placeholders(dialect, len(args), 1))
+	}
+
+	if query.SessionID != "" {
+		where("session_id =", query.SessionID)
+	}
+	if query.Tool != "" {
+		where("tool =", query.Tool)
+	}
+	if query.Status != "" {
+		where("status =", query.Status)
+	}
+	if !query.Since.IsZero() {
+		where("time_ms >=", query.Since.UnixMilli())
+	}
+	if !query.Until.IsZero() {
+		where("time_ms <", query.Until.UnixMilli())
+	}
+
+	statement := "SELECT " + historyColumns + " FROM ggrmcp_history"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY time_ms DESC, id DESC LIMIT " + strconv.Itoa(query.Limit)
+	return statement, args
+}
+
+// placeholders returns count bind parameters starting at position first:
+// ? for SQLite, $n for Postgres
+func placeholders(dialect string, first, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		if dialect == "postgres" {
+			params[i] = "$" + strconv.Itoa(first+i)
+		} else {
+			params[i] = "?"
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+// Prune deletes the records older than the given time
+func (s *sqlStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM ggrmcp_history WHERE time_ms < "+placeholders(s.dialect, 1, 1), before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Close closes the database
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,217 @@
+package inventory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aalobaidi/ggRMCP/pkg/composite"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Format identifies inventory reports, so consumers can check what they parse
+const Format = "ggrmcp-tool-inventory/v1"
+
+// Tool sources besides types.MethodSourceReflection and types.MethodSourceDescriptorSet
+const (
+	SourceComposite = "composite"
+	SourceUpstream  = "upstream"
+)
+
+// How a tool was found to be mutating or not
+const (
+	BasisIdempotencyLevel = "idempotency_level"
+	BasisMethodName       = "method_name"
+	BasisSteps            = "steps"
+	BasisUnknown          = "unknown"
+)
+
+// readOnlyVerbs start the names of methods assumed to have no side effects
+// when they do not set idempotency_level
+var readOnlyVerbs = []string{
+	"Get", "List", "Search", "Find", "Describe", "Query", "Read", "Lookup",
+	"Check", "Count", "Fetch", "Watch", "Validate", "Preview", "Estimate",
+}
+
+// Report lists every tool the gateway exposes, for security review
+type Report struct {
+	Format      string    `json:"format"`
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// gRPC backend address
+	Target string `json:"target"`
+
+	Tools []Tool `json:"tools"`
+}
+
+// Tool describes one exposed tool
+type Tool struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+
+	// gRPC method and its proto definition (for backend tools)
+	Method           string `json:"method,omitempty"`
+	Service          string `json:"service,omitempty"`
+	ProtoFile        string `json:"protoFile,omitempty"`
+	InputType        string `json:"inputType,omitempty"`
+	OutputType       string `json:"outputType,omitempty"`
+	ClientStreaming  bool   `json:"clientStreaming,omitempty"`
+	ServerStreaming  bool   `json:"serverStreaming,omitempty"`
+	Deprecated       bool   `json:"deprecated,omitempty"`
+	IdempotencyLevel string `json:"idempotencyLevel,omitempty"`
+
+	// Whether calling the tool may change state, and what that is based on.
+	// Tools are assumed mutating unless shown otherwise.
+	Mutating      bool   `json:"mutating"`
+	MutatingBasis string `json:"mutatingBasis"`
+
+	// Tools a composite tool calls, and the server of an upstream tool
+	Steps    []string `json:"steps,omitempty"`
+	Upstream string   `json:"upstream,omitempty"`
+
+	// SHA-256 of the input schema, to spot tools whose arguments changed
+	// between two reports
+	InputSchemaSHA256 string `json:"inputSchemaSha256"`
+}
+
+// Options contains what the report covers
+type Options struct {
+	// gRPC backend address shown in the report
+	Target string
+
+	// Discovered methods and the tools built from them; methods without a
+	// tool are not exposed and are left out
+	Methods []types.MethodInfo
+	Tools   []mcp.Tool
+
+	// Composite tools and the tools of upstream MCP servers
+	Composite []config.CompositeToolConfig
+	Upstream  []mcp.Tool
+}
+
+// Build creates the inventory report, sorted by tool name
+func Build(options Options) (*Report, error) {
+	report := &Report{Format: Format, GeneratedAt: time.Now().UTC(), Target: options.Target, Tools: []Tool{}}
+
+	methods := make(map[string]types.MethodInfo, len(options.Methods))
+	for _, method := range options.Methods {
+		methods[method.ToolName] = method
+	}
+	mutating := make(map[string]bool)
+	for _, tool := range options.Tools {
+		method, ok := methods[tool.Name]
+		if !ok {
+			continue
+		}
+		entry := methodTool(method)
+		entry.InputSchemaSHA256 = schemaDigest(tool.InputSchema)
+		mutating[entry.Name] = entry.Mutating
+		report.Tools = append(report.Tools, entry)
+	}
+
+	for _, tool := range options.Upstream {
+		upstream, _ := tool.Meta[mcp.MetaKeyUpstream].(string)
+		report.Tools = append(report.Tools, Tool{
+			Name:              tool.Name,
+			Source:            SourceUpstream,
+			Upstream:          upstream,
+			Mutating:          true,
+			MutatingBasis:     BasisUnknown,
+			InputSchemaSHA256: schemaDigest(tool.InputSchema),
+		})
+	}
+
+	for _, toolConfig := range options.Composite {
+		tool, err := composite.New(toolConfig)
+		if err != nil {
+			return nil, fmt.Errorf("composite tool %s: %w", toolConfig.Name, err)
+		}
+		definition := tool.Definition()
+		entry := Tool{
+			Name:              definition.Name,
+			Source:            SourceComposite,
+			MutatingBasis:     BasisSteps,
+			InputSchemaSHA256: schemaDigest(definition.InputSchema),
+		}
+		for _, step := range toolConfig.Steps {
+			entry.Steps = append(entry.Steps, step.Tool)
+			// Steps of unknown tools count as mutating
+			if stepMutating, ok := mutating[step.Tool]; !ok || stepMutating {
+				entry.Mutating = true
+			}
+		}
+		report.Tools = append(report.Tools, entry)
+	}
+
+	sort.Slice(report.Tools, func(i, j int) bool { return report.Tools[i].Name < report.Tools[j].Name })
+	return report, nil
+}
+
+// methodTool describes the tool of a gRPC method
+func methodTool(method types.MethodInfo) Tool {
+	tool := Tool{
+		Name:            method.ToolName,
+		Source:          method.Source,
+		Method:          method.FullName,
+		Service:         method.ServiceName,
+		ProtoFile:       method.FileDescriptor.GetName(),
+		InputType:       strings.TrimPrefix(method.InputType, "."),
+		OutputType:      strings.TrimPrefix(method.OutputType, "."),
+		ClientStreaming: method.IsClientStreaming,
+		ServerStreaming: method.IsServerStreaming,
+		Deprecated:      method.Deprecated,
+	}
+	if method.IdempotencyLevel != descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN {
+		tool.IdempotencyLevel = method.IdempotencyLevel.String()
+	}
+	tool.Mutating, tool.MutatingBasis = Mutating(method)
+	return tool
+}
+
+// Mutating reports whether a method may change state. Its idempotency_level
+// option decides; without it, methods named with a read-only verb such as
+// Get or List are assumed to have no side effects.
+func Mutating(method types.MethodInfo) (bool, string) {
+	switch method.IdempotencyLevel {
+	case descriptorpb.MethodOptions_NO_SIDE_EFFECTS:
+		return false, BasisIdempotencyLevel
+	case descriptorpb.MethodOptions_IDEMPOTENT:
+		return true, BasisIdempotencyLevel
+	}
+
+	for _, verb := range readOnlyVerbs {
+		rest, ok := strings.CutPrefix(method.Name, verb)
+		// The verb must be a whole word: GetOrder, not Getaway or Checkout
+		if ok && (rest == "" || unicode.IsUpper(rune(rest[0]))) {
+			return false, BasisMethodName
+		}
+	}
+	return true, BasisMethodName
+}
+
+// schemaDigest returns the SHA-256 of a schema's canonical JSON
+func schemaDigest(schema interface{}) string {
+	// Maps are marshaled with sorted keys, so equal schemas get equal digests
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return ""
+	}
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,117 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestMutating(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    descriptorpb.MethodOptions_IdempotencyLevel
+		mutating bool
+		basis    string
+	}{
+		{"GetOrder", descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN, false, BasisMethodName},
+		{"ListOrders", descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN, false, BasisMethodName},
+		{"Check", descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN, false, BasisMethodName},
+		{"Checkout", descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN, true, BasisMethodName},
+		{"DeleteOrder", descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN, true, BasisMethodName},
+		{"Ping", descriptorpb.MethodOptions_NO_SIDE_EFFECTS, false, BasisIdempotencyLevel},
+		{"GetOrCreateCart", descriptorpb.MethodOptions_IDEMPOTENT, true, BasisIdempotencyLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mutating, basis := Mutating(types.MethodInfo{Name: tt.name, IdempotencyLevel: tt.level})
+			assert.Equal(t, tt.mutating, mutating)
+			assert.Equal(t, tt.basis, basis)
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	file := &descriptorpb.FileDescriptorProto{Name: proto.String("shop/v1/orders.proto")}
+	methods := []types.MethodInfo{
+		{
+			Name: "GetOrder", FullName: "shop.v1.Orders.GetOrder", ServiceName: "shop.v1.Orders",
+			ToolName: "shop_orders_getorder", InputType: ".shop.v1.GetOrderRequest", OutputType: ".shop.v1.Order",
+			Source: types.MethodSourceDescriptorSet, FileDescriptor: file,
+			IdempotencyLevel: descriptorpb.MethodOptions_NO_SIDE_EFFECTS,
+		},
+		{
+			Name: "CancelOrder", FullName: "shop.v1.Orders.CancelOrder", ServiceName: "shop.v1.Orders",
+			ToolName: "shop_orders_cancelorder", Source: types.MethodSourceReflection, FileDescriptor: file,
+		},
+		// Not exposed: no tool was built for it
+		{Name: "WatchOrders", ToolName: "shop_orders_watchorders", IsServerStreaming: true},
+	}
+	schema := map[string]interface{}{"type": "object"}
+
+	report, err := Build(Options{
+		Target:  "localhost:50051",
+		Methods: methods,
+		Tools: []mcp.Tool{
+			{Name: "shop_orders_getorder", InputSchema: schema},
+			{Name: "shop_orders_cancelorder", InputSchema: schema},
+		},
+		Composite: []config.CompositeToolConfig{
+			{Name: "order_summary", Steps: []config.CompositeStepConfig{{Name: "order", Tool: "shop_orders_getorder"}}},
+			{Name: "cancel_and_refund", Steps: []config.CompositeStepConfig{
+				{Name: "cancel", Tool: "shop_orders_cancelorder"},
+				{Name: "refund", Tool: "billing_refund"},
+			}},
+		},
+		Upstream: []mcp.Tool{{Name: "docs_search", InputSchema: schema, Meta: map[string]interface{}{mcp.MetaKeyUpstream: "docs"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Format, report.Format)
+	assert.Equal(t, "localhost:50051", report.Target)
+
+	var names []string
+	tools := make(map[string]Tool)
+	for _, tool := range report.Tools {
+		names = append(names, tool.Name)
+		tools[tool.Name] = tool
+	}
+	assert.Equal(t, []string{"cancel_and_refund", "docs_search", "order_summary", "shop_orders_cancelorder", "shop_orders_getorder"}, names)
+
+	getOrder := tools["shop_orders_getorder"]
+	assert.Equal(t, types.MethodSourceDescriptorSet, getOrder.Source)
+	assert.Equal(t, "shop/v1/orders.proto", getOrder.ProtoFile)
+	assert.Equal(t, "shop.v1.GetOrderRequest", getOrder.InputType)
+	assert.Equal(t, "shop.v1.Order", getOrder.OutputType)
+	assert.Equal(t, "NO_SIDE_EFFECTS", getOrder.IdempotencyLevel)
+	assert.False(t, getOrder.Mutating)
+	assert.Equal(t, getOrder.InputSchemaSHA256, tools["shop_orders_cancelorder"].InputSchemaSHA256)
+	assert.Len(t, getOrder.InputSchemaSHA256, 64)
+
+	assert.Equal(t, types.MethodSourceReflection, tools["shop_orders_cancelorder"].Source)
+	assert.True(t, tools["shop_orders_cancelorder"].Mutating)
+
+	// Composite tools mutate when any step does, or calls an unknown tool
+	assert.False(t, tools["order_summary"].Mutating)
+	assert.True(t, tools["cancel_and_refund"].Mutating)
+	assert.Equal(t, []string{"shop_orders_cancelorder", "billing_refund"}, tools["cancel_and_refund"].Steps)
+
+	assert.Equal(t, SourceUpstream, tools["docs_search"].Source)
+	assert.Equal(t, "docs", tools["docs_search"].Upstream)
+	assert.True(t, tools["docs_search"].Mutating)
+	assert.Equal(t, BasisUnknown, tools["docs_search"].MutatingBasis)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	first := decoded["tools"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "composite", first["source"])
+	assert.Equal(t, true, first["mutating"])
+}
@@ -0,0 +1,973 @@
+// Package jq evaluates a subset of the jq language against decoded JSON.
+//
+// Supported: identity (.), field access (.a, ."a", .["a"]), indexes and
+// slices (.[0], .[-1], .[1:3]), iteration (.[]), pipes (|), multiple outputs
+// (,), array and object construction ([...], {a, b: .c}), literals,
+// comparisons (==, !=, <, <=, >, >=), and/or, parentheses and the builtins
+// select, map, length, keys, first, last, add and not. Runs are bounded by a
+// step budget and a timeout (see Limits).
+package jq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// filter produces the outputs of an expression for one input
+type filter func(ev *evaluation, input interface{}) ([]interface{}, error)
+
+// ErrLimitExceeded is returned when a run exceeds its step budget or timeout
+var ErrLimitExceeded = errors.New("jq program exceeded its limits")
+
+// Limits bound the work of a run. Steps count the values a run produces and
+// copies, so programs multiplying outputs such as {a: .[], b: .[]} or .. on
+// a large input stop early. Zero disables a limit.
+type Limits struct {
+	Steps   int
+	Timeout time.Duration
+}
+
+// DefaultLimits apply to compiled programs unless replaced with WithLimits
+var DefaultLimits = Limits{Steps: 1000000, Timeout: time.Second}
+
+// deadlineCheckInterval is how many steps pass between clock reads
+const deadlineCheckInterval = 1024
+
+// Program is a compiled jq program
+type Program struct {
+	source string
+	run    filter
+	limits Limits
+}
+
+// Compile parses a jq program
+func Compile(source string) (*Program, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq program: %w", err)
+	}
+	p := &parser{tokens: tokens}
+	run, err := p.parsePipe()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %s at offset %d", p.describe(p.peek()), p.peek().offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq program: %w", err)
+	}
+	return &Program{source: source, run: run, limits: DefaultLimits}, nil
+}
+
+// WithLimits returns a copy of the program running under other limits
+func (p *Program) WithLimits(limits Limits) *Program {
+	copied := *p
+	copied.limits = limits
+	return &copied
+}
+
+// String returns the source of the program
+func (p *Program) String() string {
+	return p.source
+}
+
+// Run returns the outputs of the program for the input, or an error wrapping
+// ErrLimitExceeded when the run takes too many steps or too long
+func (p *Program) Run(input interface{}) ([]interface{}, error) {
+	ev := &evaluation{limits: p.limits}
+	if p.limits.Timeout > 0 {
+		ev.deadline = time.Now().Add(p.limits.Timeout)
+	}
+	return p.run(ev, input)
+}
+
+// evaluation tracks the work of one run against its limits
+type evaluation struct {
+	limits   Limits
+	deadline time.Time
+	steps    int
+	checked  int // steps at the last clock read
+}
+
+// charge accounts steps of work and fails once a limit is exceeded
+func (ev *evaluation) charge(steps int) error {
+	ev.steps += steps
+	if ev.limits.Steps > 0 && ev.steps > ev.limits.Steps {
+		return fmt.Errorf("%w: more than %d steps", ErrLimitExceeded, ev.limits.Steps)
+	}
+	if !ev.deadline.IsZero() && ev.steps-ev.checked >= deadlineCheckInterval {
+		ev.checked = ev.steps
+		if time.Now().After(ev.deadline) {
+			return fmt.Errorf("%w: ran longer than %s", ErrLimitExceeded, ev.limits.Timeout)
+		}
+	}
+	return nil
+}
+
+// parser reads tokens into filters
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the punctuation or keyword if it is next
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenPunct || t.kind == tokenIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the punctuation, failing if something else is next
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("expected %q at offset %d, found %s", text, p.peek().offset, p.describe(p.peek()))
+	}
+	return nil
+}
+
+// describe names a token in error messages
+func (p *parser) describe(t token) string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of program"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	case tokenNumber:
+		return fmt.Sprintf("number %v", t.number)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// parsePipe reads comma-expressions separated by |
+func (p *parser) parsePipe() (filter, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipe(left, right)
+	}
+	return left, nil
+}
+
+// parseComma reads or-expressions separated by ,
+func (p *parser) parseComma() (filter, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(",") {
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		left = concat(left, right)
+	}
+	return left, nil
+}
+
+// parseOr reads and-expressions separated by "or"
+func (p *parser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logical(left, right, true)
+	}
+	return left, nil
+}
+
+// parseAnd reads comparisons separated by "and"
+func (p *parser) parseAnd() (filter, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logical(left, right, false)
+	}
+	return left, nil
+}
+
+// parseComparison reads a term optionally compared with another
+func (p *parser) parseComparison() (filter, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenPunct {
+		switch t.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return comparison(left, right, t.text), nil
+		}
+	}
+	return left, nil
+}
+
+// parsePostfix reads a term followed by field accesses, indexes and iterations
+func (p *parser) parsePostfix() (filter, error) {
+	term, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenPunct && t.text == "." && p.tokens[p.pos+1].kind == tokenIdent:
+			p.next()
+			term = pipe(term, field(p.next().text))
+		case t.kind == tokenPunct && t.text == "." && p.tokens[p.pos+1].kind == tokenString:
+			p.next()
+			term = pipe(term, field(p.next().text))
+		case t.kind == tokenPunct && t.text == "[":
+			suffix, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			term = pipe(term, suffix)
+		case t.kind == tokenPunct && t.text == "." && p.tokens[p.pos+1].kind == tokenPunct && p.tokens[p.pos+1].text == "[":
+			p.next()
+		default:
+			return term, nil
+		}
+	}
+}
+
+// parseTerm reads a path starting at ., a literal, a construction, a
+// parenthesized expression or a builtin
+func (p *parser) parseTerm() (filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return literal(t.number), nil
+	case tokenString:
+		return literal(t.text), nil
+	case tokenIdent:
+		return p.parseBuiltin(t)
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of program")
+	}
+
+	switch t.text {
+	case ".":
+		// .name and ."name" directly after the dot
+		if next := p.peek(); next.kind == tokenIdent || next.kind == tokenString {
+			p.next()
+			return field(next.text), nil
+		}
+		return identity, nil
+	case "..":
+		return recurse, nil
+	case "-":
+		if number := p.peek(); number.kind == tokenNumber {
+			p.next()
+			return literal(-number.number), nil
+		}
+	case "(":
+		inner, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case "[":
+		if p.accept("]") {
+			return literal([]interface{}{}), nil
+		}
+		inner, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return collect(inner), p.expect("]")
+	case "{":
+		return p.parseObject()
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", p.describe(t), t.offset)
+}
+
+// parseBracket reads [], [index], [start:end] after a term
+func (p *parser) parseBracket() (filter, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	if p.accept("]") {
+		return iterate, nil
+	}
+
+	var start filter
+	if !(p.peek().kind == tokenPunct && p.peek().text == ":") {
+		var err error
+		if start, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept(":") {
+		return index(start), p.expect("]")
+	}
+
+	var end filter
+	if !(p.peek().kind == tokenPunct && p.peek().text == "]") {
+		var err error
+		if end, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	return slice(start, end), p.expect("]")
+}
+
+// parseObject reads {key: value, key, "key": value} after the opening brace
+func (p *parser) parseObject() (filter, error) {
+	type entry struct {
+		key   string
+		value filter
+	}
+	var entries []entry
+	for !p.accept("}") {
+		if len(entries) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		t := p.next()
+		if t.kind != tokenIdent && t.kind != tokenString {
+			return nil, fmt.Errorf("expected an object key at offset %d, found %s", t.offset, p.describe(t))
+		}
+		if !p.accept(":") {
+			// {name} is short for {name: .name}
+			entries = append(entries, entry{key: t.text, value: field(t.text)})
+			continue
+		}
+		value, err := p.parseObjectValue()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key: t.text, value: value})
+	}
+
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		objects := []map[string]interface{}{{}}
+		for _, e := range entries {
+			values, err := e.value(ev, input)
+			if err != nil {
+				return nil, err
+			}
+			// Each output of a value multiplies the objects produced
+			var next []map[string]interface{}
+			for _, object := range objects {
+				for _, value := range values {
+					// Copying an object costs a step per member
+					if err := ev.charge(len(object) + 1); err != nil {
+						return nil, err
+					}
+					copied := make(map[string]interface{}, len(object)+1)
+					for k, v := range object {
+						copied[k] = v
+					}
+					copied[e.key] = value
+					next = append(next, copied)
+				}
+			}
+			objects = next
+		}
+		outputs := make([]interface{}, len(objects))
+		for i, object := range objects {
+			outputs[i] = object
+		}
+		return outputs, nil
+	}, nil
+}
+
+// parseObjectValue reads an object value: alternatives joined by | without
+// a top-level comma, which separates entries
+func (p *parser) parseObjectValue() (filter, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		left = pipe(left, right)
+	}
+	return left, nil
+}
+
+// parseBuiltin reads a keyword or builtin function
+func (p *parser) parseBuiltin(t token) (filter, error) {
+	switch t.text {
+	case "true":
+		return literal(true), nil
+	case "false":
+		return literal(false), nil
+	case "null":
+		return literal(nil), nil
+	case "not":
+		return not, nil
+	case "length":
+		return length, nil
+	case "keys":
+		return keys, nil
+	case "add":
+		return add, nil
+	case "first":
+		return index(literal(float64(0))), nil
+	case "last":
+		return index(literal(float64(-1))), nil
+	case "select", "map":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		argument, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if t.text == "select" {
+			return selectWhere(argument), nil
+		}
+		return collect(pipe(iterate, argument)), nil
+	}
+	return nil, fmt.Errorf("unknown function %q at offset %d", t.text, t.offset)
+}
+
+// identity outputs its input
+func identity(ev *evaluation, input interface{}) ([]interface{}, error) {
+	return []interface{}{input}, nil
+}
+
+// literal outputs a constant
+func literal(value interface{}) filter {
+	return func(*evaluation, interface{}) ([]interface{}, error) {
+		return []interface{}{value}, nil
+	}
+}
+
+// pipe feeds each output of left into right
+func pipe(left, right filter) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		values, err := left(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, value := range values {
+			results, err := right(ev, value)
+			if err != nil {
+				return nil, err
+			}
+			if err := ev.charge(len(results)); err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, results...)
+		}
+		return outputs, nil
+	}
+}
+
+// concat outputs the outputs of left, then those of right
+func concat(left, right filter) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		first, err := left(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		second, err := right(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		if err := ev.charge(len(first) + len(second)); err != nil {
+			return nil, err
+		}
+		return append(first, second...), nil
+	}
+}
+
+// collect outputs the outputs of inner as one array
+func collect(inner filter) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		values, err := inner(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = []interface{}{}
+		}
+		return []interface{}{values}, nil
+	}
+}
+
+// field outputs a member of an object (null for null or a missing member)
+func field(name string) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		switch value := input.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case map[string]interface{}:
+			return []interface{}{value[name]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(input), name)
+	}
+}
+
+// index outputs an array element or object member selected by the key's outputs
+func index(key filter) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		keys, err := key(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, k := range keys {
+			if name, ok := k.(string); ok {
+				values, err := field(name)(ev, input)
+				if err != nil {
+					return nil, err
+				}
+				outputs = append(outputs, values...)
+				continue
+			}
+			position, ok := toNumber(k)
+			if !ok {
+				return nil, fmt.Errorf("cannot index %s with %s", typeName(input), typeName(k))
+			}
+			switch value := input.(type) {
+			case nil:
+				outputs = append(outputs, nil)
+			case []interface{}:
+				i := int(math.Floor(position))
+				if i < 0 {
+					i += len(value)
+				}
+				if i >= 0 && i < len(value) {
+					outputs = append(outputs, value[i])
+				} else {
+					outputs = append(outputs, nil)
+				}
+			default:
+				return nil, fmt.Errorf("cannot index %s with number", typeName(input))
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// slice outputs part of an array or string; a nil bound is open
+func slice(start, end filter) filter {
+	bound := func(ev *evaluation, f filter, input interface{}, fallback, length int) (int, error) {
+		if f == nil {
+			return fallback, nil
+		}
+		values, err := f(ev, input)
+		if err != nil {
+			return 0, err
+		}
+		if len(values) != 1 {
+			return 0, fmt.Errorf("slice bounds must have one value")
+		}
+		if values[0] == nil {
+			return fallback, nil
+		}
+		number, ok := toNumber(values[0])
+		if !ok {
+			return 0, fmt.Errorf("slice bounds must be numbers")
+		}
+		i := int(math.Floor(number))
+		if i < 0 {
+			i += length
+		}
+		return min(max(i, 0), length), nil
+	}
+
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		var length int
+		switch value := input.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case []interface{}:
+			length = len(value)
+		case string:
+			length = utf8.RuneCountInString(value)
+		default:
+			return nil, fmt.Errorf("cannot slice %s", typeName(input))
+		}
+		from, err := bound(ev, start, input, 0, length)
+		if err != nil {
+			return nil, err
+		}
+		to, err := bound(ev, end, input, length, length)
+		if err != nil {
+			return nil, err
+		}
+		to = max(to, from)
+
+		if s, ok := input.(string); ok {
+			return []interface{}{string([]rune(s)[from:to])}, nil
+		}
+		return []interface{}{input.([]interface{})[from:to]}, nil
+	}
+}
+
+// iterate outputs the elements of an array or the member values of an object
+func iterate(ev *evaluation, input interface{}) ([]interface{}, error) {
+	switch value := input.(type) {
+	case []interface{}:
+		return value, nil
+	case map[string]interface{}:
+		outputs := make([]interface{}, 0, len(value))
+		for _, key := range sortedKeys(value) {
+			outputs = append(outputs, value[key])
+		}
+		return outputs, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", typeName(input))
+}
+
+// recurse outputs the input and every value nested in it
+func recurse(ev *evaluation, input interface{}) ([]interface{}, error) {
+	if err := ev.charge(1); err != nil {
+		return nil, err
+	}
+	outputs := []interface{}{input}
+	if children, err := iterate(ev, input); err == nil {
+		for _, child := range children {
+			nested, err := recurse(ev, child)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, nested...)
+		}
+	}
+	return outputs, nil
+}
+
+// selectWhere outputs the input for each truthy output of the condition
+func selectWhere(condition filter) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		values, err := condition(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, value := range values {
+			if truthy(value) {
+				outputs = append(outputs, input)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// logical combines two conditions with "or" (any) or "and" (all)
+func logical(left, right filter, any bool) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		lefts, err := left(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, l := range lefts {
+			if truthy(l) == any {
+				outputs = append(outputs, any)
+				continue
+			}
+			rights, err := right(ev, input)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rights {
+				outputs = append(outputs, truthy(r))
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// comparison compares every pair of outputs of its operands
+func comparison(left, right filter, operator string) filter {
+	return func(ev *evaluation, input interface{}) ([]interface{}, error) {
+		lefts, err := left(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		rights, err := right(ev, input)
+		if err != nil {
+			return nil, err
+		}
+		if err := ev.charge(len(lefts) * len(rights)); err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, r := range rights {
+			for _, l := range lefts {
+				order := compare(l, r)
+				var result bool
+				switch operator {
+				case "==":
+					result = order == 0
+				case "!=":
+					result = order != 0
+				case "<":
+					result = order < 0
+				case "<=":
+					result = order <= 0
+				case ">":
+					result = order > 0
+				case ">=":
+					result = order >= 0
+				}
+				outputs = append(outputs, result)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// not negates the truthiness of its input
+func not(ev *evaluation, input interface{}) ([]interface{}, error) {
+	return []interface{}{!truthy(input)}, nil
+}
+
+// length outputs the length of a string, array or object, the absolute value
+// of a number, or 0 for null
+func length(ev *evaluation, input interface{}) ([]interface{}, error) {
+	switch value := input.(type) {
+	case nil:
+		return []interface{}{float64(0)}, nil
+	case string:
+		return []interface{}{float64(utf8.RuneCountInString(value))}, nil
+	case []interface{}:
+		return []interface{}{float64(len(value))}, nil
+	case map[string]interface{}:
+		return []interface{}{float64(len(value))}, nil
+	case bool:
+		return nil, fmt.Errorf("boolean has no length")
+	}
+	number, _ := toNumber(input)
+	return []interface{}{math.Abs(number)}, nil
+}
+
+// keys outputs the sorted keys of an object or the indexes of an array
+func keys(ev *evaluation, input interface{}) ([]interface{}, error) {
+	switch value := input.(type) {
+	case map[string]interface{}:
+		sorted := sortedKeys(value)
+		outputs := make([]interface{}, len(sorted))
+		for i, key := range sorted {
+			outputs[i] = key
+		}
+		return []interface{}{outputs}, nil
+	case []interface{}:
+		outputs := make([]interface{}, len(value))
+		for i := range value {
+			outputs[i] = float64(i)
+		}
+		return []interface{}{outputs}, nil
+	}
+	return nil, fmt.Errorf("%s has no keys", typeName(input))
+}
+
+// add outputs the sum of numbers, or the concatenation of strings or arrays,
+// in an array
+func add(ev *evaluation, input interface{}) ([]interface{}, error) {
+	values, ok := input.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot add the elements of %s", typeName(input))
+	}
+	var sum interface{}
+	for _, value := range values {
+		switch {
+		case value == nil:
+		case sum == nil:
+			sum = value
+		default:
+			if a, ok := toNumber(sum); ok {
+				b, ok := toNumber(value)
+				if !ok {
+					return nil, fmt.Errorf("cannot add number and %s", typeName(value))
+				}
+				sum = a + b
+				continue
+			}
+			switch s := sum.(type) {
+			case string:
+				v, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("cannot add string and %s", typeName(value))
+				}
+				sum = s + v
+			case []interface{}:
+				v, ok := value.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("cannot add array and %s", typeName(value))
+				}
+				sum = append(append([]interface{}(nil), s...), v...)
+			default:
+				return nil, fmt.Errorf("cannot add %s values", typeName(sum))
+			}
+		}
+	}
+	return []interface{}{sum}, nil
+}
+
+// truthy reports whether a value counts as true: anything but false and null
+func truthy(value interface{}) bool {
+	return value != nil && value != false
+}
+
+// typeRank orders values of different types as jq does
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value == true {
+			return 2
+		}
+		return 1
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	case map[string]interface{}:
+		return 6
+	}
+	if _, ok := toNumber(value); ok {
+		return 3
+	}
+	return 7
+}
+
+// compare orders two values: by type first, then numbers numerically,
+// strings lexically and other values only for equality
+func compare(left, right interface{}) int {
+	if l, r := typeRank(left), typeRank(right); l != r {
+		return l - r
+	}
+	if l, ok := toNumber(left); ok {
+		r, _ := toNumber(right)
+		switch {
+		case l < r:
+			return -1
+		case l > r:
+			return 1
+		}
+		return 0
+	}
+	if l, ok := left.(string); ok {
+		return strings.Compare(l, right.(string))
+	}
+	if reflect.DeepEqual(normalize(left), normalize(right)) {
+		return 0
+	}
+	return 1
+}
+
+// normalize converts the numbers nested in a value to float64 so equal
+// documents compare equal however they were decoded
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	}
+	if number, ok := toNumber(value); ok {
+		return number
+	}
+	return value
+}
+
+// toNumber converts a decoded JSON number
+func toNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case json.Number:
+		f, err := number.Float64()
+		return f, err == nil
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+// typeName names the JSON type of a value in error messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toNumber(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// sortedKeys returns the keys of an object in order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
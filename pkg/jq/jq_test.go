@@ -0,0 +1,145 @@
+package jq
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orders = `{
+  "customer": {"name": "Ada", "tier": "gold"},
+  "orders": [
+    {"id": "o-1", "total": 12.5, "status": "shipped", "items": [{"sku": "a"}, {"sku": "b"}]},
+    {"id": "o-2", "total": 99, "status": "pending", "items": []},
+    {"id": "o-3", "total": 40, "status": "shipped", "items": [{"sku": "c"}]}
+  ],
+  "odd key": true
+}`
+
+func decode(t *testing.T, text string) interface{} {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var document interface{}
+	require.NoError(t, decoder.Decode(&document))
+	return document
+}
+
+func TestProgram_Run(t *testing.T) {
+	document := decode(t, orders)
+
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{".customer.name", `["Ada"]`},
+		{`."odd key"`, `[true]`},
+		{`.["customer"].tier`, `["gold"]`},
+		{".missing.deeper", `[null]`},
+		{".orders[0].id", `["o-1"]`},
+		{".orders[-1].id", `["o-3"]`},
+		{".orders[5]", `[null]`},
+		{"[.orders[1:][].id]", `[["o-2","o-3"]]`},
+		{"[.orders[:-2][] | .id]", `[["o-1"]]`},
+		{".orders[].id", `["o-1","o-2","o-3"]`},
+		{".orders | map(.total)", `[[12.5,99,40]]`},
+		{`[.orders[] | select(.status == "shipped") | .id]`, `[["o-1","o-3"]]`},
+		{`[.orders[] | select(.total > 20 and .status != "pending").id]`, `[["o-3"]]`},
+		{`[.orders[] | select(.total < 20 or .total >= 99) | .id]`, `[["o-1","o-2"]]`},
+		{`[.orders[] | select(.items | length == 0) | .id]`, `[["o-2"]]`},
+		{".orders | map(.items | length) | add", `[3]`},
+		{".orders | length", `[3]`},
+		{".customer | keys", `[["name","tier"]]`},
+		{".customer[]", `["Ada","gold"]`},
+		{".customer.name, .customer.tier", `["Ada","gold"]`},
+		{"{name: .customer.name, count: (.orders | length)}", `[{"count":3,"name":"Ada"}]`},
+		{`.customer | {name, "level": .tier}`, `[{"level":"gold","name":"Ada"}]`},
+		{".orders | first | .id", `["o-1"]`},
+		{".orders | last.id", `["o-3"]`},
+		{".orders[0].status == \"shipped\" | not", `[false]`},
+		{"[.orders[].items[].sku]", `[["a","b","c"]]`},
+		{`[.orders[].id] | .[1:2]`, `[["o-2"]]`},
+		{"[1, -2, \"x\", null, true, false, [], {}]", `[[1,-2,"x",null,true,false,[],{}]]`},
+		{"[.orders[] | .total] | add", `[151.5]`},
+		{`["a", "b"] | add`, `["ab"]`},
+		{"{a: (1, 2)}", `[{"a":1},{"a":2}]`},
+		{"[]", `[[]]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.program, func(t *testing.T) {
+			program, err := Compile(tt.program)
+			require.NoError(t, err)
+			outputs, err := program.Run(document)
+			require.NoError(t, err)
+			actual, err := json.Marshal(outputs)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, program := range []string{
+		"",
+		".[",
+		".a |",
+		"{a: }",
+		"select(.a",
+		"unknown(.a)",
+		".a ? .b",
+		`"unterminated`,
+		".a // .b",
+	} {
+		_, err := Compile(program)
+		assert.Error(t, err, program)
+	}
+}
+
+func TestProgram_RunErrors(t *testing.T) {
+	for _, tt := range []struct {
+		program string
+		input   string
+	}{
+		{".a", `[1]`},
+		{".[1:].id", `[{"id":1},{"id":2}]`},
+		{".[0]", `{"a":1}`},
+		{".[]", `1`},
+		{"keys", `"x"`},
+		{"add", `{"a":1}`},
+		{`add`, `[1,"a"]`},
+	} {
+		program, err := Compile(tt.program)
+		require.NoError(t, err)
+		_, err = program.Run(decode(t, tt.input))
+		assert.Error(t, err, tt.program)
+	}
+}
+
+func TestProgram_RunLimits(t *testing.T) {
+	document := decode(t, `[1,2,3,4,5,6,7,8,9,10]`)
+
+	// Each entry multiplies the objects built: 10^4 objects
+	product, err := Compile("{a: .[], b: .[], c: .[], d: .[]}")
+	require.NoError(t, err)
+	_, err = product.WithLimits(Limits{Steps: 1000}).Run(document)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	recursive, err := Compile("[..]")
+	require.NoError(t, err)
+	_, err = recursive.WithLimits(Limits{Steps: 5}).Run(document)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	// The same programs fit the default limits
+	outputs, err := recursive.Run(document)
+	require.NoError(t, err)
+	assert.Len(t, outputs[0], 11)
+
+	// A run past its deadline stops at the next clock read
+	_, err = product.WithLimits(Limits{Timeout: time.Nanosecond}).Run(document)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.Contains(t, err.Error(), "ran longer than")
+}
@@ -0,0 +1,101 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenIdent
+	tokenString
+	tokenNumber
+)
+
+// token is a lexical unit of a program
+type token struct {
+	kind   tokenKind
+	text   string  // punctuation, identifier or decoded string
+	number float64 // value of a number
+	offset int
+}
+
+// twoCharPuncts are the punctuation tokens of two characters
+var twoCharPuncts = []string{"==", "!=", "<=", ">=", ".."}
+
+// tokenize splits a program into tokens
+func tokenize(program string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(program); {
+		c := program[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			text, end, err := readString(program, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, offset: i})
+			i = end
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(program) && (program[end] >= '0' && program[end] <= '9' || program[end] == '.' ||
+				program[end] == 'e' || program[end] == 'E' ||
+				(program[end] == '-' || program[end] == '+') && (program[end-1] == 'e' || program[end-1] == 'E')) {
+				end++
+			}
+			number, err := strconv.ParseFloat(program[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", program[i:end], i)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, number: number, offset: i})
+			i = end
+		case c == '_' || unicode.IsLetter(rune(c)):
+			end := i
+			for end < len(program) && (program[end] == '_' || unicode.IsLetter(rune(program[end])) || unicode.IsDigit(rune(program[end]))) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: program[i:end], offset: i})
+			i = end
+		default:
+			punct := string(c)
+			for _, candidate := range twoCharPuncts {
+				if strings.HasPrefix(program[i:], candidate) {
+					punct = candidate
+					break
+				}
+			}
+			if !strings.Contains(".|,[](){}:<>-", punct) && len(punct) == 1 {
+				return nil, fmt.Errorf("unexpected %q at offset %d", punct, i)
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: punct, offset: i})
+			i += len(punct)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(program)}), nil
+}
+
+// readString reads a double-quoted JSON string starting at offset start,
+// returning its value and the offset after the closing quote
+func readString(program string, start int) (string, int, error) {
+	for end := start + 1; end < len(program); end++ {
+		switch program[end] {
+		case '\\':
+			end++
+		case '"':
+			text, err := strconv.Unquote(program[start : end+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string at offset %d", start)
+			}
+			return text, end + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at offset %d", start)
+}
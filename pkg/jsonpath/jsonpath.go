@@ -0,0 +1,588 @@
+// Package jsonpath evaluates JSONPath expressions against decoded JSON.
+//
+// Supported syntax: the root $, child names (.name, ['name']), wildcards
+// (.*, [*]), recursive descent (..name, ..*), indexes ([0], [-1]), unions
+// ([0,2], ['a','b']), slices ([1:3], [::2]) and filters comparing a relative
+// path with a literal ([?(@.price < 10 && @.tags)]).
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// stepKind is what a step selects from each node
+type stepKind int
+
+const (
+	stepNames stepKind = iota
+	stepWildcard
+	stepIndexes
+	stepSlice
+	stepFilter
+)
+
+// step is one selector of a path
+type step struct {
+	kind      stepKind
+	recursive bool // applies to the node and all its descendants
+	names     []string
+	indexes   []int
+	slice     [3]*int // start, end, step
+	filter    filter
+}
+
+// Compile parses a JSONPath expression
+func Compile(expr string) (*Path, error) {
+	p := &parser{expr: strings.TrimSpace(expr)}
+	if !strings.HasPrefix(p.expr, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", expr)
+	}
+	p.pos = 1
+	steps, err := p.parseSteps()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+	}
+	if p.pos < len(p.expr) {
+		return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q at offset %d", expr, p.expr[p.pos:], p.pos)
+	}
+	return &Path{expr: expr, steps: steps}, nil
+}
+
+// String returns the expression the path was compiled from
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Evaluate returns the values the path selects, in document order (object
+// members in key order)
+func (p *Path) Evaluate(document interface{}) []interface{} {
+	return evaluate(p.steps, document)
+}
+
+// Query compiles an expression and evaluates it against the document
+func Query(document interface{}, expr string) ([]interface{}, error) {
+	path, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return path.Evaluate(document), nil
+}
+
+// evaluate applies the steps to a node
+func evaluate(steps []step, node interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, s := range steps {
+		var next []interface{}
+		for _, n := range nodes {
+			if s.recursive {
+				for _, d := range descendants(n, nil) {
+					next = append(next, s.apply(d)...)
+				}
+			} else {
+				next = append(next, s.apply(n)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// apply selects the step's values from one node
+func (s step) apply(node interface{}) []interface{} {
+	switch s.kind {
+	case stepNames:
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var values []interface{}
+		for _, name := range s.names {
+			if value, ok := object[name]; ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	case stepWildcard:
+		return children(node)
+	case stepIndexes:
+		array, ok := node.([]interface{})
+		if !ok {
+			return nil
+		}
+		var values []interface{}
+		for _, index := range s.indexes {
+			if index < 0 {
+				index += len(array)
+			}
+			if index >= 0 && index < len(array) {
+				values = append(values, array[index])
+			}
+		}
+		return values
+	case stepSlice:
+		array, ok := node.([]interface{})
+		if !ok {
+			return nil
+		}
+		return sliceArray(array, s.slice)
+	case stepFilter:
+		var values []interface{}
+		for _, child := range children(node) {
+			if s.filter.matches(child) {
+				values = append(values, child)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// children returns the elements of an array or the member values of an object
+func children(node interface{}) []interface{} {
+	switch value := node.(type) {
+	case []interface{}:
+		return value
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = value[key]
+		}
+		return values
+	}
+	return nil
+}
+
+// descendants appends the node and everything nested in it
+func descendants(node interface{}, into []interface{}) []interface{} {
+	into = append(into, node)
+	for _, child := range children(node) {
+		into = descendants(child, into)
+	}
+	return into
+}
+
+// sliceArray selects array[start:end:step] with Python semantics for
+// negative bounds
+func sliceArray(array []interface{}, bounds [3]*int) []interface{} {
+	length := len(array)
+	stride := 1
+	if bounds[2] != nil {
+		stride = *bounds[2]
+	}
+	normalize := func(bound *int, fallback int) int {
+		if bound == nil {
+			return fallback
+		}
+		index := *bound
+		if index < 0 {
+			index += length
+		}
+		return min(max(index, 0), length)
+	}
+	start, end := normalize(bounds[0], 0), normalize(bounds[1], length)
+
+	var values []interface{}
+	for i := start; i < end; i += stride {
+		values = append(values, array[i])
+	}
+	return values
+}
+
+// filter is a disjunction of conjunctions of conditions
+type filter struct {
+	any [][]condition
+}
+
+// condition tests a path relative to the filtered node
+type condition struct {
+	path     []step
+	operator string // empty to test existence
+	value    interface{}
+}
+
+// matches reports whether the filter selects the node
+func (f filter) matches(node interface{}) bool {
+	for _, all := range f.any {
+		matched := true
+		for _, c := range all {
+			if !c.matches(node) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether any value at the condition's path satisfies it
+func (c condition) matches(node interface{}) bool {
+	values := evaluate(c.path, node)
+	if c.operator == "" {
+		return len(values) > 0
+	}
+	for _, value := range values {
+		if compare(value, c.operator, c.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies a comparison operator; numbers compare numerically and
+// strings lexicographically, other values only for (in)equality
+func compare(left interface{}, operator string, right interface{}) bool {
+	leftNumber, leftIsNumber := toNumber(left)
+	rightNumber, rightIsNumber := toNumber(right)
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+
+	var order int
+	switch {
+	case leftIsNumber && rightIsNumber:
+		if leftNumber < rightNumber {
+			order = -1
+		} else if leftNumber > rightNumber {
+			order = 1
+		}
+	case leftIsString && rightIsString:
+		order = strings.Compare(leftString, rightString)
+	default:
+		equal := left == right
+		switch operator {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		}
+		return false
+	}
+
+	switch operator {
+	case "==":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+// toNumber converts a decoded JSON number
+func toNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case json.Number:
+		f, err := number.Float64()
+		return f, err == nil
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+// parser reads an expression
+type parser struct {
+	expr string
+	pos  int
+}
+
+// parseSteps reads selectors until the end of the expression or a character
+// that cannot continue a path
+func (p *parser) parseSteps() ([]step, error) {
+	var steps []step
+	for p.pos < len(p.expr) {
+		recursive := false
+		switch {
+		case strings.HasPrefix(p.expr[p.pos:], ".."):
+			p.pos += 2
+			recursive = true
+			if p.pos < len(p.expr) && p.expr[p.pos] == '[' {
+				s, err := p.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				s.recursive = true
+				steps = append(steps, s)
+				continue
+			}
+		case p.expr[p.pos] == '.':
+			p.pos++
+		case p.expr[p.pos] == '[':
+			s, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+			continue
+		default:
+			return steps, nil
+		}
+
+		if p.pos < len(p.expr) && p.expr[p.pos] == '*' {
+			p.pos++
+			steps = append(steps, step{kind: stepWildcard, recursive: recursive})
+			continue
+		}
+		name := p.readName()
+		if name == "" {
+			return nil, fmt.Errorf("missing name at offset %d", p.pos)
+		}
+		steps = append(steps, step{kind: stepNames, recursive: recursive, names: []string{name}})
+	}
+	return steps, nil
+}
+
+// readName reads a dot-notation member name
+func (p *parser) readName() string {
+	start := p.pos
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		if c == '.' || c == '[' || c == ' ' || c == ')' || c == '=' || c == '!' || c == '<' || c == '>' || c == '&' || c == '|' {
+			break
+		}
+		p.pos++
+	}
+	return p.expr[start:p.pos]
+}
+
+// parseBracket reads a [...] selector
+func (p *parser) parseBracket() (step, error) {
+	end, err := p.closingBracket()
+	if err != nil {
+		return step{}, err
+	}
+	content := strings.TrimSpace(p.expr[p.pos+1 : end])
+	p.pos = end + 1
+
+	switch {
+	case content == "*":
+		return step{kind: stepWildcard}, nil
+	case strings.HasPrefix(content, "?"):
+		f, err := parseFilter(content)
+		if err != nil {
+			return step{}, err
+		}
+		return step{kind: stepFilter, filter: f}, nil
+	case strings.Contains(content, ":") && !strings.ContainsAny(content, `'"`):
+		return parseSlice(content)
+	}
+
+	var s step
+	for _, item := range splitTopLevel(content, ",") {
+		item = strings.TrimSpace(item)
+		if name, ok := unquote(item); ok {
+			s.kind = stepNames
+			s.names = append(s.names, name)
+			continue
+		}
+		index, err := strconv.Atoi(item)
+		if err != nil {
+			return step{}, fmt.Errorf("invalid selector %q", item)
+		}
+		s.kind = stepIndexes
+		s.indexes = append(s.indexes, index)
+	}
+	if len(s.names) > 0 && len(s.indexes) > 0 {
+		return step{}, fmt.Errorf("selector [%s] mixes names and indexes", content)
+	}
+	if len(s.names) == 0 && len(s.indexes) == 0 {
+		return step{}, fmt.Errorf("empty selector")
+	}
+	return s, nil
+}
+
+// closingBracket finds the ] matching the [ at the current position,
+// skipping quoted strings and nested brackets
+func (p *parser) closingBracket() (int, error) {
+	depth := 0
+	var quote byte
+	for i := p.pos; i < len(p.expr); i++ {
+		c := p.expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed [ at offset %d", p.pos)
+}
+
+// parseSlice reads start:end:step
+func parseSlice(content string) (step, error) {
+	parts := strings.Split(content, ":")
+	if len(parts) > 3 {
+		return step{}, fmt.Errorf("invalid slice [%s]", content)
+	}
+	s := step{kind: stepSlice}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return step{}, fmt.Errorf("invalid slice [%s]", content)
+		}
+		s.slice[i] = &n
+	}
+	if s.slice[2] != nil && *s.slice[2] <= 0 {
+		return step{}, fmt.Errorf("slice step must be positive")
+	}
+	return s, nil
+}
+
+// parseFilter reads ?(condition && condition || ...)
+func parseFilter(content string) (filter, error) {
+	body := strings.TrimSpace(strings.TrimPrefix(content, "?"))
+	if !strings.HasPrefix(body, "(") || !strings.HasSuffix(body, ")") {
+		return filter{}, fmt.Errorf("filter must look like ?(...)")
+	}
+	body = body[1 : len(body)-1]
+
+	var f filter
+	for _, disjunct := range splitTopLevel(body, "||") {
+		var all []condition
+		for _, conjunct := range splitTopLevel(disjunct, "&&") {
+			c, err := parseCondition(strings.TrimSpace(conjunct))
+			if err != nil {
+				return filter{}, err
+			}
+			all = append(all, c)
+		}
+		f.any = append(f.any, all)
+	}
+	return f, nil
+}
+
+// parseCondition reads "@.path", or "@.path <operator> literal"
+func parseCondition(text string) (condition, error) {
+	if !strings.HasPrefix(text, "@") {
+		return condition{}, fmt.Errorf("filter condition %q must start with @", text)
+	}
+	p := &parser{expr: text, pos: 1}
+	path, err := p.parseSteps()
+	if err != nil {
+		return condition{}, err
+	}
+	rest := strings.TrimSpace(text[p.pos:])
+	if rest == "" {
+		return condition{path: path}, nil
+	}
+
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if literal, ok := strings.CutPrefix(rest, operator); ok {
+			value, err := parseLiteral(strings.TrimSpace(literal))
+			if err != nil {
+				return condition{}, err
+			}
+			return condition{path: path, operator: operator, value: value}, nil
+		}
+	}
+	return condition{}, fmt.Errorf("invalid filter condition %q", text)
+}
+
+// parseLiteral reads a string, number, boolean or null
+func parseLiteral(text string) (interface{}, error) {
+	if s, ok := unquote(text); ok {
+		return s, nil
+	}
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid literal %q", text)
+	}
+	return number, nil
+}
+
+// unquote returns the content of a single- or double-quoted string
+func unquote(text string) (string, bool) {
+	if len(text) < 2 || (text[0] != '\'' && text[0] != '"') || text[len(text)-1] != text[0] {
+		return "", false
+	}
+	body := text[1 : len(text)-1]
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\\' && i+1 < len(body) {
+			i++
+		}
+		b.WriteByte(body[i])
+	}
+	return b.String(), true
+}
+
+// splitTopLevel splits text on a separator outside quotes, brackets and parentheses
+func splitTopLevel(text, separator string) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(text[i:], separator):
+			parts = append(parts, text[start:i])
+			i += len(separator) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
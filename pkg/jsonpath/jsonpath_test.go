@@ -0,0 +1,96 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const store = `{
+  "store": {
+    "book": [
+      {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+      {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+      {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+      {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+    ],
+    "bicycle": {"color": "red", "price": 19.95},
+    "odd key": {"x": 1}
+  }
+}`
+
+func decode(t *testing.T, text string) interface{} {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var document interface{}
+	require.NoError(t, decoder.Decode(&document))
+	return document
+}
+
+func TestQuery(t *testing.T) {
+	document := decode(t, store)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"$.store.bicycle.color", `["red"]`},
+		{"$['store']['bicycle']['color']", `["red"]`},
+		{"$.store['odd key'].x", `[1]`},
+		{"$.store.book[*].author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"$..author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"$.store.book[0].title", `["Sayings of the Century"]`},
+		{"$.store.book[-1].title", `["The Lord of the Rings"]`},
+		{"$.store.book[0,2].price", `[8.95,8.99]`},
+		{"$.store.book[1:3].price", `[12.99,8.99]`},
+		{"$.store.book[:2].price", `[8.95,12.99]`},
+		{"$.store.book[-2:].price", `[8.99,22.99]`},
+		{"$.store.book[::2].price", `[8.95,8.99]`},
+		{"$.store.book[?(@.isbn)].title", `["Moby Dick","The Lord of the Rings"]`},
+		{"$.store.book[?(@.price < 10)].title", `["Sayings of the Century","Moby Dick"]`},
+		{"$.store.book[?(@.category == 'fiction' && @.price > 20)].title", `["The Lord of the Rings"]`},
+		{"$.store.book[?(@.price > 20 || @.author == \"Nigel Rees\")].price", `[8.95,22.99]`},
+		{"$.store.book[?(@.category != 'fiction')].title", `["Sayings of the Century"]`},
+		{"$..[?(@.color)].price", `[19.95]`},
+		{"$.store.bicycle.*", `["red",19.95]`},
+		{"$.store.book[0]['title','price']", `["Sayings of the Century",8.95]`},
+		{"$.store.missing", `null`},
+		{"$.store.book[10]", `null`},
+		{"$", ``},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			values, err := Query(document, tc.expr)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Equal(t, []interface{}{document}, values)
+				return
+			}
+			encoded, err := json.Marshal(values)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(encoded))
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"store.book",
+		"$.store.book[",
+		"$.store.book[abc]",
+		"$.store.book[0,'a']",
+		"$.store.book[::0]",
+		"$.store.book[?(@.price <)]",
+		"$.store.book[?(price < 10)]",
+		"$.store.",
+		"$ store",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Compile(expr)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package jsonwalk walks the decoded protojson form of a message, guided by
+// the message's descriptor.
+//
+// Fields are found under their JSON name or their proto name, as protojson
+// accepts both. Values of the wrong JSON type are skipped and left for
+// protojson to report. Well-known types, whose JSON form is not their message
+// structure, are not descended into.
+package jsonwalk
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wellKnownPackage holds the well-known types
+const wellKnownPackage protoreflect.FullName = "google.protobuf"
+
+// Walker visits the values of selected fields in a message's decoded JSON
+type Walker struct {
+	// Match selects the fields whose values are visited; values of other
+	// message fields are descended into. Nil selects none.
+	Match func(fd protoreflect.FieldDescriptor) bool
+
+	// Visit returns the value to keep for a value of a selected field. The
+	// path locates the value, e.g. items[0].labels["env"].
+	Visit func(fd protoreflect.FieldDescriptor, path string, value interface{}) interface{}
+
+	// Entries, if set, is called with the entries of every map field before
+	// they are walked, and may rename their keys
+	Entries func(fd protoreflect.FieldDescriptor, path string, entries map[string]interface{})
+}
+
+// Walk walks obj, the decoded JSON of a message of type desc, replacing the
+// values of selected fields in place
+func (w Walker) Walk(desc protoreflect.MessageDescriptor, obj map[string]interface{}) {
+	if desc.ParentFile().Package() == wellKnownPackage {
+		return
+	}
+	w.walk(desc, obj, "")
+}
+
+// walk walks a message whose path ends in prefix
+func (w Walker) walk(desc protoreflect.MessageDescriptor, obj map[string]interface{}, prefix string) {
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+
+		key := fd.JSONName()
+		value, ok := obj[key]
+		if !ok {
+			key = fd.TextName()
+			if value, ok = obj[key]; !ok {
+				continue
+			}
+		}
+		path := prefix + key
+
+		switch {
+		case fd.IsMap():
+			entries, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if w.Entries != nil {
+				w.Entries(fd, path, entries)
+			}
+			keys := make([]string, 0, len(entries))
+			for k := range entries {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				entries[k] = w.walkValue(fd.MapValue(), entries[k], fmt.Sprintf("%s[%q]", path, k))
+			}
+		case fd.IsList():
+			items, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			for j, v := range items {
+				items[j] = w.walkValue(fd, v, fmt.Sprintf("%s[%d]", path, j))
+			}
+		default:
+			obj[key] = w.walkValue(fd, value, path)
+		}
+	}
+}
+
+// walkValue returns the value to keep for a single field value
+func (w Walker) walkValue(fd protoreflect.FieldDescriptor, value interface{}, path string) interface{} {
+	if w.Match != nil && w.Match(fd) {
+		return w.Visit(fd, path, value)
+	}
+	message := fd.Message()
+	if message == nil || message.ParentFile().Package() == wellKnownPackage {
+		return value
+	}
+	if obj, ok := value.(map[string]interface{}); ok {
+		w.walk(message, obj, path+".")
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package jsonwalk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+)
+
+// orderDescriptor builds a message with nested, repeated, map and well-known fields
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name, jsonName string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		if repeated {
+			fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+		return fd
+	}
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("order.proto"),
+		Package:    proto.String("shop"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/struct.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("sku", "sku", 1, str, "", false),
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("order_id", "orderId", 1, str, "", false),
+					field("items", "items", 2, msg, ".shop.Item", true),
+					field("by_sku", "bySku", 3, msg, ".shop.Order.BySkuEntry", true),
+					field("extra", "extra", 4, msg, ".google.protobuf.Struct", false),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("BySkuEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", "key", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", false),
+							field("value", "value", 2, msg, ".shop.Item", false),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return file.Messages().ByName("Order")
+}
+
+func TestWalker(t *testing.T) {
+	desc := orderDescriptor(t)
+	doc := map[string]interface{}{
+		"order_id": "o-1",
+		"items":    []interface{}{map[string]interface{}{"sku": "a"}, "not an object"},
+		"bySku":    map[string]interface{}{"7": map[string]interface{}{"sku": "b"}, "+8": map[string]interface{}{"sku": "c"}},
+		"extra":    map[string]interface{}{"sku": "not a field"},
+	}
+
+	var paths, entries []string
+	Walker{
+		Match: func(fd protoreflect.FieldDescriptor) bool { return fd.Kind() == protoreflect.StringKind },
+		Visit: func(fd protoreflect.FieldDescriptor, path string, value interface{}) interface{} {
+			paths = append(paths, path)
+			if s, ok := value.(string); ok {
+				return s + "!"
+			}
+			return value
+		},
+		Entries: func(fd protoreflect.FieldDescriptor, path string, values map[string]interface{}) {
+			entries = append(entries, path)
+			values["8"] = values["+8"]
+			delete(values, "+8")
+		},
+	}.Walk(desc, doc)
+
+	assert.Equal(t, []string{"order_id", "items[0].sku", `bySku["7"].sku`, `bySku["8"].sku`}, paths)
+	assert.Equal(t, []string{"bySku"}, entries)
+	assert.Equal(t, "o-1!", doc["order_id"])
+	assert.Equal(t, "a!", doc["items"].([]interface{})[0].(map[string]interface{})["sku"])
+	assert.Equal(t, "c!", doc["bySku"].(map[string]interface{})["8"].(map[string]interface{})["sku"])
+	assert.Equal(t, "not a field", doc["extra"].(map[string]interface{})["sku"], "well-known types are not descended into")
+}
@@ -0,0 +1,149 @@
+// Package loadtest drives scripted MCP traffic against a running gateway and
+// reports its throughput and latency, for capacity planning and for
+// comparing releases.
+package loadtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MCP methods a profile can send
+const (
+	MethodInitialize = "initialize"
+	MethodPing       = "ping"
+	MethodToolsList  = "tools/list"
+	MethodToolsCall  = "tools/call"
+)
+
+var supportedMethods = []string{MethodInitialize, MethodPing, MethodToolsList, MethodToolsCall}
+
+// Profile is a scripted traffic profile: virtual users run through the stages
+// in order, each sending a weighted mix of requests in its own session
+type Profile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	// Periods of the run, one after the other
+	Stages []Stage `yaml:"stages"`
+
+	// Requests the virtual users pick from, in proportion to their weight
+	Requests []Request `yaml:"requests"`
+}
+
+// Stage is a period with a fixed number of virtual users
+type Stage struct {
+	Duration    time.Duration `yaml:"duration"`
+	Concurrency int           `yaml:"concurrency"`
+}
+
+// Request is one kind of request of a profile
+type Request struct {
+	// MCP method; initialize starts a new session for the virtual user
+	Method string `yaml:"method"`
+
+	// Relative frequency of the request (1 if not set)
+	Weight int `yaml:"weight"`
+
+	// Tools called, as a path.Match pattern over the gateway's tool names;
+	// each call picks one of the matching tools at random (all if empty)
+	Tool string `yaml:"tool"`
+
+	// Arguments of the tool calls
+	Arguments map[string]interface{} `yaml:"arguments"`
+}
+
+// LoadProfile reads and validates a YAML profile
+func LoadProfile(filename string) (*Profile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	profile, err := ParseProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", filename, err)
+	}
+	return profile, nil
+}
+
+// ParseProfile parses and validates a YAML profile
+func ParseProfile(data []byte) (*Profile, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	profile := &Profile{}
+	if err := decoder.Decode(profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+	for i := range profile.Requests {
+		if profile.Requests[i].Weight == 0 {
+			profile.Requests[i].Weight = 1
+		}
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Validate checks the stages and requests of the profile
+func (p *Profile) Validate() error {
+	if p.Name == "" {
+		return errors.New("profile name cannot be empty")
+	}
+	if len(p.Stages) == 0 {
+		return errors.New("profile must have at least one stage")
+	}
+	for i, stage := range p.Stages {
+		if stage.Duration <= 0 {
+			return fmt.Errorf("stage %d: duration must be positive", i+1)
+		}
+		if stage.Concurrency <= 0 {
+			return fmt.Errorf("stage %d: concurrency must be positive", i+1)
+		}
+	}
+
+	if len(p.Requests) == 0 {
+		return errors.New("profile must have at least one request")
+	}
+	for i, request := range p.Requests {
+		if !slices.Contains(supportedMethods, request.Method) {
+			return fmt.Errorf("request %d: unsupported method %q", i+1, request.Method)
+		}
+		if request.Weight <= 0 {
+			return fmt.Errorf("request %d: weight must be positive", i+1)
+		}
+		if request.Method != MethodToolsCall && (request.Tool != "" || request.Arguments != nil) {
+			return fmt.Errorf("request %d: tool and arguments only apply to %s", i+1, MethodToolsCall)
+		}
+		if _, err := path.Match(request.Tool, ""); err != nil {
+			return fmt.Errorf("request %d: invalid tool pattern %q", i+1, request.Tool)
+		}
+	}
+	return nil
+}
+
+// Duration returns the total duration of the stages
+func (p *Profile) Duration() time.Duration {
+	var total time.Duration
+	for _, stage := range p.Stages {
+		total += stage.Duration
+	}
+	return total
+}
+
+// matches reports whether a tool is one the request calls
+func (r Request) matches(tool string) bool {
+	if r.Tool == "" {
+		return true
+	}
+	matched, _ := path.Match(r.Tool, tool)
+	return matched
+}
@@ -0,0 +1,85 @@
+package loadtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProfile(t *testing.T) {
+	profile, err := ParseProfile([]byte(`
+name: mixed
+stages:
+  - {duration: 10s, concurrency: 2}
+  - {duration: 1m, concurrency: 16}
+requests:
+  - method: tools/list
+  - method: tools/call
+    weight: 9
+    tool: shop_*
+    arguments: {query: shoes, filter: {size: 42}}
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "mixed", profile.Name)
+	assert.Equal(t, []Stage{{10 * time.Second, 2}, {time.Minute, 16}}, profile.Stages)
+	assert.Equal(t, 70*time.Second, profile.Duration())
+	require.Len(t, profile.Requests, 2)
+	assert.Equal(t, 1, profile.Requests[0].Weight, "weight defaults to 1")
+	assert.Equal(t, map[string]interface{}{"query": "shoes", "filter": map[string]interface{}{"size": 42}},
+		profile.Requests[1].Arguments)
+	assert.True(t, profile.Requests[1].matches("shop_orders_get"))
+	assert.False(t, profile.Requests[1].matches("billing_invoices_get"))
+	assert.True(t, profile.Requests[0].matches("anything"))
+}
+
+func TestParseProfile_Invalid(t *testing.T) {
+	stages := "stages: [{duration: 1s, concurrency: 1}]\n"
+	tests := []struct {
+		name    string
+		profile string
+		err     string
+	}{
+		{"no name", stages + "requests: [{method: ping}]", "profile name cannot be empty"},
+		{"no stages", "name: x\nrequests: [{method: ping}]", "at least one stage"},
+		{"zero duration", "name: x\nstages: [{concurrency: 1}]\nrequests: [{method: ping}]", "stage 1: duration must be positive"},
+		{"zero concurrency", "name: x\nstages: [{duration: 1s}]\nrequests: [{method: ping}]", "stage 1: concurrency must be positive"},
+		{"no requests", "name: x\n" + stages, "at least one request"},
+		{"unknown method", "name: x\n" + stages + "requests: [{method: tools/delete}]", `request 1: unsupported method "tools/delete"`},
+		{"negative weight", "name: x\n" + stages + "requests: [{method: ping, weight: -1}]", "request 1: weight must be positive"},
+		{"tool on ping", "name: x\n" + stages + "requests: [{method: ping, tool: a}]", "only apply to tools/call"},
+		{"bad pattern", "name: x\n" + stages + "requests: [{method: tools/call, tool: '['}]", "invalid tool pattern"},
+		{"unknown key", "name: x\n" + stages + "requests: [{method: ping, wieght: 2}]", "field wieght not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProfile([]byte(tt.profile))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestLoadProfile_Examples(t *testing.T) {
+	files, err := filepath.Glob("../../loadtest/profiles/*.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			profile, err := LoadProfile(file)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Base(file), profile.Name+".yaml")
+		})
+	}
+}
+
+func TestLoadProfile_Missing(t *testing.T) {
+	_, err := LoadProfile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
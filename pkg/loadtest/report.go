@@ -0,0 +1,237 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format identifies load test reports, so baselines can be checked before comparing
+const Format = "ggrmcp-loadtest/v1"
+
+// Name of the statistics over all requests
+const totalName = "total"
+
+// reportedErrors bounds the distinct error messages listed in a report
+const reportedErrors = 10
+
+// Report is the outcome of a load test run
+type Report struct {
+	Format  string `json:"format"`
+	Profile string `json:"profile"`
+
+	// MCP endpoint, and the server name and version it reported in initialize
+	Target        string `json:"target"`
+	Server        string `json:"server"`
+	ServerVersion string `json:"serverVersion"`
+
+	StartedAt       time.Time `json:"startedAt"`
+	DurationSeconds float64   `json:"durationSeconds"`
+
+	// Number of tools the gateway listed
+	Tools int `json:"tools"`
+
+	// Statistics over all requests, by MCP method and by stage
+	Total   Stats   `json:"total"`
+	Methods []Stats `json:"methods"`
+	Stages  []Stats `json:"stages"`
+
+	// Most frequent error messages
+	Errors []ErrorCount `json:"errors,omitempty"`
+}
+
+// Stats summarizes a set of requests
+type Stats struct {
+	Name     string `json:"name"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+
+	// Completed requests per second
+	Throughput float64 `json:"throughput"`
+
+	// Latency of the successful requests
+	Latency Latency `json:"latencyMs"`
+}
+
+// Latency holds latency statistics in milliseconds
+type Latency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// ErrorCount is an error message and how often it occurred
+type ErrorCount struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// ErrorRate returns the percentage of requests that failed
+func (s Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return 100 * float64(s.Errors) / float64(s.Requests)
+}
+
+// newStats summarizes requests over elapsed time; latencies are sorted in place
+func newStats(name string, latencies []time.Duration, errors int, elapsed time.Duration) Stats {
+	stats := Stats{Name: name, Requests: len(latencies) + errors, Errors: errors}
+	if elapsed > 0 {
+		stats.Throughput = round(float64(stats.Requests) / elapsed.Seconds())
+	}
+	if len(latencies) == 0 {
+		return stats
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+	stats.Latency = Latency{
+		Mean: milliseconds(sum / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P90:  milliseconds(percentile(latencies, 90)),
+		P95:  milliseconds(percentile(latencies, 95)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// milliseconds converts a duration to milliseconds, to the microsecond
+func milliseconds(d time.Duration) float64 {
+	return round(float64(d) / float64(time.Millisecond))
+}
+
+// round rounds to three decimals
+func round(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// WriteText writes the statistics as a table followed by the frequent errors
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	server := r.Server
+	if r.ServerVersion != "" {
+		server += " " + r.ServerVersion
+	}
+	fmt.Fprintf(&b, "Load test %s against %s (%s), %.1fs, %d tools\n", r.Profile, r.Target, server, r.DurationSeconds, r.Tools)
+	fmt.Fprintf(&b, "  %-24s %9s %7s %9s %8s %8s %8s %8s %8s %8s\n",
+		"", "requests", "errors", "req/s", "mean ms", "p50", "p90", "p95", "p99", "max")
+	rows := append(append([]Stats{}, r.Methods...), r.Stages...)
+	rows = append(rows, r.Total)
+	for _, stats := range rows {
+		fmt.Fprintf(&b, "  %-24s %9d %7d %9.1f %8.2f %8.2f %8.2f %8.2f %8.2f %8.2f\n",
+			stats.Name, stats.Requests, stats.Errors, stats.Throughput, stats.Latency.Mean,
+			stats.Latency.P50, stats.Latency.P90, stats.Latency.P95, stats.Latency.P99, stats.Latency.Max)
+	}
+	if len(r.Errors) > 0 {
+		b.WriteString("Errors:\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "  %7d  %s\n", e.Count, e.Message)
+		}
+	}
+	fmt.Fprintf(&b, "RESULT: %d requests, %.2f%% errors, %.1f req/s, p95 %.2f ms, p99 %.2f ms\n",
+		r.Total.Requests, r.Total.ErrorRate(), r.Total.Throughput, r.Total.Latency.P95, r.Total.Latency.P99)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// ReadReport reads a report written by WriteJSON, e.g. a baseline
+func ReadReport(filename string) (*Report, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	report := &Report{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", filename, err)
+	}
+	if report.Format != Format {
+		return nil, fmt.Errorf("report %s has format %q, expected %q", filename, report.Format, Format)
+	}
+	return report, nil
+}
+
+// Regression is a metric that got worse than a baseline allows
+type Regression struct {
+	Name     string  `json:"name"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+
+	// Change in percent, positive when worse
+	Change float64 `json:"change"`
+}
+
+// String describes the regression on one line
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %.2f -> %.2f (%+.1f%% worse)", r.Name, r.Metric, r.Baseline, r.Current, r.Change)
+}
+
+// Compare returns the throughput drops and p95/p99 latency increases of the
+// current report over the baseline beyond threshold percent, for all
+// requests and for each method both reports have
+func Compare(baseline, current *Report, threshold float64) []Regression {
+	baselineMethods := make(map[string]Stats, len(baseline.Methods))
+	for _, stats := range baseline.Methods {
+		baselineMethods[stats.Name] = stats
+	}
+
+	var regressions []Regression
+	compare := func(before, after Stats) {
+		check := func(metric string, beforeValue, afterValue, change float64) {
+			if beforeValue > 0 && change > threshold {
+				regressions = append(regressions, Regression{
+					Name: after.Name, Metric: metric, Baseline: beforeValue, Current: afterValue, Change: round(change),
+				})
+			}
+		}
+		// Throughput gets worse as it drops, latency as it rises
+		check("throughput", before.Throughput, after.Throughput, -percentChange(before.Throughput, after.Throughput))
+		check("p95 ms", before.Latency.P95, after.Latency.P95, percentChange(before.Latency.P95, after.Latency.P95))
+		check("p99 ms", before.Latency.P99, after.Latency.P99, percentChange(before.Latency.P99, after.Latency.P99))
+	}
+
+	compare(baseline.Total, current.Total)
+	for _, stats := range current.Methods {
+		if before, ok := baselineMethods[stats.Name]; ok {
+			compare(before, stats)
+		}
+	}
+	return regressions
+}
+
+// percentChange returns the change from before to after in percent of before
+func percentChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return (after - before) / before * 100
+}
@@ -0,0 +1,113 @@
+package loadtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStats(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := newStats("tools/call", latencies, 25, 5*time.Second)
+
+	assert.Equal(t, 125, stats.Requests)
+	assert.Equal(t, 25, stats.Errors)
+	assert.Equal(t, 25.0, stats.Throughput)
+	assert.Equal(t, 20.0, stats.ErrorRate())
+	assert.Equal(t, Latency{Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}, stats.Latency)
+}
+
+func TestNewStats_Empty(t *testing.T) {
+	stats := newStats("ping", nil, 0, 0)
+	assert.Equal(t, Stats{Name: "ping"}, stats)
+	assert.Zero(t, stats.ErrorRate())
+}
+
+func testReport() *Report {
+	return &Report{
+		Format: Format, Profile: "steady", Target: "http://localhost:50062/",
+		Server: "ggRMCP", ServerVersion: "v1.4.0", DurationSeconds: 60, Tools: 50,
+		Total: Stats{Name: totalName, Requests: 60000, Errors: 6, Throughput: 1000,
+			Latency: Latency{Mean: 10, P50: 8, P90: 15, P95: 20, P99: 40, Max: 90}},
+		Methods: []Stats{
+			{Name: "tools/call", Requests: 54000, Errors: 6, Throughput: 900, Latency: Latency{P95: 22, P99: 44}},
+			{Name: "tools/list", Requests: 6000, Throughput: 100, Latency: Latency{P95: 5, P99: 9}},
+		},
+		Errors: []ErrorCount{{Message: "tools/call: server returned status 503", Count: 6}},
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, testReport().WriteText(&b))
+
+	text := b.String()
+	assert.Contains(t, text, "Load test steady against http://localhost:50062/ (ggRMCP v1.4.0), 60.0s, 50 tools")
+	assert.Contains(t, text, "tools/list")
+	assert.Contains(t, text, "      6  tools/call: server returned status 503")
+	assert.Contains(t, text, "RESULT: 60000 requests, 0.01% errors, 1000.0 req/s, p95 20.00 ms, p99 40.00 ms")
+}
+
+func TestReport_JSONRoundTrip(t *testing.T) {
+	report := testReport()
+	path := filepath.Join(t.TempDir(), "report.json")
+	var b bytes.Buffer
+	require.NoError(t, report.WriteJSON(&b))
+	require.NoError(t, os.WriteFile(path, b.Bytes(), 0o600))
+
+	read, err := ReadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report, read)
+	assert.Contains(t, b.String(), `"latencyMs"`)
+}
+
+func TestReadReport_WrongFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"format":"ggrmcp-tool-inventory/v1"}`), 0o600))
+
+	_, err := ReadReport(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `expected "ggrmcp-loadtest/v1"`)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := testReport()
+
+	t.Run("within threshold", func(t *testing.T) {
+		current := testReport()
+		current.Total.Throughput = 850
+		current.Total.Latency.P99 = 47
+		assert.Empty(t, Compare(baseline, current, 20))
+	})
+
+	t.Run("regressed", func(t *testing.T) {
+		current := testReport()
+		current.Total.Throughput = 700
+		current.Methods[0].Latency.P95 = 33
+		// Methods missing from the baseline are not compared
+		current.Methods = append(current.Methods, Stats{Name: "ping", Latency: Latency{P95: 100}})
+
+		regressions := Compare(baseline, current, 20)
+		assert.Equal(t, []Regression{
+			{Name: totalName, Metric: "throughput", Baseline: 1000, Current: 700, Change: 30},
+			{Name: "tools/call", Metric: "p95 ms", Baseline: 22, Current: 33, Change: 50},
+		}, regressions)
+		assert.Equal(t, "total throughput: 1000.00 -> 700.00 (+30.0% worse)", regressions[0].String())
+	})
+
+	t.Run("improved", func(t *testing.T) {
+		current := testReport()
+		current.Total.Throughput = 2000
+		current.Total.Latency.P99 = 10
+		assert.Empty(t, Compare(baseline, current, 20))
+	})
+}
@@ -0,0 +1,431 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// protocolVersion is the MCP version the virtual users initialize with
+const protocolVersion = "2024-11-05"
+
+// requestTimeout bounds a single request of the default client
+const requestTimeout = 30 * time.Second
+
+// maxErrorMessages bounds the distinct error messages counted; the rest are
+// counted together
+const maxErrorMessages = 100
+
+// Options contains the gateway a profile runs against
+type Options struct {
+	// URL of the gateway's MCP endpoint
+	URL string
+
+	Profile *Profile
+
+	// Headers added to every request, e.g. Authorization
+	Headers map[string]string
+
+	// Client sending the requests; one keeping a connection per virtual
+	// user if nil
+	Client *http.Client
+
+	// Progress gets a line per finished stage, if set
+	Progress io.Writer
+}
+
+// Run discovers the gateway's tools, then runs the profile's stages and
+// reports the requests that completed; requests cut off at the end of a
+// stage are not counted. If ctx is canceled, Run returns the report of the
+// stages run so far with ctx's error.
+func Run(ctx context.Context, options Options) (*Report, error) {
+	profile := options.Profile
+	c := newClient(options)
+
+	// Discover the tools in a session of its own
+	session, server, err := c.initialize(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	tools, err := c.listTools(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+	m, err := newMix(profile.Requests, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Format:        Format,
+		Profile:       profile.Name,
+		Target:        options.URL,
+		Server:        server.Name,
+		ServerVersion: server.Version,
+		StartedAt:     time.Now().UTC(),
+		Tools:         len(tools),
+	}
+	total := newRecorder()
+	var elapsed time.Duration
+	for i, stage := range profile.Stages {
+		stageRecorder, stageElapsed := runStage(ctx, c, m, stage)
+		total.merge(stageRecorder)
+		elapsed += stageElapsed
+
+		stats := newStats(fmt.Sprintf("stage %d (%d users)", i+1, stage.Concurrency),
+			stageRecorder.allLatencies(), stageRecorder.errorCount(), stageElapsed)
+		report.Stages = append(report.Stages, stats)
+		if options.Progress != nil {
+			fmt.Fprintf(options.Progress, "%s: %d requests, %d errors, %.1f req/s, p95 %.2f ms\n",
+				stats.Name, stats.Requests, stats.Errors, stats.Throughput, stats.Latency.P95)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	report.DurationSeconds = round(elapsed.Seconds())
+	report.Total = newStats(totalName, total.allLatencies(), total.errorCount(), elapsed)
+	for _, method := range total.methods() {
+		report.Methods = append(report.Methods, newStats(method, total.latencies[method], total.errors[method], elapsed))
+	}
+	report.Errors = total.frequentErrors(reportedErrors)
+	return report, ctx.Err()
+}
+
+// runStage runs the stage's virtual users until its duration is over
+func runStage(ctx context.Context, c *client, m *mix, stage Stage) (*recorder, time.Duration) {
+	stageCtx, cancel := context.WithTimeout(ctx, stage.Duration)
+	defer cancel()
+
+	start := time.Now()
+	recorders := make([]*recorder, stage.Concurrency)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		recorders[i] = newRecorder()
+		wg.Add(1)
+		go func(r *recorder) {
+			defer wg.Done()
+			runUser(stageCtx, c, m, r)
+		}(recorders[i])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	stageRecorder := newRecorder()
+	for _, r := range recorders {
+		stageRecorder.merge(r)
+	}
+	return stageRecorder, elapsed
+}
+
+// runUser sends requests as one virtual user until ctx is done, starting
+// with a session of its own
+func runUser(ctx context.Context, c *client, m *mix, r *recorder) {
+	session := ""
+	for {
+		request, tool := m.pick()
+		if session == "" {
+			request, tool = Request{Method: MethodInitialize}, ""
+		}
+
+		start := time.Now()
+		newSession, err := c.send(ctx, session, request, tool)
+		latency := time.Since(start)
+		if ctx.Err() != nil {
+			return
+		}
+		r.record(request.Method, latency, err)
+		if request.Method == MethodInitialize && err == nil {
+			session = newSession
+		}
+	}
+}
+
+// mix picks requests of a profile in proportion to their weight
+type mix struct {
+	requests []Request
+
+	// Cumulative weights, and the tools each request may call
+	cumulative []int
+	tools      [][]string
+}
+
+// newMix matches the profile's tool calls to the listed tools
+func newMix(requests []Request, tools []string) (*mix, error) {
+	m := &mix{requests: requests, cumulative: make([]int, len(requests)), tools: make([][]string, len(requests))}
+	sum := 0
+	for i, request := range requests {
+		sum += request.Weight
+		m.cumulative[i] = sum
+		if request.Method != MethodToolsCall {
+			continue
+		}
+		for _, tool := range tools {
+			if request.matches(tool) {
+				m.tools[i] = append(m.tools[i], tool)
+			}
+		}
+		if len(m.tools[i]) == 0 {
+			return nil, fmt.Errorf("request %d: no tool matches %q", i+1, request.Tool)
+		}
+	}
+	return m, nil
+}
+
+// pick returns a random request and, for tool calls, the tool to call
+func (m *mix) pick() (Request, string) {
+	n := rand.IntN(m.cumulative[len(m.cumulative)-1])
+	i := sort.SearchInts(m.cumulative, n+1)
+	if tools := m.tools[i]; len(tools) > 0 {
+		return m.requests[i], tools[rand.IntN(len(tools))]
+	}
+	return m.requests[i], ""
+}
+
+// recorder collects latencies and errors by method
+type recorder struct {
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	messages  map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		messages:  make(map[string]int),
+	}
+}
+
+// record adds a completed request
+func (r *recorder) record(method string, latency time.Duration, err error) {
+	if err == nil {
+		r.latencies[method] = append(r.latencies[method], latency)
+		return
+	}
+	r.errors[method]++
+	r.addMessage(method+": "+err.Error(), 1)
+}
+
+// addMessage counts an error message, or other errors once there are too many
+func (r *recorder) addMessage(message string, count int) {
+	if _, ok := r.messages[message]; !ok && len(r.messages) >= maxErrorMessages {
+		message = "other errors"
+	}
+	r.messages[message] += count
+}
+
+// merge adds the requests of another recorder
+func (r *recorder) merge(other *recorder) {
+	for method, latencies := range other.latencies {
+		r.latencies[method] = append(r.latencies[method], latencies...)
+	}
+	for method, count := range other.errors {
+		r.errors[method] += count
+	}
+	for message, count := range other.messages {
+		r.addMessage(message, count)
+	}
+}
+
+// methods returns the methods requests were sent for, sorted
+func (r *recorder) methods() []string {
+	seen := make(map[string]bool)
+	for method := range r.latencies {
+		seen[method] = true
+	}
+	for method := range r.errors {
+		seen[method] = true
+	}
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// allLatencies returns the latencies of all methods
+func (r *recorder) allLatencies() []time.Duration {
+	var all []time.Duration
+	for _, latencies := range r.latencies {
+		all = append(all, latencies...)
+	}
+	return all
+}
+
+// errorCount returns the number of failed requests
+func (r *recorder) errorCount() int {
+	total := 0
+	for _, count := range r.errors {
+		total += count
+	}
+	return total
+}
+
+// frequentErrors returns the most frequent error messages, most frequent first
+func (r *recorder) frequentErrors(limit int) []ErrorCount {
+	counts := make([]ErrorCount, 0, len(r.messages))
+	for message, count := range r.messages {
+		counts = append(counts, ErrorCount{Message: message, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Message < counts[j].Message
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+// client sends JSON-RPC requests to the MCP endpoint
+type client struct {
+	url     string
+	headers map[string]string
+	http    *http.Client
+	nextID  atomic.Int64
+}
+
+// newClient creates a client for the options' endpoint
+func newClient(options Options) *client {
+	httpClient := options.Client
+	if httpClient == nil {
+		users := 0
+		for _, stage := range options.Profile.Stages {
+			users = max(users, stage.Concurrency)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = users
+		httpClient = &http.Client{Transport: transport, Timeout: requestTimeout}
+	}
+	return &client{url: options.URL, headers: options.Headers, http: httpClient}
+}
+
+// send sends one request of the profile, returning the session started by initialize
+func (c *client) send(ctx context.Context, session string, request Request, tool string) (string, error) {
+	switch request.Method {
+	case MethodInitialize:
+		newSession, _, err := c.initialize(ctx)
+		return newSession, err
+	case MethodToolsCall:
+		result := &mcp.ToolCallResult{}
+		_, err := c.call(ctx, session, MethodToolsCall, mcp.ToolsCallParams{Name: tool, Arguments: request.Arguments}, result)
+		if err == nil && result.IsError {
+			err = toolError(result)
+		}
+		return "", err
+	default:
+		_, err := c.call(ctx, session, request.Method, nil, nil)
+		return "", err
+	}
+}
+
+// initialize starts a session, returning its ID and the server's info
+func (c *client) initialize(ctx context.Context) (string, mcp.ServerInfo, error) {
+	params := mcp.InitializeParams{
+		ProtocolVersion: protocolVersion,
+		Capabilities:    map[string]json.RawMessage{},
+		ClientInfo:      mcp.ClientInfo{Name: "ggrmcp-loadtest", Version: "1.0.0"},
+	}
+	result := &mcp.InitializationResult{}
+	session, err := c.call(ctx, "", MethodInitialize, params, result)
+	return session, result.ServerInfo, err
+}
+
+// listTools returns the names of the gateway's tools
+func (c *client) listTools(ctx context.Context, session string) ([]string, error) {
+	result := &mcp.ToolsListResult{}
+	if _, err := c.call(ctx, session, MethodToolsList, nil, result); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(result.Tools))
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names, nil
+}
+
+// call posts a request in a session and decodes its result, returning the
+// session ID of the response
+func (c *client) call(ctx context.Context, session, method string, params interface{}, result interface{}) (string, error) {
+	request := mcp.JSONRPCRequest{JSONRPC: "2.0", Method: method, ID: mcp.RequestID{Value: c.nextID.Add(1)}}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode params: %w", err)
+		}
+		request.Params = data
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if session != "" {
+		req.Header.Set("Mcp-Session-Id", session)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *mcp.RPCError   `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Error != nil {
+		return "", response.Error
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return "", fmt.Errorf("failed to parse result: %w", err)
+		}
+	}
+	return resp.Header.Get("Mcp-Session-Id"), nil
+}
+
+// toolError describes a tool call result flagged as an error
+func toolError(result *mcp.ToolCallResult) error {
+	for _, content := range result.Content {
+		if content.Type == mcp.ContentTypeText && content.Text != "" {
+			return fmt.Errorf("tool error: %s", content.Text)
+		}
+	}
+	return errors.New("tool error")
+}
@@ -0,0 +1,230 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGateway answers MCP requests like the gateway, recording what it was sent
+type fakeGateway struct {
+	sessions atomic.Int64
+
+	mu      sync.Mutex
+	calls   map[string]int
+	headers []string
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request mcp.JSONRPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	var rpcErr *mcp.RPCError
+	switch request.Method {
+	case MethodInitialize:
+		w.Header().Set("Mcp-Session-Id", fmt.Sprintf("session-%d", g.sessions.Add(1)))
+		result = mcp.InitializationResult{ServerInfo: mcp.ServerInfo{Name: "fake", Version: "v9.9.9"}}
+	case MethodToolsList:
+		result = mcp.ToolsListResult{Tools: []mcp.Tool{{Name: "shop_get"}, {Name: "shop_list"}, {Name: "broken"}}}
+	case MethodToolsCall:
+		var params mcp.ToolsCallParams
+		_ = request.DecodeParams(&params)
+		g.mu.Lock()
+		g.calls[params.Name]++
+		g.headers = append(g.headers, r.Header.Get("Authorization")+"/"+r.Header.Get("Mcp-Session-Id"))
+		g.mu.Unlock()
+		if params.Name == "broken" {
+			result = mcp.ToolCallResult{Content: []mcp.ContentBlock{mcp.TextContent("backend unavailable")}, IsError: true}
+		} else {
+			result = mcp.ToolCallResult{Content: []mcp.ContentBlock{mcp.TextContent("{}")}}
+		}
+	case MethodPing:
+		result = map[string]interface{}{}
+	default:
+		rpcErr = mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, "Method not found")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(mcp.JSONRPCResponse{JSONRPC: "2.0", ID: request.ID, Result: result, Error: rpcErr})
+}
+
+func newFakeGateway(t *testing.T) (*fakeGateway, *httptest.Server) {
+	gateway := &fakeGateway{calls: make(map[string]int)}
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return gateway, server
+}
+
+func TestRun(t *testing.T) {
+	gateway, server := newFakeGateway(t)
+	profile := &Profile{
+		Name: "test",
+		Stages: []Stage{
+			{Duration: 100 * time.Millisecond, Concurrency: 2},
+			{Duration: 100 * time.Millisecond, Concurrency: 4},
+		},
+		Requests: []Request{
+			{Method: MethodPing, Weight: 1},
+			{Method: MethodToolsCall, Weight: 4, Tool: "shop_*", Arguments: map[string]interface{}{"id": "1"}},
+		},
+	}
+	var progress bytes.Buffer
+
+	report, err := Run(context.Background(), Options{
+		URL: server.URL, Profile: profile, Headers: map[string]string{"Authorization": "Bearer token"}, Progress: &progress,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Format, report.Format)
+	assert.Equal(t, "test", report.Profile)
+	assert.Equal(t, "fake", report.Server)
+	assert.Equal(t, "v9.9.9", report.ServerVersion)
+	assert.Equal(t, 3, report.Tools)
+	assert.InDelta(t, 0.2, report.DurationSeconds, 0.1)
+
+	// Every virtual user initialized its own session, then sent the mix
+	names := []string{}
+	for _, stats := range report.Methods {
+		names = append(names, stats.Name)
+	}
+	assert.Equal(t, []string{MethodInitialize, MethodPing, MethodToolsCall}, names)
+	assert.Equal(t, 6, report.Methods[0].Requests)
+	assert.Equal(t, int64(7), gateway.sessions.Load(), "one session for discovery and one per user")
+	assert.Greater(t, report.Methods[2].Requests, report.Methods[1].Requests)
+
+	assert.Zero(t, report.Total.Errors)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, report.Methods[0].Requests+report.Methods[1].Requests+report.Methods[2].Requests, report.Total.Requests)
+	assert.Positive(t, report.Total.Throughput)
+	assert.Positive(t, report.Total.Latency.P99)
+
+	require.Len(t, report.Stages, 2)
+	assert.Equal(t, "stage 1 (2 users)", report.Stages[0].Name)
+	assert.Equal(t, "stage 2 (4 users)", report.Stages[1].Name)
+	assert.Equal(t, report.Total.Requests, report.Stages[0].Requests+report.Stages[1].Requests)
+	assert.Contains(t, progress.String(), "stage 2 (4 users): ")
+
+	// Only the matching tools were called, with the headers and sessions
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+	assert.Zero(t, gateway.calls["broken"])
+	assert.Positive(t, gateway.calls["shop_get"])
+	assert.Positive(t, gateway.calls["shop_list"])
+	for _, header := range gateway.headers {
+		assert.Regexp(t, `^Bearer token/session-\d+$`, header)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	_, server := newFakeGateway(t)
+	profile := &Profile{
+		Name:   "errors",
+		Stages: []Stage{{Duration: 50 * time.Millisecond, Concurrency: 1}},
+		Requests: []Request{
+			{Method: MethodToolsCall, Weight: 1, Tool: "broken"},
+		},
+	}
+
+	report, err := Run(context.Background(), Options{URL: server.URL, Profile: profile})
+	require.NoError(t, err)
+
+	require.Len(t, report.Methods, 2)
+	calls := report.Methods[1]
+	assert.Equal(t, MethodToolsCall, calls.Name)
+	assert.Positive(t, calls.Errors)
+	assert.Equal(t, calls.Requests, calls.Errors)
+	assert.Zero(t, calls.Latency, "latencies are of successful requests")
+	assert.Equal(t, []ErrorCount{{Message: "tools/call: tool error: backend unavailable", Count: calls.Errors}}, report.Errors)
+}
+
+func TestRun_NoMatchingTool(t *testing.T) {
+	_, server := newFakeGateway(t)
+	profile := &Profile{
+		Name:     "missing",
+		Stages:   []Stage{{Duration: time.Second, Concurrency: 1}},
+		Requests: []Request{{Method: MethodToolsCall, Weight: 1, Tool: "billing_*"}},
+	}
+
+	_, err := Run(context.Background(), Options{URL: server.URL, Profile: profile})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `request 1: no tool matches "billing_*"`)
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	_, server := newFakeGateway(t)
+	url := server.URL
+	server.Close()
+	profile := &Profile{
+		Name:     "down",
+		Stages:   []Stage{{Duration: time.Second, Concurrency: 1}},
+		Requests: []Request{{Method: MethodPing, Weight: 1}},
+	}
+
+	_, err := Run(context.Background(), Options{URL: url, Profile: profile})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to initialize")
+}
+
+func TestRun_Canceled(t *testing.T) {
+	_, server := newFakeGateway(t)
+	profile := &Profile{
+		Name: "long",
+		Stages: []Stage{
+			{Duration: time.Minute, Concurrency: 1},
+			{Duration: time.Minute, Concurrency: 1},
+		},
+		Requests: []Request{{Method: MethodPing, Weight: 1}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	report, err := Run(ctx, Options{URL: server.URL, Profile: profile})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, report, "the stages run so far are reported")
+	assert.Len(t, report.Stages, 1)
+	assert.Positive(t, report.Total.Requests)
+}
+
+func TestRecorder_BoundsErrorMessages(t *testing.T) {
+	r := newRecorder()
+	for i := 0; i < maxErrorMessages+10; i++ {
+		r.record(MethodPing, 0, fmt.Errorf("error %d", i))
+	}
+
+	assert.Len(t, r.messages, maxErrorMessages+1)
+	assert.Equal(t, 10, r.messages["other errors"])
+	assert.Equal(t, maxErrorMessages+10, r.errorCount())
+	assert.Len(t, r.frequentErrors(reportedErrors), reportedErrors)
+}
+
+func TestMix_Pick(t *testing.T) {
+	m, err := newMix([]Request{
+		{Method: MethodPing, Weight: 1},
+		{Method: MethodToolsCall, Weight: 3},
+	}, []string{"a", "b"})
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		request, tool := m.pick()
+		counts[request.Method+":"+tool]++
+	}
+	assert.InDelta(t, 1000, counts["ping:"], 200)
+	assert.InDelta(t, 1500, counts["tools/call:a"], 250)
+	assert.InDelta(t, 1500, counts["tools/call:b"], 250)
+}
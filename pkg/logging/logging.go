@@ -0,0 +1,40 @@
+// Package logging carries the fields identifying a request through its
+// context, so components log a call's entries correlated without passing
+// the fields along themselves.
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// fieldsKey is the context key of the log fields
+type fieldsKey struct{}
+
+// WithFields returns a context whose loggers add the fields, after any the
+// context already carries, to every entry
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing := Fields(ctx)
+	combined := make([]zap.Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(ctx, fieldsKey{}, combined)
+}
+
+// Fields returns the log fields carried by the context, if any
+func Fields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return fields
+}
+
+// FromContext returns the component's logger with the context's fields.
+// Without fields the logger is returned as it is. The fields are only encoded
+// once the logger writes an entry, so hot paths can call it for debug logs.
+func FromContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := Fields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.WithLazy(fields...)
+}
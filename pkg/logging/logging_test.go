@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).Named("discovery")
+
+	// Without fields the component logger is used as it is
+	assert.Same(t, logger, FromContext(context.Background(), logger))
+
+	ctx := WithFields(context.Background(), zap.String("requestId", "r-1"), zap.String("sessionId", "s-1"))
+	ctx = WithFields(ctx, zap.String("toolName", "shop_orders_get"))
+	FromContext(ctx, logger).Info("Invoking gRPC method", zap.String("method", "shop.Orders.Get"))
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "discovery", entries[0].LoggerName)
+	assert.Equal(t, map[string]interface{}{
+		"requestId": "r-1",
+		"sessionId": "s-1",
+		"toolName":  "shop_orders_get",
+		"method":    "shop.Orders.Get",
+	}, entries[0].ContextMap())
+
+	// Adding fields leaves the parent context's fields alone
+	assert.Len(t, Fields(WithFields(ctx, zap.String("callId", "c-1"))), 4)
+	assert.Len(t, Fields(ctx), 3)
+}
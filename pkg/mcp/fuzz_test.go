@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzDecodeRequest decodes and validates requests as the MCP endpoint does,
+// then decodes tools/call params the way the tool call pipeline reads them
+func FuzzDecodeRequest(f *testing.F) {
+	for _, seed := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"shop_orders_get","arguments":{"id":"7"}}}`,
+		`{"jsonrpc":"2.0","id":"a","method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{"roots":{}},"clientInfo":{"name":"c"}}}`,
+		`{"jsonrpc":"2.0","id":null,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":[],"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":7}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":[1]}`,
+		`{"jsonrpc":"2.0","id":1,"method":"x","params":{"a":[[[[[[[[[[[1]]]]]]]]]]]}}`,
+		`{"jsonrpc":"1.0","id":1e400,"method":"\u0000"}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	validator := NewValidator()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var req JSONRPCRequest
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+		if err := validator.ValidateRequest(&req); err != nil {
+			assertSanitized(t, SanitizeError(err))
+			return
+		}
+		if req.ID.Value == nil {
+			t.Fatalf("request without an ID passed validation: %s", data)
+		}
+
+		var initialize InitializeParams
+		if err := req.DecodeParams(&initialize); err != nil {
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) || rpcErr.Code != ErrorCodeInvalidParams {
+				t.Fatalf("params error is not an invalid params error: %v", err)
+			}
+		}
+
+		var call ToolsCallParams
+		if err := req.DecodeParams(&call); err != nil {
+			return
+		}
+		params := call.Map()
+		if err := validator.ValidateToolCallParams(params); err != nil {
+			assertSanitized(t, SanitizeError(err))
+			return
+		}
+		// The handler reads the name of validated params without checking it
+		if name, ok := params["name"].(string); !ok || !isValidToolName(name) {
+			t.Fatalf("invalid tool name passed validation: %#v", params["name"])
+		}
+	})
+}
+
+// FuzzToolName checks tool names given in tools/call params
+func FuzzToolName(f *testing.F) {
+	for _, seed := range []string{
+		"shop_orders_get", "shop.v1.Orders.Get", "", " ", "a/b", "ünïcode", "tool\x00name",
+		strings.Repeat("a", 128), strings.Repeat("a", 129),
+	} {
+		f.Add(seed)
+	}
+
+	validator := NewValidator()
+	f.Fuzz(func(t *testing.T, name string) {
+		err := validator.ValidateToolCallParams(map[string]interface{}{"name": name})
+		valid := name != "" && len(name) <= validator.maxToolName && isValidToolName(name)
+		if valid != (err == nil) {
+			t.Fatalf("name %q: valid %v, validation error %v", name, valid, err)
+		}
+		if err != nil {
+			assertSanitized(t, SanitizeError(err))
+		}
+	})
+}
+
+// FuzzSanitizeString checks that sanitized strings are safe to return to clients
+func FuzzSanitizeString(f *testing.F) {
+	for _, seed := range []string{"plain", "line\nbreak\x00", strings.Repeat("é", 600), "a" + strings.Repeat("é", 600), "\xff\xfe"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		sanitized := SanitizeString(s)
+		assertSanitized(t, sanitized)
+		if utf8.ValidString(s) && !utf8.ValidString(sanitized) {
+			t.Fatalf("sanitizing %q split a character: %q", s, sanitized)
+		}
+	})
+}
+
+// assertSanitized fails unless a client-facing message is bounded and free of control characters
+func assertSanitized(t *testing.T, message string) {
+	t.Helper()
+	if len(message) > 1024 {
+		t.Fatalf("message is %d bytes long", len(message))
+	}
+	if utf8.ValidString(message) && strings.ContainsFunc(message, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		t.Fatalf("message contains control characters: %q", message)
+	}
+}
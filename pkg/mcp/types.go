@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -15,7 +16,8 @@ func (r RequestID) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.Value)
 }
 
-// UnmarshalJSON implements json.Unmarshaler
+// UnmarshalJSON implements json.Unmarshaler. A null ID, as in error responses
+// to unparseable requests, leaves Value nil.
 func (r *RequestID) UnmarshalJSON(data []byte) error {
 	var v interface{}
 	if err := json.Unmarshal(data, &v); err != nil {
@@ -23,7 +25,7 @@ func (r *RequestID) UnmarshalJSON(data []byte) error {
 	}
 
 	switch v := v.(type) {
-	case string, float64:
+	case nil, string, float64:
 		r.Value = v
 	default:
 		return fmt.Errorf("invalid request ID type: %T", v)
@@ -37,12 +39,93 @@ func (r RequestID) String() string {
 	return fmt.Sprintf("%v", r.Value)
 }
 
-// JSONRPCRequest represents a JSON-RPC 2.0 request
+// JSONRPCRequest represents a JSON-RPC 2.0 request. Params are kept raw and
+// decoded by the method's handler with DecodeParams.
 type JSONRPCRequest struct {
-	JSONRPC string                 `json:"jsonrpc"`
-	Method  string                 `json:"method"`
-	Params  map[string]interface{} `json:"params,omitempty"`
-	ID      RequestID              `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      RequestID       `json:"id"`
+}
+
+// DecodeParams decodes the request's params into v, keeping numbers as
+// json.Number. Absent params leave v unchanged.
+func (r *JSONRPCRequest) DecodeParams(v interface{}) error {
+	if len(r.Params) == 0 || string(r.Params) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(r.Params))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return NewRPCError(ErrorCodeInvalidParams, fmt.Sprintf("Invalid params: %v", err))
+	}
+	return nil
+}
+
+// InitializeParams are the params of an initialize request
+type InitializeParams struct {
+	ProtocolVersion string                     `json:"protocolVersion"`
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	ClientInfo      ClientInfo                 `json:"clientInfo"`
+
+	// Toolset profile the client selects, a gateway extension
+	Profile string `json:"profile,omitempty"`
+}
+
+// ToolsCallParams are the params of a tools/call request. RawArguments keeps
+// the arguments as the client wrote them, so they can be passed on unchanged.
+type ToolsCallParams struct {
+	Name         string                 `json:"name"`
+	Arguments    map[string]interface{} `json:"arguments,omitempty"`
+	Meta         map[string]interface{} `json:"_meta,omitempty"`
+	RawArguments json.RawMessage        `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping numbers as json.Number
+// and the raw bytes of the arguments
+func (p *ToolsCallParams) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+		Meta      json.RawMessage `json:"_meta"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*p = ToolsCallParams{Name: fields.Name}
+	if err := decodeObject(fields.Arguments, &p.Arguments); err != nil {
+		return fmt.Errorf("arguments: %w", err)
+	}
+	if err := decodeObject(fields.Meta, &p.Meta); err != nil {
+		return fmt.Errorf("_meta: %w", err)
+	}
+	if p.Arguments != nil {
+		p.RawArguments = fields.Arguments
+	}
+	return nil
+}
+
+// decodeObject decodes an optional JSON object, keeping numbers as json.Number
+func decodeObject(data json.RawMessage, v *map[string]interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// Map returns the params in the form the tool call pipeline works on
+func (p ToolsCallParams) Map() map[string]interface{} {
+	params := map[string]interface{}{"name": p.Name}
+	if p.Arguments != nil {
+		params["arguments"] = p.Arguments
+	}
+	if p.Meta != nil {
+		params["_meta"] = p.Meta
+	}
+	return params
 }
 
 // JSONRPCResponse represents a JSON-RPC 2.0 response
@@ -74,9 +157,26 @@ const (
 	ErrorCodeInternalError  = -32603
 )
 
+// Gateway-specific error codes (JSON-RPC server error range)
+const (
+	ErrorCodeResourceNotFound = -32002
+	ErrorCodePermissionDenied = -32003
+	ErrorCodeQuotaExceeded    = -32004
+	ErrorCodeUnavailable      = -32005 // temporarily unavailable, retry later
+)
+
+// NewRPCError creates a JSON-RPC error that can be returned as a Go error
+func NewRPCError(code int, message string) *RPCError {
+	return &RPCError{
+		Code:    code,
+		Message: message,
+	}
+}
+
 // ServerInfo represents the server information
 type ServerInfo struct {
 	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
 	Version string `json:"version"`
 }
 
@@ -88,9 +188,11 @@ type ClientInfo struct {
 
 // ServerCapabilities represents server capabilities
 type ServerCapabilities struct {
-	Tools     *ToolsCapability     `json:"tools,omitempty"`
-	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
-	Resources *ResourcesCapability `json:"resources,omitempty"`
+	Tools        *ToolsCapability       `json:"tools,omitempty"`
+	Prompts      *PromptsCapability     `json:"prompts,omitempty"`
+	Resources    *ResourcesCapability   `json:"resources,omitempty"`
+	Completions  *CompletionsCapability `json:"completions,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
 // ToolsCapability represents tools capability
@@ -108,11 +210,17 @@ type ResourcesCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+// CompletionsCapability represents argument completion capability
+type CompletionsCapability struct{}
+
 // InitializationResult represents the initialization result
 type InitializationResult struct {
 	ProtocolVersion string             `json:"protocolVersion"`
 	Capabilities    ServerCapabilities `json:"capabilities"`
 	ServerInfo      ServerInfo         `json:"serverInfo"`
+
+	// Guidance for the model on using the server's tools
+	Instructions string `json:"instructions,omitempty"`
 }
 
 // ContentType represents different content types
@@ -122,14 +230,22 @@ const (
 	ContentTypeText  ContentType = "text"
 	ContentTypeImage ContentType = "image"
 	ContentTypeAudio ContentType = "audio"
+
+	ContentTypeResource     ContentType = "resource"
+	ContentTypeResourceLink ContentType = "resource_link"
 )
 
 // ContentBlock represents a content block
 type ContentBlock struct {
-	Type     ContentType `json:"type"`
-	Text     string      `json:"text,omitempty"`
-	Data     string      `json:"data,omitempty"`
-	MimeType string      `json:"mimeType,omitempty"`
+	Type        ContentType       `json:"type"`
+	Text        string            `json:"text,omitempty"`
+	Data        string            `json:"data,omitempty"`
+	MimeType    string            `json:"mimeType,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Size        int64             `json:"size,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
 }
 
 // TextContent creates a text content block
@@ -158,25 +274,124 @@ func AudioContent(data, mimeType string) ContentBlock {
 	}
 }
 
+// ResourceLinkContent creates a content block linking to a readable resource
+func ResourceLinkContent(uri, name, description, mimeType string, size int64) ContentBlock {
+	return ContentBlock{
+		Type:        ContentTypeResourceLink,
+		URI:         uri,
+		Name:        name,
+		Description: description,
+		MimeType:    mimeType,
+		Size:        size,
+	}
+}
+
 // ToolCallResult represents the result of a tool call
 type ToolCallResult struct {
-	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content []ContentBlock         `json:"content"`
+	IsError bool                   `json:"isError,omitempty"`
+	Meta    map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// Tool call result _meta keys
+const (
+	MetaKeyElapsedMs       = "elapsedMs"
+	MetaKeyUpstreamStatus  = "upstreamStatus"
+	MetaKeyTruncated       = "truncated"
+	MetaKeyOriginalBytes   = "originalBytes"
+	MetaKeyDryRun          = "dryRun"
+	MetaKeySelectedFrom    = "selectedFromBytes"
+	MetaKeyPagesFetched    = "pagesFetched"
+	MetaKeyMorePages       = "morePages"
+	MetaKeySlowThresholdMs = "slowThresholdMs"
+)
+
+// SetMeta sets a _meta entry on the tool call result
+func (r *ToolCallResult) SetMeta(key string, value interface{}) {
+	if r.Meta == nil {
+		r.Meta = make(map[string]interface{})
+	}
+	r.Meta[key] = value
+}
+
+// ToolCallBatchItem represents the outcome of a single call in a batch
+type ToolCallBatchItem struct {
+	Result *ToolCallResult `json:"result,omitempty"`
+	Error  *RPCError       `json:"error,omitempty"`
+}
+
+// ToolCallBatchResult represents the result of a tools/call_batch request
+type ToolCallBatchResult struct {
+	Results []ToolCallBatchItem `json:"results"`
 }
 
 // Tool represents an MCP tool
 type Tool struct {
-	Name         string      `json:"name"`
-	Description  string      `json:"description"`
-	InputSchema  interface{} `json:"inputSchema"`
-	OutputSchema interface{} `json:"outputSchema,omitempty"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  interface{}            `json:"inputSchema"`
+	OutputSchema interface{}            `json:"outputSchema,omitempty"`
+	Meta         map[string]interface{} `json:"_meta,omitempty"`
 }
 
+// Tool _meta keys
+const (
+	MetaKeySchemaWarning = "schemaWarning"
+	MetaKeyUpstream      = "upstream"
+	MetaKeyCost          = "cost"
+)
+
 // ToolsListResult represents the result of listing tools
 type ToolsListResult struct {
 	Tools []Tool `json:"tools"`
 }
 
+// Completion holds argument completion candidates
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+// CompleteResult represents the result of completion/complete
+type CompleteResult struct {
+	Completion Completion `json:"completion"`
+}
+
+// SamplingMessage is a message in a sampling/createMessage request
+type SamplingMessage struct {
+	Role    Role         `json:"role"`
+	Content ContentBlock `json:"content"`
+}
+
+// CreateMessageParams represents the params of a sampling/createMessage request
+type CreateMessageParams struct {
+	Messages       []SamplingMessage `json:"messages"`
+	SystemPrompt   string            `json:"systemPrompt,omitempty"`
+	IncludeContext string            `json:"includeContext,omitempty"`
+	MaxTokens      int               `json:"maxTokens"`
+}
+
+// CreateMessageResult represents the client's answer to sampling/createMessage
+type CreateMessageResult struct {
+	Role       Role         `json:"role"`
+	Content    ContentBlock `json:"content"`
+	Model      string       `json:"model"`
+	StopReason string       `json:"stopReason,omitempty"`
+}
+
+// ElicitParams represents the params of an elicitation/create request
+type ElicitParams struct {
+	Message         string                 `json:"message"`
+	RequestedSchema map[string]interface{} `json:"requestedSchema"`
+}
+
+// ElicitResult represents the client's answer to elicitation/create
+type ElicitResult struct {
+	Action  string                 `json:"action"` // accept, decline or cancel
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
 // Role represents different roles in MCP
 type Role string
 
@@ -244,6 +459,25 @@ type ResourceLink struct {
 	Description string `json:"description,omitempty"`
 }
 
+// Resource describes a readable resource in resources/list
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// ResourcesListResult represents the result of listing resources
+type ResourcesListResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+// ResourcesReadResult represents the result of reading a resource
+type ResourcesReadResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
 // EmbeddedResource represents an embedded resource
 type EmbeddedResource struct {
 	Type     string           `json:"type"`
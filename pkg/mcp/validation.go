@@ -1,9 +1,12 @@
 package mcp
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // Validator provides validation functionality
@@ -47,8 +50,8 @@ func (v *Validator) ValidateRequest(req *JSONRPCRequest) error {
 	}
 
 	// Validate params if present
-	if req.Params != nil {
-		if err := v.validateParams(req.Params); err != nil {
+	if len(req.Params) > 0 {
+		if err := v.validateRawParams(req.Params); err != nil {
 			errors.Add("params", err.Error())
 		}
 	}
@@ -139,6 +142,48 @@ func (v *Validator) validateParams(params map[string]interface{}) error {
 	return nil
 }
 
+// validateRawParams checks the shape, nesting depth and size of undecoded
+// request parameters without building them
+func (v *Validator) validateRawParams(params json.RawMessage) error {
+	const maxSize = 1024 * 1024 // 1MB
+	if len(params) > maxSize {
+		return fmt.Errorf("object too large (max %d bytes)", maxSize)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("must be an object")
+	}
+
+	// Values inside more than maxDepth containers are nested too deep, as in validateDepth
+	const maxDepth = 10
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %v", err)
+		}
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			depth--
+			continue
+		}
+		if depth > maxDepth {
+			return fmt.Errorf("object nesting too deep (max %d)", maxDepth)
+		}
+		if delim, ok := token.(json.Delim); ok && (delim == '{' || delim == '[') {
+			depth++
+		}
+	}
+	return nil
+}
+
 // validateArguments validates tool arguments
 func (v *Validator) validateArguments(args interface{}) error {
 	switch val := args.(type) {
@@ -217,28 +262,47 @@ func calculateSize(obj interface{}) int {
 	}
 }
 
+// Method names should contain only alphanumeric characters, underscores, and
+// forward slashes; tool names alphanumeric characters, underscores and dots
+var (
+	methodNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_/]+$`)
+	toolNamePattern   = regexp.MustCompile(`^[a-zA-Z0-9_\.]+$`)
+)
+
 // isValidMethodName checks if a method name is valid
 func isValidMethodName(method string) bool {
-	// Method names should contain only alphanumeric characters, underscores, and forward slashes
-	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_/]+$`, method)
-	return matched
+	return methodNamePattern.MatchString(method)
 }
 
 // isValidToolName checks if a tool name is valid
 func isValidToolName(name string) bool {
-	// Tool names should be alphanumeric with underscores and dots
-	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_\.]+$`, name)
-	return matched
+	return toolNamePattern.MatchString(name)
 }
 
+// controlCharacters matches the characters SanitizeString removes
+var controlCharacters = regexp.MustCompile(`[\x00-\x1F\x7F]`)
+
+// sensitivePatterns match the words SanitizeError redacts, with the rest of their token
+var sensitivePatterns = func() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, word := range []string{"password", "token", "key", "secret", "credential", "auth"} {
+		patterns = append(patterns, regexp.MustCompile(`(?i)`+word+`[^\s]*`))
+	}
+	return patterns
+}()
+
 // SanitizeString sanitizes a string by removing/replacing dangerous characters
 func SanitizeString(s string) string {
 	// Remove control characters
-	s = regexp.MustCompile(`[\x00-\x1F\x7F]`).ReplaceAllString(s, "")
+	s = controlCharacters.ReplaceAllString(s, "")
 
-	// Limit length
+	// Limit length, without splitting a multi-byte character
 	if len(s) > 1024 {
-		s = s[:1024]
+		end := 1024
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		s = s[:end]
 	}
 
 	return strings.TrimSpace(s)
@@ -253,18 +317,8 @@ func SanitizeError(err error) string {
 	msg := err.Error()
 
 	// Remove sensitive patterns
-	sensitive := []string{
-		"password",
-		"token",
-		"key",
-		"secret",
-		"credential",
-		"auth",
-	}
-
-	for _, pattern := range sensitive {
-		re := regexp.MustCompile(`(?i)` + pattern + `[^\s]*`)
-		msg = re.ReplaceAllString(msg, "[REDACTED]")
+	for _, pattern := range sensitivePatterns {
+		msg = pattern.ReplaceAllString(msg, "[REDACTED]")
 	}
 
 	return SanitizeString(msg)
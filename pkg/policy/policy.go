@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// Input is the document evaluated by the policy engine for each tool call
+type Input struct {
+	Session   SessionInput           `json:"session"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+
+	// Forwarded headers, without credentials such as Authorization and Cookie
+	Headers map[string]string `json:"headers"`
+
+	// Verified JWT claims of the caller, when JWT authentication is enabled
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// SessionInput describes the calling session
+type SessionInput struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	RemoteAddr string `json:"remote_addr"`
+	CallCount  int64  `json:"call_count"`
+}
+
+// Decision is the outcome of a policy evaluation
+type Decision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Evaluator evaluates authorization decisions for tool calls
+type Evaluator interface {
+	Evaluate(ctx context.Context, input Input) (Decision, error)
+}
+
+// OPAClient evaluates decisions against an external OPA server using its Data API
+type OPAClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewOPAClient creates a client for the OPA decision URL in the configuration
+// (e.g. http://localhost:8181/v1/data/ggrmcp/authz)
+func NewOPAClient(config config.PolicyConfig) *OPAClient {
+	return &OPAClient{
+		url: config.URL,
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}
+}
+
+// Evaluate posts the input to OPA and interprets the result.
+// The policy may return either a boolean or an object with "allow" and "reason".
+func (c *OPAClient) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to create policy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("policy request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Decision{}, fmt.Errorf("policy engine returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Decision{}, fmt.Errorf("failed to decode policy response: %w", err)
+	}
+
+	return parseDecision(result.Result)
+}
+
+// parseDecision interprets an OPA result document
+func parseDecision(raw json.RawMessage) (Decision, error) {
+	// An undefined decision denies by default
+	if len(raw) == 0 {
+		return Decision{Allow: false, Reason: "policy decision is undefined"}, nil
+	}
+
+	var allow bool
+	if err := json.Unmarshal(raw, &allow); err == nil {
+		return Decision{Allow: allow}, nil
+	}
+
+	var decision Decision
+	if err := json.Unmarshal(raw, &decision); err != nil {
+		return Decision{}, fmt.Errorf("unsupported policy result: %s", string(raw))
+	}
+
+	return decision, nil
+}
@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOPAClient(t *testing.T, status int, response string) (*OPAClient, *map[string]interface{}) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewOPAClient(config.PolicyConfig{
+		Enabled: true,
+		URL:     server.URL,
+		Timeout: time.Second,
+	})
+	return client, &received
+}
+
+func TestOPAClient_Evaluate(t *testing.T) {
+	input := Input{
+		Session:   SessionInput{ID: "session-1", CallCount: 3},
+		Tool:      "hello_helloservice_sayhello",
+		Arguments: map[string]interface{}{"name": "world"},
+		Headers:   map[string]string{"x-trace-id": "trace-1"},
+		Claims:    map[string]interface{}{"sub": "alice"},
+	}
+
+	tests := []struct {
+		name          string
+		status        int
+		response      string
+		expected      Decision
+		expectedError bool
+	}{
+		{
+			name:     "Boolean_allow",
+			status:   http.StatusOK,
+			response: `{"result": true}`,
+			expected: Decision{Allow: true},
+		},
+		{
+			name:     "Boolean_deny",
+			status:   http.StatusOK,
+			response: `{"result": false}`,
+			expected: Decision{Allow: false},
+		},
+		{
+			name:     "Object_with_reason",
+			status:   http.StatusOK,
+			response: `{"result": {"allow": false, "reason": "tool not permitted"}}`,
+			expected: Decision{Allow: false, Reason: "tool not permitted"},
+		},
+		{
+			name:     "Undefined_result_denies",
+			status:   http.StatusOK,
+			response: `{}`,
+			expected: Decision{Allow: false, Reason: "policy decision is undefined"},
+		},
+		{
+			name:          "Server_error",
+			status:        http.StatusInternalServerError,
+			response:      `{"code": "internal_error"}`,
+			expectedError: true,
+		},
+		{
+			name:          "Unsupported_result",
+			status:        http.StatusOK,
+			response:      `{"result": "yes"}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, received := newTestOPAClient(t, tt.status, tt.response)
+
+			decision, err := client.Evaluate(context.Background(), input)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, decision)
+
+			// The input document is wrapped as OPA expects
+			require.Contains(t, *received, "input")
+			sent := (*received)["input"].(map[string]interface{})
+			assert.Equal(t, "hello_helloservice_sayhello", sent["tool"])
+			assert.Equal(t, "session-1", sent["session"].(map[string]interface{})["id"])
+		})
+	}
+}
@@ -0,0 +1,457 @@
+package quota
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// Subject identifies who a call is accounted to
+type Subject struct {
+	// Key is the caller's API key; empty for the shared anonymous subject
+	Key string
+
+	// IsAPIKey reports whether Key is an API key listed in the configuration.
+	// Calls with other keys, or none, share one anonymous quota.
+	IsAPIKey bool
+
+	// SessionID is the session the call comes from, charged against the
+	// session cost limit unless the caller is known by Key or Principal
+	SessionID string
+
+	// Principal is the authenticated caller, e.g. the subject of a verified
+	// token; empty when the caller is not authenticated
+	Principal string
+}
+
+// Reservation is a call accounted by Reserve, which Release gives back
+type Reservation struct {
+	subject Subject
+	cost    float64
+	day     time.Time
+	month   time.Time
+}
+
+// PeriodUsage reports consumption within a quota period
+type PeriodUsage struct {
+	Calls      int64     `json:"calls"`
+	Bytes      int64     `json:"bytes"`
+	Cost       float64   `json:"cost,omitempty"`
+	CallsLimit int64     `json:"callsLimit,omitempty"`
+	BytesLimit int64     `json:"bytesLimit,omitempty"`
+	CostLimit  float64   `json:"costLimit,omitempty"`
+	ResetsAt   time.Time `json:"resetsAt"`
+}
+
+// Usage reports a subject's consumption for the current day and month, and
+// the spending of the session when known
+type Usage struct {
+	Subject          string      `json:"subject"`
+	Daily            PeriodUsage `json:"daily"`
+	Monthly          PeriodUsage `json:"monthly"`
+	SessionCost      float64     `json:"sessionCost,omitempty"`
+	SessionCostLimit float64     `json:"sessionCostLimit,omitempty"`
+}
+
+// ExceededError is returned when a call would exceed a quota
+type ExceededError struct {
+	Period   string    // daily, monthly or session
+	Resource string    // call, byte or cost
+	ResetsAt time.Time // zero for session limits, which never reset
+
+	// For cost limits: the limit, the cost already spent and the cost of
+	// the rejected call
+	Limit float64
+	Spent float64
+	Cost  float64
+}
+
+func (e *ExceededError) Error() string {
+	message := fmt.Sprintf("%s %s quota exceeded", e.Period, e.Resource)
+	if e.Resource == "cost" {
+		message += fmt.Sprintf(" (spent %g of %g, call costs %g)", e.Spent, e.Limit, e.Cost)
+	}
+	if !e.ResetsAt.IsZero() {
+		message += ", resets at " + e.ResetsAt.Format(time.RFC3339)
+	}
+	return message
+}
+
+// counters holds consumption for one subject
+type counters struct {
+	day          time.Time
+	month        time.Time
+	dailyCalls   int64
+	dailyBytes   int64
+	monthlyCalls int64
+	monthlyBytes int64
+	dailyCost    float64
+	monthlyCost  float64
+}
+
+// anonymousKey is the counters key shared by callers without a listed API key
+const anonymousKey = "anonymous"
+
+// maxSessionSpends caps the number of sessions whose spending is tracked; the
+// least recently active session is evicted when it is reached
+const maxSessionSpends = 100000
+
+// sessionSpend holds the cost spent against the session cost limit by one
+// session, or by all sessions of one caller
+type sessionSpend struct {
+	key  string
+	cost float64
+	day  time.Time // last day the session spent
+}
+
+// Tracker accounts calls, upstream bytes and tool cost per subject and
+// enforces quotas
+type Tracker struct {
+	config config.QuotaConfig
+	costs  map[string]float64
+
+	mu    sync.Mutex
+	usage map[string]*counters
+
+	// sessions holds the session spending by spend key; recent orders it by
+	// last activity, most recent first, so the idlest is evicted in O(1)
+	sessions  map[string]*list.Element
+	recent    *list.List
+	totalCost float64
+
+	// prunedDay is the day counters were last pruned
+	prunedDay time.Time
+
+	// now returns the current time (replaceable for tests)
+	now func() time.Time
+
+	totalCalls atomic.Int64
+	totalBytes atomic.Int64
+	rejected   atomic.Int64
+	released   atomic.Int64
+}
+
+// NewTracker creates a quota tracker from configuration
+func NewTracker(quotaConfig config.QuotaConfig) *Tracker {
+	costs := make(map[string]float64, len(quotaConfig.Costs))
+	for _, cost := range quotaConfig.Costs {
+		costs[cost.Tool] = cost.Cost
+	}
+	return &Tracker{
+		config:   quotaConfig,
+		costs:    costs,
+		usage:    make(map[string]*counters),
+		sessions: make(map[string]*list.Element),
+		recent:   list.New(),
+		now:      time.Now,
+	}
+}
+
+// Cost returns the cost weight of a tool
+func (t *Tracker) Cost(toolName string) float64 {
+	if cost, exists := t.costs[toolName]; exists {
+		return cost
+	}
+	return t.costs["*"]
+}
+
+// KeyHeader returns the header carrying the caller's API key
+func (t *Tracker) KeyHeader() string {
+	return t.config.KeyHeader
+}
+
+// KnowsKey reports whether an API key is listed in the configuration
+func (t *Tracker) KnowsKey(apiKey string) bool {
+	_, exists := t.config.Keys[apiKey]
+	return apiKey != "" && exists
+}
+
+// Subject returns the subject a call with the given API key is accounted to.
+// Keys that are not listed in the configuration are not trusted: those
+// callers share the anonymous subject with callers sending no key.
+func (t *Tracker) Subject(apiKey, sessionID string) Subject {
+	if t.KnowsKey(apiKey) {
+		return Subject{Key: apiKey, IsAPIKey: true, SessionID: sessionID}
+	}
+	return Subject{SessionID: sessionID}
+}
+
+// Reserve accounts one call of the tool for the subject, or returns an
+// *ExceededError if the subject has used up its daily or monthly quota or the
+// tool's cost would take it over a cost limit. A call that is then refused or
+// fails gives its reservation back with Release.
+func (t *Tracker) Reserve(subject Subject, toolName string) (Reservation, error) {
+	plan := t.planFor(subject)
+	cost := t.Cost(toolName)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now().UTC()
+	c := t.countersFor(subject, now)
+	spend := t.sessionFor(subject, c.day)
+
+	var exceeded *ExceededError
+	switch {
+	case plan.Daily.Calls > 0 && c.dailyCalls >= plan.Daily.Calls:
+		exceeded = &ExceededError{Period: "daily", Resource: "call", ResetsAt: nextDay(c.day)}
+	case plan.Daily.Bytes > 0 && c.dailyBytes >= plan.Daily.Bytes:
+		exceeded = &ExceededError{Period: "daily", Resource: "byte", ResetsAt: nextDay(c.day)}
+	case plan.Monthly.Calls > 0 && c.monthlyCalls >= plan.Monthly.Calls:
+		exceeded = &ExceededError{Period: "monthly", Resource: "call", ResetsAt: nextMonth(c.month)}
+	case plan.Monthly.Bytes > 0 && c.monthlyBytes >= plan.Monthly.Bytes:
+		exceeded = &ExceededError{Period: "monthly", Resource: "byte", ResetsAt: nextMonth(c.month)}
+	case cost > 0 && plan.Daily.Cost > 0 && c.dailyCost+cost > plan.Daily.Cost:
+		exceeded = &ExceededError{Period: "daily", Resource: "cost", ResetsAt: nextDay(c.day),
+			Limit: plan.Daily.Cost, Spent: c.dailyCost, Cost: cost}
+	case cost > 0 && plan.Monthly.Cost > 0 && c.monthlyCost+cost > plan.Monthly.Cost:
+		exceeded = &ExceededError{Period: "monthly", Resource: "cost", ResetsAt: nextMonth(c.month),
+			Limit: plan.Monthly.Cost, Spent: c.monthlyCost, Cost: cost}
+	case cost > 0 && spend != nil && t.config.SessionCost > 0 && spend.cost+cost > t.config.SessionCost:
+		exceeded = &ExceededError{Period: "session", Resource: "cost",
+			Limit: t.config.SessionCost, Spent: spend.cost, Cost: cost}
+	}
+	if exceeded != nil {
+		t.rejected.Add(1)
+		return Reservation{}, exceeded
+	}
+
+	c.dailyCalls++
+	c.monthlyCalls++
+	c.dailyCost += cost
+	c.monthlyCost += cost
+	if spend != nil {
+		spend.cost += cost
+	}
+	t.totalCost += cost
+	t.totalCalls.Add(1)
+	return Reservation{subject: subject, cost: cost, day: c.day, month: c.month}, nil
+}
+
+// Release gives back a reservation of a call that did not go through. Periods
+// that have reset since the call was reserved are left alone.
+func (t *Tracker) Release(reservation Reservation) {
+	if reservation.day.IsZero() {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, exists := t.usage[t.counterKey(reservation.subject)]; exists {
+		if c.day.Equal(reservation.day) {
+			c.dailyCalls--
+			c.dailyCost -= reservation.cost
+		}
+		if c.month.Equal(reservation.month) {
+			c.monthlyCalls--
+			c.monthlyCost -= reservation.cost
+		}
+	}
+	if element, exists := t.sessions[spendKey(reservation.subject)]; exists {
+		element.Value.(*sessionSpend).cost -= reservation.cost
+	}
+	t.totalCost -= reservation.cost
+	t.totalCalls.Add(-1)
+	t.released.Add(1)
+}
+
+// RecordBytes accounts upstream bytes (request plus response) for the subject.
+// Byte quotas are enforced on the next call since sizes are only known afterwards.
+func (t *Tracker) RecordBytes(subject Subject, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.countersFor(subject, t.now().UTC())
+	c.dailyBytes += bytes
+	c.monthlyBytes += bytes
+	t.totalBytes.Add(bytes)
+}
+
+// Usage returns the subject's consumption for the current periods
+func (t *Tracker) Usage(subject Subject) Usage {
+	plan := t.planFor(subject)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.countersFor(subject, t.now().UTC())
+
+	kind := "anonymous"
+	if t.isListed(subject) {
+		kind = "api_key"
+	}
+
+	usage := Usage{
+		Subject: kind,
+		Daily: PeriodUsage{
+			Calls:      c.dailyCalls,
+			Bytes:      c.dailyBytes,
+			Cost:       c.dailyCost,
+			CallsLimit: plan.Daily.Calls,
+			BytesLimit: plan.Daily.Bytes,
+			CostLimit:  plan.Daily.Cost,
+			ResetsAt:   nextDay(c.day),
+		},
+		Monthly: PeriodUsage{
+			Calls:      c.monthlyCalls,
+			Bytes:      c.monthlyBytes,
+			Cost:       c.monthlyCost,
+			CallsLimit: plan.Monthly.Calls,
+			BytesLimit: plan.Monthly.Bytes,
+			CostLimit:  plan.Monthly.Cost,
+			ResetsAt:   nextMonth(c.month),
+		},
+	}
+	if element, exists := t.sessions[spendKey(subject)]; exists {
+		usage.SessionCost = element.Value.(*sessionSpend).cost
+		usage.SessionCostLimit = t.config.SessionCost
+	}
+	return usage
+}
+
+// Stats returns aggregate accounting metrics
+func (t *Tracker) Stats() map[string]interface{} {
+	t.mu.Lock()
+	subjects := len(t.usage)
+	totalCost := t.totalCost
+	t.mu.Unlock()
+
+	return map[string]interface{}{
+		"subjects": subjects,
+		"calls":    t.totalCalls.Load(),
+		"bytes":    t.totalBytes.Load(),
+		"cost":     totalCost,
+		"rejected": t.rejected.Load(),
+		"released": t.released.Load(),
+	}
+}
+
+// planFor returns the limits that apply to the subject
+func (t *Tracker) planFor(subject Subject) config.QuotaPlanConfig {
+	if t.isListed(subject) {
+		return t.config.Keys[subject.Key]
+	}
+	return config.QuotaPlanConfig{
+		Daily:   t.config.Daily,
+		Monthly: t.config.Monthly,
+	}
+}
+
+// isListed reports whether the subject is a listed API key
+func (t *Tracker) isListed(subject Subject) bool {
+	return subject.IsAPIKey && t.KnowsKey(subject.Key)
+}
+
+// counterKey returns the key of the subject's counters
+func (t *Tracker) counterKey(subject Subject) string {
+	if t.isListed(subject) {
+		return "key:" + subject.Key
+	}
+	return anonymousKey
+}
+
+// spendKey returns the key of the spending charged against the session cost
+// limit: the caller's, when it is known, so new sessions do not reset it
+func spendKey(subject Subject) string {
+	switch {
+	case subject.IsAPIKey:
+		return "key:" + subject.Key
+	case subject.Principal != "":
+		return "principal:" + subject.Principal
+	case subject.SessionID != "":
+		return "session:" + subject.SessionID
+	}
+	return ""
+}
+
+// countersFor returns the subject's counters, rolling over expired periods.
+// Callers must hold t.mu.
+func (t *Tracker) countersFor(subject Subject, now time.Time) *counters {
+	key := t.counterKey(subject)
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// Once a day, drop counters that have not been used this month and the
+	// spending of sessions idle for more than a day
+	if !t.prunedDay.Equal(day) {
+		t.prunedDay = day
+		for k, c := range t.usage {
+			if !c.month.Equal(month) {
+				delete(t.usage, k)
+			}
+		}
+		for element := t.recent.Back(); element != nil; element = t.recent.Back() {
+			spend := element.Value.(*sessionSpend)
+			if !spend.day.Before(day.AddDate(0, 0, -1)) {
+				break
+			}
+			t.recent.Remove(element)
+			delete(t.sessions, spend.key)
+		}
+	}
+
+	c, exists := t.usage[key]
+	if !exists {
+		c = &counters{day: day, month: month}
+		t.usage[key] = c
+	}
+
+	if !c.day.Equal(day) {
+		c.day = day
+		c.dailyCalls = 0
+		c.dailyBytes = 0
+		c.dailyCost = 0
+	}
+	if !c.month.Equal(month) {
+		c.month = month
+		c.monthlyCalls = 0
+		c.monthlyBytes = 0
+		c.monthlyCost = 0
+	}
+
+	return c
+}
+
+// sessionFor returns the spending charged against the session cost limit for
+// the subject, or nil when the call has neither a known caller nor a
+// session. Callers must hold t.mu.
+func (t *Tracker) sessionFor(subject Subject, day time.Time) *sessionSpend {
+	key := spendKey(subject)
+	if key == "" {
+		return nil
+	}
+	if element, exists := t.sessions[key]; exists {
+		t.recent.MoveToFront(element)
+		spend := element.Value.(*sessionSpend)
+		spend.day = day
+		return spend
+	}
+
+	// Drop the least recently active spending to make room
+	if len(t.sessions) >= maxSessionSpends {
+		oldest := t.recent.Back()
+		t.recent.Remove(oldest)
+		delete(t.sessions, oldest.Value.(*sessionSpend).key)
+	}
+	spend := &sessionSpend{key: key, day: day}
+	t.sessions[key] = t.recent.PushFront(spend)
+	return spend
+}
+
+func nextDay(day time.Time) time.Time {
+	return day.AddDate(0, 0, 1)
+}
+
+func nextMonth(month time.Time) time.Time {
+	return month.AddDate(0, 1, 0)
+}
@@ -0,0 +1,290 @@
+package quota
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracker(quotaConfig config.QuotaConfig, now *time.Time) *Tracker {
+	tracker := NewTracker(quotaConfig)
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+// reserve reserves a call and returns only the error
+func reserve(tracker *Tracker, subject Subject, toolName string) error {
+	_, err := tracker.Reserve(subject, toolName)
+	return err
+}
+
+func TestTracker_DailyCallQuota(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	daily := config.QuotaPlanConfig{Daily: config.QuotaLimitConfig{Calls: 2}}
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled: true,
+		Keys:    map[string]config.QuotaPlanConfig{"key-1": daily, "key-2": daily},
+	}, &now)
+	subject := tracker.Subject("key-1", "")
+
+	require.NoError(t, reserve(tracker, subject, ""))
+	require.NoError(t, reserve(tracker, subject, ""))
+
+	err := reserve(tracker, subject, "")
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, "daily", exceeded.Period)
+	assert.Equal(t, "call", exceeded.Resource)
+	assert.Equal(t, time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), exceeded.ResetsAt)
+
+	// Other keys are unaffected
+	assert.NoError(t, reserve(tracker, tracker.Subject("key-2", ""), ""))
+
+	// The quota resets on the next day
+	now = now.Add(24 * time.Hour)
+	assert.NoError(t, reserve(tracker, subject, ""))
+
+	stats := tracker.Stats()
+	assert.Equal(t, int64(4), stats["calls"])
+	assert.Equal(t, int64(1), stats["rejected"])
+}
+
+func TestTracker_MonthlyByteQuota(t *testing.T) {
+	now := time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled: true,
+		Keys: map[string]config.QuotaPlanConfig{
+			"key-1": {Monthly: config.QuotaLimitConfig{Bytes: 100}},
+		},
+	}, &now)
+	subject := tracker.Subject("key-1", "")
+
+	require.NoError(t, reserve(tracker, subject, ""))
+	tracker.RecordBytes(subject, 150)
+
+	// Byte quotas apply to the next call once exhausted
+	err := reserve(tracker, subject, "")
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, "monthly", exceeded.Period)
+	assert.Equal(t, "byte", exceeded.Resource)
+
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, reserve(tracker, subject, ""))
+}
+
+func TestTracker_KeyOverridesAndUsage(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled: true,
+		Daily:   config.QuotaLimitConfig{Calls: 1},
+		Keys: map[string]config.QuotaPlanConfig{
+			"premium": {Daily: config.QuotaLimitConfig{Calls: 10}},
+		},
+	}, &now)
+
+	premium := tracker.Subject("premium", "")
+	assert.True(t, premium.IsAPIKey)
+	require.NoError(t, reserve(tracker, premium, ""))
+	require.NoError(t, reserve(tracker, premium, ""))
+	tracker.RecordBytes(premium, 42)
+
+	usage := tracker.Usage(premium)
+	assert.Equal(t, "api_key", usage.Subject)
+	assert.Equal(t, int64(2), usage.Daily.Calls)
+	assert.Equal(t, int64(42), usage.Daily.Bytes)
+	assert.Equal(t, int64(10), usage.Daily.CallsLimit)
+	assert.Equal(t, int64(2), usage.Monthly.Calls)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), usage.Monthly.ResetsAt)
+
+	// Callers without a listed key share the anonymous quota under the defaults
+	anonymous := tracker.Subject("", "s1")
+	assert.False(t, anonymous.IsAPIKey)
+	require.NoError(t, reserve(tracker, anonymous, ""))
+	assert.Equal(t, "anonymous", tracker.Usage(anonymous).Subject)
+}
+
+func TestTracker_UnlistedKeysShareTheAnonymousQuota(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled: true,
+		Daily:   config.QuotaLimitConfig{Calls: 2},
+		Keys:    map[string]config.QuotaPlanConfig{"premium": {}},
+	}, &now)
+
+	assert.False(t, tracker.KnowsKey("made-up"))
+	assert.False(t, tracker.KnowsKey(""))
+	assert.True(t, tracker.KnowsKey("premium"))
+
+	// Inventing keys or sessions does not buy fresh counters
+	require.NoError(t, reserve(tracker, tracker.Subject("made-up-1", "s1"), ""))
+	require.NoError(t, reserve(tracker, tracker.Subject("", "s2"), ""))
+	assert.Error(t, reserve(tracker, tracker.Subject("made-up-2", "s3"), ""))
+
+	// A subject claiming an unlisted key is accounted anonymously as well
+	assert.Error(t, reserve(tracker, Subject{Key: "made-up-3", IsAPIKey: true}, ""))
+	assert.Equal(t, int64(2), tracker.Usage(Subject{Key: "made-up-3", IsAPIKey: true}).Daily.Calls)
+
+	require.NoError(t, reserve(tracker, tracker.Subject("premium", ""), ""))
+	assert.Equal(t, 2, tracker.Stats()["subjects"])
+}
+
+func TestTracker_PrunesStaleCounters(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled:     true,
+		SessionCost: 10,
+		Keys:        map[string]config.QuotaPlanConfig{"key-1": {}, "key-2": {}},
+	}, &now)
+
+	require.NoError(t, reserve(tracker, tracker.Subject("key-1", "s1"), ""))
+	require.NoError(t, reserve(tracker, tracker.Subject("key-2", "s2"), ""))
+	assert.Equal(t, 2, tracker.Stats()["subjects"])
+	assert.Len(t, tracker.sessions, 2)
+
+	// Counters unused this month and sessions idle for a day are dropped
+	now = now.AddDate(0, 1, 0)
+	require.NoError(t, reserve(tracker, tracker.Subject("key-1", "s3"), ""))
+	assert.Equal(t, 1, tracker.Stats()["subjects"])
+	assert.Len(t, tracker.sessions, 1)
+}
+
+func TestTracker_CapsSessions(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{Enabled: true}, &now)
+
+	tracker.mu.Lock()
+	tracker.sessionFor(Subject{SessionID: "oldest"}, now.AddDate(0, 0, -1))
+	for i := 1; i < maxSessionSpends; i++ {
+		tracker.sessionFor(Subject{SessionID: fmt.Sprintf("s%d", i)}, now)
+	}
+	tracker.sessionFor(Subject{SessionID: "newest"}, now)
+	tracker.mu.Unlock()
+
+	assert.Len(t, tracker.sessions, maxSessionSpends)
+	assert.NotContains(t, tracker.sessions, "session:oldest")
+	assert.Contains(t, tracker.sessions, "session:newest")
+}
+
+func TestTracker_CostLimits(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled:     true,
+		SessionCost: 6,
+		Keys: map[string]config.QuotaPlanConfig{
+			"key-1": {Daily: config.QuotaLimitConfig{Cost: 10.5}},
+			"key-2": {Daily: config.QuotaLimitConfig{Cost: 5.75}},
+		},
+		Costs: []config.ToolCostConfig{
+			{Tool: "reports_generate", Cost: 5},
+			{Tool: "*", Cost: 0.5},
+		},
+	}, &now)
+	assert.Equal(t, 5.0, tracker.Cost("reports_generate"))
+	assert.Equal(t, 0.5, tracker.Cost("orders_get"))
+
+	first := tracker.Subject("key-1", "s1")
+	require.NoError(t, reserve(tracker, first, "reports_generate"))
+
+	// The session budget is spent before the daily one
+	err := reserve(tracker, first, "reports_generate")
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, "session", exceeded.Period)
+	assert.Equal(t, "cost", exceeded.Resource)
+	assert.Equal(t, 6.0, exceeded.Limit)
+	assert.Equal(t, 5.0, exceeded.Spent)
+	assert.Equal(t, 5.0, exceeded.Cost)
+	assert.True(t, exceeded.ResetsAt.IsZero())
+	assert.Equal(t, "session cost quota exceeded (spent 5 of 6, call costs 5)", err.Error())
+
+	// Cheaper calls still fit
+	require.NoError(t, reserve(tracker, first, "orders_get"))
+
+	// A new session of the same key does not get a fresh session budget
+	second := tracker.Subject("key-1", "s2")
+	err = reserve(tracker, second, "reports_generate")
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, "session", exceeded.Period)
+	assert.Equal(t, 5.5, exceeded.Spent)
+
+	usage := tracker.Usage(second)
+	assert.Equal(t, 5.5, usage.Daily.Cost)
+	assert.Equal(t, 10.5, usage.Daily.CostLimit)
+	assert.Equal(t, 5.5, usage.SessionCost)
+	assert.Equal(t, 6.0, usage.SessionCostLimit)
+	assert.Equal(t, 5.5, tracker.Stats()["cost"])
+
+	// Daily cost limits apply across the key's calls
+	other := tracker.Subject("key-2", "s3")
+	require.NoError(t, reserve(tracker, other, "reports_generate"))
+	require.NoError(t, reserve(tracker, other, "orders_get"))
+	err = reserve(tracker, other, "orders_get")
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, "daily", exceeded.Period)
+	assert.Equal(t, 5.5, exceeded.Spent)
+
+	// Daily spending resets; session spending does not
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, reserve(tracker, second, "orders_get"))
+	assert.Error(t, reserve(tracker, first, "reports_generate"))
+}
+
+func TestTracker_SessionCostByCaller(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled:     true,
+		SessionCost: 6,
+		Costs:       []config.ToolCostConfig{{Tool: "reports_generate", Cost: 5}},
+	}, &now)
+
+	// Anonymous sessions each have their own budget
+	require.NoError(t, reserve(tracker, tracker.Subject("", "s1"), "reports_generate"))
+	require.NoError(t, reserve(tracker, tracker.Subject("", "s2"), "reports_generate"))
+
+	// An authenticated caller keeps its budget across sessions
+	alice := tracker.Subject("", "s3")
+	alice.Principal = "alice"
+	require.NoError(t, reserve(tracker, alice, "reports_generate"))
+	alice.SessionID = "s4"
+	assert.Error(t, reserve(tracker, alice, "reports_generate"))
+}
+
+func TestTracker_Release(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(config.QuotaConfig{
+		Enabled:     true,
+		Daily:       config.QuotaLimitConfig{Calls: 1, Cost: 5},
+		SessionCost: 5,
+		Costs:       []config.ToolCostConfig{{Tool: "reports_generate", Cost: 5}},
+	}, &now)
+	subject := tracker.Subject("", "s1")
+
+	reservation, err := tracker.Reserve(subject, "reports_generate")
+	require.NoError(t, err)
+	assert.Error(t, reserve(tracker, subject, "reports_generate"))
+
+	// A released call frees its quota for the next one
+	tracker.Release(reservation)
+	usage := tracker.Usage(subject)
+	assert.Zero(t, usage.Daily.Calls)
+	assert.Zero(t, usage.Daily.Cost)
+	assert.Zero(t, usage.SessionCost)
+	assert.Equal(t, int64(1), tracker.Stats()["released"])
+	reservation, err = tracker.Reserve(subject, "reports_generate")
+	require.NoError(t, err)
+
+	// Released after the day reset, it leaves the new day's counters alone
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, reserve(tracker, tracker.Subject("", "s2"), ""))
+	tracker.Release(reservation)
+	assert.Equal(t, int64(1), tracker.Usage(subject).Daily.Calls)
+	assert.Equal(t, int64(1), tracker.Usage(subject).Monthly.Calls)
+
+	// The zero reservation is ignored
+	tracker.Release(Reservation{})
+}
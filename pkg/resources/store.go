@@ -0,0 +1,176 @@
+package resources
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// URIPrefix is the scheme and authority of gateway-held resource URIs
+const URIPrefix = "ggrmcp://resources/"
+
+// Resource describes a stored payload
+type Resource struct {
+	URI       string
+	Name      string
+	MimeType  string
+	Size      int64
+	ExpiresAt time.Time
+}
+
+// TooLargeError is returned when a payload exceeds the per-resource limit
+type TooLargeError struct {
+	Size  int64
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("resource of %d bytes exceeds the %d byte limit", e.Size, e.Limit)
+}
+
+// entry is a stored resource and its owner
+type entry struct {
+	resource Resource
+	owner    string
+	data     []byte
+}
+
+// Store holds temporary resources, each readable only by the session that created it
+type Store struct {
+	config config.ResourcesConfig
+
+	mu         sync.Mutex
+	entries    map[string]*entry
+	order      []string // URIs, oldest first
+	totalBytes int64
+
+	// now returns the current time (replaceable for tests)
+	now func() time.Time
+}
+
+// NewStore creates a resource store from configuration
+func NewStore(resourcesConfig config.ResourcesConfig) *Store {
+	return &Store{
+		config:  resourcesConfig,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// Put stores a payload for the owner, evicting the oldest resources if needed
+func (s *Store) Put(owner, name, mimeType string, data []byte) (Resource, error) {
+	size := int64(len(data))
+	if size > s.config.MaxResourceBytes {
+		return Resource{}, &TooLargeError{Size: size, Limit: s.config.MaxResourceBytes}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Resource{}, fmt.Errorf("failed to generate resource ID: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evictLocked(now, size)
+
+	resource := Resource{
+		URI:       URIPrefix + id,
+		Name:      name,
+		MimeType:  mimeType,
+		Size:      size,
+		ExpiresAt: now.Add(s.config.TTL),
+	}
+	s.entries[resource.URI] = &entry{resource: resource, owner: owner, data: data}
+	s.order = append(s.order, resource.URI)
+	s.totalBytes += size
+
+	return resource, nil
+}
+
+// Get returns an owner's resource and its contents
+func (s *Store) Get(owner, uri string) (Resource, []byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[uri]
+	if !ok || e.owner != owner || !s.now().Before(e.resource.ExpiresAt) {
+		return Resource{}, nil, false
+	}
+	return e.resource, e.data, true
+}
+
+// List returns the owner's unexpired resources, oldest first
+func (s *Store) List(owner string) []Resource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var resources []Resource
+	for _, uri := range s.order {
+		e := s.entries[uri]
+		if e.owner == owner && now.Before(e.resource.ExpiresAt) {
+			resources = append(resources, e.resource)
+		}
+	}
+	return resources
+}
+
+// Stats returns store occupancy for metrics
+func (s *Store) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"count":      len(s.entries),
+		"totalBytes": s.totalBytes,
+	}
+}
+
+// IsResourceURI reports whether the URI names a gateway-held resource
+func IsResourceURI(uri string) bool {
+	return strings.HasPrefix(uri, URIPrefix)
+}
+
+// evictLocked drops expired resources, then the oldest ones until the
+// incoming payload fits within the configured limits
+func (s *Store) evictLocked(now time.Time, incoming int64) {
+	kept := s.order[:0]
+	for _, uri := range s.order {
+		if e := s.entries[uri]; !now.Before(e.resource.ExpiresAt) {
+			s.removeLocked(uri)
+			continue
+		}
+		kept = append(kept, uri)
+	}
+	s.order = kept
+
+	for len(s.order) > 0 &&
+		(len(s.order) >= s.config.MaxEntries || s.totalBytes+incoming > s.config.MaxTotalBytes) {
+		s.removeLocked(s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// removeLocked deletes a resource without touching the order slice
+func (s *Store) removeLocked(uri string) {
+	if e, ok := s.entries[uri]; ok {
+		s.totalBytes -= e.resource.Size
+		delete(s.entries, uri)
+	}
+}
+
+// newID returns an unguessable resource identifier
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(resourcesConfig config.ResourcesConfig, now *time.Time) *Store {
+	store := NewStore(resourcesConfig)
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestStore_PutAndGet(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(config.Default().MCP.Resources, &now)
+
+	resource, err := store.Put("session-1", "report", "application/json", []byte(`{"a":1}`))
+	require.NoError(t, err)
+	assert.True(t, IsResourceURI(resource.URI))
+	assert.Equal(t, int64(7), resource.Size)
+
+	got, data, ok := store.Get("session-1", resource.URI)
+	require.True(t, ok)
+	assert.Equal(t, resource, got)
+	assert.Equal(t, `{"a":1}`, string(data))
+
+	// Other sessions can neither read nor list it
+	_, _, ok = store.Get("session-2", resource.URI)
+	assert.False(t, ok)
+	assert.Empty(t, store.List("session-2"))
+	assert.Len(t, store.List("session-1"), 1)
+}
+
+func TestStore_Expiry(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	cfg := config.Default().MCP.Resources
+	cfg.TTL = time.Minute
+	store := newTestStore(cfg, &now)
+
+	resource, err := store.Put("session-1", "report", "text/plain", []byte("hello"))
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, _, ok := store.Get("session-1", resource.URI)
+	assert.False(t, ok)
+	assert.Empty(t, store.List("session-1"))
+
+	// Expired resources are dropped on the next write
+	_, err = store.Put("session-1", "other", "text/plain", []byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Stats()["count"])
+	assert.Equal(t, int64(1), store.Stats()["totalBytes"])
+}
+
+func TestStore_Limits(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(config.ResourcesConfig{
+		TTL:              time.Hour,
+		MaxEntries:       2,
+		MaxTotalBytes:    10,
+		MaxResourceBytes: 6,
+	}, &now)
+
+	_, err := store.Put("s", "too-big", "text/plain", []byte("1234567"))
+	var tooLarge *TooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, int64(6), tooLarge.Limit)
+
+	first, err := store.Put("s", "first", "text/plain", []byte("12345"))
+	require.NoError(t, err)
+	second, err := store.Put("s", "second", "text/plain", []byte("12345"))
+	require.NoError(t, err)
+
+	// A third resource evicts the oldest to stay within entry and byte limits
+	third, err := store.Put("s", "third", "text/plain", []byte("1"))
+	require.NoError(t, err)
+
+	_, _, ok := store.Get("s", first.URI)
+	assert.False(t, ok)
+	_, _, ok = store.Get("s", second.URI)
+	assert.True(t, ok)
+	_, _, ok = store.Get("s", third.URI)
+	assert.True(t, ok)
+	assert.Equal(t, int64(6), store.Stats()["totalBytes"])
+}
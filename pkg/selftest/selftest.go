@@ -0,0 +1,255 @@
+package selftest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"github.com/aalobaidi/ggRMCP/pkg/upstream"
+)
+
+// Status is the outcome of a check or of the whole self-test
+type Status string
+
+// Check outcomes; only FAIL fails the self-test
+const (
+	StatusPass Status = "PASS"
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+	StatusSkip Status = "SKIP"
+)
+
+// Check is the outcome of one self-test step
+type Check struct {
+	Name       string   `json:"name"`
+	Status     Status   `json:"status"`
+	Detail     string   `json:"detail,omitempty"`
+	Issues     []string `json:"issues,omitempty"`
+	DurationMs int64    `json:"durationMs"`
+}
+
+// Report is the outcome of the self-test
+type Report struct {
+	Status Status  `json:"status"`
+	Target string  `json:"target"`
+	Checks []Check `json:"checks"`
+}
+
+// Options contains what the self-test checks
+type Options struct {
+	// gRPC backend address shown in the report
+	Target string
+
+	Discoverer  grpc.ServiceDiscoverer
+	ToolBuilder *tools.MCPToolBuilder
+
+	// Downstream MCP servers, checked when set
+	Upstreams *upstream.Aggregator
+
+	Config config.SelfTestConfig
+
+	// Whether the discoverer is already connected and has discovered services
+	// (at startup), in which case the connection is only health checked
+	Discovered bool
+}
+
+// Run runs the checks in order. A check that depends on a failed one is skipped.
+func Run(ctx context.Context, options Options) *Report {
+	ctx, cancel := context.WithTimeout(ctx, options.Config.Timeout)
+	defer cancel()
+
+	r := &runner{options: options, report: &Report{Status: StatusPass, Target: options.Target}}
+
+	steps := []struct {
+		name string
+		run  func(ctx context.Context) Check
+	}{
+		{"connect", r.connect},
+		{"discover", r.discover},
+		{"build_tools", r.buildTools},
+		{"schemas", r.schemas},
+		{"invoke", r.invoke},
+	}
+	failed := ""
+	for _, step := range steps {
+		if failed != "" {
+			r.add(Check{Name: step.name, Status: StatusSkip, Detail: fmt.Sprintf("%s failed", failed)}, 0)
+			continue
+		}
+		started := time.Now()
+		check := step.run(ctx)
+		check.Name = step.name
+		r.add(check, time.Since(started))
+		if check.Status == StatusFail {
+			failed = step.name
+		}
+	}
+
+	// Downstream MCP servers do not depend on the gRPC server
+	if options.Upstreams != nil {
+		started := time.Now()
+		check := r.upstreams(ctx)
+		check.Name = "upstreams"
+		r.add(check, time.Since(started))
+	}
+
+	return r.report
+}
+
+// runner runs the checks of one self-test
+type runner struct {
+	options Options
+	report  *Report
+	tools   []mcp.Tool
+}
+
+// add appends a check to the report and fails the report if the check failed
+func (r *runner) add(check Check, elapsed time.Duration) {
+	check.DurationMs = elapsed.Milliseconds()
+	if check.Status == StatusFail {
+		r.report.Status = StatusFail
+	}
+	r.report.Checks = append(r.report.Checks, check)
+}
+
+// connect checks the connection to the gRPC server
+func (r *runner) connect(ctx context.Context) Check {
+	if r.options.Discovered {
+		if err := r.options.Discoverer.HealthCheck(ctx); err != nil {
+			return Check{Status: StatusFail, Detail: fmt.Sprintf("health check failed: %v", err)}
+		}
+		return Check{Status: StatusPass, Detail: "connection healthy"}
+	}
+
+	if err := r.options.Discoverer.Connect(ctx); err != nil {
+		return Check{Status: StatusFail, Detail: fmt.Sprintf("failed to connect: %v", err)}
+	}
+	return Check{Status: StatusPass, Detail: "connected"}
+}
+
+// discover checks that services are discovered; inconsistencies between
+// discovery sources are reported as warnings
+func (r *runner) discover(ctx context.Context) Check {
+	if !r.options.Discovered {
+		if err := r.options.Discoverer.DiscoverServices(ctx); err != nil {
+			return Check{Status: StatusFail, Detail: fmt.Sprintf("failed to discover services: %v", err)}
+		}
+	}
+
+	methods := r.options.Discoverer.GetMethods()
+	if len(methods) == 0 {
+		return Check{Status: StatusFail, Detail: "no methods discovered"}
+	}
+
+	check := Check{Status: StatusPass, Detail: fmt.Sprintf("%d methods discovered", len(methods))}
+	for _, issue := range r.options.Discoverer.DiscoveryIssues() {
+		check.Status = StatusWarn
+		check.Issues = append(check.Issues, issue.String())
+	}
+	return check
+}
+
+// buildTools checks that a tool builds for every method
+func (r *runner) buildTools(ctx context.Context) Check {
+	tools, err := r.options.ToolBuilder.BuildDiscoveredTools(r.options.Discoverer.GetMethods())
+	if err != nil {
+		return Check{Status: StatusFail, Detail: fmt.Sprintf("failed to build tools: %v", err)}
+	}
+	r.tools = tools
+
+	failures := r.options.ToolBuilder.SchemaFailures()
+	if len(failures) == 0 {
+		return Check{Status: StatusPass, Detail: fmt.Sprintf("%d tools built", len(tools))}
+	}
+
+	check := Check{Status: StatusFail, Detail: fmt.Sprintf("%d tools built, %d failed", len(tools), len(failures))}
+	for _, failure := range failures {
+		check.Issues = append(check.Issues, fmt.Sprintf("%s: %s", failure.Method, failure.Error))
+	}
+	return check
+}
+
+// schemas checks that every tool's schemas marshal to JSON
+func (r *runner) schemas(ctx context.Context) Check {
+	var issues []string
+	for _, tool := range r.tools {
+		if _, err := json.Marshal(tool.InputSchema); err != nil {
+			issues = append(issues, fmt.Sprintf("%s: input schema: %v", tool.Name, err))
+		}
+		if tool.OutputSchema != nil {
+			if _, err := json.Marshal(tool.OutputSchema); err != nil {
+				issues = append(issues, fmt.Sprintf("%s: output schema: %v", tool.Name, err))
+			}
+		}
+	}
+	if len(issues) > 0 {
+		return Check{Status: StatusFail, Detail: fmt.Sprintf("%d schemas failed to marshal", len(issues)), Issues: issues}
+	}
+	return Check{Status: StatusPass, Detail: fmt.Sprintf("%d tool schemas marshal", len(r.tools))}
+}
+
+// invoke calls the configured tool
+func (r *runner) invoke(ctx context.Context) Check {
+	toolName := r.options.Config.Tool
+	if toolName == "" {
+		return Check{Status: StatusSkip, Detail: "no self-test tool configured"}
+	}
+
+	arguments := r.options.Config.Arguments
+	if arguments == "" {
+		arguments = "{}"
+	}
+	if _, err := r.options.Discoverer.InvokeMethodByTool(ctx, nil, toolName, arguments); err != nil {
+		return Check{Status: StatusFail, Detail: fmt.Sprintf("%s failed: %v", toolName, err)}
+	}
+	return Check{Status: StatusPass, Detail: fmt.Sprintf("%s succeeded", toolName)}
+}
+
+// upstreams checks that every downstream MCP server lists its tools
+func (r *runner) upstreams(ctx context.Context) Check {
+	tools := r.options.Upstreams.Tools(ctx)
+
+	check := Check{Status: StatusPass, Detail: fmt.Sprintf("%d upstream tools listed", len(tools))}
+	for _, status := range r.options.Upstreams.Status() {
+		if !status.Connected {
+			check.Status = StatusFail
+			check.Issues = append(check.Issues, fmt.Sprintf("%s: %s", status.Name, status.LastError))
+		}
+	}
+	return check
+}
+
+// Passed reports whether no check failed
+func (r *Report) Passed() bool {
+	return r.Status != StatusFail
+}
+
+// WriteText writes the report as aligned text lines for terminals and CI logs
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ggRMCP self-test against %s\n", r.Target)
+	for _, check := range r.Checks {
+		fmt.Fprintf(&b, "  %-4s  %-16s  %s (%dms)\n", check.Status, check.Name, check.Detail, check.DurationMs)
+		for _, issue := range check.Issues {
+			fmt.Fprintf(&b, "          - %s\n", issue)
+		}
+	}
+	fmt.Fprintf(&b, "RESULT: %s\n", r.Status)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as a JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
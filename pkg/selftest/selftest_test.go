@@ -0,0 +1,201 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/aalobaidi/ggRMCP/pkg/upstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// stubDiscoverer answers the calls the self-test makes
+type stubDiscoverer struct {
+	grpc.ServiceDiscoverer
+
+	connectErr error
+	invokeErr  error
+	methods    []types.MethodInfo
+	issues     []grpc.DiscoveryIssue
+	invoked    string
+}
+
+func (d *stubDiscoverer) Connect(ctx context.Context) error          { return d.connectErr }
+func (d *stubDiscoverer) DiscoverServices(ctx context.Context) error { return nil }
+func (d *stubDiscoverer) HealthCheck(ctx context.Context) error      { return d.connectErr }
+func (d *stubDiscoverer) GetMethods() []types.MethodInfo             { return d.methods }
+func (d *stubDiscoverer) DiscoveryIssues() []grpc.DiscoveryIssue     { return d.issues }
+func (d *stubDiscoverer) InvokeMethodByTool(ctx context.Context, headers map[string]string, toolName string, inputJSON string) (string, error) {
+	d.invoked = toolName + " " + inputJSON
+	return "{}", d.invokeErr
+}
+
+// pingMethod is a method whose schemas build
+func pingMethod() types.MethodInfo {
+	return types.MethodInfo{
+		Name:             "Ping",
+		FullName:         "health.PingService.Ping",
+		ServiceName:      "health.PingService",
+		InputDescriptor:  (&emptypb.Empty{}).ProtoReflect().Descriptor(),
+		OutputDescriptor: (&wrapperspb.StringValue{}).ProtoReflect().Descriptor(),
+	}
+}
+
+func newOptions(discoverer grpc.ServiceDiscoverer, selfTestConfig config.SelfTestConfig) Options {
+	selfTestConfig.Timeout = 5 * time.Second
+	return Options{
+		Target:      "localhost:50051",
+		Discoverer:  discoverer,
+		ToolBuilder: tools.NewMCPToolBuilder(zap.NewNop()),
+		Config:      selfTestConfig,
+	}
+}
+
+// statuses returns the status of each check by name
+func statuses(report *Report) map[string]Status {
+	result := make(map[string]Status)
+	for _, check := range report.Checks {
+		result[check.Name] = check.Status
+	}
+	return result
+}
+
+func TestRun_Passes(t *testing.T) {
+	discoverer := &stubDiscoverer{methods: []types.MethodInfo{pingMethod()}}
+
+	report := Run(context.Background(), newOptions(discoverer, config.SelfTestConfig{
+		Tool:      "health_pingservice_ping",
+		Arguments: `{"verbose":true}`,
+	}))
+
+	assert.True(t, report.Passed())
+	assert.Equal(t, StatusPass, report.Status)
+	assert.Equal(t, map[string]Status{
+		"connect":     StatusPass,
+		"discover":    StatusPass,
+		"build_tools": StatusPass,
+		"schemas":     StatusPass,
+		"invoke":      StatusPass,
+	}, statuses(report))
+	assert.Equal(t, `health_pingservice_ping {"verbose":true}`, discoverer.invoked)
+}
+
+func TestRun_WarningsDoNotFail(t *testing.T) {
+	discoverer := &stubDiscoverer{
+		methods: []types.MethodInfo{pingMethod()},
+		issues:  []grpc.DiscoveryIssue{{Kind: "missing_from_reflection", Detail: "health.PingService"}},
+	}
+
+	report := Run(context.Background(), newOptions(discoverer, config.SelfTestConfig{}))
+
+	assert.True(t, report.Passed())
+	assert.Equal(t, StatusWarn, statuses(report)["discover"])
+	assert.Equal(t, StatusSkip, statuses(report)["invoke"])
+	assert.Empty(t, discoverer.invoked)
+}
+
+func TestRun_Failures(t *testing.T) {
+	t.Run("Connection_failure_skips_the_rest", func(t *testing.T) {
+		discoverer := &stubDiscoverer{connectErr: errors.New("connection refused")}
+
+		report := Run(context.Background(), newOptions(discoverer, config.SelfTestConfig{Tool: "health_pingservice_ping"}))
+
+		assert.False(t, report.Passed())
+		assert.Equal(t, map[string]Status{
+			"connect":     StatusFail,
+			"discover":    StatusSkip,
+			"build_tools": StatusSkip,
+			"schemas":     StatusSkip,
+			"invoke":      StatusSkip,
+		}, statuses(report))
+		assert.Contains(t, report.Checks[0].Detail, "connection refused")
+	})
+
+	t.Run("No_methods", func(t *testing.T) {
+		report := Run(context.Background(), newOptions(&stubDiscoverer{}, config.SelfTestConfig{}))
+
+		assert.False(t, report.Passed())
+		assert.Equal(t, StatusFail, statuses(report)["discover"])
+	})
+
+	t.Run("Schema_failure", func(t *testing.T) {
+		broken := pingMethod()
+		broken.Name = "Broken"
+		broken.InputDescriptor = nil
+		discoverer := &stubDiscoverer{methods: []types.MethodInfo{pingMethod(), broken}}
+
+		report := Run(context.Background(), newOptions(discoverer, config.SelfTestConfig{}))
+
+		assert.False(t, report.Passed())
+		assert.Equal(t, StatusFail, statuses(report)["build_tools"])
+		require.Len(t, report.Checks[2].Issues, 1)
+		assert.Contains(t, report.Checks[2].Issues[0], "health.PingService.Broken")
+	})
+
+	t.Run("Invocation_failure", func(t *testing.T) {
+		discoverer := &stubDiscoverer{
+			methods:   []types.MethodInfo{pingMethod()},
+			invokeErr: errors.New("rpc error: code = Unavailable"),
+		}
+
+		report := Run(context.Background(), newOptions(discoverer, config.SelfTestConfig{Tool: "health_pingservice_ping"}))
+
+		assert.False(t, report.Passed())
+		assert.Equal(t, StatusFail, statuses(report)["invoke"])
+	})
+
+	t.Run("Unreachable_upstream", func(t *testing.T) {
+		discoverer := &stubDiscoverer{methods: []types.MethodInfo{pingMethod()}}
+		options := newOptions(discoverer, config.SelfTestConfig{})
+		options.Upstreams = upstream.NewAggregator([]config.UpstreamConfig{{
+			Name:    "missing",
+			Command: filepath.Join(os.TempDir(), "ggrmcp-missing-server"),
+		}}, zap.NewNop())
+
+		report := Run(context.Background(), options)
+
+		assert.False(t, report.Passed())
+		assert.Equal(t, StatusFail, statuses(report)["upstreams"])
+	})
+}
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{
+		Status: StatusFail,
+		Target: "localhost:50051",
+		Checks: []Check{
+			{Name: "connect", Status: StatusPass, Detail: "connected", DurationMs: 3},
+			{Name: "build_tools", Status: StatusFail, Detail: "1 tools built, 1 failed", Issues: []string{"svc.Broken: no descriptor"}},
+		},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "ggRMCP self-test against localhost:50051", lines[0])
+	assert.Contains(t, lines[1], "PASS  connect")
+	assert.Contains(t, lines[2], "FAIL  build_tools")
+	assert.Contains(t, lines[3], "- svc.Broken: no descriptor")
+	assert.Equal(t, "RESULT: FAIL", lines[4])
+
+	var encoded bytes.Buffer
+	require.NoError(t, report.WriteJSON(&encoded))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
+	assert.Equal(t, *report, decoded)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// sessionAffinity issues the header and cookie that let L7 load balancers route
+// a session's requests to this replica, which holds the session in memory
+type sessionAffinity struct {
+	config  config.AffinityConfig
+	replica string
+	maxAge  time.Duration
+
+	// Requests that carried another replica's affinity, e.g. after a scale-down
+	misrouted atomic.Int64
+}
+
+// newSessionAffinity creates session affinity from configuration, or nil when disabled
+func newSessionAffinity(sessionConfig config.SessionConfig, logger *zap.Logger) *sessionAffinity {
+	if !sessionConfig.Affinity.Enabled {
+		return nil
+	}
+
+	replica := sessionConfig.Affinity.ReplicaID
+	if replica == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			logger.Warn("Failed to determine hostname for session affinity", zap.Error(err))
+			hostname = "ggrmcp"
+		}
+		replica = hostname
+	}
+
+	return &sessionAffinity{
+		config:  sessionConfig.Affinity,
+		replica: replica,
+		maxAge:  sessionConfig.Expiration,
+	}
+}
+
+// requested returns the replica named by the request's affinity header or cookie
+func (a *sessionAffinity) requested(r *http.Request) string {
+	if a.config.Header != "" {
+		if replica := r.Header.Get(a.config.Header); replica != "" {
+			return replica
+		}
+	}
+	if a.config.Cookie != "" {
+		if cookie, err := r.Cookie(a.config.Cookie); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// stats returns affinity counters for the metrics endpoint
+func (a *sessionAffinity) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"replica":   a.replica,
+		"misrouted": a.misrouted.Load(),
+	}
+}
+
+// setSessionHeaders names the session in the response, along with the replica
+// affinity header and cookie when affinity is enabled
+func (h *Handler) setSessionHeaders(w http.ResponseWriter, r *http.Request, sessionCtx *session.Context) {
+	w.Header().Set("Mcp-Session-Id", sessionCtx.ID)
+
+	a := h.affinity
+	if a == nil {
+		return
+	}
+
+	requested := a.requested(r)
+	if requested != "" && requested != a.replica {
+		a.misrouted.Add(1)
+		h.logger.Warn("Request carried another replica's session affinity",
+			zap.String("requestedReplica", requested),
+			zap.String("replica", a.replica),
+			zap.String("sessionId", sessionCtx.ID))
+	}
+
+	if a.config.Header != "" {
+		w.Header().Set(a.config.Header, a.replica)
+	}
+	if a.config.Cookie != "" && requested != a.replica {
+		http.SetCookie(w, &http.Cookie{
+			Name:     a.config.Cookie,
+			Value:    a.replica,
+			Path:     "/",
+			MaxAge:   int(a.maxAge.Seconds()),
+			Secure:   a.config.CookieSecure,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
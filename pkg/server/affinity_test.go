@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SessionAffinity(t *testing.T) {
+	cfg := config.Default()
+	cfg.Session.Affinity.Enabled = true
+	cfg.Session.Affinity.ReplicaID = "replica-a"
+	handler, _, _ := newTestHandler(t, cfg)
+
+	initialize := func(mutate func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+		req.Header.Set("Content-Type", "application/json")
+		if mutate != nil {
+			mutate(req)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Issued_with_session", func(t *testing.T) {
+		rec := initialize(nil)
+
+		assert.NotEmpty(t, rec.Header().Get("Mcp-Session-Id"))
+		assert.Equal(t, "replica-a", rec.Header().Get("X-Gateway-Replica"))
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "ggrmcp_replica", cookies[0].Name)
+		assert.Equal(t, "replica-a", cookies[0].Value)
+		assert.Equal(t, int(cfg.Session.Expiration.Seconds()), cookies[0].MaxAge)
+		assert.True(t, cookies[0].HttpOnly)
+	})
+
+	t.Run("Cookie_not_reissued_to_same_replica", func(t *testing.T) {
+		rec := initialize(func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "ggrmcp_replica", Value: "replica-a"})
+		})
+
+		assert.Equal(t, "replica-a", rec.Header().Get("X-Gateway-Replica"))
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("Misrouted_request_repinned", func(t *testing.T) {
+		rec := initialize(func(r *http.Request) {
+			r.Header.Set("X-Gateway-Replica", "replica-b")
+		})
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "replica-a", cookies[0].Value)
+		assert.Equal(t, int64(1), handler.affinity.stats()["misrouted"])
+	})
+}
+
+func TestHandler_SessionAffinityDisabled(t *testing.T) {
+	handler, _, _ := newTestHandler(t, config.Default())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.NotEmpty(t, rec.Header().Get("Mcp-Session-Id"))
+	assert.Empty(t, rec.Header().Get("X-Gateway-Replica"))
+	assert.Empty(t, rec.Result().Cookies())
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/approval"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+// ApprovalsPath is the route of the approvals admin endpoint; decisions are
+// posted to ApprovalsPath + "/{id}"
+const ApprovalsPath = "/admin/approvals"
+
+// maxApprovalDecisionSize bounds the body of a posted decision
+const maxApprovalDecisionSize = 64 * 1024
+
+// approvalsDocument is the body of an approvals listing
+type approvalsDocument struct {
+	Pending []approval.Request `json:"pending"`
+	Decided []approval.Request `json:"decided"`
+}
+
+// approvalDecision is the body of a decision posted by an approver
+type approvalDecision struct {
+	Approve  bool   `json:"approve"`
+	Approver string `json:"approver"`
+	Reason   string `json:"reason"`
+}
+
+// awaitApproval parks a call of a tool requiring approval until an approver
+// decides, returning an error unless the call was approved
+func (h *Handler) awaitApproval(ctx context.Context, toolName string, params map[string]interface{}, sessionCtx *session.Context) error {
+	if !h.approvals.Requires(toolName) {
+		return nil
+	}
+
+	var argumentsJSON string
+	if args, ok := params["arguments"]; ok && args != nil {
+		if encoded, err := json.Marshal(args); err == nil {
+			argumentsJSON = string(encoded)
+		}
+	}
+
+	// Ask the user directly when the client can elicit over this request's stream
+	channel := "admin"
+	if h.approvalConfig.Channel == "elicitation" && sessionCtx.ElicitationSupported() && canRequestClient(ctx) {
+		channel = "elicitation"
+	}
+
+	// The parked call expires no later than the request carrying it
+	deadline, _ := ctx.Deadline()
+	request, err := h.approvals.Submit(approval.Request{
+		Tool:      toolName,
+		SessionID: sessionCtx.ID,
+		RequestID: requestIDFromContext(ctx),
+		Arguments: argumentsJSON,
+		Channel:   channel,
+		ExpiresAt: deadline,
+	})
+	if err != nil {
+		h.logger.Warn("Tool call needing approval refused",
+			zap.String("toolName", toolName),
+			zap.String("sessionId", sessionCtx.ID),
+			zap.Error(err))
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, fmt.Sprintf("Approval unavailable: %v", err))
+	}
+
+	if channel == "elicitation" {
+		go h.elicitApproval(ctx, request)
+	}
+
+	decided, err := h.approvals.Wait(ctx, request.ID)
+	if err != nil {
+		return fmt.Errorf("approval failed: %w", err)
+	}
+	switch decided.Status {
+	case approval.StatusApproved:
+		return nil
+	case approval.StatusRejected:
+		message := "Call rejected by the approver"
+		if decided.Reason != "" {
+			message += ": " + decided.Reason
+		}
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, message)
+	case approval.StatusExpired:
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied,
+			fmt.Sprintf("Call not approved within %s", decided.ExpiresAt.Sub(decided.RequestedAt).Round(time.Millisecond)))
+	}
+	return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, "Call cancelled while waiting for approval")
+}
+
+// elicitApproval asks the client's user to approve a parked call. Without an
+// answer the call waits for an admin decision until it expires.
+func (h *Handler) elicitApproval(ctx context.Context, request approval.Request) {
+	data, err := requestClient(ctx, "elicitation/create", &mcp.ElicitParams{
+		Message: fmt.Sprintf("Allow the call to %s with arguments %s?", request.Tool, request.Arguments),
+		RequestedSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"reason": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional note kept with the decision",
+				},
+			},
+		},
+	}, h.approvalConfig.Timeout)
+	if err != nil {
+		h.logger.Warn("Failed to ask the client for approval",
+			zap.String("approvalId", request.ID),
+			zap.String("toolName", request.Tool),
+			zap.Error(err))
+		return
+	}
+
+	var answer mcp.ElicitResult
+	if err := json.Unmarshal(data, &answer); err != nil {
+		h.logger.Warn("Invalid approval answer from the client",
+			zap.String("approvalId", request.ID),
+			zap.Error(err))
+		return
+	}
+	reason, _ := answer.Content["reason"].(string)
+	if _, err := h.approvals.Decide(request.ID, answer.Action == "accept", "session:"+request.SessionID, reason); err != nil &&
+		!errors.Is(err, approval.ErrNotFound) {
+		h.logger.Warn("Failed to record the client's approval", zap.String("approvalId", request.ID), zap.Error(err))
+	}
+}
+
+// notifyApproval logs approval requests and decisions for the audit trail
+// and announces them to webhooks
+func (h *Handler) notifyApproval(request approval.Request) {
+	eventType := webhook.EventTypeApprovalRequested
+	if request.Status == approval.StatusPending {
+		h.logger.Info("Tool call waiting for approval",
+			zap.String("approvalId", request.ID),
+			zap.String("toolName", request.Tool),
+			zap.String("sessionId", request.SessionID),
+			zap.String("channel", request.Channel))
+	} else {
+		eventType = webhook.EventTypeApprovalDecided
+		h.logger.Info("Tool call approval decided",
+			zap.String("approvalId", request.ID),
+			zap.String("toolName", request.Tool),
+			zap.String("sessionId", request.SessionID),
+			zap.String("status", request.Status),
+			zap.String("approver", request.Approver),
+			zap.String("reason", request.Reason))
+	}
+
+	h.webhooks.Publish(webhook.Event{
+		Type:      eventType,
+		ID:        request.ID,
+		Time:      time.Now().UTC(),
+		Tool:      request.Tool,
+		Status:    request.Status,
+		SessionID: request.SessionID,
+		RequestID: request.RequestID,
+		Arguments: request.Arguments,
+		Approver:  request.Approver,
+		Reason:    request.Reason,
+	})
+}
+
+// CancelApprovals cancels the calls waiting for approval, e.g. when shutting down
+func (h *Handler) CancelApprovals() {
+	h.approvals.Close()
+}
+
+// authorizeApprovals checks the approvals admin token
+func (h *Handler) authorizeApprovals(w http.ResponseWriter, r *http.Request) bool {
+	if h.approvals == nil || h.approvalConfig.AdminToken == "" {
+		http.Error(w, "Approvals are not enabled", http.StatusNotFound)
+		return false
+	}
+
+	return h.requireBearer(w, r, h.approvalConfig.AdminToken, "approvals")
+}
+
+// ApprovalsHandler lists the calls waiting for approval and the audit trail
+// of decided ones
+func (h *Handler) ApprovalsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeApprovals(w, r) {
+		return
+	}
+
+	document := approvalsDocument{
+		Pending: nonNilRequests(h.approvals.Pending()),
+		Decided: nonNilRequests(h.approvals.Audit()),
+	}
+	writeApprovalJSON(w, http.StatusOK, document, h.logger)
+}
+
+// ApprovalDecisionHandler approves or rejects a waiting call
+func (h *Handler) ApprovalDecisionHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeApprovals(w, r) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, ApprovalsPath+"/")
+	var decision approvalDecision
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApprovalDecisionSize)).Decode(&decision); err != nil {
+		http.Error(w, "Invalid decision: expected {\"approve\": bool, \"approver\": string, \"reason\": string}", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(decision.Approver) == "" {
+		http.Error(w, "Invalid decision: approver must be specified", http.StatusBadRequest)
+		return
+	}
+
+	request, err := h.approvals.Decide(id, decision.Approve, decision.Approver, decision.Reason)
+	if errors.Is(err, approval.ErrNotFound) {
+		http.Error(w, "Approval request not found or already decided", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeApprovalJSON(w, http.StatusOK, request, h.logger)
+}
+
+// writeApprovalJSON writes an approvals response
+func writeApprovalJSON(w http.ResponseWriter, statusCode int, value interface{}, logger *zap.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.Error("Failed to encode approvals", zap.Error(err))
+	}
+}
+
+// nonNilRequests makes an empty list encode as [] rather than null
+func nonNilRequests(requests []approval.Request) []approval.Request {
+	if requests == nil {
+		return []approval.Request{}
+	}
+	return requests
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/approval"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ApprovalGate(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Approval.Tools = []string{"bank_transfer"}
+	cfg.MCP.Approval.AdminToken = "admin-token"
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "bank_transfer", mock.Anything).
+		Return(`{"status":"sent"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "bank_balance", mock.Anything).
+		Return(`{"balance":10}`, nil)
+
+	request := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		if method == http.MethodGet {
+			handler.ApprovalsHandler(rec, req)
+		} else {
+			handler.ApprovalDecisionHandler(rec, req)
+		}
+		return rec
+	}
+	pendingID := func() string {
+		var id string
+		require.Eventually(t, func() bool {
+			pending := handler.approvals.Pending()
+			if len(pending) == 1 {
+				id = pending[0].ID
+			}
+			return id != ""
+		}, 5*time.Second, 5*time.Millisecond)
+		return id
+	}
+	transfer := func() chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+				"name":      "bank_transfer",
+				"arguments": map[string]interface{}{"amount": 100},
+			}, sessionCtx)
+			done <- err
+		}()
+		return done
+	}
+
+	// Other tools are not gated
+	_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "bank_balance"}, sessionCtx)
+	require.NoError(t, err)
+
+	t.Run("Approved", func(t *testing.T) {
+		done := transfer()
+		id := pendingID()
+
+		rec := request(http.MethodGet, ApprovalsPath, "admin-token", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var document approvalsDocument
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &document))
+		require.Len(t, document.Pending, 1)
+		assert.Equal(t, "bank_transfer", document.Pending[0].Tool)
+		assert.JSONEq(t, `{"amount":100}`, document.Pending[0].Arguments)
+
+		rec = request(http.MethodPost, ApprovalsPath+"/"+id, "admin-token", `{"approve":true,"approver":"alice"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, <-done)
+		mockDiscoverer.AssertCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "bank_transfer", mock.Anything)
+
+		// A decided request cannot be decided again
+		rec = request(http.MethodPost, ApprovalsPath+"/"+id, "admin-token", `{"approve":false,"approver":"bob"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		done := transfer()
+		id := pendingID()
+
+		rec := request(http.MethodPost, ApprovalsPath+"/"+id, "admin-token", `{"approve":false,"approver":"bob","reason":"too large"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		err := <-done
+		require.Error(t, err)
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, rpcErr.Code)
+		assert.Contains(t, rpcErr.Message, "too large")
+
+		rec = request(http.MethodGet, ApprovalsPath, "admin-token", "")
+		var document approvalsDocument
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &document))
+		assert.Empty(t, document.Pending)
+		require.Len(t, document.Decided, 2)
+		assert.Equal(t, approval.StatusRejected, document.Decided[0].Status)
+		assert.Equal(t, "bob", document.Decided[0].Approver)
+	})
+
+	t.Run("Dry_runs_are_not_gated", func(t *testing.T) {
+		cfg.Tools.DryRun = true
+		dryRunHandler, dryRunDiscoverer, dryRunSession := newTestHandler(t, cfg)
+		dryRunDiscoverer.On("GetMethodByTool", "bank_transfer").Return(types.MethodInfo{}, false)
+		_, err := dryRunHandler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "bank_transfer",
+			"arguments": map[string]interface{}{dryRunArgument: true},
+		}, dryRunSession)
+		if err != nil {
+			assert.NotContains(t, err.Error(), "approv")
+		}
+		assert.Empty(t, dryRunHandler.approvals.Audit())
+	})
+
+	t.Run("Admin_endpoint", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, ApprovalsPath, "", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, ApprovalsPath, "wrong", "").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, ApprovalsPath+"/x", "admin-token", `{"approve":true}`).Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodPost, ApprovalsPath+"/x", "admin-token", `{"approve":true,"approver":"alice"}`).Code)
+
+		// Without gated tools the endpoint is off
+		disabled, _, _ := newTestHandler(t, config.Default())
+		rec := httptest.NewRecorder()
+		disabled.ApprovalsHandler(rec, httptest.NewRequest(http.MethodGet, ApprovalsPath, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Cancelled_on_shutdown", func(t *testing.T) {
+		done := transfer()
+		pendingID()
+		handler.CancelApprovals()
+		err := <-done
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cancelled")
+
+		_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "bank_transfer"}, sessionCtx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Approval unavailable")
+	})
+
+	assert.Equal(t, int64(1), handler.approvals.Stats().Approved)
+}
+
+func TestHandler_ApprovalTimeouts(t *testing.T) {
+	t.Run("Longer_than_the_request", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Approval.Tools = []string{"bank_transfer"}
+		cfg.MCP.Approval.AdminToken = "admin-token"
+		cfg.MCP.Approval.Timeout = time.Minute
+		assert.ErrorContains(t, cfg.Validate(), "approval timeout 1m0s must be below the server timeout 30s")
+
+		cfg.Server.Timeout = 2 * time.Minute
+		assert.ErrorContains(t, cfg.Validate(), "must be below the server write timeout 15s")
+
+		cfg.Server.WriteTimeout = 2 * time.Minute
+		assert.NoError(t, cfg.Validate())
+
+		// Without the timeout middleware only the write timeout bounds the request
+		cfg.Server.Timeout = 30 * time.Second
+		cfg.Server.Middleware.Order = []string{"recovery", "logging"}
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Expires_with_the_request", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Approval.Tools = []string{"bank_transfer"}
+		cfg.MCP.Approval.Timeout = time.Minute
+		cfg.Server.Timeout = 50 * time.Millisecond
+		cfg.Server.Middleware.Order = []string{"timeout"}
+		handler, _, sessionCtx := newTestHandler(t, cfg)
+
+		var err error
+		call := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err = handler.HandleToolsCall(r.Context(), map[string]interface{}{"name": "bank_transfer"}, sessionCtx)
+		})
+		ChainMiddleware(handler.Middleware()...)(call).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+		// The parked call ends at the request's deadline as expired, not cancelled
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Call not approved within")
+		assert.Equal(t, int64(1), handler.approvals.Stats().Expired)
+		assert.Zero(t, handler.approvals.Stats().Cancelled)
+	})
+}
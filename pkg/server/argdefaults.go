@@ -0,0 +1,248 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// presetArgument is the argument selecting a named set of argument values
+const presetArgument = "_preset"
+
+// argumentDefaultsFor returns the argument defaults entries matching a tool, in order
+func (h *Handler) argumentDefaultsFor(toolName string) []config.ArgumentDefaultsConfig {
+	var matched []config.ArgumentDefaultsConfig
+	for _, defaults := range h.argumentDefaults {
+		if defaults.Tool == "*" || defaults.Tool == toolName {
+			matched = append(matched, defaults)
+		}
+	}
+	return matched
+}
+
+// applyArgumentDefaults merges the tool's defaults and the selected preset
+// under the client's arguments. The caller's maps are left untouched.
+func (h *Handler) applyArgumentDefaults(toolName string, params map[string]interface{}) (map[string]interface{}, error) {
+	if len(h.argumentDefaults) == 0 {
+		return params, nil
+	}
+	params, preset, hasPreset := removeArgument(params, presetArgument)
+	matched := h.argumentDefaultsFor(toolName)
+	if len(matched) == 0 && !hasPreset {
+		return params, nil
+	}
+
+	merged := make(map[string]interface{})
+	for _, defaults := range matched {
+		mergeArguments(merged, defaults.Defaults)
+	}
+
+	if hasPreset {
+		name, ok := preset.(string)
+		if !ok {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("Invalid arguments: %s must be a string", presetArgument))
+		}
+		values, ok := findPreset(matched, name)
+		if !ok {
+			available := "none"
+			if names := presetNames(matched); len(names) > 0 {
+				available = strings.Join(names, ", ")
+			}
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("Invalid arguments: unknown %s %q for %s (available: %s)",
+					presetArgument, name, toolName, available))
+		}
+		mergeArguments(merged, values)
+	}
+
+	if args, ok := params["arguments"].(map[string]interface{}); ok {
+		mergeArguments(merged, args)
+	}
+
+	copied := make(map[string]interface{}, len(params)+1)
+	for key, value := range params {
+		copied[key] = value
+	}
+	copied["arguments"] = merged
+	return copied, nil
+}
+
+// findPreset returns the values of a preset, later entries overriding earlier ones
+func findPreset(matched []config.ArgumentDefaultsConfig, name string) (map[string]interface{}, bool) {
+	for i := len(matched) - 1; i >= 0; i-- {
+		if values, ok := matched[i].Presets[name]; ok {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// presetNames returns the sorted names of the presets available to a tool
+func presetNames(matched []config.ArgumentDefaultsConfig) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, defaults := range matched {
+		for name := range defaults.Presets {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mergeArguments copies src into dst, merging nested objects. A key replaces
+// any key naming the same field in the other spelling (page_size and pageSize),
+// since protojson rejects a field given twice.
+func mergeArguments(dst, src map[string]interface{}) {
+	for key, value := range src {
+		existingKey, found := matchingArgument(dst, key)
+		if found {
+			existing, dstIsObject := dst[existingKey].(map[string]interface{})
+			incoming, srcIsObject := value.(map[string]interface{})
+			if dstIsObject && srcIsObject {
+				nested := copyArgument(existing).(map[string]interface{})
+				mergeArguments(nested, incoming)
+				delete(dst, existingKey)
+				dst[key] = nested
+				continue
+			}
+			delete(dst, existingKey)
+		}
+		dst[key] = copyArgument(value)
+	}
+}
+
+// matchingArgument finds the key of args naming the same field as key
+func matchingArgument(args map[string]interface{}, key string) (string, bool) {
+	if _, ok := args[key]; ok {
+		return key, true
+	}
+	normalized := lowerCamel(key)
+	for existing := range args {
+		if lowerCamel(existing) == normalized {
+			return existing, true
+		}
+	}
+	return "", false
+}
+
+// lowerCamel converts a proto field name to its JSON name (page_size to pageSize)
+func lowerCamel(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(r)
+			upper = false
+		}
+	}
+	return b.String()
+}
+
+// copyArgument deep-copies objects and arrays so configured values are never
+// modified by a call
+func copyArgument(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyArgument(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyArgument(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// advertiseArgumentDefaults shows configured defaults in the input schemas,
+// no longer requiring defaulted arguments, and lists the available presets
+func (h *Handler) advertiseArgumentDefaults(tools []mcp.Tool) []mcp.Tool {
+	if len(h.argumentDefaults) == 0 {
+		return tools
+	}
+
+	for i, tool := range tools {
+		matched := h.argumentDefaultsFor(tool.Name)
+		if len(matched) == 0 {
+			continue
+		}
+		schema, ok := tool.InputSchema.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		defaults := make(map[string]interface{})
+		for _, entry := range matched {
+			mergeArguments(defaults, entry.Defaults)
+		}
+
+		// Schemas may be cached by the builder, so they are copied before the change
+		copied := make(map[string]interface{}, len(schema))
+		for key, value := range schema {
+			copied[key] = value
+		}
+		properties := make(map[string]interface{})
+		if existing, ok := schema["properties"].(map[string]interface{}); ok {
+			for key, value := range existing {
+				properties[key] = value
+			}
+		}
+		defaulted := make(map[string]bool)
+		for name, property := range properties {
+			key, ok := matchingArgument(defaults, name)
+			propertySchema, isObject := property.(map[string]interface{})
+			if !ok || !isObject {
+				continue
+			}
+			withDefault := make(map[string]interface{}, len(propertySchema)+1)
+			for k, v := range propertySchema {
+				withDefault[k] = v
+			}
+			withDefault["default"] = defaults[key]
+			properties[name] = withDefault
+			defaulted[name] = true
+		}
+		copied["properties"] = properties
+		if required, ok := schema["required"].([]string); ok {
+			var remaining []string
+			for _, name := range required {
+				if !defaulted[name] {
+					remaining = append(remaining, name)
+				}
+			}
+			if len(remaining) > 0 {
+				copied["required"] = remaining
+			} else {
+				delete(copied, "required")
+			}
+		}
+		tools[i].InputSchema = copied
+
+		if names := presetNames(matched); len(names) > 0 {
+			addArgumentProperty(tools[i:i+1], presetArgument, map[string]interface{}{
+				"type":        "string",
+				"enum":        names,
+				"description": "Named set of argument values applied under the arguments given",
+			})
+		}
+	}
+	return tools
+}
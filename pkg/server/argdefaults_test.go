@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ArgumentDefaults(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.ArgumentDefaults = []config.ArgumentDefaultsConfig{
+		{
+			Tool:     "*",
+			Defaults: map[string]interface{}{"environment": "prod"},
+		},
+		{
+			Tool:     "shop_orders_list",
+			Defaults: map[string]interface{}{"page_size": 20, "filter": map[string]interface{}{"status": "OPEN", "region": "eu"}},
+			Presets: map[string]map[string]interface{}{
+				"failed":  {"filter": map[string]interface{}{"status": "FAILED"}},
+				"staging": {"environment": "staging"},
+			},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+
+	call := func(toolName string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		params := map[string]interface{}{"name": toolName}
+		if arguments != nil {
+			params["arguments"] = arguments
+		}
+		return handler.HandleToolsCall(context.Background(), params, sessionCtx)
+	}
+
+	t.Run("Defaults_fill_missing_arguments", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_list",
+			`{"environment":"prod","filter":{"region":"eu","status":"OPEN"},"page_size":20}`).Return(`{}`, nil).Once()
+		_, err := call("shop_orders_list", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("Client_arguments_win", func(t *testing.T) {
+		// pageSize names the same field as page_size, and nested objects merge
+		arguments := map[string]interface{}{"pageSize": 5, "filter": map[string]interface{}{"status": "SHIPPED"}}
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_list",
+			`{"environment":"prod","filter":{"region":"eu","status":"SHIPPED"},"pageSize":5}`).Return(`{}`, nil).Once()
+		_, err := call("shop_orders_list", arguments)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"status": "SHIPPED"}, arguments["filter"], "caller's arguments are untouched")
+		assert.Equal(t, "OPEN", cfg.Tools.ArgumentDefaults[1].Defaults["filter"].(map[string]interface{})["status"])
+	})
+
+	t.Run("Presets_apply_over_defaults", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_list",
+			`{"environment":"staging","filter":{"region":"eu","status":"FAILED"},"page_size":20}`).Return(`{}`, nil).Once()
+		_, err := call("shop_orders_list", map[string]interface{}{"_preset": "failed", "environment": "staging"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Wildcard_defaults", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get",
+			`{"environment":"prod","id":"o-1"}`).Return(`{}`, nil).Once()
+		_, err := call("shop_orders_get", map[string]interface{}{"id": "o-1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Unknown_preset", func(t *testing.T) {
+		_, err := call("shop_orders_list", map[string]interface{}{"_preset": "archived"})
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		assert.Contains(t, rpcErr.Message, "available: failed, staging")
+
+		_, err = call("shop_orders_get", map[string]interface{}{"_preset": "failed"})
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Contains(t, rpcErr.Message, "available: none")
+	})
+
+	mockDiscoverer.AssertExpectations(t)
+}
+
+func TestHandler_ArgumentDefaultsAdvertised(t *testing.T) {
+	order := orderDescriptor(t)
+	method := types.MethodInfo{
+		Name:             "Place",
+		FullName:         "shop.OrderService.Place",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  order,
+		OutputDescriptor: order,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.ArgumentDefaults = []config.ArgumentDefaultsConfig{{
+		Tool:     method.ToolName,
+		Defaults: map[string]interface{}{"note": "via agent"},
+		Presets:  map[string]map[string]interface{}{"gift": {"gift": true}},
+	}}
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{method})
+
+	result, err := handler.handleToolsList(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Tools, 1)
+	properties := result.Tools[0].InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, "via agent", properties["note"].(map[string]interface{})["default"])
+	assert.Equal(t, []string{"gift"}, properties["_preset"].(map[string]interface{})["enum"])
+}
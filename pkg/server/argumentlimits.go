@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// argumentLimitFor returns the first argument limit that applies to the tool
+func (h *Handler) argumentLimitFor(toolName string) (config.ArgumentLimitConfig, bool) {
+	for _, limit := range h.argumentLimits {
+		if limit.Tool == "*" || limit.Tool == toolName {
+			return limit, true
+		}
+	}
+	return config.ArgumentLimitConfig{}, false
+}
+
+// checkArgumentLimits rejects calls whose arguments hold an array, object or
+// string over the tool's limits
+func (h *Handler) checkArgumentLimits(toolName string, params map[string]interface{}) error {
+	limit, ok := h.argumentLimitFor(toolName)
+	if !ok {
+		return nil
+	}
+	args, ok := params["arguments"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if err := checkArgumentValue("", args, limit); err != nil {
+		return mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %v", err))
+	}
+	return nil
+}
+
+// checkArgumentValue checks a value and everything nested in it against the
+// limits; path locates the value in the arguments
+func checkArgumentValue(path string, value interface{}, limit config.ArgumentLimitConfig) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if limit.MaxMapEntries > 0 && len(v) > limit.MaxMapEntries {
+			return fmt.Errorf("%s has %d entries (max %d)", argumentPath(path), len(v), limit.MaxMapEntries)
+		}
+		for key, item := range v {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if err := checkArgumentValue(child, item, limit); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if limit.MaxArrayLength > 0 && len(v) > limit.MaxArrayLength {
+			return fmt.Errorf("%s has %d items (max %d)", argumentPath(path), len(v), limit.MaxArrayLength)
+		}
+		for i, item := range v {
+			if err := checkArgumentValue(fmt.Sprintf("%s[%d]", path, i), item, limit); err != nil {
+				return err
+			}
+		}
+	case string:
+		if limit.MaxStringLength > 0 && len(v) > limit.MaxStringLength {
+			return fmt.Errorf("%s is %d bytes long (max %d)", argumentPath(path), len(v), limit.MaxStringLength)
+		}
+	}
+	return nil
+}
+
+// argumentPath names a location in the arguments for error messages
+func argumentPath(path string) string {
+	if path == "" {
+		return "arguments"
+	}
+	return path
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ArgumentLimits(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.ArgumentLimits = []config.ArgumentLimitConfig{
+		{Tool: "shop_orders_import", MaxArrayLength: 1000},
+		{Tool: "*", MaxArrayLength: 3, MaxMapEntries: 2, MaxStringLength: 8},
+	}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(`{}`, nil)
+
+	call := func(toolName string, arguments map[string]interface{}) error {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      toolName,
+			"arguments": arguments,
+		}, sessionCtx)
+		return err
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		expected  string
+	}{
+		{"Array", map[string]interface{}{"ids": []interface{}{"a", "b", "c", "d"}}, "ids has 4 items (max 3)"},
+		{"Map", map[string]interface{}{"order": map[string]interface{}{"labels": map[string]interface{}{"a": "1", "b": "2", "c": "3"}}},
+			"order.labels has 3 entries (max 2)"},
+		{"Nested_string", map[string]interface{}{"orders": []interface{}{map[string]interface{}{"note": "far too long"}}},
+			"orders[0].note is 12 bytes long (max 8)"},
+		{"Top_level_object", map[string]interface{}{"a": 1, "b": 2, "c": 3}, "arguments has 3 entries (max 2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := call("shop_orders_create", tt.arguments)
+			var rpcErr *mcp.RPCError
+			require.ErrorAs(t, err, &rpcErr)
+			assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+			assert.Equal(t, "Invalid arguments: "+tt.expected, rpcErr.Message)
+		})
+	}
+	mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_create", mock.Anything)
+
+	t.Run("Within_limits", func(t *testing.T) {
+		require.NoError(t, call("shop_orders_create", map[string]interface{}{"ids": []interface{}{"a", "b", "c"}, "note": "short"}))
+	})
+
+	t.Run("Tool_entry_replaces_global", func(t *testing.T) {
+		ids := make([]interface{}, 500)
+		for i := range ids {
+			ids[i] = strings.Repeat("x", 20)
+		}
+		require.NoError(t, call("shop_orders_import", map[string]interface{}{"ids": ids}))
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// decodedArguments are a call's arguments, decoded once for the stages that
+// check and rewrite them against the method's input descriptor
+type decodedArguments struct {
+	toolName   string
+	sessionCtx *session.Context
+	desc       protoreflect.MessageDescriptor
+	doc        map[string]interface{}
+
+	// Set by a stage that rewrote doc
+	changed bool
+}
+
+// argumentStage checks the decoded arguments of a call, rewriting them in place if needed
+type argumentStage func(args *decodedArguments) error
+
+// checkArguments runs the argument stages on a single decoding of the
+// arguments and re-encodes them only if a stage changed them, so unchanged
+// arguments reach protojson as given
+func (h *Handler) checkArguments(toolName, argumentsJSON string, sessionCtx *session.Context) (string, error) {
+	if argumentsJSON == "" || !h.checksArguments() {
+		return argumentsJSON, nil
+	}
+
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok || method.InputDescriptor == nil {
+		return argumentsJSON, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(argumentsJSON)))
+	decoder.UseNumber()
+
+	// Malformed arguments are reported by protojson on invocation
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return argumentsJSON, nil
+	}
+
+	args := &decodedArguments{toolName: toolName, sessionCtx: sessionCtx, desc: method.InputDescriptor, doc: doc}
+	stages := []argumentStage{
+		// Resolve uploaded resources and data URIs given for bytes fields
+		h.resolveBinaryInputs,
+		// Check the encoding of bytes arguments, which may also be given as hex
+		h.normalizeBytesInputs,
+		// Write integer and bool map keys the way protojson expects them
+		h.normalizeMapKeyArguments,
+		// Reject malformed dates, UUIDs and similar before they reach the backend
+		h.validateFormats,
+		// Refuse doubles and floats the field would round, when configured
+		h.checkDoublePrecision,
+	}
+	for _, stage := range stages {
+		if err := stage(args); err != nil {
+			return "", err
+		}
+	}
+
+	if !args.changed {
+		return argumentsJSON, nil
+	}
+	return encodeJSON(args.doc)
+}
+
+// checksArguments reports whether any argument stage is enabled
+func (h *Handler) checksArguments() bool {
+	return h.binaryInputs.Enabled ||
+		h.bytesEncoding.Normalize || h.bytesEncoding.AcceptHex ||
+		h.normalizeMapKeys ||
+		h.formats.Validating() ||
+		h.doublePrecision == "reject"
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_CheckArguments(t *testing.T) {
+	sample := fuzzDescriptor(t)
+	method := types.MethodInfo{
+		Name:             "Put",
+		FullName:         "fuzz.SampleService.Put",
+		ServiceName:      "fuzz.SampleService",
+		InputDescriptor:  sample,
+		OutputDescriptor: sample,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.BytesEncoding.AcceptHex = true
+	cfg.Tools.NormalizeMapKeys = true
+	cfg.Tools.DoublePrecision = "reject"
+	require.NoError(t, cfg.Validate())
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true)
+
+	call := func(arguments string) error {
+		_, err := handler.handleRequest(context.Background(), &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"` + method.ToolName + `","arguments":` + arguments + `}`),
+		}, sessionCtx)
+		return err
+	}
+
+	t.Run("Stages_share_one_rewrite", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, method.ToolName,
+			`{"flags":{"true":true},"parent":{"payload":"AAE="},"ratio":0.5}`).Return(`{}`, nil).Once()
+		require.NoError(t, call(`{"ratio":0.5,"parent":{"payload":"0x0001"},"flags":{"True":true}}`))
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Unchanged_arguments_are_passed_as_given", func(t *testing.T) {
+		arguments := `{"ratio": 0.5, "payload":"AAE=", "flags":{"true":true}}`
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, method.ToolName, arguments).Return(`{}`, nil).Once()
+		require.NoError(t, call(arguments))
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("First_failing_stage_is_reported", func(t *testing.T) {
+		err := call(`{"payload":"0xZZ","ratio":0.30000000000000004441}`)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "hex")
+	})
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/auth"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+)
+
+// newJWTVerifier creates the bearer token verifier, or nil if disabled
+func newJWTVerifier(jwtConfig config.JWTConfig) *auth.Verifier {
+	if !jwtConfig.Enabled {
+		return nil
+	}
+	return auth.NewVerifier(jwtConfig)
+}
+
+// authenticate verifies the request's bearer token and returns the request
+// carrying its claims. Requests without a valid token get 401.
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if h.jwt == nil {
+		return r, true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		h.challenge(w, r, "")
+		return r, false
+	}
+	claims, err := h.jwt.Verify(r.Context(), token)
+	if err != nil {
+		h.logger.Warn("Rejected bearer token",
+			zap.String("remoteAddr", r.RemoteAddr),
+			zap.Error(err))
+		h.challenge(w, r, "invalid_token")
+		return r, false
+	}
+	return r.WithContext(auth.WithClaims(r.Context(), claims)), true
+}
+
+// requireBearer checks that the request carries the admin token of an
+// endpoint as a bearer token, answering 401 if not. what names the endpoint
+// in the log.
+func (h *Handler) requireBearer(w http.ResponseWriter, r *http.Request, token, what string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		h.logger.Warn("Rejected "+what+" request", zap.String("remoteAddr", r.RemoteAddr))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// challenge answers 401 with a Bearer challenge pointing to the protected
+// resource metadata, so OAuth clients can discover the authorization server
+func (h *Handler) challenge(w http.ResponseWriter, r *http.Request, errorCode string) {
+	challenge := `Bearer realm="ggRMCP"`
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error=%q`, errorCode)
+	}
+	if len(h.wellKnown.AuthorizationServers) > 0 {
+		challenge += fmt.Sprintf(`, resource_metadata=%q`, originOf(h.publicURL(r))+ProtectedResourcePath)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// roleAccess maps the roles in token claims to the tools they grant
+type roleAccess struct {
+	claims []string
+	tools  map[string][]string // role -> tool patterns
+}
+
+// newRoleAccess creates the role mapping, or nil if RBAC is disabled
+func newRoleAccess(rbacConfig config.RBACConfig) *roleAccess {
+	if !rbacConfig.Enabled {
+		return nil
+	}
+	tools := make(map[string][]string, len(rbacConfig.Roles))
+	for _, role := range rbacConfig.Roles {
+		tools[role.Name] = append(tools[role.Name], role.Tools...)
+	}
+	return &roleAccess{claims: rbacConfig.Claims, tools: tools}
+}
+
+// roles returns the caller's roles found in the claims
+func (a *roleAccess) roles(claims auth.Claims) []string {
+	var roles []string
+	for _, claim := range a.claims {
+		roles = append(roles, claims.Strings(claim)...)
+	}
+	return roles
+}
+
+// allows reports whether one of the caller's roles grants the tool
+func (a *roleAccess) allows(claims auth.Claims, toolName string) bool {
+	for _, role := range a.roles(claims) {
+		for _, pattern := range a.tools[role] {
+			if matched, _ := path.Match(pattern, toolName); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rolesAllow reports whether the caller of the request may use a tool
+func (h *Handler) rolesAllow(ctx context.Context, toolName string) bool {
+	if h.rbac == nil {
+		return true
+	}
+	claims, _ := auth.ClaimsFromContext(ctx)
+	return h.rbac.allows(claims, toolName)
+}
+
+// checkRoles rejects calls of tools no role of the caller grants
+func (h *Handler) checkRoles(ctx context.Context, toolName string) error {
+	if h.rolesAllow(ctx, toolName) {
+		return nil
+	}
+	claims, _ := auth.ClaimsFromContext(ctx)
+	h.logger.Info("Tool call denied by roles",
+		zap.String("toolName", toolName),
+		zap.String("subject", claims.Subject()),
+		zap.Strings("roles", h.rbac.roles(claims)))
+	return mcp.NewRPCError(mcp.ErrorCodePermissionDenied,
+		fmt.Sprintf("Permission denied: no role grants tool %s", toolName))
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// testJWT returns an HS256 token carrying the claims
+func testJWT(t *testing.T, claims map[string]interface{}) string {
+	encode := func(value interface{}) string {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandler_RBAC(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.JWT.Enabled = true
+	cfg.Server.Security.JWT.Secret = testJWTSecret
+	cfg.Server.Security.RBAC.Enabled = true
+	cfg.Server.Security.RBAC.Claims = []string{"roles", "realm_access.roles"}
+	cfg.Server.Security.RBAC.Roles = []config.RoleConfig{
+		{Name: "support", Tools: []string{"*_get"}},
+		{Name: "admin", Tools: []string{"*"}},
+	}
+	cfg.MCP.WellKnown.AuthorizationServers = []string{"https://auth.example.com"}
+	require.NoError(t, cfg.Validate())
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+
+	order := orderDescriptor(t)
+	var methods []types.MethodInfo
+	for _, name := range []string{"Get", "Cancel"} {
+		method := types.MethodInfo{
+			Name:             name,
+			FullName:         "shop.OrderService." + name,
+			ServiceName:      "shop.OrderService",
+			InputDescriptor:  order,
+			OutputDescriptor: order,
+		}
+		method.ToolName = method.GenerateToolName()
+		methods = append(methods, method)
+	}
+	mockDiscoverer.On("GetMethods").Return(methods)
+	getTool, cancelTool := methods[0].ToolName, methods[1].ToolName
+
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	listTools := func(token string) []string {
+		rec := post(token, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response struct {
+			Result mcp.ToolsListResult `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		var names []string
+		for _, tool := range response.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	expires := time.Now().Add(time.Hour).Unix()
+	support := testJWT(t, map[string]interface{}{"sub": "alice", "exp": expires, "roles": []string{"support"}})
+	admin := testJWT(t, map[string]interface{}{"sub": "bob", "exp": expires, "realm_access": map[string]interface{}{"roles": []string{"admin"}}})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		rec := post("", `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, `Bearer realm="ggRMCP", resource_metadata="http://example.com/.well-known/oauth-protected-resource"`,
+			rec.Header().Get("WWW-Authenticate"))
+
+		expired := testJWT(t, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()})
+		rec = post(expired, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+	})
+
+	t.Run("Listing_follows_roles", func(t *testing.T) {
+		assert.Equal(t, []string{getTool}, listTools(support))
+		assert.ElementsMatch(t, []string{getTool, cancelTool}, listTools(admin))
+		assert.Empty(t, listTools(testJWT(t, map[string]interface{}{"sub": "carol", "exp": expires})))
+	})
+
+	t.Run("Calls_follow_roles", func(t *testing.T) {
+		rec := post(support, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"`+cancelTool+`"}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var response mcp.JSONRPCResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.NotNil(t, response.Error)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, response.Error.Code)
+		assert.Contains(t, response.Error.Message, "no role grants tool "+cancelTool)
+
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, cancelTool, "").Return(`{}`, nil).Once()
+		rec = post(admin, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"`+cancelTool+`"}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), `"error"`)
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Invalid_config", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Security.RBAC.Enabled = true
+		assert.ErrorContains(t, cfg.Validate(), "requires JWT")
+
+		cfg.Server.Security.JWT.Enabled = true
+		assert.ErrorContains(t, cfg.Validate(), "secret or JWKS URL")
+
+		cfg.Server.Security.JWT.Secret = "short"
+		assert.ErrorContains(t, cfg.Validate(), "at least 32 bytes")
+	})
+}
+
+func TestHandler_RequireBearer(t *testing.T) {
+	handler, _, _ := newTestHandler(t, config.Default())
+
+	for _, tt := range []struct {
+		name, token, header string
+		allowed             bool
+	}{
+		{name: "Matching_token", token: "secret", header: "Bearer secret", allowed: true},
+		{name: "Wrong_token", token: "secret", header: "Bearer other"},
+		{name: "Not_a_bearer_token", token: "secret", header: "secret"},
+		{name: "Missing_header", token: "secret"},
+		{name: "No_token_configured", header: "Bearer "},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			assert.Equal(t, tt.allowed, handler.requireBearer(rec, req, tt.token, "test"))
+			if !tt.allowed {
+				assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// handleToolsCallBatch handles the tools/call_batch extension method. The raw
+// arguments of each call, when known, are passed on as the client wrote them.
+func (h *Handler) handleToolsCallBatch(ctx context.Context, params map[string]interface{}, rawArguments []json.RawMessage, sessionCtx *session.Context) (*mcp.ToolCallBatchResult, error) {
+	calls, err := h.parseBatchCalls(params)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Debug("Invoking tool batch",
+		zap.Int("callCount", len(calls)),
+		zap.Int("concurrency", h.batchConfig.Concurrency),
+		zap.String("sessionId", sessionCtx.ID))
+
+	results := make([]mcp.ToolCallBatchItem, len(calls))
+	sem := make(chan struct{}, h.batchConfig.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call map[string]interface{}) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = mcp.ToolCallBatchItem{
+					Error: &mcp.RPCError{
+						Code:    mcp.ErrorCodeInternalError,
+						Message: mcp.SanitizeError(ctx.Err()),
+					},
+				}
+				return
+			}
+
+			callCtx := ctx
+			if i < len(rawArguments) {
+				arguments, _ := call["arguments"].(map[string]interface{})
+				callCtx = withClientArguments(ctx, arguments, rawArguments[i])
+			}
+			result, err := h.handleBatchCall(callCtx, call, sessionCtx)
+			if err != nil {
+				results[i] = mcp.ToolCallBatchItem{
+					Error: rpcErrorFor(err),
+				}
+				return
+			}
+			results[i] = mcp.ToolCallBatchItem{Result: result}
+		}(i, call)
+	}
+	wg.Wait()
+
+	return &mcp.ToolCallBatchResult{
+		Results: results,
+	}, nil
+}
+
+// handleBatchCall handles one call of a batch. A panic in the batch's goroutine
+// would not reach the request's recovery, so it is recovered here.
+func (h *Handler) handleBatchCall(ctx context.Context, call map[string]interface{}, sessionCtx *session.Context) (_ *mcp.ToolCallResult, err error) {
+	defer h.recovery.recoverRequest("tools/call", &err)
+
+	return h.handleToolsCall(ctx, call, sessionCtx)
+}
+
+// parseBatchCalls extracts and validates the list of calls in a batch request
+func (h *Handler) parseBatchCalls(params map[string]interface{}) ([]map[string]interface{}, error) {
+	rawCalls, exists := params["calls"]
+	if !exists {
+		return nil, fmt.Errorf("invalid parameters: calls is required")
+	}
+
+	items, ok := rawCalls.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid parameters: calls must be an array")
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("invalid parameters: calls cannot be empty")
+	}
+
+	if len(items) > h.batchConfig.MaxItems {
+		return nil, fmt.Errorf("invalid parameters: batch exceeds %d calls", h.batchConfig.MaxItems)
+	}
+
+	calls := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid parameters: calls[%d] must be an object", i)
+		}
+		calls = append(calls, call)
+	}
+
+	return calls, nil
+}
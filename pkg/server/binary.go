@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aalobaidi/ggRMCP/pkg/jsonwalk"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// bytesValueName is the wrapper type whose JSON form is a base64 string
+const bytesValueName protoreflect.FullName = "google.protobuf.BytesValue"
+
+// binaryExtraction stores large bytes fields of one response as resources
+type binaryExtraction struct {
+	handler    *Handler
+	toolName   string
+	sessionCtx *session.Context
+	links      []mcp.ContentBlock
+}
+
+// extractBinaryFields replaces large bytes fields in a tool response with
+// resource URIs and returns resource links for them
+func (h *Handler) extractBinaryFields(toolName, result string, sessionCtx *session.Context) (string, []mcp.ContentBlock) {
+	threshold := h.binaryFields.ThresholdBytes
+	if !h.binaryFields.Enabled || base64.StdEncoding.EncodedLen(threshold) >= len(result) {
+		return result, nil
+	}
+
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok || method.OutputDescriptor == nil {
+		return result, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(result)))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		// Response transformations may have reshaped the payload
+		return result, nil
+	}
+
+	extraction := &binaryExtraction{handler: h, toolName: toolName, sessionCtx: sessionCtx}
+	walkBytesFields(method.OutputDescriptor, doc, extraction.storeBytes)
+	if len(extraction.links) == 0 {
+		return result, nil
+	}
+
+	encoded, err := encodeJSON(doc)
+	if err != nil {
+		h.logger.Warn("Failed to re-encode response after extracting bytes fields",
+			zap.String("toolName", toolName),
+			zap.Error(err))
+		return result, nil
+	}
+	return encoded, extraction.links
+}
+
+// walkBytesFields visits every bytes and google.protobuf.BytesValue value in a
+// message's decoded JSON, replacing each with the value returned by visit
+func walkBytesFields(desc protoreflect.MessageDescriptor, obj map[string]interface{}, visit func(protoreflect.FieldDescriptor, interface{}) interface{}) {
+	jsonwalk.Walker{
+		Match: isBytesField,
+		Visit: func(fd protoreflect.FieldDescriptor, _ string, value interface{}) interface{} {
+			return visit(fd, value)
+		},
+	}.Walk(desc, obj)
+}
+
+// isBytesField reports whether a field holds bytes, directly or wrapped
+func isBytesField(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.BytesKind ||
+		(fd.Message() != nil && fd.Message().FullName() == bytesValueName)
+}
+
+// storeBytes stores a base64 value above the threshold and returns its URI in its place
+func (e *binaryExtraction) storeBytes(fd protoreflect.FieldDescriptor, value interface{}) interface{} {
+	encoded, ok := value.(string)
+	if !ok || base64.StdEncoding.DecodedLen(len(encoded)) <= e.handler.binaryFields.ThresholdBytes {
+		return value
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) <= e.handler.binaryFields.ThresholdBytes {
+		return value
+	}
+
+	mimeType := e.handler.binaryFields.MimeTypes[string(fd.FullName())]
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	name := fmt.Sprintf("%s %s", e.toolName, fd.Name())
+	resource, err := e.handler.resources.Put(e.sessionCtx.ID, name, mimeType, data)
+	if err != nil {
+		e.handler.logger.Warn("Failed to store bytes field as resource, returning it inline",
+			zap.String("toolName", e.toolName),
+			zap.String("field", string(fd.FullName())),
+			zap.Error(err))
+		return value
+	}
+
+	e.links = append(e.links, mcp.ResourceLinkContent(resource.URI, resource.Name,
+		fmt.Sprintf("Contents of bytes field %s", fd.FullName()), resource.MimeType, resource.Size))
+	return resource.URI
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// documentDescriptor builds a message with scalar, repeated and nested bytes fields
+func documentDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	child := field("child", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional)
+	child.TypeName = proto.String(".docs.Document")
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("docs.proto"),
+		Package: proto.String("docs"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Document"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional),
+				field("content", 2, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional),
+				field("pages", 3, descriptorpb.FieldDescriptorProto_TYPE_BYTES, repeated),
+				child,
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Document")
+}
+
+func TestHandler_ToolsCallBinaryFields(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.BinaryFields.Enabled = true
+	cfg.Tools.BinaryFields.ThresholdBytes = 16
+	cfg.Tools.BinaryFields.MimeTypes = map[string]string{"docs.Document.content": "application/pdf"}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+
+	large := []byte(strings.Repeat("%PDF-", 10))
+	page := []byte(strings.Repeat("page ", 10))
+	small := []byte("tiny")
+	encode := base64.StdEncoding.EncodeToString
+	response, err := json.Marshal(map[string]interface{}{
+		"name":    "report",
+		"content": encode(large),
+		"pages":   []string{encode(small), encode(page)},
+		"child":   map[string]interface{}{"content": encode(large)},
+	})
+	require.NoError(t, err)
+
+	mockDiscoverer.On("GetMethodByTool", "docs_service_get").
+		Return(types.MethodInfo{OutputDescriptor: documentDescriptor(t)}, true)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "docs_service_get", "").
+		Return(string(response), nil)
+
+	result, err := handler.HandleToolsCall(context.Background(),
+		map[string]interface{}{"name": "docs_service_get"}, sessionCtx)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	// Three large values become links; the small page stays inline
+	require.Len(t, result.Content, 4)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &doc))
+	assert.Equal(t, "report", doc["name"])
+	pages := doc["pages"].([]interface{})
+	assert.Equal(t, encode(small), pages[0])
+
+	links := map[string]mcp.ContentBlock{}
+	for _, block := range result.Content[1:] {
+		assert.Equal(t, mcp.ContentTypeResourceLink, block.Type)
+		links[block.URI] = block
+	}
+
+	// Configured MIME types apply to the field wherever it appears; others are sniffed
+	contentLink := links[doc["content"].(string)]
+	assert.Equal(t, "application/pdf", contentLink.MimeType)
+	assert.Equal(t, int64(len(large)), contentLink.Size)
+	assert.Equal(t, "application/pdf", links[doc["child"].(map[string]interface{})["content"].(string)].MimeType)
+	assert.Equal(t, "text/plain; charset=utf-8", links[pages[1].(string)].MimeType)
+
+	// The stored bytes are returned as a blob
+	read, err := handler.handleResourcesRead(context.Background(),
+		map[string]interface{}{"uri": doc["content"]}, sessionCtx)
+	require.NoError(t, err)
+	assert.Equal(t, encode(large), read.Contents[0].Blob)
+}
+
+func TestHandler_ToolsCallBinaryFieldsBelowThreshold(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.BinaryFields.Enabled = true
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "docs_service_get", "").
+		Return(`{"content":"dGlueQ=="}`, nil)
+
+	result, err := handler.HandleToolsCall(context.Background(),
+		map[string]interface{}{"name": "docs_service_get"}, sessionCtx)
+	require.NoError(t, err)
+
+	// Small responses skip the descriptor lookup entirely
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, `{"content":"dGlueQ=="}`, result.Content[0].Text)
+	mockDiscoverer.AssertNotCalled(t, "GetMethodByTool", mock.Anything)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+)
+
+// builtinTool is a tool provided by the gateway itself rather than a backend
+type builtinTool struct {
+	tool mcp.Tool
+	call func(ctx context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error)
+}
+
+// addBuiltinTool exposes a gateway tool; tools are added while the handler is
+// set up, before it serves requests
+func (h *Handler) addBuiltinTool(tool builtinTool) {
+	h.builtins = append(h.builtins, tool)
+}
+
+// builtinToolList returns the definitions of the gateway tools
+func (h *Handler) builtinToolList() []mcp.Tool {
+	tools := make([]mcp.Tool, 0, len(h.builtins))
+	for _, builtin := range h.builtins {
+		tools = append(tools, builtin.tool)
+	}
+	return tools
+}
+
+// findBuiltinTool returns the gateway tool with the given name
+func (h *Handler) findBuiltinTool(name string) (builtinTool, bool) {
+	for _, builtin := range h.builtins {
+		if builtin.tool.Name == name {
+			return builtin, true
+		}
+	}
+	return builtinTool{}, false
+}
+
+// callBuiltinTool calls a gateway tool and counts the call in the session
+func (h *Handler) callBuiltinTool(ctx context.Context, builtin builtinTool, params map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	arguments, _ := params["arguments"].(map[string]interface{})
+	result, err := builtin.call(ctx, arguments, sessionCtx)
+	if err != nil {
+		return nil, err
+	}
+	sessionCtx.IncrementCallCount()
+	sessionCtx.UpdateLastAccessed()
+	return result, nil
+}
+
+// integerArgument reads a whole number argument within bounds
+func integerArgument(value interface{}, minimum, maximum int) (int, bool) {
+	if literal, ok := value.(json.Number); ok {
+		parsed, err := literal.Float64()
+		if err != nil {
+			return 0, false
+		}
+		value = parsed
+	}
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) || number < float64(minimum) || number > float64(maximum) {
+		return 0, false
+	}
+	return int(number), true
+}
+
+// jsonToolResult returns a value as the JSON text of a tool result
+func jsonToolResult(value interface{}) (*mcp.ToolCallResult, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return &mcp.ToolCallResult{
+		Content: []mcp.ContentBlock{mcp.TextContent(string(encoded))},
+	}, nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// hexPrefix marks a bytes argument given as hex
+const hexPrefix = "0x"
+
+// bytesNormalization re-encodes the bytes fields of one call's arguments
+type bytesNormalization struct {
+	acceptHex bool
+	changed   bool
+	err       error
+}
+
+// normalizeBytesInputs decodes bytes arguments in any accepted encoding and
+// passes them on as standard base64, rejecting values that are not encoded
+func (h *Handler) normalizeBytesInputs(args *decodedArguments) error {
+	if !h.bytesEncoding.Normalize && !h.bytesEncoding.AcceptHex {
+		return nil
+	}
+
+	normalization := &bytesNormalization{acceptHex: h.bytesEncoding.AcceptHex}
+	walkBytesFields(args.desc, args.doc, normalization.normalize)
+	if normalization.err != nil {
+		return mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %s", normalization.err.Error()))
+	}
+	args.changed = args.changed || normalization.changed
+	return nil
+}
+
+// normalize returns the standard base64 form of a bytes value
+func (n *bytesNormalization) normalize(fd protoreflect.FieldDescriptor, value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || n.err != nil {
+		return value
+	}
+
+	data, err := decodeBytes(text, n.acceptHex)
+	if err != nil {
+		n.err = fmt.Errorf("field %s: %w", fd.Name(), err)
+		return value
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	if encoded != text {
+		n.changed = true
+	}
+	return encoded
+}
+
+// decodeBytes decodes a bytes argument given as base64, or as 0x-prefixed
+// hex if allowed
+func decodeBytes(text string, acceptHex bool) ([]byte, error) {
+	if acceptHex && (strings.HasPrefix(text, hexPrefix) || strings.HasPrefix(text, "0X")) {
+		data, err := hex.DecodeString(text[len(hexPrefix):])
+		if err != nil {
+			return nil, errors.New("invalid hex value: expected an even number of hex digits after 0x")
+		}
+		return data, nil
+	}
+
+	data, err := decodeBase64(text)
+	if err != nil {
+		if acceptHex {
+			return nil, errors.New("invalid value: expected base64 (standard or URL-safe) or 0x-prefixed hex")
+		}
+		return nil, errors.New("invalid value: expected base64 (standard or URL-safe)")
+	}
+	return data, nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding,
+// detecting the alphabet the same way protojson does
+func decodeBase64(text string) ([]byte, error) {
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(text, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if len(text)%4 != 0 {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	return encoding.DecodeString(text)
+}
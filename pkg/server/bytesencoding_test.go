@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBytes(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0xbe, 0x01}
+
+	tests := []struct {
+		name      string
+		input     string
+		acceptHex bool
+		wantErr   bool
+	}{
+		{name: "Standard", input: "+/++AQ=="},
+		{name: "Standard_unpadded", input: "+/++AQ"},
+		{name: "URL_safe", input: "-_--AQ=="},
+		{name: "URL_safe_unpadded", input: "-_--AQ"},
+		{name: "Hex", input: "0xfbffbe01", acceptHex: true},
+		{name: "Hex_uppercase", input: "0XFBFFBE01", acceptHex: true},
+		{name: "Odd_hex", input: "0xfbf", acceptHex: true, wantErr: true},
+		{name: "Mixed_alphabets", input: "+_++AQ==", wantErr: true},
+		{name: "Not_encoded", input: "hello world", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := decodeBytes(tt.input, tt.acceptHex)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, data, decoded)
+		})
+	}
+}
+
+func TestHandler_ToolsCallBytesEncoding(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.BytesEncoding.AcceptHex = true
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", "docs_service_process").
+		Return(types.MethodInfo{InputDescriptor: documentDescriptor(t)}, true)
+
+	call := func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "docs_service_process",
+			"arguments": arguments,
+		}, sessionCtx)
+	}
+
+	t.Run("Normalizes_to_standard_base64", func(t *testing.T) {
+		expected, err := encodeJSON(map[string]interface{}{
+			"content": "+/++AQ==",
+			"pages":   []interface{}{"aGk=", "AAEC"},
+		})
+		require.NoError(t, err)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "docs_service_process", expected).
+			Return(`{}`, nil).Once()
+
+		result, err := call(map[string]interface{}{
+			"content": "-_--AQ",
+			"pages":   []interface{}{"0x6869", "AAEC"},
+		})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("Rejects_invalid_encoding", func(t *testing.T) {
+		_, err := call(map[string]interface{}{
+			"child": map[string]interface{}{"content": "not base64!"},
+		})
+
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		assert.Equal(t, "Invalid arguments: field content: invalid value: expected base64 (standard or URL-safe) or 0x-prefixed hex", rpcErr.Message)
+	})
+
+	mockDiscoverer.AssertExpectations(t)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// headersArgument is the argument holding headers forwarded with one call only
+const headersArgument = "_headers"
+
+// callHeadersEnabled reports whether clients may set headers per call
+func (h *Handler) callHeadersEnabled() bool {
+	return h.headerFilter.IsEnabled() && len(h.headerFilter.GetCallHeaders()) > 0
+}
+
+// extractCallHeaders removes the headers argument from the call parameters.
+// Every header must be allowed per call; names are lowercased as in gRPC
+// metadata.
+func (h *Handler) extractCallHeaders(params map[string]interface{}) (map[string]interface{}, map[string]string, error) {
+	if !h.callHeadersEnabled() {
+		return params, nil, nil
+	}
+	params, value, ok := removeArgument(params, headersArgument)
+	if !ok {
+		return params, nil, nil
+	}
+	given, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("Invalid arguments: %s must be an object", headersArgument))
+	}
+
+	callHeaders := make(map[string]string, len(given))
+	for name, value := range given {
+		if !h.headerFilter.AllowsCallHeader(name) {
+			return nil, nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("Invalid arguments: %s: header %q may not be set per call", headersArgument, name))
+		}
+		text, ok := value.(string)
+		if !ok {
+			return nil, nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("Invalid arguments: %s: header %q must be a string", headersArgument, name))
+		}
+		callHeaders[strings.ToLower(name)] = text
+	}
+	return params, callHeaders, nil
+}
+
+// forwardedHeaders returns the session's forwarded headers with the call's
+// own headers replacing those of the same name, and the client's identity,
+// as valid gRPC metadata
+func (h *Handler) forwardedHeaders(sessionCtx *session.Context, callHeaders map[string]string) map[string]string {
+	forwarded := h.headerFilter.FilterHeaders(sessionCtx.Headers)
+	for name, value := range callHeaders {
+		for existing := range forwarded {
+			if strings.EqualFold(existing, name) {
+				delete(forwarded, existing)
+			}
+		}
+		forwarded[name] = value
+	}
+	forwarded = h.withIdentity(h.profileHeaders(forwarded, sessionCtx), sessionCtx)
+
+	// Invalid headers would fail the call in the gRPC client, so they are dropped
+	metadata, problems := h.headerFilter.Sanitize(forwarded)
+	for _, problem := range problems {
+		h.logger.Warn("Forwarded header changed to keep metadata valid",
+			zap.String("header", problem.Header),
+			zap.String("reason", problem.Reason),
+			zap.Bool("dropped", problem.Dropped))
+	}
+	return metadata
+}
+
+// advertiseCallHeaders adds the headers argument to the input schema of every tool
+func (h *Handler) advertiseCallHeaders(tools []mcp.Tool) []mcp.Tool {
+	if !h.callHeadersEnabled() {
+		return tools
+	}
+	properties := make(map[string]interface{})
+	for _, name := range h.headerFilter.GetCallHeaders() {
+		if !h.headerFilter.AllowsCallHeader(name) {
+			continue
+		}
+		property := map[string]interface{}{"type": "string"}
+		if h.headerFilter.IsBinary(name) {
+			property["contentEncoding"] = "base64"
+		}
+		properties[strings.ToLower(name)] = property
+	}
+	return addArgumentProperty(tools, headersArgument, map[string]interface{}{
+		"type":                 "object",
+		"description":          "gRPC metadata sent with this call only, e.g. a trace or tenant ID",
+		"properties":           properties,
+		"additionalProperties": false,
+	})
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_CallHeaders(t *testing.T) {
+	cfg := config.Default()
+	cfg.GRPC.HeaderForwarding.CallHeaders = []string{"x-tenant-id", "X-Trace-Id", "cookie"}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.Headers["X-Trace-Id"] = "session-trace"
+	sessionCtx.Headers["x-user-id"] = "u-1"
+
+	t.Run("Merged_for_one_call", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything,
+			map[string]string{"x-trace-id": "call-trace", "x-tenant-id": "acme", "x-user-id": "u-1"},
+			"shop_orders_list", `{"limit":2}`).Return(`{}`, nil).Once()
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything,
+			map[string]string{"X-Trace-Id": "session-trace", "x-user-id": "u-1"},
+			"shop_orders_list", `{"limit":2}`).Return(`{}`, nil).Once()
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "shop_orders_list",
+			"arguments": map[string]interface{}{
+				"limit":    2,
+				"_headers": map[string]interface{}{"X-Tenant-Id": "acme", "x-trace-id": "call-trace"},
+			},
+		}, sessionCtx)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+
+		// The next call of the session forwards its own headers only
+		result, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "shop_orders_list",
+			"arguments": map[string]interface{}{"limit": 2},
+		}, sessionCtx)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Invalid_headers_are_rejected_before_invocation", func(t *testing.T) {
+		for _, headers := range []interface{}{
+			map[string]interface{}{"authorization": "Bearer other"},
+			map[string]interface{}{"cookie": "session=1"},
+			map[string]interface{}{"x-tenant-id": 7},
+			"x-tenant-id: acme",
+		} {
+			_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+				"name":      "shop_orders_get",
+				"arguments": map[string]interface{}{"_headers": headers},
+			}, sessionCtx)
+			var rpcErr *mcp.RPCError
+			require.ErrorAs(t, err, &rpcErr, headers)
+			assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		}
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", mock.Anything)
+	})
+
+	t.Run("Advertised_in_schema", func(t *testing.T) {
+		order := orderDescriptor(t)
+		method := types.MethodInfo{
+			Name:             "Place",
+			FullName:         "shop.OrderService.Place",
+			ServiceName:      "shop.OrderService",
+			InputDescriptor:  order,
+			OutputDescriptor: order,
+		}
+		method.ToolName = method.GenerateToolName()
+		mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{method})
+
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		properties := result.Tools[0].InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+		headers := properties["_headers"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"x-tenant-id": map[string]interface{}{"type": "string"},
+			"x-trace-id":  map[string]interface{}{"type": "string"},
+		}, headers["properties"])
+		assert.Equal(t, false, headers["additionalProperties"])
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, sessionCtx := newTestHandler(t, config.Default())
+		assert.False(t, handler.callHeadersEnabled())
+
+		// The argument is left for the backend, which rejects unknown fields
+		params := map[string]interface{}{
+			"name":      "shop_orders_list",
+			"arguments": map[string]interface{}{"_headers": map[string]interface{}{"x-tenant-id": "acme"}},
+		}
+		stripped, callHeaders, err := handler.extractCallHeaders(params)
+		require.NoError(t, err)
+		assert.Nil(t, callHeaders)
+		assert.Equal(t, params, stripped)
+		assert.Empty(t, handler.forwardedHeaders(sessionCtx, nil))
+	})
+}
+
+func TestHandler_ForwardedHeadersSanitized(t *testing.T) {
+	cfg := config.Default()
+	cfg.GRPC.HeaderForwarding.CallHeaders = []string{"x-tenant-id", "x-note"}
+	cfg.GRPC.HeaderForwarding.MaxMetadataBytes = 100
+	handler, _, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.Headers["X-Trace-Id"] = "trace-1"
+	sessionCtx.Headers["X-User-Id"] = strings.Repeat("u", 80)
+
+	forwarded := handler.forwardedHeaders(sessionCtx, map[string]string{
+		"x-tenant-id": "acme",
+		"x-note":      "café",
+	})
+	assert.Equal(t, map[string]string{"X-Trace-Id": "trace-1", "x-tenant-id": "acme"}, forwarded)
+}
+
+func TestHandler_BinaryHeaders(t *testing.T) {
+	cfg := config.Default()
+	cfg.GRPC.HeaderForwarding.AllowedHeaders = append(cfg.GRPC.HeaderForwarding.AllowedHeaders, "x-trace-context")
+	cfg.GRPC.HeaderForwarding.CallHeaders = []string{"x-trace-context", "x-tenant-id"}
+	cfg.GRPC.HeaderForwarding.BinaryHeaders = []string{"x-trace-context"}
+	handler, _, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.Headers["X-Trace-Context"] = "AAECAw"
+
+	assert.Equal(t, map[string]string{"X-Trace-Context-bin": "AAECAw=="}, handler.forwardedHeaders(sessionCtx, nil))
+	assert.Equal(t, map[string]string{"x-trace-context-bin": "BAU="},
+		handler.forwardedHeaders(sessionCtx, map[string]string{"x-trace-context": "BAU"}))
+
+	tools := handler.advertiseCallHeaders([]mcp.Tool{{Name: "shop_orders_list", InputSchema: map[string]interface{}{"type": "object"}}})
+	properties := tools[0].InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+	headers := properties["_headers"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "contentEncoding": "base64"}, headers["x-trace-context"])
+	assert.Equal(t, map[string]interface{}{"type": "string"}, headers["x-tenant-id"])
+}
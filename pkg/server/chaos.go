@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chaosInjector injects latency, gRPC errors and truncated responses into
+// tool calls so clients can test their retry and fallback behavior
+type chaosInjector struct {
+	logger *zap.Logger
+	config config.ChaosConfig
+	codes  []codes.Code
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	// Fault counters
+	calls     atomic.Int64
+	delayed   atomic.Int64
+	failed    atomic.Int64
+	truncated atomic.Int64
+}
+
+// newChaosInjector creates the fault injector, or nil if disabled
+func newChaosInjector(chaosConfig config.ChaosConfig, logger *zap.Logger) *chaosInjector {
+	if !chaosConfig.Enabled {
+		return nil
+	}
+
+	seed := chaosConfig.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	c := &chaosInjector{
+		logger: logger.Named("chaos"),
+		config: chaosConfig,
+		rand:   rand.New(rand.NewSource(seed)),
+	}
+	// Codes are validated with the configuration
+	for _, name := range chaosConfig.ErrorCodes {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(name))); err == nil {
+			c.codes = append(c.codes, code)
+		}
+	}
+	return c
+}
+
+// roll reports whether a fault with the given percentage happens
+func (c *chaosInjector) roll(percent float64) bool {
+	if percent <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64()*100 < percent
+}
+
+// intn returns a random number in [0, n)
+func (c *chaosInjector) intn(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Int63n(n)
+}
+
+// invoke calls the backend through the configured faults
+func (c *chaosInjector) invoke(ctx context.Context, toolName string, call func(ctx context.Context) (string, error)) (string, error) {
+	if c == nil || (len(c.config.Tools) > 0 && !slices.Contains(c.config.Tools, toolName)) {
+		return call(ctx)
+	}
+	c.calls.Add(1)
+
+	if c.roll(c.config.LatencyPercent) {
+		delay := c.config.Latency
+		if c.config.LatencyJitter > 0 {
+			delay += time.Duration(c.intn(int64(c.config.LatencyJitter)))
+		}
+		c.delayed.Add(1)
+		c.logger.Info("Injecting latency", zap.String("toolName", toolName), zap.Duration("delay", delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if len(c.codes) > 0 && c.roll(c.config.ErrorPercent) {
+		code := c.codes[c.intn(int64(len(c.codes)))]
+		c.failed.Add(1)
+		c.logger.Info("Injecting error", zap.String("toolName", toolName), zap.Stringer("code", code))
+		return "", status.Error(code, "chaos: injected fault")
+	}
+
+	result, err := call(ctx)
+	if err != nil || len(result) == 0 || !c.roll(c.config.TruncatePercent) {
+		return result, err
+	}
+
+	cut := c.intn(int64(len(result)))
+	c.truncated.Add(1)
+	c.logger.Info("Injecting truncated response", zap.String("toolName", toolName),
+		zap.Int("bytes", len(result)), zap.Int64("keptBytes", cut))
+	return result[:cut], nil
+}
+
+// stats returns the fault counters for the metrics endpoint
+func (c *chaosInjector) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"calls":     c.calls.Load(),
+		"delayed":   c.delayed.Load(),
+		"failed":    c.failed.Load(),
+		"truncated": c.truncated.Load(),
+	}
+}
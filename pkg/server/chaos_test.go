@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChaosInjector(t *testing.T) {
+	newInjector := func(modify func(*config.ChaosConfig)) *chaosInjector {
+		chaosConfig := config.Default().Tools.Chaos
+		chaosConfig.Enabled = true
+		chaosConfig.Seed = 1
+		modify(&chaosConfig)
+		return newChaosInjector(chaosConfig, zap.NewNop())
+	}
+	backend := func(calls *int) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			*calls++
+			return `{"items":[1,2,3]}`, nil
+		}
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert.Nil(t, newChaosInjector(config.Default().Tools.Chaos, zap.NewNop()))
+
+		// A nil injector calls the backend directly
+		var injector *chaosInjector
+		calls := 0
+		result, err := injector.invoke(context.Background(), "svc_method", backend(&calls))
+		require.NoError(t, err)
+		assert.Equal(t, `{"items":[1,2,3]}`, result)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Errors_skip_the_backend", func(t *testing.T) {
+		injector := newInjector(func(c *config.ChaosConfig) {
+			c.ErrorPercent = 100
+			c.ErrorCodes = []string{"UNAVAILABLE"}
+		})
+		calls := 0
+		_, err := injector.invoke(context.Background(), "svc_method", backend(&calls))
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, int64(1), injector.stats()["failed"])
+	})
+
+	t.Run("Truncation", func(t *testing.T) {
+		injector := newInjector(func(c *config.ChaosConfig) { c.TruncatePercent = 100 })
+		calls := 0
+		result, err := injector.invoke(context.Background(), "svc_method", backend(&calls))
+		require.NoError(t, err)
+		assert.Less(t, len(result), len(`{"items":[1,2,3]}`))
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, int64(1), injector.stats()["truncated"])
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		injector := newInjector(func(c *config.ChaosConfig) {
+			c.LatencyPercent = 100
+			c.Latency = 20 * time.Millisecond
+			c.LatencyJitter = 10 * time.Millisecond
+		})
+		calls := 0
+		start := time.Now()
+		_, err := injector.invoke(context.Background(), "svc_method", backend(&calls))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, int64(1), injector.stats()["delayed"])
+	})
+
+	t.Run("Latency_respects_the_deadline", func(t *testing.T) {
+		injector := newInjector(func(c *config.ChaosConfig) {
+			c.LatencyPercent = 100
+			c.Latency = time.Minute
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		calls := 0
+		_, err := injector.invoke(ctx, "svc_method", backend(&calls))
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Only_listed_tools", func(t *testing.T) {
+		injector := newInjector(func(c *config.ChaosConfig) {
+			c.Tools = []string{"svc_flaky"}
+			c.ErrorPercent = 100
+		})
+		calls := 0
+		_, err := injector.invoke(context.Background(), "svc_method", backend(&calls))
+		require.NoError(t, err)
+		_, err = injector.invoke(context.Background(), "svc_flaky", backend(&calls))
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, int64(1), injector.stats()["calls"])
+	})
+
+	t.Run("Percentage", func(t *testing.T) {
+		injector := newInjector(func(c *config.ChaosConfig) { c.ErrorPercent = 25 })
+		calls := 0
+		for i := 0; i < 1000; i++ {
+			_, _ = injector.invoke(context.Background(), "svc_method", backend(&calls))
+		}
+		assert.InDelta(t, 250, injector.stats()["failed"], 60)
+		assert.Equal(t, 1000, calls+int(injector.stats()["failed"].(int64)))
+	})
+}
+
+func TestHandler_ChaosErrors(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.Chaos.Enabled = true
+	cfg.Tools.Chaos.ErrorPercent = 100
+	cfg.Tools.Chaos.ErrorCodes = []string{"RESOURCE_EXHAUSTED"}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+
+	result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+		"name": "test_service_testmethod",
+	}, sessionCtx)
+	require.NoError(t, err)
+
+	// Injected faults look like backend errors
+	assert.True(t, result.IsError)
+	assert.Equal(t, "ResourceExhausted", result.Meta[mcp.MetaKeyUpstreamStatus])
+	mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	mockDiscoverer.On("GetServiceStats").Return(map[string]interface{}{})
+	rec := httptest.NewRecorder()
+	handler.MetricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var stats struct {
+		Chaos map[string]int64 `json:"chaos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.Chaos["failed"])
+}
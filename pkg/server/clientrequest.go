@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// errNoClientStream means the request is not answered over an event stream,
+// so the gateway has no way to send requests to the client
+var errNoClientStream = errors.New("client requests need an event-stream response")
+
+// clientRequesterKey is the context key for the request's clientRequester
+type clientRequesterKey struct{}
+
+// clientRequester sends JSON-RPC requests to the client over the event stream
+// of the request being handled; the client posts its answers back separately
+type clientRequester struct {
+	handler *Handler
+	stream  *eventStream
+}
+
+// clientReply is a client's answer to a server request
+type clientReply struct {
+	Result json.RawMessage `json:"result"`
+	Error  *mcp.RPCError   `json:"error"`
+}
+
+// outgoingRequest is a server-to-client JSON-RPC request with typed params
+type outgoingRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  interface{}   `json:"params,omitempty"`
+	ID      mcp.RequestID `json:"id"`
+}
+
+// withClientRequester returns a context through which handlers can reach the client
+func withClientRequester(ctx context.Context, requester *clientRequester) context.Context {
+	return context.WithValue(ctx, clientRequesterKey{}, requester)
+}
+
+// canRequestClient reports whether the request being handled can reach the client
+func canRequestClient(ctx context.Context) bool {
+	_, ok := ctx.Value(clientRequesterKey{}).(*clientRequester)
+	return ok
+}
+
+// requestClient sends a request to the client and waits up to timeout for its result
+func requestClient(ctx context.Context, method string, params interface{}, timeout time.Duration) (json.RawMessage, error) {
+	requester, ok := ctx.Value(clientRequesterKey{}).(*clientRequester)
+	if !ok {
+		return nil, errNoClientStream
+	}
+	return requester.request(ctx, method, params, timeout)
+}
+
+// request sends one request on the stream and waits for the matching reply
+func (c *clientRequester) request(ctx context.Context, method string, params interface{}, timeout time.Duration) (json.RawMessage, error) {
+	id, err := newClientRequestID()
+	if err != nil {
+		return nil, err
+	}
+
+	answer := make(chan clientReply, 1)
+	c.handler.pendingRequests.Store(id, answer)
+	defer c.handler.pendingRequests.Delete(id)
+
+	data, err := json.Marshal(&outgoingRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      mcp.RequestID{Value: id},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	if _, err := c.stream.send(data); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-answer:
+		if reply.Error != nil {
+			return nil, fmt.Errorf("client rejected %s: %s", method, reply.Error.Message)
+		}
+		return reply.Result, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("client did not answer %s within %s", method, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliverClientResponse routes a client's JSON-RPC response to the request waiting for it
+func (h *Handler) deliverClientResponse(body []byte) {
+	var response struct {
+		ID mcp.RequestID `json:"id"`
+		clientReply
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return
+	}
+
+	id, ok := response.ID.Value.(string)
+	if !ok {
+		return
+	}
+	pending, ok := h.pendingRequests.Load(id)
+	if !ok {
+		return
+	}
+
+	select {
+	case pending.(chan clientReply) <- response.clientReply:
+	default:
+	}
+}
+
+// newClientRequestID returns a unique ID for a server-to-client request
+func newClientRequestID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "srv-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxCompletionValues is the most candidates returned in one response, per the MCP spec
+const maxCompletionValues = 100
+
+// completionRefTool references a tool's arguments in completion/complete
+const completionRefTool = "ref/tool"
+
+// handleCompletionComplete handles the completion/complete method for tool arguments
+func (h *Handler) handleCompletionComplete(ctx context.Context, params map[string]interface{}) (*mcp.CompleteResult, error) {
+	ref, _ := params["ref"].(map[string]interface{})
+	refType, _ := ref["type"].(string)
+	toolName, _ := ref["name"].(string)
+	argument, _ := params["argument"].(map[string]interface{})
+	argumentName, _ := argument["name"].(string)
+	prefix, _ := argument["value"].(string)
+
+	// Prompts and resources are not offered, so only tool references complete
+	if refType != completionRefTool {
+		return &mcp.CompleteResult{Completion: mcp.Completion{Values: []string{}}}, nil
+	}
+	if toolName == "" || argumentName == "" {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid params: ref.name and argument.name are required")
+	}
+
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok || method.InputDescriptor == nil {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid params: unknown tool %s", toolName))
+	}
+
+	fd := fieldByPath(h.flattenedInput(method), argumentName)
+	if fd == nil {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid params: unknown argument %s", argumentName))
+	}
+
+	var matches []string
+	for _, candidate := range h.completionCandidates(fd) {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	completion := mcp.Completion{Values: matches, Total: len(matches)}
+	if completion.Values == nil {
+		completion.Values = []string{}
+	}
+	if len(matches) > maxCompletionValues {
+		completion.Values = matches[:maxCompletionValues]
+		completion.HasMore = true
+	}
+	return &mcp.CompleteResult{Completion: completion}, nil
+}
+
+// completionCandidates returns the known values for a field: configured values
+// first, then enum value names or boolean literals
+func (h *Handler) completionCandidates(fd protoreflect.FieldDescriptor) []string {
+	candidates := append([]string(nil), h.completionConfig.Values[string(fd.FullName())]...)
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			candidates = append(candidates, string(values.Get(i).Name()))
+		}
+	case protoreflect.BoolKind:
+		candidates = append(candidates, "true", "false")
+	}
+
+	return candidates
+}
+
+// fieldByPath resolves a dot-separated argument path (JSON or proto names) to a field
+func fieldByPath(desc protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	var fd protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if desc == nil {
+			return nil
+		}
+
+		fields := desc.Fields()
+		if fd = fields.ByJSONName(name); fd == nil {
+			if fd = fields.ByTextName(name); fd == nil {
+				return nil
+			}
+		}
+
+		// Continue into message values, including map values
+		desc = nil
+		if fd.IsMap() {
+			if value := fd.MapValue(); value.Kind() == protoreflect.MessageKind {
+				desc = value.Message()
+			}
+		} else if fd.Message() != nil {
+			desc = fd.Message()
+		}
+	}
+	return fd
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// orderDescriptor builds a message with enum, bool, string and nested fields
+func orderDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shop.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_OPEN"), Number: proto.Int32(1)},
+				{Name: proto.String("STATUS_SHIPPED"), Number: proto.Int32(2)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Price"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("currency", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("status", 1, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".shop.Status"),
+					field("gift", 2, descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""),
+					field("price", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Price"),
+					field("note", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Order")
+}
+
+func TestHandler_CompletionComplete(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Completion.Enabled = true
+	cfg.MCP.Completion.Values = map[string][]string{
+		"shop.Price.currency": {"USD", "EUR", "GBP"},
+	}
+
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", "shop_orderservice_create").
+		Return(types.MethodInfo{InputDescriptor: orderDescriptor(t)}, true)
+	mockDiscoverer.On("GetMethodByTool", "missing").Return(types.MethodInfo{}, false)
+
+	complete := func(tool, argument, value string) (*mcp.CompleteResult, error) {
+		return handler.handleCompletionComplete(context.Background(), map[string]interface{}{
+			"ref":      map[string]interface{}{"type": "ref/tool", "name": tool},
+			"argument": map[string]interface{}{"name": argument, "value": value},
+		})
+	}
+
+	tests := []struct {
+		argument string
+		value    string
+		expected []string
+	}{
+		{"status", "status_s", []string{"STATUS_SHIPPED"}},
+		{"status", "", []string{"STATUS_UNSPECIFIED", "STATUS_OPEN", "STATUS_SHIPPED"}},
+		{"gift", "t", []string{"true"}},
+		{"price.currency", "e", []string{"EUR"}},
+		{"note", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.argument+"/"+tt.value, func(t *testing.T) {
+			result, err := complete("shop_orderservice_create", tt.argument, tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Completion.Values)
+			assert.Equal(t, len(tt.expected), result.Completion.Total)
+			assert.False(t, result.Completion.HasMore)
+		})
+	}
+
+	t.Run("UnknownArgument", func(t *testing.T) {
+		_, err := complete("shop_orderservice_create", "price.amount", "")
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+	})
+
+	t.Run("UnknownTool", func(t *testing.T) {
+		_, err := complete("missing", "status", "")
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+	})
+
+	t.Run("PromptReference", func(t *testing.T) {
+		result, err := handler.handleCompletionComplete(context.Background(), map[string]interface{}{
+			"ref":      map[string]interface{}{"type": "ref/prompt", "name": "greeting"},
+			"argument": map[string]interface{}{"name": "name", "value": ""},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, result.Completion.Values)
+	})
+
+	t.Run("CapsValues", func(t *testing.T) {
+		values := make([]string, maxCompletionValues+5)
+		for i := range values {
+			values[i] = fmt.Sprintf("note-%d", i)
+		}
+		handler.completionConfig.Values["shop.Order.note"] = values
+
+		result, err := complete("shop_orderservice_create", "note", "note")
+		require.NoError(t, err)
+		assert.Len(t, result.Completion.Values, maxCompletionValues)
+		assert.Equal(t, maxCompletionValues+5, result.Completion.Total)
+		assert.True(t, result.Completion.HasMore)
+	})
+
+	assert.NotNil(t, handler.handleInitialize().Capabilities.Completions)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aalobaidi/ggRMCP/pkg/composite"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// AddCompositeTool exposes a tool chaining several RPCs; tools are added
+// while the handler is set up, before it serves requests
+func (h *Handler) AddCompositeTool(tool *composite.Tool) {
+	h.addBuiltinTool(builtinTool{
+		tool: tool.Definition(),
+		call: func(ctx context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+			return h.callCompositeTool(ctx, tool, arguments, sessionCtx)
+		},
+	})
+}
+
+// authorizeChainedCall runs the checks of a direct call of the tool for a call
+// the gateway makes on the caller's behalf, such as a composite step or a
+// sampled tool's follow-up: the tool must be enabled and in the session's
+// profile, the caller's roles, argument constraints and policy must allow it,
+// and approval-gated tools wait for a decision
+func (h *Handler) authorizeChainedCall(ctx context.Context, toolName, argumentsJSON string, sessionCtx *session.Context) error {
+	if err := h.checkToolEnabled(toolName); err != nil {
+		return err
+	}
+	if err := h.checkProfile(toolName, sessionCtx); err != nil {
+		return err
+	}
+	if err := h.checkRoles(ctx, toolName); err != nil {
+		return err
+	}
+	var arguments map[string]interface{}
+	_ = json.Unmarshal([]byte(argumentsJSON), &arguments)
+	params := map[string]interface{}{"arguments": arguments}
+	if err := h.checkArgumentConstraints(ctx, toolName, params); err != nil {
+		return err
+	}
+	if err := h.authorizeToolCall(ctx, toolName, params, sessionCtx); err != nil {
+		return err
+	}
+	return h.awaitApproval(ctx, toolName, params, sessionCtx)
+}
+
+// callCompositeTool runs the steps of a composite tool and reports each of them
+func (h *Handler) callCompositeTool(ctx context.Context, tool *composite.Tool, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	filteredHeaders := h.forwardedHeaders(sessionCtx, nil)
+	invoke := func(ctx context.Context, toolName, argumentsJSON string) (string, error) {
+		// Each step needs the same permission as calling its tool directly
+		if err := h.authorizeChainedCall(ctx, toolName, argumentsJSON, sessionCtx); err != nil {
+			return "", err
+		}
+
+		invokeCtx, cancel := context.WithTimeout(ctx, toolCallTimeout)
+		defer cancel()
+		result, err := h.serviceDiscoverer.InvokeMethodByTool(invokeCtx, filteredHeaders, toolName, argumentsJSON)
+		if err != nil {
+			return "", errors.New(mcp.SanitizeError(err))
+		}
+		return result, nil
+	}
+
+	name := tool.Definition().Name
+	result := tool.Run(ctx, arguments, invoke)
+	if result.Status != composite.StatusCompleted {
+		h.logger.Warn("Composite tool did not complete",
+			zap.String("toolName", name),
+			zap.String("status", result.Status),
+			zap.String("error", result.Error))
+	}
+
+	toolResult, err := jsonToolResult(result)
+	if err != nil {
+		return nil, err
+	}
+	toolResult.IsError = result.Status != composite.StatusCompleted
+	return toolResult, nil
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/composite"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandler_CompositeTool(t *testing.T) {
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	tool, err := composite.New(config.CompositeToolConfig{
+		Name:        "create_user_and_send_invite",
+		Description: "Creates a user and emails an invitation",
+		Steps: []config.CompositeStepConfig{
+			{Name: "user", Tool: "users_create", Arguments: map[string]interface{}{"email": "{{ .input.email }}"}},
+			{Name: "invite", Tool: "mail_send", Arguments: map[string]interface{}{"user_id": "{{ .steps.user.id }}"}},
+		},
+	})
+	require.NoError(t, err)
+	handler.AddCompositeTool(tool)
+
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{})
+	list, err := handler.handleToolsList(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list.Tools, 1)
+	assert.Equal(t, "create_user_and_send_invite", list.Tools[0].Name)
+
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "users_create", `{"email":"ada@example.com"}`).
+		Return(`{"id":"u-1"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "mail_send", `{"user_id":"u-1"}`).
+		Return("", status.Error(codes.Unavailable, "mail server down")).Once()
+
+	call := func() composite.Result {
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "create_user_and_send_invite",
+			"arguments": map[string]interface{}{"email": "ada@example.com"},
+		}, sessionCtx)
+		require.NoError(t, err)
+
+		var report composite.Result
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &report))
+		assert.Equal(t, report.Status != composite.StatusCompleted, result.IsError)
+		return report
+	}
+
+	// The second step fails after the first one committed
+	report := call()
+	assert.Equal(t, composite.StatusPartial, report.Status)
+	assert.Equal(t, composite.StepSucceeded, report.Steps[0].Status)
+	assert.Equal(t, composite.StepFailed, report.Steps[1].Status)
+	assert.Contains(t, report.Steps[1].Error, "mail server down")
+
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "mail_send", `{"user_id":"u-1"}`).
+		Return(`{"messageId":"m-1"}`, nil)
+	report = call()
+	assert.Equal(t, composite.StatusCompleted, report.Status)
+	assert.Equal(t, map[string]interface{}{"messageId": "m-1"}, report.Steps[1].Result)
+}
+
+func TestHandler_CompositeToolApproval(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Approval.Tools = []string{"mail_send"}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	tool, err := composite.New(config.CompositeToolConfig{
+		Name: "send_invite",
+		Steps: []config.CompositeStepConfig{
+			{Name: "invite", Tool: "mail_send", Arguments: map[string]interface{}{"user_id": "{{ .input.user_id }}"}},
+		},
+	})
+	require.NoError(t, err)
+	handler.AddCompositeTool(tool)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "mail_send", `{"user_id":"u-1"}`).
+		Return(`{"messageId":"m-1"}`, nil)
+
+	call := func() chan composite.Result {
+		done := make(chan composite.Result, 1)
+		go func() {
+			result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+				"name":      "send_invite",
+				"arguments": map[string]interface{}{"user_id": "u-1"},
+			}, sessionCtx)
+			var report composite.Result
+			if assert.NoError(t, err) {
+				assert.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &report))
+			}
+			done <- report
+		}()
+		return done
+	}
+	pendingID := func() string {
+		var id string
+		require.Eventually(t, func() bool {
+			if pending := handler.approvals.Pending(); len(pending) == 1 {
+				id = pending[0].ID
+			}
+			return id != ""
+		}, 5*time.Second, 5*time.Millisecond)
+		return id
+	}
+
+	// The gated step waits for a decision before it is invoked
+	done := call()
+	id := pendingID()
+	assert.Equal(t, "mail_send", handler.approvals.Pending()[0].Tool)
+	mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "mail_send", mock.Anything)
+	_, err = handler.approvals.Decide(id, true, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, composite.StatusCompleted, (<-done).Status)
+
+	// A rejected step fails the chain without calling the backend
+	done = call()
+	_, err = handler.approvals.Decide(pendingID(), false, "bob", "not now")
+	require.NoError(t, err)
+	report := <-done
+	assert.Equal(t, composite.StatusFailed, report.Status)
+	assert.Contains(t, report.Steps[0].Error, "not now")
+	mockDiscoverer.AssertNumberOfCalls(t, "InvokeMethodByTool", 1)
+}
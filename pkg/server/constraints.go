@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/auth"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// checkArgumentConstraints refuses calls whose constrained arguments do not
+// match the caller's token claims. Values are left out of the error, so a
+// caller cannot probe other tenants' identifiers.
+func (h *Handler) checkArgumentConstraints(ctx context.Context, toolName string, params map[string]interface{}) error {
+	if len(h.security.ArgumentConstraints) == 0 {
+		return nil
+	}
+	claims, _ := auth.ClaimsFromContext(ctx)
+	arguments, _ := params["arguments"].(map[string]interface{})
+
+	for _, constraint := range h.security.ArgumentConstraints {
+		if matched, _ := path.Match(constraint.Tool, toolName); !matched {
+			continue
+		}
+
+		values := h.argumentValues(toolName, arguments, constraint.Argument)
+		if len(values) == 0 {
+			if constraint.AllowMissing {
+				continue
+			}
+			return h.constraintViolation(toolName, claims,
+				fmt.Sprintf("argument %s is required", constraint.Argument))
+		}
+
+		// A field may be sent under both of its names; every spelling must match
+		allowed, _ := claims.Value(constraint.Claim)
+		for _, value := range values {
+			if !claimPermits(allowed, value) {
+				return h.constraintViolation(toolName, claims,
+					fmt.Sprintf("argument %s does not match the caller's %s", constraint.Argument, constraint.Claim))
+			}
+		}
+	}
+	return nil
+}
+
+// constraintViolation logs and returns a permission error for a constraint
+func (h *Handler) constraintViolation(toolName string, claims auth.Claims, reason string) error {
+	h.logger.Warn("Tool call denied by argument constraint",
+		zap.String("toolName", toolName),
+		zap.String("subject", claims.Subject()),
+		zap.String("reason", reason))
+	return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, "Permission denied: "+reason)
+}
+
+// argumentValues returns the values given for the argument at a dotted path.
+// For gRPC tools the path is resolved through the input descriptor, so each
+// field matches under its proto name and its JSON name, as the backend
+// accepts both.
+func (h *Handler) argumentValues(toolName string, arguments map[string]interface{}, argumentPath string) []interface{} {
+	var descriptor protoreflect.MessageDescriptor
+	if method, ok := h.serviceDiscoverer.GetMethodByTool(toolName); ok {
+		descriptor = method.InputDescriptor
+	}
+	return argumentsAt(arguments, descriptor, strings.Split(argumentPath, "."))
+}
+
+// argumentsAt collects the non-null values at the keys below value, trying
+// both names of each field known to the descriptor
+func argumentsAt(value interface{}, descriptor protoreflect.MessageDescriptor, keys []string) []interface{} {
+	if len(keys) == 0 {
+		if value == nil {
+			return nil
+		}
+		return []interface{}{value}
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	names := []string{keys[0]}
+	var next protoreflect.MessageDescriptor
+	if descriptor != nil {
+		field := descriptor.Fields().ByName(protoreflect.Name(keys[0]))
+		if field == nil {
+			field = descriptor.Fields().ByJSONName(keys[0])
+		}
+		if field != nil {
+			names = []string{string(field.Name())}
+			if jsonName := field.JSONName(); jsonName != names[0] {
+				names = append(names, jsonName)
+			}
+			if field.Message() != nil && !field.IsList() && !field.IsMap() {
+				next = field.Message()
+			}
+		}
+	}
+
+	var values []interface{}
+	for _, name := range names {
+		if child, present := object[name]; present {
+			values = append(values, argumentsAt(child, next, keys[1:])...)
+		}
+	}
+	return values
+}
+
+// claimPermits reports whether an argument equals a scalar claim, or one of
+// the values of an array claim
+func claimPermits(claim, argument interface{}) bool {
+	want, ok := scalarString(argument)
+	if !ok {
+		return false
+	}
+	if values, isArray := claim.([]interface{}); isArray {
+		for _, value := range values {
+			if allowed, ok := scalarString(value); ok && allowed == want {
+				return true
+			}
+		}
+		return false
+	}
+	allowed, ok := scalarString(claim)
+	return ok && allowed == want
+}
+
+// scalarString returns the text of a string, number or boolean. Numbers keep
+// the digits they were written with, so large IDs never round to each other.
+func scalarString(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(value), true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case bool:
+		return strconv.FormatBool(value), true
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/auth"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestHandler_ArgumentConstraints(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.JWT.Enabled = true
+	cfg.Server.Security.JWT.Secret = testJWTSecret
+	cfg.Server.Security.ArgumentConstraints = []config.ArgumentConstraintConfig{
+		{Tool: "shop_*", Argument: "tenant_id", Claim: "tenant_id"},
+		{Tool: "shop_orders_*", Argument: "filter.region", Claim: "org.regions", AllowMissing: true},
+	}
+	require.NoError(t, cfg.Validate())
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(`{}`, nil)
+	mockDiscoverer.On("GetMethodByTool", "shop_tenants_get").Return(tenantMethod(t), true)
+	mockDiscoverer.On("GetMethodByTool", mock.Anything).Return(types.MethodInfo{}, false)
+
+	var claims auth.Claims
+	require.NoError(t, json.Unmarshal([]byte(`{"sub":"alice","tenant_id":"acme","org":{"regions":["eu","us"]}}`), &claims))
+	ctx := auth.WithClaims(context.Background(), claims)
+	call := func(ctx context.Context, toolName string, arguments map[string]interface{}) error {
+		_, err := handler.HandleToolsCall(ctx, map[string]interface{}{"name": toolName, "arguments": arguments}, sessionCtx)
+		return err
+	}
+	denied := func(t *testing.T, err error, message string) {
+		t.Helper()
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, errorCodeFor(err))
+		assert.Contains(t, err.Error(), message)
+	}
+
+	t.Run("Matching", func(t *testing.T) {
+		require.NoError(t, call(ctx, "shop_orders_list", map[string]interface{}{"tenant_id": "acme"}))
+		require.NoError(t, call(ctx, "shop_orders_list", map[string]interface{}{
+			"tenant_id": "acme",
+			"filter":    map[string]interface{}{"region": "us"},
+		}))
+	})
+
+	t.Run("Cross_tenant", func(t *testing.T) {
+		err := call(ctx, "shop_orders_list", map[string]interface{}{"tenant_id": "globex"})
+		denied(t, err, "argument tenant_id does not match the caller's tenant_id")
+		assert.NotContains(t, err.Error(), "globex")
+
+		err = call(ctx, "shop_orders_list", map[string]interface{}{
+			"tenant_id": "acme",
+			"filter":    map[string]interface{}{"region": "apac"},
+		})
+		denied(t, err, "argument filter.region does not match the caller's org.regions")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		denied(t, call(ctx, "shop_orders_list", nil), "argument tenant_id is required")
+
+		// Unauthenticated contexts carry no claims to match
+		denied(t, call(context.Background(), "shop_orders_list", map[string]interface{}{"tenant_id": "acme"}), "does not match")
+
+		// Other tools are not constrained
+		require.NoError(t, call(ctx, "billing_invoices_list", nil))
+	})
+
+	t.Run("Numbers", func(t *testing.T) {
+		var numeric auth.Claims
+		decoder := json.NewDecoder(strings.NewReader(`{"tenant_id": 9007199254740993}`))
+		decoder.UseNumber()
+		require.NoError(t, decoder.Decode(&numeric))
+		ctx := auth.WithClaims(context.Background(), numeric)
+
+		// JSON-RPC arguments keep their digits, so neighboring IDs never match
+		_, err := handler.handleRequest(ctx, &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"shop_orders_list","arguments":{"tenant_id":9007199254740993}}`),
+		}, sessionCtx)
+		require.NoError(t, err)
+		_, err = handler.handleRequest(ctx, &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"shop_orders_list","arguments":{"tenant_id":9007199254740992}}`),
+		}, sessionCtx)
+		denied(t, err, "does not match")
+	})
+
+	t.Run("JSON_names", func(t *testing.T) {
+		// The backend accepts tenantId as well as tenant_id
+		require.NoError(t, call(ctx, "shop_tenants_get", map[string]interface{}{"tenantId": "acme"}))
+		denied(t, call(ctx, "shop_tenants_get", map[string]interface{}{"tenantId": "globex"}), "does not match")
+		denied(t, call(ctx, "shop_tenants_get", map[string]interface{}{
+			"tenant_id": "acme",
+			"tenantId":  "globex",
+		}), "does not match")
+		denied(t, call(ctx, "shop_tenants_get", map[string]interface{}{"filter": map[string]interface{}{}}), "is required")
+	})
+
+	t.Run("Invalid_config", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Security.ArgumentConstraints = []config.ArgumentConstraintConfig{{Tool: "*", Argument: "tenant_id", Claim: "tenant_id"}}
+		assert.ErrorContains(t, cfg.Validate(), "require JWT")
+	})
+}
+
+// tenantMethod returns a method whose request has a tenant_id field, sent by
+// clients as tenantId
+func tenantMethod(t *testing.T) types.MethodInfo {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("tenants.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("GetTenantRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("tenant_id"),
+				JsonName: proto.String("tenantId"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	input := file.Messages().ByName("GetTenantRequest")
+	return types.MethodInfo{
+		Name:             "Get",
+		FullName:         "shop.Tenants.Get",
+		ServiceName:      "shop.Tenants",
+		ToolName:         "shop_tenants_get",
+		InputDescriptor:  input,
+		OutputDescriptor: input,
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// jsonRPCMediaTypes are the media types accepted for JSON-RPC request bodies
+var jsonRPCMediaTypes = []string{"application/json", "application/json-rpc"}
+
+// checkContentType validates the Content-Type of a request against the
+// allowed media types, accepting only a UTF-8 charset, and returns a
+// message for the client if it is unsupported
+func checkContentType(header string, allowedTypes []string) (string, bool) {
+	if header == "" {
+		return fmt.Sprintf("Content-Type header is required; use %s", allowedTypes[0]), false
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return fmt.Sprintf("Malformed Content-Type header %q; use %s", header, allowedTypes[0]), false
+	}
+	if !slices.Contains(allowedTypes, mediaType) {
+		return fmt.Sprintf("Unsupported content type %s; use %s", mediaType, strings.Join(allowedTypes, " or ")), false
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		return fmt.Sprintf("Unsupported charset %s; JSON-RPC requests must be UTF-8", charset), false
+	}
+	return "", true
+}
+
+// decodeJSONBody reads a request body that must hold a single JSON value,
+// describing why it is not one
+func decodeJSONBody(body io.Reader) (json.RawMessage, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("request body exceeds %d bytes", maxBytesErr.Limit)
+		}
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("request body is empty")
+	}
+	if trimmed[0] != '{' && trimmed[0] != '[' {
+		return nil, fmt.Errorf("request body is not JSON")
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(trimmed, &raw); err != nil {
+		return nil, fmt.Errorf("request body is not valid JSON: %w", err)
+	}
+	return raw, nil
+}
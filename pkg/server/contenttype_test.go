@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContentType(t *testing.T) {
+	tests := []struct {
+		header  string
+		allowed bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"Application/JSON; charset=UTF-8", true},
+		{"application/json-rpc", true},
+		{"application/json; charset=iso-8859-1", false},
+		{"text/plain", false},
+		{"text/plain; note=application/json", false},
+		{"application/jsonx", false},
+		{"application/json; charset", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			message, ok := checkContentType(tt.header, jsonRPCMediaTypes)
+			assert.Equal(t, tt.allowed, ok)
+			if !ok {
+				assert.NotEmpty(t, message)
+			}
+		})
+	}
+}
+
+func TestHandler_ContentNegotiation(t *testing.T) {
+	handler, _, _ := newTestHandler(t, config.Default())
+
+	post := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	t.Run("Charset_variant_is_accepted", func(t *testing.T) {
+		rec := post("application/json; charset=utf-8", ping)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("JSON_RPC_media_type_is_accepted", func(t *testing.T) {
+		rec := post("application/json-rpc", ping)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Unsupported_type_is_rejected", func(t *testing.T) {
+		rec := post("text/plain", ping)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Unsupported content type text/plain")
+	})
+
+	t.Run("Missing_type_is_rejected", func(t *testing.T) {
+		rec := post("", ping)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Content-Type header is required")
+	})
+
+	t.Run("Body_that_is_not_JSON", func(t *testing.T) {
+		for body, reason := range map[string]string{
+			"":                    "request body is empty",
+			"method=ping":         "request body is not JSON",
+			`{"jsonrpc":"2.0",`:   "request body is not valid JSON",
+			`{"jsonrpc":"2.0"} x`: "request body is not valid JSON",
+		} {
+			rec := post("application/json", body)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var response struct {
+				Error *mcp.RPCError `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			require.NotNil(t, response.Error)
+			assert.Equal(t, mcp.ErrorCodeParseError, response.Error.Code)
+			assert.Contains(t, response.Error.Message, reason, body)
+		}
+	})
+}
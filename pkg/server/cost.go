@@ -0,0 +1,50 @@
+package server
+
+import (
+	"maps"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/quota"
+)
+
+// advertiseCosts records the cost weight of each tool in its _meta block so
+// agents can plan within their budget
+func (h *Handler) advertiseCosts(tools []mcp.Tool) []mcp.Tool {
+	if h.quota == nil {
+		return tools
+	}
+
+	for i := range tools {
+		cost := h.quota.Cost(tools[i].Name)
+		if cost == 0 {
+			continue
+		}
+		// Copy the _meta block, which may be shared with cached tool lists
+		meta := maps.Clone(tools[i].Meta)
+		if meta == nil {
+			meta = make(map[string]interface{})
+		}
+		meta[mcp.MetaKeyCost] = cost
+		tools[i].Meta = meta
+	}
+	return tools
+}
+
+// quotaErrorData describes an exceeded quota in the data of the JSON-RPC
+// error, so clients can tell which limit they hit and when it resets
+func quotaErrorData(exceeded *quota.ExceededError) map[string]interface{} {
+	data := map[string]interface{}{
+		"period":   exceeded.Period,
+		"resource": exceeded.Resource,
+	}
+	if exceeded.Resource == "cost" {
+		data["limit"] = exceeded.Limit
+		data["spent"] = exceeded.Spent
+		data["cost"] = exceeded.Cost
+	}
+	if !exceeded.ResetsAt.IsZero() {
+		data["resetsAt"] = exceeded.ResetsAt.Format(time.RFC3339)
+	}
+	return data
+}
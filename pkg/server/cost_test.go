@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ToolsCallCost(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.Quota.Enabled = true
+	cfg.Server.Security.Quota.SessionCost = 10
+	cfg.Server.Security.Quota.Costs = []config.ToolCostConfig{{Tool: "reports_generate", Cost: 4}}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(`{"status":"done"}`, nil)
+
+	generate := map[string]interface{}{"name": "reports_generate"}
+	for i := 0; i < 2; i++ {
+		_, err := handler.HandleToolsCall(context.Background(), generate, sessionCtx)
+		require.NoError(t, err)
+	}
+
+	// Tools without a cost are not limited by the budget
+	_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "reports_list"}, sessionCtx)
+	require.NoError(t, err)
+
+	_, err = handler.HandleToolsCall(context.Background(), generate, sessionCtx)
+	require.Error(t, err)
+	rpcErr := rpcErrorFor(err)
+	assert.Equal(t, mcp.ErrorCodeQuotaExceeded, rpcErr.Code)
+	assert.Equal(t, map[string]interface{}{
+		"period":   "session",
+		"resource": "cost",
+		"limit":    10.0,
+		"spent":    8.0,
+		"cost":     4.0,
+	}, rpcErr.Data)
+	mockDiscoverer.AssertNumberOfCalls(t, "InvokeMethodByTool", 3)
+
+	// Other sessions have their own budget
+	other := handler.sessionManager.CreateSession(map[string]string{})
+	_, err = handler.HandleToolsCall(context.Background(), generate, other)
+	require.NoError(t, err)
+}
+
+func TestHandler_AdvertiseCosts(t *testing.T) {
+	tools := []mcp.Tool{
+		{Name: "reports_generate", Meta: map[string]interface{}{mcp.MetaKeyUpstream: "reports"}},
+		{Name: "reports_list"},
+	}
+
+	handler, _, _ := newTestHandler(t, config.Default())
+	assert.Nil(t, handler.advertiseCosts(tools)[1].Meta)
+
+	cfg := config.Default()
+	cfg.Server.Security.Quota.Enabled = true
+	cfg.Server.Security.Quota.Costs = []config.ToolCostConfig{{Tool: "reports_generate", Cost: 2.5}}
+	handler, _, _ = newTestHandler(t, cfg)
+	original := tools[0].Meta
+
+	tools = handler.advertiseCosts(tools)
+	assert.Equal(t, map[string]interface{}{mcp.MetaKeyUpstream: "reports", mcp.MetaKeyCost: 2.5}, tools[0].Meta)
+	assert.Nil(t, tools[1].Meta)
+	assert.NotContains(t, original, mcp.MetaKeyCost)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+// applyDeprecation hides deprecated tools or prefixes their descriptions with
+// a deprecation notice and the configured replacement hint
+func (h *Handler) applyDeprecation(methods []types.MethodInfo, tools []mcp.Tool) []mcp.Tool {
+	deprecated := make(map[string]bool)
+	for _, method := range methods {
+		if method.Deprecated {
+			deprecated[method.ToolName] = true
+		}
+	}
+	if len(deprecated) == 0 {
+		return tools
+	}
+
+	listed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if deprecated[tool.Name] {
+			if h.deprecation.Hide {
+				continue
+			}
+			tool.Description = h.deprecationNotice(tool.Name) + "\n\n" + tool.Description
+		}
+		listed = append(listed, tool)
+	}
+	return listed
+}
+
+// checkDeprecation refuses calls to deprecated tools when they are hidden,
+// so a hidden tool cannot be called by clients that still know its name
+func (h *Handler) checkDeprecation(toolName string) error {
+	if !h.deprecation.Hide {
+		return nil
+	}
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok || !method.Deprecated {
+		return nil
+	}
+
+	message := fmt.Sprintf("Tool %s is deprecated and no longer offered", toolName)
+	if replacement := h.deprecation.Replacements[toolName]; replacement != "" {
+		message += fmt.Sprintf(": use %s instead", replacement)
+	}
+	return mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, message)
+}
+
+// deprecationNotice returns the notice shown at the start of a deprecated tool's description
+func (h *Handler) deprecationNotice(toolName string) string {
+	if replacement := h.deprecation.Replacements[toolName]; replacement != "" {
+		return fmt.Sprintf("DEPRECATED: use %s instead.", replacement)
+	}
+	return "DEPRECATED: this tool may be removed in a future version."
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ToolsListDeprecation(t *testing.T) {
+	order := orderDescriptor(t)
+	method := func(name string, deprecated bool) types.MethodInfo {
+		info := types.MethodInfo{
+			Name:             name,
+			ServiceName:      "shop.OrderService",
+			Description:      name + " an order",
+			InputDescriptor:  order,
+			OutputDescriptor: order,
+			Deprecated:       deprecated,
+		}
+		info.ToolName = info.GenerateToolName()
+		return info
+	}
+	methods := []types.MethodInfo{method("Create", false), method("Place", true), method("Cancel", true)}
+
+	toolsByName := func(result *mcp.ToolsListResult) map[string]mcp.Tool {
+		tools := make(map[string]mcp.Tool)
+		for _, tool := range result.Tools {
+			tools[tool.Name] = tool
+		}
+		return tools
+	}
+
+	t.Run("Marked_with_replacement_hint", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Tools.Deprecation.Replacements = map[string]string{
+			"shop_orderservice_place": "shop_orderservice_create",
+		}
+		handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+		mockDiscoverer.On("GetMethods").Return(methods)
+
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+
+		tools := toolsByName(result)
+		require.Len(t, tools, 3)
+		assert.Equal(t, "Create an order", tools["shop_orderservice_create"].Description)
+		assert.Equal(t, "DEPRECATED: use shop_orderservice_create instead.\n\nPlace an order",
+			tools["shop_orderservice_place"].Description)
+		assert.Contains(t, tools["shop_orderservice_cancel"].Description, "DEPRECATED:")
+	})
+
+	t.Run("Hidden", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Tools.Deprecation.Hide = true
+		cfg.Tools.Deprecation.Replacements = map[string]string{
+			"shop_orderservice_place": "shop_orderservice_create",
+		}
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+		mockDiscoverer.On("GetMethods").Return(methods)
+
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+
+		tools := toolsByName(result)
+		assert.Len(t, tools, 1)
+		assert.Contains(t, tools, "shop_orderservice_create")
+
+		// Hidden tools cannot be called by name either
+		mockDiscoverer.On("GetMethodByTool", "shop_orderservice_place").Return(methods[1], true)
+		_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "shop_orderservice_place"}, sessionCtx)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeMethodNotFound, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "use shop_orderservice_create instead")
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+)
+
+// descriptionURIPrefix is the scheme and authority of full tool description resources
+const descriptionURIPrefix = "ggrmcp://descriptions/"
+
+// descriptionMimeType is the MIME type of full tool description resources
+const descriptionMimeType = "text/plain"
+
+// limitDescriptions shortens tool descriptions longer than the configured
+// maximum and links their full text as a resource
+func (h *Handler) limitDescriptions(tools []mcp.Tool) []mcp.Tool {
+	maxLength := h.descriptions.MaxLength
+	if maxLength <= 0 {
+		return tools
+	}
+
+	for i, tool := range tools {
+		if len(tool.Description) > maxLength {
+			tools[i].Description = truncateDescription(tool.Description, maxLength, descriptionURIPrefix+tool.Name)
+		}
+	}
+	return tools
+}
+
+// truncateDescription keeps the first paragraph of a description, cut at a
+// sentence or word boundary if it is still too long, followed by a link to
+// the full text, all within maxLength bytes
+func truncateDescription(description string, maxLength int, uri string) string {
+	link := fmt.Sprintf("\n\n(Full description: %s)", uri)
+	budget := maxLength - len(link)
+	if budget <= 0 {
+		return strings.TrimSpace(link)
+	}
+
+	summary := strings.TrimSpace(description)
+	if i := strings.Index(summary, "\n\n"); i >= 0 {
+		summary = strings.TrimSpace(summary[:i])
+	}
+	if len(summary) > budget {
+		summary = cutText(summary, budget)
+	}
+	return summary + link
+}
+
+// cutText shortens text to at most limit bytes, preferring to end after a
+// sentence, then at a word, and marking a cut mid-sentence with an ellipsis
+func cutText(text string, limit int) string {
+	const ellipsis = "…"
+
+	cut := limit - len(ellipsis)
+	if cut <= 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	text = text[:cut]
+
+	// Only use a boundary that keeps most of the text
+	if i := strings.LastIndex(text, ". "); i >= cut/2 {
+		return text[:i+1]
+	}
+	if i := strings.LastIndexAny(text, " \n"); i >= cut/2 {
+		text = text[:i]
+	}
+	return strings.TrimRight(text, " \n,;:") + ellipsis
+}
+
+// descriptionResources lists the full descriptions of tools whose
+// description is shortened in tools/list
+func (h *Handler) descriptionResources() []mcp.Resource {
+	maxLength := h.descriptions.MaxLength
+	if maxLength <= 0 {
+		return nil
+	}
+
+	var listed []mcp.Resource
+	for _, method := range h.serviceDiscoverer.GetMethods() {
+		if method.IsClientStreaming || method.IsServerStreaming {
+			continue
+		}
+		description := h.toolBuilder.Description(method)
+		if len(description) <= maxLength {
+			continue
+		}
+		listed = append(listed, mcp.Resource{
+			URI:         descriptionURIPrefix + toolNameOf(method),
+			Name:        toolNameOf(method) + " description",
+			Description: "Full description of the " + toolNameOf(method) + " tool",
+			MimeType:    descriptionMimeType,
+			Size:        int64(len(description)),
+		})
+	}
+	return listed
+}
+
+// readDescriptionResource returns the full description of the tool named by the URI
+func (h *Handler) readDescriptionResource(uri string) (*mcp.ResourcesReadResult, error) {
+	method, ok := h.serviceDiscoverer.GetMethodByTool(strings.TrimPrefix(uri, descriptionURIPrefix))
+	if !ok {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeResourceNotFound, "Resource not found")
+	}
+
+	return &mcp.ResourcesReadResult{
+		Contents: []mcp.ResourceContents{{
+			URI:      uri,
+			MimeType: descriptionMimeType,
+			Text:     h.toolBuilder.Description(method),
+		}},
+	}, nil
+}
+
+// toolNameOf returns the name of a method's tool
+func toolNameOf(method types.MethodInfo) string {
+	if method.ToolName != "" {
+		return method.ToolName
+	}
+	return method.GenerateToolName()
+}
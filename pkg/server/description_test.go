@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateDescription(t *testing.T) {
+	const uri = "ggrmcp://descriptions/shop_orderservice_place"
+	link := "\n\n(Full description: " + uri + ")"
+
+	t.Run("First_paragraph", func(t *testing.T) {
+		description := "Places an order.\n\nThe order is validated, priced and reserved. " + strings.Repeat("Details. ", 50)
+
+		assert.Equal(t, "Places an order."+link, truncateDescription(description, 200, uri))
+	})
+
+	t.Run("Cut_at_sentence", func(t *testing.T) {
+		description := "Places an order. Validates the items. " + strings.Repeat("Reserves stock in every warehouse ", 10)
+
+		assert.Equal(t, "Places an order. Validates the items."+link, truncateDescription(description, 130, uri))
+	})
+
+	t.Run("Cut_at_word", func(t *testing.T) {
+		description := strings.Repeat("reserve ", 40)
+
+		truncated := truncateDescription(description, 120, uri)
+		assert.LessOrEqual(t, len(truncated), 120)
+		assert.True(t, strings.HasSuffix(truncated, "reserve…"+link), truncated)
+	})
+
+	t.Run("Multibyte_text", func(t *testing.T) {
+		description := strings.Repeat("ü", 200)
+
+		truncated := truncateDescription(description, 150, uri)
+		assert.LessOrEqual(t, len(truncated), 150)
+		assert.True(t, strings.HasSuffix(truncated, "…"+link))
+		assert.True(t, strings.HasPrefix(truncated, "üü"))
+	})
+}
+
+func TestHandler_LongDescriptions(t *testing.T) {
+	order := orderDescriptor(t)
+	long := types.MethodInfo{
+		Name:             "Place",
+		ServiceName:      "shop.OrderService",
+		Description:      "Places an order.\n\n" + strings.Repeat("Internal details about pricing. ", 20),
+		InputDescriptor:  order,
+		OutputDescriptor: order,
+	}
+	long.ToolName = long.GenerateToolName()
+	short := types.MethodInfo{
+		Name:             "Cancel",
+		ServiceName:      "shop.OrderService",
+		Description:      "Cancels an order.",
+		InputDescriptor:  order,
+		OutputDescriptor: order,
+	}
+	short.ToolName = short.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.Descriptions.MaxLength = 120
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{long, short})
+	mockDiscoverer.On("GetMethodByTool", long.ToolName).Return(long, true)
+	mockDiscoverer.On("GetMethodByTool", "unknown").Return(types.MethodInfo{}, false)
+	mockDiscoverer.On("ServerInfo").Return(nil)
+
+	const uri = "ggrmcp://descriptions/shop_orderservice_place"
+
+	t.Run("Tools_list", func(t *testing.T) {
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 2)
+
+		for _, tool := range result.Tools {
+			switch tool.Name {
+			case long.ToolName:
+				assert.Equal(t, "Places an order.\n\n(Full description: "+uri+")", tool.Description)
+			default:
+				assert.Equal(t, "Cancels an order.", tool.Description)
+			}
+		}
+	})
+
+	t.Run("Resources_list", func(t *testing.T) {
+		result, err := handler.handleResourcesList(context.Background(), sessionCtx)
+		require.NoError(t, err)
+		require.Len(t, result.Resources, 1)
+		assert.Equal(t, uri, result.Resources[0].URI)
+		assert.Equal(t, int64(len(long.Description)), result.Resources[0].Size)
+	})
+
+	t.Run("Resources_read", func(t *testing.T) {
+		result, err := handler.handleResourcesRead(context.Background(), map[string]interface{}{"uri": uri}, sessionCtx)
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+		assert.Equal(t, long.Description, result.Contents[0].Text)
+
+		_, err = handler.handleResourcesRead(context.Background(),
+			map[string]interface{}{"uri": descriptionURIPrefix + "unknown"}, sessionCtx)
+		assert.Error(t, err)
+	})
+}
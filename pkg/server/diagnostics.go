@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DiagnosticsPath is the admin endpoint reporting gRPC connection diagnostics
+const DiagnosticsPath = "/admin/diagnostics"
+
+// diagnosticsTimeout bounds the channelz queries of one diagnostics request
+const diagnosticsTimeout = 10 * time.Second
+
+// DiagnosticsHandler reports the gateway's gRPC channels, subchannels and
+// sockets from channelz and, when configured, the backend's view of them
+func (h *Handler) DiagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.channelzConfig.AdminToken == "" {
+		http.Error(w, "Diagnostics are not enabled", http.StatusNotFound)
+		return
+	}
+
+	if !h.requireBearer(w, r, h.channelzConfig.AdminToken, "diagnostics") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), diagnosticsTimeout)
+	defer cancel()
+
+	report, err := h.serviceDiscoverer.Channelz(ctx, h.channelzConfig.QueryBackend)
+	if err != nil {
+		h.logger.Error("Failed to collect diagnostics", zap.Error(err))
+		http.Error(w, "Failed to collect diagnostics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.logger.Error("Failed to encode diagnostics", zap.Error(err))
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Diagnostics(t *testing.T) {
+	diagnostics := func(handler *Handler, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, DiagnosticsPath, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.DiagnosticsHandler(rec, req)
+		return rec
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		assert.Equal(t, http.StatusNotFound, diagnostics(handler, "admin-token").Code)
+	})
+
+	cfg := config.Default()
+	cfg.GRPC.Channelz.AdminToken = "admin-token"
+	cfg.GRPC.Channelz.QueryBackend = true
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, cfg)
+		assert.Equal(t, http.StatusUnauthorized, diagnostics(handler, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, diagnostics(handler, "wrong").Code)
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+		mockDiscoverer.On("Channelz", mock.Anything, true).Return(&grpc.ChannelzReport{
+			Gateway: &grpc.ChannelzSnapshot{
+				Channels: []grpc.ChannelzChannel{{ID: 1, Target: "localhost:50051", State: "READY"}},
+				Servers:  []grpc.ChannelzServer{},
+			},
+			BackendError: "backend does not expose channelz",
+		}, nil)
+
+		rec := diagnostics(handler, "admin-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var report grpc.ChannelzReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		require.Len(t, report.Gateway.Channels, 1)
+		assert.Equal(t, "READY", report.Gateway.Channels[0].State)
+		assert.Equal(t, "backend does not expose channelz", report.BackendError)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+		mockDiscoverer.On("Channelz", mock.Anything, true).Return(nil, errors.New("boom"))
+		assert.Equal(t, http.StatusInternalServerError, diagnostics(handler, "admin-token").Code)
+	})
+}
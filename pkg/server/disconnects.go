@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// disconnectStats counts the work clients abandoned by disconnecting
+type disconnectStats struct {
+	logger           *zap.Logger
+	abandonedCalls   atomic.Int64
+	droppedResponses atomic.Int64
+}
+
+// newDisconnectStats creates the disconnect counters
+func newDisconnectStats(logger *zap.Logger) *disconnectStats {
+	return &disconnectStats{logger: logger}
+}
+
+// clientDisconnected reports whether the client of a request went away. The
+// request context is cancelled when the connection closes, or when the
+// client stops answering the pings of an event stream.
+func clientDisconnected(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// callAbandoned logs and counts a tool call whose client disconnected before
+// it completed; the upstream call was cancelled with the request context
+func (d *disconnectStats) callAbandoned(ctx context.Context, params map[string]interface{}, sessionID string, elapsed time.Duration) {
+	d.abandonedCalls.Add(1)
+	toolName, _ := params["name"].(string)
+	d.logger.Warn("Tool call abandoned by disconnected client",
+		zap.String("toolName", toolName),
+		zap.String("requestId", requestIDFromContext(ctx)),
+		zap.String("sessionId", sessionID),
+		zap.Duration("elapsed", elapsed))
+}
+
+// responseDropped logs and counts a response not written because its
+// client disconnected
+func (d *disconnectStats) responseDropped(method, sessionID string) {
+	d.droppedResponses.Add(1)
+	d.logger.Info("Dropping response for disconnected client",
+		zap.String("method", method),
+		zap.String("sessionId", sessionID))
+}
+
+// stats returns the disconnect counters for the metrics endpoint
+func (d *disconnectStats) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"abandonedCalls":   d.abandonedCalls.Load(),
+		"droppedResponses": d.droppedResponses.Load(),
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ClientDisconnect(t *testing.T) {
+	handler, mockDiscoverer, _ := newTestHandler(t, config.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var upstreamCtx context.Context
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Run(func(args mock.Arguments) {
+			// The client goes away while the backend is working
+			upstreamCtx = args.Get(0).(context.Context)
+			cancel()
+		}).
+		Return("", context.Canceled)
+
+	req := httptest.NewRequest(http.MethodPost, "/",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_service_testmethod"}}`)).
+		WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.NotNil(t, upstreamCtx)
+	assert.ErrorIs(t, upstreamCtx.Err(), context.Canceled, "the upstream call is cancelled with the request")
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, map[string]interface{}{
+		"abandonedCalls":   int64(1),
+		"droppedResponses": int64(1),
+	}, handler.disconnects.stats())
+
+	t.Run("Timeouts_are_not_disconnects", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+		<-ctx.Done()
+		assert.False(t, clientDisconnected(ctx))
+		assert.False(t, clientDisconnected(context.Background()))
+	})
+}
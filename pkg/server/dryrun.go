@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// dryRunArgument is the argument that turns a tool call into a dry run
+const dryRunArgument = "_dryRun"
+
+// dryRunRequest describes the gRPC request a call would send
+type dryRunRequest struct {
+	Tool      string            `json:"tool"`
+	Method    string            `json:"method"`
+	InputType string            `json:"inputType"`
+	Request   json.RawMessage   `json:"request"`
+	Metadata  map[string]string `json:"metadata"`
+	Fallbacks []string          `json:"fallbacks,omitempty"`
+}
+
+// extractDryRun removes the dry run argument from the call parameters,
+// reporting whether a dry run was requested
+func (h *Handler) extractDryRun(params map[string]interface{}) (map[string]interface{}, bool, error) {
+	if !h.dryRun {
+		return params, false, nil
+	}
+	params, flag, ok := removeArgument(params, dryRunArgument)
+	if !ok {
+		return params, false, nil
+	}
+	dryRun, ok := flag.(bool)
+	if !ok {
+		return nil, false, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("Invalid arguments: %s must be a boolean", dryRunArgument))
+	}
+	return params, dryRun, nil
+}
+
+// removeArgument returns a copy of the call parameters without the named
+// argument, along with its value and whether it was given. The caller's maps
+// are left untouched.
+func removeArgument(params map[string]interface{}, name string) (map[string]interface{}, interface{}, bool) {
+	args, ok := params["arguments"].(map[string]interface{})
+	if !ok {
+		return params, nil, false
+	}
+	value, ok := args[name]
+	if !ok {
+		return params, nil, false
+	}
+
+	stripped := make(map[string]interface{}, len(args))
+	for key, v := range args {
+		if key != name {
+			stripped[key] = v
+		}
+	}
+	copied := make(map[string]interface{}, len(params))
+	for key, v := range params {
+		copied[key] = v
+	}
+	copied["arguments"] = stripped
+	return copied, value, true
+}
+
+// dryRunResult builds the request message from the final arguments and
+// returns it with the resolved method and forwarded metadata
+func (h *Handler) dryRunResult(toolName, argumentsJSON string, metadata map[string]string) (*mcp.ToolCallResult, error) {
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok {
+		return nil, fmt.Errorf("tool %s not found", toolName)
+	}
+	if method.IsClientStreaming || method.IsServerStreaming {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Streaming methods are not supported")
+	}
+
+	message := dynamicpb.NewMessage(method.InputDescriptor)
+	if argumentsJSON != "" && argumentsJSON != "{}" {
+		if err := protojson.Unmarshal([]byte(argumentsJSON), message); err != nil {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %v", err))
+		}
+	}
+	request, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	described := dryRunRequest{
+		Tool:      toolName,
+		Method:    "/" + method.ServiceName + "/" + method.Name,
+		InputType: string(method.InputDescriptor.FullName()),
+		Request:   request,
+		Metadata:  metadata,
+	}
+	for _, fallback := range method.Fallbacks {
+		described.Fallbacks = append(described.Fallbacks, "/"+fallback.ServiceName+"/"+fallback.Name)
+	}
+
+	text, err := json.MarshalIndent(described, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dry run: %w", err)
+	}
+
+	result := &mcp.ToolCallResult{
+		Content: []mcp.ContentBlock{mcp.TextContent(string(text))},
+	}
+	result.SetMeta(mcp.MetaKeyDryRun, true)
+	return result, nil
+}
+
+// advertiseDryRun adds the dry run argument to the input schema of every tool
+func (h *Handler) advertiseDryRun(tools []mcp.Tool) []mcp.Tool {
+	if !h.dryRun {
+		return tools
+	}
+	return addArgumentProperty(tools, dryRunArgument, map[string]interface{}{
+		"type":        "boolean",
+		"description": "Return the gRPC request this call would send without invoking the method",
+	})
+}
+
+// addArgumentProperty adds a property to the input schema of every tool
+func addArgumentProperty(tools []mcp.Tool, name string, property map[string]interface{}) []mcp.Tool {
+	for i, tool := range tools {
+		schema, ok := tool.InputSchema.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Schemas may be cached by the builder, so they are copied before the change
+		copied := make(map[string]interface{}, len(schema))
+		for key, value := range schema {
+			copied[key] = value
+		}
+		properties := make(map[string]interface{})
+		if existing, ok := schema["properties"].(map[string]interface{}); ok {
+			for key, value := range existing {
+				properties[key] = value
+			}
+		}
+		properties[name] = property
+		copied["properties"] = properties
+		tools[i].InputSchema = copied
+	}
+	return tools
+}
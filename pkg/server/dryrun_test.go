@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_DryRun(t *testing.T) {
+	order := orderDescriptor(t)
+	method := types.MethodInfo{
+		Name:             "Place",
+		FullName:         "shop.OrderService.Place",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  order,
+		OutputDescriptor: order,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.DryRun = true
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{method})
+	mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true)
+
+	t.Run("Returns_request_without_invoking", func(t *testing.T) {
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": method.ToolName,
+			"arguments": map[string]interface{}{
+				"_dryRun": true,
+				"status":  "STATUS_OPEN",
+				"price":   map[string]interface{}{"currency": "EUR"},
+			},
+		}, sessionCtx)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, true, result.Meta[mcp.MetaKeyDryRun])
+
+		var described dryRunRequest
+		require.Len(t, result.Content, 1)
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &described))
+		assert.Equal(t, "/shop.OrderService/Place", described.Method)
+		assert.Equal(t, "shop.Order", described.InputType)
+		assert.JSONEq(t, `{"status":"STATUS_OPEN","price":{"currency":"EUR"}}`, string(described.Request))
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool")
+	})
+
+	t.Run("Invalid_arguments", func(t *testing.T) {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      method.ToolName,
+			"arguments": map[string]interface{}{"_dryRun": true, "status": "STATUS_LOST"},
+		}, sessionCtx)
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+	})
+
+	t.Run("Flag_must_be_boolean", func(t *testing.T) {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      method.ToolName,
+			"arguments": map[string]interface{}{"_dryRun": "yes"},
+		}, sessionCtx)
+		assert.Error(t, err)
+	})
+
+	t.Run("Advertised_in_schema", func(t *testing.T) {
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		schema := result.Tools[0].InputSchema.(map[string]interface{})
+		assert.Contains(t, schema["properties"], "_dryRun")
+	})
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// errorEnvelopeDef names the error envelope under an output schema's $defs
+const errorEnvelopeDef = "ggrmcp.Error"
+
+// envelope is the JSON form of a failed call, shaped like a google.rpc.Status
+type envelope struct {
+	Code    int32             `json:"code"`
+	Message string            `json:"message"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+// errorEnvelopeSchema describes the envelope returned by failed calls
+func errorEnvelopeSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Returned instead of the response when the call fails (isError is true)",
+		"properties": map[string]interface{}{
+			"code": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     0,
+				"maximum":     16,
+				"description": "gRPC status code, for example 5 (NOT_FOUND) or 14 (UNAVAILABLE)",
+			},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "Description of the error",
+			},
+			"details": map[string]interface{}{
+				"type":        "array",
+				"description": "Error details such as google.rpc.ErrorInfo, with their fields next to @type",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"@type": map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"@type"},
+				},
+			},
+		},
+		"required":             []string{"code", "message"},
+		"additionalProperties": false,
+	}
+}
+
+// errorEnvelope returns the envelope of a failed call as JSON. Details of
+// types the gateway does not know keep only their @type.
+func errorEnvelope(err error) string {
+	st := status.Convert(err)
+	failed := envelope{
+		Code:    int32(st.Code()),
+		Message: mcp.SanitizeError(errors.New(st.Message())),
+	}
+	for _, detail := range st.Proto().GetDetails() {
+		encoded, err := protojson.Marshal(detail)
+		if err != nil {
+			encoded, _ = json.Marshal(map[string]string{"@type": detail.GetTypeUrl()})
+		}
+		failed.Details = append(failed.Details, encoded)
+	}
+
+	encoded, err := json.Marshal(failed)
+	if err != nil {
+		return `{"code":2,"message":"failed to encode error"}`
+	}
+	return string(encoded)
+}
+
+// advertiseErrorEnvelope turns the output schema of each method's tool into
+// a oneOf of its response and the error envelope. The response moves under
+// $defs, named after its message, so references to the schema's root keep
+// meaning the response.
+func (h *Handler) advertiseErrorEnvelope(methods []types.MethodInfo, tools []mcp.Tool) []mcp.Tool {
+	if !h.errorEnvelope {
+		return tools
+	}
+
+	outputs := make(map[string]string)
+	for _, method := range methods {
+		if method.OutputDescriptor != nil {
+			outputs[toolNameOf(method)] = string(method.OutputDescriptor.FullName())
+		}
+	}
+	for i, tool := range tools {
+		response, ok := tool.OutputSchema.(map[string]interface{})
+		output, known := outputs[tool.Name]
+		if !ok || !known {
+			continue
+		}
+		tools[i].OutputSchema = withErrorEnvelope(response, output)
+	}
+	return tools
+}
+
+// withErrorEnvelope returns a oneOf of a response schema, defined as name, and the error envelope
+func withErrorEnvelope(response map[string]interface{}, name string) map[string]interface{} {
+	ref := "#/$defs/" + name
+	defs := make(map[string]interface{})
+	if existing, ok := response["$defs"].(map[string]interface{}); ok {
+		for defName, def := range existing {
+			defs[defName] = rewriteRootRefs(def, ref)
+		}
+	}
+
+	body := make(map[string]interface{}, len(response))
+	for key, value := range response {
+		if key != "$defs" {
+			body[key] = value
+		}
+	}
+	defs[name] = rewriteRootRefs(body, ref)
+	defs[errorEnvelopeDef] = errorEnvelopeSchema()
+
+	return map[string]interface{}{
+		"$defs": defs,
+		"oneOf": []interface{}{
+			map[string]interface{}{"$ref": ref},
+			map[string]interface{}{"$ref": "#/$defs/" + errorEnvelopeDef},
+		},
+	}
+}
+
+// rewriteRootRefs returns a copy of a schema whose references to the root
+// ("#") point at ref instead
+func rewriteRootRefs(schema interface{}, ref string) interface{} {
+	switch value := schema.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, nested := range value {
+			if key == "$ref" && nested == "#" {
+				copied[key] = ref
+				continue
+			}
+			copied[key] = rewriteRootRefs(nested, ref)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, nested := range value {
+			copied[i] = rewriteRootRefs(nested, ref)
+		}
+		return copied
+	default:
+		return value
+	}
+}
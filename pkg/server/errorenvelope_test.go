@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestHandler_ErrorEnvelope(t *testing.T) {
+	request, response, _ := listDescriptors(t)
+	list := types.MethodInfo{
+		Name:             "ListOrders",
+		FullName:         "shop.OrderService.ListOrders",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  request,
+		OutputDescriptor: response,
+	}
+	list.ToolName = list.GenerateToolName()
+
+	newHandler := func(t *testing.T, enabled bool) (*Handler, *mockServiceDiscoverer, func() string) {
+		cfg := config.Default()
+		cfg.Tools.ErrorEnvelope = enabled
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+		mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{list})
+		mockDiscoverer.On("GetMethodByTool", list.ToolName).Return(list, true)
+
+		call := func() string {
+			result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": list.ToolName}, sessionCtx)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+			return result.Content[0].Text
+		}
+		return handler, mockDiscoverer, call
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, mockDiscoverer, call := newHandler(t, false)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, list.ToolName, "").
+			Return("", status.Error(codes.NotFound, "no such order"))
+
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "object", result.Tools[0].OutputSchema.(map[string]interface{})["type"])
+		assert.Contains(t, call(), "Error invoking method")
+	})
+
+	t.Run("Advertised", func(t *testing.T) {
+		handler, _, _ := newHandler(t, true)
+
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		schema := result.Tools[0].OutputSchema.(map[string]interface{})
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"$ref": "#/$defs/shop.ListOrdersResponse"},
+			map[string]interface{}{"$ref": "#/$defs/ggrmcp.Error"},
+		}, schema["oneOf"])
+
+		defs := schema["$defs"].(map[string]interface{})
+		orders := defs["shop.ListOrdersResponse"].(map[string]interface{})
+		assert.Contains(t, orders["properties"], "next_page_token")
+		envelope := defs[errorEnvelopeDef].(map[string]interface{})
+		assert.Equal(t, []string{"code", "message"}, envelope["required"])
+	})
+
+	t.Run("Failed_call_returns_envelope", func(t *testing.T) {
+		_, mockDiscoverer, call := newHandler(t, true)
+		st, err := status.New(codes.FailedPrecondition, "order is closed").WithDetails(&errdetails.ErrorInfo{
+			Reason: "ORDER_CLOSED",
+			Domain: "shop.example.com",
+		})
+		require.NoError(t, err)
+		unknown := &anypb.Any{TypeUrl: "type.googleapis.com/shop.Unknown", Value: []byte{0x08, 0x01}}
+		proto := st.Proto()
+		proto.Details = append(proto.Details, unknown)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, list.ToolName, "").
+			Return("", status.ErrorProto(proto))
+
+		assert.JSONEq(t, `{
+			"code": 9,
+			"message": "order is closed",
+			"details": [
+				{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "ORDER_CLOSED", "domain": "shop.example.com"},
+				{"@type": "type.googleapis.com/shop.Unknown"}
+			]
+		}`, call())
+	})
+
+	t.Run("Gateway_error_returns_envelope", func(t *testing.T) {
+		_, mockDiscoverer, call := newHandler(t, true)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, list.ToolName, "").
+			Return("", fmt.Errorf("not connected to gRPC server"))
+
+		var failed envelope
+		require.NoError(t, json.Unmarshal([]byte(call()), &failed))
+		assert.Equal(t, int32(codes.Unknown), failed.Code)
+		assert.Equal(t, "not connected to gRPC server", failed.Message)
+		assert.Empty(t, failed.Details)
+	})
+}
+
+func TestWithErrorEnvelope_RootReferences(t *testing.T) {
+	tree := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"children": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#"}},
+			"meta":     map[string]interface{}{"$ref": "#/$defs/shop.Meta"},
+		},
+		"$defs": map[string]interface{}{
+			"shop.Meta": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"owner": map[string]interface{}{"$ref": "#"}},
+			},
+		},
+	}
+
+	schema := withErrorEnvelope(tree, "shop.Tree")
+	defs := schema["$defs"].(map[string]interface{})
+	assert.NotContains(t, defs["shop.Tree"], "$defs")
+
+	encoded, err := json.Marshal(defs)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), `"$ref":"#"`)
+	assert.Contains(t, string(encoded), `"items":{"$ref":"#/$defs/shop.Tree"}`)
+	assert.Contains(t, string(encoded), `"owner":{"$ref":"#/$defs/shop.Tree"}`)
+
+	// The tool's own schema is left as it was
+	items := tree["properties"].(map[string]interface{})["children"].(map[string]interface{})["items"]
+	assert.Equal(t, map[string]interface{}{"$ref": "#"}, items)
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"path"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// flattenPath returns the wrapper fields a tool's request is flattened
+// through, outermost first, or nil when the tool is not flattened. A request
+// is flattened while its message has a single singular message field, so
+// wrappers of wrappers are promoted down to the first message with real fields.
+func (h *Handler) flattenPath(method types.MethodInfo) []protoreflect.FieldDescriptor {
+	if !h.flatten.Enabled || method.InputDescriptor == nil || !h.flattenSelected(method.ToolName) {
+		return nil
+	}
+
+	var wrappers []protoreflect.FieldDescriptor
+	seen := map[protoreflect.FullName]bool{}
+	message := method.InputDescriptor
+	for {
+		seen[message.FullName()] = true
+		field, ok := wrappedField(message)
+		if !ok || seen[field.Message().FullName()] {
+			break
+		}
+		wrappers = append(wrappers, field)
+		message = field.Message()
+	}
+
+	// A promoted message referring back to the request would need the
+	// request's own schema, which is no longer the root of the tool's schema
+	if len(wrappers) > 0 && reachesMessage(message, method.InputDescriptor.FullName(), map[protoreflect.FullName]bool{}) {
+		return nil
+	}
+	return wrappers
+}
+
+// flattenSelected reports whether flattening is configured for a tool
+func (h *Handler) flattenSelected(toolName string) bool {
+	if len(h.flatten.Tools) == 0 {
+		return true
+	}
+	for _, pattern := range h.flatten.Tools {
+		if matched, _ := path.Match(pattern, toolName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// wrappedField returns the only field of a message when it holds a single
+// message with fields of its own. Well-known types map to JSON scalars,
+// arrays or free-form objects, so they are not promoted.
+func wrappedField(message protoreflect.MessageDescriptor) (protoreflect.FieldDescriptor, bool) {
+	if message.Fields().Len() != 1 {
+		return nil, false
+	}
+	field := message.Fields().Get(0)
+	if field.Kind() != protoreflect.MessageKind || field.IsList() || field.IsMap() {
+		return nil, false
+	}
+	if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+		return nil, false
+	}
+	if strings.HasPrefix(string(field.Message().FullName()), "google.protobuf.") {
+		return nil, false
+	}
+	return field, true
+}
+
+// reachesMessage reports whether a message refers to target through any of
+// its fields, directly or nested
+func reachesMessage(message protoreflect.MessageDescriptor, target protoreflect.FullName, visited map[protoreflect.FullName]bool) bool {
+	if message.FullName() == target {
+		return true
+	}
+	if visited[message.FullName()] {
+		return false
+	}
+	visited[message.FullName()] = true
+
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.IsMap() {
+			field = field.MapValue()
+		}
+		if field.Message() != nil && reachesMessage(field.Message(), target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenedInput returns the message whose fields a tool takes as arguments
+func (h *Handler) flattenedInput(method types.MethodInfo) protoreflect.MessageDescriptor {
+	wrappers := h.flattenPath(method)
+	if len(wrappers) == 0 {
+		return method.InputDescriptor
+	}
+	return wrappers[len(wrappers)-1].Message()
+}
+
+// unflattenArguments wraps the arguments of a flattened tool back into the
+// request message it was flattened from. The caller's maps are left untouched.
+func (h *Handler) unflattenArguments(toolName string, params map[string]interface{}) map[string]interface{} {
+	if !h.flatten.Enabled {
+		return params
+	}
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok {
+		return params
+	}
+	wrappers := h.flattenPath(method)
+	if len(wrappers) == 0 {
+		return params
+	}
+
+	var arguments interface{} = map[string]interface{}{}
+	if args, exists := params["arguments"]; exists && args != nil {
+		arguments = args
+	}
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		arguments = map[string]interface{}{string(wrappers[i].Name()): arguments}
+	}
+
+	copied := make(map[string]interface{}, len(params)+1)
+	for key, value := range params {
+		copied[key] = value
+	}
+	copied["arguments"] = arguments
+	return copied
+}
+
+// flattenTools replaces the input schema of flattened tools with the schema
+// of the message their request wraps
+func (h *Handler) flattenTools(methods []types.MethodInfo, tools []mcp.Tool) []mcp.Tool {
+	if !h.flatten.Enabled {
+		return tools
+	}
+
+	flattened := make(map[string][]protoreflect.FieldDescriptor)
+	for _, method := range methods {
+		if wrappers := h.flattenPath(method); len(wrappers) > 0 {
+			flattened[method.ToolName] = wrappers
+		}
+	}
+	for i, tool := range tools {
+		wrappers, ok := flattened[tool.Name]
+		if !ok {
+			continue
+		}
+		schema, ok := tool.InputSchema.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		promoted, ok := promoteSchema(schema, wrappers)
+		if !ok {
+			h.logger.Warn("Failed to flatten tool input schema", zap.String("tool", tool.Name))
+			continue
+		}
+		tools[i].InputSchema = promoted
+	}
+	return tools
+}
+
+// promoteSchema returns the schema of the message nested in a request
+// schema through the wrapper fields, keeping the request's $defs. Schemas
+// may be cached by the builder, so the result is a new map.
+func promoteSchema(schema map[string]interface{}, wrappers []protoreflect.FieldDescriptor) (map[string]interface{}, bool) {
+	defs, _ := schema["$defs"].(map[string]interface{})
+	current := schema
+	for _, field := range wrappers {
+		properties, ok := current["properties"].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		property, ok := properties[string(field.Name())].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if ref, isRef := property["$ref"].(string); isRef {
+			definition, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+			if !ok || !strings.HasPrefix(ref, "#/$defs/") {
+				return nil, false
+			}
+			property = definition
+		}
+		current = property
+	}
+	if _, ok := current["properties"].(map[string]interface{}); !ok {
+		return nil, false
+	}
+
+	promoted := make(map[string]interface{}, len(current)+1)
+	for key, value := range current {
+		promoted[key] = value
+	}
+	if len(defs) > 0 {
+		promoted["$defs"] = defs
+	}
+	return promoted, true
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// wrapperMessages builds request messages wrapping an Order: directly, through
+// a second wrapper, with a scalar field, and wrapping a message that refers
+// back to the request
+func wrapperMessages(t *testing.T) protoreflect.MessageDescriptors {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("wrappers.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("quantity", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				},
+			},
+			{
+				Name:  proto.String("CreateOrderRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("order", 1, message, ".shop.Order")},
+			},
+			{
+				Name:  proto.String("ImportRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("create", 1, message, ".shop.CreateOrderRequest")},
+			},
+			{
+				Name:  proto.String("GetOrderRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")},
+			},
+			{
+				Name:  proto.String("TreeRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("tree", 1, message, ".shop.Tree")},
+			},
+			{
+				Name: proto.String("Tree"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("next", 2, message, ".shop.TreeRequest"),
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages()
+}
+
+// wrapperMethod returns a method of the order service taking a message
+func wrapperMethod(name string, input protoreflect.MessageDescriptor) types.MethodInfo {
+	method := types.MethodInfo{
+		Name:             name,
+		FullName:         "shop.OrderService." + name,
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  input,
+		OutputDescriptor: input,
+	}
+	method.ToolName = method.GenerateToolName()
+	return method
+}
+
+func TestHandler_FlattenPath(t *testing.T) {
+	messages := wrapperMessages(t)
+	cfg := config.Default()
+	cfg.Tools.Flatten.Enabled = true
+	handler, _, _ := newTestHandler(t, cfg)
+
+	names := func(method types.MethodInfo) []string {
+		var names []string
+		for _, field := range handler.flattenPath(method) {
+			names = append(names, string(field.Name()))
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"order"}, names(wrapperMethod("Create", messages.ByName("CreateOrderRequest"))))
+	assert.Equal(t, []string{"create", "order"}, names(wrapperMethod("Import", messages.ByName("ImportRequest"))))
+	assert.Empty(t, names(wrapperMethod("Get", messages.ByName("GetOrderRequest"))), "scalar field")
+	assert.Empty(t, names(wrapperMethod("Update", messages.ByName("Order"))), "several fields")
+	assert.Empty(t, names(wrapperMethod("Grow", messages.ByName("TreeRequest"))), "refers back to the request")
+
+	handler.flatten.Tools = []string{"*_import"}
+	assert.Empty(t, names(wrapperMethod("Create", messages.ByName("CreateOrderRequest"))), "not selected")
+	assert.Equal(t, []string{"create", "order"}, names(wrapperMethod("Import", messages.ByName("ImportRequest"))))
+
+	handler.flatten.Enabled = false
+	assert.Empty(t, names(wrapperMethod("Import", messages.ByName("ImportRequest"))), "disabled")
+}
+
+func TestHandler_Flatten(t *testing.T) {
+	messages := wrapperMessages(t)
+	create := wrapperMethod("Create", messages.ByName("CreateOrderRequest"))
+	importOrder := wrapperMethod("Import", messages.ByName("ImportRequest"))
+	get := wrapperMethod("Get", messages.ByName("GetOrderRequest"))
+
+	cfg := config.Default()
+	cfg.Tools.Flatten.Enabled = true
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{create, importOrder, get})
+	for _, method := range []types.MethodInfo{create, importOrder, get} {
+		mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true)
+	}
+
+	t.Run("Schema_takes_the_wrapped_fields", func(t *testing.T) {
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 3)
+		for _, tool := range result.Tools {
+			properties := tool.InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+			switch tool.Name {
+			case create.ToolName, importOrder.ToolName:
+				assert.Contains(t, properties, "name", tool.Name)
+				assert.Contains(t, properties, "quantity", tool.Name)
+				assert.NotContains(t, properties, "order", tool.Name)
+			case get.ToolName:
+				assert.Contains(t, properties, "id")
+			}
+		}
+	})
+
+	t.Run("Arguments_are_wrapped_before_invocation", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, create.ToolName,
+			`{"order":{"name":"widget","quantity":2}}`).Return(`{}`, nil).Once()
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, importOrder.ToolName,
+			`{"create":{"order":{"name":"widget"}}}`).Return(`{}`, nil).Once()
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, get.ToolName,
+			`{"id":"o-1"}`).Return(`{}`, nil).Once()
+
+		calls := map[string]map[string]interface{}{
+			create.ToolName:      {"name": "widget", "quantity": float64(2)},
+			importOrder.ToolName: {"name": "widget"},
+			get.ToolName:         {"id": "o-1"},
+		}
+		for toolName, arguments := range calls {
+			result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+				"name":      toolName,
+				"arguments": arguments,
+			}, sessionCtx)
+			require.NoError(t, err, toolName)
+			assert.False(t, result.IsError, toolName)
+		}
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Missing_arguments_send_an_empty_message", func(t *testing.T) {
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, create.ToolName,
+			`{"order":{}}`).Return(`{}`, nil).Once()
+
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": create.ToolName,
+		}, sessionCtx)
+		require.NoError(t, err)
+		mockDiscoverer.AssertExpectations(t)
+	})
+}
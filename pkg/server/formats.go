@@ -0,0 +1,18 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// validateFormats checks string arguments against the formats of their
+// fields, returning an invalid params error listing every mismatch
+func (h *Handler) validateFormats(args *decodedArguments) error {
+	problems := h.formats.Validate(args.desc, args.doc)
+	if len(problems) == 0 {
+		return nil
+	}
+	return mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %s", strings.Join(problems, "; ")))
+}
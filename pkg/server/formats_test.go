@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_FormatValidation(t *testing.T) {
+	order := orderDescriptor(t)
+	method := types.MethodInfo{
+		Name:             "Place",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  order,
+		OutputDescriptor: order,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.Formats.Validate = true
+	cfg.Tools.Formats.Fields = map[string]string{"shop.Order.note": "date"}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, method.ToolName, `{"note":"2024-03-15"}`).
+		Return(`{}`, nil)
+
+	call := func(note string) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      method.ToolName,
+			"arguments": map[string]interface{}{"note": note},
+		}, sessionCtx)
+	}
+
+	t.Run("Invalid_value_rejected", func(t *testing.T) {
+		_, err := call("15.03.2024")
+
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		assert.Equal(t, `Invalid arguments: field note must be a date in YYYY-MM-DD form, e.g. 2024-03-15 (got "15.03.2024")`, rpcErr.Message)
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, method.ToolName, mock.Anything)
+	})
+
+	t.Run("Valid_value_invoked", func(t *testing.T) {
+		result, err := call("2024-03-15")
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		mockDiscoverer.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fuzzRecoveredMessage is the error message of recovered panics in fuzzed handlers
+const fuzzRecoveredMessage = "fuzz: recovered panic"
+
+// fuzzDescriptor builds a message with a field of every shape the argument
+// pipeline rewrites: bytes, integer and bool keyed maps, enums, oneofs,
+// recursive messages and well-known types
+func fuzzDescriptor(tb testing.TB) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+	repeated := func(fd *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return fd
+	}
+	oneof := func(fd *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		fd.OneofIndex = proto.Int32(0)
+		return fd
+	}
+	entry := func(name string, key descriptorpb.FieldDescriptorProto_Type, value descriptorpb.FieldDescriptorProto_Type, valueType string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("key", 1, key, ""),
+				field("value", 2, value, valueType),
+			},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("fuzz.proto"),
+		Package:    proto.String("fuzz"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/struct.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_OPEN"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Sample"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("ratio", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""),
+				field("payload", 4, descriptorpb.FieldDescriptorProto_TYPE_BYTES, ""),
+				repeated(field("chunks", 5, descriptorpb.FieldDescriptorProto_TYPE_BYTES, "")),
+				repeated(field("flags", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".fuzz.Sample.FlagsEntry")),
+				repeated(field("scores", 7, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".fuzz.Sample.ScoresEntry")),
+				repeated(field("children", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".fuzz.Sample.ChildrenEntry")),
+				field("status", 9, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".fuzz.Status"),
+				field("parent", 10, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".fuzz.Sample"),
+				field("created", 11, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+				field("extra", 12, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Struct"),
+				oneof(field("text", 13, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")),
+				oneof(field("code", 14, descriptorpb.FieldDescriptorProto_TYPE_UINT32, "")),
+				field("id", 15, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{
+				entry("FlagsEntry", descriptorpb.FieldDescriptorProto_TYPE_BOOL, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				entry("ScoresEntry", descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				entry("ChildrenEntry", descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".fuzz.Sample"),
+			},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("choice")}},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(tb, err)
+	return file.Messages().ByName("Sample")
+}
+
+// newFuzzHandler creates a handler that runs every argument rewrite and
+// answers tool calls with dry runs, or with an empty response from the mock
+// backend when arguments are not an object
+func newFuzzHandler(tb testing.TB) (*Handler, types.MethodInfo, *session.Context) {
+	sample := fuzzDescriptor(tb)
+	method := types.MethodInfo{
+		Name:             "Put",
+		FullName:         "fuzz.SampleService.Put",
+		ServiceName:      "fuzz.SampleService",
+		InputDescriptor:  sample,
+		OutputDescriptor: sample,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.DryRun = true
+	cfg.Tools.NormalizeMapKeys = true
+	cfg.Tools.DoublePrecision = "reject"
+	cfg.Tools.BytesEncoding.AcceptHex = true
+	cfg.Tools.BinaryInputs.Enabled = true
+	cfg.Tools.Formats = config.FormatsConfig{Validate: true, Fields: map[string]string{"fuzz.Sample.id": "uuid"}}
+	cfg.Server.Middleware.Recovery.ErrorMessage = fuzzRecoveredMessage
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(tb, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{method}).Maybe()
+	mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true).Maybe()
+	mockDiscoverer.On("GetMethodByTool", mock.Anything).Return(types.MethodInfo{}, false).Maybe()
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("{}", nil).Maybe()
+	mockDiscoverer.On("GetServiceCount").Return(1).Maybe()
+	mockDiscoverer.On("GetMethodCount").Return(1).Maybe()
+	return handler, method, sessionCtx
+}
+
+// FuzzToolArguments feeds arbitrary arguments through the tool call pipeline
+// down to the dynamic request message
+func FuzzToolArguments(f *testing.F) {
+	for _, seed := range []string{
+		`{}`,
+		`{"name":"a","count":"9007199254740993","ratio":1e308,"status":"STATUS_OPEN"}`,
+		`{"payload":"0xdeadbeef","chunks":["aGVsbG8","_-8=","0X0"]}`,
+		`{"flags":{"TRUE":"x"," false ":"y"},"scores":{"+7":1,"1e3":"2","7.0":3}}`,
+		`{"children":{"18446744073709551615":{"parent":{"children":{"1":{}}}}}}`,
+		`{"created":"2024-01-01T00:00:00Z","extra":{"a":[1,{"b":null}]}}`,
+		`{"text":"x","code":4294967295}`,
+		`{"id":"123e4567-e89b-12d3-a456-426614174000"}`,
+		`{"parent":null,"flags":null,"chunks":[null]}`,
+		`[1,2,3]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	handler, method, sessionCtx := newFuzzHandler(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Arguments are decoded as the handler decodes request params
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var arguments interface{}
+		if err := decoder.Decode(&arguments); err != nil {
+			return
+		}
+		object, dryRun := arguments.(map[string]interface{})
+		if dryRun {
+			object[dryRunArgument] = true
+		}
+
+		result, err := handler.callTool(context.Background(), map[string]interface{}{
+			"name":      method.ToolName,
+			"arguments": arguments,
+		}, sessionCtx)
+		if err != nil || !dryRun {
+			return
+		}
+
+		// Accepted arguments build a request that protojson reads back
+		require.Len(t, result.Content, 1)
+		var described dryRunRequest
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &described))
+		message := dynamicpb.NewMessage(method.InputDescriptor)
+		require.NoError(t, protojson.Unmarshal(described.Request, message))
+	})
+}
+
+// FuzzServeHTTP posts arbitrary bodies to the MCP endpoint
+func FuzzServeHTTP(f *testing.F) {
+	for _, seed := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","id":"a","method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fuzz_sampleservice_put","arguments":{"_dryRun":true,"count":1}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":7,"arguments":[]}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":null}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":{}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"completion/complete","params":{"ref":1}}`,
+		`{"jsonrpc":"2.0","id":"srv-00","result":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":null,"method":"ping"}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	handler, _, sessionCtx := newFuzzHandler(f)
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", sessionCtx.ID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if strings.Contains(rec.Body.String(), fuzzRecoveredMessage) {
+			t.Fatalf("request panicked: %s", rec.Body.String())
+		}
+		if rec.Code == http.StatusOK && rec.Header().Get("Content-Type") == "application/json" {
+			var response mcp.JSONRPCResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+		}
+	})
+}
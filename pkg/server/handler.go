@@ -1,48 +1,263 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aalobaidi/ggRMCP/pkg/approval"
+	"github.com/aalobaidi/ggRMCP/pkg/auth"
 	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/errcatalog"
+	"github.com/aalobaidi/ggRMCP/pkg/events"
+	"github.com/aalobaidi/ggRMCP/pkg/formats"
 	"github.com/aalobaidi/ggRMCP/pkg/grpc"
 	"github.com/aalobaidi/ggRMCP/pkg/headers"
+	"github.com/aalobaidi/ggRMCP/pkg/history"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
 	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/policy"
+	"github.com/aalobaidi/ggRMCP/pkg/quota"
+	"github.com/aalobaidi/ggRMCP/pkg/resources"
 	"github.com/aalobaidi/ggRMCP/pkg/session"
 	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"github.com/aalobaidi/ggRMCP/pkg/transform"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/aalobaidi/ggRMCP/pkg/upstream"
+	"github.com/aalobaidi/ggRMCP/pkg/version"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// toolCallTimeout bounds each backend call, whether the caller made it or the
+// gateway made it on the caller's behalf
+const toolCallTimeout = 30 * time.Second
+
 // Handler handles HTTP requests for the MCP gateway
 type Handler struct {
 	logger            *zap.Logger
 	validator         *mcp.Validator
 	serviceDiscoverer grpc.ServiceDiscoverer
-	sessionManager    *session.Manager
+	sessionManager    session.Manager
 	toolBuilder       *tools.MCPToolBuilder
 	headerFilter      *headers.Filter
+	identity          config.IdentityForwardingConfig
+	errorCatalog      *errcatalog.Catalog
+	batchConfig       config.BatchConfig
+	transforms        *transform.Pipeline
+	policy            policy.Evaluator
+	policyConfig      config.PolicyConfig
+	quota             *quota.Tracker
+	streaming         config.StreamingConfig
+	responseLimits    []config.ResponseLimitConfig
+	argumentLimits    []config.ArgumentLimitConfig
+	resources         *resources.Store
+	binaryFields      config.BinaryFieldsConfig
+	binaryInputs      config.BinaryInputsConfig
+	bytesEncoding     config.BytesEncodingConfig
+	normalizeMapKeys  bool
+	doublePrecision   string
+	dryRun            bool
+	selection         bool
+	errorEnvelope     bool
+	pagination        config.PaginationConfig
+	longRunning       config.LongRunningConfig
+	argumentDefaults  []config.ArgumentDefaultsConfig
+	mediaFields       []config.MediaFieldConfig
+	completionConfig  config.CompletionConfig
+	rootsConfig       config.RootsConfig
+	pathArguments     []config.PathArgumentConfig
+	samplingConfig    config.SamplingConfig
+	sampledTools      []config.SampledToolConfig
+	deprecation       config.DeprecationConfig
+	descriptions      config.DescriptionsConfig
+	lazySchemas       config.LazySchemasConfig
+	flatten           config.FlattenConfig
+	meta              config.MetaConfig
+	formats           *formats.Registry
+	affinity          *sessionAffinity
+	migration         config.MigrationConfig
+	sessionAdminToken string
+	terminated        *terminatedSessions
+	middlewareOrder   []string
+	requestTimeout    time.Duration
+	security          config.SecurityConfig
+	recovery          *panicRecovery
+	disconnects       *disconnectStats
+	upstreams         *upstream.Aggregator
+	wellKnown         config.WellKnownConfig
+	serverConfig      config.MCPServerConfig
+	chaos             *chaosInjector
+	replay            *replayGuard
+	toolStats         *toolStats
+	slowCalls         *slowCallDetector
+	usage             *usageLabels
+	profiles          *toolProfiles
+	jwt               *auth.Verifier
+	rbac              *roleAccess
+	webhooks          *webhook.Dispatcher
+	events            *events.Sink
+	history           *history.Recorder
+	historyConfig     config.HistoryConfig
+	responseCache     config.ResponseCacheConfig
+	approvals         *approval.Gate
+	approvalConfig    config.ApprovalConfig
+	toolSwitch        *toolSwitch
+	toolAdminConfig   config.ToolAdminConfig
+	channelzConfig    config.ChannelzConfig
+	notifications     *notifier
+	maintenance       *maintenanceMode
+	builtins          []builtinTool
+	pendingRequests   sync.Map // server-to-client request ID -> chan clientReply
 }
 
 // NewHandler creates a new HTTP handler
 func NewHandler(
 	logger *zap.Logger,
 	serviceDiscoverer grpc.ServiceDiscoverer,
-	sessionManager *session.Manager,
+	sessionManager session.Manager,
 	toolBuilder *tools.MCPToolBuilder,
 	headerConfig config.HeaderForwardingConfig,
 ) *Handler {
-	return &Handler{
+	cfg := config.Default()
+	cfg.GRPC.HeaderForwarding = headerConfig
+	return NewHandlerWithConfig(logger, serviceDiscoverer, sessionManager, toolBuilder, cfg)
+}
+
+// NewHandlerWithConfig creates a new HTTP handler from the full application configuration
+func NewHandlerWithConfig(
+	logger *zap.Logger,
+	serviceDiscoverer grpc.ServiceDiscoverer,
+	sessionManager session.Manager,
+	toolBuilder *tools.MCPToolBuilder,
+	cfg *config.Config,
+) *Handler {
+	transforms := transform.NewPipeline(cfg.Tools.Transforms)
+	transforms.AddPathHooks(cfg.Tools.PathArguments)
+
+	h := &Handler{
 		logger:            logger,
 		validator:         mcp.NewValidator(),
 		serviceDiscoverer: serviceDiscoverer,
 		sessionManager:    sessionManager,
 		toolBuilder:       toolBuilder,
-		headerFilter:      headers.NewFilter(headerConfig),
+		headerFilter:      headers.NewFilter(cfg.GRPC.HeaderForwarding),
+		identity:          cfg.GRPC.HeaderForwarding.Identity,
+		errorCatalog:      errcatalog.NewCatalog(cfg.MCP.ErrorCatalog),
+		batchConfig:       cfg.MCP.Batch,
+		transforms:        transforms,
+		policy:            newPolicyEvaluator(cfg.Server.Security.Policy),
+		policyConfig:      cfg.Server.Security.Policy,
+		quota:             newQuotaTracker(cfg.Server.Security.Quota),
+		streaming:         cfg.Server.Streaming,
+		responseLimits:    cfg.Tools.ResponseLimits,
+		argumentLimits:    cfg.Tools.ArgumentLimits,
+		resources:         resources.NewStore(cfg.MCP.Resources),
+		binaryFields:      cfg.Tools.BinaryFields,
+		binaryInputs:      cfg.Tools.BinaryInputs,
+		bytesEncoding:     cfg.Tools.BytesEncoding,
+		normalizeMapKeys:  cfg.Tools.NormalizeMapKeys,
+		doublePrecision:   cfg.Tools.DoublePrecision,
+		dryRun:            cfg.Tools.DryRun,
+		selection:         cfg.Tools.Select,
+		errorEnvelope:     cfg.Tools.ErrorEnvelope,
+		pagination:        cfg.Tools.Pagination,
+		longRunning:       cfg.Tools.LongRunning,
+		argumentDefaults:  cfg.Tools.ArgumentDefaults,
+		mediaFields:       cfg.Tools.MediaFields,
+		completionConfig:  cfg.MCP.Completion,
+		rootsConfig:       cfg.MCP.Roots,
+		pathArguments:     cfg.Tools.PathArguments,
+		samplingConfig:    cfg.MCP.Sampling,
+		sampledTools:      cfg.Tools.Sampled,
+		deprecation:       cfg.Tools.Deprecation,
+		descriptions:      cfg.Tools.Descriptions,
+		lazySchemas:       cfg.Tools.LazySchemas,
+		flatten:           cfg.Tools.Flatten,
+		meta:              cfg.MCP.Meta,
+		formats:           formats.NewRegistry(cfg.Tools.Formats),
+		affinity:          newSessionAffinity(cfg.Session, logger),
+		migration:         cfg.Session.Migration,
+		sessionAdminToken: cfg.Session.AdminToken,
+		terminated:        newTerminatedSessions(cfg.Session.AdminToken),
+		middlewareOrder:   cfg.Server.Middleware.Order,
+		requestTimeout:    cfg.Server.Timeout,
+		security:          cfg.Server.Security,
+		recovery:          newPanicRecovery(cfg.Server.Middleware.Recovery, logger),
+		disconnects:       newDisconnectStats(logger),
+		wellKnown:         cfg.MCP.WellKnown,
+		serverConfig:      cfg.MCP.Server,
+		chaos:             newChaosInjector(cfg.Tools.Chaos, logger),
+		replay:            newReplayGuard(cfg.Server.Security.Replay),
+		toolStats:         newToolStats(cfg.MCP.ToolStats),
+		slowCalls:         newSlowCallDetector(cfg.Tools.SlowCalls, logger),
+		usage:             newUsageLabels(cfg.MCP.UsageLabels),
+		profiles:          newToolProfiles(cfg.MCP.Profiles),
+		jwt:               newJWTVerifier(cfg.Server.Security.JWT),
+		rbac:              newRoleAccess(cfg.Server.Security.RBAC),
+		historyConfig:     cfg.MCP.History,
+		responseCache:     cfg.MCP.ResponseCache,
+		approvalConfig:    cfg.MCP.Approval,
+		toolSwitch:        newToolSwitch(cfg.MCP.ToolAdmin, logger),
+		toolAdminConfig:   cfg.MCP.ToolAdmin,
+		channelzConfig:    cfg.GRPC.Channelz,
+		maintenance:       newMaintenanceMode(cfg.MCP.Maintenance),
+	}
+	if h.toolSwitch != nil {
+		h.notifications = newNotifier()
+	}
+	h.approvals = approval.NewGate(cfg.MCP.Approval, h.notifyApproval)
+
+	if h.responseCache.Enabled {
+		h.addBuiltinTool(builtinTool{tool: h.getCachedTool(), call: h.callGetCachedTool})
 	}
+	if h.longRunning.Mode != "" {
+		h.addBuiltinTool(builtinTool{tool: pollOperationTool(), call: h.callPollOperationTool})
+	}
+	if h.toolStats != nil && cfg.MCP.ToolStats.Tool {
+		h.addBuiltinTool(builtinTool{tool: statsTool(), call: h.callStatsTool})
+	}
+	if h.lazySchemas.Enabled {
+		h.addBuiltinTool(builtinTool{tool: describeTool(), call: h.callDescribeTool})
+	}
+	return h
+}
+
+// newQuotaTracker creates the quota tracker, or nil if disabled
+func newQuotaTracker(quotaConfig config.QuotaConfig) *quota.Tracker {
+	if !quotaConfig.Enabled {
+		return nil
+	}
+	return quota.NewTracker(quotaConfig)
+}
+
+// newPolicyEvaluator creates the configured policy evaluator, or nil if disabled
+func newPolicyEvaluator(policyConfig config.PolicyConfig) policy.Evaluator {
+	if !policyConfig.Enabled {
+		return nil
+	}
+	return policy.NewOPAClient(policyConfig)
+}
+
+// SetPolicyEvaluator replaces the policy evaluator
+func (h *Handler) SetPolicyEvaluator(evaluator policy.Evaluator) {
+	h.policy = evaluator
+}
+
+// AddTransformHook registers a custom request/response transformation hook
+func (h *Handler) AddTransformHook(hook transform.Hook) {
+	h.transforms.Add(hook)
 }
 
 // ServeHTTP handles HTTP requests
@@ -59,12 +274,26 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleGet handles GET requests (for capability discovery)
 func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
+	r, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	if h.rejectTerminated(w, r) {
+		return
+	}
+
 	// Extract session information
 	sessionID := r.Header.Get("Mcp-Session-Id")
 	sessionCtx := h.sessionManager.GetOrCreateSession(sessionID, extractHeaders(r))
 
-	// Set session header in response
-	w.Header().Set("Mcp-Session-Id", sessionCtx.ID)
+	// Set session and affinity headers in response
+	h.setSessionHeaders(w, r, sessionCtx)
+
+	// Stream notifications to clients listening for them
+	if h.notifications != nil && acceptsEventStream(r) {
+		h.serveNotifications(w, r, sessionCtx)
+		return
+	}
 
 	// Handle initialization
 	initResult := h.handleInitialize()
@@ -79,14 +308,69 @@ func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
 
 // handlePost handles POST requests (JSON-RPC)
 func (h *Handler) handlePost(w http.ResponseWriter, r *http.Request) {
-	// Parse JSON-RPC request
+	// Correlate the request's log entries, including those of its upstream calls
+	requestID := requestIDFor(r)
+	w.Header().Set(RequestIDHeader, requestID)
+	r = r.WithContext(withRequestID(r.Context(), requestID))
+	if h.lazySchemas.Enabled {
+		r = r.WithContext(withPublicURL(r.Context(), h.publicURL(r)))
+	}
+
+	// Authenticate the caller before reading the body
+	r, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// Reject bodies that cannot be JSON-RPC before reading them
+	if message, ok := checkContentType(r.Header.Get("Content-Type"), jsonRPCMediaTypes); !ok {
+		h.logger.Warn("Rejected request content type",
+			zap.String("contentType", r.Header.Get("Content-Type")))
+		http.Error(w, message, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Keep the exact body for signature checks
+	var rawBody bytes.Buffer
+	reader := io.Reader(r.Body)
+	if h.replay != nil {
+		reader = io.TeeReader(r.Body, &rawBody)
+	}
+
+	// Parse JSON-RPC message
+	body, err := decodeJSONBody(reader)
+	if err != nil {
+		h.logger.Error("Failed to decode JSON-RPC request", zap.Error(err))
+		h.writeErrorResponse(w, mcp.RequestID{Value: nil}, mcp.ErrorCodeParseError, "Parse error: "+err.Error())
+		return
+	}
+
+	// Clients answer server requests (pings, roots/list) with responses, which need no reply
+	if isClientResponse(body) {
+		h.logger.Debug("Received client response",
+			zap.String("sessionId", r.Header.Get("Mcp-Session-Id")))
+		h.deliverClientResponse(body)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Numbers are kept as the client wrote them, so int64 and double
+	// arguments reach protojson without a float64 round trip
 	var req mcp.JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
 		h.logger.Error("Failed to decode JSON-RPC request", zap.Error(err))
 		h.writeErrorResponse(w, mcp.RequestID{Value: nil}, mcp.ErrorCodeParseError, "Parse error")
 		return
 	}
 
+	// Notifications carry no ID and get no response
+	if strings.HasPrefix(req.Method, "notifications/") {
+		h.handleNotification(w, r, req.Method)
+		return
+	}
+
 	// Validate request
 	if err := h.validator.ValidateRequest(&req); err != nil {
 		h.logger.Error("Request validation failed", zap.Error(err))
@@ -94,37 +378,52 @@ func (h *Handler) handlePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject unsigned, stale and replayed calls before they take effect
+	if h.replay.protects(req.Method) {
+		if err := h.replay.check(r.Header, rawBody.Bytes()); err != nil {
+			h.logger.Warn("Rejected request by replay protection",
+				zap.String("method", req.Method),
+				zap.Error(err))
+			h.writeRPCError(w, req.ID, rpcErrorFor(err))
+			return
+		}
+	}
+
 	// Extract session information
+	if h.rejectTerminated(w, r) {
+		return
+	}
 	sessionID := r.Header.Get("Mcp-Session-Id")
 	sessionCtx := h.sessionManager.GetOrCreateSession(sessionID, extractHeaders(r))
 
-	// Set session header in response
-	w.Header().Set("Mcp-Session-Id", sessionCtx.ID)
+	// Set session and affinity headers in response
+	h.setSessionHeaders(w, r, sessionCtx)
 
 	// Log the request
 	h.logger.Info("Processing MCP request",
+		zap.String("requestId", requestID),
 		zap.String("method", req.Method),
 		zap.String("sessionId", sessionCtx.ID),
-		zap.Any("params", req.Params))
+		zap.ByteString("params", req.Params))
+
+	// Answer tool calls over an event stream carrying pings and client requests
+	if h.streamsWhileHandling(r, req.Method, sessionCtx) {
+		h.handleOverStream(w, r, &req, sessionCtx)
+		return
+	}
 
 	// Handle the request
 	result, err := h.handleRequest(r.Context(), &req, sessionCtx)
+	if clientDisconnected(r.Context()) {
+		h.disconnects.responseDropped(req.Method, sessionCtx.ID)
+		return
+	}
 	if err != nil {
 		h.logger.Error("Request handling failed",
 			zap.String("method", req.Method),
 			zap.Error(err))
 
-		// Determine error code
-		var errorCode int
-		if strings.Contains(err.Error(), "not found") {
-			errorCode = mcp.ErrorCodeMethodNotFound
-		} else if strings.Contains(err.Error(), "invalid") {
-			errorCode = mcp.ErrorCodeInvalidParams
-		} else {
-			errorCode = mcp.ErrorCodeInternalError
-		}
-
-		h.writeErrorResponse(w, req.ID, errorCode, mcp.SanitizeError(err))
+		h.writeRPCError(w, req.ID, rpcErrorFor(err))
 		return
 	}
 
@@ -135,47 +434,175 @@ func (h *Handler) handlePost(w http.ResponseWriter, r *http.Request) {
 		Result:  result,
 	}
 
-	h.writeJSONResponse(w, response)
+	h.writeResponse(w, r, response)
 }
 
 // handleRequest handles individual JSON-RPC requests
-func (h *Handler) handleRequest(ctx context.Context, req *mcp.JSONRPCRequest, sessionCtx *session.Context) (interface{}, error) {
+func (h *Handler) handleRequest(ctx context.Context, req *mcp.JSONRPCRequest, sessionCtx *session.Context) (_ interface{}, err error) {
+	defer h.recovery.recoverRequest(req.Method, &err)
+
 	switch req.Method {
 	case "initialize":
+		var params mcp.InitializeParams
+		if err := req.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		if err := h.selectProfile(params.Profile, sessionCtx); err != nil {
+			return nil, err
+		}
+		if err := h.checkClientRoots(params); err != nil {
+			return nil, err
+		}
+		h.recordClientCapabilities(params, sessionCtx)
 		return h.handleInitialize(), nil
+	case "ping":
+		return h.handlePing(), nil
 	case "tools/list":
-		return h.handleToolsList(ctx)
+		result, err := h.handleSessionToolsList(ctx, sessionCtx)
+		if err != nil {
+			return nil, err
+		}
+		return h.compactToolsList(ctx, result), nil
 	case "tools/call":
-		return h.handleToolsCall(ctx, req.Params, sessionCtx)
+		var params mcp.ToolsCallParams
+		if err := req.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		ctx = withClientArguments(ctx, params.Arguments, params.RawArguments)
+		return h.handleToolsCall(ctx, params.Map(), sessionCtx)
+	case "tools/call_batch":
+		if !h.batchConfig.Enabled {
+			return nil, fmt.Errorf("method not found: %s", req.Method)
+		}
+		params, err := decodeParamsMap(req)
+		if err != nil {
+			return nil, err
+		}
+		return h.handleToolsCallBatch(ctx, params, batchArguments(req), sessionCtx)
 	case "prompts/list":
 		return h.handlePromptsList(ctx)
 	case "resources/list":
-		return h.handleResourcesList(ctx)
+		return h.handleResourcesList(ctx, sessionCtx)
+	case "resources/read":
+		params, err := decodeParamsMap(req)
+		if err != nil {
+			return nil, err
+		}
+		return h.handleResourcesRead(ctx, params, sessionCtx)
+	case "completion/complete":
+		if !h.completionConfig.Enabled {
+			return nil, fmt.Errorf("method not found: %s", req.Method)
+		}
+		params, err := decodeParamsMap(req)
+		if err != nil {
+			return nil, err
+		}
+		return h.handleCompletionComplete(ctx, params)
 	default:
 		return nil, fmt.Errorf("method not found: %s", req.Method)
 	}
 }
 
+// decodeParamsMap decodes the params of methods without typed params
+func decodeParamsMap(req *mcp.JSONRPCRequest) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if err := req.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// errorResponseFor determines the JSON-RPC error code and client-facing message for an error
+func errorResponseFor(err error) (int, string) {
+	var rpcErr *mcp.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code, mcp.SanitizeString(rpcErr.Message)
+	}
+	return errorCodeFor(err), mcp.SanitizeError(err)
+}
+
+// rpcErrorFor builds the client-facing JSON-RPC error for an error, keeping
+// the structured data of gateway errors
+func rpcErrorFor(err error) *mcp.RPCError {
+	code, message := errorResponseFor(err)
+	rpcErr := mcp.NewRPCError(code, message)
+	var source *mcp.RPCError
+	if errors.As(err, &source) {
+		rpcErr.Data = source.Data
+	}
+	return rpcErr
+}
+
+// errorCodeFor determines the JSON-RPC error code for a request handling error
+func errorCodeFor(err error) int {
+	var rpcErr *mcp.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+
+	if strings.Contains(err.Error(), "not found") {
+		return mcp.ErrorCodeMethodNotFound
+	} else if strings.Contains(err.Error(), "invalid") {
+		return mcp.ErrorCodeInvalidParams
+	}
+	return mcp.ErrorCodeInternalError
+}
+
 // handleInitialize handles the initialize method
 func (h *Handler) handleInitialize() *mcp.InitializationResult {
-	return &mcp.InitializationResult{
+	result := &mcp.InitializationResult{
 		ProtocolVersion: "2024-11-05",
 		Capabilities: mcp.ServerCapabilities{
 			Tools: &mcp.ToolsCapability{
-				ListChanged: false,
-			},
-			Prompts: &mcp.PromptsCapability{
-				ListChanged: false,
-			},
-			Resources: &mcp.ResourcesCapability{
-				ListChanged: false,
+				// Tools disabled by operators are announced on the GET stream
+				ListChanged: h.notifications != nil,
 			},
 		},
 		ServerInfo: mcp.ServerInfo{
-			Name:    "ggRMCP",
-			Version: "1.0.0",
+			Name:    h.serverConfig.Name,
+			Title:   h.serverConfig.Title,
+			Version: h.serverConfig.Version,
 		},
+		Instructions: h.serverConfig.Instructions,
+	}
+	if result.ServerInfo.Version == "" {
+		result.ServerInfo.Version = version.Get().Version
 	}
+
+	// Prompts and logging are not implemented, so only features that can
+	// return something are advertised
+	if h.resourcesAvailable() {
+		result.Capabilities.Resources = &mcp.ResourcesCapability{}
+	}
+	if h.completionConfig.Enabled {
+		result.Capabilities.Completions = &mcp.CompletionsCapability{}
+	}
+
+	// Advertise opt-in extensions
+	if h.batchConfig.Enabled {
+		result.Capabilities.Experimental = map[string]interface{}{
+			"tools/call_batch": map[string]interface{}{
+				"maxItems": h.batchConfig.MaxItems,
+			},
+		}
+	}
+
+	return result
+}
+
+// resourcesAvailable reports whether resources/list can list anything: the
+// resources stored by tool calls and uploads, full tool descriptions, or the
+// backend's server info
+func (h *Handler) resourcesAvailable() bool {
+	if h.binaryFields.Enabled || h.binaryInputs.Enabled || h.responseCache.Enabled || h.descriptions.MaxLength > 0 {
+		return true
+	}
+	for _, limit := range h.responseLimits {
+		if limit.StoreFull {
+			return true
+		}
+	}
+	return h.serviceDiscoverer.ServerInfo() != nil
 }
 
 // handleToolsList handles the tools/list method
@@ -203,6 +630,15 @@ func (h *Handler) handleToolsList(ctx context.Context) (*mcp.ToolsListResult, er
 		h.logger.Error("Failed to build tools", zap.Error(err))
 		return nil, fmt.Errorf("failed to build tools: %w", err)
 	}
+	tools = h.decorateMethodTools(methods, tools)
+
+	// Re-export the tools of downstream MCP servers
+	tools = h.refuseUpstreamCollisions(tools)
+	tools = append(tools, h.upstreams.Tools(ctx)...)
+
+	// Add the tools provided by the gateway itself
+	tools = append(tools, h.builtinToolList()...)
+	tools = h.decorateListedTools(tools)
 
 	h.logger.Info("Generated tools list", zap.Int("toolCount", len(tools)))
 
@@ -211,8 +647,87 @@ func (h *Handler) handleToolsList(ctx context.Context) (*mcp.ToolsListResult, er
 	}, nil
 }
 
-// handleToolsCall handles the tools/call method
+// decorateMethodTools applies the configured rewrites and advertised
+// arguments to the tools built from gRPC methods
+func (h *Handler) decorateMethodTools(methods []types.MethodInfo, tools []mcp.Tool) []mcp.Tool {
+	tools = h.limitDescriptions(tools)
+	tools = h.applyDeprecation(methods, tools)
+	tools = h.flattenTools(methods, tools)
+	tools = h.advertiseDryRun(tools)
+	tools = h.advertiseSelection(tools)
+	tools = h.advertisePagination(methods, tools)
+	tools = h.advertiseCallHeaders(tools)
+	tools = h.advertiseOperations(methods, tools)
+	tools = h.advertiseArgumentDefaults(tools)
+	return h.advertiseErrorEnvelope(methods, tools)
+}
+
+// decorateListedTools hides disabled tools and annotates the rest, whatever
+// their origin
+func (h *Handler) decorateListedTools(tools []mcp.Tool) []mcp.Tool {
+	tools = h.hideDisabledTools(tools)
+	tools = h.advertiseCosts(tools)
+	return h.flagMaintenance(tools)
+}
+
+// buildListedTool returns the tools/list entry of one tool, building only
+// that tool instead of the whole list
+func (h *Handler) buildListedTool(ctx context.Context, toolName string) (mcp.Tool, bool) {
+	var tools []mcp.Tool
+	if method, ok := h.serviceDiscoverer.GetMethodByTool(toolName); ok {
+		if tool, built := h.toolBuilder.BuildMethodTool(method); built {
+			methods := []types.MethodInfo{method}
+			tools = h.refuseUpstreamCollisions(h.decorateMethodTools(methods, []mcp.Tool{tool}))
+		}
+	}
+	if len(tools) == 0 {
+		candidates := append(h.upstreams.Tools(ctx), h.builtinToolList()...)
+		for _, tool := range candidates {
+			if tool.Name == toolName {
+				tools = []mcp.Tool{tool}
+				break
+			}
+		}
+	}
+
+	for _, tool := range h.decorateListedTools(tools) {
+		if tool.Name == toolName {
+			return tool, true
+		}
+	}
+	return mcp.Tool{}, false
+}
+
+// handleToolsCall handles the tools/call method and publishes its outcome
 func (h *Handler) handleToolsCall(ctx context.Context, params map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	start := time.Now()
+	result, err := h.callTool(ctx, params, sessionCtx)
+	elapsed := time.Since(start)
+	h.echoMeta(params, result)
+	if clientDisconnected(ctx) {
+		h.disconnects.callAbandoned(ctx, params, sessionCtx.ID, elapsed)
+	}
+	h.toolStats.record(params, result, err, elapsed)
+	clientName, origin := h.usage.labels(sessionCtx)
+	h.usage.record(clientName, origin, result, err)
+	h.publishToolCall(ctx, params, sessionCtx, result, err, elapsed)
+	return result, err
+}
+
+// callTool runs a tool call, giving its quota reservation back when the call
+// is refused, for example by an approver, or fails
+func (h *Handler) callTool(ctx context.Context, params map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	var reservation quota.Reservation
+	result, err := h.runToolCall(ctx, params, sessionCtx, &reservation)
+	if err != nil || (result != nil && result.IsError) {
+		h.releaseQuota(reservation)
+	}
+	return result, err
+}
+
+// runToolCall validates, authorizes and invokes a tool call, keeping the
+// quota reservation it makes in reservation
+func (h *Handler) runToolCall(ctx context.Context, params map[string]interface{}, sessionCtx *session.Context, reservation *quota.Reservation) (*mcp.ToolCallResult, error) {
 	// Validate parameters
 	if err := h.validator.ValidateToolCallParams(params); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
@@ -220,54 +735,412 @@ func (h *Handler) handleToolsCall(ctx context.Context, params map[string]interfa
 
 	// Extract tool name and arguments
 	toolName := params["name"].(string)
+	if err := h.checkMaintenance(); err != nil {
+		return nil, err
+	}
+	if err := h.checkToolEnabled(toolName); err != nil {
+		return nil, err
+	}
+	if err := h.checkDeprecation(toolName); err != nil {
+		return nil, err
+	}
+	if err := h.checkProfile(toolName, sessionCtx); err != nil {
+		return nil, err
+	}
+	if err := h.checkRoles(ctx, toolName); err != nil {
+		return nil, err
+	}
+	params, dryRun, err := h.extractDryRun(params)
+	if err != nil {
+		return nil, err
+	}
+	params, selected, err := h.extractSelection(params)
+	if err != nil {
+		return nil, err
+	}
+	params, pages, err := h.extractPages(params)
+	if err != nil {
+		return nil, err
+	}
+	params, callHeaders, err := h.extractCallHeaders(params)
+	if err != nil {
+		return nil, err
+	}
 
-	var argumentsJSON string
-	if args, exists := params["arguments"]; exists && args != nil {
-		argBytes, err := json.Marshal(args)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	// Fill in configured defaults and the selected preset under the client's arguments
+	params, err = h.applyArgumentDefaults(toolName, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject oversized arrays, objects and strings before they become messages
+	if err := h.checkArgumentLimits(toolName, params); err != nil {
+		return nil, err
+	}
+
+	// Wrap the arguments of flattened tools back into their request message
+	params = h.unflattenArguments(toolName, params)
+
+	// Arguments no stage has changed are passed on as the client wrote them
+	argumentsJSON, err := encodeArguments(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tie constrained arguments, including filled-in defaults, to the caller's claims
+	if err := h.checkArgumentConstraints(ctx, toolName, params); err != nil {
+		return nil, err
+	}
+
+	// Authorize the call before any transformation or invocation
+	if err := h.authorizeToolCall(ctx, toolName, params, sessionCtx); err != nil {
+		return nil, err
+	}
+
+	// Account the call against the caller's quota
+	subject := h.quotaSubject(ctx, sessionCtx)
+	if *reservation, err = h.reserveQuota(subject, toolName); err != nil {
+		return nil, err
+	}
+
+	// Park calls of sensitive tools until an approver decides; dry runs call nothing
+	if !dryRun {
+		if err := h.awaitApproval(ctx, toolName, params, sessionCtx); err != nil {
+			return nil, err
 		}
-		argumentsJSON = string(argBytes)
 	}
 
-	h.logger.Debug("Invoking tool",
-		zap.String("toolName", toolName),
-		zap.String("arguments", argumentsJSON),
-		zap.String("sessionId", sessionCtx.ID))
+	// Tools provided by the gateway itself need no backend
+	if builtin, ok := h.findBuiltinTool(toolName); ok {
+		if dryRun {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Dry runs are not supported for gateway tools")
+		}
+		if selected != nil {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("%s is not supported for gateway tools", selectArgument))
+		}
+		if pages > 0 {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("%s is not supported for gateway tools", pagesArgument))
+		}
+		if callHeaders != nil {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("%s is not supported for gateway tools", headersArgument))
+		}
+		return h.callBuiltinTool(ctx, builtin, params, sessionCtx)
+	}
+
+	// Tools re-exported from downstream MCP servers are proxied as they are
+	if h.upstreams.Owns(toolName) {
+		if err := h.checkUpstreamCollision(toolName); err != nil {
+			return nil, err
+		}
+		if dryRun {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Dry runs are not supported for upstream tools")
+		}
+		if selected != nil {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("%s is not supported for upstream tools", selectArgument))
+		}
+		if pages > 0 {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("%s is not supported for upstream tools", pagesArgument))
+		}
+		if callHeaders != nil {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+				fmt.Sprintf("%s is not supported for upstream tools", headersArgument))
+		}
+		return h.callUpstreamTool(ctx, toolName, params, sessionCtx)
+	}
+
+	// Send the configured _meta entries as metadata along with the call's own headers
+	callHeaders = h.metaHeaders(params, callHeaders)
+
+	// Follow next_page_token when the client asked for several pages
+	var paging *paginatedMethod
+	if pages > 0 {
+		if paging, err = h.paginationFor(toolName); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply request transformations before the arguments reach protojson,
+	// with the client's roots available to path hooks
+	argumentsJSON, err = h.transforms.ApplyContext(h.withClientRoots(ctx, sessionCtx),
+		toolName, transform.StageRequest, argumentsJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	// Check and normalize the arguments against the method's input message
+	argumentsJSON, err = h.checkArguments(toolName, argumentsJSON, sessionCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop before the backend and show the request the call would send
+	if dryRun {
+		return h.dryRunResult(toolName, argumentsJSON, h.forwardedHeaders(sessionCtx, callHeaders))
+	}
+
+	// Identify the call, including a sampled follow-up, in the gRPC client's logs
+	callInfo := grpc.CallInfo{
+		CallID:    newLogID(),
+		RequestID: requestIDFromContext(ctx),
+		SessionID: sessionCtx.ID,
+	}
+	ctx = grpc.WithCallInfo(ctx, callInfo)
+
+	// Correlate every entry logged for the call, down to the reflection client
+	ctx = logging.WithFields(ctx, append(callInfo.LogFields(), zap.String("toolName", toolName))...)
+	if h.usage != nil {
+		clientName, origin := h.usage.labels(sessionCtx)
+		ctx = logging.WithFields(ctx, zap.String("clientName", clientName), zap.String("origin", origin))
+	}
+	logger := logging.FromContext(ctx, h.logger)
+
+	logger.Debug("Invoking tool", zap.String("arguments", argumentsJSON))
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	invokeCtx, cancel := context.WithTimeout(ctx, toolCallTimeout)
 	defer cancel()
 
-	// Filter headers for forwarding
-	filteredHeaders := h.headerFilter.FilterHeaders(sessionCtx.Headers)
+	// Filter headers for forwarding and add the call's own headers
+	filteredHeaders := h.forwardedHeaders(sessionCtx, callHeaders)
 
-	h.logger.Debug("Filtered headers for forwarding",
-		zap.String("toolName", toolName),
+	logger.Debug("Filtered headers for forwarding",
 		zap.Any("originalHeaders", sessionCtx.Headers),
 		zap.Any("filteredHeaders", filteredHeaders))
 
 	// Invoke the gRPC method by tool name with filtered headers
-	result, err := h.serviceDiscoverer.InvokeMethodByTool(ctx, filteredHeaders, toolName, argumentsJSON)
+	start := time.Now()
+	var pageInfo pageSummary
+	result, err := h.chaos.invoke(invokeCtx, toolName, func(ctx context.Context) (string, error) {
+		if paging != nil {
+			paged, summary, err := paging.fetch(argumentsJSON, pages, func(argumentsJSON string) (string, error) {
+				return h.serviceDiscoverer.InvokeMethodByTool(ctx, filteredHeaders, toolName, argumentsJSON)
+			})
+			pageInfo = summary
+			return paged, err
+		}
+		return h.serviceDiscoverer.InvokeMethodByTool(ctx, filteredHeaders, toolName, argumentsJSON)
+	})
+	if err == nil {
+		// Long-running operations are waited for or returned as pending
+		result, err = h.resolveOperation(ctx, toolName, result, filteredHeaders)
+	}
+	if err == nil {
+		// Sampled tools continue with a follow-up call once the client's LLM has answered
+		result, err = h.completeWithSampling(ctx, toolName, result, filteredHeaders, sessionCtx)
+	}
+	elapsed := time.Since(start)
+	slowThreshold := h.slowCalls.observe(ctx, toolName, argumentsJSON, elapsed, err)
+
+	if h.quota != nil {
+		h.quota.RecordBytes(subject, int64(len(argumentsJSON)+len(result)))
+	}
+
 	if err != nil {
-		return &mcp.ToolCallResult{
-			Content: []mcp.ContentBlock{
-				mcp.TextContent(fmt.Sprintf("Error invoking method: %s", mcp.SanitizeError(err))),
-			},
+		content := []mcp.ContentBlock{
+			mcp.TextContent(fmt.Sprintf("Error invoking method: %s", mcp.SanitizeError(err))),
+		}
+		if h.errorEnvelope {
+			content[0] = mcp.TextContent(errorEnvelope(err))
+		}
+
+		// Add curated guidance for known backend error reasons
+		if guidance := h.errorCatalog.Describe(err); guidance != "" {
+			content = append(content, mcp.TextContent(guidance))
+		}
+
+		toolResult := &mcp.ToolCallResult{
+			Content: content,
 			IsError: true,
-		}, nil
+		}
+		h.annotateToolCallResult(toolResult, elapsed, slowThreshold, err)
+		return toolResult, nil
 	}
 
 	// Update session context
 	sessionCtx.IncrementCallCount()
 	sessionCtx.UpdateLastAccessed()
 
-	return &mcp.ToolCallResult{
-		Content: []mcp.ContentBlock{
-			mcp.TextContent(result),
-		},
+	// Apply response transformations
+	result, err = h.transforms.ApplyContext(h.withClientRoots(ctx, sessionCtx),
+		toolName, transform.StageResponse, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform response: %w", err)
+	}
+
+	// Move media and large bytes fields out of the text, then keep it within the tool's budget
+	result, mediaBlocks := h.extractMediaFields(toolName, result)
+	result, binaryLinks := h.extractBinaryFields(toolName, result, sessionCtx)
+
+	// Keep only the part of the response the client selected
+	selectedFrom := len(result)
+	if selected != nil {
+		projected, err := selected.apply(result)
+		if err != nil {
+			toolResult := &mcp.ToolCallResult{
+				Content: []mcp.ContentBlock{mcp.TextContent(fmt.Sprintf("Failed to apply %s: %v", selectArgument, err))},
+				IsError: true,
+			}
+			h.annotateToolCallResult(toolResult, elapsed, slowThreshold, nil)
+			return toolResult, nil
+		}
+		result = projected
+	}
+
+	content, truncated := h.limitResponse(toolName, result, sessionCtx)
+	content = append(content, mediaBlocks...)
+	content = append(content, binaryLinks...)
+
+	toolResult := &mcp.ToolCallResult{
+		Content: content,
 		IsError: false,
-	}, nil
+	}
+	h.annotateToolCallResult(toolResult, elapsed, slowThreshold, nil)
+	if paging != nil {
+		toolResult.SetMeta(mcp.MetaKeyPagesFetched, pageInfo.fetched)
+		toolResult.SetMeta(mcp.MetaKeyMorePages, pageInfo.more)
+	}
+	if selected != nil {
+		toolResult.SetMeta(mcp.MetaKeySelectedFrom, selectedFrom)
+	}
+	if truncated {
+		toolResult.SetMeta(mcp.MetaKeyTruncated, true)
+		toolResult.SetMeta(mcp.MetaKeyOriginalBytes, len(result))
+	}
+	return toolResult, nil
+}
+
+// policyCredentialHeaders are left out of the policy input even when they
+// are forwarded; policies see the verified token claims instead
+var policyCredentialHeaders = []string{"authorization", "proxy-authorization", "cookie"}
+
+// policyHeaders returns the session headers forwarded to the backend, without credentials
+func (h *Handler) policyHeaders(sessionCtx *session.Context) map[string]string {
+	headers := h.headerFilter.FilterHeaders(sessionCtx.Headers)
+	for name := range headers {
+		if slices.Contains(policyCredentialHeaders, strings.ToLower(name)) {
+			delete(headers, name)
+		}
+	}
+	return headers
+}
+
+// authorizeToolCall asks the policy engine whether the session may call the tool
+func (h *Handler) authorizeToolCall(ctx context.Context, toolName string, params map[string]interface{}, sessionCtx *session.Context) error {
+	if h.policy == nil {
+		return nil
+	}
+
+	arguments, _ := params["arguments"].(map[string]interface{})
+	input := policy.Input{
+		Session: policy.SessionInput{
+			ID:         sessionCtx.ID,
+			UserAgent:  sessionCtx.UserAgent,
+			RemoteAddr: sessionCtx.RemoteAddr,
+			CallCount:  sessionCtx.GetCallCount(),
+		},
+		Tool:      toolName,
+		Arguments: arguments,
+		Headers:   h.policyHeaders(sessionCtx),
+	}
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		input.Claims = claims
+	}
+
+	decision, err := h.policy.Evaluate(ctx, input)
+	if err != nil {
+		if h.policyConfig.FailOpen {
+			h.logger.Warn("Policy evaluation failed, allowing call (fail open)",
+				zap.String("toolName", toolName),
+				zap.Error(err))
+			return nil
+		}
+		h.logger.Error("Policy evaluation failed, denying call",
+			zap.String("toolName", toolName),
+			zap.Error(err))
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, "Permission denied: policy evaluation failed")
+	}
+
+	if !decision.Allow {
+		h.logger.Info("Tool call denied by policy",
+			zap.String("toolName", toolName),
+			zap.String("sessionId", sessionCtx.ID),
+			zap.String("reason", decision.Reason))
+
+		message := "Permission denied"
+		if decision.Reason != "" {
+			message = fmt.Sprintf("Permission denied: %s", decision.Reason)
+		}
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, message)
+	}
+
+	return nil
+}
+
+// quotaSubject identifies who the call is accounted to: the API key if it is
+// listed, otherwise the shared anonymous quota. The subject of a verified
+// token identifies the caller for the session cost limit.
+func (h *Handler) quotaSubject(ctx context.Context, sessionCtx *session.Context) quota.Subject {
+	if h.quota == nil {
+		return quota.Subject{}
+	}
+	apiKey := sessionCtx.GetHeader(http.CanonicalHeaderKey(h.quota.KeyHeader()))
+	subject := h.quota.Subject(apiKey, sessionCtx.ID)
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		subject.Principal = claims.Subject()
+	}
+	return subject
+}
+
+// releaseQuota gives back the reservation of a call that did not go through
+func (h *Handler) releaseQuota(reservation quota.Reservation) {
+	if h.quota != nil {
+		h.quota.Release(reservation)
+	}
+}
+
+// reserveQuota accounts a call against the subject's quota
+func (h *Handler) reserveQuota(subject quota.Subject, toolName string) (quota.Reservation, error) {
+	if h.quota == nil {
+		return quota.Reservation{}, nil
+	}
+
+	reservation, err := h.quota.Reserve(subject, toolName)
+	if err != nil {
+		h.logger.Info("Tool call rejected by quota",
+			zap.String("toolName", toolName),
+			zap.Bool("apiKey", subject.IsAPIKey),
+			zap.Error(err))
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeQuotaExceeded, fmt.Sprintf("Quota exceeded: %s", err.Error()))
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			rpcErr.Data = quotaErrorData(exceeded)
+		}
+		return quota.Reservation{}, rpcErr
+	}
+
+	return reservation, nil
+}
+
+// annotateToolCallResult attaches timing and upstream status to the result's _meta block.
+// A non-zero slowThreshold warns the client that the call exceeded it.
+func (h *Handler) annotateToolCallResult(result *mcp.ToolCallResult, elapsed, slowThreshold time.Duration, err error) {
+	result.SetMeta(mcp.MetaKeyElapsedMs, elapsed.Milliseconds())
+	result.SetMeta(mcp.MetaKeyTruncated, false)
+	if slowThreshold > 0 {
+		result.SetMeta(mcp.MetaKeySlowThresholdMs, slowThreshold.Milliseconds())
+	}
+
+	// Only report an upstream status when the error came from the backend
+	if err == nil {
+		result.SetMeta(mcp.MetaKeyUpstreamStatus, codes.OK.String())
+	} else if st, ok := status.FromError(err); ok {
+		result.SetMeta(mcp.MetaKeyUpstreamStatus, st.Code().String())
+	}
 }
 
 // handlePromptsList handles the prompts/list method
@@ -279,18 +1152,74 @@ func (h *Handler) handlePromptsList(ctx context.Context) (interface{}, error) {
 }
 
 // handleResourcesList handles the resources/list method
-func (h *Handler) handleResourcesList(ctx context.Context) (interface{}, error) {
-	// Return empty resources list since this implementation focuses on tools
-	return map[string]interface{}{
-		"resources": []interface{}{},
+func (h *Handler) handleResourcesList(ctx context.Context, sessionCtx *session.Context) (*mcp.ResourcesListResult, error) {
+	// Only resources created by this session's tool calls are listed
+	stored := h.resources.List(sessionCtx.ID)
+
+	result := &mcp.ResourcesListResult{
+		Resources: make([]mcp.Resource, 0, len(stored)),
+	}
+	for _, resource := range stored {
+		result.Resources = append(result.Resources, mcp.Resource{
+			URI:      resource.URI,
+			Name:     resource.Name,
+			MimeType: resource.MimeType,
+			Size:     resource.Size,
+		})
+	}
+	result.Resources = append(result.Resources, h.descriptionResources()...)
+	result.Resources = append(result.Resources, h.serverInfoResources()...)
+	return result, nil
+}
+
+// handleResourcesRead handles the resources/read method
+func (h *Handler) handleResourcesRead(ctx context.Context, params map[string]interface{}, sessionCtx *session.Context) (*mcp.ResourcesReadResult, error) {
+	uri, _ := params["uri"].(string)
+	if uri == "" {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid params: uri is required")
+	}
+	if strings.HasPrefix(uri, descriptionURIPrefix) {
+		return h.readDescriptionResource(uri)
+	}
+	if uri == serverInfoURI {
+		return h.readServerInfoResource()
+	}
+
+	resource, data, ok := h.resources.Get(sessionCtx.ID, uri)
+	if !ok {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeResourceNotFound, "Resource not found")
+	}
+
+	contents := mcp.ResourceContents{
+		URI:      resource.URI,
+		MimeType: resource.MimeType,
+	}
+	if isTextMimeType(resource.MimeType) {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return &mcp.ResourcesReadResult{
+		Contents: []mcp.ResourceContents{contents},
 	}, nil
 }
 
+// isTextMimeType reports whether resource contents of the MIME type are returned as text
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || mimeType == "application/json"
+}
+
 // writeJSONResponse writes a JSON response
 func (h *Handler) writeJSONResponse(w http.ResponseWriter, response interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
+		// The response may be partially written; a second write cannot help a slow client
+		if isWriteDeadlineError(err) {
+			h.logWriteError(err)
+			return
+		}
 		h.logger.Error("Failed to encode JSON response", zap.Error(err))
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
@@ -298,13 +1227,15 @@ func (h *Handler) writeJSONResponse(w http.ResponseWriter, response interface{})
 
 // writeErrorResponse writes an error response
 func (h *Handler) writeErrorResponse(w http.ResponseWriter, id mcp.RequestID, code int, message string) {
+	h.writeRPCError(w, id, mcp.NewRPCError(code, message))
+}
+
+// writeRPCError writes an error response carrying the given error
+func (h *Handler) writeRPCError(w http.ResponseWriter, id mcp.RequestID, rpcErr *mcp.RPCError) {
 	response := &mcp.JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
-		Error: &mcp.RPCError{
-			Code:    code,
-			Message: message,
-		},
+		Error:   rpcErr,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -356,6 +1287,27 @@ func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 		"timestamp":    time.Now().UTC().Format(time.RFC3339),
 		"serviceCount": stats["serviceCount"],
 		"methodCount":  h.serviceDiscoverer.GetMethodCount(),
+		"version":      version.Get().Version,
+	}
+	if info := h.serviceDiscoverer.ServerInfo(); info != nil {
+		healthInfo["backend"] = info
+	}
+
+	// Tools of the current discovery that failed to build do not fail the
+	// check, but are reported so they are not silently missing
+	if failures := h.toolBuilder.SchemaFailures(); len(failures) > 0 {
+		healthInfo["status"] = "degraded"
+		healthInfo["schemaFailures"] = failures
+	}
+
+	// Unreachable downstream MCP servers only remove their own tools
+	if statuses := h.upstreams.Status(); len(statuses) > 0 {
+		healthInfo["upstreams"] = statuses
+		for _, status := range statuses {
+			if !status.Connected && status.LastError != "" {
+				healthInfo["status"] = "degraded"
+			}
+		}
 	}
 
 	if err := json.NewEncoder(w).Encode(healthInfo); err != nil {
@@ -366,6 +1318,50 @@ func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 // MetricsHandler handles metrics requests
 func (h *Handler) MetricsHandler(w http.ResponseWriter, r *http.Request) {
 	stats := h.serviceDiscoverer.GetServiceStats()
+	if h.quota != nil {
+		stats["quota"] = h.quota.Stats()
+	}
+	stats["sessions"] = h.sessionManager.GetSessionStats()
+	stats["resources"] = h.resources.Stats()
+	stats["recovery"] = h.recovery.stats()
+	stats["disconnects"] = h.disconnects.stats()
+	stats["schemas"] = schemaStats(h.toolBuilder.SchemaFailures())
+	if statuses := h.upstreams.Status(); len(statuses) > 0 {
+		stats["upstreams"] = statuses
+	}
+	if h.affinity != nil {
+		stats["affinity"] = h.affinity.stats()
+	}
+	if h.chaos != nil {
+		stats["chaos"] = h.chaos.stats()
+	}
+	if h.replay != nil {
+		stats["replay"] = h.replay.stats()
+	}
+	if h.toolStats != nil {
+		stats["toolStats"] = h.toolStats.stats()
+	}
+	if h.slowCalls != nil {
+		stats["slowCalls"] = h.slowCalls.stats()
+	}
+	if h.usage != nil {
+		stats["usage"] = h.usage.stats()
+	}
+	if webhookStats := h.webhooks.Stats(); len(webhookStats) > 0 {
+		stats["webhooks"] = webhookStats
+	}
+	if eventStats := h.events.Stats(); eventStats != nil {
+		stats["events"] = eventStats
+	}
+	if historyStats := h.history.Stats(); historyStats != nil {
+		stats["history"] = historyStats
+	}
+	if approvalStats := h.approvals.Stats(); approvalStats != nil {
+		stats["approvals"] = approvalStats
+	}
+	if state := h.maintenance.current(); state.Enabled {
+		stats["maintenance"] = state
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -375,6 +1371,55 @@ func (h *Handler) MetricsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// schemaStats summarizes the schema failures of the last tools/list
+func schemaStats(failures []tools.SchemaFailure) map[string]interface{} {
+	degraded := 0
+	for _, failure := range failures {
+		if failure.Degraded {
+			degraded++
+		}
+	}
+	return map[string]interface{}{
+		"failures": len(failures),
+		"degraded": degraded,
+	}
+}
+
+// UsageHandler reports quota usage for the caller's listed API key. Callers
+// without one see the shared anonymous usage when they pass JWT
+// authentication, and are rejected otherwise.
+func (h *Handler) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	if h.quota == nil {
+		http.Error(w, "Quota accounting is not enabled", http.StatusNotFound)
+		return
+	}
+
+	r, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// Without a listed key only authenticated callers may read the shared
+	// anonymous usage
+	apiKey := r.Header.Get(h.quota.KeyHeader())
+	if apiKey != "" && !h.quota.KnowsKey(apiKey) {
+		http.Error(w, "Unknown API key", http.StatusUnauthorized)
+		return
+	}
+	if apiKey == "" && h.jwt == nil {
+		http.Error(w, "Missing API key", http.StatusUnauthorized)
+		return
+	}
+	subject := h.quota.Subject(apiKey, r.Header.Get("Mcp-Session-Id"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(h.quota.Usage(subject)); err != nil {
+		h.logger.Error("Failed to encode usage", zap.Error(err))
+	}
+}
+
 // HandleToolsCall handles tool calls directly (for testing)
 func (h *Handler) HandleToolsCall(ctx context.Context, params map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
 	return h.handleToolsCall(ctx, params, sessionCtx)
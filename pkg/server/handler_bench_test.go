@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/benchutil"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"go.uber.org/zap"
+	grpcLib "google.golang.org/grpc"
+)
+
+// bufconnConnectionManager serves a fixed in-memory connection
+type bufconnConnectionManager struct {
+	conn *grpcLib.ClientConn
+}
+
+func (m *bufconnConnectionManager) Connect(ctx context.Context) error     { return nil }
+func (m *bufconnConnectionManager) GetConnection() *grpcLib.ClientConn    { return m.conn }
+func (m *bufconnConnectionManager) IsConnected() bool                     { return true }
+func (m *bufconnConnectionManager) Reconnect(ctx context.Context) error   { return nil }
+func (m *bufconnConnectionManager) HealthCheck(ctx context.Context) error { return nil }
+func (m *bufconnConnectionManager) Close() error                          { return nil }
+
+// newBenchHandler creates a handler backed by an in-memory echo backend discovered via reflection
+func newBenchHandler(b *testing.B) (*Handler, string) {
+	logger := zap.NewNop()
+
+	files, err := benchutil.Files(5, 5, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	conn, stop, err := benchutil.StartBackend(files)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(stop)
+
+	discoverer := grpc.NewServiceDiscovererWithConnManager(&bufconnConnectionManager{conn: conn}, logger)
+	if err := discoverer.Connect(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+	if err := discoverer.DiscoverServices(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+
+	sessionManager := session.NewManager(logger)
+	b.Cleanup(func() { _ = sessionManager.Close() })
+
+	handler := NewHandlerWithConfig(logger, discoverer, sessionManager, tools.NewMCPToolBuilder(logger), config.Default())
+	return handler, benchutil.Methods(files)[0].ToolName
+}
+
+func BenchmarkHandler_ToolsCall(b *testing.B) {
+	handler, toolName := newBenchHandler(b)
+
+	var arguments map[string]interface{}
+	if err := json.Unmarshal([]byte(benchutil.SampleRequestJSON(20)), &arguments); err != nil {
+		b.Fatal(err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": toolName, "arguments": arguments},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK || bytes.Contains(rec.Body.Bytes(), []byte(`"error"`)) {
+				b.Fatalf("tool call failed: %s", rec.Body.String())
+			}
+		}
+	})
+}
+
+func BenchmarkHandler_ToolsList(b *testing.B) {
+	handler, _ := newBenchHandler(b)
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("tools/list failed: %s", rec.Body.String())
+		}
+	}
+}
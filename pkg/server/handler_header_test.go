@@ -67,6 +67,11 @@ func (m *mockServiceDiscoverer) Close() error {
 	return args.Error(0)
 }
 
+func (m *mockServiceDiscoverer) GetMethodByTool(toolName string) (types.MethodInfo, bool) {
+	args := m.Called(toolName)
+	return args.Get(0).(types.MethodInfo), args.Bool(1)
+}
+
 func (m *mockServiceDiscoverer) GetMethodCount() int {
 	args := m.Called()
 	return args.Int(0)
@@ -77,6 +82,40 @@ func (m *mockServiceDiscoverer) GetServiceStats() map[string]interface{} {
 	return args.Get(0).(map[string]interface{})
 }
 
+func (m *mockServiceDiscoverer) DiscoveryIssues() []grpc.DiscoveryIssue {
+	args := m.Called()
+	return args.Get(0).([]grpc.DiscoveryIssue)
+}
+
+func (m *mockServiceDiscoverer) ServerInfo() *grpc.ServerInfo {
+	// initialize looks the server info up; tests not about it need not mock it
+	if !m.expects("ServerInfo") {
+		return nil
+	}
+	args := m.Called()
+	info, _ := args.Get(0).(*grpc.ServerInfo)
+	return info
+}
+
+func (m *mockServiceDiscoverer) Channelz(ctx context.Context, queryBackend bool) (*grpc.ChannelzReport, error) {
+	args := m.Called(ctx, queryBackend)
+	report, _ := args.Get(0).(*grpc.ChannelzReport)
+	return report, args.Error(1)
+}
+
+func (m *mockServiceDiscoverer) GetOperation(ctx context.Context, headers map[string]string, name string) (string, error) {
+	args := m.Called(ctx, headers, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockServiceDiscoverer) OnDiscoveryChange(listener func(grpc.DiscoveryChange)) {
+	m.Called(listener)
+}
+
+func (m *mockServiceDiscoverer) OnDiscovery(listener func([]types.MethodInfo)) {
+	m.Called(listener)
+}
+
 func TestHandler_HeaderFilteringAndForwarding(t *testing.T) {
 	// Create logger
 	logger := zap.NewNop()
@@ -133,12 +172,12 @@ func TestHandler_HeaderFilteringAndForwarding(t *testing.T) {
 		JSONRPC: "2.0",
 		ID:      mcp.RequestID{Value: 1},
 		Method:  "tools/call",
-		Params: map[string]interface{}{
+		Params: rawParams(t, map[string]interface{}{
 			"name": "test_service_testmethod",
 			"arguments": map[string]interface{}{
 				"input": "test",
 			},
-		},
+		}),
 	}
 
 	bodyBytes, err := json.Marshal(requestBody)
@@ -216,12 +255,12 @@ func TestHandler_HeaderFilteringDisabled(t *testing.T) {
 		JSONRPC: "2.0",
 		ID:      mcp.RequestID{Value: 1},
 		Method:  "tools/call",
-		Params: map[string]interface{}{
+		Params: rawParams(t, map[string]interface{}{
 			"name": "test_service_testmethod",
 			"arguments": map[string]interface{}{
 				"input": "test",
 			},
-		},
+		}),
 	}
 
 	bodyBytes, err := json.Marshal(requestBody)
@@ -305,12 +344,12 @@ func TestHandler_HeaderFilteringForwardAll(t *testing.T) {
 		JSONRPC: "2.0",
 		ID:      mcp.RequestID{Value: 1},
 		Method:  "tools/call",
-		Params: map[string]interface{}{
+		Params: rawParams(t, map[string]interface{}{
 			"name": "test_service_testmethod",
 			"arguments": map[string]interface{}{
 				"input": "test",
 			},
-		},
+		}),
 	}
 
 	bodyBytes, err := json.Marshal(requestBody)
@@ -391,12 +430,12 @@ func TestHandler_HeaderFilteringCaseSensitive(t *testing.T) {
 		JSONRPC: "2.0",
 		ID:      mcp.RequestID{Value: 1},
 		Method:  "tools/call",
-		Params: map[string]interface{}{
+		Params: rawParams(t, map[string]interface{}{
 			"name": "test_service_testmethod",
 			"arguments": map[string]interface{}{
 				"input": "test",
 			},
-		},
+		}),
 	}
 
 	bodyBytes, err := json.Marshal(requestBody)
@@ -425,3 +464,13 @@ func TestHandler_HeaderFilteringCaseSensitive(t *testing.T) {
 	// Verify mock expectations
 	mockDiscoverer.AssertExpectations(t)
 }
+
+// expects reports whether the test set an expectation for a method
+func (m *mockServiceDiscoverer) expects(method string) bool {
+	for _, call := range m.ExpectedCalls {
+		if call.Method == method {
+			return true
+		}
+	}
+	return false
+}
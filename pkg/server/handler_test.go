@@ -0,0 +1,699 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/auth"
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/policy"
+	"github.com/aalobaidi/ggRMCP/pkg/quota"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/tools"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// newTestHandler creates a handler backed by a mock discoverer using the given config
+func newTestHandler(t testing.TB, cfg *config.Config) (*Handler, *mockServiceDiscoverer, *session.Context) {
+	logger := zap.NewNop()
+	mockDiscoverer := &mockServiceDiscoverer{}
+
+	sessionManager := session.NewManager(logger)
+	t.Cleanup(func() { _ = sessionManager.Close() })
+
+	handler := NewHandlerWithConfig(logger, mockDiscoverer, sessionManager, tools.NewMCPToolBuilder(logger), cfg)
+	sessionCtx := sessionManager.CreateSession(map[string]string{})
+
+	return handler, mockDiscoverer, sessionCtx
+}
+
+// rawParams encodes request params for tests building JSON-RPC requests
+func rawParams(t *testing.T, params map[string]interface{}) json.RawMessage {
+	encoded, err := json.Marshal(params)
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestHandler_ToolCallResultMeta(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+			Return(`{"output":"success"}`, nil)
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "test_service_testmethod",
+		}, sessionCtx)
+		require.NoError(t, err)
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "OK", result.Meta[mcp.MetaKeyUpstreamStatus])
+		assert.Contains(t, result.Meta, mcp.MetaKeyElapsedMs)
+		assert.Equal(t, false, result.Meta[mcp.MetaKeyTruncated])
+	})
+
+	t.Run("Upstream_error", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		upstreamErr := fmt.Errorf("failed to invoke method: %w", status.Error(codes.NotFound, "missing"))
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+			Return("", upstreamErr)
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "test_service_testmethod",
+		}, sessionCtx)
+		require.NoError(t, err)
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, "NotFound", result.Meta[mcp.MetaKeyUpstreamStatus])
+	})
+
+	t.Run("Gateway_error_has_no_upstream_status", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+			Return("", fmt.Errorf("tool test_service_testmethod not found"))
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "test_service_testmethod",
+		}, sessionCtx)
+		require.NoError(t, err)
+
+		assert.True(t, result.IsError)
+		assert.NotContains(t, result.Meta, mcp.MetaKeyUpstreamStatus)
+	})
+}
+
+func TestHandler_ArgumentNumberPrecision(t *testing.T) {
+	handler, mockDiscoverer, _ := newTestHandler(t, config.Default())
+
+	// Beyond 2^53, and more digits than a float64 round trip keeps. The
+	// client's bytes are passed on, keys in their order and spacing kept.
+	arguments := `{"id": 9007199254740993, "amount":0.30000000000000004441,"ids":[-9223372036854775808,18446744073709551615]}`
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", arguments).
+		Return(`{}`, nil).Once()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"shop_orders_get","arguments":` + arguments + `}}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"error"`)
+	mockDiscoverer.AssertExpectations(t)
+
+	// Gateway arguments read from exact numbers
+	pages, ok := integerArgument(json.Number("3"), 1, 5)
+	assert.True(t, ok)
+	assert.Equal(t, 3, pages)
+	_, ok = integerArgument(json.Number("2.5"), 1, 5)
+	assert.False(t, ok)
+}
+
+func TestHandler_RawParams(t *testing.T) {
+	handler, _, _ := newTestHandler(t, config.Default())
+	post := func(t *testing.T, body string) mcp.JSONRPCResponse {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var response mcp.JSONRPCResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("Mistyped_params", func(t *testing.T) {
+		response := post(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":7}}`)
+		require.NotNil(t, response.Error)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, response.Error.Code)
+	})
+
+	t.Run("Params_not_an_object", func(t *testing.T) {
+		response := post(t, `{"jsonrpc":"2.0","id":1,"method":"ping","params":[1,2]}`)
+		require.NotNil(t, response.Error)
+		assert.Equal(t, mcp.ErrorCodeInvalidRequest, response.Error.Code)
+	})
+
+	t.Run("Nesting_checked_without_decoding", func(t *testing.T) {
+		deep := `{"name":"a_b","arguments":` + strings.Repeat(`{"x":`, 10) + `1` + strings.Repeat(`}`, 10) + `}`
+		response := post(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+deep+`}`)
+		require.NotNil(t, response.Error)
+		assert.Equal(t, mcp.ErrorCodeInvalidRequest, response.Error.Code)
+	})
+
+	t.Run("Typed_initialize", func(t *testing.T) {
+		sessionCtx := handler.sessionManager.CreateSession(nil)
+		req := &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      mcp.RequestID{Value: 1},
+			Method:  "initialize",
+			Params: rawParams(t, map[string]interface{}{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]interface{}{"roots": map[string]interface{}{}},
+				"clientInfo":      map[string]interface{}{"name": "orders-agent", "version": "1.4.0"},
+			}),
+		}
+		_, err := handler.handleRequest(context.Background(), req, sessionCtx)
+		require.NoError(t, err)
+		assert.True(t, sessionCtx.RootsSupported())
+		assert.False(t, sessionCtx.SamplingSupported())
+		name, version := sessionCtx.ClientInfo()
+		assert.Equal(t, "orders-agent", name)
+		assert.Equal(t, "1.4.0", version)
+	})
+}
+
+func TestHandler_CallLogFields(t *testing.T) {
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+
+	var fields map[string]interface{}
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", "").
+		Run(func(args mock.Arguments) {
+			encoder := zapcore.NewMapObjectEncoder()
+			for _, field := range logging.Fields(args.Get(0).(context.Context)) {
+				field.AddTo(encoder)
+			}
+			fields = encoder.Fields
+		}).
+		Return(`{}`, nil).Once()
+
+	ctx := withRequestID(context.Background(), "req-1")
+	_, err := handler.HandleToolsCall(ctx, map[string]interface{}{"name": "shop_orders_get"}, sessionCtx)
+	require.NoError(t, err)
+
+	// The discoverer's loggers correlate their entries with the call
+	assert.Equal(t, "req-1", fields["requestId"])
+	assert.Equal(t, sessionCtx.ID, fields["sessionId"])
+	assert.Equal(t, "shop_orders_get", fields["toolName"])
+	assert.NotEmpty(t, fields["callId"])
+}
+
+// tokenSessions is a stateless session store: the session ID is the
+// client's token and nothing is kept between requests
+type tokenSessions struct {
+	created int
+}
+
+var _ session.Manager = (*tokenSessions)(nil)
+
+func (s *tokenSessions) GetOrCreateSession(sessionID string, headers map[string]string) *session.Context {
+	if sessionID == "" {
+		return s.CreateSession(headers)
+	}
+	return &session.Context{ID: sessionID, Headers: headers}
+}
+
+func (s *tokenSessions) CreateSession(headers map[string]string) *session.Context {
+	s.created++
+	return &session.Context{ID: fmt.Sprintf("token-%d", s.created), Headers: headers}
+}
+
+func (s *tokenSessions) GetSession(sessionID string) (*session.Context, bool) {
+	return &session.Context{ID: sessionID}, true
+}
+
+func (s *tokenSessions) DeleteSession(sessionID string) {}
+
+func (s *tokenSessions) Export() []session.Snapshot { return nil }
+
+func (s *tokenSessions) Import(snapshots []session.Snapshot) session.ImportResult {
+	return session.ImportResult{}
+}
+
+func (s *tokenSessions) GetSessionStats() map[string]interface{} {
+	return map[string]interface{}{"created": s.created}
+}
+
+func (s *tokenSessions) Close() error { return nil }
+
+func TestHandler_SessionStore(t *testing.T) {
+	logger := zap.NewNop()
+	mockDiscoverer := &mockServiceDiscoverer{}
+	mockDiscoverer.On("GetServiceStats").Return(map[string]interface{}{})
+	sessions := &tokenSessions{}
+	handler := NewHandlerWithConfig(logger, mockDiscoverer, sessions, tools.NewMCPToolBuilder(logger), config.Default())
+
+	post := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, "token-1", post("").Header().Get("Mcp-Session-Id"))
+	assert.Equal(t, "token-1", post("token-1").Header().Get("Mcp-Session-Id"))
+	assert.Equal(t, 1, sessions.created)
+
+	rec := httptest.NewRecorder()
+	handler.MetricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	var metrics map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metrics))
+	assert.Equal(t, map[string]interface{}{"created": float64(1)}, metrics["sessions"])
+}
+
+func TestHandler_ToolsCallBatch(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Batch.Enabled = true
+	cfg.MCP.Batch.MaxItems = 3
+	cfg.MCP.Batch.Concurrency = 2
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_first", "").
+		Return(`{"output":"first"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_second", "").
+		Return(`{"output":"second"}`, nil)
+
+	req := &mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      mcp.RequestID{Value: 1},
+		Method:  "tools/call_batch",
+		Params: rawParams(t, map[string]interface{}{
+			"calls": []interface{}{
+				map[string]interface{}{"name": "test_service_first"},
+				map[string]interface{}{"name": "test_service_second"},
+				map[string]interface{}{"name": "invalid name!"},
+			},
+		}),
+	}
+
+	result, err := handler.handleRequest(context.Background(), req, sessionCtx)
+	require.NoError(t, err)
+
+	batch, ok := result.(*mcp.ToolCallBatchResult)
+	require.True(t, ok)
+	require.Len(t, batch.Results, 3)
+
+	assert.Equal(t, `{"output":"first"}`, batch.Results[0].Result.Content[0].Text)
+	assert.Equal(t, `{"output":"second"}`, batch.Results[1].Result.Content[0].Text)
+	assert.Nil(t, batch.Results[2].Result)
+	assert.Equal(t, mcp.ErrorCodeInvalidParams, batch.Results[2].Error.Code)
+
+	t.Run("Too_many_calls", func(t *testing.T) {
+		req.Params = rawParams(t, map[string]interface{}{
+			"calls": []interface{}{
+				map[string]interface{}{"name": "a_b"},
+				map[string]interface{}{"name": "a_b"},
+				map[string]interface{}{"name": "a_b"},
+				map[string]interface{}{"name": "a_b"},
+			},
+		})
+		_, err := handler.handleRequest(context.Background(), req, sessionCtx)
+		assert.Error(t, err)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		disabledHandler, _, sessionCtx := newTestHandler(t, config.Default())
+		_, err := disabledHandler.handleRequest(context.Background(), req, sessionCtx)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeMethodNotFound, errorCodeFor(err))
+	})
+}
+
+type stubPolicyEvaluator struct {
+	decision policy.Decision
+	err      error
+	input    policy.Input
+}
+
+func (s *stubPolicyEvaluator) Evaluate(ctx context.Context, input policy.Input) (policy.Decision, error) {
+	s.input = input
+	return s.decision, s.err
+}
+
+func TestHandler_ToolsCallPolicy(t *testing.T) {
+	params := map[string]interface{}{
+		"name":      "test_service_testmethod",
+		"arguments": map[string]interface{}{"input": "test"},
+	}
+
+	t.Run("Allowed", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		evaluator := &stubPolicyEvaluator{decision: policy.Decision{Allow: true}}
+		handler.SetPolicyEvaluator(evaluator)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", `{"input":"test"}`).
+			Return(`{"output":"success"}`, nil)
+
+		result, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+		require.NoError(t, err)
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "test_service_testmethod", evaluator.input.Tool)
+		assert.Equal(t, sessionCtx.ID, evaluator.input.Session.ID)
+		assert.Equal(t, "test", evaluator.input.Arguments["input"])
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		handler.SetPolicyEvaluator(&stubPolicyEvaluator{
+			decision: policy.Decision{Allow: false, Reason: "tool not permitted"},
+		})
+
+		_, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+		require.Error(t, err)
+
+		code, message := errorResponseFor(err)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, code)
+		assert.Equal(t, "Permission denied: tool not permitted", message)
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Evaluation_error_fails_closed", func(t *testing.T) {
+		handler, _, sessionCtx := newTestHandler(t, config.Default())
+		handler.SetPolicyEvaluator(&stubPolicyEvaluator{err: fmt.Errorf("connection refused")})
+
+		_, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, errorCodeFor(err))
+	})
+
+	t.Run("Evaluation_error_fails_open", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Security.Policy.FailOpen = true
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+		handler.SetPolicyEvaluator(&stubPolicyEvaluator{err: fmt.Errorf("connection refused")})
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", `{"input":"test"}`).
+			Return(`{"output":"success"}`, nil)
+
+		result, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("Input_has_claims_and_no_credentials", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		evaluator := &stubPolicyEvaluator{decision: policy.Decision{Allow: true}}
+		handler.SetPolicyEvaluator(evaluator)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", `{"input":"test"}`).
+			Return(`{"output":"success"}`, nil)
+		sessionCtx.SetHeader("Authorization", "Bearer secret")
+		sessionCtx.SetHeader("Cookie", "session=secret")
+		sessionCtx.SetHeader("X-Trace-Id", "trace-1")
+		sessionCtx.SetHeader("X-Internal", "not forwarded")
+
+		ctx := auth.WithClaims(context.Background(), auth.Claims{"sub": "alice"})
+		_, err := handler.HandleToolsCall(ctx, params, sessionCtx)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"X-Trace-Id": "trace-1"}, evaluator.input.Headers)
+		assert.Equal(t, "alice", evaluator.input.Claims["sub"])
+	})
+}
+
+func TestHandler_ToolsCallQuota(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.Quota.Enabled = true
+	cfg.Server.Security.Quota.Keys = map[string]config.QuotaPlanConfig{
+		"key-1": {Daily: config.QuotaLimitConfig{Calls: 1}},
+	}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.SetHeader("X-Api-Key", "key-1")
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Return(`{"output":"success"}`, nil)
+
+	params := map[string]interface{}{"name": "test_service_testmethod"}
+
+	result, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	_, err = handler.HandleToolsCall(context.Background(), params, sessionCtx)
+	require.Error(t, err)
+	assert.Equal(t, mcp.ErrorCodeQuotaExceeded, errorCodeFor(err))
+	mockDiscoverer.AssertNumberOfCalls(t, "InvokeMethodByTool", 1)
+
+	// The usage endpoint reports consumption for the key
+	req := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	rec := httptest.NewRecorder()
+	handler.UsageHandler(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var usage quota.Usage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &usage))
+	assert.Equal(t, "api_key", usage.Subject)
+	assert.Equal(t, int64(1), usage.Daily.Calls)
+	assert.Equal(t, int64(len(`{"output":"success"}`)), usage.Daily.Bytes)
+	assert.Equal(t, int64(1), usage.Daily.CallsLimit)
+
+	// Unknown keys and anonymous callers cannot read usage without authentication
+	for _, apiKey := range []string{"made-up", ""} {
+		req = httptest.NewRequest(http.MethodGet, "/usage", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec = httptest.NewRecorder()
+		handler.UsageHandler(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, apiKey)
+	}
+}
+
+func TestHandler_ToolsCallQuotaRelease(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.Quota.Enabled = true
+	cfg.Server.Security.Quota.Daily.Calls = 1
+	cfg.MCP.Approval.Tools = []string{"bank_transfer"}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_failing", "").
+		Return("", status.Error(codes.Unavailable, "backend down"))
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Return(`{"output":"success"}`, nil)
+
+	// A failed upstream call gives its reservation back
+	result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "test_service_failing"}, sessionCtx)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	// So does a call the approver rejects
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "bank_transfer"}, sessionCtx)
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return len(handler.approvals.Pending()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	_, err = handler.approvals.Decide(handler.approvals.Pending()[0].ID, false, "bob", "")
+	require.NoError(t, err)
+	require.Error(t, <-done)
+
+	// The one call of the day is still available
+	_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "test_service_testmethod"}, sessionCtx)
+	require.NoError(t, err)
+	_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "test_service_testmethod"}, sessionCtx)
+	assert.Equal(t, mcp.ErrorCodeQuotaExceeded, errorCodeFor(err))
+	assert.Equal(t, int64(2), handler.quota.Stats()["released"])
+}
+
+func TestHandler_ToolsCallQuotaUnlistedKeys(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.Quota.Enabled = true
+	cfg.Server.Security.Quota.Daily.Calls = 1
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Return(`{"output":"success"}`, nil)
+	params := map[string]interface{}{"name": "test_service_testmethod"}
+
+	sessionCtx.SetHeader("X-Api-Key", "made-up-1")
+	_, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+	require.NoError(t, err)
+
+	// A different invented key in a different session lands in the same bucket
+	other := &session.Context{ID: "other-session", Headers: map[string]string{"X-Api-Key": "made-up-2"}}
+	_, err = handler.HandleToolsCall(context.Background(), params, other)
+	require.Error(t, err)
+	assert.Equal(t, mcp.ErrorCodeQuotaExceeded, errorCodeFor(err))
+}
+
+func TestHandler_ToolsCallResponseLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.ResponseLimits = []config.ResponseLimitConfig{
+		{Tool: "test_service_testmethod", MaxTokens: 10, StoreFull: true},
+	}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	full := `{"items":["aaaaaaaaaa","bbbbbbbbbb","cccccccccc","dddddddddd"]}`
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Return(full, nil)
+	mockDiscoverer.On("ServerInfo").Return(nil)
+
+	params := map[string]interface{}{"name": "test_service_testmethod"}
+	result, err := handler.HandleToolsCall(context.Background(), params, sessionCtx)
+	require.NoError(t, err)
+
+	require.Len(t, result.Content, 3)
+	assert.Equal(t, `{"items":["aaaaaaaaaa","bbbbbbbbbb"]}`, result.Content[0].Text)
+	assert.Contains(t, result.Content[1].Text, "Response truncated")
+	assert.Equal(t, mcp.ContentTypeResourceLink, result.Content[2].Type)
+	assert.Equal(t, true, result.Meta[mcp.MetaKeyTruncated])
+	assert.Equal(t, len(full), result.Meta[mcp.MetaKeyOriginalBytes])
+
+	// The full response can be listed and read by the same session only
+	listed, err := handler.handleResourcesList(context.Background(), sessionCtx)
+	require.NoError(t, err)
+	require.Len(t, listed.Resources, 1)
+	assert.Equal(t, result.Content[2].URI, listed.Resources[0].URI)
+
+	read, err := handler.handleResourcesRead(context.Background(),
+		map[string]interface{}{"uri": result.Content[2].URI}, sessionCtx)
+	require.NoError(t, err)
+	require.Len(t, read.Contents, 1)
+	assert.Equal(t, full, read.Contents[0].Text)
+
+	other := handler.sessionManager.CreateSession(map[string]string{})
+	_, err = handler.handleResourcesRead(context.Background(),
+		map[string]interface{}{"uri": result.Content[2].URI}, other)
+	require.Error(t, err)
+	assert.Equal(t, mcp.ErrorCodeResourceNotFound, errorCodeFor(err))
+}
+
+func TestHandler_ToolsListRecursiveTypes(t *testing.T) {
+	node := func(name string, number int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".tree.Node"),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		}
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("tree.proto"),
+		Package: proto.String("tree"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Node"), Field: []*descriptorpb.FieldDescriptorProto{node("children", 1), node("links", 2)}},
+			{Name: proto.String("Forest"), Field: []*descriptorpb.FieldDescriptorProto{node("roots", 1)}},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	forest := file.Messages().ByName("Forest")
+	method := types.MethodInfo{
+		Name:             "Prune",
+		ServiceName:      "tree.ForestService",
+		InputDescriptor:  forest,
+		OutputDescriptor: forest,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	handler, mockDiscoverer, _ := newTestHandler(t, config.Default())
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{method})
+
+	result, err := handler.handleToolsList(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Tools, 1)
+
+	// A node with two recursive fields would double at every inlined level
+	payload, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Less(t, len(payload), 1536, string(payload))
+	assert.Contains(t, string(payload), `"$ref":"#/$defs/tree.Node"`)
+}
+
+func TestHandler_SchemaFailureIsolation(t *testing.T) {
+	order := orderDescriptor(t)
+	healthy := types.MethodInfo{
+		Name:             "Place",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  order,
+		OutputDescriptor: order,
+	}
+	healthy.ToolName = healthy.GenerateToolName()
+	// A method without descriptors makes schema generation panic
+	broken := types.MethodInfo{
+		Name:        "Audit",
+		ServiceName: "shop.OrderService",
+	}
+	broken.ToolName = broken.GenerateToolName()
+
+	for _, degraded := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Degraded_%t", degraded), func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Tools.DegradedSchemas = degraded
+			logger := zap.NewNop()
+			toolBuilder, err := tools.NewMCPToolBuilderWithConfig(logger, cfg.Tools)
+			require.NoError(t, err)
+
+			mockDiscoverer := &mockServiceDiscoverer{}
+			mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{healthy, broken})
+			mockDiscoverer.On("HealthCheck", mock.Anything).Return(nil)
+			mockDiscoverer.On("GetMethodCount").Return(2)
+			mockDiscoverer.On("GetServiceStats").Return(map[string]interface{}{"serviceCount": 1})
+			mockDiscoverer.On("ServerInfo").Return(nil)
+			sessionManager := session.NewManager(logger)
+			t.Cleanup(func() { _ = sessionManager.Close() })
+			handler := NewHandlerWithConfig(logger, mockDiscoverer, sessionManager, toolBuilder, cfg)
+
+			// Failures are recorded per discovery, not by building tools
+			_, err = toolBuilder.BuildTools([]types.MethodInfo{broken})
+			require.NoError(t, err)
+			assert.Empty(t, toolBuilder.SchemaFailures())
+			_, err = toolBuilder.BuildDiscoveredTools([]types.MethodInfo{healthy, broken})
+			require.NoError(t, err)
+			_, err = toolBuilder.BuildTools([]types.MethodInfo{healthy})
+			require.NoError(t, err)
+
+			result, err := handler.handleToolsList(context.Background())
+			require.NoError(t, err)
+
+			if degraded {
+				require.Len(t, result.Tools, 2)
+				tool := result.Tools[1]
+				assert.Equal(t, broken.ToolName, tool.Name)
+				assert.Equal(t, map[string]interface{}{"type": "object"}, tool.InputSchema)
+				assert.Nil(t, tool.OutputSchema)
+				assert.Contains(t, tool.Meta, mcp.MetaKeySchemaWarning)
+				assert.True(t, strings.HasPrefix(tool.Description, "WARNING: "))
+			} else {
+				require.Len(t, result.Tools, 1)
+				assert.Equal(t, healthy.ToolName, result.Tools[0].Name)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.HealthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var health map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+			assert.Equal(t, "degraded", health["status"])
+			failures := health["schemaFailures"].([]interface{})
+			require.Len(t, failures, 1)
+			failure := failures[0].(map[string]interface{})
+			assert.Equal(t, broken.ToolName, failure["tool"])
+			assert.Equal(t, degraded, failure["degraded"])
+			assert.Contains(t, failure["error"], "panicked")
+
+			rec = httptest.NewRecorder()
+			handler.MetricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			var metrics map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metrics))
+			schemas := metrics["schemas"].(map[string]interface{})
+			assert.Equal(t, float64(1), schemas["failures"])
+			if degraded {
+				assert.Equal(t, float64(1), schemas["degraded"])
+			} else {
+				assert.Equal(t, float64(0), schemas["degraded"])
+			}
+		})
+	}
+}
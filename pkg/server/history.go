@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/history"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+// HistoryPath is the route of the call history admin endpoint
+const HistoryPath = "/admin/history"
+
+// HistoryToolName is the gateway tool listing the session's own calls
+const HistoryToolName = "ggrmcp_history"
+
+// maxHistoryToolLimit bounds the calls returned by the history tool
+const maxHistoryToolLimit = 100
+
+// historyStatuses lists the outcomes a history query may filter on
+var historyStatuses = []string{webhook.StatusSuccess, webhook.StatusError, webhook.StatusRejected}
+
+// historyDocument is the body of a history response
+type historyDocument struct {
+	Calls []history.Record `json:"calls"`
+}
+
+// SetHistory attaches the call history and exposes the history tool if enabled
+func (h *Handler) SetHistory(recorder *history.Recorder) {
+	h.history = recorder
+	if recorder != nil && h.historyConfig.Tool {
+		h.addBuiltinTool(builtinTool{tool: historyTool(), call: h.callHistoryTool})
+	}
+}
+
+// historyTool describes the history tool
+func historyTool() mcp.Tool {
+	return mcp.Tool{
+		Name: HistoryToolName,
+		Description: "Lists the tool calls this session already made, most recent first, " +
+			"with their outcome and redacted arguments. Use it to avoid repeating calls.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tool": map[string]interface{}{
+					"type":        "string",
+					"description": "Only calls of this tool",
+				},
+				"status": map[string]interface{}{
+					"type":        "string",
+					"enum":        historyStatuses,
+					"description": "Only calls with this outcome",
+				},
+				"since": map[string]interface{}{
+					"type":        "string",
+					"format":      "date-time",
+					"description": "Only calls made at or after this time (RFC 3339)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     maxHistoryToolLimit,
+					"description": fmt.Sprintf("Number of calls to return (%d by default)", history.DefaultLimit),
+				},
+			},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// callHistoryTool returns the session's own calls
+func (h *Handler) callHistoryTool(ctx context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	query := history.Query{SessionID: sessionCtx.ID}
+	for name, value := range arguments {
+		var ok bool
+		switch name {
+		case "tool":
+			query.Tool, ok = value.(string)
+		case "status":
+			query.Status, ok = value.(string)
+			ok = ok && slices.Contains(historyStatuses, query.Status)
+		case "since":
+			var since string
+			if since, ok = value.(string); ok {
+				var err error
+				query.Since, err = time.Parse(time.RFC3339, since)
+				ok = err == nil
+			}
+		case "limit":
+			query.Limit, ok = integerArgument(value, 1, maxHistoryToolLimit)
+		}
+		if !ok {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid %s argument", name))
+		}
+	}
+
+	calls, err := h.history.Query(ctx, query)
+	if err != nil {
+		h.logger.Error("Failed to query the call history", zap.Error(err))
+		return &mcp.ToolCallResult{
+			Content: []mcp.ContentBlock{mcp.TextContent("The call history is unavailable")},
+			IsError: true,
+		}, nil
+	}
+	return jsonToolResult(historyDocument{Calls: nonNilRecords(calls)})
+}
+
+// recordHistory writes a completed call to the history; calls of gateway
+// tools are not recorded
+func (h *Handler) recordHistory(event webhook.Event, result *mcp.ToolCallResult) {
+	if h.history == nil {
+		return
+	}
+	if _, builtin := h.findBuiltinTool(event.Tool); builtin {
+		return
+	}
+
+	record := history.Record{
+		ID:        event.ID,
+		Time:      event.Time,
+		SessionID: event.SessionID,
+		RequestID: event.RequestID,
+		Tool:      event.Tool,
+		Status:    event.Status,
+		Code:      event.Code,
+		LatencyMs: event.LatencyMs,
+		Arguments: event.Arguments,
+	}
+	if result != nil {
+		var text []string
+		for _, block := range result.Content {
+			if block.Type == mcp.ContentTypeText {
+				text = append(text, block.Text)
+			}
+		}
+		record.Result = strings.Join(text, "\n")
+	}
+	h.history.Record(record)
+}
+
+// HistoryHandler returns recorded calls for operators. Calls can be filtered
+// with the session, tool, status, since and until (RFC 3339) query parameters.
+func (h *Handler) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if h.history == nil || h.historyConfig.AdminToken == "" {
+		http.Error(w, "Call history is not enabled", http.StatusNotFound)
+		return
+	}
+	if !h.requireBearer(w, r, h.historyConfig.AdminToken, "call history") {
+		return
+	}
+
+	params := r.URL.Query()
+	query := history.Query{
+		SessionID: params.Get("session"),
+		Tool:      params.Get("tool"),
+		Status:    params.Get("status"),
+	}
+	var err error
+	for name, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if value := params.Get(name); value != "" {
+			if *target, err = time.Parse(time.RFC3339, value); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid %s: must be an RFC 3339 time", name), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if value := params.Get("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 1 {
+			http.Error(w, "Invalid limit: must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	calls, err := h.history.Query(r.Context(), query)
+	if err != nil {
+		h.logger.Error("Failed to query the call history", zap.Error(err))
+		http.Error(w, "Call history unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(historyDocument{Calls: nonNilRecords(calls)}); err != nil {
+		h.logger.Error("Failed to encode call history", zap.Error(err))
+	}
+}
+
+// nonNilRecords makes an empty result encode as [] rather than null
+func nonNilRecords(records []history.Record) []history.Record {
+	if records == nil {
+		return []history.Record{}
+	}
+	return records
+}
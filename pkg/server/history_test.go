@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/history"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newHistoryHandler creates a handler recording calls in memory
+func newHistoryHandler(t *testing.T) (*Handler, *mockServiceDiscoverer, *history.Recorder) {
+	cfg := config.Default()
+	cfg.MCP.History.Enabled = true
+	cfg.MCP.History.AdminToken = "admin-token"
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+
+	recorder, err := history.NewRecorder(cfg.MCP.History, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = recorder.Close() })
+	handler.SetHistory(recorder)
+	return handler, mockDiscoverer, recorder
+}
+
+func TestHandler_HistoryTool(t *testing.T) {
+	handler, mockDiscoverer, recorder := newHistoryHandler(t)
+	mine := handler.sessionManager.CreateSession(map[string]string{})
+	other := handler.sessionManager.CreateSession(map[string]string{})
+
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{})
+	list, err := handler.handleToolsList(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list.Tools, 1)
+	assert.Equal(t, HistoryToolName, list.Tools[0].Name)
+
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", mock.Anything).
+		Return(`{"status":"shipped"}`, nil)
+	for _, sessionCtx := range []*session.Context{mine, mine, other} {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "shop_orders_get",
+			"arguments": map[string]interface{}{"id": "A-1", "token": "secret"},
+		}, sessionCtx)
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return recorder.Stats().Recorded == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	callHistory := func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      HistoryToolName,
+			"arguments": arguments,
+		}, mine)
+	}
+
+	// Sessions only see their own calls
+	result, err := callHistory(map[string]interface{}{"limit": float64(5)})
+	require.NoError(t, err)
+	var document historyDocument
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &document))
+	require.Len(t, document.Calls, 2)
+	assert.Equal(t, mine.ID, document.Calls[0].SessionID)
+	assert.Equal(t, `{"id":"A-1","token":"[REDACTED]"}`, document.Calls[0].Arguments)
+	assert.Equal(t, `{"status":"shipped"}`, document.Calls[0].Result)
+
+	// History lookups are not recorded themselves
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int64(3), recorder.Stats().Recorded)
+
+	_, err = callHistory(map[string]interface{}{"status": "unknown"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid status argument")
+
+	_, err = callHistory(map[string]interface{}{"limit": float64(1000)})
+	require.Error(t, err)
+}
+
+func TestHandler_HistoryToolDisabled(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.History.Enabled = true
+	cfg.MCP.History.Tool = false
+	handler, _, _ := newTestHandler(t, cfg)
+	recorder, err := history.NewRecorder(cfg.MCP.History, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { _ = recorder.Close() }()
+	handler.SetHistory(recorder)
+
+	assert.Empty(t, handler.builtinToolList())
+}
+
+func TestHandler_HistoryEndpoint(t *testing.T) {
+	handler, _, recorder := newHistoryHandler(t)
+	now := time.Now().UTC()
+	recorder.Record(history.Record{ID: "1", Time: now.Add(-time.Hour), SessionID: "s1", Tool: "shop_orders_get", Status: "success"})
+	recorder.Record(history.Record{ID: "2", Time: now, SessionID: "s2", Tool: "shop_orders_cancel", Status: "error"})
+	require.Eventually(t, func() bool { return recorder.Stats().Recorded == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	get := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.HistoryHandler(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get(HistoryPath, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, get(HistoryPath, "wrong").Code)
+
+	rec := get(HistoryPath+"?status=error", "admin-token")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var document historyDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &document))
+	require.Len(t, document.Calls, 1)
+	assert.Equal(t, "2", document.Calls[0].ID)
+
+	rec = get(HistoryPath+"?session=s1&until="+now.Add(-time.Minute).Format(time.RFC3339), "admin-token")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &document))
+	require.Len(t, document.Calls, 1)
+	assert.Equal(t, "1", document.Calls[0].ID)
+
+	rec = get(HistoryPath+"?tool=none", "admin-token")
+	assert.JSONEq(t, `{"calls":[]}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get(HistoryPath+"?since=yesterday", "admin-token").Code)
+	assert.Equal(t, http.StatusBadRequest, get(HistoryPath+"?limit=0", "admin-token").Code)
+
+	// Without an admin token the endpoint is off
+	disabled, _, _ := newTestHandler(t, config.Default())
+	rec = httptest.NewRecorder()
+	disabled.HistoryHandler(rec, httptest.NewRequest(http.MethodGet, HistoryPath, nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
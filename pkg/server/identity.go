@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+)
+
+// identityMetadataPrefix starts the metadata names reserved for the client identity
+const identityMetadataPrefix = "x-mcp-"
+
+// Metadata identifying the MCP client behind a call
+const (
+	clientNameMetadata    = "x-mcp-client-name"
+	clientVersionMetadata = "x-mcp-client-version"
+	sessionIDMetadata     = "x-mcp-session-id"
+	principalMetadata     = "x-mcp-principal"
+)
+
+// withIdentity adds the identity of the session's client to the forwarded
+// headers. Forwarded x-mcp-* headers are dropped first, so only the gateway
+// sets them. Values are made valid metadata with the other headers.
+func (h *Handler) withIdentity(headers map[string]string, sessionCtx *session.Context) map[string]string {
+	if !h.identity.Enabled {
+		return headers
+	}
+	for name := range headers {
+		if strings.HasPrefix(strings.ToLower(name), identityMetadataPrefix) {
+			delete(headers, name)
+		}
+	}
+
+	set := func(name, value string) {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	clientName, clientVersion := sessionCtx.ClientInfo()
+	set(clientNameMetadata, clientName)
+	set(clientVersionMetadata, clientVersion)
+	set(sessionIDMetadata, sessionCtx.ID)
+	if h.identity.PrincipalHeader != "" {
+		set(principalMetadata, sessionCtx.GetHeader(http.CanonicalHeaderKey(h.identity.PrincipalHeader)))
+	}
+	return headers
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_IdentityMetadata(t *testing.T) {
+	cfg := config.Default()
+	cfg.GRPC.HeaderForwarding.AllowedHeaders = []string{"x-trace-id", "x-mcp-principal"}
+	cfg.GRPC.HeaderForwarding.InvalidValues = "encode"
+	cfg.GRPC.HeaderForwarding.Identity = config.IdentityForwardingConfig{
+		Enabled:         true,
+		PrincipalHeader: "x-forwarded-user",
+	}
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.Headers["X-Trace-Id"] = "t-1"
+	sessionCtx.Headers["X-Forwarded-User"] = "alice@example.com"
+	sessionCtx.Headers["X-Mcp-Principal"] = "admin@example.com"
+
+	handler.recordClientCapabilities(mcp.InitializeParams{
+		ClientInfo: mcp.ClientInfo{Name: "orders-agent ✓", Version: "1.4.0"},
+	}, sessionCtx)
+
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, map[string]string{
+		"X-Trace-Id":           "t-1",
+		"x-mcp-client-name":    "orders-agent %E2%9C%93",
+		"x-mcp-client-version": "1.4.0",
+		"x-mcp-session-id":     sessionCtx.ID,
+		"x-mcp-principal":      "alice@example.com",
+	}, "shop_orders_list", "").Return(`{}`, nil).Once()
+
+	result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "shop_orders_list"}, sessionCtx)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	mockDiscoverer.AssertExpectations(t)
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, sessionCtx := newTestHandler(t, config.Default())
+		handler.recordClientCapabilities(mcp.InitializeParams{
+			ClientInfo: mcp.ClientInfo{Name: "orders-agent", Version: "1.4.0"},
+		}, sessionCtx)
+		assert.Empty(t, handler.forwardedHeaders(sessionCtx, nil))
+	})
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_InitializeServerInfo(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		result := handler.handleInitialize()
+		assert.Equal(t, "ggRMCP", result.ServerInfo.Name)
+		assert.Equal(t, version.Get().Version, result.ServerInfo.Version)
+
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), `"title"`)
+		assert.NotContains(t, string(data), `"instructions"`)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Server = config.MCPServerConfig{
+			Name:         "orders-gateway",
+			Title:        "Orders",
+			Version:      "2026.10",
+			Instructions: "Look orders up by ID before cancelling them.",
+		}
+		require.NoError(t, cfg.Validate())
+
+		handler, _, _ := newTestHandler(t, cfg)
+		result := handler.handleInitialize()
+		assert.Equal(t, "orders-gateway", result.ServerInfo.Name)
+		assert.Equal(t, "Orders", result.ServerInfo.Title)
+		assert.Equal(t, "2026.10", result.ServerInfo.Version)
+		assert.Equal(t, "Look orders up by ID before cancelling them.", result.Instructions)
+	})
+
+	t.Run("EmptyName", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Server.Name = " "
+		assert.ErrorContains(t, cfg.Validate(), "server name")
+	})
+}
+
+func TestHandler_InitializeCapabilities(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		capabilities := handler.handleInitialize().Capabilities
+		require.NotNil(t, capabilities.Tools)
+		assert.False(t, capabilities.Tools.ListChanged)
+		assert.Nil(t, capabilities.Prompts)
+		assert.Nil(t, capabilities.Resources)
+		assert.Nil(t, capabilities.Completions)
+		assert.Nil(t, capabilities.Experimental)
+	})
+
+	t.Run("Resources", func(t *testing.T) {
+		enable := map[string]func(cfg *config.Config){
+			"BinaryFields":  func(cfg *config.Config) { cfg.Tools.BinaryFields.Enabled = true },
+			"BinaryInputs":  func(cfg *config.Config) { cfg.Tools.BinaryInputs.Enabled = true },
+			"ResponseCache": func(cfg *config.Config) { cfg.MCP.ResponseCache.Enabled = true },
+			"Descriptions":  func(cfg *config.Config) { cfg.Tools.Descriptions.MaxLength = 200 },
+			"StoreFull": func(cfg *config.Config) {
+				cfg.Tools.ResponseLimits = []config.ResponseLimitConfig{{Tool: "*", MaxBytes: 1024, StoreFull: true}}
+			},
+		}
+		for name, apply := range enable {
+			t.Run(name, func(t *testing.T) {
+				cfg := config.Default()
+				apply(cfg)
+				handler, _, _ := newTestHandler(t, cfg)
+				assert.NotNil(t, handler.handleInitialize().Capabilities.Resources)
+			})
+		}
+
+		t.Run("BackendServerInfo", func(t *testing.T) {
+			handler, mockDiscoverer, _ := newTestHandler(t, config.Default())
+			mockDiscoverer.On("ServerInfo").Return(&grpc.ServerInfo{Method: "shop.v1.Admin.GetVersion"})
+			assert.NotNil(t, handler.handleInitialize().Capabilities.Resources)
+		})
+	})
+
+	t.Run("Serialized", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		data, err := json.Marshal(handler.handleInitialize().Capabilities)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"tools":{}}`, string(data))
+	})
+}
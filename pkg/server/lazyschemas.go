@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// SchemasPath is the route of the full tool definitions linked from compact
+// tools/list entries, served at SchemasPath + "/{tool}"
+const SchemasPath = "/schemas"
+
+// DescribeToolName is the gateway tool returning the full definition of a tool
+const DescribeToolName = "ggrmcp_describe_tool"
+
+// compactDescriptionLength bounds the one-line description of compact entries
+const compactDescriptionLength = 200
+
+// publicURLKey carries the public URL of the MCP endpoint a request came to
+type publicURLKey struct{}
+
+// withPublicURL returns a context carrying the public URL of the MCP endpoint
+func withPublicURL(ctx context.Context, publicURL string) context.Context {
+	return context.WithValue(ctx, publicURLKey{}, publicURL)
+}
+
+// schemaURL returns the URL of a tool's full definition, absolute when the
+// context carries the public URL of the endpoint
+func schemaURL(ctx context.Context, toolName string) string {
+	origin := ""
+	if publicURL, ok := ctx.Value(publicURLKey{}).(string); ok {
+		origin = originOf(publicURL)
+	}
+	return origin + SchemasPath + "/" + url.PathEscape(toolName)
+}
+
+// compactToolsList replaces the entries of a list longer than the configured
+// minimum with their name, the first line of their description and $refs to
+// their schemas
+func (h *Handler) compactToolsList(ctx context.Context, result *mcp.ToolsListResult) *mcp.ToolsListResult {
+	if !h.lazySchemas.Enabled || len(result.Tools) <= h.lazySchemas.MinTools {
+		return result
+	}
+
+	for i, tool := range result.Tools {
+		// The describe tool stays whole, so clients know how to call it
+		if tool.Name == DescribeToolName {
+			continue
+		}
+		ref := schemaURL(ctx, tool.Name)
+		compact := mcp.Tool{
+			Name:        tool.Name,
+			Description: firstLine(tool.Description),
+			InputSchema: map[string]interface{}{"type": "object", "$ref": ref + "#/inputSchema"},
+			Meta:        tool.Meta,
+		}
+		if tool.OutputSchema != nil {
+			compact.OutputSchema = map[string]interface{}{"type": "object", "$ref": ref + "#/outputSchema"}
+		}
+		result.Tools[i] = compact
+	}
+	return result
+}
+
+// firstLine returns the first line of a description, shortened if needed
+func firstLine(description string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	line = strings.TrimSpace(line)
+	if len(line) > compactDescriptionLength {
+		line = cutText(line, compactDescriptionLength)
+	}
+	return line
+}
+
+// findSessionTool returns the full definition of a tool the session can list
+func (h *Handler) findSessionTool(ctx context.Context, toolName string, sessionCtx *session.Context) (mcp.Tool, bool, error) {
+	profile, err := h.sessionProfile(sessionCtx)
+	if err != nil {
+		return mcp.Tool{}, false, err
+	}
+	if !profileAllows(profile, toolName) || !h.rolesAllow(ctx, toolName) {
+		return mcp.Tool{}, false, nil
+	}
+	tool, found := h.buildListedTool(ctx, toolName)
+	return tool, found, nil
+}
+
+// describeTool describes the tool returning full tool definitions
+func describeTool() mcp.Tool {
+	return mcp.Tool{
+		Name: DescribeToolName,
+		Description: "Returns the full definition of a tool: its description and input and output schemas. " +
+			"The tool list only gives each tool's name, a one-line summary and a $ref to its schemas; " +
+			"call this before calling a tool to learn its arguments.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the tool to describe",
+				},
+			},
+			"required":             []string{"name"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// callDescribeTool returns the full definition of the requested tool
+func (h *Handler) callDescribeTool(ctx context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	name, ok := arguments["name"].(string)
+	if !ok || name == "" {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid name argument")
+	}
+	tool, found, err := h.findSessionTool(ctx, name, sessionCtx)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid params: unknown tool %s", name))
+	}
+	return jsonToolResult(tool)
+}
+
+// SchemasHandler serves the full definition of a tool, with the callers'
+// roles and the profile of the session named by Mcp-Session-Id (or the
+// profile a new session would get) deciding which tools can be read
+func (h *Handler) SchemasHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.lazySchemas.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+	r, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	toolName := strings.TrimPrefix(r.URL.Path, SchemasPath+"/")
+	if toolName == "" || strings.Contains(toolName, "/") {
+		http.Error(w, "Invalid tool name", http.StatusBadRequest)
+		return
+	}
+
+	sessionCtx, ok := h.sessionManager.GetSession(r.Header.Get("Mcp-Session-Id"))
+	if !ok {
+		sessionCtx = &session.Context{Headers: extractHeaders(r)}
+	}
+	tool, found, err := h.findSessionTool(r.Context(), toolName, sessionCtx)
+	var rpcErr *mcp.RPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		http.Error(w, rpcErr.Message, http.StatusForbidden)
+		return
+	case err != nil:
+		h.logger.Error("Failed to list tools for schema", zap.String("toolName", toolName), zap.Error(err))
+		http.Error(w, "Failed to list tools", http.StatusInternalServerError)
+		return
+	case !found:
+		http.Error(w, "Tool not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tool); err != nil {
+		h.logger.Error("Failed to encode tool schema", zap.Error(err))
+	}
+}
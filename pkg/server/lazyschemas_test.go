@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstLine(t *testing.T) {
+	assert.Equal(t, "Gets an order.", firstLine("\n  Gets an order.\nReturns NOT_FOUND if missing.\n"))
+	assert.Equal(t, "", firstLine(""))
+
+	long := firstLine(strings.Repeat("word ", 100))
+	assert.LessOrEqual(t, len(long), compactDescriptionLength)
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
+
+func TestHandler_LazySchemas(t *testing.T) {
+	newHandler := func(t *testing.T, minTools int) (*Handler, *mockServiceDiscoverer) {
+		cfg := config.Default()
+		cfg.Tools.LazySchemas = config.LazySchemasConfig{Enabled: true, MinTools: minTools}
+		require.NoError(t, cfg.Validate())
+		handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+
+		order := orderDescriptor(t)
+		var methods []types.MethodInfo
+		for _, name := range []string{"Get", "Cancel"} {
+			method := types.MethodInfo{
+				Name:             name,
+				FullName:         "shop.OrderService." + name,
+				ServiceName:      "shop.OrderService",
+				Description:      name + " an order.\n\nSecond paragraph.",
+				InputDescriptor:  order,
+				OutputDescriptor: order,
+			}
+			method.ToolName = method.GenerateToolName()
+			methods = append(methods, method)
+			mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true)
+		}
+		mockDiscoverer.On("GetMethodByTool", mock.Anything).Return(types.MethodInfo{}, false)
+		mockDiscoverer.On("GetMethods").Return(methods)
+		return handler, mockDiscoverer
+	}
+	list := func(t *testing.T, handler *Handler, ctx context.Context) map[string]mcp.Tool {
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{})
+		result, err := handler.handleRequest(ctx, &mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/list"}, sessionCtx)
+		require.NoError(t, err)
+		tools := make(map[string]mcp.Tool)
+		for _, tool := range result.(*mcp.ToolsListResult).Tools {
+			tools[tool.Name] = tool
+		}
+		return tools
+	}
+
+	t.Run("Compact", func(t *testing.T) {
+		handler, _ := newHandler(t, 2)
+		tools := list(t, handler, withPublicURL(context.Background(), "https://mcp.example.com/mcp"))
+		require.Len(t, tools, 3)
+
+		get := tools["shop_orderservice_get"]
+		assert.Equal(t, "Get an order.", get.Description)
+		assert.Equal(t, map[string]interface{}{
+			"type": "object",
+			"$ref": "https://mcp.example.com/schemas/shop_orderservice_get#/inputSchema",
+		}, get.InputSchema)
+
+		// The describe tool keeps its schema
+		assert.Equal(t, describeTool(), tools[DescribeToolName])
+	})
+
+	t.Run("Below_minimum", func(t *testing.T) {
+		handler, _ := newHandler(t, 3)
+		tools := list(t, handler, context.Background())
+		get := tools["shop_orderservice_get"]
+		assert.Contains(t, get.Description, "Second paragraph.")
+		assert.Contains(t, get.InputSchema, "properties")
+	})
+
+	t.Run("Relative_ref", func(t *testing.T) {
+		handler, _ := newHandler(t, 0)
+		tools := list(t, handler, context.Background())
+		assert.Equal(t, "/schemas/shop_orderservice_cancel#/inputSchema",
+			tools["shop_orderservice_cancel"].InputSchema.(map[string]interface{})["$ref"])
+	})
+
+	t.Run("ServeHTTP", func(t *testing.T) {
+		handler, _ := newHandler(t, 0)
+		req := httptest.NewRequest(http.MethodPost, "http://gateway.internal:8080/",
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Result mcp.ToolsListResult `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.NotEmpty(t, response.Result.Tools)
+		assert.Equal(t, "https://gateway.internal:8080/schemas/shop_orderservice_get#/inputSchema",
+			response.Result.Tools[0].InputSchema.(map[string]interface{})["$ref"])
+	})
+
+	t.Run("Describe_tool", func(t *testing.T) {
+		handler, _ := newHandler(t, 0)
+		full, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{})
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      DescribeToolName,
+			"arguments": map[string]interface{}{"name": "shop_orderservice_get"},
+		}, sessionCtx)
+		require.NoError(t, err)
+		expected, err := json.Marshal(full.Tools[0])
+		require.NoError(t, err)
+		assert.JSONEq(t, string(expected), result.Content[0].Text)
+
+		_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      DescribeToolName,
+			"arguments": map[string]interface{}{"name": "shop_orderservice_refund"},
+		}, sessionCtx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown tool shop_orderservice_refund")
+
+		_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      DescribeToolName,
+			"arguments": map[string]interface{}{},
+		}, sessionCtx)
+		assert.Error(t, err)
+	})
+
+	t.Run("Endpoint", func(t *testing.T) {
+		handler, mockDiscoverer := newHandler(t, 0)
+		get := func(path string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			handler.SchemasHandler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			return rec
+		}
+
+		rec := get(SchemasPath + "/shop_orderservice_cancel")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var tool mcp.Tool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tool))
+		assert.Equal(t, "shop_orderservice_cancel", tool.Name)
+		assert.Contains(t, tool.Description, "Second paragraph.")
+		assert.Contains(t, tool.InputSchema, "properties")
+
+		assert.Equal(t, http.StatusNotFound, get(SchemasPath+"/shop_orderservice_refund").Code)
+		assert.Equal(t, http.StatusBadRequest, get(SchemasPath+"/").Code)
+
+		// Only the requested tool is built, not the whole list
+		mockDiscoverer.AssertNotCalled(t, "GetMethods")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		rec := httptest.NewRecorder()
+		handler.SchemasHandler(rec, httptest.NewRequest(http.MethodGet, SchemasPath+"/shop_orderservice_get", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, handler.builtinToolList())
+	})
+}
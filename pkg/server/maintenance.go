@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+)
+
+// MaintenancePath is the route of the maintenance admin endpoint, protected
+// by the tool admin token
+const MaintenancePath = "/admin/maintenance"
+
+// maxMaintenanceChangeSize bounds the body of a posted maintenance change
+const maxMaintenanceChangeSize = 64 * 1024
+
+// maintenanceState is the gateway's maintenance mode as reported by the
+// admin endpoint
+type maintenanceState struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message,omitempty"`
+	RetryAfter int64      `json:"retryAfter,omitempty"` // seconds
+	Operator   string     `json:"operator,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// maintenanceChange is the body posted to switch maintenance mode
+type maintenanceChange struct {
+	Enabled    bool   `json:"enabled"`
+	Message    string `json:"message"`
+	RetryAfter int64  `json:"retryAfter"` // seconds; 0 for the configured default
+	Operator   string `json:"operator"`
+}
+
+// maintenanceMode holds the current maintenance state
+type maintenanceMode struct {
+	defaults config.MaintenanceConfig
+
+	mu    sync.RWMutex
+	state maintenanceState
+}
+
+// newMaintenanceMode creates the maintenance mode in its configured state
+func newMaintenanceMode(cfg config.MaintenanceConfig) *maintenanceMode {
+	m := &maintenanceMode{defaults: cfg}
+	if cfg.Enabled {
+		now := time.Now().UTC()
+		m.state = maintenanceState{
+			Enabled:    true,
+			Message:    cfg.Message,
+			RetryAfter: int64(cfg.RetryAfter / time.Second),
+			Since:      &now,
+		}
+	}
+	return m
+}
+
+// current returns the maintenance state
+func (m *maintenanceMode) current() maintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// set switches maintenance mode and reports whether it changed
+func (m *maintenanceMode) set(change maintenanceChange, now time.Time) (maintenanceState, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !change.Enabled {
+		changed := m.state.Enabled
+		m.state = maintenanceState{}
+		return m.state, changed
+	}
+
+	state := maintenanceState{
+		Enabled:    true,
+		Message:    change.Message,
+		RetryAfter: change.RetryAfter,
+		Operator:   change.Operator,
+		Since:      m.state.Since,
+	}
+	if state.Message == "" {
+		state.Message = m.defaults.Message
+	}
+	if state.RetryAfter <= 0 {
+		state.RetryAfter = int64(m.defaults.RetryAfter / time.Second)
+	}
+	if state.Since == nil {
+		since := now.UTC()
+		state.Since = &since
+	}
+	changed := state.Message != m.state.Message || !m.state.Enabled
+	m.state = state
+	return state, changed
+}
+
+// checkMaintenance rejects tool calls while the gateway is under maintenance
+func (h *Handler) checkMaintenance() error {
+	state := h.maintenance.current()
+	if !state.Enabled {
+		return nil
+	}
+
+	message := "Gateway under maintenance"
+	if state.Message != "" {
+		message += ": " + state.Message
+	}
+	if state.RetryAfter > 0 {
+		message += fmt.Sprintf(" Retry after %s.", time.Duration(state.RetryAfter)*time.Second)
+	}
+	rpcErr := mcp.NewRPCError(mcp.ErrorCodeUnavailable, message)
+	rpcErr.Data = map[string]interface{}{
+		"maintenance": true,
+		"retryAfter":  state.RetryAfter,
+	}
+	return rpcErr
+}
+
+// flagMaintenance prefixes tool descriptions with the maintenance notice so
+// agents know why calls fail
+func (h *Handler) flagMaintenance(tools []mcp.Tool) []mcp.Tool {
+	state := h.maintenance.current()
+	if !state.Enabled {
+		return tools
+	}
+
+	notice := "[Maintenance] Calls are rejected until maintenance ends."
+	if state.Message != "" {
+		notice = "[Maintenance] " + state.Message + " Calls are rejected until maintenance ends."
+	}
+	for i := range tools {
+		tools[i].Description = notice + "\n\n" + tools[i].Description
+	}
+	return tools
+}
+
+// MaintenanceHandler reports maintenance mode on GET and switches it on POST.
+// Switching it tells clients listening for notifications that the tool list
+// changed, since descriptions carry the notice.
+func (h *Handler) MaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeToolAdmin(w, r) {
+		return
+	}
+
+	state := h.maintenance.current()
+	if r.Method == http.MethodPost {
+		var change maintenanceChange
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMaintenanceChangeSize)).Decode(&change); err != nil {
+			http.Error(w, "Invalid change: expected {\"enabled\": bool, \"message\": string, \"retryAfter\": seconds, \"operator\": string}", http.StatusBadRequest)
+			return
+		}
+		if change.RetryAfter < 0 {
+			http.Error(w, "Invalid change: retryAfter must not be negative", http.StatusBadRequest)
+			return
+		}
+
+		var changed bool
+		state, changed = h.maintenance.set(change, time.Now())
+		if changed {
+			if h.notifications != nil {
+				h.notifications.broadcast("notifications/tools/list_changed")
+			}
+			h.logger.Warn("Maintenance mode changed by operator",
+				zap.Bool("enabled", state.Enabled),
+				zap.String("message", state.Message),
+				zap.String("operator", change.Operator))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		h.logger.Error("Failed to encode maintenance state", zap.Error(err))
+	}
+}
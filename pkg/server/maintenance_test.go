@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Maintenance(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.ResponseCache.Enabled = true
+	cfg.MCP.ToolAdmin.AdminToken = "admin-token"
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{})
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "reports_generate", mock.Anything).
+		Return(`{"status":"done"}`, nil)
+
+	maintenance := func(method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, MaintenancePath, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.MaintenanceHandler(rec, req)
+		return rec
+	}
+	call := func() error {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "reports_generate"}, sessionCtx)
+		return err
+	}
+	description := func() string {
+		list, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, list.Tools, 1)
+		return list.Tools[0].Description
+	}
+	original := description()
+	require.NoError(t, call())
+
+	listener, unsubscribe := handler.notifications.subscribe()
+	defer unsubscribe()
+
+	rec := maintenance(http.MethodPost, "admin-token", `{"enabled":true,"message":"Migrating the reports backend.","retryAfter":600,"operator":"alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var state maintenanceState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.True(t, state.Enabled)
+	assert.Equal(t, int64(600), state.RetryAfter)
+	require.NotNil(t, state.Since)
+	select {
+	case data := <-listener:
+		assert.Contains(t, string(data), "notifications/tools/list_changed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no list_changed notification")
+	}
+
+	// Calls fail with a retry-later error
+	err := call()
+	require.Error(t, err)
+	rpcErr := rpcErrorFor(err)
+	assert.Equal(t, mcp.ErrorCodeUnavailable, rpcErr.Code)
+	assert.Equal(t, "Gateway under maintenance: Migrating the reports backend. Retry after 10m0s.", rpcErr.Message)
+	assert.Equal(t, map[string]interface{}{"maintenance": true, "retryAfter": int64(600)}, rpcErr.Data)
+
+	// Tools carry the notice
+	assert.Equal(t, "[Maintenance] Migrating the reports backend. Calls are rejected until maintenance ends.\n\n"+original, description())
+
+	rec = maintenance(http.MethodGet, "admin-token", "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, "alice", state.Operator)
+
+	// Switching it off restores calls and descriptions
+	rec = maintenance(http.MethodPost, "admin-token", `{"enabled":false}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"enabled":false}`, rec.Body.String())
+	require.NoError(t, call())
+	assert.Equal(t, original, description())
+
+	assert.Equal(t, http.StatusUnauthorized, maintenance(http.MethodGet, "", "").Code)
+	assert.Equal(t, http.StatusBadRequest, maintenance(http.MethodPost, "admin-token", `{"enabled":true,"retryAfter":-1}`).Code)
+}
+
+func TestHandler_MaintenanceFromConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Maintenance.Enabled = true
+	handler, _, sessionCtx := newTestHandler(t, cfg)
+
+	_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "reports_generate"}, sessionCtx)
+	require.Error(t, err)
+	assert.Equal(t, mcp.ErrorCodeUnavailable, errorCodeFor(err))
+	assert.Contains(t, err.Error(), "The gateway is under maintenance. Retry after 5m0s.")
+
+	// Without the tool admin token maintenance mode cannot be changed at runtime
+	rec := httptest.NewRecorder()
+	handler.MaintenanceHandler(rec, httptest.NewRequest(http.MethodGet, MaintenancePath, nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/jsonwalk"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// mapKeyNormalization rewrites the map keys of one call's arguments
+type mapKeyNormalization struct {
+	changed bool
+	err     error
+}
+
+// normalizeMapKeyArguments rewrites integer and bool map keys in the
+// arguments to their canonical form, rejecting keys of the wrong type
+func (h *Handler) normalizeMapKeyArguments(args *decodedArguments) error {
+	if !h.normalizeMapKeys {
+		return nil
+	}
+
+	normalization := &mapKeyNormalization{}
+	jsonwalk.Walker{Entries: normalization.normalizeEntries}.Walk(args.desc, args.doc)
+	if normalization.err != nil {
+		return mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %s", normalization.err.Error()))
+	}
+	args.changed = args.changed || normalization.changed
+	return nil
+}
+
+// normalizeEntries rewrites the keys of one map field
+func (n *mapKeyNormalization) normalizeEntries(fd protoreflect.FieldDescriptor, _ string, entries map[string]interface{}) {
+	if n.err != nil || fd.MapKey().Kind() == protoreflect.StringKind {
+		return
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		canonical, err := canonicalMapKey(fd.MapKey().Kind(), key)
+		if err != nil {
+			n.err = fmt.Errorf("field %s: %w", fd.Name(), err)
+			return
+		}
+		if canonical == key {
+			continue
+		}
+		if _, exists := entries[canonical]; exists {
+			n.err = fmt.Errorf("field %s: map keys %q and %q are the same key", fd.Name(), key, canonical)
+			return
+		}
+		entries[canonical] = entries[key]
+		delete(entries, key)
+		n.changed = true
+	}
+}
+
+// canonicalMapKey returns the form protojson accepts for a map key of the given kind
+func canonicalMapKey(kind protoreflect.Kind, key string) (string, error) {
+	switch kind {
+	case protoreflect.BoolKind:
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "true":
+			return "true", nil
+		case "false":
+			return "false", nil
+		}
+		return "", fmt.Errorf("map key %q is not a bool (use \"true\" or \"false\")", key)
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return canonicalIntKey(key, math.MinInt32, math.MaxInt32, "int32")
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return canonicalIntKey(key, math.MinInt64, math.MaxInt64, "int64")
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return canonicalUintKey(key, math.MaxUint32, "uint32")
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return canonicalUintKey(key, math.MaxUint64, "uint64")
+	}
+	return key, nil
+}
+
+// canonicalIntKey parses a signed integer key, allowing a sign and an
+// integral decimal or exponent form (e.g. "+7", "7.0", "1e3")
+func canonicalIntKey(key string, minValue, maxValue int64, typeName string) (string, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(key), "+")
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		if n < minValue || n > maxValue {
+			return "", fmt.Errorf("map key %q is out of range for %s", key, typeName)
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || f != math.Trunc(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("map key %q is not an %s", key, typeName)
+	}
+	// -minValue is exact as a float64, unlike maxValue for int64
+	if f < float64(minValue) || f >= -float64(minValue) {
+		return "", fmt.Errorf("map key %q is out of range for %s", key, typeName)
+	}
+	return strconv.FormatInt(int64(f), 10), nil
+}
+
+// canonicalUintKey parses an unsigned integer key like canonicalIntKey
+func canonicalUintKey(key string, maxValue uint64, typeName string) (string, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(key), "+")
+	if n, err := strconv.ParseUint(text, 10, 64); err == nil {
+		if n > maxValue {
+			return "", fmt.Errorf("map key %q is out of range for %s", key, typeName)
+		}
+		return strconv.FormatUint(n, 10), nil
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || f != math.Trunc(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("map key %q is not a %s", key, typeName)
+	}
+	if f < 0 || f >= float64(maxValue)+1 {
+		return "", fmt.Errorf("map key %q is out of range for %s", key, typeName)
+	}
+	return strconv.FormatUint(uint64(f), 10), nil
+}
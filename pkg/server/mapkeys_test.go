@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// inventoryDescriptor builds a message with int32, bool and nested uint64 keyed maps
+func inventoryDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+			fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+		return fd
+	}
+	entry := func(name string, key descriptorpb.FieldDescriptorProto_Type, value descriptorpb.FieldDescriptorProto_Type, valueType string) *descriptorpb.DescriptorProto {
+		valueField := field("value", 2, value, "")
+		if valueType != "" {
+			valueField.TypeName = proto.String(valueType)
+		}
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("key", 1, key, ""),
+				valueField,
+			},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("inventory.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Bin"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("serials", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Bin.SerialsEntry"),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					entry("SerialsEntry", descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+			{
+				Name: proto.String("Inventory"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("stock", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Inventory.StockEntry"),
+					field("bins", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Inventory.BinsEntry"),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					entry("StockEntry", descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					entry("BinsEntry", descriptorpb.FieldDescriptorProto_TYPE_BOOL, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".shop.Bin"),
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Inventory")
+}
+
+func TestCanonicalMapKey(t *testing.T) {
+	tests := []struct {
+		kind    protoreflect.Kind
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{kind: protoreflect.Int32Kind, key: "+7", want: "7"},
+		{kind: protoreflect.Int32Kind, key: "007", want: "7"},
+		{kind: protoreflect.Int32Kind, key: "-3.0", want: "-3"},
+		{kind: protoreflect.Int32Kind, key: "1e3", want: "1000"},
+		{kind: protoreflect.Int32Kind, key: "2147483648", wantErr: true},
+		{kind: protoreflect.Int32Kind, key: "1.5", wantErr: true},
+		{kind: protoreflect.Int64Kind, key: "9223372036854775807", want: "9223372036854775807"},
+		{kind: protoreflect.Int64Kind, key: "9.3e18", wantErr: true},
+		{kind: protoreflect.Uint64Kind, key: "18446744073709551615", want: "18446744073709551615"},
+		{kind: protoreflect.Uint32Kind, key: "-1", wantErr: true},
+		{kind: protoreflect.BoolKind, key: "True", want: "true"},
+		{kind: protoreflect.BoolKind, key: "yes", wantErr: true},
+		{kind: protoreflect.StringKind, key: " any ", want: " any "},
+	}
+
+	for _, tt := range tests {
+		got, err := canonicalMapKey(tt.kind, tt.key)
+		if tt.wantErr {
+			assert.Error(t, err, "%s %q", tt.kind, tt.key)
+			continue
+		}
+		if assert.NoError(t, err, "%s %q", tt.kind, tt.key) {
+			assert.Equal(t, tt.want, got)
+		}
+	}
+}
+
+func TestHandler_ToolsCallMapKeys(t *testing.T) {
+	desc := inventoryDescriptor(t)
+	cfg := config.Default()
+	cfg.Tools.NormalizeMapKeys = true
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", "shop_inventoryservice_update").
+		Return(types.MethodInfo{InputDescriptor: desc}, true)
+
+	call := func(arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "shop_inventoryservice_update",
+			"arguments": arguments,
+		}, sessionCtx)
+	}
+
+	t.Run("Canonical_keys", func(t *testing.T) {
+		var invoked string
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_inventoryservice_update", mock.Anything).
+			Run(func(args mock.Arguments) { invoked = args.String(3) }).
+			Return(`{}`, nil).Once()
+
+		_, err := call(map[string]interface{}{
+			"stock": map[string]interface{}{"+1": "one", "2.0": "two"},
+			"bins": map[string]interface{}{
+				"TRUE": map[string]interface{}{"serials": map[string]interface{}{"42.0": "a"}},
+			},
+		})
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{"stock":{"1":"one","2":"two"},"bins":{"true":{"serials":{"42":"a"}}}}`, invoked)
+		assert.NoError(t, protojson.Unmarshal([]byte(invoked), dynamicpb.NewMessage(desc)))
+	})
+
+	t.Run("Wrong_key_type", func(t *testing.T) {
+		_, err := call(map[string]interface{}{
+			"stock": map[string]interface{}{"first": "one"},
+		})
+
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		assert.Equal(t, `Invalid arguments: field stock: map key "first" is not an int32`, rpcErr.Message)
+	})
+
+	t.Run("Colliding_keys", func(t *testing.T) {
+		_, err := call(map[string]interface{}{
+			"stock": map[string]interface{}{"1": "one", "+1": "uno"},
+		})
+		assert.ErrorContains(t, err, "are the same key")
+	})
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// mediaFieldFor returns the media mapping that applies to a field
+func (h *Handler) mediaFieldFor(fd protoreflect.FieldDescriptor) (config.MediaFieldConfig, bool) {
+	fullName := string(fd.FullName())
+	for _, media := range h.mediaFields {
+		if fullName == media.Field || strings.HasSuffix(fullName, "."+media.Field) {
+			return media, true
+		}
+	}
+	return config.MediaFieldConfig{}, false
+}
+
+// extractMediaFields moves configured bytes fields out of a tool response
+// into image and audio content blocks
+func (h *Handler) extractMediaFields(toolName, result string) (string, []mcp.ContentBlock) {
+	if len(h.mediaFields) == 0 {
+		return result, nil
+	}
+
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok || method.OutputDescriptor == nil {
+		return result, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(result)))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return result, nil
+	}
+
+	var blocks []mcp.ContentBlock
+	walkBytesFields(method.OutputDescriptor, doc, func(fd protoreflect.FieldDescriptor, value interface{}) interface{} {
+		media, ok := h.mediaFieldFor(fd)
+		if !ok {
+			return value
+		}
+		encoded, ok := value.(string)
+		if !ok || encoded == "" {
+			return value
+		}
+
+		mimeType := media.MimeType
+		if mimeType == "" {
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return value
+			}
+			mimeType = http.DetectContentType(data)
+		}
+
+		if media.Type == "audio" {
+			blocks = append(blocks, mcp.AudioContent(encoded, mimeType))
+		} else {
+			blocks = append(blocks, mcp.ImageContent(encoded, mimeType))
+		}
+
+		// Leave a pointer in the text so the model knows where the media went
+		return fmt.Sprintf("[%s content block %d]", media.Type, len(blocks))
+	})
+	if len(blocks) == 0 {
+		return result, nil
+	}
+
+	encoded, err := encodeJSON(doc)
+	if err != nil {
+		h.logger.Warn("Failed to re-encode response after extracting media fields",
+			zap.String("toolName", toolName),
+			zap.Error(err))
+		return result, nil
+	}
+	return encoded, blocks
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ToolsCallMediaFields(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.MediaFields = []config.MediaFieldConfig{
+		{Field: "Document.content", Type: "image"},
+		{Field: "docs.Document.pages", Type: "audio", MimeType: "audio/wav"},
+	}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n0000"))
+	wav := base64.StdEncoding.EncodeToString([]byte("RIFF"))
+	response, err := json.Marshal(map[string]interface{}{
+		"name":    "chart",
+		"content": png,
+		"pages":   []string{wav},
+	})
+	require.NoError(t, err)
+
+	mockDiscoverer.On("GetMethodByTool", "render_service_draw").
+		Return(types.MethodInfo{OutputDescriptor: documentDescriptor(t)}, true)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "render_service_draw", "").
+		Return(string(response), nil)
+
+	result, err := handler.HandleToolsCall(context.Background(),
+		map[string]interface{}{"name": "render_service_draw"}, sessionCtx)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 3)
+
+	assert.JSONEq(t, `{"name":"chart","content":"[image content block 1]","pages":["[audio content block 2]"]}`,
+		result.Content[0].Text)
+
+	assert.Equal(t, mcp.ImageContent(png, "image/png"), result.Content[1])
+	assert.Equal(t, mcp.AudioContent(wav, "audio/wav"), result.Content[2])
+}
+
+func TestHandler_ToolsCallMediaFieldsUnmapped(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.MediaFields = []config.MediaFieldConfig{{Field: "Other.content", Type: "image"}}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", "render_service_draw").
+		Return(types.MethodInfo{OutputDescriptor: documentDescriptor(t)}, true)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "render_service_draw", "").
+		Return(`{"content":"AAEC"}`, nil)
+
+	result, err := handler.HandleToolsCall(context.Background(),
+		map[string]interface{}{"name": "render_service_draw"}, sessionCtx)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, `{"content":"AAEC"}`, result.Content[0].Text)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// metaParam is the tool call param holding the client's _meta block
+const metaParam = "_meta"
+
+// callMeta returns the _meta block of tool call params, if any
+func callMeta(params map[string]interface{}) map[string]interface{} {
+	meta, _ := params[metaParam].(map[string]interface{})
+	return meta
+}
+
+// echoMeta copies the call's _meta entries into the result's _meta block,
+// keeping the entries the gateway set
+func (h *Handler) echoMeta(params map[string]interface{}, result *mcp.ToolCallResult) {
+	if !h.meta.Echo || result == nil {
+		return
+	}
+	for key, value := range callMeta(params) {
+		if _, set := result.Meta[key]; !set {
+			result.SetMeta(key, value)
+		}
+	}
+}
+
+// metaHeaders adds the configured _meta entries of a call to its headers.
+// Strings are sent as they are and other values as JSON.
+func (h *Handler) metaHeaders(params map[string]interface{}, callHeaders map[string]string) map[string]string {
+	if len(h.meta.Forward) == 0 {
+		return callHeaders
+	}
+	meta := callMeta(params)
+	for key, name := range h.meta.Forward {
+		value, ok := meta[key]
+		if !ok || value == nil {
+			continue
+		}
+		text, isString := value.(string)
+		if !isString {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			text = string(encoded)
+		}
+		if callHeaders == nil {
+			callHeaders = make(map[string]string)
+		}
+		callHeaders[name] = text
+	}
+	return callHeaders
+}
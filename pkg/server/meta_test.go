@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Meta(t *testing.T) {
+	call := func(t *testing.T, handler *Handler, sessionCtx *session.Context, params string) *mcp.ToolCallResult {
+		result, err := handler.handleRequest(context.Background(), &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  json.RawMessage(params),
+		}, sessionCtx)
+		require.NoError(t, err)
+		return result.(*mcp.ToolCallResult)
+	}
+
+	t.Run("Echoed_in_the_result", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", "").
+			Return(`{}`, nil)
+
+		result := call(t, handler, sessionCtx, `{"name":"shop_orders_get","_meta":{"requestId":"r-1","elapsedMs":-1}}`)
+		assert.Equal(t, "r-1", result.Meta["requestId"])
+		assert.NotEqual(t, -1, result.Meta[mcp.MetaKeyElapsedMs], "gateway entries are kept")
+	})
+
+	t.Run("Echoed_on_error_results", func(t *testing.T) {
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", "").
+			Return("", assert.AnError)
+
+		result := call(t, handler, sessionCtx, `{"name":"shop_orders_get","_meta":{"requestId":"r-2"}}`)
+		assert.True(t, result.IsError)
+		assert.Equal(t, "r-2", result.Meta["requestId"])
+	})
+
+	t.Run("Not_echoed_when_disabled", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Meta.Echo = false
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", "").
+			Return(`{}`, nil)
+
+		result := call(t, handler, sessionCtx, `{"name":"shop_orders_get","_meta":{"requestId":"r-3"}}`)
+		assert.NotContains(t, result.Meta, "requestId")
+	})
+
+	t.Run("Forwarded_as_metadata", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Meta.Forward = map[string]string{
+			"traceId": "x-trace-id",
+			"attempt": "x-attempt",
+			"absent":  "x-absent",
+			"note":    "x-note",
+		}
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, map[string]string{
+			"x-trace-id": "abc",
+			"x-attempt":  "2",
+		}, "shop_orders_get", "").Return(`{}`, nil)
+
+		// Values gRPC does not allow are dropped with the other invalid headers
+		result := call(t, handler, sessionCtx, `{"name":"shop_orders_get","_meta":{"traceId":"abc","attempt":2,"note":"café","other":"x"}}`)
+		assert.False(t, result.IsError)
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Invalid_config", func(t *testing.T) {
+		for _, name := range []string{"X-Trace", "grpc-timeout", "trace-bin", ""} {
+			cfg := config.Default()
+			cfg.MCP.Meta.Forward = map[string]string{"traceId": name}
+			assert.ErrorContains(t, cfg.Validate(), "invalid metadata name", name)
+		}
+	})
+}
@@ -3,9 +3,9 @@ package server
 import (
 	"context"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/aalobaidi/ggRMCP/pkg/config"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
@@ -18,12 +18,16 @@ func LoggingMiddleware(logger *zap.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			requestID := requestIDFor(r)
+			w.Header().Set(RequestIDHeader, requestID)
+			r = r.WithContext(withRequestID(r.Context(), requestID))
 
 			// Create a response writer wrapper to capture status code
 			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
 			// Log request
 			logger.Info("Request received",
+				zap.String("request_id", requestID),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.String("remote_addr", r.RemoteAddr),
@@ -34,6 +38,7 @@ func LoggingMiddleware(logger *zap.Logger) Middleware {
 
 			// Log response
 			logger.Info("Request completed",
+				zap.String("request_id", requestID),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.Int("status", rw.statusCode),
@@ -49,7 +54,7 @@ func CORSMiddleware() Middleware {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id")
-			w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
+			w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id, X-Request-ID")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusNoContent)
@@ -63,28 +68,87 @@ func CORSMiddleware() Middleware {
 
 // SecurityMiddleware adds security headers
 func SecurityMiddleware() Middleware {
+	return SecurityMiddlewareWithConfig(config.Default().Server.Security)
+}
+
+// SecurityMiddlewareWithConfig adds the configured security headers and
+// removes the configured headers from responses
+func SecurityMiddlewareWithConfig(securityConfig config.SecurityConfig) Middleware {
+	headerConfig := securityConfig.Headers
+
+	added := make(map[string]string)
+	cacheControl := ""
+	if securityConfig.EnableHeaders {
+		cacheControl = headerConfig.CacheControl
+		added["X-Content-Type-Options"] = "nosniff"
+		added["X-Frame-Options"] = headerConfig.FrameOptions
+		added["X-XSS-Protection"] = headerConfig.XSSProtection
+		added["Strict-Transport-Security"] = headerConfig.StrictTransportSecurity
+		added["Referrer-Policy"] = headerConfig.ReferrerPolicy
+		added["Content-Security-Policy"] = headerConfig.ContentSecurityPolicy
+		for name, value := range headerConfig.Custom {
+			added[name] = value
+		}
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Security headers
-			w.Header().Set("X-Content-Type-Options", "nosniff")
-			w.Header().Set("X-Frame-Options", "DENY")
-			w.Header().Set("X-XSS-Protection", "1; mode=block")
-			w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
-			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-
-			// Content Security Policy
-			csp := "default-src 'self'; " +
-				"script-src 'self' 'unsafe-inline'; " +
-				"style-src 'self' 'unsafe-inline'; " +
-				"img-src 'self' data: https:; " +
-				"connect-src 'self'"
-			w.Header().Set("Content-Security-Policy", csp)
+			for name, value := range added {
+				if value != "" {
+					w.Header().Set(name, value)
+				}
+			}
 
-			next.ServeHTTP(w, r)
+			if len(headerConfig.Strip) == 0 && cacheControl == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(&hardenedWriter{ResponseWriter: w, strip: headerConfig.Strip, cacheControl: cacheControl}, r)
 		})
 	}
 }
 
+// hardenedWriter finalizes response headers before they are sent: it removes
+// stripped headers, including ones set by handlers, and applies the default
+// Cache-Control to responses that did not choose their own
+type hardenedWriter struct {
+	http.ResponseWriter
+	strip        []string
+	cacheControl string
+	wroteHeader  bool
+}
+
+func (hw *hardenedWriter) finalizeHeaders() {
+	if hw.wroteHeader {
+		return
+	}
+	hw.wroteHeader = true
+
+	header := hw.ResponseWriter.Header()
+	for _, name := range hw.strip {
+		header.Del(name)
+	}
+	if hw.cacheControl != "" && header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", hw.cacheControl)
+	}
+}
+
+func (hw *hardenedWriter) WriteHeader(code int) {
+	hw.finalizeHeaders()
+	hw.ResponseWriter.WriteHeader(code)
+}
+
+func (hw *hardenedWriter) Write(data []byte) (int, error) {
+	hw.finalizeHeaders()
+	return hw.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the underlying writer so http.ResponseController can reach it
+func (hw *hardenedWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
 // RateLimitMiddleware adds rate limiting
 func RateLimitMiddleware(requestsPerSecond int, burst int) Middleware {
 	limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
@@ -133,24 +197,10 @@ func SessionRateLimitMiddleware(requestsPerSecond int, burst int) Middleware {
 func ContentTypeMiddleware(allowedTypes ...string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == "POST" || r.Method == "PUT" {
-				contentType := r.Header.Get("Content-Type")
-				if contentType == "" {
-					http.Error(w, "Content-Type header is required", http.StatusBadRequest)
-					return
-				}
-
-				// Check if content type is allowed
-				allowed := false
-				for _, allowedType := range allowedTypes {
-					if strings.Contains(contentType, allowedType) {
-						allowed = true
-						break
-					}
-				}
-
-				if !allowed {
-					http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+			// Uploads carry arbitrary content types
+			if (r.Method == "POST" || r.Method == "PUT") && r.URL.Path != UploadPath {
+				if message, ok := checkContentType(r.Header.Get("Content-Type"), allowedTypes); !ok {
+					http.Error(w, message, http.StatusUnsupportedMediaType)
 					return
 				}
 			}
@@ -164,6 +214,12 @@ func ContentTypeMiddleware(allowedTypes ...string) Middleware {
 func RequestSizeMiddleware(maxBytes int64) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Uploads and session imports are limited by their handlers
+			if r.URL.Path == UploadPath || r.URL.Path == SessionsImportPath {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			if r.ContentLength > maxBytes {
 				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
 				return
@@ -192,22 +248,7 @@ func TimeoutMiddleware(timeout time.Duration) Middleware {
 
 // RecoveryMiddleware recovers from panics
 func RecoveryMiddleware(logger *zap.Logger) Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					logger.Error("Panic recovered",
-						zap.String("method", r.Method),
-						zap.String("path", r.URL.Path),
-						zap.Any("error", err))
-
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				}
-			}()
-
-			next.ServeHTTP(w, r)
-		})
-	}
+	return newPanicRecovery(config.Default().Server.Middleware.Recovery, logger).middleware()
 }
 
 // MetricsMiddleware adds metrics collection
@@ -243,6 +284,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap returns the underlying writer so http.ResponseController can reach it
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // ChainMiddleware chains multiple middleware functions
 func ChainMiddleware(middlewares ...Middleware) Middleware {
 	return func(next http.Handler) http.Handler {
@@ -278,16 +324,50 @@ func ValidateJSONRPC() Middleware {
 
 // DefaultMiddleware returns a set of default middleware
 func DefaultMiddleware(logger *zap.Logger) []Middleware {
-	return []Middleware{
-		RecoveryMiddleware(logger),
-		LoggingMiddleware(logger),
-		SecurityMiddleware(),
-		CORSMiddleware(),
-		RateLimitMiddleware(100, 200), // 100 requests per second, burst of 200
-		ContentTypeMiddleware("application/json"),
-		RequestSizeMiddleware(1024 * 1024),  // 1MB max request size
-		TimeoutMiddleware(30 * time.Second), // 30 second timeout
-		MetricsMiddleware(),
-		ValidateJSONRPC(),
+	defaults := config.Default()
+	return buildMiddleware(logger, nil, newPanicRecovery(defaults.Server.Middleware.Recovery, logger),
+		defaults.Server.Security, defaults.Server.Timeout)
+}
+
+// Middleware returns the middleware chain in the configured order, recovering
+// panics with the handler's recovery settings
+func (h *Handler) Middleware() []Middleware {
+	return buildMiddleware(h.logger, h.middlewareOrder, h.recovery, h.security, h.requestTimeout)
+}
+
+// buildMiddleware creates the named middleware in order (the default order if empty)
+func buildMiddleware(logger *zap.Logger, order []string, recovery *panicRecovery, security config.SecurityConfig, timeout time.Duration) []Middleware {
+	if len(order) == 0 {
+		order = config.MiddlewareNames
+	}
+
+	middlewares := make([]Middleware, 0, len(order))
+	for _, name := range order {
+		switch name {
+		case "recovery":
+			middlewares = append(middlewares, recovery.middleware())
+		case "logging":
+			middlewares = append(middlewares, LoggingMiddleware(logger))
+		case "security":
+			middlewares = append(middlewares, SecurityMiddlewareWithConfig(security))
+		case "cors":
+			middlewares = append(middlewares, CORSMiddleware())
+		case "rate_limit":
+			middlewares = append(middlewares, RateLimitMiddleware(100, 200)) // 100 requests per second, burst of 200
+		case "content_type":
+			middlewares = append(middlewares, ContentTypeMiddleware(jsonRPCMediaTypes...))
+		case "request_size":
+			middlewares = append(middlewares, RequestSizeMiddleware(1024*1024)) // 1MB max request size
+		case "timeout":
+			middlewares = append(middlewares, TimeoutMiddleware(timeout))
+		case "metrics":
+			middlewares = append(middlewares, MetricsMiddleware())
+		case "validate_jsonrpc":
+			middlewares = append(middlewares, ValidateJSONRPC())
+		default:
+			logger.Warn("Ignoring unknown middleware", zap.String("name", name))
+		}
 	}
+
+	return middlewares
 }
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityMiddlewareWithConfig(t *testing.T) {
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "backend/1.2")
+		w.Header().Set("X-Powered-By", "grpc-go")
+		if r.URL.Path == "/stream" {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		_, _ = w.Write([]byte(`{"result":{}}`))
+	})
+
+	serve := func(securityConfig config.SecurityConfig, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		SecurityMiddlewareWithConfig(securityConfig)(backend).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		return rec
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		rec := serve(config.Default().Server.Security, "/")
+
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Empty(t, rec.Header().Get("Server"))
+		assert.Empty(t, rec.Header().Get("X-Powered-By"))
+	})
+
+	t.Run("Handler_cache_control_kept", func(t *testing.T) {
+		rec := serve(config.Default().Server.Security, "/stream")
+
+		assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		securityConfig := config.Default().Server.Security
+		securityConfig.Headers.FrameOptions = ""
+		securityConfig.Headers.Custom = map[string]string{"Permissions-Policy": "camera=()"}
+		securityConfig.Headers.Strip = []string{"Server"}
+		rec := serve(securityConfig, "/")
+
+		assert.Empty(t, rec.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "camera=()", rec.Header().Get("Permissions-Policy"))
+		assert.Empty(t, rec.Header().Get("Server"))
+		assert.Equal(t, "grpc-go", rec.Header().Get("X-Powered-By"))
+	})
+
+	t.Run("Headers_disabled", func(t *testing.T) {
+		securityConfig := config.Default().Server.Security
+		securityConfig.EnableHeaders = false
+		rec := serve(securityConfig, "/")
+
+		assert.Empty(t, rec.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, rec.Header().Get("Cache-Control"))
+		assert.Empty(t, rec.Header().Get("Server"))
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// Routes of the session migration endpoints
+const (
+	SessionsExportPath = "/admin/sessions/export"
+	SessionsImportPath = "/admin/sessions/import"
+)
+
+// sessionsDocument is the body of an export response and an import request
+type sessionsDocument struct {
+	Sessions []session.Snapshot `json:"sessions"`
+}
+
+// authorizeMigration checks that migration is enabled and the request
+// carries the admin token, writing the error response if not
+func (h *Handler) authorizeMigration(w http.ResponseWriter, r *http.Request) bool {
+	if !h.migration.Enabled {
+		http.Error(w, "Session migration is not enabled", http.StatusNotFound)
+		return false
+	}
+
+	return h.requireBearer(w, r, h.migration.Token, "session migration")
+}
+
+// SessionsExportHandler returns the state of all active sessions so another
+// replica can take them over
+func (h *Handler) SessionsExportHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeMigration(w, r) {
+		return
+	}
+
+	snapshots := h.sessionManager.Export()
+	h.logger.Info("Exported sessions", zap.Int("count", len(snapshots)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(sessionsDocument{Sessions: snapshots}); err != nil {
+		h.logger.Error("Failed to encode sessions", zap.Error(err))
+	}
+}
+
+// SessionsImportHandler restores sessions exported by another replica
+func (h *Handler) SessionsImportHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeMigration(w, r) {
+		return
+	}
+
+	var document sessionsDocument
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.migration.MaxImportSize)).Decode(&document); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Import too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid session export", http.StatusBadRequest)
+		return
+	}
+
+	result := h.sessionManager.Import(document.Sessions)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("Failed to encode import result", zap.Error(err))
+	}
+}
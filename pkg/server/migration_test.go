@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_SessionMigration(t *testing.T) {
+	cfg := config.Default()
+	cfg.Session.Migration.Enabled = true
+	cfg.Session.Migration.Token = "secret"
+
+	source, _, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.SetHeader("Authorization", "Bearer upstream")
+	sessionCtx.SetRootsSupported(true)
+	sessionCtx.SetRoots([]string{"file:///work"})
+	sessionCtx.IncrementCallCount()
+
+	target, _, existing := newTestHandler(t, cfg)
+
+	t.Run("Requires_token", func(t *testing.T) {
+		for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+			req := httptest.NewRequest(http.MethodGet, SessionsExportPath, nil)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			rec := httptest.NewRecorder()
+			source.SessionsExportHandler(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, authorization)
+		}
+	})
+
+	t.Run("Export_then_import", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, SessionsExportPath, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		source.SessionsExportHandler(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		// Include a session the target already holds
+		var document sessionsDocument
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &document))
+		require.Len(t, document.Sessions, 1)
+		document.Sessions = append(document.Sessions, existing.Snapshot())
+		body, err := json.Marshal(document)
+		require.NoError(t, err)
+
+		req = httptest.NewRequest(http.MethodPost, SessionsImportPath, strings.NewReader(string(body)))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec = httptest.NewRecorder()
+		target.SessionsImportHandler(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"imported":1,"skipped":1}`, rec.Body.String())
+
+		imported, ok := target.sessionManager.GetSession(sessionCtx.ID)
+		require.True(t, ok)
+		assert.Equal(t, "Bearer upstream", imported.GetHeader("Authorization"))
+		assert.Equal(t, int64(1), imported.GetCallCount())
+		assert.True(t, imported.RootsSupported())
+		roots, known := imported.GetRoots()
+		assert.True(t, known)
+		assert.Equal(t, []string{"file:///work"}, roots)
+	})
+
+	t.Run("Invalid_body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, SessionsImportPath, strings.NewReader("not json"))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		target.SessionsImportHandler(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestHandler_SessionMigrationDisabled(t *testing.T) {
+	handler, _, _ := newTestHandler(t, config.Default())
+
+	req := httptest.NewRequest(http.MethodGet, SessionsExportPath, nil)
+	rec := httptest.NewRecorder()
+	handler.SessionsExportHandler(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSessionManager_StateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	source := session.NewManager(zap.NewNop())
+	t.Cleanup(func() { _ = source.Close() })
+	created := source.CreateSession(map[string]string{"X-Tenant": "acme"})
+	require.NoError(t, source.SaveState(path))
+
+	target := session.NewManager(zap.NewNop())
+	t.Cleanup(func() { _ = target.Close() })
+	result, err := target.LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	restored, ok := target.GetSession(created.ID)
+	require.True(t, ok)
+	assert.Equal(t, "acme", restored.GetHeader("X-Tenant"))
+
+	// A missing file means there is nothing to restore
+	result, err = target.LoadState(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Zero(t, result.Imported)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// notificationBuffer is the number of notifications queued for a slow
+// listener before further ones are dropped
+const notificationBuffer = 16
+
+// notifier fans server notifications out to the clients listening on a GET
+// event stream
+type notifier struct {
+	mu        sync.Mutex
+	listeners map[chan []byte]struct{}
+	closed    bool
+}
+
+func newNotifier() *notifier {
+	return &notifier{listeners: make(map[chan []byte]struct{})}
+}
+
+// subscribe registers a listener; the channel is closed when the notifier is
+func (n *notifier) subscribe() (chan []byte, func()) {
+	ch := make(chan []byte, notificationBuffer)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	n.listeners[ch] = struct{}{}
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if _, ok := n.listeners[ch]; ok {
+			delete(n.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+// broadcast sends a notification to every listener and returns how many
+// received it
+func (n *notifier) broadcast(method string) int {
+	data, _ := json.Marshal(map[string]string{"jsonrpc": "2.0", "method": method})
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sent := 0
+	for ch := range n.listeners {
+		select {
+		case ch <- data:
+			sent++
+		default:
+		}
+	}
+	return sent
+}
+
+// close ends every listener's stream
+func (n *notifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	for ch := range n.listeners {
+		delete(n.listeners, ch)
+		close(ch)
+	}
+}
+
+// serveNotifications keeps a GET event stream open and writes the server's
+// notifications to it until the client goes away or the server shuts down
+func (h *Handler) serveNotifications(w http.ResponseWriter, r *http.Request, sessionCtx *session.Context) {
+	notifications, unsubscribe := h.notifications.subscribe()
+	defer unsubscribe()
+
+	stream := h.startEventStream(w)
+	// The stream outlives the server's write timeout; each send sets its own deadline
+	_ = stream.controller.SetWriteDeadline(time.Time{})
+	_ = stream.controller.Flush()
+
+	h.logger.Debug("Notification stream opened", zap.String("sessionId", sessionCtx.ID))
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if written, err := stream.send(data); err != nil {
+				h.logWriteError(err, zap.Int("bytesWritten", written), zap.Int("bytesTotal", len(data)))
+				return
+			}
+		}
+	}
+}
+
+// CloseNotifications ends the notification streams, e.g. when shutting down
+func (h *Handler) CloseNotifications() {
+	if h.notifications != nil {
+		h.notifications.close()
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/jsonwalk"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Wrapper types whose JSON form is a plain number
+const (
+	doubleValueName protoreflect.FullName = "google.protobuf.DoubleValue"
+	floatValueName  protoreflect.FullName = "google.protobuf.FloatValue"
+)
+
+// clientArgumentsKey carries the arguments of a call as the client sent them
+type clientArgumentsKey struct{}
+
+// clientArguments pairs the decoded arguments of a call with their raw bytes
+type clientArguments struct {
+	decoded map[string]interface{}
+	raw     json.RawMessage
+}
+
+// withClientArguments returns a context carrying the raw bytes of the
+// decoded arguments, so they can be passed on if no stage replaces them
+func withClientArguments(ctx context.Context, decoded map[string]interface{}, raw json.RawMessage) context.Context {
+	if decoded == nil || len(raw) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, clientArgumentsKey{}, clientArguments{decoded: decoded, raw: raw})
+}
+
+// encodeArguments returns the JSON of a call's arguments. While they are
+// still the map decoded from the client's request, the client's own bytes are
+// returned, so numbers and key order reach protojson untouched. Stages that
+// change arguments work on copies, so any other map is encoded afresh.
+func encodeArguments(ctx context.Context, params map[string]interface{}) (string, error) {
+	args, exists := params["arguments"]
+	if !exists || args == nil {
+		return "", nil
+	}
+	if client, ok := ctx.Value(clientArgumentsKey{}).(clientArguments); ok {
+		if decoded, ok := args.(map[string]interface{}); ok &&
+			reflect.ValueOf(decoded).UnsafePointer() == reflect.ValueOf(client.decoded).UnsafePointer() {
+			return string(client.raw), nil
+		}
+	}
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// batchArguments returns the raw arguments of each call of a batch, or nil
+// when the batch cannot be read that way
+func batchArguments(req *mcp.JSONRPCRequest) []json.RawMessage {
+	var batch struct {
+		Calls []struct {
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"calls"`
+	}
+	if err := json.Unmarshal(req.Params, &batch); err != nil {
+		return nil
+	}
+	raw := make([]json.RawMessage, len(batch.Calls))
+	for i, call := range batch.Calls {
+		raw[i] = call.Arguments
+	}
+	return raw
+}
+
+// checkDoublePrecision refuses, when configured, number arguments for double
+// and float fields that the field cannot hold exactly
+func (h *Handler) checkDoublePrecision(args *decodedArguments) error {
+	if h.doublePrecision != "reject" {
+		return nil
+	}
+
+	var inexact []string
+	jsonwalk.Walker{
+		Match: isFloatingField,
+		Visit: func(fd protoreflect.FieldDescriptor, path string, value interface{}) interface{} {
+			if number, ok := value.(json.Number); ok && !holdsExactly(string(number), floatingBits(fd)) {
+				inexact = append(inexact, fmt.Sprintf("field %s: %s cannot be held exactly by a %d-bit float", path, number, floatingBits(fd)))
+			}
+			return value
+		},
+	}.Walk(args.desc, args.doc)
+	if len(inexact) == 0 {
+		return nil
+	}
+	return mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %s", strings.Join(inexact, "; ")))
+}
+
+// isFloatingField reports whether a field holds a double or float, directly or wrapped
+func isFloatingField(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		return true
+	}
+	return fd.Message() != nil &&
+		(fd.Message().FullName() == doubleValueName || fd.Message().FullName() == floatValueName)
+}
+
+// floatingBits returns the size of a floating-point field
+func floatingBits(fd protoreflect.FieldDescriptor) int {
+	if fd.Kind() == protoreflect.FloatKind || (fd.Message() != nil && fd.Message().FullName() == floatValueName) {
+		return 32
+	}
+	return 64
+}
+
+// holdsExactly reports whether the nearest float of the given size, written
+// in its shortest form, is the number the literal names
+func holdsExactly(literal string, bits int) bool {
+	value, err := strconv.ParseFloat(literal, bits)
+	if err != nil {
+		return false
+	}
+	return canonicalDecimal(literal) == canonicalDecimal(strconv.FormatFloat(value, 'g', -1, bits))
+}
+
+// canonicalDecimal writes a JSON number as its sign, significant digits and
+// exponent, so numbers of equal value give equal strings
+func canonicalDecimal(literal string) string {
+	sign := ""
+	if strings.HasPrefix(literal, "-") {
+		sign, literal = "-", literal[1:]
+	}
+	mantissa, exponentText, _ := strings.Cut(strings.ToLower(literal), "e")
+	exponent := 0
+	if exponentText != "" {
+		parsed, err := strconv.Atoi(exponentText)
+		if err != nil {
+			return literal
+		}
+		exponent = parsed
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := strings.TrimLeft(whole+fraction, "0")
+	exponent -= len(fraction)
+	significant := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(significant)
+	if significant == "" {
+		return "0"
+	}
+	return fmt.Sprintf("%s%se%d", sign, significant, exponent)
+}
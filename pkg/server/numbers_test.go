@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldsExactly(t *testing.T) {
+	for _, literal := range []string{"0", "-0", "0.1", "1.5e300", "100", "1E2", "0.30000000000000004", "9007199254740992", "-2.5"} {
+		assert.True(t, holdsExactly(literal, 64), literal)
+	}
+	for _, literal := range []string{"0.30000000000000004441", "9007199254740993", "1e400", "1e-400"} {
+		assert.False(t, holdsExactly(literal, 64), literal)
+	}
+	assert.True(t, holdsExactly("0.1", 32))
+	assert.False(t, holdsExactly("16777217", 32))
+	assert.True(t, holdsExactly("16777217", 64))
+}
+
+func TestHandler_DoublePrecision(t *testing.T) {
+	sample := fuzzDescriptor(t)
+	method := types.MethodInfo{
+		Name:             "Put",
+		FullName:         "fuzz.SampleService.Put",
+		ServiceName:      "fuzz.SampleService",
+		InputDescriptor:  sample,
+		OutputDescriptor: sample,
+	}
+	method.ToolName = method.GenerateToolName()
+
+	newHandler := func(t *testing.T, mode string) (*Handler, *mockServiceDiscoverer) {
+		cfg := config.Default()
+		cfg.Tools.DoublePrecision = mode
+		require.NoError(t, cfg.Validate())
+		handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+		mockDiscoverer.On("GetMethodByTool", method.ToolName).Return(method, true).Maybe()
+		return handler, mockDiscoverer
+	}
+	call := func(handler *Handler, arguments string) error {
+		_, err := handler.handleRequest(context.Background(), &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"` + method.ToolName + `","arguments":` + arguments + `}`),
+		}, handler.sessionManager.CreateSession(map[string]string{}))
+		return err
+	}
+
+	t.Run("Round", func(t *testing.T) {
+		handler, mockDiscoverer := newHandler(t, "round")
+		arguments := `{"ratio":0.30000000000000004441}`
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, method.ToolName, arguments).Return(`{}`, nil).Once()
+		require.NoError(t, call(handler, arguments))
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		handler, mockDiscoverer := newHandler(t, "reject")
+		err := call(handler, `{"ratio":0.30000000000000004441,"parent":{"ratio":9007199254740993}}`)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "ratio: 0.30000000000000004441 cannot be held exactly by a 64-bit float")
+		assert.Contains(t, err.Error(), "ratio: 9007199254740993 cannot be held exactly")
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		// Exact doubles and large integers in int64 fields pass
+		arguments := `{"ratio":0.1,"count":9007199254740993}`
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, method.ToolName, arguments).Return(`{}`, nil).Once()
+		require.NoError(t, call(handler, arguments))
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Invalid_config", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Tools.DoublePrecision = "truncate"
+		assert.ErrorContains(t, cfg.Validate(), "double precision")
+	})
+}
+
+func TestEncodeArguments(t *testing.T) {
+	raw := json.RawMessage(`{"b": 1, "a": 9007199254740993}`)
+	var params mcp.ToolsCallParams
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"x","arguments":`+string(raw)+`}`), &params))
+	assert.Equal(t, raw, params.RawArguments)
+	ctx := withClientArguments(context.Background(), params.Arguments, params.RawArguments)
+
+	// The client's bytes while the arguments are the decoded map
+	encoded, err := encodeArguments(ctx, params.Map())
+	require.NoError(t, err)
+	assert.Equal(t, string(raw), encoded)
+
+	// A fresh encoding once a stage has replaced them
+	changed, _, _ := removeArgument(params.Map(), "b")
+	encoded, err = encodeArguments(ctx, changed)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":9007199254740993}`, encoded)
+
+	encoded, err = encodeArguments(ctx, map[string]interface{}{"name": "x"})
+	require.NoError(t, err)
+	assert.Empty(t, encoded)
+}
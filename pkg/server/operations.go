@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PollOperationToolName is the gateway tool checking on a long-running operation
+const PollOperationToolName = "ggrmcp_poll_operation"
+
+// operationState is the JSON form of a google.longrunning.Operation
+type operationState struct {
+	Name     string          `json:"name"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Done     bool            `json:"done"`
+	Error    *operationError `json:"error,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// operationError is the JSON form of the google.rpc.Status of a failed operation
+type operationError struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// pendingOperation is returned for an operation that is still running
+type pendingOperation struct {
+	Name     string          `json:"name"`
+	Done     bool            `json:"done"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Poll     string          `json:"poll"`
+}
+
+// pollOperationTool describes the poll tool
+func pollOperationTool() mcp.Tool {
+	return mcp.Tool{
+		Name: PollOperationToolName,
+		Description: "Checks on a long-running operation started by another tool. Returns the " +
+			"operation's response once it is done, or its name and progress metadata while it runs.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the operation, as returned by the tool that started it",
+				},
+			},
+			"required":             []string{"name"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// callPollOperationTool polls an operation once
+func (h *Handler) callPollOperationTool(ctx context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	name, _ := arguments["name"].(string)
+	if name == "" {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid name argument")
+	}
+
+	headers := h.forwardedHeaders(sessionCtx, nil)
+	polled, err := h.serviceDiscoverer.GetOperation(ctx, headers, name)
+	if err != nil {
+		return operationErrorResult("Error polling operation", err), nil
+	}
+	operation, err := parseOperation(polled)
+	if err != nil {
+		return operationErrorResult("Error polling operation", err), nil
+	}
+	result, err := operation.result()
+	if err != nil {
+		return operationErrorResult("Operation failed", err), nil
+	}
+	return &mcp.ToolCallResult{Content: []mcp.ContentBlock{mcp.TextContent(result)}}, nil
+}
+
+// operationErrorResult reports a failed poll or operation as a tool error
+func operationErrorResult(prefix string, err error) *mcp.ToolCallResult {
+	return &mcp.ToolCallResult{
+		Content: []mcp.ContentBlock{mcp.TextContent(fmt.Sprintf("%s: %s", prefix, mcp.SanitizeError(err)))},
+		IsError: true,
+	}
+}
+
+// resolveOperation replaces the operation returned by a method starting a
+// long-running operation: in "wait" mode it is polled until done, bounded by
+// the timeout. A finished operation becomes its response or its error; a
+// pending one is returned with a hint to poll it.
+func (h *Handler) resolveOperation(ctx context.Context, toolName string, result string, headers map[string]string) (string, error) {
+	if h.longRunning.Mode == "" {
+		return result, nil
+	}
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok || !grpc.ReturnsOperation(method) {
+		return result, nil
+	}
+
+	operation, err := parseOperation(result)
+	if err != nil {
+		return "", err
+	}
+	if !operation.Done && h.longRunning.Mode == "wait" {
+		if operation, err = h.waitForOperation(ctx, headers, operation); err != nil {
+			return "", err
+		}
+	}
+	return operation.result()
+}
+
+// waitForOperation polls an operation until it is done or the timeout passes,
+// returning its last state
+func (h *Handler) waitForOperation(ctx context.Context, headers map[string]string, operation operationState) (operationState, error) {
+	deadline := time.Now().Add(h.longRunning.Timeout)
+	ticker := time.NewTicker(h.longRunning.PollInterval)
+	defer ticker.Stop()
+
+	for !operation.Done {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			logging.FromContext(ctx, h.logger).Info("Long-running operation still pending after timeout",
+				zap.String("operation", operation.Name),
+				zap.Duration("timeout", h.longRunning.Timeout))
+			return operation, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return operation, ctx.Err()
+		case <-ticker.C:
+		}
+
+		pollCtx, cancel := context.WithTimeout(ctx, remaining)
+		polled, err := h.serviceDiscoverer.GetOperation(pollCtx, headers, operation.Name)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && time.Until(deadline) <= 0 {
+				return operation, nil
+			}
+			return operation, err
+		}
+		if operation, err = parseOperation(polled); err != nil {
+			return operation, err
+		}
+	}
+	return operation, nil
+}
+
+// parseOperation reads an operation from its JSON form
+func parseOperation(result string) (operationState, error) {
+	var operation operationState
+	if err := json.Unmarshal([]byte(result), &operation); err != nil {
+		return operationState{}, fmt.Errorf("failed to parse operation: %w", err)
+	}
+	if operation.Name == "" && !operation.Done {
+		return operationState{}, fmt.Errorf("failed to parse operation: pending operation has no name")
+	}
+	return operation, nil
+}
+
+// result returns a finished operation's response without its type URL, a
+// failed operation's error as a gRPC status, or a pending operation's state
+func (o operationState) result() (string, error) {
+	if !o.Done {
+		encoded, err := json.Marshal(pendingOperation{
+			Name:     o.Name,
+			Metadata: o.Metadata,
+			Poll:     fmt.Sprintf("The operation is still running; call %s with this name to check on it.", PollOperationToolName),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode operation: %w", err)
+		}
+		return string(encoded), nil
+	}
+
+	if o.Error != nil {
+		return "", status.Error(codes.Code(o.Error.Code), o.Error.Message)
+	}
+	if len(o.Response) == 0 {
+		return "{}", nil
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(o.Response, &response); err != nil {
+		return string(o.Response), nil
+	}
+	delete(response, "@type")
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode operation response: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// advertiseOperations tells clients how the tools starting long-running operations behave
+func (h *Handler) advertiseOperations(methods []types.MethodInfo, tools []mcp.Tool) []mcp.Tool {
+	var note string
+	switch h.longRunning.Mode {
+	case "wait":
+		note = fmt.Sprintf("\n\nStarts a long-running operation and waits up to %s for its response. "+
+			"If it is still running then, the operation's name is returned; check on it with %s.",
+			h.longRunning.Timeout, PollOperationToolName)
+	case "handle":
+		note = fmt.Sprintf("\n\nStarts a long-running operation and returns its name while it runs; "+
+			"check on it with %s.", PollOperationToolName)
+	default:
+		return tools
+	}
+
+	operations := make(map[string]bool)
+	for _, method := range methods {
+		if grpc.ReturnsOperation(method) {
+			operations[toolNameOf(method)] = true
+		}
+	}
+	for i, tool := range tools {
+		if operations[tool.Name] {
+			tools[i].Description += note
+		}
+	}
+	return tools
+}
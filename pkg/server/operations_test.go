@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pendingExport = `{"name":"operations/1","metadata":{"@type":"type.googleapis.com/shop.ExportProgress","percent":40}}`
+	doneExport    = `{"name":"operations/1","done":true,"response":{"@type":"type.googleapis.com/shop.ExportResult","url":"https://example.com/export.csv"}}`
+	failedExport  = `{"name":"operations/1","done":true,"error":{"code":8,"message":"export quota exhausted"}}`
+)
+
+func TestHandler_LongRunningOperations(t *testing.T) {
+	export := types.MethodInfo{
+		Name:        "Export",
+		ServiceName: "shop.ExportService",
+		OutputType:  grpc.OperationType,
+	}
+	export.ToolName = export.GenerateToolName()
+
+	newHandler := func(t *testing.T, mode string) (*Handler, *mockServiceDiscoverer, func() (*mcp.ToolCallResult, error)) {
+		cfg := config.Default()
+		cfg.Tools.LongRunning.Mode = mode
+		cfg.Tools.LongRunning.Timeout = 200 * time.Millisecond
+		cfg.Tools.LongRunning.PollInterval = 5 * time.Millisecond
+		handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+		mockDiscoverer.On("GetMethodByTool", export.ToolName).Return(export, true)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, export.ToolName, "").Return(pendingExport, nil)
+
+		call := func() (*mcp.ToolCallResult, error) {
+			return handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": export.ToolName}, sessionCtx)
+		}
+		return handler, mockDiscoverer, call
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, call := newHandler(t, "")
+		assert.Empty(t, handler.builtinToolList())
+
+		result, err := call()
+		require.NoError(t, err)
+		assert.Equal(t, pendingExport, result.Content[0].Text)
+	})
+
+	t.Run("Wait_until_done", func(t *testing.T) {
+		_, mockDiscoverer, call := newHandler(t, "wait")
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(pendingExport, nil).Once()
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(doneExport, nil).Once()
+
+		result, err := call()
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.JSONEq(t, `{"url":"https://example.com/export.csv"}`, result.Content[0].Text)
+		mockDiscoverer.AssertNumberOfCalls(t, "GetOperation", 2)
+	})
+
+	t.Run("Wait_until_failed", func(t *testing.T) {
+		_, mockDiscoverer, call := newHandler(t, "wait")
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(failedExport, nil)
+
+		result, err := call()
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].Text, "export quota exhausted")
+		assert.Equal(t, "ResourceExhausted", result.Meta[mcp.MetaKeyUpstreamStatus])
+	})
+
+	t.Run("Wait_times_out", func(t *testing.T) {
+		_, mockDiscoverer, call := newHandler(t, "wait")
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(pendingExport, nil)
+
+		result, err := call()
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.JSONEq(t, `{
+			"name": "operations/1",
+			"done": false,
+			"metadata": {"@type": "type.googleapis.com/shop.ExportProgress", "percent": 40},
+			"poll": "The operation is still running; call ggrmcp_poll_operation with this name to check on it."
+		}`, result.Content[0].Text)
+	})
+
+	t.Run("Handle_and_poll", func(t *testing.T) {
+		handler, mockDiscoverer, call := newHandler(t, "handle")
+		tools := handler.builtinToolList()
+		require.Len(t, tools, 1)
+		assert.Equal(t, PollOperationToolName, tools[0].Name)
+
+		result, err := call()
+		require.NoError(t, err)
+		assert.Contains(t, result.Content[0].Text, `"poll"`)
+		mockDiscoverer.AssertNotCalled(t, "GetOperation", mock.Anything, mock.Anything, mock.Anything)
+
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{})
+		poll := func() *mcp.ToolCallResult {
+			result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+				"name":      PollOperationToolName,
+				"arguments": map[string]interface{}{"name": "operations/1"},
+			}, sessionCtx)
+			require.NoError(t, err)
+			return result
+		}
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(pendingExport, nil).Once()
+		assert.Contains(t, poll().Content[0].Text, `"percent":40`)
+
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(doneExport, nil).Once()
+		assert.JSONEq(t, `{"url":"https://example.com/export.csv"}`, poll().Content[0].Text)
+
+		mockDiscoverer.On("GetOperation", mock.Anything, mock.Anything, "operations/1").Return(failedExport, nil).Once()
+		failed := poll()
+		assert.True(t, failed.IsError)
+		assert.Contains(t, failed.Content[0].Text, "Operation failed")
+
+		_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": PollOperationToolName}, sessionCtx)
+		assert.Error(t, err)
+	})
+
+	t.Run("Advertised", func(t *testing.T) {
+		handler, _, _ := newHandler(t, "handle")
+		place := types.MethodInfo{Name: "Place", ServiceName: "shop.OrderService", OutputType: "shop.Order"}
+		place.ToolName = place.GenerateToolName()
+
+		tools := handler.advertiseOperations([]types.MethodInfo{export, place}, []mcp.Tool{
+			{Name: export.ToolName, Description: "Exports orders."},
+			{Name: place.ToolName, Description: "Places an order."},
+		})
+		assert.Contains(t, tools[0].Description, "Starts a long-running operation")
+		assert.Equal(t, "Places an order.", tools[1].Description)
+	})
+}
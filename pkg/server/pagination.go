@@ -0,0 +1,200 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// pagesArgument is the argument asking for several pages of a list method
+const pagesArgument = "_pages"
+
+// paginatedMethod names the fields used to page through a list method
+type paginatedMethod struct {
+	pageToken     protoreflect.FieldDescriptor // request field carrying the token
+	nextPageToken string                       // response field with the next token
+	items         string                       // repeated response field concatenated across pages
+}
+
+// detectPagination finds the standard pagination fields of a method: a
+// page_token request field, a next_page_token response field and the
+// repeated response field holding the items (the one named items, or the
+// only repeated field)
+func detectPagination(method types.MethodInfo) (*paginatedMethod, bool) {
+	if method.InputDescriptor == nil || method.OutputDescriptor == nil ||
+		method.IsClientStreaming || method.IsServerStreaming {
+		return nil, false
+	}
+
+	pageToken := method.InputDescriptor.Fields().ByName("page_token")
+	if pageToken == nil || pageToken.Kind() != protoreflect.StringKind || pageToken.IsList() {
+		return nil, false
+	}
+	output := method.OutputDescriptor.Fields()
+	nextPageToken := output.ByName("next_page_token")
+	if nextPageToken == nil || nextPageToken.Kind() != protoreflect.StringKind || nextPageToken.IsList() {
+		return nil, false
+	}
+
+	var items protoreflect.FieldDescriptor
+	if named := output.ByName("items"); named != nil && named.IsList() {
+		items = named
+	} else {
+		for i := 0; i < output.Len(); i++ {
+			if !output.Get(i).IsList() {
+				continue
+			}
+			if items != nil {
+				// Several candidates: the items to concatenate are ambiguous
+				return nil, false
+			}
+			items = output.Get(i)
+		}
+	}
+	if items == nil {
+		return nil, false
+	}
+
+	return &paginatedMethod{
+		pageToken:     pageToken,
+		nextPageToken: nextPageToken.JSONName(),
+		items:         items.JSONName(),
+	}, true
+}
+
+// pageSummary describes the pages an auto-paginated call fetched
+type pageSummary struct {
+	fetched int
+	more    bool
+}
+
+// fetch invokes the method up to pages times, following next_page_token,
+// and returns the first response with the items of every page and the last
+// next_page_token
+func (p *paginatedMethod) fetch(argumentsJSON string, pages int, invoke func(argumentsJSON string) (string, error)) (string, pageSummary, error) {
+	arguments := make(map[string]interface{})
+	if argumentsJSON != "" {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(argumentsJSON)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&arguments); err != nil {
+			return "", pageSummary{}, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid arguments: not a JSON object")
+		}
+	}
+
+	var merged map[string]interface{}
+	items := []interface{}{}
+	var summary pageSummary
+	for {
+		response, err := invoke(argumentsJSON)
+		if err != nil {
+			return "", summary, err
+		}
+		summary.fetched++
+
+		decoder := json.NewDecoder(bytes.NewReader([]byte(response)))
+		decoder.UseNumber()
+		var page map[string]interface{}
+		if err := decoder.Decode(&page); err != nil {
+			return "", summary, fmt.Errorf("page %d is not a JSON object", summary.fetched)
+		}
+		if merged == nil {
+			merged = page
+		}
+		if pageItems, ok := page[p.items].([]interface{}); ok {
+			items = append(items, pageItems...)
+		}
+
+		token, _ := page[p.nextPageToken].(string)
+		previous, _ := arguments[p.pageToken.JSONName()].(string)
+		summary.more = token != ""
+		if token == "" || token == previous || summary.fetched >= pages {
+			if token == "" {
+				delete(merged, p.nextPageToken)
+			} else {
+				merged[p.nextPageToken] = token
+			}
+			break
+		}
+
+		// Request the next page, replacing a token given under either field name
+		delete(arguments, string(p.pageToken.Name()))
+		arguments[p.pageToken.JSONName()] = token
+		encoded, err := json.Marshal(arguments)
+		if err != nil {
+			return "", summary, fmt.Errorf("failed to marshal arguments: %w", err)
+		}
+		argumentsJSON = string(encoded)
+	}
+
+	merged[p.items] = items
+	result, err := encodeJSON(merged)
+	if err != nil {
+		return "", summary, fmt.Errorf("failed to encode pages: %w", err)
+	}
+	return result, summary, nil
+}
+
+// extractPages removes the pages argument from the call parameters,
+// returning the number of pages requested (0 when absent)
+func (h *Handler) extractPages(params map[string]interface{}) (map[string]interface{}, int, error) {
+	if !h.pagination.Enabled {
+		return params, 0, nil
+	}
+	params, value, ok := removeArgument(params, pagesArgument)
+	if !ok {
+		return params, 0, nil
+	}
+	pages, ok := integerArgument(value, 1, h.pagination.MaxPages)
+	if !ok {
+		return nil, 0, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("Invalid arguments: %s must be an integer from 1 to %d", pagesArgument, h.pagination.MaxPages))
+	}
+	return params, pages, nil
+}
+
+// paginationFor returns the pagination fields of a tool, rejecting the pages
+// argument on tools without them
+func (h *Handler) paginationFor(toolName string) (*paginatedMethod, error) {
+	method, ok := h.serviceDiscoverer.GetMethodByTool(toolName)
+	if !ok {
+		return nil, fmt.Errorf("tool %s not found", toolName)
+	}
+	paging, ok := detectPagination(method)
+	if !ok {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("%s is not supported for %s: it has no page_token and next_page_token fields", pagesArgument, toolName))
+	}
+	return paging, nil
+}
+
+// advertisePagination adds the pages argument to the input schema of
+// paginated tools
+func (h *Handler) advertisePagination(methods []types.MethodInfo, tools []mcp.Tool) []mcp.Tool {
+	if !h.pagination.Enabled {
+		return tools
+	}
+
+	paginated := make(map[string]bool)
+	for _, method := range methods {
+		if _, ok := detectPagination(method); ok {
+			paginated[method.ToolName] = true
+		}
+	}
+	for i, tool := range tools {
+		if !paginated[tool.Name] {
+			continue
+		}
+		addArgumentProperty(tools[i:i+1], pagesArgument, map[string]interface{}{
+			"type":    "integer",
+			"minimum": 1,
+			"maximum": h.pagination.MaxPages,
+			"description": "Fetch up to this many pages by following next_page_token and " +
+				"return their items together",
+		})
+	}
+	return tools
+}
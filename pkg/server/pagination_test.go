@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// listDescriptors returns the messages of a list method following the
+// standard pagination fields, and a response with two repeated fields
+func listDescriptors(t *testing.T) (request, response, ambiguous protoreflect.MessageDescriptor) {
+	field := func(name, jsonName string, number int32, typ descriptorpb.FieldDescriptorProto_Type, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("list.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("ListOrdersRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("page_size", "pageSize", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, false),
+					field("page_token", "pageToken", 2, str, false),
+				},
+			},
+			{
+				Name: proto.String("ListOrdersResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("orders", "orders", 1, str, true),
+					field("next_page_token", "nextPageToken", 2, str, false),
+				},
+			},
+			{
+				Name: proto.String("SearchResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("orders", "orders", 1, str, true),
+					field("facets", "facets", 2, str, true),
+					field("next_page_token", "nextPageToken", 3, str, false),
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	messages := file.Messages()
+	return messages.ByName("ListOrdersRequest"), messages.ByName("ListOrdersResponse"), messages.ByName("SearchResponse")
+}
+
+func TestDetectPagination(t *testing.T) {
+	request, response, ambiguous := listDescriptors(t)
+
+	paging, ok := detectPagination(types.MethodInfo{InputDescriptor: request, OutputDescriptor: response})
+	require.True(t, ok)
+	assert.Equal(t, "pageToken", paging.pageToken.JSONName())
+	assert.Equal(t, "nextPageToken", paging.nextPageToken)
+	assert.Equal(t, "orders", paging.items)
+
+	_, ok = detectPagination(types.MethodInfo{InputDescriptor: request, OutputDescriptor: ambiguous})
+	assert.False(t, ok, "several repeated fields")
+	_, ok = detectPagination(types.MethodInfo{InputDescriptor: response, OutputDescriptor: response})
+	assert.False(t, ok, "no page_token")
+	_, ok = detectPagination(types.MethodInfo{InputDescriptor: request, OutputDescriptor: request})
+	assert.False(t, ok, "no next_page_token")
+	_, ok = detectPagination(types.MethodInfo{InputDescriptor: request, OutputDescriptor: response, IsServerStreaming: true})
+	assert.False(t, ok, "streaming")
+}
+
+func TestHandler_Pagination(t *testing.T) {
+	request, response, _ := listDescriptors(t)
+	list := types.MethodInfo{
+		Name:             "ListOrders",
+		FullName:         "shop.OrderService.ListOrders",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  request,
+		OutputDescriptor: response,
+	}
+	list.ToolName = list.GenerateToolName()
+	get := types.MethodInfo{
+		Name:             "GetOrder",
+		FullName:         "shop.OrderService.GetOrder",
+		ServiceName:      "shop.OrderService",
+		InputDescriptor:  response,
+		OutputDescriptor: request,
+	}
+	get.ToolName = get.GenerateToolName()
+
+	cfg := config.Default()
+	cfg.Tools.Pagination.Enabled = true
+	cfg.Tools.Pagination.MaxPages = 5
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{list, get})
+	mockDiscoverer.On("GetMethodByTool", list.ToolName).Return(list, true)
+	mockDiscoverer.On("GetMethodByTool", get.ToolName).Return(get, true)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, list.ToolName, `{"pageSize":2}`).
+		Return(`{"orders":["a","b"],"nextPageToken":"t1"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, list.ToolName, `{"pageSize":2,"pageToken":"t1"}`).
+		Return(`{"orders":["c","d"],"nextPageToken":"t2"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, list.ToolName, `{"pageSize":2,"pageToken":"t2"}`).
+		Return(`{"orders":["e"]}`, nil)
+
+	call := func(toolName string, pages interface{}) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      toolName,
+			"arguments": map[string]interface{}{"pageSize": 2, "_pages": pages},
+		}, sessionCtx)
+	}
+
+	t.Run("Fetches_until_the_last_page", func(t *testing.T) {
+		result, err := call(list.ToolName, float64(5))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.JSONEq(t, `{"orders":["a","b","c","d","e"]}`, result.Content[0].Text)
+		assert.Equal(t, 3, result.Meta[mcp.MetaKeyPagesFetched])
+		assert.Equal(t, false, result.Meta[mcp.MetaKeyMorePages])
+	})
+
+	t.Run("Stops_at_the_requested_pages", func(t *testing.T) {
+		result, err := call(list.ToolName, float64(2))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"orders":["a","b","c","d"],"nextPageToken":"t2"}`, result.Content[0].Text)
+		assert.Equal(t, 2, result.Meta[mcp.MetaKeyPagesFetched])
+		assert.Equal(t, true, result.Meta[mcp.MetaKeyMorePages])
+	})
+
+	t.Run("Invalid_pages", func(t *testing.T) {
+		for _, pages := range []interface{}{float64(0), float64(6), float64(1.5), "2"} {
+			_, err := call(list.ToolName, pages)
+			var rpcErr *mcp.RPCError
+			require.ErrorAs(t, err, &rpcErr, pages)
+			assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		}
+	})
+
+	t.Run("Rejected_for_methods_without_pagination", func(t *testing.T) {
+		_, err := call(get.ToolName, float64(2))
+		var rpcErr *mcp.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Contains(t, rpcErr.Message, "no page_token")
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, get.ToolName, mock.Anything)
+	})
+
+	t.Run("Advertised_on_paginated_tools", func(t *testing.T) {
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 2)
+		for _, tool := range result.Tools {
+			properties := tool.InputSchema.(map[string]interface{})["properties"]
+			if tool.Name == list.ToolName {
+				assert.Contains(t, properties, "_pages")
+			} else {
+				assert.NotContains(t, properties, "_pages")
+			}
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// handlePing handles the ping method
+func (h *Handler) handlePing() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// clientMessage holds the fields that tell a client's JSON-RPC response
+// (e.g. to a server ping or roots/list) apart from a request
+type clientMessage struct {
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// isClientResponse reports whether a posted message is a JSON-RPC response
+func isClientResponse(body []byte) bool {
+	var msg clientMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return false
+	}
+	return msg.Method == "" && (msg.Result != nil || msg.Error != nil)
+}
+
+// streamsWhileHandling reports whether the request is answered over an event
+// stream, which carries server-initiated pings and requests (roots/list,
+// sampling) while it is handled
+func (h *Handler) streamsWhileHandling(r *http.Request, method string, sessionCtx *session.Context) bool {
+	if !h.streaming.Enabled || !acceptsEventStream(r) {
+		return false
+	}
+	if method != "tools/call" && method != "tools/call_batch" {
+		return false
+	}
+	return h.streaming.PingInterval > 0 || sessionCtx.RootsSupported() || sessionCtx.SamplingSupported()
+}
+
+// handleOverStream handles a long-running request over an event stream,
+// pinging the client until the response is ready. A failed ping means the
+// client is gone, so the request is cancelled.
+func (h *Handler) handleOverStream(w http.ResponseWriter, r *http.Request, req *mcp.JSONRPCRequest, sessionCtx *session.Context) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stream := h.startEventStream(w)
+	stop := h.startPinger(ctx, cancel, stream, sessionCtx.ID)
+
+	// The stream is the only way to send requests (roots/list, sampling) to the client
+	ctx = withClientRequester(ctx, &clientRequester{handler: h, stream: stream})
+	h.fetchRoots(ctx, sessionCtx)
+
+	result, err := h.handleRequest(ctx, req, sessionCtx)
+	stop()
+
+	if ctx.Err() != nil {
+		h.disconnects.responseDropped(req.Method, sessionCtx.ID)
+		return
+	}
+
+	response := &mcp.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+	}
+	if err != nil {
+		h.logger.Error("Request handling failed",
+			zap.String("method", req.Method),
+			zap.Error(err))
+
+		response.Error = rpcErrorFor(err)
+	} else {
+		response.Result = result
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+		return
+	}
+	if written, err := stream.send(data); err != nil {
+		h.logWriteError(err, zap.Int("bytesWritten", written), zap.Int("bytesTotal", len(data)))
+	}
+}
+
+// startPinger sends ping requests on the stream at the configured interval, if
+// any, and returns a function that stops it and waits for it to exit
+func (h *Handler) startPinger(ctx context.Context, cancel context.CancelFunc, stream *eventStream, sessionID string) func() {
+	if h.streaming.PingInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(h.streaming.PingInterval)
+		defer ticker.Stop()
+
+		for n := 1; ; n++ {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			data, err := json.Marshal(&mcp.JSONRPCRequest{
+				JSONRPC: "2.0",
+				Method:  "ping",
+				ID:      mcp.RequestID{Value: fmt.Sprintf("ping-%d", n)},
+			})
+			if err != nil {
+				return
+			}
+
+			if _, err := stream.send(data); err != nil {
+				h.logger.Warn("Ping failed, cancelling request for unreachable client",
+					zap.String("sessionId", sessionID),
+					zap.Error(err))
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
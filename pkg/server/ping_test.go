@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// failingResponseWriter accepts headers but fails every body write, like a dead client
+type failingResponseWriter struct {
+	header http.Header
+}
+
+func (w *failingResponseWriter) Header() http.Header { return w.header }
+func (w *failingResponseWriter) WriteHeader(int)     {}
+func (w *failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func newPingTestHandler(t *testing.T) (*Handler, *mockServiceDiscoverer) {
+	cfg := config.Default()
+	cfg.Server.Streaming = config.StreamingConfig{
+		Enabled:      true,
+		ChunkSize:    64 * 1024,
+		ChunkTimeout: time.Second,
+		PingInterval: 10 * time.Millisecond,
+	}
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+	return handler, mockDiscoverer
+}
+
+func postJSONRPC(handler *Handler, w http.ResponseWriter, body string, eventStream bool) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if eventStream {
+		req.Header.Set("Accept", "application/json, text/event-stream")
+	}
+	handler.ServeHTTP(w, req)
+}
+
+func TestHandler_Ping(t *testing.T) {
+	handler, _ := newPingTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	postJSONRPC(handler, rec, `{"jsonrpc":"2.0","id":7,"method":"ping"}`, false)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":7,"result":{}}`, rec.Body.String())
+}
+
+func TestHandler_ClientResponseAccepted(t *testing.T) {
+	handler, _ := newPingTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	postJSONRPC(handler, rec, `{"jsonrpc":"2.0","id":"ping-1","result":{}}`, false)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestHandler_PingsWhileHandling(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_service_testmethod"}}`
+
+	t.Run("Pings_until_response", func(t *testing.T) {
+		handler, mockDiscoverer := newPingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+			Run(func(mock.Arguments) { time.Sleep(50 * time.Millisecond) }).
+			Return(`{"output":"done"}`, nil)
+
+		rec := httptest.NewRecorder()
+		postJSONRPC(handler, rec, body, true)
+
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		events := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+		require.GreaterOrEqual(t, len(events), 2)
+		assert.Contains(t, events[0], `"method":"ping"`)
+		assert.Contains(t, events[len(events)-1], `"id":1`)
+		assert.Contains(t, events[len(events)-1], `done`)
+	})
+
+	t.Run("Failed_ping_cancels_call", func(t *testing.T) {
+		handler, mockDiscoverer := newPingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+			Run(func(args mock.Arguments) {
+				select {
+				case <-args.Get(0).(context.Context).Done():
+				case <-time.After(5 * time.Second):
+				}
+			}).
+			Return("", context.Canceled)
+
+		start := time.Now()
+		postJSONRPC(handler, &failingResponseWriter{header: http.Header{}}, body, true)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Plain_json_without_event_stream", func(t *testing.T) {
+		handler, mockDiscoverer := newPingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+			Return(`{"output":"done"}`, nil)
+
+		rec := httptest.NewRecorder()
+		postJSONRPC(handler, rec, body, false)
+
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.NotContains(t, rec.Body.String(), `"method":"ping"`)
+	})
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// toolProfiles holds the toolset profiles sessions select from
+type toolProfiles struct {
+	header      string // canonical name of the header selecting a profile
+	defaultName string
+	byName      map[string]config.ProfileConfig
+}
+
+// newToolProfiles creates the profiles, or nil if none are defined
+func newToolProfiles(profilesConfig config.ProfilesConfig) *toolProfiles {
+	if len(profilesConfig.Definitions) == 0 {
+		return nil
+	}
+	byName := make(map[string]config.ProfileConfig, len(profilesConfig.Definitions))
+	for _, profile := range profilesConfig.Definitions {
+		byName[profile.Name] = profile
+	}
+	return &toolProfiles{
+		header:      textproto.CanonicalMIMEHeaderKey(profilesConfig.Header),
+		defaultName: profilesConfig.Default,
+		byName:      byName,
+	}
+}
+
+// resolve returns the profile of a session: the one requested, else the one
+// named by the profile header, else the default. A session without any gets
+// no profile (nil) and all tools.
+func (p *toolProfiles) resolve(requested string, sessionCtx *session.Context) (*config.ProfileConfig, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	name := requested
+	if name == "" {
+		name = sessionCtx.GetHeader(p.header)
+	}
+	if name == "" {
+		name = p.defaultName
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, ok := p.byName[name]
+	if !ok {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("Unknown profile %q (available: %s)", name, strings.Join(p.names(), ", ")))
+	}
+	for _, header := range profile.RequiredHeaders {
+		if sessionCtx.GetHeader(textproto.CanonicalMIMEHeaderKey(header)) == "" {
+			return nil, mcp.NewRPCError(mcp.ErrorCodePermissionDenied,
+				fmt.Sprintf("Profile %s requires the %s header", name, header))
+		}
+	}
+	return &profile, nil
+}
+
+// names returns the names of the profiles, sorted
+func (p *toolProfiles) names() []string {
+	names := make([]string, 0, len(p.byName))
+	for name := range p.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileAllows reports whether a profile covers a tool; no profile covers all
+func profileAllows(profile *config.ProfileConfig, toolName string) bool {
+	if profile == nil {
+		return true
+	}
+	for _, pattern := range profile.Tools {
+		if matched, _ := path.Match(pattern, toolName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// selectProfile resolves the profile a client asks for in initialize and
+// keeps it for the session
+func (h *Handler) selectProfile(requested string, sessionCtx *session.Context) error {
+	profile, err := h.profiles.resolve(requested, sessionCtx)
+	if err != nil {
+		return err
+	}
+	if profile != nil {
+		sessionCtx.SetProfile(profile.Name)
+		h.logger.Info("Session selected profile",
+			zap.String("sessionId", sessionCtx.ID),
+			zap.String("profile", profile.Name))
+	}
+	return nil
+}
+
+// sessionProfile returns the profile of a session
+func (h *Handler) sessionProfile(sessionCtx *session.Context) (*config.ProfileConfig, error) {
+	return h.profiles.resolve(sessionCtx.Profile(), sessionCtx)
+}
+
+// checkProfile rejects calls of tools outside the session's profile
+func (h *Handler) checkProfile(toolName string, sessionCtx *session.Context) error {
+	profile, err := h.sessionProfile(sessionCtx)
+	if err != nil {
+		return err
+	}
+	if !profileAllows(profile, toolName) {
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied,
+			fmt.Sprintf("Tool %s is not in profile %s", toolName, profile.Name))
+	}
+	return nil
+}
+
+// handleSessionToolsList lists the tools of the session's profile that the
+// caller's roles grant
+func (h *Handler) handleSessionToolsList(ctx context.Context, sessionCtx *session.Context) (*mcp.ToolsListResult, error) {
+	profile, err := h.sessionProfile(sessionCtx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := h.handleToolsList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Tools = slices.DeleteFunc(result.Tools, func(tool mcp.Tool) bool {
+		return !profileAllows(profile, tool.Name) || !h.rolesAllow(ctx, tool.Name)
+	})
+	return result, nil
+}
+
+// profileHeaders narrows forwarded headers to those of the session's profile
+func (h *Handler) profileHeaders(headers map[string]string, sessionCtx *session.Context) map[string]string {
+	profile, err := h.sessionProfile(sessionCtx)
+	if err != nil || profile == nil || len(profile.ForwardHeaders) == 0 {
+		return headers
+	}
+	for name := range headers {
+		if !slices.ContainsFunc(profile.ForwardHeaders, func(allowed string) bool {
+			return strings.EqualFold(allowed, name)
+		}) {
+			delete(headers, name)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Profiles(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Profiles.Default = "read-only"
+	cfg.MCP.Profiles.Definitions = []config.ProfileConfig{
+		{Name: "read-only", Tools: []string{"*_get"}, ForwardHeaders: []string{"x-trace-id"}},
+		{Name: "admin", Tools: []string{"*"}, RequiredHeaders: []string{"authorization"}},
+	}
+	require.NoError(t, cfg.Validate())
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+
+	order := orderDescriptor(t)
+	var methods []types.MethodInfo
+	for _, name := range []string{"Get", "Cancel"} {
+		method := types.MethodInfo{
+			Name:             name,
+			FullName:         "shop.OrderService." + name,
+			ServiceName:      "shop.OrderService",
+			InputDescriptor:  order,
+			OutputDescriptor: order,
+		}
+		method.ToolName = method.GenerateToolName()
+		methods = append(methods, method)
+	}
+	mockDiscoverer.On("GetMethods").Return(methods)
+	getTool, cancelTool := methods[0].ToolName, methods[1].ToolName
+
+	initialize := func(sessionCtx *session.Context, params map[string]interface{}) error {
+		_, err := handler.handleRequest(context.Background(), &mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "initialize",
+			Params:  rawParams(t, params),
+		}, sessionCtx)
+		return err
+	}
+	listed := func(sessionCtx *session.Context) []string {
+		result, err := handler.handleRequest(context.Background(), &mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/list"}, sessionCtx)
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range result.(*mcp.ToolsListResult).Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	call := func(sessionCtx *session.Context, toolName string) error {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": toolName}, sessionCtx)
+		return err
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{
+			"X-Trace-Id": "trace-1",
+			"X-User-Id":  "u-1",
+		})
+		require.NoError(t, initialize(sessionCtx, map[string]interface{}{}))
+		assert.Equal(t, "read-only", sessionCtx.Profile())
+		assert.Equal(t, []string{getTool}, listed(sessionCtx))
+
+		err := call(sessionCtx, cancelTool)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "not in profile read-only")
+
+		// Only the profile's headers reach the backend
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, map[string]string{"X-Trace-Id": "trace-1"}, getTool, "").
+			Return(`{}`, nil).Once()
+		require.NoError(t, call(sessionCtx, getTool))
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Selected_by_header", func(t *testing.T) {
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{
+			"Mcp-Profile":   "admin",
+			"Authorization": "Bearer ops",
+		})
+		require.NoError(t, initialize(sessionCtx, map[string]interface{}{}))
+		assert.Equal(t, "admin", sessionCtx.Profile())
+		assert.ElementsMatch(t, []string{getTool, cancelTool}, listed(sessionCtx))
+	})
+
+	t.Run("Initialize_param_wins", func(t *testing.T) {
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{
+			"Mcp-Profile":   "admin",
+			"Authorization": "Bearer ops",
+		})
+		require.NoError(t, initialize(sessionCtx, map[string]interface{}{"profile": "read-only"}))
+		assert.Equal(t, []string{getTool}, listed(sessionCtx))
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		sessionCtx := handler.sessionManager.CreateSession(map[string]string{})
+		err := initialize(sessionCtx, map[string]interface{}{"profile": "admin"})
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, errorCodeFor(err))
+
+		err = initialize(sessionCtx, map[string]interface{}{"profile": "billing"})
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "available: admin, read-only")
+	})
+
+	t.Run("Invalid_config", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.Profiles.Default = "support"
+		assert.Error(t, cfg.Validate())
+
+		cfg.MCP.Profiles.Definitions = []config.ProfileConfig{{Name: "support", Tools: []string{"[a-"}}}
+		assert.Error(t, cfg.Validate())
+	})
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+)
+
+// panicRecovery turns panics into error responses and counts them
+type panicRecovery struct {
+	logger *zap.Logger
+	config config.RecoveryConfig
+	panics atomic.Int64
+}
+
+// newPanicRecovery creates the panic recovery handler
+func newPanicRecovery(recoveryConfig config.RecoveryConfig, logger *zap.Logger) *panicRecovery {
+	return &panicRecovery{
+		logger: logger,
+		config: recoveryConfig,
+	}
+}
+
+// recovered logs and counts a recovered panic and returns the client-facing message
+func (p *panicRecovery) recovered(value interface{}, fields ...zap.Field) string {
+	p.panics.Add(1)
+
+	fields = append(fields, zap.Any("error", value))
+	if p.config.LogStack {
+		fields = append(fields, zap.ByteString("stack", debug.Stack()))
+	}
+	p.logger.Error("Panic recovered", fields...)
+
+	if p.config.IncludeDetails {
+		return fmt.Sprintf("%s: %v", p.config.ErrorMessage, value)
+	}
+	return p.config.ErrorMessage
+}
+
+// recoverRequest converts a panic while handling a JSON-RPC request into an
+// internal error. It must be deferred directly.
+func (p *panicRecovery) recoverRequest(method string, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	message := p.recovered(value, zap.String("rpcMethod", method))
+	*err = mcp.NewRPCError(mcp.ErrorCodeInternalError, message)
+}
+
+// middleware recovers from panics anywhere in the HTTP chain
+func (p *panicRecovery) middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				value := recover()
+				if value == nil {
+					return
+				}
+				// The server aborts the connection silently for this one
+				if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(value)
+				}
+
+				message := p.recovered(value,
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path))
+				http.Error(w, message, http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// stats returns the recovered panic count
+func (p *panicRecovery) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"panics": p.panics.Load(),
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ToolCallPanic(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.Batch.Enabled = true
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_panics", "").
+		Run(func(mock.Arguments) { panic("boom") })
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_works", "").
+		Return(`{"output":"ok"}`, nil)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", sessionCtx.ID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Single_call", func(t *testing.T) {
+		rec := post(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"test_service_panics"}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response mcp.JSONRPCResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.NotNil(t, response.Error)
+		assert.Equal(t, mcp.ErrorCodeInternalError, response.Error.Code)
+		assert.Equal(t, "Internal Server Error", response.Error.Message)
+		assert.Equal(t, float64(7), response.ID.Value)
+	})
+
+	t.Run("Batch_call", func(t *testing.T) {
+		rec := post(`{"jsonrpc":"2.0","id":8,"method":"tools/call_batch","params":{"calls":[` +
+			`{"name":"test_service_panics"},{"name":"test_service_works"}]}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			Result mcp.ToolCallBatchResult `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.Len(t, response.Result.Results, 2)
+		assert.Equal(t, mcp.ErrorCodeInternalError, response.Result.Results[0].Error.Code)
+		assert.Equal(t, `{"output":"ok"}`, response.Result.Results[1].Result.Content[0].Text)
+	})
+
+	assert.Equal(t, int64(2), handler.recovery.stats()["panics"])
+}
+
+func TestHandler_Middleware(t *testing.T) {
+	panics := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") })
+
+	t.Run("Recovery_error_body", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Middleware.Recovery.ErrorMessage = "Gateway failure"
+		cfg.Server.Middleware.Recovery.IncludeDetails = true
+		handler, _, _ := newTestHandler(t, cfg)
+
+		rec := httptest.NewRecorder()
+		ChainMiddleware(handler.Middleware()...)(panics).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "Gateway failure: boom\n", rec.Body.String())
+		assert.Equal(t, int64(1), handler.recovery.stats()["panics"])
+	})
+
+	t.Run("Configured_order", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Middleware.Order = []string{"recovery", "security"}
+		handler, _, _ := newTestHandler(t, cfg)
+		require.Len(t, handler.Middleware(), 2)
+
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+		rec := httptest.NewRecorder()
+		ChainMiddleware(handler.Middleware()...)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Configured_timeout", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Timeout = 2 * time.Minute
+		cfg.Server.Middleware.Order = []string{"timeout"}
+		handler, _, _ := newTestHandler(t, cfg)
+
+		var remaining time.Duration
+		deadline := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			until, ok := r.Context().Deadline()
+			require.True(t, ok)
+			remaining = time.Until(until)
+		})
+		ChainMiddleware(handler.Middleware()...)(deadline).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+		assert.Greater(t, remaining, time.Minute)
+	})
+
+	t.Run("Invalid_order", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Middleware.Order = []string{"recovery", "gzip"}
+		assert.ErrorContains(t, cfg.Validate(), "unknown middleware: gzip")
+
+		cfg.Server.Middleware.Order = []string{"cors", "cors"}
+		assert.ErrorContains(t, cfg.Validate(), "listed twice")
+	})
+}
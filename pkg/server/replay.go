@@ -0,0 +1,136 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// replayGuard rejects protected requests that are unsigned, outside the
+// time window, or carry a nonce already seen within it
+type replayGuard struct {
+	config config.ReplayConfig
+	now    func() time.Time
+
+	mu     sync.Mutex
+	nonces map[string]time.Time // nonce -> time it leaves the window
+
+	accepted atomic.Int64
+	rejected atomic.Int64
+}
+
+// newReplayGuard creates the replay guard, or nil if disabled
+func newReplayGuard(replayConfig config.ReplayConfig) *replayGuard {
+	if !replayConfig.Enabled {
+		return nil
+	}
+	return &replayGuard{
+		config: replayConfig,
+		now:    time.Now,
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// protects reports whether requests for the JSON-RPC method must be signed
+func (g *replayGuard) protects(method string) bool {
+	return g != nil && slices.Contains(g.config.Methods, method)
+}
+
+// signReplayRequest computes the hex-encoded signature of a request
+func signReplayRequest(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// check verifies the request's timestamp and signature and records its nonce.
+// The nonce is only recorded for authentic requests, so forged requests cannot
+// use up the nonces of real ones.
+func (g *replayGuard) check(header http.Header, body []byte) error {
+	err := g.verify(header, body)
+	if err != nil {
+		g.rejected.Add(1)
+		return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, "Replay protection: "+err.Error())
+	}
+	g.accepted.Add(1)
+	return nil
+}
+
+// verify returns why the request is rejected
+func (g *replayGuard) verify(header http.Header, body []byte) error {
+	timestamp := header.Get(g.config.TimestampHeader)
+	nonce := header.Get(g.config.NonceHeader)
+	signature := header.Get(g.config.SignatureHeader)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return fmt.Errorf("request must carry %s, %s and %s headers",
+			g.config.TimestampHeader, g.config.NonceHeader, g.config.SignatureHeader)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a Unix timestamp in seconds", g.config.TimestampHeader)
+	}
+	signedAt := time.Unix(seconds, 0)
+	now := g.now()
+	if now.Sub(signedAt) > g.config.Window || signedAt.Sub(now) > g.config.Window {
+		return fmt.Errorf("request timestamp is outside the %s window", g.config.Window)
+	}
+
+	expected := signReplayRequest(g.config.Secret, timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid request signature")
+	}
+
+	return g.recordNonce(nonce, signedAt.Add(g.config.Window), now)
+}
+
+// recordNonce remembers a nonce until its request leaves the window
+func (g *replayGuard) recordNonce(nonce string, expires, now time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if until, seen := g.nonces[nonce]; seen && until.After(now) {
+		return fmt.Errorf("nonce has already been used")
+	}
+
+	if len(g.nonces) >= g.config.MaxNonces {
+		for seen, until := range g.nonces {
+			if !until.After(now) {
+				delete(g.nonces, seen)
+			}
+		}
+		if len(g.nonces) >= g.config.MaxNonces {
+			return fmt.Errorf("too many requests within the window")
+		}
+	}
+
+	g.nonces[nonce] = expires
+	return nil
+}
+
+// stats returns the replay protection counters for the metrics endpoint
+func (g *replayGuard) stats() map[string]interface{} {
+	g.mu.Lock()
+	nonces := len(g.nonces)
+	g.mu.Unlock()
+
+	return map[string]interface{}{
+		"accepted": g.accepted.Load(),
+		"rejected": g.rejected.Load(),
+		"nonces":   nonces,
+	}
+}
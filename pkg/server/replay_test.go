@@ -0,0 +1,200 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplayConfig() config.ReplayConfig {
+	replayConfig := config.Default().Server.Security.Replay
+	replayConfig.Enabled = true
+	replayConfig.Secret = "shared-secret"
+	return replayConfig
+}
+
+// signedHeader returns the headers of a request signed at the given time
+func signedHeader(replayConfig config.ReplayConfig, signedAt time.Time, nonce string, body string) http.Header {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	header := http.Header{}
+	header.Set(replayConfig.TimestampHeader, timestamp)
+	header.Set(replayConfig.NonceHeader, nonce)
+	header.Set(replayConfig.SignatureHeader, signReplayRequest(replayConfig.Secret, timestamp, nonce, []byte(body)))
+	return header
+}
+
+func TestReplayGuard(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"shop_orders_cancel"}}`
+
+	newGuard := func(modify func(*config.ReplayConfig)) (*replayGuard, config.ReplayConfig) {
+		replayConfig := newReplayConfig()
+		if modify != nil {
+			modify(&replayConfig)
+		}
+		guard := newReplayGuard(replayConfig)
+		guard.now = func() time.Time { return now }
+		return guard, replayConfig
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		guard := newReplayGuard(config.Default().Server.Security.Replay)
+		assert.Nil(t, guard)
+		assert.False(t, guard.protects("tools/call"))
+	})
+
+	t.Run("Protected_methods", func(t *testing.T) {
+		guard, _ := newGuard(nil)
+		assert.True(t, guard.protects("tools/call"))
+		assert.True(t, guard.protects("tools/call_batch"))
+		assert.False(t, guard.protects("tools/list"))
+	})
+
+	t.Run("Accepts_once", func(t *testing.T) {
+		guard, replayConfig := newGuard(nil)
+		header := signedHeader(replayConfig, now.Add(-time.Minute), "nonce-1", body)
+
+		require.NoError(t, guard.check(header, []byte(body)))
+
+		err := guard.check(header, []byte(body))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nonce has already been used")
+
+		var rpcErr *mcp.RPCError
+		require.True(t, errors.As(err, &rpcErr))
+		assert.Equal(t, mcp.ErrorCodePermissionDenied, rpcErr.Code)
+		assert.Equal(t, map[string]interface{}{"accepted": int64(1), "rejected": int64(1), "nonces": 1}, guard.stats())
+	})
+
+	rejections := []struct {
+		name    string
+		header  func(replayConfig config.ReplayConfig) http.Header
+		message string
+	}{
+		{
+			name:    "Unsigned",
+			header:  func(config.ReplayConfig) http.Header { return http.Header{} },
+			message: "must carry X-Request-Timestamp, X-Request-Nonce and X-Request-Signature headers",
+		},
+		{
+			name: "Too_old",
+			header: func(replayConfig config.ReplayConfig) http.Header {
+				return signedHeader(replayConfig, now.Add(-6*time.Minute), "nonce-2", body)
+			},
+			message: "outside the 5m0s window",
+		},
+		{
+			name: "In_the_future",
+			header: func(replayConfig config.ReplayConfig) http.Header {
+				return signedHeader(replayConfig, now.Add(6*time.Minute), "nonce-3", body)
+			},
+			message: "outside the 5m0s window",
+		},
+		{
+			name: "Malformed_timestamp",
+			header: func(replayConfig config.ReplayConfig) http.Header {
+				header := signedHeader(replayConfig, now, "nonce-4", body)
+				header.Set(replayConfig.TimestampHeader, now.Format(time.RFC3339))
+				return header
+			},
+			message: "must be a Unix timestamp in seconds",
+		},
+		{
+			name: "Wrong_secret",
+			header: func(replayConfig config.ReplayConfig) http.Header {
+				replayConfig.Secret = "guessed"
+				return signedHeader(replayConfig, now, "nonce-5", body)
+			},
+			message: "invalid request signature",
+		},
+		{
+			name: "Modified_body",
+			header: func(replayConfig config.ReplayConfig) http.Header {
+				return signedHeader(replayConfig, now, "nonce-6", strings.Replace(body, "cancel", "list", 1))
+			},
+			message: "invalid request signature",
+		},
+	}
+	for _, tc := range rejections {
+		t.Run(tc.name, func(t *testing.T) {
+			guard, replayConfig := newGuard(nil)
+			err := guard.check(tc.header(replayConfig), []byte(body))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.message)
+
+			// Rejected requests do not use up their nonce
+			assert.Equal(t, 0, guard.stats()["nonces"])
+		})
+	}
+
+	t.Run("Nonce_store_full", func(t *testing.T) {
+		guard, replayConfig := newGuard(func(c *config.ReplayConfig) { c.MaxNonces = 1 })
+		require.NoError(t, guard.check(signedHeader(replayConfig, now, "nonce-a", body), []byte(body)))
+
+		err := guard.check(signedHeader(replayConfig, now, "nonce-b", body), []byte(body))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "too many requests")
+
+		// Nonces are forgotten once their requests leave the window
+		now = now.Add(6 * time.Minute)
+		require.NoError(t, guard.check(signedHeader(replayConfig, now, "nonce-b", body), []byte(body)))
+		assert.Equal(t, 1, guard.stats()["nonces"])
+	})
+}
+
+func TestHandler_ReplayProtection(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Security.Replay = newReplayConfig()
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_cancel", "").
+		Return(`{"cancelled":true}`, nil).Once()
+
+	post := func(body string, header http.Header) mcp.JSONRPCResponse {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", sessionCtx.ID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response mcp.JSONRPCResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response
+	}
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_service_cancel"}}`
+	header := signedHeader(cfg.Server.Security.Replay, time.Now(), "nonce-1", call)
+
+	response := post(call, header)
+	assert.Nil(t, response.Error)
+
+	// The same signed request sent again does not reach the backend
+	response = post(call, header)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, mcp.ErrorCodePermissionDenied, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "nonce has already been used")
+
+	response = post(call, nil)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "Replay protection")
+
+	// Methods that are not protected need no signature
+	response = post(`{"jsonrpc":"2.0","id":2,"method":"ping"}`, nil)
+	assert.Nil(t, response.Error)
+
+	mockDiscoverer.AssertNumberOfCalls(t, "InvokeMethodByTool", 1)
+}
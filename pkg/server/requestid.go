@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// RequestIDHeader carries the ID correlating a request's log entries
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID matches client-supplied request IDs safe to put in logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// requestIDFor returns the request's ID: one assigned by an outer layer, a
+// well-formed client-supplied one, or a new one
+func requestIDFor(r *http.Request) string {
+	if id := requestIDFromContext(r.Context()); id != "" {
+		return id
+	}
+	if id := r.Header.Get(RequestIDHeader); validRequestID.MatchString(id) {
+		return id
+	}
+	return newLogID()
+}
+
+// withRequestID returns a context carrying the request ID
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFromContext returns the request ID carried by the context, if any
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newLogID returns a random ID for correlating log entries
+func newLogID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestHandler_CallInfoCorrelation(t *testing.T) {
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	withCallInfo := mock.MatchedBy(func(ctx context.Context) bool {
+		info, ok := grpc.CallInfoFromContext(ctx)
+		return ok && info.CallID != "" && info.RequestID == "req-42" && info.SessionID == sessionCtx.ID
+	})
+	mockDiscoverer.On("InvokeMethodByTool", withCallInfo, mock.Anything, "test_service_testmethod", "").
+		Return(`{"output":"success"}`, nil)
+
+	post := func(requestID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", sessionCtx.ID)
+		req.Header.Set(RequestIDHeader, requestID)
+		rec := httptest.NewRecorder()
+		ChainMiddleware(LoggingMiddleware(zap.NewNop()))(handler).ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Client_request_id", func(t *testing.T) {
+		rec := post("req-42", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_service_testmethod"}}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "req-42", rec.Header().Get(RequestIDHeader))
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Malformed_request_id_replaced", func(t *testing.T) {
+		rec := post("bad id\nforged log line", `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+		assert.Regexp(t, `^[0-9a-f]{16}$`, rec.Header().Get(RequestIDHeader))
+	})
+}
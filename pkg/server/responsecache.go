@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/aalobaidi/ggRMCP/pkg/jsonpath"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// GetCachedToolName is the gateway tool reading cached responses
+const GetCachedToolName = "ggrmcp_get_cached"
+
+// cacheResponse keeps a large response in the session's resources and returns
+// its first page with the handle to read the rest, or false if the response
+// is small enough to return whole
+func (h *Handler) cacheResponse(toolName, result string, sessionCtx *session.Context) ([]mcp.ContentBlock, bool) {
+	if !h.responseCache.Enabled || len(result) <= h.responseCache.MinBytes {
+		return nil, false
+	}
+
+	mimeType := "text/plain"
+	if json.Valid([]byte(result)) {
+		mimeType = "application/json"
+	}
+	resource, err := h.resources.Put(sessionCtx.ID, toolName+" response", mimeType, []byte(result))
+	if err != nil {
+		h.logger.Warn("Failed to cache response",
+			zap.String("toolName", toolName),
+			zap.Error(err))
+		return nil, false
+	}
+
+	page := cutUTF8(result, h.responseCache.PageBytes)
+	note := fmt.Sprintf("Response of %d bytes cached as %s; this is bytes 0-%d. "+
+		"Call %s with this handle and an offset, limit or JSONPath to read more.",
+		len(result), resource.URI, len(page), GetCachedToolName)
+
+	h.logger.Info("Cached tool response",
+		zap.String("toolName", toolName),
+		zap.String("handle", resource.URI),
+		zap.Int("bytes", len(result)))
+
+	return []mcp.ContentBlock{
+		mcp.TextContent(page),
+		mcp.TextContent(note),
+		mcp.ResourceLinkContent(resource.URI, resource.Name, "Cached response", resource.MimeType, resource.Size),
+	}, true
+}
+
+// getCachedTool describes the tool reading cached responses
+func (h *Handler) getCachedTool() mcp.Tool {
+	return mcp.Tool{
+		Name: GetCachedToolName,
+		Description: "Reads a large tool response cached by the gateway. Page through it with offset and limit, " +
+			"or extract parts of a JSON response with a JSONPath expression (e.g. $.items[?(@.status == 'FAILED')].id), " +
+			"which is then paged the same way.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"handle": map[string]interface{}{
+					"type":        "string",
+					"description": "Handle of the cached response (ggrmcp://resources/...)",
+				},
+				"offset": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     0,
+					"description": "Byte offset of the page (0 by default)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"maximum":     h.responseCache.MaxPageBytes,
+					"description": fmt.Sprintf("Page size in bytes (%d by default)", h.responseCache.PageBytes),
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "JSONPath expression selecting parts of a JSON response; matches are returned as a JSON array",
+				},
+			},
+			"required":             []string{"handle"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// callGetCachedTool returns a page of a cached response
+func (h *Handler) callGetCachedTool(_ context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	handle, _ := arguments["handle"].(string)
+	if handle == "" {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid handle argument")
+	}
+	offset, limit := 0, h.responseCache.PageBytes
+	var path string
+	for name, value := range arguments {
+		ok := true
+		switch name {
+		case "offset":
+			offset, ok = integerArgument(value, 0, math.MaxInt32)
+		case "limit":
+			limit, ok = integerArgument(value, 1, h.responseCache.MaxPageBytes)
+		case "path":
+			path, ok = value.(string)
+		}
+		if !ok {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid %s argument", name))
+		}
+	}
+
+	_, data, found := h.resources.Get(sessionCtx.ID, handle)
+	if !found {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Unknown or expired handle "+handle)
+	}
+
+	text := string(data)
+	if path != "" {
+		extracted, err := extractJSONPath(data, path)
+		if err != nil {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error())
+		}
+		text = extracted
+	}
+
+	if offset > len(text) {
+		offset = len(text)
+	}
+	// Start on a character boundary
+	for offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset++
+	}
+	page := cutUTF8(text[offset:], limit)
+	end := offset + len(page)
+
+	note := fmt.Sprintf("Bytes %d-%d of %d.", offset, end, len(text))
+	if end < len(text) {
+		note += fmt.Sprintf(" Next offset: %d.", end)
+	}
+	return &mcp.ToolCallResult{
+		Content: []mcp.ContentBlock{mcp.TextContent(page), mcp.TextContent(note)},
+	}, nil
+}
+
+// extractJSONPath returns the values a JSONPath expression selects from a JSON
+// document, as a JSON array
+func extractJSONPath(data []byte, expr string) (string, error) {
+	path, err := jsonpath.Compile(expr)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return "", fmt.Errorf("the cached response is not JSON")
+	}
+
+	matches := path.Evaluate(document)
+	if matches == nil {
+		matches = []interface{}{}
+	}
+	return encodeJSON(matches)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// largeOrders returns a JSON response of n orders
+func largeOrders(n int) string {
+	orders := make([]string, n)
+	for i := range orders {
+		status := "SHIPPED"
+		if i%10 == 0 {
+			status = "FAILED"
+		}
+		orders[i] = fmt.Sprintf(`{"id":"order-%04d","status":"%s","note":"%s"}`, i, status, strings.Repeat("é", 20))
+	}
+	return `{"orders":[` + strings.Join(orders, ",") + `]}`
+}
+
+func TestHandler_ResponseCache(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.ResponseCache.Enabled = true
+	cfg.MCP.ResponseCache.MinBytes = 1024
+	cfg.MCP.ResponseCache.PageBytes = 256
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+
+	response := largeOrders(100)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_list", "").Return(response, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", "").Return(`{"id":"order-0001"}`, nil)
+
+	// Small responses are returned whole
+	result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "shop_orders_get"}, sessionCtx)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+
+	// Large responses are cached and return their first page
+	result, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "shop_orders_list"}, sessionCtx)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 3)
+	assert.Equal(t, response[:256], result.Content[0].Text)
+	assert.Contains(t, result.Content[1].Text, GetCachedToolName)
+	assert.Equal(t, true, result.Meta[mcp.MetaKeyTruncated])
+	handle := result.Content[2].URI
+
+	getCached := func(sessionCtx *session.Context, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      GetCachedToolName,
+			"arguments": arguments,
+		}, sessionCtx)
+	}
+
+	t.Run("Pages", func(t *testing.T) {
+		var pages strings.Builder
+		offset := 0
+		for {
+			result, err := getCached(sessionCtx, map[string]interface{}{
+				"handle": handle,
+				"offset": float64(offset),
+				"limit":  float64(1000),
+			})
+			require.NoError(t, err)
+			pages.WriteString(result.Content[0].Text)
+
+			note := result.Content[1].Text
+			if !strings.Contains(note, "Next offset") {
+				assert.Equal(t, fmt.Sprintf("Bytes %d-%d of %d.", offset, len(response), len(response)), note)
+				break
+			}
+			_, err = fmt.Sscanf(note[strings.Index(note, "Next offset: "):], "Next offset: %d.", &offset)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, response, pages.String())
+	})
+
+	t.Run("JSONPath", func(t *testing.T) {
+		result, err := getCached(sessionCtx, map[string]interface{}{
+			"handle": handle,
+			"path":   "$.orders[?(@.status == 'FAILED')].id",
+		})
+		require.NoError(t, err)
+		var ids []string
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &ids))
+		assert.Equal(t, []string{"order-0000", "order-0010", "order-0020", "order-0030", "order-0040",
+			"order-0050", "order-0060", "order-0070", "order-0080", "order-0090"}, ids)
+
+		_, err = getCached(sessionCtx, map[string]interface{}{"handle": handle, "path": "orders"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must start with $")
+	})
+
+	t.Run("Only_the_owning_session", func(t *testing.T) {
+		other := handler.sessionManager.CreateSession(map[string]string{})
+		_, err := getCached(other, map[string]interface{}{"handle": handle})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Unknown or expired handle")
+	})
+
+	t.Run("Invalid_arguments", func(t *testing.T) {
+		_, err := getCached(sessionCtx, map[string]interface{}{})
+		require.Error(t, err)
+		_, err = getCached(sessionCtx, map[string]interface{}{"handle": handle, "limit": float64(1 << 20)})
+		require.Error(t, err)
+		_, err = getCached(sessionCtx, map[string]interface{}{"handle": handle, "offset": 1.5})
+		require.Error(t, err)
+	})
+}
+
+func TestHandler_GetCachedToolListed(t *testing.T) {
+	handler, _, _ := newTestHandler(t, config.Default())
+	assert.Empty(t, handler.builtinToolList())
+
+	cfg := config.Default()
+	cfg.MCP.ResponseCache.Enabled = true
+	handler, _, _ = newTestHandler(t, cfg)
+	tools := handler.builtinToolList()
+	require.Len(t, tools, 1)
+	assert.Equal(t, GetCachedToolName, tools[0].Name)
+}
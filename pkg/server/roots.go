@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/transform"
+	"go.uber.org/zap"
+)
+
+// rootsListResult is a client's answer to roots/list
+type rootsListResult struct {
+	Roots []struct {
+		URI  string `json:"uri"`
+		Name string `json:"name,omitempty"`
+	} `json:"roots"`
+}
+
+// recordClientCapabilities remembers the capabilities and client info a
+// client declared in initialize
+func (h *Handler) recordClientCapabilities(params mcp.InitializeParams, sessionCtx *session.Context) {
+	_, supportsRoots := params.Capabilities["roots"]
+	_, supportsSampling := params.Capabilities["sampling"]
+	_, supportsElicitation := params.Capabilities["elicitation"]
+
+	sessionCtx.SetRootsSupported(supportsRoots)
+	sessionCtx.InvalidateRoots()
+	sessionCtx.SetSamplingSupported(supportsSampling)
+	sessionCtx.SetElicitationSupported(supportsElicitation)
+
+	sessionCtx.SetClientInfo(params.ClientInfo.Name, params.ClientInfo.Version)
+}
+
+// checkClientRoots refuses clients that declare roots when path arguments
+// are checked against them but roots/list cannot be sent, which needs the
+// event stream of a streamed tools/call
+func (h *Handler) checkClientRoots(params mcp.InitializeParams) error {
+	if _, declared := params.Capabilities["roots"]; !declared || len(h.pathArguments) == 0 || h.streaming.Enabled {
+		return nil
+	}
+	return mcp.NewRPCError(mcp.ErrorCodeInvalidRequest,
+		"Client roots cannot be fetched: path arguments are checked against them, but server.streaming is disabled")
+}
+
+// handleNotification handles a client notification; notifications get no response
+func (h *Handler) handleNotification(w http.ResponseWriter, r *http.Request, method string) {
+	if sessionCtx, ok := h.sessionManager.GetSession(r.Header.Get("Mcp-Session-Id")); ok {
+		switch method {
+		case "notifications/roots/list_changed":
+			sessionCtx.InvalidateRoots()
+			h.logger.Debug("Client roots changed", zap.String("sessionId", sessionCtx.ID))
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// withClientRoots adds the session's roots to the context for transformation hooks.
+// Sessions that declared roots but have not provided them get an empty list.
+func (h *Handler) withClientRoots(ctx context.Context, sessionCtx *session.Context) context.Context {
+	if !sessionCtx.RootsSupported() {
+		return ctx
+	}
+	roots, _ := sessionCtx.GetRoots()
+	return transform.WithRoots(ctx, roots)
+}
+
+// fetchRoots asks the client for its roots when they are not known yet
+func (h *Handler) fetchRoots(ctx context.Context, sessionCtx *session.Context) {
+	if !sessionCtx.RootsSupported() {
+		return
+	}
+	if _, known := sessionCtx.GetRoots(); known {
+		return
+	}
+
+	data, err := requestClient(ctx, "roots/list", nil, h.rootsConfig.FetchTimeout)
+	if err != nil {
+		h.logger.Warn("Failed to fetch client roots",
+			zap.String("sessionId", sessionCtx.ID),
+			zap.Error(err))
+		return
+	}
+
+	var result rootsListResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		h.logger.Warn("Invalid roots/list response from client", zap.Error(err))
+		return
+	}
+
+	roots := make([]string, 0, len(result.Roots))
+	for _, root := range result.Roots {
+		roots = append(roots, root.URI)
+	}
+	sessionCtx.SetRoots(roots)
+
+	h.logger.Debug("Fetched client roots",
+		zap.String("sessionId", sessionCtx.ID),
+		zap.Strings("roots", roots))
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var clientRequestPattern = regexp.MustCompile(`"method":"([a-z/A-Z]+)",(?:"params":.*,)?"id":"(srv-[0-9a-f]+)"`)
+
+// streamClient is an event-stream response writer that answers server requests
+// by posting the configured replies back to the handler, like an MCP client
+type streamClient struct {
+	*httptest.ResponseRecorder
+
+	handler   *Handler
+	sessionID string
+
+	mu       sync.Mutex
+	replies  map[string]string // method -> "result" or "error" member of the reply
+	requests map[string]int
+}
+
+func newStreamClient(handler *Handler, sessionID string) *streamClient {
+	return &streamClient{
+		ResponseRecorder: httptest.NewRecorder(),
+		handler:          handler,
+		sessionID:        sessionID,
+		replies:          make(map[string]string),
+		requests:         make(map[string]int),
+	}
+}
+
+func (c *streamClient) Write(data []byte) (int, error) {
+	if match := clientRequestPattern.FindSubmatch(data); match != nil {
+		method, id := string(match[1]), string(match[2])
+		c.mu.Lock()
+		c.requests[method]++
+		reply := c.replies[method]
+		c.mu.Unlock()
+
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+				`{"jsonrpc":"2.0","id":"`+id+`",`+reply+`}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Mcp-Session-Id", c.sessionID)
+			c.handler.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	return c.ResponseRecorder.Write(data)
+}
+
+func (c *streamClient) answer(method, result string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replies[method] = `"result":` + result
+}
+
+func (c *streamClient) reject(method, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replies[method] = `"error":{"code":-1,"message":"` + message + `"}`
+}
+
+func (c *streamClient) requestCount(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests[method]
+}
+
+// initializeSession opens a session declaring the given client capabilities
+func initializeSession(t *testing.T, handler *Handler, capabilities string) string {
+	rec := httptest.NewRecorder()
+	postSessionJSONRPC(handler, rec, "",
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":`+capabilities+`}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	sessionID := rec.Header().Get("Mcp-Session-Id")
+	require.NotEmpty(t, sessionID)
+	return sessionID
+}
+
+func postSessionJSONRPC(handler *Handler, w http.ResponseWriter, sessionID, body string) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	handler.ServeHTTP(w, req)
+}
+
+func TestHandler_ClientRoots(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Streaming.Enabled = true
+	cfg.MCP.Roots.FetchTimeout = time.Second
+	cfg.Tools.PathArguments = []config.PathArgumentConfig{
+		{Tool: "test_service_testmethod", Fields: []string{"path"}},
+	}
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", `{"path":"/work/a.txt"}`).
+		Return(`{"output":"done"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", `{"path":"/etc/passwd"}`).
+		Return(`{"output":"done"}`, nil)
+
+	sessionID := initializeSession(t, handler, `{"roots":{"listChanged":true}}`)
+	client := newStreamClient(handler, sessionID)
+	client.answer("roots/list", `{"roots":[{"uri":"file:///work","name":"work"}]}`)
+
+	call := func(path string) string {
+		client.ResponseRecorder = httptest.NewRecorder()
+		postSessionJSONRPC(handler, client, sessionID,
+			`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test_service_testmethod","arguments":{"path":"`+path+`"}}}`)
+		return client.Body.String()
+	}
+
+	t.Run("Fetches_roots_and_allows_path_inside", func(t *testing.T) {
+		body := call("/work/a.txt")
+		assert.Contains(t, body, `"method":"roots/list"`)
+		assert.Contains(t, body, "done")
+		assert.Equal(t, 1, client.requestCount("roots/list"))
+	})
+
+	t.Run("Rejects_path_outside_with_cached_roots", func(t *testing.T) {
+		body := call("/etc/passwd")
+		assert.Contains(t, body, "outside the client's roots")
+		assert.Equal(t, 1, client.requestCount("roots/list"))
+	})
+
+	t.Run("List_changed_notification_refetches", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		postSessionJSONRPC(handler, rec, sessionID, `{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}`)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+
+		client.answer("roots/list", `{"roots":[{"uri":"file:///etc"}]}`)
+		body := call("/etc/passwd")
+		assert.Equal(t, 2, client.requestCount("roots/list"))
+		assert.Contains(t, body, "done")
+	})
+}
+
+func TestHandler_ClientWithoutRoots(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Streaming.Enabled = true
+	cfg.Tools.PathArguments = []config.PathArgumentConfig{
+		{Tool: "*", Fields: []string{"path"}},
+	}
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+
+	sessionID := initializeSession(t, handler, `{}`)
+
+	rec := httptest.NewRecorder()
+	postSessionJSONRPC(handler, rec, sessionID,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test_service_testmethod","arguments":{"path":"/etc/passwd"}}}`)
+
+	assert.NotContains(t, rec.Body.String(), "roots/list")
+	assert.Contains(t, rec.Body.String(), "has not declared the roots capability")
+	mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_ClientRootsWithoutStreaming(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.PathArguments = []config.PathArgumentConfig{
+		{Tool: "*", Fields: []string{"path"}},
+	}
+	handler, _, _ := newTestHandler(t, cfg)
+
+	rec := httptest.NewRecorder()
+	postSessionJSONRPC(handler, rec, "",
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"roots":{}}}}`)
+	assert.Contains(t, rec.Body.String(), "-32600")
+	assert.Contains(t, rec.Body.String(), "server.streaming is disabled")
+
+	// Without path arguments the roots are only offered to scripts
+	cfg.Tools.PathArguments = nil
+	handler, _, _ = newTestHandler(t, cfg)
+	initializeSession(t, handler, `{"roots":{}}`)
+}
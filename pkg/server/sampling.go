@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// sampledToolFor returns the sampling configuration of a tool, if it has one
+func (h *Handler) sampledToolFor(toolName string) (config.SampledToolConfig, bool) {
+	for _, sampled := range h.sampledTools {
+		if sampled.Tool == toolName {
+			return sampled, true
+		}
+	}
+	return config.SampledToolConfig{}, false
+}
+
+// completeWithSampling asks the client's LLM to complete the prompt in a sampled
+// tool's response and returns the follow-up method's response instead. Responses
+// without a prompt, and clients that cannot sample, get the original response.
+func (h *Handler) completeWithSampling(ctx context.Context, toolName, result string, headers map[string]string, sessionCtx *session.Context) (string, error) {
+	sampled, ok := h.sampledToolFor(toolName)
+	if !ok {
+		return result, nil
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		return result, nil
+	}
+	prompt, _ := response[sampled.PromptField].(string)
+	if prompt == "" {
+		return result, nil
+	}
+
+	if !sessionCtx.SamplingSupported() {
+		logging.FromContext(ctx, h.logger).Debug("Client does not support sampling, returning prompt")
+		return result, nil
+	}
+
+	maxTokens := sampled.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = h.samplingConfig.MaxTokens
+	}
+
+	data, err := requestClient(ctx, "sampling/createMessage", &mcp.CreateMessageParams{
+		Messages: []mcp.SamplingMessage{
+			{Role: mcp.RoleUser, Content: mcp.TextContent(prompt)},
+		},
+		SystemPrompt:   sampled.SystemPrompt,
+		IncludeContext: "none",
+		MaxTokens:      maxTokens,
+	}, h.samplingConfig.Timeout)
+	if errors.Is(err, errNoClientStream) {
+		logging.FromContext(ctx, h.logger).Debug("No event stream to sample over, returning prompt")
+		return result, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sampling failed: %w", err)
+	}
+
+	var completion mcp.CreateMessageResult
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return "", fmt.Errorf("sampling failed: invalid result: %w", err)
+	}
+	if completion.Content.Type != mcp.ContentTypeText {
+		return "", fmt.Errorf("sampling failed: expected text content, got %q", completion.Content.Type)
+	}
+
+	logging.FromContext(ctx, h.logger).Info("Sampled completion for tool",
+		zap.String("followUpTool", sampled.FollowUpTool),
+		zap.String("model", completion.Model))
+
+	// Feed the completion, and the fields the backend needs to correlate it, to the follow-up
+	arguments := map[string]interface{}{
+		sampled.CompletionField: completion.Content.Text,
+	}
+	for _, field := range sampled.PassFields {
+		if value, exists := response[field]; exists {
+			arguments[field] = value
+		}
+	}
+	argumentsJSON, err := json.Marshal(arguments)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal follow-up arguments: %w", err)
+	}
+
+	// The follow-up runs for the caller, so it needs the same permission as a direct call
+	if err := h.authorizeChainedCall(ctx, sampled.FollowUpTool, string(argumentsJSON), sessionCtx); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, toolCallTimeout)
+	defer cancel()
+
+	return h.serviceDiscoverer.InvokeMethodByTool(ctx, headers, sampled.FollowUpTool, string(argumentsJSON))
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newSamplingTestHandler(t *testing.T) (*Handler, *mockServiceDiscoverer) {
+	cfg := config.Default()
+	cfg.Server.Streaming.Enabled = true
+	cfg.MCP.Sampling.Timeout = time.Second
+	cfg.Tools.Sampled = []config.SampledToolConfig{
+		{
+			Tool:            "review_service_draft",
+			PromptField:     "prompt",
+			SystemPrompt:    "You are a careful reviewer.",
+			FollowUpTool:    "review_service_submit",
+			CompletionField: "completion",
+			PassFields:      []string{"draftId"},
+		},
+	}
+	handler, mockDiscoverer, _ := newTestHandler(t, cfg)
+	return handler, mockDiscoverer
+}
+
+func callDraft(handler *Handler, client *streamClient, sessionID string) string {
+	client.ResponseRecorder = httptest.NewRecorder()
+	postSessionJSONRPC(handler, client, sessionID,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"review_service_draft","arguments":{"text":"x"}}}`)
+	return client.Body.String()
+}
+
+func TestHandler_Sampling(t *testing.T) {
+	t.Run("Completion_fed_to_follow_up", func(t *testing.T) {
+		handler, mockDiscoverer := newSamplingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_draft", `{"text":"x"}`).
+			Return(`{"draftId":"d1","prompt":"Summarize the change"}`, nil)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_submit", `{"completion":"Looks good","draftId":"d1"}`).
+			Return(`{"status":"submitted"}`, nil)
+
+		sessionID := initializeSession(t, handler, `{"sampling":{}}`)
+		client := newStreamClient(handler, sessionID)
+		client.answer("sampling/createMessage",
+			`{"role":"assistant","content":{"type":"text","text":"Looks good"},"model":"test-model"}`)
+
+		body := callDraft(handler, client, sessionID)
+		assert.Equal(t, 1, client.requestCount("sampling/createMessage"))
+		assert.Contains(t, body, "Summarize the change")
+		assert.Contains(t, body, "You are a careful reviewer.")
+		assert.Contains(t, body, "submitted")
+		mockDiscoverer.AssertExpectations(t)
+	})
+
+	t.Run("Response_without_prompt", func(t *testing.T) {
+		handler, mockDiscoverer := newSamplingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_draft", `{"text":"x"}`).
+			Return(`{"draftId":"d1"}`, nil)
+
+		sessionID := initializeSession(t, handler, `{"sampling":{}}`)
+		client := newStreamClient(handler, sessionID)
+
+		body := callDraft(handler, client, sessionID)
+		assert.Equal(t, 0, client.requestCount("sampling/createMessage"))
+		assert.Contains(t, body, "d1")
+	})
+
+	t.Run("Client_without_sampling", func(t *testing.T) {
+		handler, mockDiscoverer := newSamplingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_draft", `{"text":"x"}`).
+			Return(`{"draftId":"d1","prompt":"Summarize the change"}`, nil)
+
+		sessionID := initializeSession(t, handler, `{}`)
+		client := newStreamClient(handler, sessionID)
+
+		body := callDraft(handler, client, sessionID)
+		assert.Equal(t, 0, client.requestCount("sampling/createMessage"))
+		assert.Contains(t, body, "Summarize the change")
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_submit", mock.Anything)
+	})
+
+	t.Run("Follow_up_denied_to_caller", func(t *testing.T) {
+		handler, mockDiscoverer := newSamplingTestHandler(t)
+		handler.SetPolicyEvaluator(denyTools{"review_service_submit": "submissions are closed"})
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_draft", `{"text":"x"}`).
+			Return(`{"draftId":"d1","prompt":"Summarize the change"}`, nil)
+
+		sessionID := initializeSession(t, handler, `{"sampling":{}}`)
+		client := newStreamClient(handler, sessionID)
+		client.answer("sampling/createMessage",
+			`{"role":"assistant","content":{"type":"text","text":"Looks good"},"model":"test-model"}`)
+
+		body := callDraft(handler, client, sessionID)
+		assert.Contains(t, body, "submissions are closed")
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_submit", mock.Anything)
+	})
+
+	t.Run("Client_rejects_sampling", func(t *testing.T) {
+		handler, mockDiscoverer := newSamplingTestHandler(t)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_draft", `{"text":"x"}`).
+			Return(`{"draftId":"d1","prompt":"Summarize the change"}`, nil)
+
+		sessionID := initializeSession(t, handler, `{"sampling":{}}`)
+		client := newStreamClient(handler, sessionID)
+		client.reject("sampling/createMessage", "User rejected sampling request")
+
+		body := callDraft(handler, client, sessionID)
+		assert.Contains(t, body, `"isError":true`)
+		assert.Contains(t, body, "User rejected sampling request")
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "review_service_submit", mock.Anything)
+	})
+}
+
+// denyTools is a policy denying the listed tools with a reason
+type denyTools map[string]string
+
+func (d denyTools) Evaluate(_ context.Context, input policy.Input) (policy.Decision, error) {
+	if reason, denied := d[input.Tool]; denied {
+		return policy.Decision{Allow: false, Reason: reason}, nil
+	}
+	return policy.Decision{Allow: true}, nil
+}
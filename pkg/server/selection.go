@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/jq"
+	"github.com/aalobaidi/ggRMCP/pkg/jsonpath"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// selectArgument is the argument holding an expression that projects the response
+const selectArgument = "_select"
+
+// selection projects a JSON response with a JSONPath or jq expression
+type selection struct {
+	path    *jsonpath.Path
+	program *jq.Program
+}
+
+// compileSelection compiles a JSONPath expression when it starts with $ and
+// a jq program otherwise
+func compileSelection(expr string) (*selection, error) {
+	if strings.HasPrefix(strings.TrimSpace(expr), "$") {
+		path, err := jsonpath.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, err
+		}
+		return &selection{path: path}, nil
+	}
+	program, err := jq.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &selection{program: program}, nil
+}
+
+// apply projects a JSON response. JSONPath returns the array of matches; jq
+// returns its single output, or the array of its outputs when there are
+// several.
+func (s *selection) apply(result string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(result)))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return "", fmt.Errorf("the response is not JSON")
+	}
+
+	if s.path != nil {
+		matches := s.path.Evaluate(document)
+		if matches == nil {
+			matches = []interface{}{}
+		}
+		return encodeJSON(matches)
+	}
+
+	outputs, err := s.program.Run(document)
+	if err != nil {
+		return "", err
+	}
+	if len(outputs) == 1 {
+		return encodeJSON(outputs[0])
+	}
+	if outputs == nil {
+		outputs = []interface{}{}
+	}
+	return encodeJSON(outputs)
+}
+
+// extractSelection removes the select argument from the call parameters and
+// compiles it, so invalid expressions are rejected before invocation
+func (h *Handler) extractSelection(params map[string]interface{}) (map[string]interface{}, *selection, error) {
+	if !h.selection {
+		return params, nil, nil
+	}
+	params, value, ok := removeArgument(params, selectArgument)
+	if !ok {
+		return params, nil, nil
+	}
+	expr, ok := value.(string)
+	if !ok || strings.TrimSpace(expr) == "" {
+		return nil, nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("Invalid arguments: %s must be a non-empty string", selectArgument))
+	}
+	selected, err := compileSelection(expr)
+	if err != nil {
+		return nil, nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+			fmt.Sprintf("Invalid arguments: %s: %v", selectArgument, err))
+	}
+	return params, selected, nil
+}
+
+// advertiseSelection adds the select argument to the input schema of every tool
+func (h *Handler) advertiseSelection(tools []mcp.Tool) []mcp.Tool {
+	if !h.selection {
+		return tools
+	}
+	return addArgumentProperty(tools, selectArgument, map[string]interface{}{
+		"type": "string",
+		"description": "Return only part of the response: a JSONPath expression starting with $ " +
+			"(e.g. $.items[*].id) or a jq expression (e.g. .items | map({id, name}))",
+	})
+}
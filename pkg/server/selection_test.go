@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Selection(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.Select = true
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+
+	response := `{"orders":[{"id":"o-1","status":"SHIPPED","total":12},{"id":"o-2","status":"FAILED","total":40}],"next":"abc"}`
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_list", `{"limit":2}`).Return(response, nil)
+
+	call := func(expr interface{}) (*mcp.ToolCallResult, error) {
+		return handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "shop_orders_list",
+			"arguments": map[string]interface{}{"limit": 2, "_select": expr},
+		}, sessionCtx)
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{"JSONPath", "$.orders[*].id", `["o-1","o-2"]`},
+		{"JSONPath_filter", "$.orders[?(@.total > 20)].id", `["o-2"]`},
+		{"JSONPath_no_match", "$.missing", `[]`},
+		{"jq_single_output", `.orders | map({id, status})`, `[{"id":"o-1","status":"SHIPPED"},{"id":"o-2","status":"FAILED"}]`},
+		{"jq_several_outputs", `(.orders[] | select(.status == "SHIPPED") | .id), .next`, `["o-1","abc"]`},
+		{"jq_scalar", `.orders | length`, `2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := call(tt.expr)
+			require.NoError(t, err)
+			assert.False(t, result.IsError)
+			require.Len(t, result.Content, 1)
+			assert.JSONEq(t, tt.expected, result.Content[0].Text)
+			assert.Equal(t, len(response), result.Meta[mcp.MetaKeySelectedFrom])
+		})
+	}
+
+	t.Run("Invalid_expression_is_rejected_before_invocation", func(t *testing.T) {
+		for _, expr := range []interface{}{".orders[", "$.orders[", "", 42} {
+			_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+				"name":      "shop_orders_get",
+				"arguments": map[string]interface{}{"_select": expr},
+			}, sessionCtx)
+			var rpcErr *mcp.RPCError
+			require.ErrorAs(t, err, &rpcErr, expr)
+			assert.Equal(t, mcp.ErrorCodeInvalidParams, rpcErr.Code)
+		}
+		mockDiscoverer.AssertNotCalled(t, "InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", mock.Anything)
+	})
+
+	t.Run("Evaluation_error", func(t *testing.T) {
+		result, err := call(".orders.id")
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].Text, "Failed to apply _select")
+	})
+
+	t.Run("Advertised_in_schema", func(t *testing.T) {
+		order := orderDescriptor(t)
+		method := types.MethodInfo{
+			Name:             "Place",
+			FullName:         "shop.OrderService.Place",
+			ServiceName:      "shop.OrderService",
+			InputDescriptor:  order,
+			OutputDescriptor: order,
+		}
+		method.ToolName = method.GenerateToolName()
+		mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{method})
+
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		schema := result.Tools[0].InputSchema.(map[string]interface{})
+		assert.Contains(t, schema["properties"], "_select")
+		assert.NotContains(t, schema["properties"], "_dryRun")
+	})
+}
+
+func TestHandler_SelectionDisabled(t *testing.T) {
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+
+	// Without the option the argument reaches the backend unchanged
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", `{"_select":".id"}`).
+		Return(`{"id":"o-1"}`, nil)
+	result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+		"name":      "shop_orders_get",
+		"arguments": map[string]interface{}{"_select": ".id"},
+	}, sessionCtx)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"o-1"}`, result.Content[0].Text)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+)
+
+// serverInfoURI is the resource describing the backend's version and build
+const serverInfoURI = "ggrmcp://backend/server-info"
+
+// serverInfoMimeType is the MIME type of the backend server info resource
+const serverInfoMimeType = "application/json"
+
+// serverInfoResources lists the backend server info, when discovery found any
+func (h *Handler) serverInfoResources() []mcp.Resource {
+	if h.serviceDiscoverer.ServerInfo() == nil {
+		return nil
+	}
+	return []mcp.Resource{{
+		URI:         serverInfoURI,
+		Name:        "Backend server info",
+		Description: "Version and build metadata of the gRPC backend",
+		MimeType:    serverInfoMimeType,
+	}}
+}
+
+// readServerInfoResource returns the backend server info read by the last discovery
+func (h *Handler) readServerInfoResource() (*mcp.ResourcesReadResult, error) {
+	info := h.serviceDiscoverer.ServerInfo()
+	if info == nil {
+		return nil, mcp.NewRPCError(mcp.ErrorCodeResourceNotFound, "Resource not found")
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode server info: %w", err)
+	}
+	return &mcp.ResourcesReadResult{
+		Contents: []mcp.ResourceContents{{
+			URI:      serverInfoURI,
+			MimeType: serverInfoMimeType,
+			Text:     string(data),
+		}},
+	}, nil
+}
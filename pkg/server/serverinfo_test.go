@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ServerInfo(t *testing.T) {
+	info := &grpc.ServerInfo{
+		Method:    "shop.AdminService.GetVersion",
+		Info:      map[string]interface{}{"version": "1.4.2"},
+		FetchedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	mockDiscoverer.On("ServerInfo").Return(info)
+	mockDiscoverer.On("HealthCheck", mock.Anything).Return(nil)
+	mockDiscoverer.On("GetMethodCount").Return(1)
+	mockDiscoverer.On("GetServiceStats").Return(map[string]interface{}{"serviceCount": 1})
+
+	t.Run("Resources_list", func(t *testing.T) {
+		result, err := handler.handleResourcesList(context.Background(), sessionCtx)
+		require.NoError(t, err)
+		require.Len(t, result.Resources, 1)
+		assert.Equal(t, serverInfoURI, result.Resources[0].URI)
+		assert.Equal(t, "application/json", result.Resources[0].MimeType)
+	})
+
+	t.Run("Resources_read", func(t *testing.T) {
+		result, err := handler.handleResourcesRead(context.Background(), map[string]interface{}{"uri": serverInfoURI}, sessionCtx)
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+
+		var read grpc.ServerInfo
+		require.NoError(t, json.Unmarshal([]byte(result.Contents[0].Text), &read))
+		assert.Equal(t, *info, read)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.HealthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var health map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+		backend := health["backend"].(map[string]interface{})
+		assert.Equal(t, info.Method, backend["method"])
+		assert.Equal(t, "1.4.2", backend["info"].(map[string]interface{})["version"])
+	})
+}
+
+func TestHandler_ServerInfoUnavailable(t *testing.T) {
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	mockDiscoverer.On("ServerInfo").Return(nil)
+
+	result, err := handler.handleResourcesList(context.Background(), sessionCtx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Resources)
+
+	_, err = handler.handleResourcesRead(context.Background(), map[string]interface{}{"uri": serverInfoURI}, sessionCtx)
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// SessionsAdminPath is the route of the session listing; a session is
+// terminated by deleting SessionsAdminPath + "/{id}"
+const SessionsAdminPath = "/admin/sessions"
+
+// terminatedRetention is how long requests with a terminated session ID are
+// refused; longer than sessions and tokens usually live
+const terminatedRetention = 24 * time.Hour
+
+// sessionSummary describes an active session without its forwarded headers
+type sessionSummary struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"createdAt"`
+	LastAccessed  time.Time `json:"lastAccessed"`
+	AgeSeconds    int64     `json:"ageSeconds"`
+	CallCount     int64     `json:"callCount"`
+	ClientName    string    `json:"clientName,omitempty"`
+	ClientVersion string    `json:"clientVersion,omitempty"`
+	Profile       string    `json:"profile,omitempty"`
+	UserAgent     string    `json:"userAgent,omitempty"`
+	RemoteAddr    string    `json:"remoteAddr,omitempty"`
+}
+
+// sessionsListing is the body of a session listing
+type sessionsListing struct {
+	Sessions []sessionSummary `json:"sessions"`
+}
+
+// terminatedSessions remembers the sessions ended by an operator, so their
+// clients get 404 and re-initialize instead of silently getting a new session
+type terminatedSessions struct {
+	mu  sync.Mutex
+	ids map[string]time.Time // session ID -> termination time
+}
+
+// newTerminatedSessions creates the record, or nil if the session admin API is off
+func newTerminatedSessions(adminToken string) *terminatedSessions {
+	if adminToken == "" {
+		return nil
+	}
+	return &terminatedSessions{ids: make(map[string]time.Time)}
+}
+
+// add records a terminated session, forgetting those past the retention
+func (t *terminatedSessions) add(sessionID string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, terminatedAt := range t.ids {
+		if now.Sub(terminatedAt) > terminatedRetention {
+			delete(t.ids, id)
+		}
+	}
+	t.ids[sessionID] = now
+}
+
+// contains reports whether a session was terminated
+func (t *terminatedSessions) contains(sessionID string) bool {
+	if t == nil || sessionID == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.ids[sessionID]
+	return ok
+}
+
+// rejectTerminated answers requests of a terminated session with 404, as MCP
+// requires once a server ends a session
+func (h *Handler) rejectTerminated(w http.ResponseWriter, r *http.Request) bool {
+	if !h.terminated.contains(r.Header.Get("Mcp-Session-Id")) {
+		return false
+	}
+	http.Error(w, "Session terminated", http.StatusNotFound)
+	return true
+}
+
+// authorizeSessionAdmin checks the session admin token
+func (h *Handler) authorizeSessionAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.terminated == nil {
+		http.Error(w, "Session admin is not enabled", http.StatusNotFound)
+		return false
+	}
+
+	return h.requireBearer(w, r, h.sessionAdminToken, "session admin")
+}
+
+// summarizeSessions returns the sessions' summaries, busiest first
+func summarizeSessions(snapshots []session.Snapshot, now time.Time) []sessionSummary {
+	summaries := make([]sessionSummary, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		summaries = append(summaries, sessionSummary{
+			ID:            snapshot.ID,
+			CreatedAt:     snapshot.CreatedAt,
+			LastAccessed:  snapshot.LastAccessed,
+			AgeSeconds:    int64(now.Sub(snapshot.CreatedAt).Seconds()),
+			CallCount:     snapshot.CallCount,
+			ClientName:    snapshot.ClientName,
+			ClientVersion: snapshot.ClientVersion,
+			Profile:       snapshot.Profile,
+			UserAgent:     snapshot.UserAgent,
+			RemoteAddr:    snapshot.RemoteAddr,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].CallCount != summaries[j].CallCount {
+			return summaries[i].CallCount > summaries[j].CallCount
+		}
+		return summaries[i].ID < summaries[j].ID
+	})
+	return summaries
+}
+
+// SessionsAdminHandler lists the active sessions, busiest first. Forwarded
+// headers are left out; the migration export carries them.
+func (h *Handler) SessionsAdminHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeSessionAdmin(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	listing := sessionsListing{Sessions: summarizeSessions(h.sessionManager.Export(), time.Now())}
+	if err := json.NewEncoder(w).Encode(listing); err != nil {
+		h.logger.Error("Failed to encode sessions", zap.Error(err))
+	}
+}
+
+// SessionTerminateHandler ends a session. Its later requests get 404, which
+// tells the client to initialize a new session.
+func (h *Handler) SessionTerminateHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeSessionAdmin(w, r) {
+		return
+	}
+
+	sessionID := strings.TrimPrefix(r.URL.Path, SessionsAdminPath+"/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+	sessionCtx, ok := h.sessionManager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	h.terminated.add(sessionID, time.Now())
+	h.sessionManager.DeleteSession(sessionID)
+
+	clientName, _ := sessionCtx.ClientInfo()
+	h.logger.Warn("Session terminated by operator",
+		zap.String("sessionId", sessionID),
+		zap.String("clientName", clientName),
+		zap.Int64("callCount", sessionCtx.GetCallCount()),
+		zap.String("remoteAddr", r.RemoteAddr))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_SessionAdmin(t *testing.T) {
+	cfg := config.Default()
+	cfg.Session.AdminToken = "admin-secret"
+	handler, _, busy := newTestHandler(t, cfg)
+	busy.SetClientInfo("orders-agent", "1.4.0")
+	for i := 0; i < 3; i++ {
+		busy.IncrementCallCount()
+	}
+	idle := handler.sessionManager.CreateSession(map[string]string{"Authorization": "Bearer user-token"})
+
+	admin := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		switch method {
+		case http.MethodGet:
+			handler.SessionsAdminHandler(rec, req)
+		case http.MethodDelete:
+			handler.SessionTerminateHandler(rec, req)
+		}
+		return rec
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, admin(http.MethodGet, SessionsAdminPath, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, admin(http.MethodDelete, SessionsAdminPath+"/"+busy.ID, "wrong").Code)
+	})
+
+	t.Run("Listing", func(t *testing.T) {
+		rec := admin(http.MethodGet, SessionsAdminPath, "admin-secret")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "user-token")
+
+		var listing sessionsListing
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+		require.Len(t, listing.Sessions, 2)
+		assert.Equal(t, busy.ID, listing.Sessions[0].ID)
+		assert.Equal(t, int64(3), listing.Sessions[0].CallCount)
+		assert.Equal(t, "orders-agent", listing.Sessions[0].ClientName)
+		assert.Equal(t, idle.ID, listing.Sessions[1].ID)
+	})
+
+	t.Run("Terminate", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, admin(http.MethodDelete, SessionsAdminPath+"/"+busy.ID, "admin-secret").Code)
+		assert.Equal(t, http.StatusNotFound, admin(http.MethodDelete, SessionsAdminPath+"/"+busy.ID, "admin-secret").Code)
+
+		// The client is told to re-initialize instead of getting a new session
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", busy.ID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		_, ok := handler.sessionManager.GetSession(busy.ID)
+		assert.False(t, ok)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats := handler.sessionManager.GetSessionStats()
+		assert.Equal(t, 1, stats["active_sessions"])
+		assert.Equal(t, 1, stats["age_distribution"].(map[string]int)["under_1m"])
+		assert.Equal(t, map[string]int64{"total": 0, "max_per_session": 0}, stats["calls"])
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, SessionsAdminPath, nil)
+		req.Header.Set("Authorization", "Bearer ")
+		handler.SessionsAdminHandler(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
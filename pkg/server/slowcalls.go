@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// slowCallDetector logs and counts tool calls slower than their tool's threshold
+type slowCallDetector struct {
+	thresholds []config.SlowCallConfig
+	logger     *zap.Logger
+
+	mu     sync.Mutex
+	counts map[string]int64 // tool name -> slow calls
+}
+
+// newSlowCallDetector creates the detector, or nil if no thresholds are set
+func newSlowCallDetector(thresholds []config.SlowCallConfig, logger *zap.Logger) *slowCallDetector {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	return &slowCallDetector{
+		thresholds: thresholds,
+		logger:     logger,
+		counts:     make(map[string]int64),
+	}
+}
+
+// thresholdFor returns the first threshold whose pattern matches a tool
+func (d *slowCallDetector) thresholdFor(toolName string) (config.SlowCallConfig, bool) {
+	for _, slow := range d.thresholds {
+		if matched, _ := path.Match(slow.Tool, toolName); matched {
+			return slow, true
+		}
+	}
+	return config.SlowCallConfig{}, false
+}
+
+// observe logs and counts a call if it took longer than its tool's
+// threshold. Only the argument names and size are logged, not their values.
+// It returns the threshold when the client should be warned, or zero.
+func (d *slowCallDetector) observe(ctx context.Context, toolName, argumentsJSON string, elapsed time.Duration, err error) time.Duration {
+	if d == nil {
+		return 0
+	}
+	slow, ok := d.thresholdFor(toolName)
+	threshold := slow.Threshold
+	if !ok || elapsed <= threshold {
+		return 0
+	}
+
+	d.mu.Lock()
+	d.counts[toolName]++
+	d.mu.Unlock()
+
+	logging.FromContext(ctx, d.logger).Warn("Slow tool call",
+		zap.Duration("elapsed", elapsed),
+		zap.Duration("threshold", threshold),
+		zap.Strings("arguments", argumentNames(argumentsJSON)),
+		zap.Int("argumentBytes", len(argumentsJSON)),
+		zap.Bool("failed", err != nil))
+
+	if !slow.Warn {
+		return 0
+	}
+	return threshold
+}
+
+// argumentNames returns the sorted top-level names of JSON arguments
+func argumentNames(argumentsJSON string) []string {
+	var arguments map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(argumentsJSON), &arguments); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(arguments))
+	for name := range arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stats returns the slow call counters for the metrics endpoint
+func (d *slowCallDetector) stats() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var total int64
+	tools := make(map[string]int64, len(d.counts))
+	for toolName, count := range d.counts {
+		tools[toolName] = count
+		total += count
+	}
+	return map[string]interface{}{
+		"total": total,
+		"tools": tools,
+	}
+}
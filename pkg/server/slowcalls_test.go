@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/grpc"
+	"github.com/aalobaidi/ggRMCP/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowCallDetector(t *testing.T) {
+	assert.Nil(t, newSlowCallDetector(nil, zap.NewNop()))
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	detector := newSlowCallDetector([]config.SlowCallConfig{
+		{Tool: "shop_orderservice_getorder", Threshold: time.Second, Warn: true},
+		{Tool: "shop_reportservice_*", Threshold: time.Minute},
+		{Tool: "*", Threshold: 100 * time.Millisecond},
+	}, zap.New(core))
+	ctx := logging.WithFields(context.Background(), grpc.CallInfo{CallID: "call-1", SessionID: "session-1"}.LogFields()...)
+	arguments := `{"order_id":"secret-order","customer":{"name":"Ada"}}`
+
+	assert.Zero(t, detector.observe(ctx, "shop_orderservice_getorder", arguments, 500*time.Millisecond, nil))
+	assert.Zero(t, logs.Len())
+
+	// Only thresholds with warn set are returned for the result's _meta
+	assert.Equal(t, time.Second, detector.observe(ctx, "shop_orderservice_getorder", arguments, 2*time.Second, errors.New("deadline exceeded")))
+	assert.Zero(t, detector.observe(ctx, "shop_orderservice_listorders", "{}", 200*time.Millisecond, nil))
+	assert.Zero(t, detector.observe(ctx, "shop_orderservice_listorders", "{}", 50*time.Millisecond, nil))
+	assert.Zero(t, detector.observe(ctx, "shop_reportservice_generate", "{}", 30*time.Second, nil))
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "Slow tool call", entries[0].Message)
+	assert.Equal(t, "call-1", fields["callId"])
+	assert.Equal(t, time.Second, fields["threshold"])
+	assert.Equal(t, []interface{}{"customer", "order_id"}, fields["arguments"])
+	assert.Equal(t, int64(len(arguments)), fields["argumentBytes"])
+	assert.Equal(t, true, fields["failed"])
+	assert.NotContains(t, fmt.Sprint(fields), "secret-order")
+
+	assert.Equal(t, map[string]interface{}{
+		"total": int64(2),
+		"tools": map[string]int64{
+			"shop_orderservice_getorder":   1,
+			"shop_orderservice_listorders": 1,
+		},
+	}, detector.stats())
+
+	// A nil detector ignores calls
+	var disabled *slowCallDetector
+	assert.Zero(t, disabled.observe(ctx, "shop_orderservice_getorder", arguments, time.Hour, nil))
+}
+
+func TestSlowCallConfig_InvalidPattern(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.SlowCalls = []config.SlowCallConfig{{Tool: "shop_[", Threshold: time.Second}}
+	assert.ErrorContains(t, cfg.Validate(), "invalid tool pattern")
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// acceptsEventStream reports whether the client negotiated the streaming transport
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// isWriteDeadlineError reports whether a write failed because the client was too slow
+func isWriteDeadlineError(err error) bool {
+	return errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// writeResponse writes a successful JSON-RPC response, streaming it in chunks
+// when it is large and the client accepts server-sent events
+func (h *Handler) writeResponse(w http.ResponseWriter, r *http.Request, response interface{}) {
+	if !h.streaming.Enabled || !acceptsEventStream(r) {
+		h.writeJSONResponse(w, response)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(data) <= h.streaming.ChunkSize {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			h.logWriteError(err, zap.Int("bytesTotal", len(data)))
+		}
+		return
+	}
+
+	h.writeStreamedResponse(w, data)
+}
+
+// writeStreamedResponse writes the payload as a single server-sent event, flushing
+// it in chunks and extending the write deadline for each one
+func (h *Handler) writeStreamedResponse(w http.ResponseWriter, data []byte) {
+	stream := h.startEventStream(w)
+	if written, err := stream.send(data); err != nil {
+		h.logWriteError(err, zap.Int("bytesWritten", written), zap.Int("bytesTotal", len(data)))
+	}
+}
+
+// eventStream writes server-sent events to a response, one writer at a time
+type eventStream struct {
+	w            http.ResponseWriter
+	controller   *http.ResponseController
+	chunkSize    int
+	chunkTimeout time.Duration
+	logger       *zap.Logger
+
+	mu sync.Mutex
+}
+
+// startEventStream writes the event stream headers and returns the stream
+func (h *Handler) startEventStream(w http.ResponseWriter) *eventStream {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	return &eventStream{
+		w:            w,
+		controller:   http.NewResponseController(w),
+		chunkSize:    h.streaming.ChunkSize,
+		chunkTimeout: h.streaming.ChunkTimeout,
+		logger:       h.logger,
+	}
+}
+
+// send writes one message event in chunks and returns the bytes written
+func (s *eventStream) send(data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// json.Marshal output has no newlines, so the payload fits one data line
+	chunks := make([][]byte, 0, len(data)/s.chunkSize+3)
+	chunks = append(chunks, []byte("event: message\ndata: "))
+	for start := 0; start < len(data); start += s.chunkSize {
+		end := min(start+s.chunkSize, len(data))
+		chunks = append(chunks, data[start:end])
+	}
+	chunks = append(chunks, []byte("\n\n"))
+
+	written := 0
+	for _, chunk := range chunks {
+		if err := s.controller.SetWriteDeadline(time.Now().Add(s.chunkTimeout)); err != nil {
+			s.logger.Debug("Write deadline not supported, streaming without it", zap.Error(err))
+		}
+
+		n, err := s.w.Write(chunk)
+		written += n
+		if err == nil {
+			err = s.controller.Flush()
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// logWriteError logs a failed response write, distinguishing slow clients
+// that hit the write deadline from other connection errors
+func (h *Handler) logWriteError(err error, fields ...zap.Field) {
+	fields = append(fields, zap.Error(err))
+	if isWriteDeadlineError(err) {
+		h.logger.Warn("Client too slow, write deadline exceeded mid-response", fields...)
+		return
+	}
+	h.logger.Error("Failed to write response", fields...)
+}
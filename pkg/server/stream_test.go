@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamingTestHandler(t *testing.T, chunkSize int) *Handler {
+	cfg := config.Default()
+	cfg.Server.Streaming = config.StreamingConfig{
+		Enabled:      true,
+		ChunkSize:    chunkSize,
+		ChunkTimeout: time.Second,
+	}
+	handler, _, _ := newTestHandler(t, cfg)
+	return handler
+}
+
+func TestHandler_WriteResponse(t *testing.T) {
+	response := &mcp.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      mcp.RequestID{Value: 1},
+		Result:  map[string]interface{}{"payload": strings.Repeat("x", 200)},
+	}
+
+	t.Run("Large_response_is_streamed", func(t *testing.T) {
+		handler := newStreamingTestHandler(t, 32)
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		rec := httptest.NewRecorder()
+
+		handler.writeResponse(rec, req, response)
+
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		body := rec.Body.String()
+		require.True(t, strings.HasPrefix(body, "event: message\ndata: "))
+		require.True(t, strings.HasSuffix(body, "\n\n"))
+
+		var decoded mcp.JSONRPCResponse
+		data := strings.TrimSuffix(strings.TrimPrefix(body, "event: message\ndata: "), "\n\n")
+		require.NoError(t, json.Unmarshal([]byte(data), &decoded))
+		assert.Equal(t, "2.0", decoded.JSONRPC)
+	})
+
+	t.Run("Small_response_is_plain_json", func(t *testing.T) {
+		handler := newStreamingTestHandler(t, 64*1024)
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		rec := httptest.NewRecorder()
+
+		handler.writeResponse(rec, req, response)
+
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.True(t, json.Valid(rec.Body.Bytes()))
+	})
+
+	t.Run("Client_without_event_stream_gets_json", func(t *testing.T) {
+		handler := newStreamingTestHandler(t, 32)
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.writeResponse(rec, req, response)
+
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.True(t, json.Valid(rec.Body.Bytes()))
+	})
+}
+
+func TestIsWriteDeadlineError(t *testing.T) {
+	deadlineErr := &net.OpError{Op: "write", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	assert.True(t, isWriteDeadlineError(deadlineErr))
+	assert.True(t, isWriteDeadlineError(fmt.Errorf("write failed: %w", deadlineErr)))
+	assert.False(t, isWriteDeadlineError(fmt.Errorf("connection reset by peer")))
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+)
+
+// ToolsAdminPath is the route of the tool admin endpoint; a tool is disabled
+// or enabled by posting to ToolsAdminPath + "/{name}"
+const ToolsAdminPath = "/admin/tools"
+
+// maxToolChangeSize bounds the body of a posted tool change
+const maxToolChangeSize = 64 * 1024
+
+// disabledTool is a tool switched off by an operator
+type disabledTool struct {
+	Tool       string    `json:"tool"`
+	Reason     string    `json:"reason,omitempty"`
+	Operator   string    `json:"operator,omitempty"`
+	DisabledAt time.Time `json:"disabledAt"`
+}
+
+// disabledToolsDocument is the body of a listing and of the state file
+type disabledToolsDocument struct {
+	Disabled []disabledTool `json:"disabled"`
+}
+
+// toolChange is the body posted to enable or disable a tool
+type toolChange struct {
+	Enabled  bool   `json:"enabled"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// toolSwitch holds the tools disabled at runtime, persisting them to a state
+// file when one is configured
+type toolSwitch struct {
+	stateFile string
+
+	mu       sync.RWMutex
+	disabled map[string]disabledTool
+}
+
+// newToolSwitch creates the tool switch, restoring the disabled tools from the
+// state file. It returns nil when the tool admin API is off.
+func newToolSwitch(cfg config.ToolAdminConfig, logger *zap.Logger) *toolSwitch {
+	if cfg.AdminToken == "" {
+		return nil
+	}
+
+	s := &toolSwitch{stateFile: cfg.StateFile, disabled: make(map[string]disabledTool)}
+	if err := s.load(); err != nil {
+		logger.Warn("Failed to restore disabled tools", zap.String("path", cfg.StateFile), zap.Error(err))
+	} else if len(s.disabled) > 0 {
+		logger.Warn("Restored disabled tools", zap.Strings("tools", s.names()))
+	}
+	return s
+}
+
+// lookup returns the entry of a disabled tool
+func (s *toolSwitch) lookup(toolName string) (disabledTool, bool) {
+	if s == nil {
+		return disabledTool{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.disabled[toolName]
+	return tool, ok
+}
+
+// list returns the disabled tools sorted by name
+func (s *toolSwitch) list() []disabledTool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tools := make([]disabledTool, 0, len(s.disabled))
+	for _, tool := range s.disabled {
+		tools = append(tools, tool)
+	}
+	slices.SortFunc(tools, func(a, b disabledTool) int { return strings.Compare(a.Tool, b.Tool) })
+	return tools
+}
+
+// names returns the names of the disabled tools
+func (s *toolSwitch) names() []string {
+	tools := s.list()
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Tool
+	}
+	return names
+}
+
+// set enables or disables a tool and reports whether that changed anything.
+// The state file is written before the change takes effect.
+func (s *toolSwitch) set(toolName string, change toolChange, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, disabled := s.disabled[toolName]
+	if disabled != change.Enabled {
+		return false, nil
+	}
+
+	next := maps.Clone(s.disabled)
+	if change.Enabled {
+		delete(next, toolName)
+	} else {
+		next[toolName] = disabledTool{
+			Tool:       toolName,
+			Reason:     change.Reason,
+			Operator:   change.Operator,
+			DisabledAt: now.UTC(),
+		}
+	}
+	if err := s.save(next); err != nil {
+		return false, err
+	}
+	s.disabled = next
+	return true, nil
+}
+
+// load reads the state file. A missing file is not an error.
+func (s *toolSwitch) load() error {
+	if s.stateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tool state file: %w", err)
+	}
+
+	var document disabledToolsDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to decode tool state file: %w", err)
+	}
+	for _, tool := range document.Disabled {
+		s.disabled[tool.Tool] = tool
+	}
+	return nil
+}
+
+// save writes the disabled tools to the state file, if any
+func (s *toolSwitch) save(disabled map[string]disabledTool) error {
+	if s.stateFile == "" {
+		return nil
+	}
+
+	document := disabledToolsDocument{Disabled: make([]disabledTool, 0, len(disabled))}
+	for _, tool := range disabled {
+		document.Disabled = append(document.Disabled, tool)
+	}
+	slices.SortFunc(document.Disabled, func(a, b disabledTool) int { return strings.Compare(a.Tool, b.Tool) })
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode disabled tools: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial state
+	tmp, err := os.CreateTemp(filepath.Dir(s.stateFile), filepath.Base(s.stateFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create tool state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write tool state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write tool state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.stateFile); err != nil {
+		return fmt.Errorf("failed to replace tool state file: %w", err)
+	}
+	return nil
+}
+
+// checkToolEnabled rejects calls of a tool disabled by an operator
+func (h *Handler) checkToolEnabled(toolName string) error {
+	tool, disabled := h.toolSwitch.lookup(toolName)
+	if !disabled {
+		return nil
+	}
+
+	message := fmt.Sprintf("Tool %s is disabled", toolName)
+	if tool.Reason != "" {
+		message += ": " + tool.Reason
+	}
+	return mcp.NewRPCError(mcp.ErrorCodePermissionDenied, message)
+}
+
+// hideDisabledTools removes the tools disabled by an operator from tools/list
+func (h *Handler) hideDisabledTools(tools []mcp.Tool) []mcp.Tool {
+	if h.toolSwitch == nil {
+		return tools
+	}
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+		_, disabled := h.toolSwitch.lookup(tool.Name)
+		return disabled
+	})
+}
+
+// authorizeToolAdmin checks the tool admin token
+func (h *Handler) authorizeToolAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.toolSwitch == nil {
+		http.Error(w, "Tool admin is not enabled", http.StatusNotFound)
+		return false
+	}
+
+	return h.requireBearer(w, r, h.toolAdminConfig.AdminToken, "tool admin")
+}
+
+// ToolsAdminHandler lists the tools disabled by operators
+func (h *Handler) ToolsAdminHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeToolAdmin(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(disabledToolsDocument{Disabled: h.toolSwitch.list()}); err != nil {
+		h.logger.Error("Failed to encode disabled tools", zap.Error(err))
+	}
+}
+
+// ToolChangeHandler disables or enables a tool. The change applies to
+// tools/list and tools/call at once, and clients listening for notifications
+// are told the tool list changed.
+func (h *Handler) ToolChangeHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeToolAdmin(w, r) {
+		return
+	}
+
+	toolName := strings.TrimPrefix(r.URL.Path, ToolsAdminPath+"/")
+	if toolName == "" || strings.Contains(toolName, "/") {
+		http.Error(w, "Invalid tool name", http.StatusBadRequest)
+		return
+	}
+	var change toolChange
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxToolChangeSize)).Decode(&change); err != nil {
+		http.Error(w, "Invalid change: expected {\"enabled\": bool, \"reason\": string, \"operator\": string}", http.StatusBadRequest)
+		return
+	}
+
+	changed, err := h.toolSwitch.set(toolName, change, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to save disabled tools", zap.String("toolName", toolName), zap.Error(err))
+		http.Error(w, "Failed to save the change", http.StatusInternalServerError)
+		return
+	}
+	if changed {
+		listeners := 0
+		if h.notifications != nil {
+			listeners = h.notifications.broadcast("notifications/tools/list_changed")
+		}
+		h.logger.Warn("Tool availability changed by operator",
+			zap.String("toolName", toolName),
+			zap.Bool("enabled", change.Enabled),
+			zap.String("operator", change.Operator),
+			zap.String("reason", change.Reason),
+			zap.Int("notifiedClients", listeners))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"tool":    toolName,
+		"enabled": change.Enabled,
+		"changed": changed,
+	}); err != nil {
+		h.logger.Error("Failed to encode tool change", zap.Error(err))
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ToolAdmin(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "tools.json")
+	cfg := config.Default()
+	cfg.MCP.ResponseCache.Enabled = true
+	cfg.MCP.ToolAdmin.AdminToken = "admin-token"
+	cfg.MCP.ToolAdmin.StateFile = stateFile
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{})
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "reports_generate", mock.Anything).
+		Return(`{"status":"done"}`, nil)
+	assert.True(t, handler.handleInitialize().Capabilities.Tools.ListChanged)
+
+	change := func(toolName, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, ToolsAdminPath+"/"+toolName, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ToolChangeHandler(rec, req)
+		return rec
+	}
+	call := func(toolName string) error {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": toolName}, sessionCtx)
+		return err
+	}
+
+	// Clients listening on the GET stream hear about changes
+	server := httptest.NewServer(http.HandlerFunc(handler.ServeHTTP))
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	require.Eventually(t, func() bool {
+		handler.notifications.mu.Lock()
+		defer handler.notifications.mu.Unlock()
+		return len(handler.notifications.listeners) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, call("reports_generate"))
+	rec := change("reports_generate", "admin-token", `{"enabled":false,"reason":"incident 42","operator":"alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"tool":"reports_generate","enabled":false,"changed":true}`, rec.Body.String())
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: message\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`, strings.TrimPrefix(line, "data: "))
+
+	// Calls are rejected at once
+	err = call("reports_generate")
+	require.Error(t, err)
+	assert.Equal(t, mcp.ErrorCodePermissionDenied, errorCodeFor(err))
+	assert.Contains(t, err.Error(), "Tool reports_generate is disabled: incident 42")
+
+	// Disabling again changes nothing
+	rec = change("reports_generate", "admin-token", `{"enabled":false}`)
+	assert.JSONEq(t, `{"tool":"reports_generate","enabled":false,"changed":false}`, rec.Body.String())
+
+	// Builtin tools can be switched off too
+	require.Equal(t, http.StatusOK, change(GetCachedToolName, "admin-token", `{"enabled":false}`).Code)
+	list, err := handler.handleToolsList(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, list.Tools)
+
+	// The disabled tools survive a restart
+	data, err := os.ReadFile(stateFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "incident 42")
+	restarted, _, _ := newTestHandler(t, cfg)
+	tool, disabled := restarted.toolSwitch.lookup("reports_generate")
+	require.True(t, disabled)
+	assert.Equal(t, "alice", tool.Operator)
+
+	require.Equal(t, http.StatusOK, change("reports_generate", "admin-token", `{"enabled":true}`).Code)
+	require.NoError(t, call("reports_generate"))
+
+	getRec := httptest.NewRecorder()
+	getReq := httptest.NewRequest(http.MethodGet, ToolsAdminPath, nil)
+	getReq.Header.Set("Authorization", "Bearer admin-token")
+	handler.ToolsAdminHandler(getRec, getReq)
+	require.Equal(t, http.StatusOK, getRec.Code)
+	assert.Contains(t, getRec.Body.String(), `"tool":"`+GetCachedToolName+`"`)
+	assert.NotContains(t, getRec.Body.String(), "reports_generate")
+
+	// Shutting down ends the notification streams
+	handler.CloseNotifications()
+	_, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+}
+
+func TestHandler_ToolAdminEndpoint(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.ToolAdmin.AdminToken = "admin-token"
+	handler, _, _ := newTestHandler(t, cfg)
+
+	post := func(target, token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ToolChangeHandler(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post(ToolsAdminPath+"/tool", "", `{"enabled":false}`))
+	assert.Equal(t, http.StatusUnauthorized, post(ToolsAdminPath+"/tool", "wrong", `{"enabled":false}`))
+	assert.Equal(t, http.StatusBadRequest, post(ToolsAdminPath+"/", "admin-token", `{"enabled":false}`))
+	assert.Equal(t, http.StatusBadRequest, post(ToolsAdminPath+"/tool", "admin-token", `enabled`))
+
+	// Without an admin token the endpoints are off and tools cannot change
+	disabled, _, _ := newTestHandler(t, config.Default())
+	rec := httptest.NewRecorder()
+	disabled.ToolChangeHandler(rec, httptest.NewRequest(http.MethodPost, ToolsAdminPath+"/tool", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.False(t, disabled.handleInitialize().Capabilities.Tools.ListChanged)
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// ToolStatsPath is the route of the per-tool usage statistics endpoint
+const ToolStatsPath = "/stats/tools"
+
+// StatsToolName is the gateway tool reporting per-tool usage statistics
+const StatsToolName = "ggrmcp_stats"
+
+// toolStats keeps per-tool call counts and a ring buffer of the outcomes of
+// each tool's most recent calls
+type toolStats struct {
+	window  int
+	now     func() time.Time
+	started time.Time
+
+	mu    sync.Mutex
+	tools map[string]*toolCalls
+}
+
+// toolCalls holds the counters and recent calls of one tool
+type toolCalls struct {
+	calls    int64
+	errors   int64
+	rejected int64
+	lastCall time.Time
+
+	// recent is a ring buffer; next is where the following call is written
+	recent []callSample
+	next   int
+}
+
+// callSample is the outcome of a completed call
+type callSample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// ToolUsage is the statistics of one tool. ErrorRate and the latency
+// percentiles cover the tool's most recent calls, up to the window.
+type ToolUsage struct {
+	Tool      string    `json:"tool"`
+	Calls     int64     `json:"calls"`
+	Errors    int64     `json:"errors"`
+	Rejected  int64     `json:"rejected"`
+	Recent    int       `json:"recent"`
+	ErrorRate float64   `json:"errorRate"`
+	P50Ms     float64   `json:"p50Ms"`
+	P95Ms     float64   `json:"p95Ms"`
+	LastCall  time.Time `json:"lastCall"`
+}
+
+// toolStatsDocument is the body of a statistics response
+type toolStatsDocument struct {
+	Since  time.Time   `json:"since"`
+	Window int         `json:"window"`
+	Tools  []ToolUsage `json:"tools"`
+}
+
+// newToolStats creates the statistics, or nil if disabled
+func newToolStats(statsConfig config.ToolStatsConfig) *toolStats {
+	if !statsConfig.Enabled {
+		return nil
+	}
+	return &toolStats{
+		window:  statsConfig.Window,
+		now:     time.Now,
+		started: time.Now(),
+		tools:   make(map[string]*toolCalls),
+	}
+}
+
+// record counts a call. A call failing before it completed (err) is counted
+// as rejected, but only for tools that already completed a call, so unknown
+// tool names do not grow the statistics.
+func (s *toolStats) record(params map[string]interface{}, result *mcp.ToolCallResult, err error, elapsed time.Duration) {
+	if s == nil {
+		return
+	}
+	name, _ := params["name"].(string)
+	if name == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls, ok := s.tools[name]
+	if err != nil {
+		if ok {
+			calls.rejected++
+		}
+		return
+	}
+	if !ok {
+		calls = &toolCalls{recent: make([]callSample, 0, min(s.window, 64))}
+		s.tools[name] = calls
+	}
+
+	calls.calls++
+	calls.lastCall = s.now()
+	sample := callSample{latency: elapsed, failed: result != nil && result.IsError}
+	if sample.failed {
+		calls.errors++
+	}
+	if len(calls.recent) < s.window {
+		calls.recent = append(calls.recent, sample)
+	} else {
+		calls.recent[calls.next] = sample
+	}
+	calls.next = (calls.next + 1) % s.window
+}
+
+// usage returns the statistics of the named tools, or of every tool if none
+// are named, sorted by tool name
+func (s *toolStats) usage(names ...string) []ToolUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := make([]ToolUsage, 0, len(s.tools))
+	for name, calls := range s.tools {
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+		usage = append(usage, calls.usage(name))
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Tool < usage[j].Tool })
+	return usage
+}
+
+// usage summarizes the counters and recent calls of a tool
+func (c *toolCalls) usage(name string) ToolUsage {
+	usage := ToolUsage{
+		Tool:     name,
+		Calls:    c.calls,
+		Errors:   c.errors,
+		Rejected: c.rejected,
+		Recent:   len(c.recent),
+		LastCall: c.lastCall,
+	}
+	if len(c.recent) == 0 {
+		return usage
+	}
+
+	latencies := make([]time.Duration, len(c.recent))
+	failed := 0
+	for i, sample := range c.recent {
+		latencies[i] = sample.latency
+		if sample.failed {
+			failed++
+		}
+	}
+	slices.Sort(latencies)
+
+	usage.ErrorRate = roundStat(float64(failed) / float64(len(c.recent)))
+	usage.P50Ms = percentileMs(latencies, 50)
+	usage.P95Ms = percentileMs(latencies, 95)
+	return usage
+}
+
+// percentileMs returns the nearest-rank percentile of sorted latencies in milliseconds
+func percentileMs(sorted []time.Duration, percentile int) float64 {
+	rank := int(math.Ceil(float64(percentile)/100*float64(len(sorted)))) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return roundStat(float64(sorted[rank].Microseconds()) / 1000)
+}
+
+// roundStat rounds a statistic to three decimals
+func roundStat(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// document returns the statistics response for the named tools
+func (s *toolStats) document(names ...string) toolStatsDocument {
+	return toolStatsDocument{
+		Since:  s.started,
+		Window: s.window,
+		Tools:  s.usage(names...),
+	}
+}
+
+// stats returns the totals of the statistics for the metrics endpoint
+func (s *toolStats) stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls, errors int64
+	for _, tool := range s.tools {
+		calls += tool.calls
+		errors += tool.errors
+	}
+	return map[string]interface{}{
+		"tools":  len(s.tools),
+		"calls":  calls,
+		"errors": errors,
+	}
+}
+
+// statsTool describes the statistics tool
+func statsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: StatsToolName,
+		Description: "Reports how each tool has been doing: call and error counts, and the error rate " +
+			"and median and 95th percentile latencies of its recent calls. Use it to avoid tools that are failing or slow.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tool": map[string]interface{}{
+					"type":        "string",
+					"description": "Only this tool",
+				},
+			},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// callStatsTool reports the statistics of every tool or of the requested one
+func (h *Handler) callStatsTool(ctx context.Context, arguments map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	var names []string
+	if value, ok := arguments["tool"]; ok {
+		name, ok := value.(string)
+		if !ok || name == "" {
+			return nil, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Invalid tool argument")
+		}
+		names = append(names, name)
+	}
+	return jsonToolResult(h.toolStats.document(names...))
+}
+
+// ToolStatsHandler serves the per-tool usage statistics; ?tool= may be
+// repeated to select tools
+func (h *Handler) ToolStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.toolStats == nil {
+		http.Error(w, "Tool statistics are not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.toolStats.document(r.URL.Query()["tool"]...)); err != nil {
+		h.logger.Error("Failed to encode tool statistics", zap.Error(err))
+	}
+}
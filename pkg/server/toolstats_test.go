@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToolStats_Window(t *testing.T) {
+	stats := newToolStats(config.ToolStatsConfig{Enabled: true, Window: 4})
+	params := map[string]interface{}{"name": "shop_orderservice_getorder"}
+	ok := &mcp.ToolCallResult{}
+	failed := &mcp.ToolCallResult{IsError: true}
+
+	// Rejected calls of tools without a completed call are not tracked
+	stats.record(params, nil, errors.New("invalid parameters"), 0)
+	assert.Empty(t, stats.usage())
+
+	for _, latency := range []time.Duration{40, 10, 30, 20} {
+		stats.record(params, ok, nil, latency*time.Millisecond)
+	}
+	stats.record(params, failed, nil, 100*time.Millisecond)
+	stats.record(params, failed, nil, 200*time.Millisecond)
+	stats.record(params, nil, errors.New("quota exceeded"), 0)
+
+	usage := stats.usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, "shop_orderservice_getorder", usage[0].Tool)
+	assert.Equal(t, int64(6), usage[0].Calls)
+	assert.Equal(t, int64(2), usage[0].Errors)
+	assert.Equal(t, int64(1), usage[0].Rejected)
+
+	// The window holds the last four calls: 30, 20, 100 and 200ms
+	assert.Equal(t, 4, usage[0].Recent)
+	assert.Equal(t, 0.5, usage[0].ErrorRate)
+	assert.Equal(t, 30.0, usage[0].P50Ms)
+	assert.Equal(t, 200.0, usage[0].P95Ms)
+
+	assert.Empty(t, stats.usage("other_tool"))
+	assert.Equal(t, map[string]interface{}{"tools": 1, "calls": int64(6), "errors": int64(2)}, stats.stats())
+}
+
+func TestHandler_ToolStats(t *testing.T) {
+	cfg := config.Default()
+	cfg.MCP.ToolStats.Enabled = true
+	cfg.MCP.ToolStats.Tool = true
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Return(`{"output":"success"}`, nil).Once()
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_testmethod", "").
+		Return("", status.Error(codes.Unavailable, "backend down"))
+
+	for i := 0; i < 2; i++ {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "test_service_testmethod"}, sessionCtx)
+		require.NoError(t, err)
+	}
+
+	t.Run("Endpoint", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ToolStatsHandler(recorder, httptest.NewRequest(http.MethodGet, ToolStatsPath, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var document toolStatsDocument
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+		assert.Equal(t, 1000, document.Window)
+		require.Len(t, document.Tools, 1)
+		assert.Equal(t, int64(2), document.Tools[0].Calls)
+		assert.Equal(t, int64(1), document.Tools[0].Errors)
+		assert.Equal(t, 0.5, document.Tools[0].ErrorRate)
+	})
+
+	t.Run("Tool", func(t *testing.T) {
+		tools := handler.builtinToolList()
+		require.Len(t, tools, 1)
+		assert.Equal(t, StatsToolName, tools[0].Name)
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      StatsToolName,
+			"arguments": map[string]interface{}{"tool": "test_service_testmethod"},
+		}, sessionCtx)
+		require.NoError(t, err)
+		var document toolStatsDocument
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &document))
+		require.Len(t, document.Tools, 1)
+		assert.Equal(t, "test_service_testmethod", document.Tools[0].Tool)
+
+		_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      StatsToolName,
+			"arguments": map[string]interface{}{"tool": 7},
+		}, sessionCtx)
+		assert.Error(t, err)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+		recorder := httptest.NewRecorder()
+		handler.ToolStatsHandler(recorder, httptest.NewRequest(http.MethodGet, ToolStatsPath, nil))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Empty(t, handler.builtinToolList())
+	})
+}
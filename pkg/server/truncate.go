@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+)
+
+// bytesPerToken approximates how many bytes of JSON make up one model token
+const bytesPerToken = 4
+
+// responseLimitFor returns the first response limit that applies to the tool
+func (h *Handler) responseLimitFor(toolName string) (config.ResponseLimitConfig, bool) {
+	for _, limit := range h.responseLimits {
+		if limit.Tool == "*" || limit.Tool == toolName {
+			return limit, true
+		}
+	}
+	return config.ResponseLimitConfig{}, false
+}
+
+// responseByteLimit returns the effective byte budget of a limit (0 means unlimited)
+func responseByteLimit(limit config.ResponseLimitConfig) int {
+	maxBytes := limit.MaxBytes
+	if tokenBytes := limit.MaxTokens * bytesPerToken; tokenBytes > 0 && (maxBytes == 0 || tokenBytes < maxBytes) {
+		maxBytes = tokenBytes
+	}
+	return maxBytes
+}
+
+// limitResponse builds the result content for a tool response, caching a large
+// response or truncating it to the tool's response budget, and reports whether
+// only part of it is returned
+func (h *Handler) limitResponse(toolName, result string, sessionCtx *session.Context) ([]mcp.ContentBlock, bool) {
+	if content, cached := h.cacheResponse(toolName, result, sessionCtx); cached {
+		return content, true
+	}
+
+	limit, ok := h.responseLimitFor(toolName)
+	maxBytes := responseByteLimit(limit)
+	if !ok || maxBytes == 0 || len(result) <= maxBytes {
+		return []mcp.ContentBlock{mcp.TextContent(result)}, false
+	}
+
+	truncated := truncateJSON(result, maxBytes)
+	note := fmt.Sprintf("Response truncated from %d to %d bytes to fit the response budget; arrays were shortened.",
+		len(result), len(truncated))
+
+	var link *mcp.ContentBlock
+	if limit.StoreFull {
+		resource, err := h.resources.Put(sessionCtx.ID, toolName+" response", "application/json", []byte(result))
+		if err != nil {
+			h.logger.Warn("Failed to store full response as resource",
+				zap.String("toolName", toolName),
+				zap.Error(err))
+		} else {
+			note += fmt.Sprintf(" The full response is available as resource %s.", resource.URI)
+			block := mcp.ResourceLinkContent(resource.URI, resource.Name, "Full untruncated response",
+				resource.MimeType, resource.Size)
+			link = &block
+		}
+	}
+
+	h.logger.Info("Truncated tool response",
+		zap.String("toolName", toolName),
+		zap.Int("originalBytes", len(result)),
+		zap.Int("truncatedBytes", len(truncated)),
+		zap.Bool("stored", link != nil))
+
+	content := []mcp.ContentBlock{mcp.TextContent(truncated), mcp.TextContent(note)}
+	if link != nil {
+		content = append(content, *link)
+	}
+	return content, true
+}
+
+// truncateJSON shrinks a JSON document to at most maxBytes. Arrays are
+// shortened, largest first, so the result stays valid JSON where possible;
+// if that is not enough the text is cut at maxBytes.
+func truncateJSON(text string, maxBytes int) string {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err == nil {
+		for {
+			encoded, err := encodeJSON(doc)
+			if err != nil {
+				break
+			}
+			if len(encoded) <= maxBytes {
+				return encoded
+			}
+
+			arr, setter := largestArray(doc, func(v interface{}) { doc = v })
+			if len(arr) == 0 {
+				break
+			}
+			setter(arr[:len(arr)/2])
+		}
+	}
+
+	return cutUTF8(text, maxBytes)
+}
+
+// largestArray finds the non-empty array with the most elements in a decoded
+// JSON value, along with a function that replaces it in its parent
+func largestArray(v interface{}, set func(interface{})) ([]interface{}, func(interface{})) {
+	var best []interface{}
+	var bestSet func(interface{})
+
+	consider := func(arr []interface{}, s func(interface{})) {
+		if len(arr) > len(best) {
+			best, bestSet = arr, s
+		}
+	}
+
+	switch value := v.(type) {
+	case []interface{}:
+		consider(value, set)
+		for i := range value {
+			i := i
+			consider(largestArray(value[i], func(n interface{}) { value[i] = n }))
+		}
+	case map[string]interface{}:
+		for key := range value {
+			key := key
+			consider(largestArray(value[key], func(n interface{}) { value[key] = n }))
+		}
+	}
+
+	return best, bestSet
+}
+
+// encodeJSON encodes a decoded JSON value compactly without HTML escaping
+func encodeJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
+
+// cutUTF8 truncates text to at most maxBytes without splitting a UTF-8 sequence
+func cutUTF8(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return text
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateJSON_ShortensLargestArray(t *testing.T) {
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = `{"id":"item"}`
+	}
+	input := `{"name":"list","tags":["a","b"],"items":[` + strings.Join(items, ",") + `]}`
+
+	out := truncateJSON(input, 200)
+	assert.LessOrEqual(t, len(out), 200)
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &doc))
+	assert.Equal(t, "list", doc["name"])
+	assert.Len(t, doc["tags"], 2)
+	assert.NotEmpty(t, doc["items"])
+	assert.Less(t, len(doc["items"].([]interface{})), 100)
+}
+
+func TestTruncateJSON_FallsBackToCut(t *testing.T) {
+	input := `{"text":"` + strings.Repeat("é", 50) + `"}`
+
+	out := truncateJSON(input, 21)
+	assert.LessOrEqual(t, len(out), 21)
+	assert.True(t, strings.HasPrefix(input, out))
+	assert.False(t, json.Valid([]byte(out)))
+	assert.Equal(t, `{"text":"`+strings.Repeat("é", 6), out)
+}
+
+func TestResponseByteLimit(t *testing.T) {
+	assert.Equal(t, 0, responseByteLimit(config.ResponseLimitConfig{}))
+	assert.Equal(t, 100, responseByteLimit(config.ResponseLimitConfig{MaxBytes: 100}))
+	assert.Equal(t, 40, responseByteLimit(config.ResponseLimitConfig{MaxTokens: 10}))
+	assert.Equal(t, 40, responseByteLimit(config.ResponseLimitConfig{MaxBytes: 100, MaxTokens: 10}))
+	assert.Equal(t, 100, responseByteLimit(config.ResponseLimitConfig{MaxBytes: 100, MaxTokens: 1000}))
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/resources"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// UploadPath is the route of the upload endpoint
+const UploadPath = "/resources"
+
+// dataURIPrefix starts an RFC 2397 data URI
+const dataURIPrefix = "data:"
+
+// binaryResolution resolves references in the bytes fields of one call's arguments
+type binaryResolution struct {
+	handler    *Handler
+	sessionCtx *session.Context
+	total      int64
+	err        error
+}
+
+// resolveBinaryInputs replaces resource URIs and data URIs given for bytes
+// fields with the base64 content protojson expects
+func (h *Handler) resolveBinaryInputs(args *decodedArguments) error {
+	if !h.binaryInputs.Enabled {
+		return nil
+	}
+
+	resolution := &binaryResolution{handler: h, sessionCtx: args.sessionCtx}
+	walkBytesFields(args.desc, args.doc, resolution.resolve)
+	if resolution.err != nil {
+		return mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Invalid arguments: %s", resolution.err.Error()))
+	}
+	if resolution.total == 0 {
+		return nil
+	}
+
+	h.logger.Debug("Resolved binary inputs",
+		zap.String("toolName", args.toolName),
+		zap.Int64("bytes", resolution.total))
+	args.changed = true
+	return nil
+}
+
+// resolve returns the base64 content for a referenced value, or the value unchanged
+func (r *binaryResolution) resolve(fd protoreflect.FieldDescriptor, value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || r.err != nil {
+		return value
+	}
+
+	var data []byte
+	switch {
+	case resources.IsResourceURI(text):
+		_, stored, found := r.handler.resources.Get(r.sessionCtx.ID, text)
+		if !found {
+			r.err = fmt.Errorf("field %s: resource %s not found", fd.Name(), text)
+			return value
+		}
+		data = stored
+	case strings.HasPrefix(text, dataURIPrefix):
+		decoded, err := decodeDataURI(text)
+		if err != nil {
+			r.err = fmt.Errorf("field %s: %w", fd.Name(), err)
+			return value
+		}
+		data = decoded
+	default:
+		return value
+	}
+
+	r.total += int64(len(data))
+	if r.total > r.handler.binaryInputs.MaxBytes {
+		r.err = fmt.Errorf("binary inputs exceed the %d byte limit", r.handler.binaryInputs.MaxBytes)
+		return value
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// decodeDataURI returns the content of an RFC 2397 data URI
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, dataURIPrefix), ",")
+	if !ok {
+		return nil, errors.New("invalid data URI: missing ','")
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		data, err := decodeBase64(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URI: %w", err)
+		}
+		return data, nil
+	}
+
+	text, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URI: %w", err)
+	}
+	return []byte(text), nil
+}
+
+// UploadHandler stores the request body as a resource for the session named
+// by Mcp-Session-Id, so it can be passed to tools that take bytes fields
+func (h *Handler) UploadHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.binaryInputs.Enabled {
+		http.Error(w, "Binary inputs are not enabled", http.StatusNotFound)
+		return
+	}
+	r, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	sessionCtx, ok := h.sessionManager.GetSession(r.Header.Get("Mcp-Session-Id"))
+	if !ok || h.terminated.contains(sessionCtx.ID) {
+		http.Error(w, "Missing or unknown session ID", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.binaryInputs.MaxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Upload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	mimeType := r.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil {
+			name = params["filename"]
+		}
+	}
+	if name == "" {
+		name = "upload"
+	}
+
+	resource, err := h.resources.Put(sessionCtx.ID, name, mimeType, data)
+	if err != nil {
+		var tooLarge *resources.TooLargeError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Upload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.logger.Error("Failed to store upload", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Stored upload",
+		zap.String("sessionId", sessionCtx.ID),
+		zap.String("uri", resource.URI),
+		zap.Int64("size", resource.Size))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(mcp.Resource{
+		URI:      resource.URI,
+		Name:     resource.Name,
+		MimeType: resource.MimeType,
+		Size:     resource.Size,
+	}); err != nil {
+		h.logger.Error("Failed to encode upload response", zap.Error(err))
+	}
+}
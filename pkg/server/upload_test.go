@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeDataURI(t *testing.T) {
+	data, err := decodeDataURI("data:application/pdf;base64,JVBERi0=")
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-", string(data))
+
+	data, err = decodeDataURI("data:,hello%20world")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	_, err = decodeDataURI("data:text/plain")
+	assert.Error(t, err)
+
+	_, err = decodeDataURI("data:;base64,not base64!")
+	assert.Error(t, err)
+}
+
+func TestHandler_ToolsCallBinaryInputs(t *testing.T) {
+	cfg := config.Default()
+	cfg.Tools.BinaryInputs.Enabled = true
+	cfg.Tools.BinaryInputs.MaxBytes = 32
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	mockDiscoverer.On("GetMethodByTool", "docs_service_process").
+		Return(types.MethodInfo{InputDescriptor: documentDescriptor(t)}, true)
+
+	// Upload a document for the session
+	req := httptest.NewRequest(http.MethodPost, UploadPath+"?name=report.pdf", bytes.NewReader([]byte("%PDF-1.7")))
+	req.Header.Set("Mcp-Session-Id", sessionCtx.ID)
+	req.Header.Set("Content-Type", "application/pdf")
+	rec := httptest.NewRecorder()
+	handler.UploadHandler(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var uploaded mcp.Resource
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &uploaded))
+	assert.Equal(t, "report.pdf", uploaded.Name)
+	assert.Equal(t, "application/pdf", uploaded.MimeType)
+
+	t.Run("ResolvesReferences", func(t *testing.T) {
+		encode := base64.StdEncoding.EncodeToString
+		expected, err := encodeJSON(map[string]interface{}{
+			"name":    "report",
+			"content": encode([]byte("%PDF-1.7")),
+			"pages":   []interface{}{encode([]byte("hi")), "AAEC"},
+		})
+		require.NoError(t, err)
+		mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "docs_service_process", expected).
+			Return(`{}`, nil).Once()
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "docs_service_process",
+			"arguments": map[string]interface{}{
+				"name":    "report",
+				"content": uploaded.URI,
+				"pages":   []interface{}{"data:text/plain,hi", "AAEC"},
+			},
+		}, sessionCtx)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("UnknownResource", func(t *testing.T) {
+		other := handler.sessionManager.CreateSession(map[string]string{})
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "docs_service_process",
+			"arguments": map[string]interface{}{"content": uploaded.URI},
+		}, other)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("SizeLimit", func(t *testing.T) {
+		large := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("x"), 40))
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "docs_service_process",
+			"arguments": map[string]interface{}{"content": "data:;base64," + large},
+		}, sessionCtx)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "32 byte limit")
+	})
+
+	t.Run("UploadTooLarge", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, UploadPath, bytes.NewReader(bytes.Repeat([]byte("x"), 40)))
+		req.Header.Set("Mcp-Session-Id", sessionCtx.ID)
+		rec := httptest.NewRecorder()
+		handler.UploadHandler(rec, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("UploadUnknownSession", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, UploadPath, bytes.NewReader([]byte("x")))
+		req.Header.Set("Mcp-Session-Id", "missing")
+		rec := httptest.NewRecorder()
+		handler.UploadHandler(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/upstream"
+	"go.uber.org/zap"
+)
+
+// SetUpstreams attaches downstream MCP servers whose tools are re-exported
+// alongside the gRPC tools
+func (h *Handler) SetUpstreams(aggregator *upstream.Aggregator) {
+	h.upstreams = aggregator
+}
+
+// refuseUpstreamCollisions leaves out the gRPC tools whose names carry the
+// prefix of an upstream server, since calls of such names cannot be routed
+// unambiguously
+func (h *Handler) refuseUpstreamCollisions(tools []mcp.Tool) []mcp.Tool {
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+		owner, collides := h.upstreams.Owner(tool.Name)
+		if collides {
+			h.logger.Error("gRPC tool name carries an upstream prefix and is not exported",
+				zap.String("toolName", tool.Name),
+				zap.String("upstream", owner))
+		}
+		return collides
+	})
+}
+
+// checkUpstreamCollision refuses calls of a name both a gRPC method and an
+// upstream server claim
+func (h *Handler) checkUpstreamCollision(toolName string) error {
+	if _, isGRPC := h.serviceDiscoverer.GetMethodByTool(toolName); !isGRPC {
+		return nil
+	}
+	owner, _ := h.upstreams.Owner(toolName)
+	return mcp.NewRPCError(mcp.ErrorCodeInvalidParams,
+		fmt.Sprintf("Tool %s is ambiguous: it is a gRPC tool and carries the prefix of upstream %s", toolName, owner))
+}
+
+// callUpstreamTool proxies a call of a re-exported tool to its MCP server.
+// The result is passed through as the server returned it; errors the server
+// answers with are returned to the client, connection failures become an
+// error result like a failed gRPC invocation.
+func (h *Handler) callUpstreamTool(ctx context.Context, toolName string, params map[string]interface{}, sessionCtx *session.Context) (*mcp.ToolCallResult, error) {
+	arguments, _ := params["arguments"].(map[string]interface{})
+
+	start := time.Now()
+	result, err := h.upstreams.CallTool(ctx, toolName, arguments)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		var rpcErr *mcp.RPCError
+		if errors.As(err, &rpcErr) {
+			return nil, rpcErr
+		}
+		result = &mcp.ToolCallResult{
+			Content: []mcp.ContentBlock{
+				mcp.TextContent(fmt.Sprintf("Error invoking upstream tool: %s", mcp.SanitizeError(err))),
+			},
+			IsError: true,
+		}
+	} else {
+		sessionCtx.IncrementCallCount()
+		sessionCtx.UpdateLastAccessed()
+	}
+
+	result.SetMeta(mcp.MetaKeyElapsedMs, elapsed.Milliseconds())
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/types"
+	"github.com/aalobaidi/ggRMCP/pkg/upstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_UpstreamTools(t *testing.T) {
+	// A minimal MCP server with a single tool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req mcp.JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID.Value == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		response := mcp.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+		switch req.Method {
+		case "initialize":
+			response.Result = map[string]interface{}{"protocolVersion": "2024-11-05"}
+		case "tools/list":
+			response.Result = mcp.ToolsListResult{Tools: []mcp.Tool{{
+				Name:        "search-docs",
+				Description: "Searches the documentation",
+				InputSchema: map[string]interface{}{"type": "object"},
+			}}}
+		case "tools/call":
+			var params mcp.ToolsCallParams
+			if err := req.DecodeParams(&params); err != nil || params.Name != "search-docs" {
+				response.Error = mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Unknown tool")
+				break
+			}
+			response.Result = mcp.ToolCallResult{
+				Content: []mcp.ContentBlock{mcp.TextContent("results for " + params.Arguments["query"].(string))},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer srv.Close()
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	colliding := types.MethodInfo{
+		Name:        "Search",
+		FullName:    "docs.Index.Search",
+		ServiceName: "docs.Index",
+		ToolName:    "docs_index_search",
+	}
+	mockDiscoverer.On("GetMethods").Return([]types.MethodInfo{colliding})
+	mockDiscoverer.On("GetMethodByTool", "docs_index_search").Return(colliding, true)
+	mockDiscoverer.On("GetMethodByTool", mock.Anything).Return(types.MethodInfo{}, false)
+
+	aggregator := upstream.NewAggregator([]config.UpstreamConfig{{Name: "docs", URL: srv.URL}}, zap.NewNop())
+	defer func() { _ = aggregator.Close() }()
+	handler.SetUpstreams(aggregator)
+
+	t.Run("Tools_list", func(t *testing.T) {
+		// The gRPC tool carrying the upstream's prefix is left out
+		result, err := handler.handleToolsList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		assert.Equal(t, "docs_search_docs", result.Tools[0].Name)
+		assert.Equal(t, "Searches the documentation", result.Tools[0].Description)
+	})
+
+	t.Run("Colliding_tool_is_refused", func(t *testing.T) {
+		_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "docs_index_search",
+		}, sessionCtx)
+		require.Error(t, err)
+		assert.Equal(t, mcp.ErrorCodeInvalidParams, errorCodeFor(err))
+		assert.Contains(t, err.Error(), "ambiguous")
+	})
+
+	t.Run("Tools_call", func(t *testing.T) {
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name":      "docs_search_docs",
+			"arguments": map[string]interface{}{"query": "quota"},
+		}, sessionCtx)
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+		assert.Equal(t, "results for quota", result.Content[0].Text)
+		assert.Contains(t, result.Meta, mcp.MetaKeyElapsedMs)
+	})
+
+	t.Run("Unreachable_server", func(t *testing.T) {
+		down := upstream.NewAggregator([]config.UpstreamConfig{{Name: "down", URL: "http://127.0.0.1:1/mcp"}}, zap.NewNop())
+		handler.SetUpstreams(down)
+		defer handler.SetUpstreams(aggregator)
+
+		result, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{
+			"name": "down_tool",
+		}, sessionCtx)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+)
+
+// Label values of sessions without a client name or origin, and of values
+// past the cardinality limit
+const (
+	unknownLabel = "unknown"
+	otherLabel   = "other"
+)
+
+// maxLabelLength bounds a label value taken from a client
+const maxLabelLength = 64
+
+// usageCounts counts the calls carrying one label value
+type usageCounts struct {
+	Calls    int64 `json:"calls"`
+	Errors   int64 `json:"errors"`
+	Rejected int64 `json:"rejected"`
+}
+
+// usageLabels counts tool calls per client name and per origin
+type usageLabels struct {
+	originHeader string
+	maxValues    int
+
+	mu      sync.Mutex
+	clients map[string]*usageCounts
+	origins map[string]*usageCounts
+}
+
+// newUsageLabels creates the usage labels, or nil if disabled
+func newUsageLabels(labelsConfig config.UsageLabelsConfig) *usageLabels {
+	if !labelsConfig.Enabled {
+		return nil
+	}
+	return &usageLabels{
+		originHeader: textproto.CanonicalMIMEHeaderKey(labelsConfig.OriginHeader),
+		maxValues:    labelsConfig.MaxValues,
+		clients:      make(map[string]*usageCounts),
+		origins:      make(map[string]*usageCounts),
+	}
+}
+
+// labels returns the client name and origin of a session, made safe for
+// logs and metrics
+func (u *usageLabels) labels(sessionCtx *session.Context) (string, string) {
+	if u == nil {
+		return "", ""
+	}
+	clientName, _ := sessionCtx.ClientInfo()
+	return labelValue(clientName), labelValue(sessionCtx.GetHeader(u.originHeader))
+}
+
+// labelValue cuts a client-supplied value to printable ASCII of a bounded length
+func labelValue(value string) string {
+	if value == "" {
+		return unknownLabel
+	}
+	if len(value) > maxLabelLength {
+		value = value[:maxLabelLength]
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, value)
+}
+
+// record counts a call under its labels. A call failing before it completed
+// (err) is counted as rejected.
+func (u *usageLabels) record(clientName, origin string, result *mcp.ToolCallResult, err error) {
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, counts := range []*usageCounts{u.countsFor(u.clients, clientName), u.countsFor(u.origins, origin)} {
+		switch {
+		case err != nil:
+			counts.Rejected++
+		case result != nil && result.IsError:
+			counts.Calls++
+			counts.Errors++
+		default:
+			counts.Calls++
+		}
+	}
+}
+
+// countsFor returns the counters of a label value, folding values past the
+// limit into "other"
+func (u *usageLabels) countsFor(values map[string]*usageCounts, value string) *usageCounts {
+	if counts, ok := values[value]; ok {
+		return counts
+	}
+	if len(values) >= u.maxValues {
+		value = otherLabel
+		if counts, ok := values[value]; ok {
+			return counts
+		}
+	}
+	counts := &usageCounts{}
+	values[value] = counts
+	return counts
+}
+
+// stats returns the counters per client name and origin for the metrics endpoint
+func (u *usageLabels) stats() map[string]interface{} {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	copyCounts := func(values map[string]*usageCounts) map[string]usageCounts {
+		copied := make(map[string]usageCounts, len(values))
+		for value, counts := range values {
+			copied[value] = *counts
+		}
+		return copied
+	}
+	return map[string]interface{}{
+		"clients": copyCounts(u.clients),
+		"origins": copyCounts(u.origins),
+	}
+}
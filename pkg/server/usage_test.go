@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUsageLabels_Cardinality(t *testing.T) {
+	assert.Nil(t, newUsageLabels(config.UsageLabelsConfig{}))
+
+	usage := newUsageLabels(config.UsageLabelsConfig{Enabled: true, OriginHeader: "x-origin", MaxValues: 2})
+	sessionCtx := &session.Context{Headers: map[string]string{"X-Origin": "https://app.example.com"}}
+	sessionCtx.SetClientInfo("bot\n"+strings.Repeat("x", 100), "1.0")
+
+	clientName, origin := usage.labels(sessionCtx)
+	assert.Equal(t, "bot?"+strings.Repeat("x", 60), clientName)
+	assert.Equal(t, "https://app.example.com", origin)
+
+	clientName, origin = usage.labels(&session.Context{})
+	assert.Equal(t, unknownLabel, clientName)
+	assert.Equal(t, unknownLabel, origin)
+
+	usage.record("claude-ai", unknownLabel, &mcp.ToolCallResult{}, nil)
+	usage.record("cursor", unknownLabel, &mcp.ToolCallResult{IsError: true}, nil)
+	usage.record("custom-bot", unknownLabel, nil, errors.New("denied"))
+	usage.record("other-bot", unknownLabel, &mcp.ToolCallResult{}, nil)
+
+	stats := usage.stats()
+	assert.Equal(t, map[string]usageCounts{
+		"claude-ai": {Calls: 1},
+		"cursor":    {Calls: 1, Errors: 1},
+		otherLabel:  {Calls: 1, Rejected: 1},
+	}, stats["clients"])
+	assert.Equal(t, map[string]usageCounts{
+		unknownLabel: {Calls: 3, Errors: 1, Rejected: 1},
+	}, stats["origins"])
+}
+
+func TestHandler_UsageLabels(t *testing.T) {
+	var mu sync.Mutex
+	var events []webhook.Event
+	cfg := config.Default()
+	cfg.MCP.UsageLabels.Enabled = true
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, cfg)
+	sessionCtx.Headers["Origin"] = "https://agents.example.com"
+	handler.recordClientCapabilities(mcp.InitializeParams{ClientInfo: mcp.ClientInfo{Name: "claude-ai", Version: "0.1.0"}}, sessionCtx)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var delivery struct {
+			Events []webhook.Event `json:"events"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&delivery))
+		mu.Lock()
+		events = append(events, delivery.Events...)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+	dispatcher := webhook.NewDispatcher([]config.WebhookConfig{{Name: "siem", URL: receiver.URL, FlushInterval: time.Hour}}, zap.NewNop())
+	handler.SetWebhooks(dispatcher)
+
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "shop_orders_get", "").
+		Return("", status.Error(codes.Unavailable, "backend down"))
+	_, err := handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "shop_orders_get"}, sessionCtx)
+	require.NoError(t, err)
+
+	stats := handler.usage.stats()
+	assert.Equal(t, map[string]usageCounts{"claude-ai": {Calls: 1, Errors: 1}}, stats["clients"])
+	assert.Equal(t, map[string]usageCounts{"https://agents.example.com": {Calls: 1, Errors: 1}}, stats["origins"])
+
+	require.NoError(t, dispatcher.Close())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 1)
+	assert.Equal(t, "claude-ai", events[0].ClientName)
+	assert.Equal(t, "https://agents.example.com", events[0].Origin)
+}
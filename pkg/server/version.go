@@ -0,0 +1,22 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aalobaidi/ggRMCP/pkg/version"
+	"go.uber.org/zap"
+)
+
+// VersionPath is the endpoint reporting the build of the running gateway
+const VersionPath = "/version"
+
+// VersionHandler reports the gateway's version, commit and build date
+func (h *Handler) VersionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(version.Get()); err != nil {
+		h.logger.Error("Failed to encode version", zap.Error(err))
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Version(t *testing.T) {
+	defer func(v, c, d string) { version.Version, version.Commit, version.Date = v, c, d }(version.Version, version.Commit, version.Date)
+	version.Version, version.Commit, version.Date = "v1.4.0", "0123456789abcdef", "2026-10-01T12:00:00Z"
+
+	handler, _, _ := newTestHandler(t, config.Default())
+
+	rec := httptest.NewRecorder()
+	handler.VersionHandler(rec, httptest.NewRequest(http.MethodGet, VersionPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var info version.Info
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, "v1.4.0", info.Version)
+	assert.Equal(t, "0123456789abcdef", info.Commit)
+	assert.Equal(t, "2026-10-01T12:00:00Z", info.Date)
+
+	// Clients see the same version in the initialize result
+	assert.Equal(t, "v1.4.0", handler.handleInitialize().ServerInfo.Version)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/events"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/session"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+)
+
+// SetWebhooks attaches the webhooks notified after each tool call
+func (h *Handler) SetWebhooks(dispatcher *webhook.Dispatcher) {
+	h.webhooks = dispatcher
+}
+
+// SetEvents attaches the event stream receiving each tool call
+func (h *Handler) SetEvents(sink *events.Sink) {
+	h.events = sink
+}
+
+// publishToolCall sends the outcome of a tool call to the webhooks and event
+// stream, and records it in the call history
+func (h *Handler) publishToolCall(ctx context.Context, params map[string]interface{}, sessionCtx *session.Context, result *mcp.ToolCallResult, err error, elapsed time.Duration) {
+	if h.webhooks == nil && h.events == nil && h.history == nil {
+		return
+	}
+
+	event := webhook.Event{
+		Type:      webhook.EventTypeToolCall,
+		ID:        newLogID(),
+		Time:      time.Now().UTC(),
+		Status:    webhook.StatusSuccess,
+		LatencyMs: elapsed.Milliseconds(),
+		SessionID: sessionCtx.ID,
+		RequestID: requestIDFromContext(ctx),
+	}
+	event.ClientName, event.Origin = h.usage.labels(sessionCtx)
+	event.Tool, _ = params["name"].(string)
+	if arguments, ok := params["arguments"]; ok && arguments != nil {
+		if encoded, err := json.Marshal(arguments); err == nil {
+			event.Arguments = string(encoded)
+		}
+	}
+
+	if err != nil {
+		event.Status = webhook.StatusRejected
+		event.Code = strconv.Itoa(errorCodeFor(err))
+	} else {
+		if result.IsError {
+			event.Status = webhook.StatusError
+		}
+		event.Code, _ = result.Meta[mcp.MetaKeyUpstreamStatus].(string)
+	}
+
+	h.webhooks.Publish(event)
+	h.events.PublishInvocation(event)
+	h.recordHistory(event, result)
+}
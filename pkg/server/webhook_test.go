@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/events"
+	"github.com/aalobaidi/ggRMCP/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandler_ToolCallWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []webhook.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var delivery struct {
+			Events []webhook.Event `json:"events"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&delivery))
+		mu.Lock()
+		events = append(events, delivery.Events...)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	dispatcher := webhook.NewDispatcher([]config.WebhookConfig{{
+		Name:             "siem",
+		URL:              receiver.URL,
+		FlushInterval:    time.Hour,
+		MaxArgumentBytes: 10,
+	}}, zap.NewNop())
+	handler.SetWebhooks(dispatcher)
+
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_get", `{"id":"order-12345"}`).
+		Return(`{"status":"shipped"}`, nil)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_fail", "").
+		Return("", status.Error(codes.Unavailable, "backend down"))
+
+	ctx := withRequestID(context.Background(), "req-1")
+	_, err := handler.HandleToolsCall(ctx, map[string]interface{}{
+		"name":      "test_service_get",
+		"arguments": map[string]interface{}{"id": "order-12345"},
+	}, sessionCtx)
+	require.NoError(t, err)
+	_, err = handler.HandleToolsCall(ctx, map[string]interface{}{"name": "test_service_fail"}, sessionCtx)
+	require.NoError(t, err)
+	_, err = handler.HandleToolsCall(ctx, map[string]interface{}{"name": 42}, sessionCtx)
+	require.Error(t, err)
+
+	// Closing flushes the batch
+	require.NoError(t, dispatcher.Close())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 3)
+
+	assert.Equal(t, webhook.EventTypeToolCall, events[0].Type)
+	assert.Equal(t, "test_service_get", events[0].Tool)
+	assert.Equal(t, webhook.StatusSuccess, events[0].Status)
+	assert.Equal(t, "OK", events[0].Code)
+	assert.Equal(t, sessionCtx.ID, events[0].SessionID)
+	assert.Equal(t, "req-1", events[0].RequestID)
+	assert.Equal(t, `{"id":"ord`, events[0].Arguments)
+	assert.True(t, events[0].ArgumentsTruncated)
+	assert.NotEmpty(t, events[0].ID)
+
+	assert.Equal(t, webhook.StatusError, events[1].Status)
+	assert.Equal(t, "Unavailable", events[1].Code)
+
+	assert.Equal(t, webhook.StatusRejected, events[2].Status)
+	assert.Equal(t, "-32602", events[2].Code)
+}
+
+func TestHandler_ToolCallEvents(t *testing.T) {
+	var mu sync.Mutex
+	var records []webhook.Event
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/ggrmcp.invocations", r.URL.Path)
+		var produce struct {
+			Records []struct {
+				Value webhook.Event `json:"value"`
+			} `json:"records"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&produce))
+		mu.Lock()
+		for _, record := range produce.Records {
+			records = append(records, record.Value)
+		}
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"offsets":[]}`))
+	}))
+	defer proxy.Close()
+
+	eventsConfig := config.Default().MCP.Events
+	eventsConfig.Enabled = true
+	eventsConfig.Driver = "kafka"
+	eventsConfig.Kafka.RESTURL = proxy.URL
+	sink, err := events.NewSink(eventsConfig, zap.NewNop())
+	require.NoError(t, err)
+
+	handler, mockDiscoverer, sessionCtx := newTestHandler(t, config.Default())
+	handler.SetEvents(sink)
+	mockDiscoverer.On("InvokeMethodByTool", mock.Anything, mock.Anything, "test_service_get", "").
+		Return(`{"status":"shipped"}`, nil)
+
+	_, err = handler.HandleToolsCall(context.Background(), map[string]interface{}{"name": "test_service_get"}, sessionCtx)
+	require.NoError(t, err)
+
+	mockDiscoverer.On("GetServiceStats").Return(map[string]interface{}{})
+	rec := httptest.NewRecorder()
+	handler.MetricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `"events":{"driver":"kafka"`)
+
+	// Closing flushes the batch
+	require.NoError(t, sink.Close())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, records, 1)
+	assert.Equal(t, "test_service_get", records[0].Tool)
+	assert.Equal(t, webhook.StatusSuccess, records[0].Status)
+	assert.Equal(t, sessionCtx.ID, records[0].SessionID)
+}
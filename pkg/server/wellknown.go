@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+)
+
+// Routes of the discovery documents
+const (
+	WellKnownMCPPath      = "/.well-known/mcp.json"
+	ProtectedResourcePath = "/.well-known/oauth-protected-resource"
+)
+
+// wellKnownCacheControl lets clients and registries cache discovery documents briefly
+const wellKnownCacheControl = "public, max-age=300"
+
+// serverCard describes the gateway so clients and registries can configure a connection
+type serverCard struct {
+	Name             string                 `json:"name"`
+	Version          string                 `json:"version"`
+	Description      string                 `json:"description,omitempty"`
+	Endpoint         string                 `json:"endpoint"`
+	Transport        serverCardTransport    `json:"transport"`
+	ProtocolVersions []string               `json:"protocolVersions"`
+	Capabilities     mcp.ServerCapabilities `json:"capabilities"`
+	Authentication   *serverCardAuth        `json:"authentication,omitempty"`
+}
+
+// serverCardTransport describes how to reach the MCP endpoint
+type serverCardTransport struct {
+	Type     string `json:"type"`
+	Endpoint string `json:"endpoint"`
+}
+
+// serverCardAuth points to the OAuth metadata of the gateway
+type serverCardAuth struct {
+	Type                      string   `json:"type"`
+	AuthorizationServers      []string `json:"authorizationServers"`
+	ProtectedResourceMetadata string   `json:"protectedResourceMetadata"`
+}
+
+// protectedResourceMetadata is the OAuth 2.0 protected resource metadata (RFC 9728)
+type protectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported"`
+	ResourceName           string   `json:"resource_name"`
+}
+
+// WellKnownHandler serves the discovery document describing the MCP endpoint
+func (h *Handler) WellKnownHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.wellKnown.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	endpoint := h.publicURL(r)
+	initResult := h.handleInitialize()
+	card := serverCard{
+		Name:        initResult.ServerInfo.Name,
+		Version:     initResult.ServerInfo.Version,
+		Description: h.wellKnown.Description,
+		Endpoint:    endpoint,
+		Transport: serverCardTransport{
+			Type:     "streamable-http",
+			Endpoint: endpoint,
+		},
+		ProtocolVersions: []string{initResult.ProtocolVersion},
+		Capabilities:     initResult.Capabilities,
+	}
+	if len(h.wellKnown.AuthorizationServers) > 0 {
+		card.Authentication = &serverCardAuth{
+			Type:                      "oauth2",
+			AuthorizationServers:      h.wellKnown.AuthorizationServers,
+			ProtectedResourceMetadata: originOf(endpoint) + ProtectedResourcePath,
+		}
+	}
+
+	h.writeWellKnown(w, card)
+}
+
+// ProtectedResourceHandler serves the OAuth protected resource metadata
+// naming the authorization servers that issue tokens for the gateway
+func (h *Handler) ProtectedResourceHandler(w http.ResponseWriter, r *http.Request) {
+	if len(h.wellKnown.AuthorizationServers) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.writeWellKnown(w, protectedResourceMetadata{
+		Resource:               h.publicURL(r),
+		AuthorizationServers:   h.wellKnown.AuthorizationServers,
+		ScopesSupported:        h.wellKnown.Scopes,
+		BearerMethodsSupported: []string{"header"},
+		ResourceName:           h.handleInitialize().ServerInfo.Name,
+	})
+}
+
+// writeWellKnown writes a cacheable discovery document
+func (h *Handler) writeWellKnown(w http.ResponseWriter, document interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", wellKnownCacheControl)
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		h.logger.Error("Failed to encode discovery document", zap.Error(err))
+	}
+}
+
+// publicURL returns the configured URL of the MCP endpoint, or derives it
+// from the request, honoring X-Forwarded-Proto behind a TLS-terminating proxy
+func (h *Handler) publicURL(r *http.Request) string {
+	if h.wellKnown.PublicURL != "" {
+		return h.wellKnown.PublicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	return scheme + "://" + r.Host + "/"
+}
+
+// originOf returns the scheme and host of a URL
+func originOf(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return strings.TrimSuffix(raw, "/")
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_WellKnown(t *testing.T) {
+	get := func(handler http.HandlerFunc, path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = "mcp.internal:8080"
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	t.Run("Server_card", func(t *testing.T) {
+		handler, _, _ := newTestHandler(t, config.Default())
+
+		rec := get(handler.WellKnownHandler, WellKnownMCPPath, http.Header{"X-Forwarded-Proto": {"https"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, wellKnownCacheControl, rec.Header().Get("Cache-Control"))
+
+		var card serverCard
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &card))
+		assert.Equal(t, "ggRMCP", card.Name)
+		assert.Equal(t, "https://mcp.internal:8080/", card.Endpoint)
+		assert.Equal(t, "streamable-http", card.Transport.Type)
+		assert.Equal(t, []string{"2024-11-05"}, card.ProtocolVersions)
+		assert.NotNil(t, card.Capabilities.Tools)
+		assert.Nil(t, card.Authentication)
+
+		// Without authorization servers there is no protected resource metadata
+		rec = get(handler.ProtectedResourceHandler, ProtectedResourcePath, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("OAuth_metadata", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.WellKnown.PublicURL = "https://mcp.example.com/mcp"
+		cfg.MCP.WellKnown.AuthorizationServers = []string{"https://auth.example.com"}
+		cfg.MCP.WellKnown.Scopes = []string{"tools:call"}
+		handler, _, _ := newTestHandler(t, cfg)
+
+		var card serverCard
+		rec := get(handler.WellKnownHandler, WellKnownMCPPath, nil)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &card))
+		assert.Equal(t, "https://mcp.example.com/mcp", card.Endpoint)
+		require.NotNil(t, card.Authentication)
+		assert.Equal(t, "https://mcp.example.com"+ProtectedResourcePath, card.Authentication.ProtectedResourceMetadata)
+
+		var metadata protectedResourceMetadata
+		rec = get(handler.ProtectedResourceHandler, ProtectedResourcePath, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metadata))
+		assert.Equal(t, "https://mcp.example.com/mcp", metadata.Resource)
+		assert.Equal(t, []string{"https://auth.example.com"}, metadata.AuthorizationServers)
+		assert.Equal(t, []string{"tools:call"}, metadata.ScopesSupported)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.MCP.WellKnown.Enabled = false
+		handler, _, _ := newTestHandler(t, cfg)
+
+		rec := get(handler.WellKnownHandler, WellKnownMCPPath, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -29,12 +29,58 @@ type Context struct {
 	// Security
 	IsBlocked bool `json:"is_blocked"`
 
+	// Client roots (filesystem boundaries declared by the client)
+	rootsSupported bool
+	roots          []string
+	rootsKnown     bool
+
+	// Client sampling (LLM completions requested by the server)
+	samplingSupported bool
+
+	// Client elicitation (questions the server asks the user)
+	elicitationSupported bool
+
+	// Client name and version declared in initialize
+	clientName    string
+	clientVersion string
+
+	// Toolset profile selected in initialize
+	profile string
+
 	// Synchronization
 	mu sync.RWMutex
 }
 
-// Manager manages user sessions
-type Manager struct {
+// Manager stores the sessions of MCP clients. MemoryManager keeps them in
+// the gateway's memory; other stores can be passed to the handler instead.
+type Manager interface {
+	// GetOrCreateSession gets an existing session or creates a new one
+	GetOrCreateSession(sessionID string, headers map[string]string) *Context
+
+	// CreateSession creates a new session
+	CreateSession(headers map[string]string) *Context
+
+	// GetSession retrieves a session by ID
+	GetSession(sessionID string) (*Context, bool)
+
+	// DeleteSession ends a session
+	DeleteSession(sessionID string)
+
+	// Export returns snapshots of the active sessions
+	Export() []Snapshot
+
+	// Import restores exported sessions
+	Import(snapshots []Snapshot) ImportResult
+
+	// GetSessionStats returns session statistics
+	GetSessionStats() map[string]interface{}
+
+	// Close closes the store
+	Close() error
+}
+
+// MemoryManager manages user sessions in memory
+type MemoryManager struct {
 	cache  *gocache.Cache
 	logger *zap.Logger
 	mu     sync.RWMutex
@@ -49,12 +95,12 @@ type Manager struct {
 	windowSize        time.Duration
 }
 
-// NewManager creates a new session manager
-func NewManager(logger *zap.Logger) *Manager {
+// NewManager creates a new in-memory session manager
+func NewManager(logger *zap.Logger) *MemoryManager {
 	defaultExpiration := 30 * time.Minute
 	cleanupInterval := 5 * time.Minute
 
-	return &Manager{
+	return &MemoryManager{
 		cache:             gocache.New(defaultExpiration, cleanupInterval),
 		logger:            logger,
 		defaultExpiration: defaultExpiration,
@@ -66,7 +112,7 @@ func NewManager(logger *zap.Logger) *Manager {
 }
 
 // GetOrCreateSession gets an existing session or creates a new one
-func (m *Manager) GetOrCreateSession(sessionID string, headers map[string]string) *Context {
+func (m *MemoryManager) GetOrCreateSession(sessionID string, headers map[string]string) *Context {
 	// If no session ID provided, create a new session
 	if sessionID == "" {
 		return m.CreateSession(headers)
@@ -84,7 +130,7 @@ func (m *Manager) GetOrCreateSession(sessionID string, headers map[string]string
 }
 
 // CreateSession creates a new session
-func (m *Manager) CreateSession(headers map[string]string) *Context {
+func (m *MemoryManager) CreateSession(headers map[string]string) *Context {
 	// Check if we're at the session limit
 	if m.cache.ItemCount() >= m.maxSessions {
 		m.logger.Warn("Session limit reached", zap.Int("current", m.cache.ItemCount()), zap.Int("max", m.maxSessions))
@@ -122,7 +168,7 @@ func (m *Manager) CreateSession(headers map[string]string) *Context {
 }
 
 // GetSession retrieves a session by ID
-func (m *Manager) GetSession(sessionID string) (*Context, bool) {
+func (m *MemoryManager) GetSession(sessionID string) (*Context, bool) {
 	if item, exists := m.cache.Get(sessionID); exists {
 		if ctx, ok := item.(*Context); ok {
 			return ctx, true
@@ -132,18 +178,18 @@ func (m *Manager) GetSession(sessionID string) (*Context, bool) {
 }
 
 // UpdateSession updates an existing session
-func (m *Manager) UpdateSession(sessionID string, ctx *Context) {
+func (m *MemoryManager) UpdateSession(sessionID string, ctx *Context) {
 	m.cache.Set(sessionID, ctx, m.defaultExpiration)
 }
 
 // DeleteSession removes a session
-func (m *Manager) DeleteSession(sessionID string) {
+func (m *MemoryManager) DeleteSession(sessionID string) {
 	m.cache.Delete(sessionID)
 	m.logger.Info("Deleted session", zap.String("sessionId", sessionID))
 }
 
 // BlockSession blocks a session
-func (m *Manager) BlockSession(sessionID string) {
+func (m *MemoryManager) BlockSession(sessionID string) {
 	if ctx, exists := m.GetSession(sessionID); exists {
 		ctx.mu.Lock()
 		ctx.IsBlocked = true
@@ -154,7 +200,7 @@ func (m *Manager) BlockSession(sessionID string) {
 }
 
 // UnblockSession unblocks a session
-func (m *Manager) UnblockSession(sessionID string) {
+func (m *MemoryManager) UnblockSession(sessionID string) {
 	if ctx, exists := m.GetSession(sessionID); exists {
 		ctx.mu.Lock()
 		ctx.IsBlocked = false
@@ -165,7 +211,7 @@ func (m *Manager) UnblockSession(sessionID string) {
 }
 
 // IsSessionBlocked checks if a session is blocked
-func (m *Manager) IsSessionBlocked(sessionID string) bool {
+func (m *MemoryManager) IsSessionBlocked(sessionID string) bool {
 	if ctx, exists := m.GetSession(sessionID); exists {
 		ctx.mu.RLock()
 		defer ctx.mu.RUnlock()
@@ -175,7 +221,7 @@ func (m *Manager) IsSessionBlocked(sessionID string) bool {
 }
 
 // CheckRateLimit checks if a session has exceeded the rate limit
-func (m *Manager) CheckRateLimit(sessionID string) bool {
+func (m *MemoryManager) CheckRateLimit(sessionID string) bool {
 	ctx, exists := m.GetSession(sessionID)
 	if !exists {
 		return true // Allow if session doesn't exist
@@ -208,7 +254,7 @@ func (m *Manager) CheckRateLimit(sessionID string) bool {
 }
 
 // GetSessionStats returns session statistics
-func (m *Manager) GetSessionStats() map[string]interface{} {
+func (m *MemoryManager) GetSessionStats() map[string]interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -220,11 +266,47 @@ func (m *Manager) GetSessionStats() map[string]interface{} {
 		"requests_per_minute": m.requestsPerMinute,
 	}
 
+	// Active sessions by age, and their tool calls
+	ages := map[string]int{"under_1m": 0, "under_10m": 0, "under_1h": 0, "under_1d": 0, "over_1d": 0}
+	active := 0
+	var totalCalls, maxCalls int64
+	now := time.Now()
+	for _, item := range m.cache.Items() {
+		ctx, ok := item.Object.(*Context)
+		if !ok {
+			continue
+		}
+		active++
+		ages[ageBucket(now.Sub(ctx.CreatedAt))]++
+		calls := atomic.LoadInt64(&ctx.CallCount)
+		totalCalls += calls
+		maxCalls = max(maxCalls, calls)
+	}
+	stats["active_sessions"] = active
+	stats["age_distribution"] = ages
+	stats["calls"] = map[string]int64{"total": totalCalls, "max_per_session": maxCalls}
+
 	return stats
 }
 
+// ageBucket names the age distribution bucket of a session age
+func ageBucket(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "under_1m"
+	case age < 10*time.Minute:
+		return "under_10m"
+	case age < time.Hour:
+		return "under_1h"
+	case age < 24*time.Hour:
+		return "under_1d"
+	default:
+		return "over_1d"
+	}
+}
+
 // GetActiveSessions returns information about active sessions
-func (m *Manager) GetActiveSessions() []map[string]interface{} {
+func (m *MemoryManager) GetActiveSessions() []map[string]interface{} {
 	var sessions []map[string]interface{}
 
 	for sessionID, item := range m.cache.Items() {
@@ -249,13 +331,13 @@ func (m *Manager) GetActiveSessions() []map[string]interface{} {
 }
 
 // cleanup removes expired sessions
-func (m *Manager) cleanup() {
+func (m *MemoryManager) cleanup() {
 	m.cache.DeleteExpired()
 	m.logger.Debug("Cleaned up expired sessions")
 }
 
 // generateSessionID generates a cryptographically secure session ID
-func (m *Manager) generateSessionID() string {
+func (m *MemoryManager) generateSessionID() string {
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
 		// Fallback to timestamp-based ID if random generation fails
@@ -265,7 +347,7 @@ func (m *Manager) generateSessionID() string {
 }
 
 // Close closes the session manager
-func (m *Manager) Close() error {
+func (m *MemoryManager) Close() error {
 	m.cache.Flush()
 	m.logger.Info("Session manager closed")
 	return nil
@@ -328,6 +410,99 @@ func (ctx *Context) SetHeader(key, value string) {
 	ctx.Headers[key] = value
 }
 
+// SetRootsSupported records whether the client declared the roots capability
+func (ctx *Context) SetRootsSupported(supported bool) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.rootsSupported = supported
+}
+
+// RootsSupported reports whether the client declared the roots capability
+func (ctx *Context) RootsSupported() bool {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.rootsSupported
+}
+
+// SetRoots stores the root URIs listed by the client
+func (ctx *Context) SetRoots(roots []string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.roots = append([]string(nil), roots...)
+	ctx.rootsKnown = true
+}
+
+// GetRoots returns the client's root URIs and whether they have been fetched
+func (ctx *Context) GetRoots() ([]string, bool) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return append([]string(nil), ctx.roots...), ctx.rootsKnown
+}
+
+// InvalidateRoots marks the roots as stale after the client reports a change
+func (ctx *Context) InvalidateRoots() {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.rootsKnown = false
+}
+
+// SetSamplingSupported records whether the client declared the sampling capability
+func (ctx *Context) SetSamplingSupported(supported bool) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.samplingSupported = supported
+}
+
+// SamplingSupported reports whether the client declared the sampling capability
+func (ctx *Context) SamplingSupported() bool {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.samplingSupported
+}
+
+// SetElicitationSupported records whether the client declared the elicitation capability
+func (ctx *Context) SetElicitationSupported(supported bool) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.elicitationSupported = supported
+}
+
+// ElicitationSupported reports whether the client declared the elicitation capability
+func (ctx *Context) ElicitationSupported() bool {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.elicitationSupported
+}
+
+// SetClientInfo records the client name and version declared in initialize
+func (ctx *Context) SetClientInfo(name, version string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.clientName = name
+	ctx.clientVersion = version
+}
+
+// ClientInfo returns the client name and version declared in initialize
+func (ctx *Context) ClientInfo() (string, string) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.clientName, ctx.clientVersion
+}
+
+// SetProfile records the toolset profile selected in initialize
+func (ctx *Context) SetProfile(profile string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.profile = profile
+}
+
+// Profile returns the toolset profile selected in initialize
+func (ctx *Context) Profile() string {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.profile
+}
+
 // GetInfo returns session information
 func (ctx *Context) GetInfo() map[string]interface{} {
 	ctx.mu.RLock()
@@ -0,0 +1,190 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Snapshot is the portable state of a session, used to move it to another
+// replica or across a restart without the client re-initializing
+type Snapshot struct {
+	ID                   string            `json:"id"`
+	Headers              map[string]string `json:"headers"`
+	CreatedAt            time.Time         `json:"created_at"`
+	LastAccessed         time.Time         `json:"last_accessed"`
+	CallCount            int64             `json:"call_count"`
+	UserAgent            string            `json:"user_agent"`
+	RemoteAddr           string            `json:"remote_addr"`
+	IsBlocked            bool              `json:"is_blocked"`
+	RootsSupported       bool              `json:"roots_supported,omitempty"`
+	Roots                []string          `json:"roots,omitempty"`
+	RootsKnown           bool              `json:"roots_known,omitempty"`
+	SamplingSupported    bool              `json:"sampling_supported,omitempty"`
+	ElicitationSupported bool              `json:"elicitation_supported,omitempty"`
+	ClientName           string            `json:"client_name,omitempty"`
+	ClientVersion        string            `json:"client_version,omitempty"`
+	Profile              string            `json:"profile,omitempty"`
+}
+
+// ImportResult reports how many snapshots were restored
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Snapshot returns the portable state of the session
+func (ctx *Context) Snapshot() Snapshot {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+
+	headers := make(map[string]string, len(ctx.Headers))
+	for key, value := range ctx.Headers {
+		headers[key] = value
+	}
+
+	return Snapshot{
+		ID:                   ctx.ID,
+		Headers:              headers,
+		CreatedAt:            ctx.CreatedAt,
+		LastAccessed:         ctx.LastAccessed,
+		CallCount:            atomic.LoadInt64(&ctx.CallCount),
+		UserAgent:            ctx.UserAgent,
+		RemoteAddr:           ctx.RemoteAddr,
+		IsBlocked:            ctx.IsBlocked,
+		RootsSupported:       ctx.rootsSupported,
+		Roots:                append([]string(nil), ctx.roots...),
+		RootsKnown:           ctx.rootsKnown,
+		SamplingSupported:    ctx.samplingSupported,
+		ElicitationSupported: ctx.elicitationSupported,
+		ClientName:           ctx.clientName,
+		ClientVersion:        ctx.clientVersion,
+		Profile:              ctx.profile,
+	}
+}
+
+// Export returns snapshots of all active sessions
+func (m *MemoryManager) Export() []Snapshot {
+	snapshots := make([]Snapshot, 0, m.cache.ItemCount())
+	for _, item := range m.cache.Items() {
+		if ctx, ok := item.Object.(*Context); ok {
+			snapshots = append(snapshots, ctx.Snapshot())
+		}
+	}
+	return snapshots
+}
+
+// Import restores sessions from snapshots. Sessions whose ID is already
+// active are left untouched, and restored sessions get a fresh expiration.
+func (m *MemoryManager) Import(snapshots []Snapshot) ImportResult {
+	var result ImportResult
+
+	for _, snapshot := range snapshots {
+		if snapshot.ID == "" {
+			result.Skipped++
+			continue
+		}
+		if _, exists := m.GetSession(snapshot.ID); exists {
+			result.Skipped++
+			continue
+		}
+		if m.cache.ItemCount() >= m.maxSessions {
+			m.logger.Warn("Session limit reached during import",
+				zap.Int("max", m.maxSessions),
+				zap.Int("remaining", len(snapshots)-result.Imported-result.Skipped))
+			result.Skipped += len(snapshots) - result.Imported - result.Skipped
+			break
+		}
+
+		headers := snapshot.Headers
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+
+		ctx := &Context{
+			ID:                   snapshot.ID,
+			Headers:              headers,
+			CreatedAt:            snapshot.CreatedAt,
+			LastAccessed:         snapshot.LastAccessed,
+			CallCount:            snapshot.CallCount,
+			UserAgent:            snapshot.UserAgent,
+			RemoteAddr:           snapshot.RemoteAddr,
+			WindowStart:          time.Now(),
+			IsBlocked:            snapshot.IsBlocked,
+			rootsSupported:       snapshot.RootsSupported,
+			roots:                append([]string(nil), snapshot.Roots...),
+			rootsKnown:           snapshot.RootsKnown,
+			samplingSupported:    snapshot.SamplingSupported,
+			elicitationSupported: snapshot.ElicitationSupported,
+			clientName:           snapshot.ClientName,
+			clientVersion:        snapshot.ClientVersion,
+			profile:              snapshot.Profile,
+		}
+
+		if err := m.cache.Add(snapshot.ID, ctx, m.defaultExpiration); err != nil {
+			result.Skipped++
+			continue
+		}
+		result.Imported++
+	}
+
+	m.logger.Info("Imported sessions",
+		zap.Int("imported", result.Imported),
+		zap.Int("skipped", result.Skipped))
+
+	return result
+}
+
+// SaveState writes snapshots of all active sessions to a file
+func (m *MemoryManager) SaveState(path string) error {
+	data, err := json.Marshal(m.Export())
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial state
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create session state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write session state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace session state file: %w", err)
+	}
+
+	m.logger.Info("Saved session state", zap.String("path", path))
+	return nil
+}
+
+// LoadState restores sessions from a file written by SaveState. A missing
+// file is not an error.
+func (m *MemoryManager) LoadState(path string) (ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ImportResult{}, nil
+	}
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to read session state file: %w", err)
+	}
+
+	var snapshots []Snapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return ImportResult{}, fmt.Errorf("failed to decode session state file: %w", err)
+	}
+
+	return m.Import(snapshots), nil
+}
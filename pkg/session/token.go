@@ -0,0 +1,237 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"go.uber.org/zap"
+)
+
+// tokenClaims is the signed content of a session token
+type tokenClaims struct {
+	ID       string            `json:"id"`
+	IssuedAt int64             `json:"iat"`
+	Headers  map[string]string `json:"h,omitempty"`
+}
+
+// TokenManager keeps no sessions: each session ID is a signed token carrying
+// the session's creation time and selected headers, so any gateway sharing
+// the secret can serve any session. State a client declares later, such as
+// its capabilities in initialize, lasts for one request only.
+type TokenManager struct {
+	keys    [][]byte // the first signs, all verify
+	headers []string // canonical names of the headers kept in tokens
+	maxAge  time.Duration
+	logger  *zap.Logger
+	now     func() time.Time
+
+	issued   atomic.Int64
+	resumed  atomic.Int64
+	rejected atomic.Int64
+}
+
+// NewTokenManager creates a session manager issuing signed session tokens
+func NewTokenManager(statelessConfig config.StatelessSessionConfig, logger *zap.Logger) *TokenManager {
+	keys := [][]byte{[]byte(statelessConfig.Secret)}
+	for _, secret := range statelessConfig.PreviousSecrets {
+		keys = append(keys, []byte(secret))
+	}
+	headers := make([]string, len(statelessConfig.Headers))
+	for i, name := range statelessConfig.Headers {
+		headers[i] = textproto.CanonicalMIMEHeaderKey(name)
+	}
+
+	return &TokenManager{
+		keys:    keys,
+		headers: headers,
+		maxAge:  statelessConfig.MaxAge,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// GetOrCreateSession resumes the session of a valid token or creates a new one
+func (m *TokenManager) GetOrCreateSession(sessionID string, headers map[string]string) *Context {
+	if sessionID == "" {
+		return m.CreateSession(headers)
+	}
+
+	claims, err := m.verify(sessionID)
+	if err != nil {
+		m.rejected.Add(1)
+		m.logger.Debug("Rejected session token", zap.Error(err))
+		return m.CreateSession(headers)
+	}
+	m.resumed.Add(1)
+
+	// The token's headers are those of the initializing request
+	merged := make(map[string]string, len(headers)+len(claims.Headers))
+	for name, value := range headers {
+		merged[name] = value
+	}
+	for name, value := range claims.Headers {
+		merged[name] = value
+	}
+	return m.sessionContext(sessionID, claims, merged)
+}
+
+// CreateSession issues a token for a new session
+func (m *TokenManager) CreateSession(headers map[string]string) *Context {
+	claims := tokenClaims{
+		ID:       newTokenID(),
+		IssuedAt: m.now().Unix(),
+	}
+	for _, name := range m.headers {
+		if value, ok := headers[name]; ok {
+			if claims.Headers == nil {
+				claims.Headers = make(map[string]string)
+			}
+			claims.Headers[name] = value
+		}
+	}
+
+	token, err := m.sign(claims)
+	if err != nil {
+		// Claims of strings and a number always encode
+		m.logger.Error("Failed to sign session token", zap.Error(err))
+	}
+	m.issued.Add(1)
+
+	m.logger.Info("Created new session",
+		zap.String("tokenId", claims.ID),
+		zap.Strings("headers", m.headers))
+
+	return m.sessionContext(token, claims, headers)
+}
+
+// GetSession resumes the session of a valid token
+func (m *TokenManager) GetSession(sessionID string) (*Context, bool) {
+	claims, err := m.verify(sessionID)
+	if err != nil {
+		return nil, false
+	}
+	return m.sessionContext(sessionID, claims, claims.Headers), true
+}
+
+// sessionContext builds the context of a request in a token's session
+func (m *TokenManager) sessionContext(token string, claims tokenClaims, headers map[string]string) *Context {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	ctx := &Context{
+		ID:           token,
+		Headers:      headers,
+		CreatedAt:    time.Unix(claims.IssuedAt, 0),
+		LastAccessed: m.now(),
+		UserAgent:    headers["User-Agent"],
+		RemoteAddr:   headers["X-Real-IP"],
+		WindowStart:  m.now(),
+	}
+	if ctx.RemoteAddr == "" {
+		ctx.RemoteAddr = headers["X-Forwarded-For"]
+	}
+	return ctx
+}
+
+// sign encodes the claims and appends their HMAC under the current key
+func (m *TokenManager) sign(claims tokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(tokenMAC(m.keys[0], encoded)), nil
+}
+
+// verify checks a token's signature against every key and its age
+func (m *TokenManager) verify(token string) (tokenClaims, error) {
+	var claims tokenClaims
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, errors.New("malformed token")
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return claims, errors.New("malformed signature")
+	}
+
+	valid := false
+	for _, key := range m.keys {
+		if hmac.Equal(mac, tokenMAC(key, encoded)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return claims, errors.New("invalid signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return claims, errors.New("malformed payload")
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("invalid claims: %w", err)
+	}
+	if age := m.now().Sub(time.Unix(claims.IssuedAt, 0)); age > m.maxAge {
+		return claims, fmt.Errorf("token expired %s ago", (age - m.maxAge).Round(time.Second))
+	}
+	return claims, nil
+}
+
+// tokenMAC returns the HMAC-SHA256 of a token's encoded claims
+func tokenMAC(key []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// newTokenID returns a random ID distinguishing tokens issued in the same second
+func newTokenID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("session_%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(bytes)
+}
+
+// DeleteSession does nothing: a token stays valid until it expires, so the
+// handler refuses terminated sessions itself
+func (m *TokenManager) DeleteSession(sessionID string) {}
+
+// Export returns no snapshots: the sessions live in their tokens
+func (m *TokenManager) Export() []Snapshot {
+	return nil
+}
+
+// Import skips every snapshot: tokens need no import
+func (m *TokenManager) Import(snapshots []Snapshot) ImportResult {
+	return ImportResult{Skipped: len(snapshots)}
+}
+
+// GetSessionStats returns token statistics
+func (m *TokenManager) GetSessionStats() map[string]interface{} {
+	return map[string]interface{}{
+		"mode":            "stateless",
+		"issued_tokens":   m.issued.Load(),
+		"resumed_tokens":  m.resumed.Load(),
+		"rejected_tokens": m.rejected.Load(),
+		"max_age":         m.maxAge.String(),
+	}
+}
+
+// Close does nothing: there is no state to release
+func (m *TokenManager) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTokenManager(t *testing.T) {
+	statelessConfig := config.StatelessSessionConfig{
+		Enabled: true,
+		Secret:  strings.Repeat("a", 32),
+		Headers: []string{"x-tenant"},
+		MaxAge:  time.Hour,
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	newManager := func(statelessConfig config.StatelessSessionConfig) *TokenManager {
+		manager := NewTokenManager(statelessConfig, zap.NewNop())
+		manager.now = func() time.Time { return now }
+		return manager
+	}
+
+	replicaA := newManager(statelessConfig)
+	replicaB := newManager(statelessConfig)
+	created := replicaA.CreateSession(map[string]string{"X-Tenant": "acme", "Authorization": "Bearer t-1"})
+	assert.Equal(t, "Bearer t-1", created.GetHeader("Authorization"))
+
+	t.Run("Resumed_on_another_replica", func(t *testing.T) {
+		resumed := replicaB.GetOrCreateSession(created.ID, map[string]string{"X-Tenant": "evil", "Authorization": "Bearer t-2"})
+		assert.Equal(t, created.ID, resumed.ID)
+		assert.Equal(t, now, resumed.CreatedAt.UTC())
+
+		// Kept headers come from the token, the others from the request
+		assert.Equal(t, "acme", resumed.GetHeader("X-Tenant"))
+		assert.Equal(t, "Bearer t-2", resumed.GetHeader("Authorization"))
+
+		looked, ok := replicaB.GetSession(created.ID)
+		require.True(t, ok)
+		assert.Equal(t, map[string]string{"X-Tenant": "acme"}, looked.Headers)
+	})
+
+	t.Run("Tampered_token_gets_new_session", func(t *testing.T) {
+		payload, signature, _ := strings.Cut(created.ID, ".")
+		tampered := payload + "x." + signature
+		session := replicaB.GetOrCreateSession(tampered, map[string]string{})
+		assert.NotEqual(t, tampered, session.ID)
+		_, ok := replicaB.GetSession(tampered)
+		assert.False(t, ok)
+		_, ok = replicaB.GetSession("not-a-token")
+		assert.False(t, ok)
+	})
+
+	t.Run("Expired_token", func(t *testing.T) {
+		later := newManager(statelessConfig)
+		later.now = func() time.Time { return now.Add(2 * time.Hour) }
+		_, ok := later.GetSession(created.ID)
+		assert.False(t, ok)
+	})
+
+	t.Run("Secret_rotation", func(t *testing.T) {
+		rotated := statelessConfig
+		rotated.Secret = strings.Repeat("b", 32)
+		rotated.PreviousSecrets = []string{statelessConfig.Secret}
+		_, ok := newManager(rotated).GetSession(created.ID)
+		assert.True(t, ok)
+
+		rotated.PreviousSecrets = nil
+		_, ok = newManager(rotated).GetSession(created.ID)
+		assert.False(t, ok)
+	})
+
+	stats := replicaB.GetSessionStats()
+	assert.Equal(t, "stateless", stats["mode"])
+	assert.Equal(t, int64(1), stats["resumed_tokens"])
+	assert.Equal(t, int64(1), stats["rejected_tokens"])
+}
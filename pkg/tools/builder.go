@@ -3,7 +3,10 @@ package tools
 import (
 	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/formats"
 	"github.com/aalobaidi/ggRMCP/pkg/mcp"
 	"github.com/aalobaidi/ggRMCP/pkg/types"
 	"go.uber.org/zap"
@@ -20,8 +23,35 @@ type MCPToolBuilder struct {
 	// Configuration
 	maxRecursionDepth int
 	includeComments   bool
+
+	// Cleanup of comments before they become descriptions (nil for none)
+	comments *commentScrubber
+
+	// Formats of string fields (nil for none)
+	formats *formats.Registry
+
+	// Schema of 64-bit integers: "integer" (default), "string" or "both"
+	int64Encoding string
+
+	// List tools whose schema fails with a permissive schema
+	degradeSchemas bool
+
+	// Schema failures of the methods of the current discovery
+	failuresMu sync.Mutex
+	failures   []SchemaFailure
+}
+
+// SchemaFailure records a method whose tool could not be built
+type SchemaFailure struct {
+	Tool     string `json:"tool"`
+	Method   string `json:"method"`
+	Error    string `json:"error"`
+	Degraded bool   `json:"degraded"`
 }
 
+// degradedSchemaWarning is shown on tools listed with a permissive schema
+const degradedSchemaWarning = "The schema of this tool could not be generated; its arguments are not described and are checked only by the backend."
+
 // NewMCPToolBuilder creates a new MCP tool builder
 func NewMCPToolBuilder(logger *zap.Logger) *MCPToolBuilder {
 	return &MCPToolBuilder{
@@ -32,10 +62,32 @@ func NewMCPToolBuilder(logger *zap.Logger) *MCPToolBuilder {
 	}
 }
 
+// NewMCPToolBuilderWithConfig creates a new MCP tool builder with the schema
+// and description settings of the tools configuration
+func NewMCPToolBuilderWithConfig(logger *zap.Logger, toolsConfig config.ToolsConfig) (*MCPToolBuilder, error) {
+	comments, err := newCommentScrubber(toolsConfig.Descriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := NewMCPToolBuilder(logger)
+	builder.comments = comments
+	builder.formats = formats.NewRegistry(toolsConfig.Formats)
+	builder.int64Encoding = toolsConfig.Int64Encoding
+	if toolsConfig.MaxDepth > 0 {
+		builder.maxRecursionDepth = toolsConfig.MaxDepth
+	}
+	builder.degradeSchemas = toolsConfig.DegradedSchemas
+	return builder, nil
+}
+
 // BuildTool builds an MCP tool from a gRPC method
 func (b *MCPToolBuilder) BuildTool(method types.MethodInfo) (mcp.Tool, error) {
-	// Generate tool name
-	toolName := method.GenerateToolName()
+	// Use the discovered tool name (which may be collapsed across package versions)
+	toolName := method.ToolName
+	if toolName == "" {
+		toolName = method.GenerateToolName()
+	}
 
 	// Generate description
 	description := b.generateDescription(method)
@@ -88,15 +140,25 @@ func (b *MCPToolBuilder) BuildTool(method types.MethodInfo) (mcp.Tool, error) {
 	return tool, nil
 }
 
+// Description returns the full description of a method's tool
+func (b *MCPToolBuilder) Description(method types.MethodInfo) string {
+	return b.generateDescription(method)
+}
+
 // generateDescription generates a tool description
 func (b *MCPToolBuilder) generateDescription(method types.MethodInfo) string {
 	// Use description from method if available (could be from FileDescriptorSet comments)
-	if method.Description != "" {
-		return method.Description
+	description := b.comments.clean(method.Description)
+	if description == "" {
+		// Fallback to generic description
+		description = fmt.Sprintf("Calls the %s method of the %s service", method.Name, method.ServiceName)
 	}
 
-	// Fallback to generic description
-	return fmt.Sprintf("Calls the %s method of the %s service", method.Name, method.ServiceName)
+	// Tell apart the versions of a service served in several package versions
+	if method.Version != "" {
+		description = fmt.Sprintf("[%s] %s", method.Version, description)
+	}
+	return description
 }
 
 // validateTool validates a generated tool
@@ -121,58 +183,213 @@ func (b *MCPToolBuilder) validateTool(tool mcp.Tool) error {
 	return nil
 }
 
-// BuildTools builds MCP tools for all methods
+// BuildTools builds MCP tools for all methods. A method whose tool cannot be
+// built is left out or, in degraded mode, listed with a permissive schema;
+// the other tools are unaffected.
 func (b *MCPToolBuilder) BuildTools(methods []types.MethodInfo) ([]mcp.Tool, error) {
+	tools, failures := b.buildTools(methods)
+	b.logger.Info("Built tools", zap.Int("count", len(tools)), zap.Int("schemaFailures", len(failures)))
+	return tools, nil
+}
+
+// BuildDiscoveredTools builds the tools of a discovery's methods like
+// BuildTools and records their schema failures, replacing those of the
+// previous discovery
+func (b *MCPToolBuilder) BuildDiscoveredTools(methods []types.MethodInfo) ([]mcp.Tool, error) {
+	tools, failures := b.buildTools(methods)
+
+	b.failuresMu.Lock()
+	b.failures = failures
+	b.failuresMu.Unlock()
+
+	b.logger.Info("Built discovered tools", zap.Int("count", len(tools)), zap.Int("schemaFailures", len(failures)))
+	return tools, nil
+}
+
+// buildTools builds the tools of the methods and returns their schema failures
+func (b *MCPToolBuilder) buildTools(methods []types.MethodInfo) ([]mcp.Tool, []SchemaFailure) {
 	var tools []mcp.Tool
+	var failures []SchemaFailure
 
 	for _, method := range methods {
-		// Skip streaming methods
-		if method.IsClientStreaming || method.IsServerStreaming {
-			b.logger.Debug("Skipping streaming method",
-				zap.String("service", method.ServiceName),
-				zap.String("method", method.Name))
-			continue
+		tool, ok, failure := b.buildMethodTool(method)
+		if failure != nil {
+			failures = append(failures, *failure)
 		}
+		if ok {
+			tools = append(tools, tool)
+		}
+	}
+	return tools, failures
+}
 
-		tool, err := b.BuildTool(method)
-		if err != nil {
-			b.logger.Error("Failed to build tool",
-				zap.String("service", method.ServiceName),
-				zap.String("method", method.Name),
-				zap.Error(err))
-			continue
+// BuildMethodTool builds the tool of one method as BuildTools would. It
+// reports false for streaming methods and, outside degraded mode, for methods
+// whose tool cannot be built.
+func (b *MCPToolBuilder) BuildMethodTool(method types.MethodInfo) (mcp.Tool, bool) {
+	tool, ok, _ := b.buildMethodTool(method)
+	return tool, ok
+}
+
+// buildMethodTool builds the tool of one method, returning the schema failure if any
+func (b *MCPToolBuilder) buildMethodTool(method types.MethodInfo) (mcp.Tool, bool, *SchemaFailure) {
+	// Skip streaming methods
+	if method.IsClientStreaming || method.IsServerStreaming {
+		b.logger.Debug("Skipping streaming method",
+			zap.String("service", method.ServiceName),
+			zap.String("method", method.Name))
+		return mcp.Tool{}, false, nil
+	}
+
+	tool, err := b.buildToolIsolated(method)
+	if err == nil {
+		return tool, true, nil
+	}
+	b.logger.Error("Failed to build tool",
+		zap.String("service", method.ServiceName),
+		zap.String("method", method.Name),
+		zap.Bool("degraded", b.degradeSchemas),
+		zap.Error(err))
+
+	failure := &SchemaFailure{
+		Tool:     toolNameOf(method),
+		Method:   method.ServiceName + "." + method.Name,
+		Error:    err.Error(),
+		Degraded: b.degradeSchemas,
+	}
+	if !b.degradeSchemas {
+		return mcp.Tool{}, false, failure
+	}
+	return b.degradedTool(method), true, failure
+}
+
+// buildToolIsolated builds a tool, turning a panic in schema generation into an error
+func (b *MCPToolBuilder) buildToolIsolated(method types.MethodInfo) (tool mcp.Tool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("schema generation panicked: %v", r)
 		}
+	}()
+	return b.BuildTool(method)
+}
 
-		tools = append(tools, tool)
+// degradedTool lists a method with a permissive input schema and a warning
+func (b *MCPToolBuilder) degradedTool(method types.MethodInfo) mcp.Tool {
+	return mcp.Tool{
+		Name:        toolNameOf(method),
+		Description: "WARNING: " + degradedSchemaWarning + "\n\n" + b.generateDescription(method),
+		InputSchema: map[string]interface{}{"type": "object"},
+		Meta: map[string]interface{}{
+			mcp.MetaKeySchemaWarning: degradedSchemaWarning,
+		},
 	}
+}
 
-	b.logger.Info("Built tools", zap.Int("count", len(tools)))
-	return tools, nil
+// SchemaFailures returns the methods of the current discovery whose tools
+// failed to build, as recorded by BuildDiscoveredTools
+func (b *MCPToolBuilder) SchemaFailures() []SchemaFailure {
+	b.failuresMu.Lock()
+	defer b.failuresMu.Unlock()
+	return append([]SchemaFailure(nil), b.failures...)
+}
+
+// toolNameOf returns the name of a method's tool
+func toolNameOf(method types.MethodInfo) string {
+	if method.ToolName != "" {
+		return method.ToolName
+	}
+	return method.GenerateToolName()
 }
 
 // ========== Schema Extraction Methods ==========
 
-// ExtractMessageSchema generates a JSON schema for a message with comments
+// schemaState tracks the generation of one root schema
+type schemaState struct {
+	// Root message, referenced as "#" from within its own schema
+	root string
+
+	// Messages being expanded on the current path
+	visited map[string]bool
+
+	// Nesting depth of the message being expanded
+	depth int
+
+	// Messages referenced by $ref instead of being inlined, by full name
+	defs map[string]interface{}
+}
+
+// ExtractMessageSchema generates a JSON schema for a message with comments.
+// Recursive messages and messages nested deeper than the maximum depth are
+// defined once under $defs and referenced, keeping the schema finite.
 func (b *MCPToolBuilder) ExtractMessageSchema(msgDesc protoreflect.MessageDescriptor) (map[string]interface{}, error) {
-	// Use internal method with visited tracking
-	return b.extractMessageSchemaInternal(msgDesc, make(map[string]bool))
+	state := &schemaState{
+		root:    string(msgDesc.FullName()),
+		visited: make(map[string]bool),
+		defs:    make(map[string]interface{}),
+	}
+
+	schema, err := b.extractMessageSchemaInternal(msgDesc, state)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.defs) > 0 {
+		schema["$defs"] = state.defs
+	}
+	return schema, nil
 }
 
 // extractMessageSchemaInternal generates a JSON schema with circular reference detection
-func (b *MCPToolBuilder) extractMessageSchemaInternal(msgDesc protoreflect.MessageDescriptor, visited map[string]bool) (map[string]interface{}, error) {
-	// Check for circular references
+func (b *MCPToolBuilder) extractMessageSchemaInternal(msgDesc protoreflect.MessageDescriptor, state *schemaState) (map[string]interface{}, error) {
+	// Reference recursive and deeply nested messages instead of inlining them
 	fullName := string(msgDesc.FullName())
-	if visited[fullName] {
-		// Return a reference to break the cycle
-		b.logger.Debug("Found circular reference, using $ref",
-			zap.String("messageType", fullName))
-		return map[string]interface{}{
-			"$ref": "#/definitions/" + fullName,
-		}, nil
+	if state.visited[fullName] || state.depth >= b.maxRecursionDepth {
+		b.logger.Debug("Referencing message schema with $ref",
+			zap.String("messageType", fullName),
+			zap.Bool("circular", state.visited[fullName]))
+		return b.referenceMessageSchema(msgDesc, state)
 	}
-	visited[fullName] = true
-	defer func() { delete(visited, fullName) }() // Clean up on exit
 
+	state.visited[fullName] = true
+	state.depth++
+	defer func() {
+		delete(state.visited, fullName)
+		state.depth--
+	}()
+
+	return b.messageSchemaBody(msgDesc, state)
+}
+
+// referenceMessageSchema returns a $ref to a message's schema, defining it
+// under $defs the first time it is referenced
+func (b *MCPToolBuilder) referenceMessageSchema(msgDesc protoreflect.MessageDescriptor, state *schemaState) (map[string]interface{}, error) {
+	fullName := string(msgDesc.FullName())
+	if fullName == state.root {
+		return map[string]interface{}{"$ref": "#"}, nil
+	}
+
+	ref := map[string]interface{}{"$ref": "#/$defs/" + fullName}
+	if _, defined := state.defs[fullName]; defined {
+		return ref, nil
+	}
+
+	// Expand the definition from its own top, so it is inlined to the full
+	// depth once rather than at every reference
+	state.defs[fullName] = nil
+	visited, depth := state.visited, state.depth
+	state.visited, state.depth = map[string]bool{fullName: true}, 1
+	definition, err := b.messageSchemaBody(msgDesc, state)
+	state.visited, state.depth = visited, depth
+	if err != nil {
+		delete(state.defs, fullName)
+		return nil, err
+	}
+
+	state.defs[fullName] = definition
+	return ref, nil
+}
+
+// messageSchemaBody generates the object schema of a message's fields and oneofs
+func (b *MCPToolBuilder) messageSchemaBody(msgDesc protoreflect.MessageDescriptor, state *schemaState) (map[string]interface{}, error) {
 	schema := map[string]interface{}{
 		"type":       "object",
 		"properties": make(map[string]interface{}),
@@ -191,7 +408,7 @@ func (b *MCPToolBuilder) extractMessageSchemaInternal(msgDesc protoreflect.Messa
 		field := msgDesc.Fields().Get(i)
 		fieldName := string(field.Name())
 
-		fieldSchema, err := b.extractFieldSchemaInternal(field, visited)
+		fieldSchema, err := b.extractFieldSchemaInternal(field, state)
 		if err != nil {
 			b.logger.Warn("Failed to extract field schema",
 				zap.String("message", string(msgDesc.FullName())),
@@ -202,10 +419,7 @@ func (b *MCPToolBuilder) extractMessageSchemaInternal(msgDesc protoreflect.Messa
 
 		properties[fieldName] = fieldSchema
 
-		// Add to required if field is required (not optional)
-		if field.HasOptionalKeyword() || field.HasPresence() {
-			// Field is optional
-		} else {
+		if isRequiredField(field) {
 			required = append(required, fieldName)
 		}
 	}
@@ -230,7 +444,7 @@ func (b *MCPToolBuilder) extractMessageSchemaInternal(msgDesc protoreflect.Messa
 			field := oneof.Fields().Get(j)
 			fieldName := string(field.Name())
 
-			fieldSchema, err := b.extractFieldSchemaInternal(field, visited)
+			fieldSchema, err := b.extractFieldSchemaInternal(field, state)
 			if err != nil {
 				b.logger.Warn("Failed to extract field schema for oneof",
 					zap.String("field", fieldName),
@@ -259,8 +473,16 @@ func (b *MCPToolBuilder) extractMessageSchemaInternal(msgDesc protoreflect.Messa
 	return schema, nil
 }
 
+// isRequiredField reports whether a field is listed as required: proto2
+// required and editions LEGACY_REQUIRED fields, and fields without presence
+// (proto3 fields without the optional keyword, editions IMPLICIT fields).
+// Fields with explicit presence, the editions default, are optional.
+func isRequiredField(field protoreflect.FieldDescriptor) bool {
+	return field.Cardinality() == protoreflect.Required || !field.HasPresence()
+}
+
 // extractFieldSchemaInternal generates schema for a single field with circular reference detection
-func (b *MCPToolBuilder) extractFieldSchemaInternal(field protoreflect.FieldDescriptor, visited map[string]bool) (map[string]interface{}, error) {
+func (b *MCPToolBuilder) extractFieldSchemaInternal(field protoreflect.FieldDescriptor, state *schemaState) (map[string]interface{}, error) {
 	schema := make(map[string]interface{})
 
 	// Add field description if available
@@ -270,7 +492,7 @@ func (b *MCPToolBuilder) extractFieldSchemaInternal(field protoreflect.FieldDesc
 
 	// Handle repeated fields
 	if field.IsList() {
-		itemSchema, err := b.extractFieldTypeSchemaInternal(field, visited)
+		itemSchema, err := b.extractFieldTypeSchemaInternal(field, state)
 		if err != nil {
 			return nil, err
 		}
@@ -283,25 +505,50 @@ func (b *MCPToolBuilder) extractFieldSchemaInternal(field protoreflect.FieldDesc
 	// Handle map fields
 	if field.IsMap() {
 		valueField := field.MapValue()
-		valueSchema, err := b.extractFieldTypeSchemaInternal(valueField, visited)
+		valueSchema, err := b.extractFieldTypeSchemaInternal(valueField, state)
 		if err != nil {
 			return nil, err
 		}
 
+		// protojson writes every map key as a JSON string, so keys of other
+		// types are constrained to their string form
+		keyPattern := mapKeyPattern(field.MapKey())
+
 		schema["type"] = "object"
 		schema["patternProperties"] = map[string]interface{}{
-			".*": valueSchema,
+			keyPattern: valueSchema,
+		}
+		if keyPattern != ".*" {
+			schema["propertyNames"] = map[string]interface{}{
+				"pattern": keyPattern,
+			}
 		}
 		schema["additionalProperties"] = false
 		return schema, nil
 	}
 
 	// Handle regular fields
-	return b.extractFieldTypeSchemaInternal(field, visited)
+	return b.extractFieldTypeSchemaInternal(field, state)
+}
+
+// mapKeyPattern returns the pattern matching the JSON form of a map key
+func mapKeyPattern(key protoreflect.FieldDescriptor) string {
+	switch key.Kind() {
+	case protoreflect.BoolKind:
+		return "^(true|false)$"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "^-?(0|[1-9][0-9]*)$"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "^(0|[1-9][0-9]*)$"
+	default:
+		return ".*"
+	}
 }
 
 // extractFieldTypeSchemaInternal generates schema for the field's type with circular reference detection
-func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.FieldDescriptor, visited map[string]bool) (map[string]interface{}, error) {
+func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.FieldDescriptor, state *schemaState) (map[string]interface{}, error) {
 	schema := make(map[string]interface{})
 
 	switch field.Kind() {
@@ -313,8 +560,7 @@ func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.Field
 		schema["format"] = "int32"
 
 	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
-		schema["type"] = "integer"
-		schema["format"] = "int64"
+		b.setInt64Schema(schema, false)
 
 	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
 		schema["type"] = "integer"
@@ -322,9 +568,7 @@ func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.Field
 		schema["minimum"] = 0
 
 	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
-		schema["type"] = "integer"
-		schema["format"] = "uint64"
-		schema["minimum"] = 0
+		b.setInt64Schema(schema, true)
 
 	case protoreflect.FloatKind:
 		schema["type"] = "number"
@@ -336,10 +580,14 @@ func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.Field
 
 	case protoreflect.StringKind:
 		schema["type"] = "string"
+		if format := b.formats.Format(field); format != "" {
+			schema["format"] = format
+		}
 
 	case protoreflect.BytesKind:
 		schema["type"] = "string"
 		schema["format"] = "byte"
+		schema["contentEncoding"] = "base64"
 
 	case protoreflect.EnumKind:
 		enumDesc := field.Enum()
@@ -370,7 +618,8 @@ func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.Field
 			schema["enumDescriptions"] = enumDescriptions
 		}
 
-	case protoreflect.MessageKind:
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// Groups and editions DELIMITED fields only differ on the wire
 		msgDesc := field.Message()
 
 		// Handle well-known types
@@ -400,26 +649,33 @@ func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.Field
 			schema["type"] = "array"
 			schema["description"] = "Array of JSON values"
 
-		case "google.protobuf.StringValue",
-			"google.protobuf.BytesValue":
+		case "google.protobuf.StringValue":
+			schema["type"] = "string"
+
+		case "google.protobuf.BytesValue":
 			schema["type"] = "string"
+			schema["contentEncoding"] = "base64"
 
 		case "google.protobuf.BoolValue":
 			schema["type"] = "boolean"
 
 		case "google.protobuf.Int32Value",
-			"google.protobuf.UInt32Value",
-			"google.protobuf.Int64Value",
-			"google.protobuf.UInt64Value":
+			"google.protobuf.UInt32Value":
 			schema["type"] = "integer"
 
+		case "google.protobuf.Int64Value":
+			b.setInt64Schema(schema, false)
+
+		case "google.protobuf.UInt64Value":
+			b.setInt64Schema(schema, true)
+
 		case "google.protobuf.FloatValue",
 			"google.protobuf.DoubleValue":
 			schema["type"] = "number"
 
 		default:
 			// Custom message type - extract schema recursively
-			messageSchema, err := b.extractMessageSchemaInternal(msgDesc, visited)
+			messageSchema, err := b.extractMessageSchemaInternal(msgDesc, state)
 			if err != nil {
 				return nil, fmt.Errorf("failed to extract schema for message %s: %w", msgDesc.FullName(), err)
 			}
@@ -433,6 +689,31 @@ func (b *MCPToolBuilder) extractFieldTypeSchemaInternal(field protoreflect.Field
 	return schema, nil
 }
 
+// setInt64Schema describes a 64-bit integer as configured. protojson accepts
+// both numbers and decimal strings but emits strings, and numbers above 2^53
+// lose precision in JSON clients.
+func (b *MCPToolBuilder) setInt64Schema(schema map[string]interface{}, unsigned bool) {
+	integer := map[string]interface{}{"type": "integer", "format": "int64"}
+	str := map[string]interface{}{"type": "string", "format": "int64", "pattern": `^-?[0-9]+$`}
+	if unsigned {
+		integer = map[string]interface{}{"type": "integer", "format": "uint64", "minimum": 0}
+		str = map[string]interface{}{"type": "string", "format": "uint64", "pattern": `^[0-9]+$`}
+	}
+
+	var chosen map[string]interface{}
+	switch b.int64Encoding {
+	case "string":
+		chosen = str
+	case "both":
+		chosen = map[string]interface{}{"oneOf": []interface{}{integer, str}}
+	default:
+		chosen = integer
+	}
+	for k, v := range chosen {
+		schema[k] = v
+	}
+}
+
 // ExtractFieldComments extracts field description from comments (trimmed)
 func (b *MCPToolBuilder) ExtractFieldComments(field protoreflect.FieldDescriptor) string {
 	return strings.TrimSpace(b.extractComments(field))
@@ -458,5 +739,5 @@ func (b *MCPToolBuilder) extractComments(desc protoreflect.Descriptor) string {
 		}
 	}
 
-	return comments
+	return b.comments.clean(comments)
 }
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/benchutil"
+	"go.uber.org/zap"
+)
+
+func BenchmarkBuildTools_LargeDescriptorSet(b *testing.B) {
+	files, err := benchutil.Files(50, 10, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	methods := benchutil.Methods(files)
+	builder := NewMCPToolBuilder(zap.NewNop())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.BuildTools(methods); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuildTool_Single(b *testing.B) {
+	files, err := benchutil.Files(1, 1, 20)
+	if err != nil {
+		b.Fatal(err)
+	}
+	method := benchutil.Methods(files)[0]
+	builder := NewMCPToolBuilder(zap.NewNop())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.BuildTool(method); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
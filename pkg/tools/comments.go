@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// Markdown constructs reduced to plain text
+var (
+	markdownImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownBold     = regexp.MustCompile(`(\*\*|__)([^*_\n]+)(\*\*|__)`)
+	markdownItalic   = regexp.MustCompile(`\*([^*\s][^*\n]*)\*`)
+	markdownCode     = regexp.MustCompile("`([^`\n]+)`")
+	markdownHeading  = regexp.MustCompile(`^#{1,6}\s+`)
+	markdownQuote    = regexp.MustCompile(`^>\s?`)
+	markdownRule     = regexp.MustCompile(`^(\*{3,}|-{3,}|_{3,})$`)
+	markdownFence    = regexp.MustCompile("^(```|~~~)")
+	markdownBlankRun = regexp.MustCompile(`\n{3,}`)
+)
+
+// commentScrubber cleans proto comments before they become descriptions
+type commentScrubber struct {
+	stripMarkdown bool
+	dropLines     []string
+	rules         []scrubRule
+}
+
+// scrubRule is a compiled regular expression replacement
+type scrubRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// newCommentScrubber compiles the comment cleanup settings, or returns nil if
+// none are configured
+func newCommentScrubber(descriptionsConfig config.DescriptionsConfig) (*commentScrubber, error) {
+	if !descriptionsConfig.StripMarkdown && len(descriptionsConfig.DropLines) == 0 && len(descriptionsConfig.Scrub) == 0 {
+		return nil, nil
+	}
+
+	scrubber := &commentScrubber{
+		stripMarkdown: descriptionsConfig.StripMarkdown,
+	}
+	for _, marker := range descriptionsConfig.DropLines {
+		if marker = strings.ToLower(strings.TrimSpace(marker)); marker != "" {
+			scrubber.dropLines = append(scrubber.dropLines, marker)
+		}
+	}
+	for i, rule := range descriptionsConfig.Scrub {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("scrub rule %d: invalid pattern: %w", i, err)
+		}
+		scrubber.rules = append(scrubber.rules, scrubRule{pattern: pattern, replacement: rule.Replacement})
+	}
+
+	return scrubber, nil
+}
+
+// clean drops marked lines, strips markdown and applies the scrub rules
+func (s *commentScrubber) clean(comment string) string {
+	if s == nil || comment == "" {
+		return comment
+	}
+
+	lines := strings.Split(comment, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if s.dropped(line) {
+			continue
+		}
+		if s.stripMarkdown {
+			var ok bool
+			if line, ok = plainLine(line); !ok {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	comment = strings.Join(kept, "\n")
+
+	for _, rule := range s.rules {
+		comment = rule.pattern.ReplaceAllString(comment, rule.replacement)
+	}
+
+	comment = markdownBlankRun.ReplaceAllString(comment, "\n\n")
+	return strings.TrimSpace(comment)
+}
+
+// dropped reports whether the line starts with a drop marker
+func (s *commentScrubber) dropped(line string) bool {
+	trimmed := strings.ToLower(strings.TrimSpace(line))
+	for _, marker := range s.dropLines {
+		if strings.HasPrefix(trimmed, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// plainLine reduces the markdown of one line to plain text, reporting false
+// for lines that only carry markup (code fences, rules)
+func plainLine(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if markdownFence.MatchString(trimmed) || markdownRule.MatchString(trimmed) {
+		return "", false
+	}
+
+	line = markdownHeading.ReplaceAllString(trimmed, "")
+	line = markdownQuote.ReplaceAllString(line, "")
+	line = markdownImage.ReplaceAllString(line, "$1")
+	line = markdownLink.ReplaceAllString(line, "$1")
+	line = markdownBold.ReplaceAllString(line, "$2")
+	line = markdownItalic.ReplaceAllString(line, "$1")
+	line = markdownCode.ReplaceAllString(line, "$1")
+	return line, true
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentScrubber(t *testing.T) {
+	comment := ` ## Places an order
+
+ Creates the order **atomically** and returns its ID. See [the guide](https://wiki.internal/orders)
+ for the ` + "`status`" + ` values.
+ TODO: support partial fills
+ @internal owned by team-payments
+
+ ` + "```" + `
+ order_id: 42
+ ` + "```" + `
+`
+
+	t.Run("Not_configured", func(t *testing.T) {
+		scrubber, err := newCommentScrubber(config.DescriptionsConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, scrubber)
+		assert.Equal(t, comment, scrubber.clean(comment))
+	})
+
+	t.Run("Markdown_and_dropped_lines", func(t *testing.T) {
+		scrubber, err := newCommentScrubber(config.DescriptionsConfig{
+			StripMarkdown: true,
+			DropLines:     []string{"todo:", "@internal"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Places an order\n\n"+
+			"Creates the order atomically and returns its ID. See the guide\n"+
+			"for the status values.\n\n"+
+			"order_id: 42", scrubber.clean(comment))
+	})
+
+	t.Run("Scrub_rules", func(t *testing.T) {
+		scrubber, err := newCommentScrubber(config.DescriptionsConfig{
+			Scrub: []config.ScrubRuleConfig{
+				{Pattern: `https?://[a-z.]+\.internal[^\s)]*`, Replacement: "[internal link]"},
+				{Pattern: `team-(\w+)`, Replacement: "the $1 team"},
+			},
+		})
+		require.NoError(t, err)
+
+		cleaned := scrubber.clean(comment)
+		assert.Contains(t, cleaned, "[the guide]([internal link])")
+		assert.Contains(t, cleaned, "owned by the payments team")
+	})
+
+	t.Run("Invalid_pattern", func(t *testing.T) {
+		_, err := newCommentScrubber(config.DescriptionsConfig{
+			Scrub: []config.ScrubRuleConfig{{Pattern: "("}},
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// counterDescriptor builds a message with signed and unsigned 64-bit fields
+func counterDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("counter.proto"),
+		Package: proto.String("metrics"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Counter"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("delta", 1, descriptorpb.FieldDescriptorProto_TYPE_SINT64),
+				field("total", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64),
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return file.Messages().ByName("Counter")
+}
+
+func TestExtractMessageSchema_Int64Encoding(t *testing.T) {
+	desc := counterDescriptor(t)
+
+	schemaFor := func(t *testing.T, encoding string) map[string]interface{} {
+		toolsConfig := config.Default().Tools
+		toolsConfig.Int64Encoding = encoding
+		builder, err := NewMCPToolBuilderWithConfig(zap.NewNop(), toolsConfig)
+		require.NoError(t, err)
+
+		schema, err := builder.ExtractMessageSchema(desc)
+		require.NoError(t, err)
+		return schema["properties"].(map[string]interface{})
+	}
+
+	t.Run("Integer", func(t *testing.T) {
+		properties := schemaFor(t, "integer")
+		assert.Equal(t, map[string]interface{}{"type": "integer", "format": "int64"}, properties["delta"])
+		assert.Equal(t, map[string]interface{}{"type": "integer", "format": "uint64", "minimum": 0}, properties["total"])
+	})
+
+	t.Run("String", func(t *testing.T) {
+		properties := schemaFor(t, "string")
+		assert.Equal(t, map[string]interface{}{"type": "string", "format": "int64", "pattern": `^-?[0-9]+$`}, properties["delta"])
+		assert.Equal(t, map[string]interface{}{"type": "string", "format": "uint64", "pattern": `^[0-9]+$`}, properties["total"])
+	})
+
+	t.Run("Both", func(t *testing.T) {
+		properties := schemaFor(t, "both")
+		assert.Equal(t, map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer", "format": "int64"},
+			map[string]interface{}{"type": "string", "format": "int64", "pattern": `^-?[0-9]+$`},
+		}}, properties["delta"])
+	})
+}
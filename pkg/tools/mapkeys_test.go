@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestExtractMessageSchema_MapKeys(t *testing.T) {
+	mapField := func(name string, number int32, entry string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".shop.Inventory." + entry),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		}
+	}
+	entry := func(name string, key descriptorpb.FieldDescriptorProto_Type) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("key"), Number: proto.Int32(1), Type: key.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("value"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+			},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("inventory.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Inventory"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				mapField("labels", 1, "LabelsEntry"),
+				mapField("stock", 2, "StockEntry"),
+				mapField("serials", 3, "SerialsEntry"),
+				mapField("flags", 4, "FlagsEntry"),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{
+				entry("LabelsEntry", descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				entry("StockEntry", descriptorpb.FieldDescriptorProto_TYPE_SINT32),
+				entry("SerialsEntry", descriptorpb.FieldDescriptorProto_TYPE_FIXED64),
+				entry("FlagsEntry", descriptorpb.FieldDescriptorProto_TYPE_BOOL),
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+
+	schema, err := NewMCPToolBuilder(zap.NewNop()).ExtractMessageSchema(file.Messages().ByName("Inventory"))
+	require.NoError(t, err)
+	properties := schema["properties"].(map[string]interface{})
+
+	tests := map[string]string{
+		"labels":  ".*",
+		"stock":   "^-?(0|[1-9][0-9]*)$",
+		"serials": "^(0|[1-9][0-9]*)$",
+		"flags":   "^(true|false)$",
+	}
+	for name, pattern := range tests {
+		property := properties[name].(map[string]interface{})
+		assert.Contains(t, property["patternProperties"], pattern, name)
+		if pattern == ".*" {
+			assert.NotContains(t, property, "propertyNames", name)
+		} else {
+			assert.Equal(t, map[string]interface{}{"pattern": pattern}, property["propertyNames"], name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// graphFile builds a self-referential Node, a Graph whose edges point at
+// nodes, and a non-recursive chain of nested messages Level1..Level6
+func graphFile(t *testing.T) protoreflect.FileDescriptor {
+	message := func(name string, number int32, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(typeName),
+			Label:    label.Enum(),
+		}
+	}
+	id := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("id"),
+		JsonName: proto.String("id"),
+		Number:   proto.Int32(1),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+
+	messages := []*descriptorpb.DescriptorProto{
+		{
+			Name: proto.String("Node"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				id,
+				message("children", 2, ".graph.Node", true),
+				message("parent", 3, ".graph.Node", false),
+			},
+		},
+		{
+			Name: proto.String("Edge"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				message("from", 1, ".graph.Node", false),
+				message("to", 2, ".graph.Node", false),
+			},
+		},
+		{
+			Name: proto.String("Graph"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				message("nodes", 1, ".graph.Node", true),
+				message("edges", 2, ".graph.Edge", true),
+			},
+		},
+	}
+	for level := 1; level <= 6; level++ {
+		fields := []*descriptorpb.FieldDescriptorProto{id}
+		if level < 6 {
+			fields = append(fields, message("next", 2, ".graph.Level"+string(rune('1'+level)), false))
+		}
+		messages = append(messages, &descriptorpb.DescriptorProto{
+			Name:  proto.String("Level" + string(rune('0'+level))),
+			Field: fields,
+		})
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("graph.proto"),
+		Package:     proto.String("graph"),
+		Syntax:      proto.String("proto3"),
+		MessageType: messages,
+	}, nil)
+	require.NoError(t, err)
+	return file
+}
+
+// collectRefs returns every $ref value in a schema
+func collectRefs(schema interface{}) []string {
+	var refs []string
+	switch value := schema.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				refs = append(refs, ref)
+			}
+			refs = append(refs, collectRefs(child)...)
+		}
+	case []interface{}:
+		for _, child := range value {
+			refs = append(refs, collectRefs(child)...)
+		}
+	}
+	return refs
+}
+
+// assertRefsResolve checks that every $ref points at the root or a definition
+func assertRefsResolve(t *testing.T, schema map[string]interface{}) {
+	defs, _ := schema["$defs"].(map[string]interface{})
+	for _, ref := range collectRefs(schema) {
+		if ref == "#" {
+			continue
+		}
+		require.True(t, strings.HasPrefix(ref, "#/$defs/"), ref)
+		assert.Contains(t, defs, strings.TrimPrefix(ref, "#/$defs/"))
+	}
+}
+
+func TestExtractMessageSchema_Recursion(t *testing.T) {
+	file := graphFile(t)
+	builder := NewMCPToolBuilder(zap.NewNop())
+
+	t.Run("Self_reference", func(t *testing.T) {
+		schema, err := builder.ExtractMessageSchema(file.Messages().ByName("Node"))
+		require.NoError(t, err)
+
+		properties := schema["properties"].(map[string]interface{})
+		children := properties["children"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"$ref": "#"}, children["items"])
+		assert.Equal(t, map[string]interface{}{"$ref": "#"}, properties["parent"])
+		assert.NotContains(t, schema, "$defs")
+	})
+
+	t.Run("Shared_recursive_definition", func(t *testing.T) {
+		schema, err := builder.ExtractMessageSchema(file.Messages().ByName("Graph"))
+		require.NoError(t, err)
+		assertRefsResolve(t, schema)
+
+		defs := schema["$defs"].(map[string]interface{})
+		assert.Len(t, defs, 1)
+		node := defs["graph.Node"].(map[string]interface{})
+		nodeProperties := node["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"$ref": "#/$defs/graph.Node"}, nodeProperties["parent"])
+	})
+
+	t.Run("Inline_depth", func(t *testing.T) {
+		toolsConfig := config.Default().Tools
+		toolsConfig.MaxDepth = 3
+		shallow, err := NewMCPToolBuilderWithConfig(zap.NewNop(), toolsConfig)
+		require.NoError(t, err)
+
+		schema, err := shallow.ExtractMessageSchema(file.Messages().ByName("Level1"))
+		require.NoError(t, err)
+		assertRefsResolve(t, schema)
+
+		// Level1..Level3 are inlined, Level4 is defined once and inlines the rest
+		level3 := schema["properties"].(map[string]interface{})["next"].(map[string]interface{})["properties"].(map[string]interface{})["next"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"$ref": "#/$defs/graph.Level4"}, level3["properties"].(map[string]interface{})["next"])
+		assert.Equal(t, []string{"graph.Level4"}, keysOf(schema["$defs"].(map[string]interface{})))
+
+		// The full chain is inlined within the default depth
+		schema, err = builder.ExtractMessageSchema(file.Messages().ByName("Level1"))
+		require.NoError(t, err)
+		assert.NotContains(t, schema, "$defs")
+	})
+
+	t.Run("Bounded_size", func(t *testing.T) {
+		sizes := make([]int, 0, 3)
+		for _, depth := range []int{2, 10, 50} {
+			toolsConfig := config.Default().Tools
+			toolsConfig.MaxDepth = depth
+			limited, err := NewMCPToolBuilderWithConfig(zap.NewNop(), toolsConfig)
+			require.NoError(t, err)
+
+			schema, err := limited.ExtractMessageSchema(file.Messages().ByName("Graph"))
+			require.NoError(t, err)
+			encoded, err := json.Marshal(schema)
+			require.NoError(t, err)
+			sizes = append(sizes, len(encoded))
+		}
+
+		// Recursion is cut by references, not by the depth limit
+		assert.Equal(t, sizes[1], sizes[2])
+		assert.Less(t, sizes[2], 2048)
+	})
+}
+
+// keysOf returns the keys of a map
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
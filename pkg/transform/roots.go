@@ -0,0 +1,175 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// rootsKey is the context key for the client's roots
+type rootsKey struct{}
+
+// WithRoots returns a context carrying the root URIs declared by the client
+func WithRoots(ctx context.Context, roots []string) context.Context {
+	return context.WithValue(ctx, rootsKey{}, roots)
+}
+
+// RootsFromContext returns the client's root URIs, and false if the client
+// did not declare the roots capability
+func RootsFromContext(ctx context.Context) ([]string, bool) {
+	roots, ok := ctx.Value(rootsKey{}).([]string)
+	return roots, ok
+}
+
+// ContextHook is a hook that also needs the request context (e.g. for the client's roots)
+type ContextHook interface {
+	Hook
+
+	// TransformContext is called instead of Transform when the pipeline has a context
+	TransformContext(ctx context.Context, toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// PathHook is the built-in hook that keeps path-like request arguments inside
+// the client's roots, optionally resolving relative paths against the first root.
+// Clients that declared no roots cannot pass path arguments at all.
+type PathHook struct {
+	config config.PathArgumentConfig
+}
+
+// NewPathHook creates a built-in path hook from configuration
+func NewPathHook(config config.PathArgumentConfig) *PathHook {
+	return &PathHook{
+		config: config,
+	}
+}
+
+// Applies reports whether the hook should run for the given tool and stage
+func (h *PathHook) Applies(toolName string, stage Stage) bool {
+	if stage != StageRequest {
+		return false
+	}
+	return h.config.Tool == "*" || h.config.Tool == toolName
+}
+
+// Transform has no roots to check against without a context, so it refuses path arguments
+func (h *PathHook) Transform(toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error) {
+	return h.TransformContext(context.Background(), toolName, stage, payload)
+}
+
+// TransformContext checks each configured path field against the client's roots
+func (h *PathHook) TransformContext(ctx context.Context, toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error) {
+	rootURIs, declared := RootsFromContext(ctx)
+
+	roots := make([]string, 0, len(rootURIs))
+	for _, uri := range rootURIs {
+		if root, ok := localPath(uri); ok {
+			roots = append(roots, resolveSymlinks(root))
+		}
+	}
+
+	for _, field := range h.config.Fields {
+		value, exists := getPath(payload, field)
+		if !exists {
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			resolved, err := h.resolve(field, v, roots, declared)
+			if err != nil {
+				return nil, err
+			}
+			setPath(payload, field, resolved)
+		case []interface{}:
+			for i, item := range v {
+				text, ok := item.(string)
+				if !ok {
+					continue
+				}
+				resolved, err := h.resolve(field, text, roots, declared)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = resolved
+			}
+		}
+	}
+
+	return payload, nil
+}
+
+// resolve returns the path to send upstream, or an error if it is outside every root
+func (h *PathHook) resolve(field, value string, roots []string, declared bool) (string, error) {
+	if !declared {
+		return "", fmt.Errorf("path argument %s: the client has not declared the roots capability", field)
+	}
+	if len(roots) == 0 {
+		return "", fmt.Errorf("path argument %s: the client has not provided any roots", field)
+	}
+
+	p, isURI := localPath(value)
+	if !isURI {
+		p = value
+	}
+
+	if !filepath.IsAbs(p) {
+		if !h.config.Prefix || isURI {
+			return "", fmt.Errorf("path argument %s must be absolute", field)
+		}
+		p = filepath.Join(roots[0], p)
+	}
+	p = resolveSymlinks(p)
+
+	for _, root := range roots {
+		if withinRoot(p, root) {
+			// Plain paths are sent resolved so the backend sees what was checked
+			if isURI {
+				return value, nil
+			}
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("path argument %s is outside the client's roots", field)
+}
+
+// localPath returns the filesystem path of a file:// URI
+func localPath(uri string) (string, bool) {
+	if !strings.HasPrefix(uri, "file://") {
+		return "", false
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", false
+	}
+	return filepath.FromSlash(path.Clean(parsed.Path)), true
+}
+
+// resolveSymlinks cleans an absolute path and resolves the symlinks of its
+// longest existing prefix, so a link inside a root cannot lead out of it.
+// The components that do not exist yet are kept as given.
+func resolveSymlinks(p string) string {
+	p = filepath.Clean(p)
+	missing := ""
+	for dir := p; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, missing)
+		}
+		if filepath.Dir(dir) == dir {
+			return p
+		}
+		missing = filepath.Join(filepath.Base(dir), missing)
+	}
+}
+
+// withinRoot reports whether a cleaned path is the root or below it
+func withinRoot(p, root string) bool {
+	if p == root || root == string(filepath.Separator) {
+		return true
+	}
+	return strings.HasPrefix(p, root+string(filepath.Separator))
+}
@@ -0,0 +1,151 @@
+package transform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathHook(t *testing.T) {
+	pipeline := NewPipeline(nil)
+	pipeline.AddPathHooks([]config.PathArgumentConfig{
+		{Tool: "files_service_read", Fields: []string{"path", "options.includes"}},
+		{Tool: "files_service_write", Fields: []string{"path"}, Prefix: true},
+	})
+	ctx := WithRoots(context.Background(), []string{"file:///home/user/project", "file:///tmp/shared"})
+
+	t.Run("Inside_root", func(t *testing.T) {
+		out, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest,
+			`{"path":"/home/user/project/src/../main.go"}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"path":"/home/user/project/main.go"}`, out)
+	})
+
+	t.Run("Outside_roots", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest,
+			`{"path":"/home/user/project/../secrets"}`)
+		assert.ErrorContains(t, err, "outside the client's roots")
+	})
+
+	t.Run("Sibling_prefix_is_outside", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest,
+			`{"path":"/home/user/project-other/main.go"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("File_URI_argument", func(t *testing.T) {
+		out, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest,
+			`{"path":"file:///tmp/shared/a.txt"}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"path":"file:///tmp/shared/a.txt"}`, out)
+	})
+
+	t.Run("File_URI_outside_roots", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest,
+			`{"path":"file:///tmp/shared/../../etc/passwd"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("List_field", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest,
+			`{"options":{"includes":["/tmp/shared/a","/etc/passwd"]}}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Relative_without_prefix", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest, `{"path":"main.go"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Relative_with_prefix", func(t *testing.T) {
+		out, err := pipeline.ApplyContext(ctx, "files_service_write", StageRequest, `{"path":"out/report.txt"}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"path":"/home/user/project/out/report.txt"}`, out)
+	})
+
+	t.Run("Relative_escaping_root", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(ctx, "files_service_write", StageRequest, `{"path":"../../etc/passwd"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("No_roots_provided", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(WithRoots(context.Background(), []string{}),
+			"files_service_read", StageRequest, `{"path":"/home/user/project/main.go"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Client_without_roots_capability", func(t *testing.T) {
+		_, err := pipeline.ApplyContext(context.Background(), "files_service_read", StageRequest,
+			`{"path":"/etc/passwd"}`)
+		assert.ErrorContains(t, err, "has not declared the roots capability")
+
+		_, err = pipeline.Apply("files_service_read", StageRequest, `{"path":"/etc/passwd"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Arguments_without_paths", func(t *testing.T) {
+		out, err := pipeline.ApplyContext(context.Background(), "files_service_read", StageRequest, `{"limit":1}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"limit":1}`, out)
+	})
+}
+
+func TestPathHook_Symlinks(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0o755))
+	require.NoError(t, os.Mkdir(outside, 0o755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "docs"), filepath.Join(base, "alias")))
+
+	pipeline := NewPipeline(nil)
+	pipeline.AddPathHooks([]config.PathArgumentConfig{{Tool: "*", Fields: []string{"path"}}})
+	call := func(rootURI, path string) (string, error) {
+		ctx := WithRoots(context.Background(), []string{rootURI})
+		return pipeline.ApplyContext(ctx, "files_service_read", StageRequest, `{"path":"`+path+`"}`)
+	}
+
+	t.Run("Link_leading_out_of_root", func(t *testing.T) {
+		_, err := call("file://"+root, filepath.Join(root, "escape", "secret.txt"))
+		assert.ErrorContains(t, err, "outside the client's roots")
+	})
+
+	t.Run("Link_in_root_is_resolved", func(t *testing.T) {
+		out, err := call("file://"+filepath.Join(base, "alias"), filepath.Join(base, "alias", "new", "a.txt"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"path":"`+filepath.Join(root, "docs", "new", "a.txt")+`"}`, out)
+	})
+}
+
+func TestScriptHook_Roots(t *testing.T) {
+	source := `
+def on_request(tool, args):
+    args["roots"] = roots()
+    return args
+`
+	hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "roots.star"}, []byte(source))
+	require.NoError(t, err)
+
+	pipeline := NewPipeline(nil)
+	pipeline.Add(hook)
+
+	t.Run("Client_roots", func(t *testing.T) {
+		ctx := WithRoots(context.Background(), []string{"file:///srv/data"})
+		out, err := pipeline.ApplyContext(ctx, "files_service_read", StageRequest, `{}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"roots":["file:///srv/data"]}`, out)
+	})
+
+	t.Run("No_roots_capability", func(t *testing.T) {
+		out, err := pipeline.Apply("files_service_read", StageRequest, `{}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"roots":null}`, out)
+	})
+}
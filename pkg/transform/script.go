@@ -0,0 +1,245 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+)
+
+// Default sandbox limits for scripts
+const (
+	defaultScriptMaxSteps        = 1000000
+	defaultScriptTimeout         = 100 * time.Millisecond
+	defaultScriptMaxPayloadBytes = 1 << 20
+)
+
+// Script entry points, keyed by stage
+var scriptFunctions = map[Stage]string{
+	StageRequest:  "on_request",
+	StageResponse: "on_response",
+}
+
+// rootsLocal is the thread-local key holding the client's roots during a call
+const rootsLocal = "roots"
+
+// ScriptHook runs a sandboxed Starlark script against tool call payloads.
+//
+// A script may define on_request(tool, args) and/or on_response(tool, response).
+// Returning None keeps the payload, returning a dict replaces it, and calling
+// fail("reason") rejects the call. roots() returns the client's root URIs, or
+// None if the client declared no roots. Scripts have no file or network access and are
+// bounded by an execution step budget, a wall-clock timeout and a size limit on
+// the payload they receive and the dict they return.
+type ScriptHook struct {
+	tool            string
+	path            string
+	globals         starlark.StringDict
+	maxSteps        uint64
+	timeout         time.Duration
+	maxPayloadBytes int
+}
+
+// NewScriptHook loads and compiles the script described by configuration
+func NewScriptHook(config config.ScriptConfig) (*ScriptHook, error) {
+	source, err := os.ReadFile(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script %s: %w", config.Path, err)
+	}
+
+	return newScriptHookFromSource(config, source)
+}
+
+// newScriptHookFromSource compiles script source and executes its top level once
+func newScriptHookFromSource(config config.ScriptConfig, source []byte) (*ScriptHook, error) {
+	hook := &ScriptHook{
+		tool:            config.Tool,
+		path:            config.Path,
+		maxSteps:        config.MaxSteps,
+		timeout:         config.Timeout,
+		maxPayloadBytes: config.MaxPayloadBytes,
+	}
+	if hook.maxSteps == 0 {
+		hook.maxSteps = defaultScriptMaxSteps
+	}
+	if hook.timeout <= 0 {
+		hook.timeout = defaultScriptTimeout
+	}
+	if hook.maxPayloadBytes <= 0 {
+		hook.maxPayloadBytes = defaultScriptMaxPayloadBytes
+	}
+
+	predeclared := starlark.StringDict{
+		"json":  starlarkjson.Module,
+		"roots": starlark.NewBuiltin("roots", scriptRoots),
+	}
+
+	// Top-level execution is bounded the same way as invocations
+	thread := hook.newThread()
+	stop := time.AfterFunc(hook.timeout, func() { thread.Cancel("script load timed out") })
+	defer stop.Stop()
+
+	globals, err := starlark.ExecFile(thread, config.Path, source, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script %s: %w", config.Path, err)
+	}
+	hook.globals = globals
+
+	for _, name := range scriptFunctions {
+		if value, exists := globals[name]; exists {
+			if _, ok := value.(starlark.Callable); !ok {
+				return nil, fmt.Errorf("script %s: %s must be a function", config.Path, name)
+			}
+		}
+	}
+
+	return hook, nil
+}
+
+// Applies reports whether the script matches the tool and defines a function for the stage
+func (h *ScriptHook) Applies(toolName string, stage Stage) bool {
+	if h.tool != "*" && h.tool != toolName {
+		return false
+	}
+	_, exists := h.globals[scriptFunctions[stage]]
+	return exists
+}
+
+// Transform calls the script function for the stage with the tool name and payload
+func (h *ScriptHook) Transform(toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error) {
+	return h.TransformContext(context.Background(), toolName, stage, payload)
+}
+
+// TransformContext calls the script function with the client's roots available to roots()
+func (h *ScriptHook) TransformContext(ctx context.Context, toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error) {
+	fn, ok := h.globals[scriptFunctions[stage]].(starlark.Callable)
+	if !ok {
+		return payload, nil
+	}
+
+	thread := h.newThread()
+	if roots, ok := RootsFromContext(ctx); ok {
+		thread.SetLocal(rootsLocal, roots)
+	}
+	stop := time.AfterFunc(h.timeout, func() { thread.Cancel("script timed out") })
+	defer stop.Stop()
+
+	value, err := h.toStarlark(thread, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := starlark.Call(thread, fn, starlark.Tuple{starlark.String(toolName), value}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("script %s rejected the call: %w", h.path, err)
+	}
+
+	switch result.(type) {
+	case starlark.NoneType:
+		return payload, nil
+	case *starlark.Dict:
+		if size := scriptValueSize(result, h.maxPayloadBytes); size > h.maxPayloadBytes {
+			return nil, fmt.Errorf("script %s: %s returned more than %d bytes", h.path, fn.Name(), h.maxPayloadBytes)
+		}
+		return fromStarlark(thread, result)
+	default:
+		return nil, fmt.Errorf("script %s: %s must return a dict or None, got %s", h.path, fn.Name(), result.Type())
+	}
+}
+
+// newThread creates a sandboxed Starlark thread with the configured step budget
+func (h *ScriptHook) newThread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  h.path,
+		Print: func(*starlark.Thread, string) {}, // Discard script output
+	}
+	thread.SetMaxExecutionSteps(h.maxSteps)
+	return thread
+}
+
+// scriptRoots implements the roots() builtin
+func scriptRoots(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+
+	roots, ok := thread.Local(rootsLocal).([]string)
+	if !ok {
+		return starlark.None, nil
+	}
+
+	values := make([]starlark.Value, len(roots))
+	for i, root := range roots {
+		values[i] = starlark.String(root)
+	}
+	return starlark.NewList(values), nil
+}
+
+// toStarlark converts a decoded JSON payload into a Starlark value, refusing
+// payloads over the size limit
+func (h *ScriptHook) toStarlark(thread *starlark.Thread, payload map[string]interface{}) (starlark.Value, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload for script: %w", err)
+	}
+	if len(data) > h.maxPayloadBytes {
+		return nil, fmt.Errorf("script %s: payload of %d bytes exceeds %d bytes", h.path, len(data), h.maxPayloadBytes)
+	}
+
+	decode := starlarkjson.Module.Members["decode"]
+	return starlark.Call(thread, decode, starlark.Tuple{starlark.String(data)}, nil)
+}
+
+// scriptValueSize estimates the JSON size of a value returned by a script. It
+// stops counting once past limit, so an oversized result is refused before
+// it is encoded.
+func scriptValueSize(value starlark.Value, limit int) int {
+	switch v := value.(type) {
+	case starlark.String:
+		return len(v) + 2
+	case *starlark.Dict:
+		size := 2
+		for _, item := range v.Items() {
+			size += scriptValueSize(item[0], limit) + scriptValueSize(item[1], limit) + 2
+			if size > limit {
+				return size
+			}
+		}
+		return size
+	case starlark.Indexable:
+		size := 2
+		for i := 0; i < v.Len(); i++ {
+			size += scriptValueSize(v.Index(i), limit) + 1
+			if size > limit {
+				return size
+			}
+		}
+		return size
+	default:
+		return len(value.String())
+	}
+}
+
+// fromStarlark converts a Starlark dict back into a decoded JSON payload
+func fromStarlark(thread *starlark.Thread, value starlark.Value) (map[string]interface{}, error) {
+	encode := starlarkjson.Module.Members["encode"]
+	encoded, err := starlark.Call(thread, encode, starlark.Tuple{value}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode script result: %w", err)
+	}
+
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(encoded.(starlark.String))))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode script result: %w", err)
+	}
+
+	return payload, nil
+}
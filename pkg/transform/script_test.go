@@ -0,0 +1,117 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `
+def on_request(tool, args):
+    if args.get("limit", 0) > 100:
+        fail("limit must be at most 100")
+    if "limit" not in args:
+        args["limit"] = 10
+    return args
+
+def on_response(tool, response):
+    response["tool"] = tool
+    return response
+`
+
+func TestScriptHook(t *testing.T) {
+	hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "test.star"}, []byte(testScript))
+	require.NoError(t, err)
+
+	pipeline := NewPipeline(nil)
+	pipeline.Add(hook)
+
+	t.Run("Request_defaults", func(t *testing.T) {
+		out, err := pipeline.Apply("items_service_list", StageRequest, `{"query":"a"}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"query":"a","limit":10}`, out)
+	})
+
+	t.Run("Request_rejected", func(t *testing.T) {
+		_, err := pipeline.Apply("items_service_list", StageRequest, `{"limit":500}`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "limit must be at most 100")
+	})
+
+	t.Run("Request_numbers_keep_precision", func(t *testing.T) {
+		out, err := pipeline.Apply("items_service_list", StageRequest, `{"limit":5,"after":9007199254740993}`)
+		require.NoError(t, err)
+		assert.Contains(t, out, `"after":9007199254740993`)
+	})
+
+	t.Run("Response_postprocessed", func(t *testing.T) {
+		out, err := pipeline.Apply("items_service_list", StageResponse, `{"items":[]}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"items":[],"tool":"items_service_list"}`, out)
+	})
+}
+
+func TestScriptHook_Applies(t *testing.T) {
+	hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "items_service_list", Path: "test.star"},
+		[]byte("def on_request(tool, args):\n    return None\n"))
+	require.NoError(t, err)
+
+	assert.True(t, hook.Applies("items_service_list", StageRequest))
+	assert.False(t, hook.Applies("items_service_list", StageResponse))
+	assert.False(t, hook.Applies("other_service_call", StageRequest))
+}
+
+func TestScriptHook_Limits(t *testing.T) {
+	loop := "def on_request(tool, args):\n    for i in range(100000000):\n        pass\n    return args\n"
+
+	t.Run("Step_budget", func(t *testing.T) {
+		hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "loop.star", MaxSteps: 1000, Timeout: time.Minute}, []byte(loop))
+		require.NoError(t, err)
+
+		_, err = hook.Transform("any_tool", StageRequest, map[string]interface{}{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "loop.star", MaxSteps: 1 << 62, Timeout: 10 * time.Millisecond}, []byte(loop))
+		require.NoError(t, err)
+
+		_, err = hook.Transform("any_tool", StageRequest, map[string]interface{}{})
+		assert.Error(t, err)
+	})
+}
+
+func TestScriptHook_PayloadSize(t *testing.T) {
+	source := "def on_request(tool, args):\n    args[\"padding\"] = \"x\" * args.get(\"pad\", 0)\n    return args\n"
+	hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "pad.star", MaxPayloadBytes: 100}, []byte(source))
+	require.NoError(t, err)
+
+	out, err := hook.Transform("any_tool", StageRequest, map[string]interface{}{"pad": 10})
+	require.NoError(t, err)
+	assert.Len(t, out["padding"], 10)
+
+	// An oversized payload is not handed to the script
+	_, err = hook.Transform("any_tool", StageRequest, map[string]interface{}{"note": strings.Repeat("y", 200)})
+	assert.ErrorContains(t, err, "payload of 211 bytes exceeds 100 bytes")
+
+	// An oversized result is refused
+	_, err = hook.Transform("any_tool", StageRequest, map[string]interface{}{"pad": 1000})
+	assert.ErrorContains(t, err, "on_request returned more than 100 bytes")
+}
+
+func TestScriptHook_InvalidScripts(t *testing.T) {
+	_, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "bad.star"}, []byte("def on_request(:"))
+	assert.Error(t, err)
+
+	_, err = newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "bad.star"}, []byte("on_request = 1\n"))
+	assert.Error(t, err)
+
+	hook, err := newScriptHookFromSource(config.ScriptConfig{Tool: "*", Path: "bad.star"}, []byte("def on_request(tool, args):\n    return 1\n"))
+	require.NoError(t, err)
+	_, err = hook.Transform("any_tool", StageRequest, map[string]interface{}{})
+	assert.Error(t, err)
+}
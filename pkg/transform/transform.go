@@ -0,0 +1,213 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// Stage identifies which payload a hook transforms
+type Stage string
+
+const (
+	StageRequest  Stage = "request"
+	StageResponse Stage = "response"
+)
+
+// Hook transforms the decoded JSON payload of a tool call. Numbers are
+// decoded as json.Number, so they keep the precision the client sent.
+type Hook interface {
+	// Applies reports whether the hook should run for the given tool and stage
+	Applies(toolName string, stage Stage) bool
+
+	// Transform modifies the payload in place or returns a replacement
+	Transform(toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Pipeline applies registered hooks in order
+type Pipeline struct {
+	hooks []Hook
+}
+
+// NewPipeline creates a pipeline with the built-in hooks described by configuration
+func NewPipeline(configs []config.TransformConfig) *Pipeline {
+	p := &Pipeline{}
+	for _, c := range configs {
+		p.Add(NewFieldHook(c))
+	}
+	return p
+}
+
+// AddPathHooks registers built-in hooks that keep path arguments inside the client's roots
+func (p *Pipeline) AddPathHooks(configs []config.PathArgumentConfig) {
+	for _, c := range configs {
+		p.Add(NewPathHook(c))
+	}
+}
+
+// Add registers a hook at the end of the pipeline
+func (p *Pipeline) Add(hook Hook) {
+	p.hooks = append(p.hooks, hook)
+}
+
+// IsEmpty returns whether the pipeline has no hooks
+func (p *Pipeline) IsEmpty() bool {
+	return len(p.hooks) == 0
+}
+
+// Apply runs all hooks matching the tool and stage against a JSON object string
+func (p *Pipeline) Apply(toolName string, stage Stage, payloadJSON string) (string, error) {
+	return p.ApplyContext(context.Background(), toolName, stage, payloadJSON)
+}
+
+// ApplyContext is like Apply but passes the request context to hooks that need it
+func (p *Pipeline) ApplyContext(ctx context.Context, toolName string, stage Stage, payloadJSON string) (string, error) {
+	if p.IsEmpty() || payloadJSON == "" {
+		return payloadJSON, nil
+	}
+
+	var payload map[string]interface{}
+	applied := false
+	for _, hook := range p.hooks {
+		if !hook.Applies(toolName, stage) {
+			continue
+		}
+
+		// Decode lazily, only once a hook needs the payload
+		if payload == nil {
+			decoder := json.NewDecoder(strings.NewReader(payloadJSON))
+			decoder.UseNumber()
+			if err := decoder.Decode(&payload); err != nil {
+				return "", fmt.Errorf("failed to decode %s payload for transformation: %w", stage, err)
+			}
+			if payload == nil {
+				payload = make(map[string]interface{})
+			}
+		}
+
+		var transformed map[string]interface{}
+		var err error
+		if contextHook, ok := hook.(ContextHook); ok {
+			transformed, err = contextHook.TransformContext(ctx, toolName, stage, payload)
+		} else {
+			transformed, err = hook.Transform(toolName, stage, payload)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s transformation failed: %w", stage, err)
+		}
+		payload = transformed
+		applied = true
+	}
+
+	if !applied {
+		return payloadJSON, nil
+	}
+
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode transformed %s payload: %w", stage, err)
+	}
+
+	return string(out), nil
+}
+
+// FieldHook is the built-in config-driven hook for renaming, stripping and scaling fields.
+// Field paths are dot-separated (e.g. "user.address.city").
+type FieldHook struct {
+	config config.TransformConfig
+}
+
+// NewFieldHook creates a built-in field hook from configuration
+func NewFieldHook(config config.TransformConfig) *FieldHook {
+	return &FieldHook{
+		config: config,
+	}
+}
+
+// Applies reports whether the hook should run for the given tool and stage
+func (h *FieldHook) Applies(toolName string, stage Stage) bool {
+	if Stage(h.config.Stage) != stage {
+		return false
+	}
+	return h.config.Tool == "*" || h.config.Tool == toolName
+}
+
+// Transform renames, strips and scales fields in the payload
+func (h *FieldHook) Transform(toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error) {
+	for from, to := range h.config.Rename {
+		if value, exists := removePath(payload, from); exists {
+			setPath(payload, to, value)
+		}
+	}
+
+	for _, path := range h.config.Strip {
+		removePath(payload, path)
+	}
+
+	for path, factor := range h.config.Scale {
+		value, exists := getPath(payload, path)
+		if !exists {
+			continue
+		}
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("cannot scale non-numeric field %s", path)
+		}
+		scaled, err := number.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("cannot scale field %s: %w", path, err)
+		}
+		setPath(payload, path, scaled*factor)
+	}
+
+	return payload, nil
+}
+
+// getPath returns the value at a dot-separated path
+func getPath(payload map[string]interface{}, path string) (interface{}, bool) {
+	parent, key, ok := walkPath(payload, path, false)
+	if !ok {
+		return nil, false
+	}
+	value, exists := parent[key]
+	return value, exists
+}
+
+// setPath sets the value at a dot-separated path, creating intermediate objects
+func setPath(payload map[string]interface{}, path string, value interface{}) {
+	if parent, key, ok := walkPath(payload, path, true); ok {
+		parent[key] = value
+	}
+}
+
+// removePath deletes and returns the value at a dot-separated path
+func removePath(payload map[string]interface{}, path string) (interface{}, bool) {
+	parent, key, ok := walkPath(payload, path, false)
+	if !ok {
+		return nil, false
+	}
+	value, exists := parent[key]
+	delete(parent, key)
+	return value, exists
+}
+
+// walkPath resolves the parent object and final key of a dot-separated path
+func walkPath(payload map[string]interface{}, path string, create bool) (map[string]interface{}, string, bool) {
+	parts := strings.Split(path, ".")
+	current := payload
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			if !create {
+				return nil, "", false
+			}
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	return current, parts[len(parts)-1], true
+}
@@ -0,0 +1,92 @@
+package transform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_FieldHooks(t *testing.T) {
+	pipeline := NewPipeline([]config.TransformConfig{
+		{
+			Tool:   "weather_service_getforecast",
+			Stage:  "request",
+			Rename: map[string]string{"city": "location.city"},
+			Scale:  map[string]float64{"days": 24},
+		},
+		{
+			Tool:  "*",
+			Stage: "response",
+			Strip: []string{"internal", "meta.debug"},
+		},
+	})
+
+	t.Run("Request_rename_and_scale", func(t *testing.T) {
+		out, err := pipeline.Apply("weather_service_getforecast", StageRequest, `{"city":"Oslo","days":2}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"location":{"city":"Oslo"},"days":48}`, out)
+	})
+
+	t.Run("Request_numbers_keep_precision", func(t *testing.T) {
+		out, err := pipeline.Apply("weather_service_getforecast", StageRequest, `{"city":"Oslo","station":9007199254740993}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"location":{"city":"Oslo"},"station":9007199254740993}`, out)
+		assert.Contains(t, out, "9007199254740993")
+	})
+
+	t.Run("Request_other_tool_unchanged", func(t *testing.T) {
+		out, err := pipeline.Apply("other_service_call", StageRequest, `{"city":"Oslo"}`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"city":"Oslo"}`, out)
+	})
+
+	t.Run("Response_strip_for_all_tools", func(t *testing.T) {
+		out, err := pipeline.Apply("other_service_call", StageResponse, `{"value":1,"internal":true,"meta":{"debug":"x","id":7}}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"value":1,"meta":{"id":7}}`, out)
+	})
+
+	t.Run("Scale_non_numeric_fails", func(t *testing.T) {
+		_, err := pipeline.Apply("weather_service_getforecast", StageRequest, `{"days":"two"}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Empty_payload_unchanged", func(t *testing.T) {
+		out, err := pipeline.Apply("weather_service_getforecast", StageRequest, "")
+		require.NoError(t, err)
+		assert.Equal(t, "", out)
+	})
+}
+
+// rejectHook is a custom hook that rejects payloads containing a field
+type rejectHook struct {
+	field string
+}
+
+func (h rejectHook) Applies(toolName string, stage Stage) bool {
+	return stage == StageRequest
+}
+
+func (h rejectHook) Transform(toolName string, stage Stage, payload map[string]interface{}) (map[string]interface{}, error) {
+	if _, exists := payload[h.field]; exists {
+		return nil, fmt.Errorf("field %s is not allowed", h.field)
+	}
+	return payload, nil
+}
+
+func TestPipeline_CustomHook(t *testing.T) {
+	pipeline := NewPipeline(nil)
+	assert.True(t, pipeline.IsEmpty())
+
+	pipeline.Add(rejectHook{field: "admin"})
+
+	_, err := pipeline.Apply("any_tool", StageRequest, `{"admin":true}`)
+	assert.Error(t, err)
+
+	out, err := pipeline.Apply("any_tool", StageRequest, `{"name":"a"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"a"}`, out)
+}
@@ -30,6 +30,17 @@ type MethodInfo struct {
 	OutputDescriptor  protoreflect.MessageDescriptor // Protobuf descriptor for output message (used for schema generation)
 	IsClientStreaming bool                           // True if method accepts streaming input
 	IsServerStreaming bool                           // True if method returns streaming output
+	Deprecated        bool                           // True if the method or its service sets option deprecated = true
+
+	// Method option idempotency_level (IDEMPOTENCY_UNKNOWN when unset)
+	IdempotencyLevel descriptorpb.MethodOptions_IdempotencyLevel
+
+	// Where the method was discovered: MethodSourceReflection or MethodSourceDescriptorSet
+	Source string
+
+	// Package versioning (set when the service is served in several package versions)
+	Version   string       // Package version label (e.g. "v2")
+	Fallbacks []MethodInfo // Other versions tried in order when this one is unimplemented
 
 	// Optional fields (populated when using file descriptors)
 	Comments       []string               `json:"comments,omitempty"`        // Raw comments from proto file
@@ -42,6 +53,12 @@ type MethodInfo struct {
 	FileDescriptor       *descriptorpb.FileDescriptorProto `json:"file_descriptor,omitempty"`        // Source file descriptor (for advanced use cases)
 }
 
+// Method sources
+const (
+	MethodSourceReflection    = "reflection"
+	MethodSourceDescriptorSet = "descriptor_set"
+)
+
 // GenerateToolName creates a standardized tool name from the method's service and method names.
 // It converts service names to lowercase with dots replaced by underscores,
 // then appends the lowercase method name.
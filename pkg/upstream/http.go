@@ -0,0 +1,208 @@
+package upstream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+)
+
+// maxEventSize bounds a single server-sent event carrying a response
+const maxEventSize = 16 << 20
+
+// httpTransport talks to a server over the streamable HTTP transport
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	nextID  atomic.Int64
+
+	// listChanged is called when the server reports that its tools changed
+	listChanged func()
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// newHTTPTransport creates a transport for the server's URL
+func newHTTPTransport(upstreamConfig config.UpstreamConfig, listChanged func()) *httpTransport {
+	return &httpTransport{
+		url:         upstreamConfig.URL,
+		headers:     upstreamConfig.Headers,
+		client:      &http.Client{},
+		listChanged: listChanged,
+	}
+}
+
+// call posts a request and reads its response from a JSON body or an event stream
+func (t *httpTransport) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := t.nextID.Add(1)
+	resp, err := t.post(ctx, message{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Servers keep a session in the header of the initialize response
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var r *reply
+	if mediaType == "text/event-stream" {
+		r, err = readEventStream(resp.Body, id, t.listChanged)
+	} else {
+		r = &reply{}
+		err = json.NewDecoder(resp.Body).Decode(r)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	return r.decode(result)
+}
+
+// notify posts a notification, which the server acknowledges without a response
+func (t *httpTransport) notify(ctx context.Context, method string, params interface{}) error {
+	resp, err := t.post(ctx, message{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// post sends a message in the current session
+func (t *httpTransport) post(ctx context.Context, msg message) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", msg.Method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", msg.Method, err)
+	}
+	t.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", msg.Method, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		// The server forgot the session (e.g. it restarted)
+		if resp.StatusCode == http.StatusNotFound && req.Header.Get("Mcp-Session-Id") != "" {
+			return nil, fmt.Errorf("%s request: session expired: %w", msg.Method, errDisconnected)
+		}
+		return nil, fmt.Errorf("%s request: server returned status %d", msg.Method, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// setHeaders adds the configured headers and the session ID to a request
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+}
+
+// reset forgets the session ID
+func (t *httpTransport) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionID = ""
+}
+
+// close ends the session on the server
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.sessionID = ""
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Mcp-Session-Id", sessionID)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// readEventStream reads server-sent events until the response to the request,
+// skipping the notifications and requests the server sends before it except
+// for tool list changes, which are reported to listChanged
+func readEventStream(body io.Reader, id int64, listChanged func()) (*reply, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// A blank line ends the event
+		var r reply
+		err := json.Unmarshal([]byte(data.String()), &r)
+		data.Reset()
+		if err != nil {
+			continue
+		}
+		if replyID, ok := r.responseID(); ok && replyID == id {
+			return &r, nil
+		}
+		if r.Method == toolsListChanged && listChanged != nil {
+			listChanged()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("event stream ended without a response")
+}
@@ -0,0 +1,266 @@
+package upstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"go.uber.org/zap"
+)
+
+// stdioTransport talks to a server started as a child process, exchanging
+// newline-delimited JSON-RPC messages over its stdin and stdout
+type stdioTransport struct {
+	command string
+	args    []string
+	env     []string
+	logger  *zap.Logger
+	nextID  atomic.Int64
+
+	// listChanged is called when the server reports that its tools changed
+	listChanged func()
+
+	mu      sync.Mutex
+	process *stdioProcess
+}
+
+// stdioProcess is one run of the server process
+type stdioProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+	done    chan struct{} // closed when the process's output ends
+
+	mu      sync.Mutex
+	pending map[int64]chan *reply
+}
+
+// newStdioTransport creates a transport for the server's command
+func newStdioTransport(upstreamConfig config.UpstreamConfig, logger *zap.Logger, listChanged func()) *stdioTransport {
+	return &stdioTransport{
+		command:     upstreamConfig.Command,
+		args:        upstreamConfig.Args,
+		env:         upstreamConfig.Env,
+		logger:      logger.With(zap.String("upstream", upstreamConfig.Name)),
+		listChanged: listChanged,
+	}
+}
+
+// running returns the server process, starting it if it is not running.
+// A process that exited is reported as a disconnect, so its replacement is
+// only started together with a new handshake.
+func (t *stdioTransport) running() (*stdioProcess, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.process != nil {
+		select {
+		case <-t.process.done:
+			t.process = nil
+			return nil, fmt.Errorf("process exited: %w", errDisconnected)
+		default:
+			return t.process, nil
+		}
+	}
+
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = append(os.Environ(), t.env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stderr: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", t.command, err)
+	}
+
+	t.logger.Info("Started upstream MCP server process",
+		zap.String("command", t.command),
+		zap.Int("pid", cmd.Process.Pid))
+
+	p := &stdioProcess{
+		cmd:     cmd,
+		stdin:   stdin,
+		done:    make(chan struct{}),
+		pending: make(map[int64]chan *reply),
+	}
+	stderrDone := make(chan struct{})
+	go t.logStderr(stderr, stderrDone)
+	go t.readLoop(p, stdout, stderrDone)
+	t.process = p
+	return p, nil
+}
+
+// readLoop delivers responses to their callers until the process's output ends
+func (t *stdioTransport) readLoop(p *stdioProcess, stdout io.Reader, stderrDone <-chan struct{}) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		var r reply
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.logger.Debug("Ignoring non-JSON-RPC output", zap.String("line", scanner.Text()))
+			continue
+		}
+
+		if id, ok := r.responseID(); ok {
+			p.mu.Lock()
+			ch, found := p.pending[id]
+			delete(p.pending, id)
+			p.mu.Unlock()
+			if found {
+				ch <- &r
+			}
+			continue
+		}
+
+		// Answer the server's own requests (pings) so it does not wait on them
+		if r.Method != "" && len(r.ID) > 0 {
+			t.answer(p, &r)
+			continue
+		}
+		if r.Method == toolsListChanged && t.listChanged != nil {
+			t.listChanged()
+		}
+	}
+
+	<-stderrDone
+	err := p.cmd.Wait()
+	t.logger.Warn("Upstream MCP server process exited", zap.Error(err))
+
+	p.mu.Lock()
+	for id, ch := range p.pending {
+		close(ch)
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+	close(p.done)
+}
+
+// answer responds to a request from the server
+func (t *stdioTransport) answer(p *stdioProcess, r *reply) {
+	response := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      r.ID,
+	}
+	if r.Method == "ping" {
+		response["result"] = map[string]interface{}{}
+	} else {
+		response["error"] = mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, "Method not found: "+r.Method)
+	}
+	if err := p.write(response); err != nil {
+		t.logger.Debug("Failed to answer upstream request", zap.String("method", r.Method), zap.Error(err))
+	}
+}
+
+// logStderr logs the process's diagnostic output
+func (t *stdioTransport) logStderr(stderr io.Reader, done chan<- struct{}) {
+	defer close(done)
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		t.logger.Debug("Upstream MCP server output", zap.String("line", scanner.Text()))
+	}
+}
+
+// write sends one message as a line
+func (p *stdioProcess) write(msg interface{}) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", errDisconnected, err)
+	}
+	return nil
+}
+
+// call sends a request and waits for its response
+func (t *stdioTransport) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	p, err := t.running()
+	if err != nil {
+		return err
+	}
+
+	id := t.nextID.Add(1)
+	ch := make(chan *reply, 1)
+	p.mu.Lock()
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	if err := p.write(message{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("%s request: process exited: %w", method, errDisconnected)
+		}
+		return r.decode(result)
+	case <-ctx.Done():
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+
+		// Let the server stop working on the abandoned request
+		_ = p.write(message{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]interface{}{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		}})
+		return fmt.Errorf("%s request: %w", method, ctx.Err())
+	}
+}
+
+// notify sends a notification
+func (t *stdioTransport) notify(ctx context.Context, method string, params interface{}) error {
+	p, err := t.running()
+	if err != nil {
+		return err
+	}
+	return p.write(message{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// reset stops the process so the next call starts a fresh one
+func (t *stdioTransport) reset() {
+	_ = t.close()
+}
+
+// close closes the process's stdin, which ends a well-behaved server, and
+// kills it if it does not exit in time
+func (t *stdioTransport) close() error {
+	t.mu.Lock()
+	p := t.process
+	t.process = nil
+	t.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	_ = p.stdin.Close()
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(5 * time.Second):
+		return p.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,482 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/aalobaidi/ggRMCP/pkg/version"
+	"go.uber.org/zap"
+)
+
+// defaultTimeout bounds each request to a server without a configured timeout
+const defaultTimeout = 30 * time.Second
+
+// defaultToolsTTL is how long a server's tool list is cached without a configured TTL
+const defaultToolsTTL = 5 * time.Minute
+
+// protocolVersion is the MCP protocol version requested from servers
+const protocolVersion = "2024-11-05"
+
+// toolsListChanged is the notification a server sends when its tools change
+const toolsListChanged = "notifications/tools/list_changed"
+
+// invalidToolNameChars matches characters not allowed in gateway tool names
+var invalidToolNameChars = regexp.MustCompile(`[^A-Za-z0-9_.]`)
+
+// errDisconnected reports that the connection to a server was lost
+var errDisconnected = errors.New("upstream disconnected")
+
+// message is an outgoing JSON-RPC request or notification
+type message struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// reply is an incoming JSON-RPC message: a response, or a request or
+// notification from the server
+type reply struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *mcp.RPCError   `json:"error"`
+}
+
+// responseID returns the ID of a response to one of the gateway's requests
+func (r *reply) responseID() (int64, bool) {
+	if r.Method != "" || len(r.ID) == 0 {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(string(r.ID), 10, 64)
+	return id, err == nil
+}
+
+// decode returns the reply's error or decodes its result into result
+func (r *reply) decode(result interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// transport exchanges JSON-RPC messages with one server
+type transport interface {
+	// call sends a request and decodes its result into result
+	call(ctx context.Context, method string, params interface{}, result interface{}) error
+
+	// notify sends a notification
+	notify(ctx context.Context, method string, params interface{}) error
+
+	// reset drops the connection state so the next call starts a new session
+	reset()
+
+	close() error
+}
+
+// Status describes the connection to one server
+type Status struct {
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`
+	Connected bool   `json:"connected"`
+	ToolCount int    `json:"toolCount"`
+	LastError string `json:"lastError,omitempty"`
+	ErrorAt   string `json:"errorAt,omitempty"`
+}
+
+// server is a downstream MCP server whose tools are re-exported under a prefix
+type server struct {
+	name      string
+	prefix    string
+	timeout   time.Duration
+	toolsTTL  time.Duration
+	transport transport
+	logger    *zap.Logger
+
+	// handshake serializes handshakes, which run without holding mu so
+	// status reports never wait on the network
+	handshake sync.Mutex
+
+	mu          sync.Mutex
+	initialized bool
+	tools       map[string]string // exported name -> downstream name
+	listed      []mcp.Tool        // cached tool list, nil when stale
+	listedAt    time.Time
+	lastError   error
+	errorAt     time.Time
+}
+
+// Aggregator re-exports the tools of downstream MCP servers and proxies their calls
+type Aggregator struct {
+	servers []*server
+	logger  *zap.Logger
+}
+
+// NewAggregator creates an aggregator for the configured servers.
+// Servers are connected on first use, so an unavailable server does not delay startup.
+func NewAggregator(upstreams []config.UpstreamConfig, logger *zap.Logger) *Aggregator {
+	a := &Aggregator{logger: logger}
+	for _, upstreamConfig := range upstreams {
+		prefix := upstreamConfig.Prefix
+		if prefix == "" {
+			prefix = upstreamConfig.Name
+		}
+		timeout := upstreamConfig.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		toolsTTL := upstreamConfig.ToolsTTL
+		if toolsTTL <= 0 {
+			toolsTTL = defaultToolsTTL
+		}
+
+		s := &server{
+			name:     upstreamConfig.Name,
+			prefix:   prefix,
+			timeout:  timeout,
+			toolsTTL: toolsTTL,
+			logger:   logger.With(zap.String("upstream", upstreamConfig.Name)),
+			tools:    make(map[string]string),
+		}
+		if upstreamConfig.Command != "" {
+			s.transport = newStdioTransport(upstreamConfig, logger, s.invalidateTools)
+		} else {
+			s.transport = newHTTPTransport(upstreamConfig, s.invalidateTools)
+		}
+		a.servers = append(a.servers, s)
+	}
+	return a
+}
+
+// Owns reports whether the tool name carries the prefix of a server
+func (a *Aggregator) Owns(toolName string) bool {
+	return a != nil && a.serverFor(toolName) != nil
+}
+
+// Owner returns the name of the server whose prefix the tool name carries
+func (a *Aggregator) Owner(toolName string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	if s := a.serverFor(toolName); s != nil {
+		return s.name, true
+	}
+	return "", false
+}
+
+// serverFor returns the server whose prefix the tool name carries
+func (a *Aggregator) serverFor(toolName string) *server {
+	for _, s := range a.servers {
+		if strings.HasPrefix(toolName, s.prefix+"_") {
+			return s
+		}
+	}
+	return nil
+}
+
+// Tools lists the tools of every server under their prefixed names. Lists
+// are cached until their TTL passes or the server reports a change. A server
+// that cannot be reached is logged and left out.
+func (a *Aggregator) Tools(ctx context.Context) []mcp.Tool {
+	if a == nil {
+		return nil
+	}
+
+	var tools []mcp.Tool
+	for _, s := range a.servers {
+		listed, err := s.cachedTools(ctx)
+		if err != nil {
+			s.logger.Warn("Failed to list upstream tools", zap.Error(err))
+			continue
+		}
+		tools = append(tools, listed...)
+	}
+	return tools
+}
+
+// CallTool proxies a tool call to the server owning the tool
+func (a *Aggregator) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	s := a.serverFor(toolName)
+	if s == nil {
+		return nil, fmt.Errorf("tool not found: %s", toolName)
+	}
+	return s.callTool(ctx, toolName, arguments)
+}
+
+// Status describes the connection to every server
+func (a *Aggregator) Status() []Status {
+	if a == nil {
+		return nil
+	}
+
+	statuses := make([]Status, 0, len(a.servers))
+	for _, s := range a.servers {
+		s.mu.Lock()
+		status := Status{
+			Name:      s.name,
+			Prefix:    s.prefix,
+			Connected: s.initialized,
+			ToolCount: len(s.tools),
+		}
+		if s.lastError != nil {
+			status.LastError = s.lastError.Error()
+			status.ErrorAt = s.errorAt.UTC().Format(time.RFC3339)
+		}
+		s.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Close closes the connections to all servers
+func (a *Aggregator) Close() error {
+	if a == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, s := range a.servers {
+		if err := s.transport.close(); err != nil {
+			errs = append(errs, fmt.Errorf("upstream %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// initialize performs the MCP handshake once per session
+func (s *server) initialize(ctx context.Context) error {
+	s.handshake.Lock()
+	defer s.handshake.Unlock()
+	s.mu.Lock()
+	initialized := s.initialized
+	s.mu.Unlock()
+	if initialized {
+		return nil
+	}
+
+	params := map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo": mcp.ClientInfo{
+			Name:    "ggRMCP",
+			Version: version.Get().Version,
+		},
+	}
+	var result mcp.InitializationResult
+	if err := s.transport.call(ctx, "initialize", params, &result); err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	if err := s.transport.notify(ctx, "notifications/initialized", nil); err != nil {
+		return fmt.Errorf("initialized notification failed: %w", err)
+	}
+
+	s.logger.Info("Connected to upstream MCP server",
+		zap.String("serverName", result.ServerInfo.Name),
+		zap.String("protocolVersion", result.ProtocolVersion))
+	s.mu.Lock()
+	s.initialized = true
+	s.mu.Unlock()
+	return nil
+}
+
+// request sends a request in an initialized session, starting a new session
+// once if the connection was lost
+func (s *server) request(ctx context.Context, method string, params interface{}, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	err := s.exchange(ctx, method, params, result)
+	if errors.Is(err, errDisconnected) {
+		s.disconnect()
+		err = s.exchange(ctx, method, params, result)
+	}
+
+	var rpcErr *mcp.RPCError
+	if err != nil && !errors.As(err, &rpcErr) {
+		s.recordError(err)
+		if errors.Is(err, errDisconnected) {
+			s.disconnect()
+		}
+	}
+	return err
+}
+
+// exchange performs the handshake if needed, then sends the request
+func (s *server) exchange(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if err := s.initialize(ctx); err != nil {
+		return err
+	}
+	return s.transport.call(ctx, method, params, result)
+}
+
+// disconnect forgets the session so the next request performs the handshake again
+func (s *server) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initialized = false
+	s.transport.reset()
+}
+
+// recordError remembers the last connection error for the status report
+func (s *server) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err
+	s.errorAt = time.Now()
+}
+
+// listTools lists the server's tools, following pagination, under their prefixed names
+func (s *server) listTools(ctx context.Context) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []mcp.Tool `json:"tools"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		if err := s.request(ctx, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// Tools whose names only differ in replaced characters would be exported
+	// under the same name; neither is exported, since a call could reach the
+	// wrong one
+	exportedNames := make(map[string][]string, len(tools))
+	for _, tool := range tools {
+		exported := s.prefix + "_" + invalidToolNameChars.ReplaceAllString(tool.Name, "_")
+		exportedNames[exported] = append(exportedNames[exported], tool.Name)
+	}
+
+	names := make(map[string]string, len(tools))
+	exported := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		name := s.prefix + "_" + invalidToolNameChars.ReplaceAllString(tool.Name, "_")
+		if colliding := exportedNames[name]; len(colliding) > 1 {
+			if colliding[0] == tool.Name {
+				s.logger.Error("Upstream tools collide under one exported name and are not exported",
+					zap.String("toolName", name),
+					zap.Strings("upstreamTools", colliding))
+			}
+			continue
+		}
+		names[name] = tool.Name
+		tool.Name = name
+		if tool.Meta == nil {
+			tool.Meta = make(map[string]interface{})
+		}
+		tool.Meta[mcp.MetaKeyUpstream] = s.name
+		if tool.InputSchema == nil {
+			tool.InputSchema = map[string]interface{}{"type": "object"}
+		}
+		exported = append(exported, tool)
+	}
+	sort.Slice(exported, func(i, j int) bool { return exported[i].Name < exported[j].Name })
+
+	s.mu.Lock()
+	s.tools = names
+	s.listed = exported
+	s.listedAt = time.Now()
+	s.mu.Unlock()
+	return copyTools(exported), nil
+}
+
+// cachedTools returns the cached tool list while it is fresh, listing the
+// tools again otherwise
+func (s *server) cachedTools(ctx context.Context) ([]mcp.Tool, error) {
+	s.mu.Lock()
+	listed, listedAt := s.listed, s.listedAt
+	s.mu.Unlock()
+	if listed != nil && time.Since(listedAt) < s.toolsTTL {
+		return copyTools(listed), nil
+	}
+	return s.listTools(ctx)
+}
+
+// invalidateTools drops the cached tool list after the server reported a change
+func (s *server) invalidateTools() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listed = nil
+	s.logger.Debug("Upstream tools changed")
+}
+
+// copyTools copies a tool list with its _meta blocks, which later stages of
+// tools/list annotate
+func copyTools(tools []mcp.Tool) []mcp.Tool {
+	copied := make([]mcp.Tool, len(tools))
+	for i, tool := range tools {
+		meta := make(map[string]interface{}, len(tool.Meta))
+		for key, value := range tool.Meta {
+			meta[key] = value
+		}
+		tool.Meta = meta
+		copied[i] = tool
+	}
+	return copied
+}
+
+// downstreamName maps an exported tool name to the server's own name,
+// listing the tools first if they have not been listed yet
+func (s *server) downstreamName(ctx context.Context, toolName string) (string, error) {
+	s.mu.Lock()
+	name, ok := s.tools[toolName]
+	s.mu.Unlock()
+	if ok {
+		return name, nil
+	}
+
+	if _, err := s.listTools(ctx); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	name, ok = s.tools[toolName]
+	s.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("tool not found: %s", toolName)
+	}
+	return name, nil
+}
+
+// callTool proxies a tool call under the server's own tool name
+func (s *server) callTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.ToolCallResult, error) {
+	name, err := s.downstreamName(ctx, toolName)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{"name": name}
+	if arguments != nil {
+		params["arguments"] = arguments
+	}
+	var result mcp.ToolCallResult
+	if err := s.request(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,294 @@
+package upstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/aalobaidi/ggRMCP/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// helperEnv makes the test binary act as a stdio MCP server
+const helperEnv = "GGRMCP_UPSTREAM_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		serveStdio()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveStdio answers newline-delimited requests on stdin
+func serveStdio() {
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req mcp.JSONRPCRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID.Value == nil {
+			continue
+		}
+		_ = encoder.Encode(fakeResponse(&req))
+	}
+}
+
+// fakeResponse answers the requests of a server with a paginated tool list and an echo tool
+func fakeResponse(req *mcp.JSONRPCRequest) *mcp.JSONRPCResponse {
+	response := &mcp.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		response.Result = map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": "fake", "version": "0.1.0"},
+		}
+	case "tools/list":
+		var params struct {
+			Cursor string `json:"cursor"`
+		}
+		_ = req.DecodeParams(&params)
+		if params.Cursor == "" {
+			response.Result = map[string]interface{}{
+				"tools":      []mcp.Tool{{Name: "read-file", Description: "Reads a file", InputSchema: map[string]interface{}{"type": "object"}}},
+				"nextCursor": "2",
+			}
+		} else {
+			response.Result = map[string]interface{}{
+				"tools": []mcp.Tool{{Name: "echo", Description: "Echoes the text"}},
+			}
+		}
+	case "tools/call":
+		var params mcp.ToolsCallParams
+		if err := req.DecodeParams(&params); err != nil || (params.Name != "echo" && params.Name != "read-file") {
+			response.Error = mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Unknown tool")
+			break
+		}
+		response.Result = mcp.ToolCallResult{
+			Content: []mcp.ContentBlock{mcp.TextContent(fmt.Sprintf("%v: %v", params.Name, params.Arguments["text"]))},
+		}
+	default:
+		response.Error = mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, "Method not found")
+	}
+	return response
+}
+
+// newFakeHTTPServer serves the fake server over HTTP, answering tool calls as
+// event streams; expire makes it forget the session once
+func newFakeHTTPServer(t *testing.T, expire *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	var sessions atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		if r.Method == http.MethodDelete {
+			return
+		}
+
+		var req mcp.JSONRPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		if req.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", fmt.Sprintf("session-%d", sessions.Add(1)))
+		} else if r.Header.Get("Mcp-Session-Id") == "" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		} else if expire != nil && expire.CompareAndSwap(true, false) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+
+		if req.ID.Value == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		body, _ := json.Marshal(fakeResponse(&req))
+		if req.Method == "tools/call" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", body)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &sessions
+}
+
+func TestAggregator_HTTP(t *testing.T) {
+	var expire atomic.Bool
+	srv, sessions := newFakeHTTPServer(t, &expire)
+
+	aggregator := NewAggregator([]config.UpstreamConfig{{
+		Name:    "docs",
+		URL:     srv.URL,
+		Headers: map[string]string{"X-Api-Key": "secret"},
+	}}, zap.NewNop())
+	defer func() { _ = aggregator.Close() }()
+
+	assert.True(t, aggregator.Owns("docs_read_file"))
+	assert.False(t, aggregator.Owns("shop_orderservice_place"))
+
+	tools := aggregator.Tools(context.Background())
+	require.Len(t, tools, 2)
+	assert.Equal(t, "docs_echo", tools[0].Name)
+	assert.Equal(t, map[string]interface{}{"type": "object"}, tools[0].InputSchema)
+	assert.Equal(t, "docs_read_file", tools[1].Name)
+	assert.Equal(t, "docs", tools[1].Meta[mcp.MetaKeyUpstream])
+
+	result, err := aggregator.CallTool(context.Background(), "docs_read_file", map[string]interface{}{"text": "README.md"})
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "read-file: README.md", result.Content[0].Text)
+
+	t.Run("Session_expiry_reconnects", func(t *testing.T) {
+		expire.Store(true)
+		result, err := aggregator.CallTool(context.Background(), "docs_echo", map[string]interface{}{"text": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "echo: hi", result.Content[0].Text)
+		assert.Equal(t, int32(2), sessions.Load())
+	})
+
+	t.Run("Unknown_tool", func(t *testing.T) {
+		_, err := aggregator.CallTool(context.Background(), "docs_missing", nil)
+		assert.Error(t, err)
+	})
+
+	status := aggregator.Status()
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Connected)
+	assert.Equal(t, 2, status[0].ToolCount)
+}
+
+func TestAggregator_Stdio(t *testing.T) {
+	aggregator := NewAggregator([]config.UpstreamConfig{{
+		Name:    "files",
+		Prefix:  "fs",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^$"},
+		Env:     []string{helperEnv + "=1"},
+	}}, zap.NewNop())
+	defer func() { _ = aggregator.Close() }()
+
+	// Calls list the tools first when tools/list has not been called
+	result, err := aggregator.CallTool(context.Background(), "fs_echo", map[string]interface{}{"text": "hello"})
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "echo: hello", result.Content[0].Text)
+
+	tools := aggregator.Tools(context.Background())
+	require.Len(t, tools, 2)
+	assert.Equal(t, "fs_read_file", tools[1].Name)
+}
+
+func TestAggregator_UnreachableServer(t *testing.T) {
+	aggregator := NewAggregator([]config.UpstreamConfig{{
+		Name: "down",
+		URL:  "http://127.0.0.1:1/mcp",
+	}}, zap.NewNop())
+
+	assert.Empty(t, aggregator.Tools(context.Background()))
+
+	_, err := aggregator.CallTool(context.Background(), "down_tool", nil)
+	assert.Error(t, err)
+
+	status := aggregator.Status()
+	require.Len(t, status, 1)
+	assert.False(t, status[0].Connected)
+	assert.NotEmpty(t, status[0].LastError)
+}
+
+func TestAggregator_ToolsCache(t *testing.T) {
+	var lists atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req mcp.JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID.Value == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		response := mcp.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID}
+		switch req.Method {
+		case "initialize":
+			response.Result = map[string]interface{}{"protocolVersion": protocolVersion}
+		case "tools/list":
+			lists.Add(1)
+			// a-b and a_b would both be exported as kb_a_b
+			response.Result = mcp.ToolsListResult{Tools: []mcp.Tool{{Name: "a-b"}, {Name: "a_b"}, {Name: "search"}}}
+		case "tools/call":
+			// The server reports a change of its tools before the response
+			body, _ := json.Marshal(mcp.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: mcp.ToolCallResult{}})
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":%q}\n\n", toolsListChanged)
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", body)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer srv.Close()
+
+	aggregator := NewAggregator([]config.UpstreamConfig{{Name: "kb", URL: srv.URL}}, zap.NewNop())
+	defer func() { _ = aggregator.Close() }()
+
+	// Colliding names are not exported
+	tools := aggregator.Tools(context.Background())
+	require.Len(t, tools, 1)
+	assert.Equal(t, "kb_search", tools[0].Name)
+	_, err := aggregator.CallTool(context.Background(), "kb_a_b", nil)
+	assert.Error(t, err)
+
+	// Later lists come from the cache, and callers cannot change it
+	tools[0].Meta["cost"] = 1
+	tools = aggregator.Tools(context.Background())
+	assert.NotContains(t, tools[0].Meta, "cost")
+	listsBefore := lists.Load()
+	aggregator.Tools(context.Background())
+	assert.Equal(t, listsBefore, lists.Load())
+
+	// A list_changed notification refreshes the list
+	_, err = aggregator.CallTool(context.Background(), "kb_search", nil)
+	require.NoError(t, err)
+	aggregator.Tools(context.Background())
+	assert.Equal(t, listsBefore+1, lists.Load())
+}
+
+func TestAggregator_StatusDuringHandshake(t *testing.T) {
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	aggregator := NewAggregator([]config.UpstreamConfig{{Name: "slow", URL: srv.URL}}, zap.NewNop())
+	defer func() { _ = aggregator.Close() }()
+
+	go aggregator.Tools(context.Background())
+	<-arrived
+
+	// The status report does not wait for the hanging handshake
+	done := make(chan []Status, 1)
+	go func() { done <- aggregator.Status() }()
+	select {
+	case status := <-done:
+		require.Len(t, status, 1)
+		assert.False(t, status[0].Connected)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Status blocked on the handshake")
+	}
+}
@@ -0,0 +1,86 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build information, set at build time with
+//
+//	-ldflags "-X github.com/aalobaidi/ggRMCP/pkg/version.Version=v1.4.0
+//	          -X github.com/aalobaidi/ggRMCP/pkg/version.Commit=<sha>
+//	          -X github.com/aalobaidi/ggRMCP/pkg/version.Date=<RFC 3339 time>"
+//
+// Values left unset are taken from the build info the Go toolchain embeds
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// devVersion is reported by builds that carry no version
+const devVersion = "dev"
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+
+	// Whether the build had uncommitted changes (only known from Go's build info)
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		// go install module@version stamps the module version
+		if info.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = devVersion
+	}
+	return info
+}
+
+// String formats the build information on one line, as printed by --version
+func (i Info) String() string {
+	details := []string{}
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if i.Modified {
+			commit += "-dirty"
+		}
+		details = append(details, "commit "+commit)
+	}
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+	details = append(details, i.GoVersion)
+	return fmt.Sprintf("grmcp %s (%s)", i.Version, strings.Join(details, ", "))
+}
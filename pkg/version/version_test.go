@@ -0,0 +1,38 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+
+	t.Run("Unset", func(t *testing.T) {
+		Version, Commit, Date = "", "", ""
+		info := Get()
+		// Test binaries carry no module version
+		assert.Equal(t, devVersion, info.Version)
+		assert.Equal(t, runtime.Version(), info.GoVersion)
+	})
+
+	t.Run("Ldflags", func(t *testing.T) {
+		Version, Commit, Date = "v1.4.0", "0123456789abcdef0123", "2026-10-01T12:00:00Z"
+		info := Get()
+		assert.Equal(t, "v1.4.0", info.Version)
+		assert.Equal(t, "0123456789abcdef0123", info.Commit)
+		assert.Equal(t, "2026-10-01T12:00:00Z", info.Date)
+	})
+}
+
+func TestInfo_String(t *testing.T) {
+	info := Info{Version: "v1.4.0", Commit: "0123456789abcdef0123", Date: "2026-10-01T12:00:00Z", GoVersion: "go1.23.4"}
+	assert.Equal(t, "grmcp v1.4.0 (commit 0123456789ab, built 2026-10-01T12:00:00Z, go1.23.4)", info.String())
+
+	info.Modified = true
+	assert.Contains(t, info.String(), "commit 0123456789ab-dirty")
+
+	assert.Equal(t, "grmcp dev (go1.23.4)", Info{Version: "dev", GoVersion: "go1.23.4"}.String())
+}
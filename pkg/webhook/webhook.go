@@ -0,0 +1,390 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Defaults for settings left unset in an endpoint's configuration
+const (
+	defaultBatchSize        = 100
+	defaultFlushInterval    = 5 * time.Second
+	defaultQueueSize        = 10000
+	defaultMaxRetries       = 3
+	defaultRetryBackoff     = time.Second
+	defaultTimeout          = 10 * time.Second
+	defaultMaxArgumentBytes = 1024
+)
+
+// SignatureHeader carries the hex-encoded HMAC-SHA256 of the request body
+const SignatureHeader = "X-Ggrmcp-Signature"
+
+// Tool call outcomes
+const (
+	StatusSuccess  = "success"  // the backend answered
+	StatusError    = "error"    // the backend or downstream server failed the call
+	StatusRejected = "rejected" // the gateway refused the call (arguments, policy, quota)
+)
+
+// Event types
+const (
+	EventTypeToolCall          = "tool_call"          // sent after a tool call
+	EventTypeApprovalRequested = "approval_requested" // a call is waiting for approval
+	EventTypeApprovalDecided   = "approval_decided"   // a call was approved, rejected, expired or cancelled
+)
+
+// Event describes a completed tool call or an approval request
+type Event struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Tool      string    `json:"tool"`
+	Status    string    `json:"status"`
+	Code      string    `json:"code,omitempty"` // gRPC status or JSON-RPC error code
+	LatencyMs int64     `json:"latencyMs"`
+	SessionID string    `json:"sessionId"`
+	RequestID string    `json:"requestId,omitempty"`
+
+	// Agent product and origin of the session, when usage labels are enabled
+	ClientName string `json:"clientName,omitempty"`
+	Origin     string `json:"origin,omitempty"`
+
+	// JSON arguments, cut to the endpoint's limit
+	Arguments          string `json:"arguments,omitempty"`
+	ArgumentsTruncated bool   `json:"argumentsTruncated,omitempty"`
+
+	// Approval events: who decided and why
+	Approver string `json:"approver,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// batch is the body of a delivery
+type batch struct {
+	Events []Event `json:"events"`
+}
+
+// Stats counts the events of one endpoint
+type Stats struct {
+	Name      string `json:"name"`
+	Delivered int64  `json:"delivered"`
+	Failed    int64  `json:"failed"`
+	Dropped   int64  `json:"dropped"`
+	Retries   int64  `json:"retries"`
+	Queued    int    `json:"queued"`
+}
+
+// endpoint delivers events to one webhook in batches
+type endpoint struct {
+	config config.WebhookConfig
+	client *http.Client
+	logger *zap.Logger
+	queue  chan Event
+	done   chan struct{}
+
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+	retries   atomic.Int64
+}
+
+// Dispatcher sends tool call events to the configured webhooks
+type Dispatcher struct {
+	endpoints []*endpoint
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher and starts delivering to the configured webhooks
+func NewDispatcher(webhooks []config.WebhookConfig, logger *zap.Logger) *Dispatcher {
+	d := &Dispatcher{closing: make(chan struct{})}
+	for _, webhookConfig := range webhooks {
+		webhookConfig = withDefaults(webhookConfig)
+		e := &endpoint{
+			config: webhookConfig,
+			client: &http.Client{Timeout: webhookConfig.Timeout},
+			logger: logger.With(zap.String("webhook", webhookConfig.Name)),
+			queue:  make(chan Event, webhookConfig.QueueSize),
+			done:   d.closing,
+		}
+		d.endpoints = append(d.endpoints, e)
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			e.run()
+		}()
+	}
+	return d
+}
+
+// withDefaults fills in the settings left unset
+func withDefaults(webhookConfig config.WebhookConfig) config.WebhookConfig {
+	if webhookConfig.BatchSize == 0 {
+		webhookConfig.BatchSize = defaultBatchSize
+	}
+	if webhookConfig.FlushInterval == 0 {
+		webhookConfig.FlushInterval = defaultFlushInterval
+	}
+	if webhookConfig.QueueSize == 0 {
+		webhookConfig.QueueSize = defaultQueueSize
+	}
+	if webhookConfig.MaxRetries == 0 {
+		webhookConfig.MaxRetries = defaultMaxRetries
+	}
+	if webhookConfig.RetryBackoff == 0 {
+		webhookConfig.RetryBackoff = defaultRetryBackoff
+	}
+	if webhookConfig.Timeout == 0 {
+		webhookConfig.Timeout = defaultTimeout
+	}
+	if webhookConfig.MaxArgumentBytes == 0 {
+		webhookConfig.MaxArgumentBytes = defaultMaxArgumentBytes
+	}
+	return webhookConfig
+}
+
+// Publish queues an event for every webhook without blocking; events that do
+// not fit in a full queue are dropped
+func (d *Dispatcher) Publish(event Event) {
+	if d == nil {
+		return
+	}
+	select {
+	case <-d.closing:
+		return
+	default:
+	}
+
+	for _, e := range d.endpoints {
+		select {
+		case e.queue <- e.prepare(event):
+		default:
+			if e.dropped.Add(1) == 1 {
+				e.logger.Warn("Webhook queue full, dropping events")
+			}
+		}
+	}
+}
+
+// Stats returns the event counters of every webhook
+func (d *Dispatcher) Stats() []Stats {
+	if d == nil {
+		return nil
+	}
+
+	stats := make([]Stats, 0, len(d.endpoints))
+	for _, e := range d.endpoints {
+		stats = append(stats, Stats{
+			Name:      e.config.Name,
+			Delivered: e.delivered.Load(),
+			Failed:    e.failed.Load(),
+			Dropped:   e.dropped.Load(),
+			Retries:   e.retries.Load(),
+			Queued:    len(e.queue),
+		})
+	}
+	return stats
+}
+
+// Close sends the queued events and stops; deliveries failing from then on are
+// not retried
+func (d *Dispatcher) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.closeOnce.Do(func() { close(d.closing) })
+	d.wg.Wait()
+	return nil
+}
+
+// prepare cuts the event's arguments to the endpoint's limit
+func (e *endpoint) prepare(event Event) Event {
+	return event.WithArgumentLimit(e.config.MaxArgumentBytes)
+}
+
+// WithArgumentLimit returns the event with its arguments cut to limit bytes
+// without splitting a character; a negative limit omits them
+func (event Event) WithArgumentLimit(limit int) Event {
+	if limit < 0 {
+		event.Arguments = ""
+		return event
+	}
+	if len(event.Arguments) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(event.Arguments[cut]) {
+			cut--
+		}
+		event.Arguments = event.Arguments[:cut]
+		event.ArgumentsTruncated = true
+	}
+	return event
+}
+
+// run collects events into batches, sending a batch when it is full or when
+// its oldest event has waited for the flush interval
+func (e *endpoint) run() {
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	var events []Event
+	for {
+		select {
+		case event := <-e.queue:
+			events = append(events, event)
+			if len(events) >= e.config.BatchSize {
+				e.deliver(events)
+				events = nil
+			}
+		case <-ticker.C:
+			if len(events) > 0 {
+				e.deliver(events)
+				events = nil
+			}
+		case <-e.done:
+			// Drain what is queued, then stop
+			for {
+				select {
+				case event := <-e.queue:
+					events = append(events, event)
+					if len(events) >= e.config.BatchSize {
+						e.deliver(events)
+						events = nil
+					}
+				default:
+					if len(events) > 0 {
+						e.deliver(events)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a batch, retrying with exponential backoff while the
+// failure may be temporary
+func (e *endpoint) deliver(events []Event) {
+	body, err := json.Marshal(batch{Events: events})
+	if err != nil {
+		e.failed.Add(int64(len(events)))
+		e.logger.Error("Failed to encode webhook events", zap.Error(err))
+		return
+	}
+
+	backoff := e.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = e.send(body)
+		if err == nil {
+			e.delivered.Add(int64(len(events)))
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= e.config.MaxRetries || e.closing() {
+			break
+		}
+
+		e.logger.Debug("Retrying webhook delivery",
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		if !e.wait(backoff) {
+			break
+		}
+		e.retries.Add(1)
+		backoff *= 2
+	}
+
+	e.failed.Add(int64(len(events)))
+	e.logger.Warn("Failed to deliver webhook events",
+		zap.Int("events", len(events)),
+		zap.Error(err))
+}
+
+// wait waits before a retry, returning false if the dispatcher closes meanwhile
+func (e *endpoint) wait(backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-e.done:
+		return false
+	}
+}
+
+// closing reports whether the dispatcher is shutting down
+func (e *endpoint) closing() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// permanentError is a delivery failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+// send posts one batch
+func (e *endpoint) send(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	for name, value := range e.config.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(e.config.Secret, body))
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return &permanentError{err: fmt.Errorf("webhook returned status %d", resp.StatusCode)}
+	}
+}
+
+// Sign returns the signature of a delivery body, for receivers to verify
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
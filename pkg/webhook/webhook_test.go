@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aalobaidi/ggRMCP/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// receiver records the batches posted to it, answering with the given statuses first
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	batches  [][]Event
+	requests atomic.Int32
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := int(r.requests.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		if req.Header.Get("X-Signed") == "yes" {
+			assert.Equal(t, Sign("secret", body), req.Header.Get(SignatureHeader))
+		}
+
+		var delivery batch
+		require.NoError(t, json.Unmarshal(body, &delivery))
+		r.mu.Lock()
+		r.batches = append(r.batches, delivery.Events)
+		r.mu.Unlock()
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() [][]Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]Event(nil), r.batches...)
+}
+
+func event(tool string) Event {
+	return Event{Type: EventTypeToolCall, ID: tool, Tool: tool, Status: StatusSuccess, Arguments: `{"id":1}`}
+}
+
+func TestDispatcher_Batches(t *testing.T) {
+	r := newReceiver(t)
+	dispatcher := NewDispatcher([]config.WebhookConfig{{
+		Name:          "siem",
+		URL:           r.URL,
+		Secret:        "secret",
+		Headers:       map[string]string{"X-Signed": "yes"},
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}}, zap.NewNop())
+
+	dispatcher.Publish(event("a"))
+	dispatcher.Publish(event("b"))
+	dispatcher.Publish(event("c"))
+
+	// A full batch is sent right away
+	require.Eventually(t, func() bool { return len(r.received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// The rest is sent when closing
+	require.NoError(t, dispatcher.Close())
+	batches := r.received()
+	require.Len(t, batches, 2)
+	assert.Equal(t, []string{"a", "b"}, []string{batches[0][0].Tool, batches[0][1].Tool})
+	assert.Equal(t, "c", batches[1][0].Tool)
+	assert.Equal(t, `{"id":1}`, batches[1][0].Arguments)
+
+	assert.Equal(t, []Stats{{Name: "siem", Delivered: 3}}, dispatcher.Stats())
+
+	// Events published after closing are ignored
+	dispatcher.Publish(event("d"))
+	assert.Equal(t, 0, dispatcher.Stats()[0].Queued)
+}
+
+func TestDispatcher_FlushInterval(t *testing.T) {
+	r := newReceiver(t)
+	dispatcher := NewDispatcher([]config.WebhookConfig{{
+		Name:          "analytics",
+		URL:           r.URL,
+		FlushInterval: 20 * time.Millisecond,
+	}}, zap.NewNop())
+	defer func() { _ = dispatcher.Close() }()
+
+	dispatcher.Publish(event("a"))
+	require.Eventually(t, func() bool { return len(r.received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	t.Run("Temporary_failures", func(t *testing.T) {
+		r := newReceiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+		dispatcher := NewDispatcher([]config.WebhookConfig{{
+			Name:          "siem",
+			URL:           r.URL,
+			BatchSize:     1,
+			RetryBackoff:  time.Millisecond,
+			FlushInterval: time.Hour,
+		}}, zap.NewNop())
+		defer func() { _ = dispatcher.Close() }()
+
+		dispatcher.Publish(event("a"))
+		require.Eventually(t, func() bool { return len(r.received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(2), dispatcher.Stats()[0].Retries)
+	})
+
+	t.Run("Permanent_failure", func(t *testing.T) {
+		r := newReceiver(t, http.StatusBadRequest)
+		dispatcher := NewDispatcher([]config.WebhookConfig{{
+			Name:          "siem",
+			URL:           r.URL,
+			BatchSize:     1,
+			RetryBackoff:  time.Millisecond,
+			FlushInterval: time.Hour,
+		}}, zap.NewNop())
+
+		dispatcher.Publish(event("a"))
+		require.Eventually(t, func() bool { return dispatcher.Stats()[0].Failed == 1 }, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, dispatcher.Close())
+		assert.Equal(t, int32(1), r.requests.Load())
+	})
+
+	t.Run("Gives_up_after_max_retries", func(t *testing.T) {
+		r := newReceiver(t, 500, 500, 500)
+		dispatcher := NewDispatcher([]config.WebhookConfig{{
+			Name:          "siem",
+			URL:           r.URL,
+			BatchSize:     1,
+			MaxRetries:    2,
+			RetryBackoff:  time.Millisecond,
+			FlushInterval: time.Hour,
+		}}, zap.NewNop())
+
+		dispatcher.Publish(event("a"))
+		require.Eventually(t, func() bool { return dispatcher.Stats()[0].Failed == 1 }, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, dispatcher.Close())
+		assert.Equal(t, int32(3), r.requests.Load())
+		assert.Empty(t, r.received())
+	})
+}
+
+func TestEndpoint_Prepare(t *testing.T) {
+	e := &endpoint{config: withDefaults(config.WebhookConfig{MaxArgumentBytes: 5})}
+	prepared := e.prepare(Event{Arguments: `{"a":"é"}`})
+	assert.Equal(t, `{"a":`, prepared.Arguments)
+	assert.True(t, prepared.ArgumentsTruncated)
+
+	// Cuts never split a character
+	e.config.MaxArgumentBytes = 7
+	prepared = e.prepare(Event{Arguments: `{"a":"é"}`})
+	assert.Equal(t, `{"a":"`, prepared.Arguments)
+
+	e.config.MaxArgumentBytes = -1
+	assert.Empty(t, e.prepare(Event{Arguments: `{}`}).Arguments)
+
+	e.config.MaxArgumentBytes = 100
+	prepared = e.prepare(Event{Arguments: `{}`})
+	assert.Equal(t, `{}`, prepared.Arguments)
+	assert.False(t, prepared.ArgumentsTruncated)
+}
+
+func TestDispatcher_DropsWhenQueueFull(t *testing.T) {
+	blocked := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-blocked
+	}))
+	defer srv.Close()
+
+	dispatcher := NewDispatcher([]config.WebhookConfig{{
+		Name:          "slow",
+		URL:           srv.URL,
+		BatchSize:     1,
+		QueueSize:     1,
+		FlushInterval: time.Hour,
+	}}, zap.NewNop())
+	defer func() { _ = dispatcher.Close() }()
+	defer close(blocked)
+
+	// The first event is being delivered, the second waits in the queue
+	dispatcher.Publish(event("a"))
+	require.Eventually(t, func() bool { return dispatcher.Stats()[0].Queued == 0 }, 5*time.Second, 10*time.Millisecond)
+	dispatcher.Publish(event("b"))
+	dispatcher.Publish(event("c"))
+
+	assert.Equal(t, int64(1), dispatcher.Stats()[0].Dropped)
+}
+
+func TestDispatcher_Nil(t *testing.T) {
+	var dispatcher *Dispatcher
+	dispatcher.Publish(event("a"))
+	assert.Nil(t, dispatcher.Stats())
+	assert.NoError(t, dispatcher.Close())
+}
@@ -35,7 +35,7 @@ const bufSize = 1024 * 1024
 type testHandler struct {
 	logger         *zap.Logger
 	reflection     grpc.ReflectionClient
-	sessionManager *session.Manager
+	sessionManager session.Manager
 	toolBuilder    *tools.MCPToolBuilder
 	headerConfig   config.HeaderForwardingConfig
 }